@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/fips140"
+	"fmt"
+
+	"key-aws-exporter/internal/config"
+)
+
+// boringCrypto reports whether BoringCrypto is in use; it is only set in binaries
+// built with GOEXPERIMENT=boringcrypto
+var boringCrypto func() bool
+
+// fipsCrypto reports whether the process runs FIPS 140 validated cryptography, either
+// the Go Cryptographic Module (GOFIPS140 builds or GODEBUG=fips140=on) or BoringCrypto.
+// Both restrict TLS to FIPS-approved versions, cipher suites and curves.
+func fipsCrypto() bool {
+	return fips140.Enabled() || (boringCrypto != nil && boringCrypto())
+}
+
+// checkFIPSMode refuses to start in FIPS_MODE without FIPS cryptography, so a
+// misbuilt image fails loudly instead of silently using unvalidated modules
+func checkFIPSMode(cfg *config.Config) error {
+	if cfg.FIPSMode && !fipsCrypto() {
+		return fmt.Errorf("FIPS_MODE requires a FIPS build: build with GOFIPS140=v1.0.0 (make build-fips) or run with GODEBUG=fips140=on")
+	}
+	return nil
+}
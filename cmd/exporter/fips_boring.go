@@ -0,0 +1,13 @@
+//go:build boringcrypto
+
+package main
+
+import (
+	"crypto/boring"
+	// Restrict every TLS configuration to FIPS-approved settings
+	_ "crypto/tls/fipsonly"
+)
+
+func init() {
+	boringCrypto = boring.Enabled
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"key-aws-exporter/internal/config"
+)
+
+func TestCheckFIPSMode(t *testing.T) {
+	if err := checkFIPSMode(&config.Config{}); err != nil {
+		t.Fatalf("expected startup without FIPS_MODE to pass, got %v", err)
+	}
+	if fipsCrypto() {
+		t.Skip("the test binary runs FIPS cryptography")
+	}
+	if err := checkFIPSMode(&config.Config{FIPSMode: true}); err == nil {
+		t.Fatalf("expected FIPS_MODE to be refused without FIPS cryptography")
+	}
+}
@@ -3,15 +3,30 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"key-aws-exporter/internal/cloudwatch"
 	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/discovery"
 	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/internal/fakes3"
 	"key-aws-exporter/internal/handlers"
+	"key-aws-exporter/internal/historydb"
+	"key-aws-exporter/internal/notify"
+	"key-aws-exporter/internal/remotewrite"
+	"key-aws-exporter/internal/reports"
+	"key-aws-exporter/internal/rotation"
+	"key-aws-exporter/internal/schedule"
+	"key-aws-exporter/internal/signing"
+	"key-aws-exporter/internal/snapshot"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
@@ -23,17 +38,35 @@ type serverRunner interface {
 }
 
 type validationRunner interface {
-	ValidateAll(ctx context.Context) *exporter.ValidationResults
+	ValidateCycle(ctx context.Context) *exporter.ValidationResults
+}
+
+type deepValidationRunner interface {
+	ValidateDeep(ctx context.Context) *exporter.ValidationResults
+}
+
+type permissionAsserter interface {
+	AssertPermissions(ctx context.Context) *exporter.ValidationResults
+}
+
+type canaryCleaner interface {
+	CleanCanaries(ctx context.Context) map[string]int
 }
 
 const (
 	httpReadTimeout       = 15 * time.Second
 	httpReadHeaderTimeout = 10 * time.Second
-	httpWriteTimeout      = 20 * time.Second
 	httpIdleTimeout       = 60 * time.Second
+
+	// historyPruneInterval is how often daily rollups are updated and results past
+	// HISTORY_DB_RETENTION are deleted
+	historyPruneInterval = time.Hour
 )
 
 func main() {
+	fakeS3 := flag.Bool("fake-s3", false, "validate every endpoint against an in-process fake S3 (or FAKE_S3_TARGET) instead of the configured one")
+	flag.Parse()
+
 	log := logrus.New()
 	log.SetLevel(logrus.InfoLevel)
 	log.SetFormatter(&logrus.JSONFormatter{})
@@ -43,21 +76,102 @@ func main() {
 	if err != nil {
 		log.WithError(err).Fatal("Failed to load configuration")
 	}
+	if err := checkFIPSMode(cfg); err != nil {
+		log.WithError(err).Fatal("Failed to enter FIPS mode")
+	}
+	if fipsCrypto() {
+		log.Info("FIPS 140 cryptography enabled")
+	}
+	loadRotatedCredentials(cfg, log)
+
+	if err := metrics.ConfigureHistograms(metrics.HistogramOptions{
+		ResponseTimeBuckets:  cfg.ResponseTimeBuckets,
+		LegacyResponseTimeMs: cfg.ResponseTimeMsCompat,
+		Native:               cfg.NativeHistograms,
+	}); err != nil {
+		log.WithError(err).Fatal("Failed to configure metrics")
+	}
 
-	server, manager := createServer(cfg, log)
+	var managerOpts []exporter.ManagerOption
+	if len(cfg.EndpointRules) > 0 {
+		// Configured endpoints are resolved on load; this covers discovered ones
+		managerOpts = append(managerOpts, exporter.WithEndpointRewrite(cfg.EndpointRules.Apply))
+	}
+	if *fakeS3 || cfg.FakeS3.Enabled {
+		fake, err := startFakeS3(cfg.FakeS3, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to start the fake S3")
+		}
+		defer fake.Close()
+		managerOpts = append(managerOpts, exporter.WithEndpointRewrite(fake.Rewrite))
+	}
+	historyDB := openHistoryDB(cfg.HistoryDB, log)
+	if historyDB != nil {
+		defer historyDB.Close()
+		managerOpts = append(managerOpts, exporter.WithHistoryStore(historyDB))
+	}
+
+	signer := loadSigner(cfg, log)
+	server, manager := createServer(cfg, log, signer, managerOpts...)
+	setupNotifications(cfg, manager, signer, log)
+	setupStatsD(cfg, manager, log)
+	setupResultsLog(cfg, manager, log)
+	setupCloudWatch(cfg, manager, log)
+	setupRotation(cfg, manager, log)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	startAutoValidation(ctx, manager, log, cfg.AutoValidateInterval)
+	startAutoValidation(ctx, manager, cfg.AutoValidateInterval)
+	startDeepValidation(ctx, manager, cfg.DeepValidateInterval)
+	startPermissionChecks(ctx, manager, cfg.PermissionCheckInterval)
+	startCanaryCleanup(ctx, manager, cfg.CanaryCleanupInterval)
+	startDiscovery(ctx, cfg, manager, log)
+	startOrganizationSweep(ctx, cfg, manager, log)
+	startReports(ctx, cfg.Reports, manager, log)
+	startRemoteWrite(ctx, cfg.RemoteWrite, log)
+	startHistoryPruning(ctx, historyDB, log)
+	startSnapshotExport(ctx, cfg.Snapshot, manager, log)
 
 	if err := runServer(ctx, server, server.Addr, log); err != nil {
 		log.WithError(err).Fatal("Server error")
 	}
 }
 
-func createServer(cfg *config.Config, log *logrus.Logger) (*http.Server, *exporter.ValidatorManager) {
-	manager := exporter.NewValidatorManager(cfg, log)
+// loadSigner loads the key validation results are signed with, or returns nil when
+// result signing is disabled
+func loadSigner(cfg *config.Config, log *logrus.Logger) *signing.Signer {
+	if cfg.SigningKeyFile == "" {
+		return nil
+	}
+	signer, err := signing.LoadSigner(cfg.SigningKeyFile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load the result signing key")
+	}
+	log.WithField("key_id", signer.KeyID()).Info("Result signing enabled")
+	return signer
+}
+
+// openHistoryDB opens the SQLite history database, or returns nil when it is disabled
+func openHistoryDB(cfg *config.HistoryDBConfig, log *logrus.Logger) *historydb.Store {
+	if cfg == nil {
+		return nil
+	}
+	store, err := historydb.Open(*cfg, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open the history database")
+	}
+	log.WithFields(logrus.Fields{
+		"path":             cfg.Path,
+		"retention":        cfg.Retention,
+		"rollup_retention": cfg.RollupRetention,
+	}).Info("Persistent history enabled")
+	return store
+}
+
+// createServer builds the manager and the HTTP server; signer may be nil
+func createServer(cfg *config.Config, log *logrus.Logger, signer *signing.Signer, opts ...exporter.ManagerOption) (*http.Server, *exporter.ValidatorManager) {
+	manager := exporter.NewValidatorManager(cfg, log, opts...)
 
 	log.WithFields(logrus.Fields{
 		"port":            cfg.Port,
@@ -68,25 +182,79 @@ func createServer(cfg *config.Config, log *logrus.Logger) (*http.Server, *export
 		log.WithField("endpoint", endpoint).Debug("Configured S3 endpoint")
 	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/health", handlers.NewHealthCheckHandler(manager))
-	mux.HandleFunc("/validate", handlers.NewValidateAllHandler(manager, log))
-	mux.HandleFunc("/validate/", handlers.NewValidateEndpointHandler(manager, log))
+	routerOpts := handlers.RouterOptions(cfg, metrics.Default)
+	if signer != nil {
+		routerOpts = append(routerOpts, handlers.WithSigner(signer))
+	}
+	mux := handlers.NewRouter(manager, log, routerOpts...)
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /admin/config", handlers.NewAdminConfigHandler(lintConfig(cfg, log), log))
+	if cfg.SlackSigningSecret != "" {
+		mux.HandleFunc("POST /slack/command", handlers.NewSlackCommandHandler(manager, cfg.SlackSigningSecret, log))
+		log.Info("Slack slash commands enabled on /slack/command")
+	}
+	if len(cfg.CredentialsAPITokens) > 0 {
+		mux.HandleFunc("POST /validate/credentials", handlers.NewValidateCredentialsHandler(manager, handlers.CredentialsAPIOptions{
+			Tokens:           cfg.CredentialsAPITokens,
+			AllowedEndpoints: cfg.CredentialsAPIAllowedEndpoints,
+		}, log))
+		log.Info("Credentials validation API enabled on /validate/credentials")
+	}
+	if cfg.FailureInjection {
+		injectFailure := handlers.NewInjectFailureHandler(manager, log)
+		mux.HandleFunc("POST /admin/inject-failure/{endpoint}", injectFailure)
+		mux.HandleFunc("DELETE /admin/inject-failure/{endpoint}", injectFailure)
+		log.Warn("Failure injection is enabled; POST /admin/inject-failure/{endpoint} makes endpoints report synthetic failures")
+	}
+
+	handler := handlers.WithMiddleware(mux, cfg, metrics.Default, log)
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
 	server := &http.Server{
 		Addr:              addr,
-		Handler:           mux,
+		Handler:           handler,
 		ReadTimeout:       httpReadTimeout,
 		ReadHeaderTimeout: httpReadHeaderTimeout,
-		WriteTimeout:      httpWriteTimeout,
+		WriteTimeout:      config.HTTPWriteTimeout,
 		IdleTimeout:       httpIdleTimeout,
 	}
 
 	return server, manager
 }
 
+// startFakeS3 serves the fake S3 every endpoint is validated against in fake S3 mode
+func startFakeS3(cfg config.FakeS3Config, log *logrus.Logger) (*fakes3.Server, error) {
+	fake, err := fakes3.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	url, err := fake.Listen(cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(logrus.Fields{
+		"url":      url,
+		"target":   cfg.Target,
+		"failures": len(cfg.Failures),
+	}).Warn("Fake S3 mode: endpoints are validated against a fake S3, not the configured services")
+	return fake, nil
+}
+
+// lintConfig logs risky settings and publishes them as s3_config_warning
+func lintConfig(cfg *config.Config, log *logrus.Logger) []config.Warning {
+	warnings := config.Lint(cfg)
+	counts := make(map[string]int)
+	for _, warning := range warnings {
+		counts[warning.Reason]++
+		log.WithFields(logrus.Fields{
+			"reason":   warning.Reason,
+			"endpoint": warning.Endpoint,
+		}).Warn(warning.Message)
+	}
+	metrics.SetConfigWarnings(counts)
+	return warnings
+}
+
 func runServer(ctx context.Context, server serverRunner, addr string, log *logrus.Logger) error {
 	errCh := make(chan error, 1)
 
@@ -118,37 +286,228 @@ func runServer(ctx context.Context, server serverRunner, addr string, log *logru
 	}
 }
 
-func startAutoValidation(ctx context.Context, manager validationRunner, log *logrus.Logger, interval time.Duration) {
-	if interval <= 0 {
+// startAutoValidation periodically validates every endpoint; the manager fans
+// results out to its sinks
+func startAutoValidation(ctx context.Context, manager validationRunner, interval time.Duration) {
+	schedule.Every(ctx, clock.Real, interval, func() {
+		// A run may not outlast its interval: stragglers are reported unfinished and
+		// the endpoints that completed are published without waiting for them
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		manager.ValidateCycle(runCtx)
+	})
+}
+
+// startDeepValidation periodically runs the multi-operation probe for endpoints
+// configured with probe_depth "deep"
+func startDeepValidation(ctx context.Context, manager deepValidationRunner, interval time.Duration) {
+	schedule.Every(ctx, clock.Real, interval, func() {
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		manager.ValidateDeep(runCtx)
+	})
+}
+
+// startPermissionChecks periodically compares the permissions of endpoints configured
+// with expected_permissions against their discovered permissions
+func startPermissionChecks(ctx context.Context, manager permissionAsserter, interval time.Duration) {
+	schedule.Every(ctx, clock.Real, interval, func() {
+		manager.AssertPermissions(ctx)
+	})
+}
+
+// startCanaryCleanup periodically deletes canary objects that probes left behind
+func startCanaryCleanup(ctx context.Context, manager canaryCleaner, interval time.Duration) {
+	schedule.Every(ctx, clock.Real, interval, func() {
+		manager.CleanCanaries(ctx)
+	})
+}
+
+// setupNotifications registers the notification dispatcher as a result sink
+func setupNotifications(cfg *config.Config, manager *exporter.ValidatorManager, signer *signing.Signer, log *logrus.Logger) {
+	if cfg.Notifications == nil || len(cfg.Notifications.Channels) == 0 {
 		return
 	}
 
-	go func() {
-		runValidation := func() {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-
-			results := manager.ValidateAll(ctx)
-			for endpoint, result := range results.Results {
-				exporter.RecordResult(log, endpoint, result)
-			}
+	opts := []notify.DispatcherOption{notify.WithEndpointResolver(manager)}
+	if signer != nil {
+		opts = append(opts, notify.WithSigner(signer))
+	}
+	dispatcher, err := notify.NewDispatcher(cfg.Notifications, cfg.Endpoints, log, opts...)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up notifications")
+	}
+	manager.AddSink(dispatcher)
+	log.WithField("channels", len(cfg.Notifications.Channels)).Info("Notifications enabled")
+}
+
+// setupStatsD registers the DogStatsD sink when an agent address is configured
+func setupStatsD(cfg *config.Config, manager *exporter.ValidatorManager, log *logrus.Logger) {
+	if cfg.StatsD == nil {
+		return
+	}
+
+	sink, err := exporter.NewStatsDSink(*cfg.StatsD, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up StatsD")
+	}
+	manager.AddSink(sink)
+	log.WithField("address", cfg.StatsD.Address).Info("StatsD metrics enabled")
+}
+
+// setupResultsLog registers the JSON Lines results log when RESULTS_LOG_FILE is set
+func setupResultsLog(cfg *config.Config, manager *exporter.ValidatorManager, log *logrus.Logger) {
+	if cfg.ResultsLog == nil {
+		return
+	}
+
+	sink, err := exporter.NewResultsLogSink(*cfg.ResultsLog, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up the results log")
+	}
+	manager.AddSink(sink)
+	log.WithField("path", cfg.ResultsLog.Path).Info("Results log enabled")
+}
+
+// setupCloudWatch registers the CloudWatch publisher when CLOUDWATCH_JSON is set
+func setupCloudWatch(cfg *config.Config, manager *exporter.ValidatorManager, log *logrus.Logger) {
+	if cfg.CloudWatch == nil {
+		return
+	}
+
+	manager.AddSink(cloudwatch.NewPublisher(*cfg.CloudWatch, manager, log))
+	log.WithFields(logrus.Fields{
+		"namespace": cfg.CloudWatch.Namespace,
+		"role_name": cfg.CloudWatch.RoleName,
+	}).Info("CloudWatch metrics enabled")
+}
+
+// loadRotatedCredentials reads the keys of endpoints opted into rotation from their
+// secret stores, where rotation writes each new key
+func loadRotatedCredentials(cfg *config.Config, log *logrus.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := rotation.LoadCredentials(ctx, cfg.Endpoints); err != nil {
+		log.WithError(err).Fatal("Failed to load rotated credentials")
+	}
+}
+
+// setupRotation registers the key rotation controller for endpoints that opted in
+func setupRotation(cfg *config.Config, manager *exporter.ValidatorManager, log *logrus.Logger) {
+	controller, err := rotation.NewController(cfg.Endpoints, manager, cfg.ReadOnly, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up key rotation")
+	}
+	if controller.Endpoints() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := controller.Resume(ctx); err != nil {
+		log.WithError(err).Warn("Failed to check for access keys left active by an earlier rotation")
+	}
+	manager.AddSink(controller)
+	log.WithField("endpoints", controller.Endpoints()).Info("Automatic key rotation enabled")
+}
+
+// startDiscovery periodically syncs endpoints with the tagged buckets of the account
+func startDiscovery(ctx context.Context, cfg *config.Config, manager discovery.EndpointManager, log *logrus.Logger) {
+	if cfg.Discovery == nil {
+		return
+	}
+
+	controller := discovery.NewController(cfg.Discovery, cfg.Endpoints, manager, log)
+	log.WithField("tags", cfg.Discovery.Tags).Info("Bucket discovery enabled")
+	schedule.Every(ctx, clock.Real, time.Duration(cfg.Discovery.Interval), func() {
+		if err := controller.Sync(ctx); err != nil {
+			log.WithError(err).Warn("Bucket discovery failed, keeping the discovered endpoints")
+		}
+	})
+}
+
+// startOrganizationSweep periodically syncs endpoints with the accounts of the organization
+func startOrganizationSweep(ctx context.Context, cfg *config.Config, manager discovery.EndpointManager, log *logrus.Logger) {
+	if cfg.Organizations == nil {
+		return
+	}
+
+	controller := discovery.NewOrganizationController(cfg.Organizations, cfg.Endpoints, manager, log)
+	log.WithField("role_name", cfg.Organizations.RoleName).Info("Organization sweep enabled")
+	schedule.Every(ctx, clock.Real, time.Duration(cfg.Organizations.Interval), func() {
+		if err := controller.Sync(ctx); err != nil {
+			log.WithError(err).Warn("Listing organization accounts failed, keeping the swept endpoints")
 		}
+	})
+}
 
-		runValidation()
+// startReports launches the configured scheduled reports
+func startReports(ctx context.Context, cfg *config.ReportsConfig, source reports.HistorySource, log *logrus.Logger) {
+	if cfg == nil || cfg.Email == nil {
+		return
+	}
 
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+	digest, err := reports.NewEmailDigest(cfg.Email, source, log)
+	if err != nil {
+		log.WithError(err).Error("Email digest disabled")
+		return
+	}
+	log.WithField("schedule", cfg.Email.Schedule).Info("Email digest enabled")
+	go digest.Run(ctx)
+}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				runValidation()
-			}
+// startRemoteWrite pushes the exporter's series to the configured remote write receiver
+func startRemoteWrite(ctx context.Context, cfg *config.RemoteWriteConfig, log *logrus.Logger) {
+	if cfg == nil {
+		return
+	}
+
+	client := remotewrite.NewClient(*cfg, log)
+	log.WithFields(logrus.Fields{
+		"url":      cfg.URL,
+		"interval": time.Duration(cfg.Interval),
+	}).Info("Remote write enabled")
+	go client.Run(ctx)
+}
+
+// startSnapshotExport periodically writes metrics or results to the snapshot bucket
+func startSnapshotExport(ctx context.Context, cfg *config.SnapshotConfig, manager *exporter.ValidatorManager, log *logrus.Logger) {
+	if cfg == nil {
+		return
+	}
+
+	objects, err := s3.NewObjectWriter(ctx, s3.ObjectTarget{
+		Endpoint:     cfg.Endpoint,
+		Region:       cfg.Region,
+		AccessKey:    cfg.AccessKey,
+		SecretKey:    cfg.SecretKey,
+		SessionToken: cfg.SessionToken,
+		UsePathStyle: cfg.UsePathStyle,
+		Bucket:       cfg.Bucket,
+	})
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up the snapshot export")
+	}
+	log.WithFields(logrus.Fields{
+		"bucket":   cfg.Bucket,
+		"key":      cfg.Key,
+		"format":   cfg.Format,
+		"interval": time.Duration(cfg.Interval),
+	}).Info("Snapshot export enabled")
+	go snapshot.NewWriter(*cfg, objects, manager, log).Run(ctx)
+}
+
+// startHistoryPruning updates the daily rollups and deletes results and rollups past
+// their retention every hour
+func startHistoryPruning(ctx context.Context, store *historydb.Store, log *logrus.Logger) {
+	if store == nil {
+		return
+	}
+	schedule.Every(ctx, clock.Real, historyPruneInterval, func() {
+		removed, err := store.Prune(ctx)
+		if err != nil {
+			log.WithError(err).Warn("Rolling up or pruning the history database failed")
+			return
 		}
-	}()
+		log.WithField("removed", removed).Debug("Pruned the history database")
+	})
 }
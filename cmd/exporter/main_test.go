@@ -5,14 +5,17 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"key-aws-exporter/internal/config"
 	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/metrics"
 	"key-aws-exporter/pkg/s3"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,7 +28,7 @@ func TestCreateServerRegistersHandlers(t *testing.T) {
 		},
 	}
 
-	server, manager := createServer(cfg, logrus.New())
+	server, manager := createServer(cfg, logrus.New(), nil)
 
 	if manager.GetEndpointCount() != 1 {
 		t.Fatalf("expected 1 endpoint, got %d", manager.GetEndpointCount())
@@ -38,6 +41,73 @@ func TestCreateServerRegistersHandlers(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected health endpoint to return 200, got %d", rr.Code)
 	}
+
+	rr = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected metrics endpoint to return 200, got %d", rr.Code)
+	}
+}
+
+func TestCreateServerLintsConfig(t *testing.T) {
+	cfg := &config.Config{
+		Port:              9090,
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "bucket", Bucket: "bucket", AccessKey: "ak", SecretKey: "sk", InsecureSkipVerify: true}},
+	}
+	server, _ := createServer(cfg, logrus.New(), nil)
+
+	if got := testutil.ToFloat64(metrics.ConfigWarning.WithLabelValues(config.WarningInsecurePublicHost)); got != 1 {
+		t.Fatalf("expected the warning to be exported, got %v", got)
+	}
+	rr := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), config.WarningInsecurePublicHost) {
+		t.Fatalf("expected /admin/config to list the warning, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateServerRegistersFailureInjection(t *testing.T) {
+	cfg := &config.Config{
+		Port:              9090,
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "bucket", Bucket: "bucket", AccessKey: "ak", SecretKey: "sk"}},
+	}
+	target := "/admin/inject-failure/bucket?error_type=access_denied"
+
+	server, _ := createServer(cfg, logrus.New(), nil)
+	rr := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, target, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected failure injection to be off by default, got %d", rr.Code)
+	}
+
+	cfg.FailureInjection = true
+	server, manager := createServer(cfg, logrus.New(), nil)
+	rr = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, target, nil))
+	defer manager.ClearInjectedFailure("bucket")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the injection route when enabled, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateServerAppliesCORS(t *testing.T) {
+	cfg := &config.Config{
+		Port:               9090,
+		ValidationTimeout:  time.Second,
+		CORSAllowedOrigins: []string{"https://dash.example.com"},
+		Endpoints:          []config.S3EndpointConfig{{Name: "bucket", Bucket: "bucket", AccessKey: "ak", SecretKey: "sk"}},
+	}
+	server, _ := createServer(cfg, logrus.New(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	rr := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Fatalf("expected CORS headers for the configured origin, got %v", rr.Header())
+	}
 }
 
 type stubHTTPServer struct {
@@ -115,7 +185,7 @@ type stubAutoValidator struct {
 	results *exporter.ValidationResults
 }
 
-func (s *stubAutoValidator) ValidateAll(ctx context.Context) *exporter.ValidationResults {
+func (s *stubAutoValidator) ValidateCycle(ctx context.Context) *exporter.ValidationResults {
 	s.mu.Lock()
 	s.calls++
 	s.mu.Unlock()
@@ -135,7 +205,7 @@ func TestStartAutoValidationRunsPeriodically(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	startAutoValidation(ctx, stub, logrus.New(), 20*time.Millisecond)
+	startAutoValidation(ctx, stub, 20*time.Millisecond)
 
 	deadline := time.After(200 * time.Millisecond)
 	for stub.callCount() < 2 {
@@ -151,6 +221,34 @@ func TestStartAutoValidationRunsPeriodically(t *testing.T) {
 	cancel()
 }
 
+type deadlineRecorder struct {
+	deadlines chan time.Duration
+}
+
+func (d *deadlineRecorder) ValidateCycle(ctx context.Context) *exporter.ValidationResults {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	select {
+	case d.deadlines <- time.Until(deadline):
+	default:
+	}
+	return &exporter.ValidationResults{}
+}
+
+func TestStartAutoValidationBoundsEachRunByInterval(t *testing.T) {
+	stub := &deadlineRecorder{deadlines: make(chan time.Duration, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startAutoValidation(ctx, stub, time.Hour)
+
+	if remaining := <-stub.deadlines; remaining <= 0 || remaining > time.Hour {
+		t.Fatalf("expected the run to be bounded by the interval, got %s left", remaining)
+	}
+}
+
 func TestStartAutoValidationDisabled(t *testing.T) {
 	stub := &stubAutoValidator{
 		results: &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{}},
@@ -158,8 +256,8 @@ func TestStartAutoValidationDisabled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	startAutoValidation(ctx, stub, logrus.New(), 0)
-	startAutoValidation(ctx, stub, logrus.New(), -1)
+	startAutoValidation(ctx, stub, 0)
+	startAutoValidation(ctx, stub, -1)
 
 	time.Sleep(30 * time.Millisecond)
 
@@ -167,3 +265,61 @@ func TestStartAutoValidationDisabled(t *testing.T) {
 		t.Fatalf("expected no auto validations when disabled, got %d", stub.callCount())
 	}
 }
+
+type stubDeepValidator struct {
+	stubAutoValidator
+}
+
+func (s *stubDeepValidator) ValidateDeep(ctx context.Context) *exporter.ValidationResults {
+	return s.ValidateCycle(ctx)
+}
+
+func TestStartDeepValidationRunsPeriodically(t *testing.T) {
+	stub := &stubDeepValidator{stubAutoValidator{
+		results: &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"bucket": {CheckedAt: time.Now(), Depth: s3.ProbeDepthDeep},
+		}},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startDeepValidation(ctx, stub, 20*time.Millisecond)
+
+	deadline := time.After(200 * time.Millisecond)
+	for stub.callCount() < 2 {
+		select {
+		case <-deadline:
+			cancel()
+			t.Fatalf("expected at least 2 deep validations, got %d", stub.callCount())
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+type stubPermissionAsserter struct {
+	stubAutoValidator
+}
+
+func (s *stubPermissionAsserter) AssertPermissions(ctx context.Context) *exporter.ValidationResults {
+	return s.ValidateCycle(ctx)
+}
+
+func TestStartPermissionChecksRunsPeriodically(t *testing.T) {
+	stub := &stubPermissionAsserter{stubAutoValidator{results: &exporter.ValidationResults{}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startPermissionChecks(ctx, stub, 20*time.Millisecond)
+
+	deadline := time.After(200 * time.Millisecond)
+	for stub.callCount() < 2 {
+		select {
+		case <-deadline:
+			cancel()
+			t.Fatalf("expected at least 2 permission checks, got %d", stub.callCount())
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
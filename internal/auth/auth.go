@@ -0,0 +1,71 @@
+// Package auth defines the callers of the HTTP API and the permissions they hold, so
+// authenticators such as OIDC can be plugged in front of the routes.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Permission is a class of API routes a caller may use
+type Permission string
+
+const (
+	// PermissionRead covers /metrics and the routes serving results without validating
+	PermissionRead Permission = "read"
+	// PermissionValidate covers the routes that run validations or probes
+	PermissionValidate Permission = "validate"
+	// PermissionAdmin covers /admin and implies every other permission
+	PermissionAdmin Permission = "admin"
+)
+
+// Permissions lists every permission a role can be granted
+var Permissions = []Permission{PermissionRead, PermissionValidate, PermissionAdmin}
+
+// Errors returned by authenticators for callers that must authenticate again; any other
+// error means the caller could not be checked
+var (
+	ErrNoCredentials      = errors.New("no bearer token")
+	ErrInvalidCredentials = errors.New("invalid token")
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject     string
+	Roles       []string
+	Permissions map[Permission]bool
+}
+
+// Allows reports whether the principal holds perm; admin holds every permission
+func (p *Principal) Allows(perm Permission) bool {
+	return p.Permissions[perm] || p.Permissions[PermissionAdmin]
+}
+
+// Authenticator identifies the caller of a request
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal the request was authenticated as, if any
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"path"
+	"slices"
+)
+
+// AllRoutes in Role.Routes allows every route
+const AllRoutes = "*"
+
+// Request is the call an authorizer decides on
+type Request struct {
+	// Route is the pattern the request matched, as registered, e.g. "POST /validate/{endpoint}"
+	Route string
+	// Endpoint is the endpoint the route targets, from {endpoint} or ?endpoint=; empty
+	// for routes serving every endpoint
+	Endpoint   string
+	Permission Permission
+}
+
+// Authorizer decides whether an authenticated principal may make a request
+type Authorizer interface {
+	Authorize(p *Principal, req Request) bool
+}
+
+// PermissionAuthorizer allows requests whose permission the principal holds
+type PermissionAuthorizer struct{}
+
+// Authorize reports whether p holds the permission of req
+func (PermissionAuthorizer) Authorize(p *Principal, req Request) bool {
+	return p.Allows(req.Permission)
+}
+
+// Role is what an RBAC role may call
+type Role struct {
+	// Routes are the route patterns the role may call, e.g. "POST /validate/{endpoint}";
+	// "*" allows every route
+	Routes []string `json:"routes"`
+	// Endpoints are globs limiting the endpoints the role may target, e.g. "team-a-*";
+	// empty allows every endpoint
+	Endpoints []string `json:"endpoints"`
+}
+
+// allows reports whether the role may make req. A role limited to some endpoints may
+// only call routes serving every endpoint when they are listed by name, so "*" cannot
+// leak other teams' results through them.
+func (r Role) allows(req Request) bool {
+	listed := slices.Contains(r.Routes, req.Route)
+	if !listed && !slices.Contains(r.Routes, AllRoutes) {
+		return false
+	}
+	if len(r.Endpoints) == 0 {
+		return true
+	}
+	if req.Endpoint == "" {
+		return listed
+	}
+	for _, glob := range r.Endpoints {
+		if ok, _ := path.Match(glob, req.Endpoint); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RBAC allows a request when one of the principal's roles allows its route and endpoint.
+// It replaces permissions: roles it does not define grant nothing.
+type RBAC struct {
+	roles map[string]Role
+}
+
+// NewRBAC creates an authorizer for roles
+func NewRBAC(roles map[string]Role) *RBAC {
+	return &RBAC{roles: roles}
+}
+
+// Authorize reports whether any of p's roles allows req
+func (a *RBAC) Authorize(p *Principal, req Request) bool {
+	for _, name := range p.Roles {
+		if role, ok := a.roles[name]; ok && role.allows(req) {
+			return true
+		}
+	}
+	return false
+}
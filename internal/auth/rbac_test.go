@@ -0,0 +1,34 @@
+package auth
+
+import "testing"
+
+func TestRBAC(t *testing.T) {
+	rbac := NewRBAC(map[string]Role{
+		"team-a":   {Routes: []string{AllRoutes, "GET /report"}, Endpoints: []string{"team-a-*", "shared"}},
+		"platform": {Routes: []string{AllRoutes}},
+		"reader":   {Routes: []string{"GET /validate"}},
+	})
+
+	tests := []struct {
+		roles []string
+		req   Request
+		want  bool
+	}{
+		{[]string{"team-a"}, Request{Route: "POST /validate/{endpoint}", Endpoint: "team-a-logs"}, true},
+		{[]string{"team-a"}, Request{Route: "POST /validate/{endpoint}", Endpoint: "shared"}, true},
+		{[]string{"team-a"}, Request{Route: "POST /validate/{endpoint}", Endpoint: "team-b-logs"}, false},
+		// Routes serving every endpoint need to be listed by name for scoped roles
+		{[]string{"team-a"}, Request{Route: "GET /validate"}, false},
+		{[]string{"team-a"}, Request{Route: "GET /report"}, true},
+		{[]string{"team-a", "platform"}, Request{Route: "GET /admin/config"}, true},
+		{[]string{"reader"}, Request{Route: "GET /validate"}, true},
+		{[]string{"reader"}, Request{Route: "POST /validate"}, false},
+		{[]string{"unknown"}, Request{Route: "GET /metrics", Permission: PermissionRead}, false},
+		{nil, Request{Route: "GET /metrics"}, false},
+	}
+	for _, tt := range tests {
+		if got := rbac.Authorize(&Principal{Roles: tt.roles}, tt.req); got != tt.want {
+			t.Errorf("roles %v, %+v: expected %v, got %v", tt.roles, tt.req, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package cloudwatch
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// maxDatumsPerCall is the number of metric datums PutMetricData accepts in one call
+const maxDatumsPerCall = 1000
+
+// Datum is one value of a metric
+type Datum struct {
+	MetricName string
+	Dimensions map[string]string
+	Value      float64
+	Unit       string
+	Timestamp  time.Time
+}
+
+// putMetricDataAPI is the part of the CloudWatch client the publisher uses
+type putMetricDataAPI interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// putMetricData sends datums in as many PutMetricData calls as maxDatumsPerCall requires
+func putMetricData(ctx context.Context, client putMetricDataAPI, namespace string, datums []Datum) error {
+	for len(datums) > 0 {
+		n := min(len(datums), maxDatumsPerCall)
+		_, err := client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(namespace),
+			MetricData: metricData(datums[:n]),
+		})
+		if err != nil {
+			return err
+		}
+		datums = datums[n:]
+	}
+	return nil
+}
+
+// metricData converts datums to the SDK's type, with dimensions sorted by name
+func metricData(datums []Datum) []types.MetricDatum {
+	data := make([]types.MetricDatum, len(datums))
+	for i, datum := range datums {
+		data[i] = types.MetricDatum{
+			MetricName: aws.String(datum.MetricName),
+			Value:      aws.Float64(datum.Value),
+			Unit:       types.StandardUnit(datum.Unit),
+		}
+		if !datum.Timestamp.IsZero() {
+			data[i].Timestamp = aws.Time(datum.Timestamp)
+		}
+
+		names := make([]string, 0, len(datum.Dimensions))
+		for name := range datum.Dimensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			data[i].Dimensions = append(data[i].Dimensions, types.Dimension{
+				Name:  aws.String(name),
+				Value: aws.String(datum.Dimensions[name]),
+			})
+		}
+	}
+	return data
+}
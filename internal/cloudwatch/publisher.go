@@ -0,0 +1,175 @@
+// Package cloudwatch publishes key validity and latency to Amazon CloudWatch, so
+// AWS-native alarms can be built where Prometheus is not available.
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/sirupsen/logrus"
+)
+
+// publishTimeout bounds the PutMetricData calls of one batch for one account
+const publishTimeout = 30 * time.Second
+
+// Metric names published for every endpoint, with an Endpoint dimension
+const (
+	MetricKeysValid         = "KeysValid"
+	MetricValidationLatency = "ValidationLatency"
+)
+
+// AccountResolver returns the AWS account of an endpoint, or "" when unknown
+type AccountResolver interface {
+	AccountID(endpointName string) string
+}
+
+// Publisher is a result sink sending each batch to CloudWatch. Like the metrics sink,
+// endpoints of an unreachable provider report latency but no KeysValid datum, so an
+// alarm on KeysValid sees missing data rather than a key failure.
+type Publisher struct {
+	cfg      config.CloudWatchConfig
+	accounts AccountResolver
+	base     aws.Credentials
+	client   *http.Client
+	log      *logrus.Logger
+
+	mu      sync.Mutex
+	clients map[string]putMetricDataAPI // account ID to its client; "" publishes with the keys
+	wg      sync.WaitGroup
+}
+
+// NewPublisher creates a publisher; accounts is consulted only when cfg.RoleName is set
+func NewPublisher(cfg config.CloudWatchConfig, accounts AccountResolver, log *logrus.Logger) *Publisher {
+	return &Publisher{
+		cfg:      cfg,
+		accounts: accounts,
+		base: aws.Credentials{
+			AccessKeyID:     cfg.AccessKey,
+			SecretAccessKey: cfg.SecretKey,
+			SessionToken:    cfg.SessionToken,
+		},
+		client:  &http.Client{Timeout: publishTimeout},
+		log:     log,
+		clients: make(map[string]putMetricDataAPI),
+	}
+}
+
+// Consume groups a shallow batch by account and publishes each group in the background
+func (p *Publisher) Consume(results *exporter.ValidationResults) {
+	if results.Deep() {
+		return
+	}
+	rolledUp := results.RolledUpEndpoints(results.UnreachableProviders())
+
+	byAccount := make(map[string][]Datum)
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		account := ""
+		if p.cfg.RoleName != "" {
+			account = p.accounts.AccountID(name)
+		}
+		byAccount[account] = append(byAccount[account], p.datums(name, result, rolledUp[name])...)
+	}
+
+	for account, datums := range byAccount {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			defer cancel()
+			if err := p.publish(ctx, account, datums); err != nil {
+				metrics.RecordCloudWatchFailure(account)
+				p.log.WithField("account", account).WithError(err).Warn("Failed to publish metrics to CloudWatch")
+			}
+		}()
+	}
+}
+
+// Wait blocks until every pending publish has finished
+func (p *Publisher) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Publisher) datums(endpointName string, result *s3.ValidationResult, rolledUp bool) []Datum {
+	dimensions := maps.Clone(p.cfg.Dimensions)
+	if dimensions == nil {
+		dimensions = make(map[string]string, 1)
+	}
+	dimensions["Endpoint"] = endpointName
+
+	datums := []Datum{{
+		MetricName: MetricValidationLatency,
+		Dimensions: dimensions,
+		Value:      float64(result.ResponseTimeMs),
+		Unit:       "Milliseconds",
+		Timestamp:  result.CheckedAt,
+	}}
+	if !rolledUp {
+		valid := 0.0
+		if result.IsValid {
+			valid = 1
+		}
+		datums = append(datums, Datum{
+			MetricName: MetricKeysValid,
+			Dimensions: dimensions,
+			Value:      valid,
+			Unit:       "None",
+			Timestamp:  result.CheckedAt,
+		})
+	}
+	return datums
+}
+
+// publish sends datums with the configured keys, or into account through its role
+func (p *Publisher) publish(ctx context.Context, account string, datums []Datum) error {
+	return putMetricData(ctx, p.accountClient(account), p.cfg.Namespace, datums)
+}
+
+// accountClient returns the cached CloudWatch client of account, which signs with the
+// credentials of the role in account or, for "", with the configured keys
+func (p *Publisher) accountClient(account string) putMetricDataAPI {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	client, ok := p.clients[account]
+	if !ok {
+		var creds aws.CredentialsProvider = credentials.StaticCredentialsProvider{Value: p.base}
+		if account != "" {
+			roleARN := fmt.Sprintf("arn:%s:iam::%s:role/%s", p.cfg.Partition, account, p.cfg.RoleName)
+			creds = s3.NewAssumeRoleCredentials(p.base, roleARN, p.cfg.ExternalID, p.cfg.STSEndpoint, p.cfg.Region, p.client)
+		}
+		client = p.newClient(creds)
+		p.clients[account] = client
+	}
+	return client
+}
+
+// newClient builds a CloudWatch client resolving its endpoint like the SDK does, unless
+// the config or an endpoint rule set one
+func (p *Publisher) newClient(creds aws.CredentialsProvider) *cloudwatch.Client {
+	return cloudwatch.NewFromConfig(aws.Config{
+		Region:      p.cfg.Region,
+		Credentials: creds,
+		HTTPClient:  p.client,
+	}, func(o *cloudwatch.Options) {
+		if p.cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(p.cfg.Endpoint)
+		}
+		if p.cfg.UseFIPSEndpoint {
+			o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateEnabled
+		}
+	})
+}
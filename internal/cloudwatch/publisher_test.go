@@ -0,0 +1,232 @@
+package cloudwatch
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubAccounts map[string]string
+
+func (s stubAccounts) AccountID(endpointName string) string {
+	return s[endpointName]
+}
+
+// putCall is a PutMetricData call seen by the fake AWS endpoint
+type putCall struct {
+	accessKey string
+	form      url.Values
+}
+
+// fakeAWS answers STS AssumeRole with keys named after the account and records
+// PutMetricData calls
+func fakeAWS(t *testing.T) (*httptest.Server, func() []putCall) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []putCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form, _ := url.ParseQuery(string(readBody(r)))
+		credential := strings.TrimPrefix(strings.Fields(r.Header.Get("Authorization"))[1], "Credential=")
+		accessKey := strings.Split(credential, "/")[0]
+
+		switch form.Get("Action") {
+		case "AssumeRole":
+			account := strings.Split(form.Get("RoleArn"), ":")[4]
+			fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ROLE%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>%s</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`, account, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		case "PutMetricData":
+			if form.Get("Namespace") == "Denied" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>not allowed</Message></Error></ErrorResponse>`)
+				return
+			}
+			mu.Lock()
+			calls = append(calls, putCall{accessKey: accessKey, form: form})
+			mu.Unlock()
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []putCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]putCall(nil), calls...)
+	}
+}
+
+// readBody returns the request body, decompressing the large PutMetricData calls the
+// SDK gzips
+func readBody(r *http.Request) []byte {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil
+		}
+		body = zr
+	}
+	data, _ := io.ReadAll(body)
+	return data
+}
+
+func testConfig(endpoint string) config.CloudWatchConfig {
+	return config.CloudWatchConfig{
+		Namespace:   "KeyAwsExporter",
+		Region:      "us-east-1",
+		AccessKey:   "BASE",
+		SecretKey:   "secret",
+		Endpoint:    endpoint,
+		STSEndpoint: endpoint,
+		Partition:   "aws",
+		Dimensions:  map[string]string{"Environment": "prod"},
+	}
+}
+
+func TestPublisherSendsValidityAndLatency(t *testing.T) {
+	server, calls := fakeAWS(t)
+	checkedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPublisher(testConfig(server.URL), stubAccounts{}, logrus.New())
+
+	p.Consume(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+		"primary": {IsValid: false, ResponseTimeMs: 85, CheckedAt: checkedAt},
+	}})
+	p.Wait()
+
+	got := calls()
+	if len(got) != 1 || got[0].accessKey != "BASE" {
+		t.Fatalf("expected one call with the configured keys, got %+v", got)
+	}
+	form := got[0].form
+	want := map[string]string{
+		"Namespace":                                     "KeyAwsExporter",
+		"MetricData.member.1.MetricName":                MetricValidationLatency,
+		"MetricData.member.1.Value":                     "85",
+		"MetricData.member.1.Unit":                      "Milliseconds",
+		"MetricData.member.1.Timestamp":                 "2026-03-01T12:00:00Z",
+		"MetricData.member.1.Dimensions.member.1.Name":  "Endpoint",
+		"MetricData.member.1.Dimensions.member.1.Value": "primary",
+		"MetricData.member.1.Dimensions.member.2.Name":  "Environment",
+		"MetricData.member.2.MetricName":                MetricKeysValid,
+		"MetricData.member.2.Value":                     "0",
+	}
+	for key, value := range want {
+		if form.Get(key) != value {
+			t.Errorf("%s = %q, want %q", key, form.Get(key), value)
+		}
+	}
+}
+
+func TestPublisherAssumesRolePerAccount(t *testing.T) {
+	server, calls := fakeAWS(t)
+	cfg := testConfig(server.URL)
+	cfg.RoleName = "s3-key-metrics"
+	accounts := stubAccounts{"a": "111111111111", "b": "222222222222"}
+	p := NewPublisher(cfg, accounts, logrus.New())
+
+	p.Consume(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+		"a":     {IsValid: true},
+		"b":     {IsValid: true},
+		"local": {IsValid: true},
+	}})
+	p.Wait()
+
+	byKey := make(map[string]string)
+	for _, call := range calls() {
+		byKey[call.accessKey] = call.form.Get("MetricData.member.1.Dimensions.member.1.Value")
+	}
+	want := map[string]string{"ROLE111111111111": "a", "ROLE222222222222": "b", "BASE": "local"}
+	if len(byKey) != len(want) {
+		t.Fatalf("expected one call per account, got %v", byKey)
+	}
+	for key, endpoint := range want {
+		if byKey[key] != endpoint {
+			t.Errorf("expected %s published with %s, got %v", endpoint, key, byKey)
+		}
+	}
+}
+
+func TestPublisherUsesFIPSEndpoint(t *testing.T) {
+	cfg := testConfig("")
+	cfg.Region = "us-east-2"
+	cfg.UseFIPSEndpoint = true
+	var host string
+	p := NewPublisher(cfg, stubAccounts{}, logrus.New())
+	p.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		host = r.URL.Host
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("<PutMetricDataResponse/>")), Header: http.Header{}}, nil
+	})}
+
+	if err := p.publish(t.Context(), "", []Datum{{MetricName: MetricKeysValid, Value: 1, Unit: "None"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if host != "monitoring-fips.us-east-2.amazonaws.com" {
+		t.Fatalf("expected the FIPS endpoint, got %s", host)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestPublisherSkipsValidityOfRolledUpEndpoints(t *testing.T) {
+	p := NewPublisher(testConfig("http://unused"), stubAccounts{}, logrus.New())
+	datums := p.datums("a", &s3.ValidationResult{ResponseTimeMs: 5}, true)
+	if len(datums) != 1 || datums[0].MetricName != MetricValidationLatency {
+		t.Fatalf("expected only latency for a rolled-up endpoint, got %+v", datums)
+	}
+}
+
+func TestPutMetricDataReportsAPIErrors(t *testing.T) {
+	server, _ := fakeAWS(t)
+	cfg := testConfig(server.URL)
+	cfg.Namespace = "Denied"
+	p := NewPublisher(cfg, stubAccounts{}, logrus.New())
+
+	err := p.publish(t.Context(), "", []Datum{{MetricName: MetricKeysValid, Value: 1, Unit: "None"}})
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied: not allowed") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+}
+
+func TestPutMetricDataSplitsCalls(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form, _ := url.ParseQuery(string(readBody(r)))
+		n := 0
+		for form.Has(fmt.Sprintf("MetricData.member.%d.MetricName", n+1)) {
+			n++
+		}
+		sizes = append(sizes, n)
+	}))
+	defer server.Close()
+
+	datums := make([]Datum, maxDatumsPerCall+1)
+	for i := range datums {
+		datums[i] = Datum{MetricName: MetricKeysValid, Value: 1, Unit: "None"}
+	}
+	cfg := testConfig(server.URL)
+	p := NewPublisher(cfg, stubAccounts{}, logrus.New())
+	if err := p.publish(t.Context(), "", datums); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(sizes) != fmt.Sprint([]int{maxDatumsPerCall, 1}) {
+		t.Fatalf("expected %d datums to take 2 calls, got calls of %v", len(datums), sizes)
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bucketARN is a bucket given as an access point ARN,
+// arn:partition:service:region:account-id:accesspoint/name
+type bucketARN struct {
+	partition string
+	service   string // s3, or s3-object-lambda for Object Lambda access points
+	region    string // empty for Multi-Region Access Points
+	account   string
+	resource  string // e.g. accesspoint/name
+}
+
+// parseBucketARN splits an S3 access point ARN into its parts
+func parseBucketARN(s string) (bucketARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[1] == "" || (parts[2] != "s3" && parts[2] != "s3-object-lambda") || parts[4] == "" || parts[5] == "" {
+		return bucketARN{}, fmt.Errorf("bucket %q is not an S3 ARN (arn:partition:s3:region:account-id:accesspoint/name)", s)
+	}
+	arn := bucketARN{partition: parts[1], service: parts[2], region: parts[3], account: parts[4], resource: parts[5]}
+	if !strings.HasPrefix(arn.resource, "accesspoint/") || strings.TrimPrefix(arn.resource, "accesspoint/") == "" {
+		return bucketARN{}, fmt.Errorf("bucket ARN %q must name an access point (accesspoint/name)", s)
+	}
+	return arn, nil
+}
+
+// multiRegion reports whether the ARN is a Multi-Region Access Point, whose alias ends
+// in .mrap and which carries no region
+func (a bucketARN) multiRegion() bool {
+	return a.service == "s3" && a.region == "" && strings.HasSuffix(a.resource, ".mrap")
+}
+
+// objectLambda reports whether the ARN is an Object Lambda access point
+func (a bucketARN) objectLambda() bool {
+	return a.service == "s3-object-lambda"
+}
+
+// validateBucketARN rejects settings a bucket given as an access point ARN cannot be
+// validated with. Plain bucket names are left alone.
+func validateBucketARN(endpoint S3EndpointConfig) error {
+	if !strings.HasPrefix(endpoint.Bucket, "arn:") {
+		if endpoint.UseARNRegion {
+			return fmt.Errorf("use_arn_region only applies when bucket is an access point ARN")
+		}
+		return nil
+	}
+	arn, err := parseBucketARN(endpoint.Bucket)
+	if err != nil {
+		return err
+	}
+
+	kind := "access points"
+	if arn.multiRegion() {
+		kind = "multi-region access points"
+		if endpoint.Endpoint != "" {
+			return fmt.Errorf("multi-region access points are reached through the global AWS endpoint; unset endpoint")
+		}
+		if endpoint.UseDualStack {
+			return fmt.Errorf("multi-region access points do not support use_dual_stack")
+		}
+		if endpoint.UseFIPSEndpoint {
+			return fmt.Errorf("multi-region access points do not support use_fips_endpoint")
+		}
+	} else {
+		if arn.objectLambda() {
+			kind = "Object Lambda access points"
+			if endpoint.ProbeDepth == ProbeDepthDeep {
+				return fmt.Errorf("probe_depth must be %q: Object Lambda access points only serve reads", ProbeDepthShallow)
+			}
+			if endpoint.Checks != nil && endpoint.Checks.KMS != nil {
+				return fmt.Errorf("the kms check writes, but Object Lambda access points only serve reads")
+			}
+		}
+		if arn.region == "" {
+			return fmt.Errorf("access point ARN %q has no region; only Multi-Region Access Point aliases end in .mrap", endpoint.Bucket)
+		}
+		if arn.region != endpoint.Region && !endpoint.UseARNRegion {
+			return fmt.Errorf("access point %q is in %s but region is %s; set region to match or use_arn_region", endpoint.Bucket, arn.region, endpoint.Region)
+		}
+	}
+
+	for _, setting := range []struct {
+		name string
+		set  bool
+	}{
+		{"use_path_style", endpoint.UsePathStyle},
+		{"use_accelerate", endpoint.UseAccelerate},
+		{"fallback_regions", len(endpoint.FallbackRegions) > 0},
+	} {
+		if setting.set {
+			return fmt.Errorf("%s do not support %s", kind, setting.name)
+		}
+	}
+	if unsupported := bucketLevelChecks(endpoint.Checks); len(unsupported) > 0 {
+		return fmt.Errorf("access points do not support the %s checks", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// bucketLevelChecks lists the enabled checks that read bucket configuration, which is
+// only available through the bucket itself
+func bucketLevelChecks(checks *ChecksConfig) []string {
+	if checks == nil {
+		return nil
+	}
+	var names []string
+	if checks.AccessLog != nil {
+		names = append(names, "access_log")
+	}
+	if checks.ObjectLock != nil {
+		names = append(names, "object_lock")
+	}
+	if checks.PublicAccess {
+		names = append(names, "public_access")
+	}
+	if checks.Tags != nil {
+		names = append(names, "tags")
+	}
+	if checks.CORS != nil {
+		names = append(names, "cors")
+	}
+	if checks.Website != nil {
+		names = append(names, "website")
+	}
+	return names
+}
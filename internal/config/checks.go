@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that unmarshals from JSON strings such as "90m"
+type Duration time.Duration
+
+// UnmarshalJSON accepts Go duration strings
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"1h\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ChecksConfig enables optional bucket checks for an endpoint. Checks run after a
+// successful validation at most once per interval and never affect key validity.
+type ChecksConfig struct {
+	Interval   Duration               `json:"interval"`
+	Timeout    Duration               `json:"timeout"` // bounds each check run; 0 uses the default
+	AccessLog  *AccessLogCheckConfig  `json:"access_log"`
+	ObjectLock *ObjectLockCheckConfig `json:"object_lock"`
+	// PublicAccess flags buckets whose ACL or policy grants public access
+	PublicAccess bool `json:"public_access"`
+	// KMS enables the SSE-KMS key usability probe
+	KMS *KMSCheckConfig `json:"kms"`
+	// Restore watches the restore status of an archived object
+	Restore *RestoreCheckConfig `json:"restore"`
+	// Inventory samples objects and counts them by storage class
+	Inventory *InventoryCheckConfig `json:"inventory"`
+	// Tags lists the bucket tags that must be set
+	Tags *TagsCheckConfig `json:"tags"`
+	// CORS compares the bucket's CORS rules and sends a browser preflight
+	CORS *CORSCheckConfig `json:"cors"`
+	// Website requests the bucket's static website
+	Website *WebsiteCheckConfig `json:"website"`
+}
+
+// WebsiteCheckConfig describes the static website a bucket serves
+type WebsiteCheckConfig struct {
+	IndexDocument string `json:"index_document"` // expected index document suffix; empty skips the comparison
+	URL           string `json:"url"`            // site root; empty uses the AWS website endpoint
+}
+
+// CORSCheckConfig describes the CORS policy of a bucket serving web assets
+type CORSCheckConfig struct {
+	Rules  []CORSRuleConfig `json:"rules"`  // expected rules in order; empty skips the comparison
+	Origin string           `json:"origin"` // preflight origin; empty skips the preflight
+	Method string           `json:"method"` // preflight request method; empty uses GET
+	URL    string           `json:"url"`    // preflight URL; empty uses the bucket's S3 URL
+}
+
+// CORSRuleConfig is one expected CORS rule
+type CORSRuleConfig struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers"`
+	ExposeHeaders  []string `json:"expose_headers"`
+	MaxAgeSeconds  int32    `json:"max_age_seconds"`
+}
+
+// TagsCheckConfig names the tags every bucket must carry, e.g. cost-center and owner
+type TagsCheckConfig struct {
+	Required []string `json:"required"`
+}
+
+// InventoryCheckConfig selects the objects sampled for the storage class distribution
+type InventoryCheckConfig struct {
+	Prefix     string `json:"prefix"`
+	SampleSize int    `json:"sample_size"` // 0 uses the default of 1000
+}
+
+// RestoreCheckConfig names the archived object whose restore status is reported
+type RestoreCheckConfig struct {
+	Key string `json:"key"`
+}
+
+// KMSCheckConfig selects the KMS key used for the SSE-KMS probe object
+type KMSCheckConfig struct {
+	KeyID string `json:"key_id"` // key ID, ARN or alias; empty uses the bucket default key
+}
+
+// AccessLogCheckConfig verifies that server access logs for the bucket are delivered
+type AccessLogCheckConfig struct {
+	TargetBucket string   `json:"target_bucket"`
+	TargetPrefix string   `json:"target_prefix"`
+	Delay        Duration `json:"delay"`
+	MaxWait      Duration `json:"max_wait"`
+}
+
+// ObjectLockCheckConfig lists the Object Lock default retention the bucket must enforce
+type ObjectLockCheckConfig struct {
+	Mode  string `json:"mode"`
+	Days  int32  `json:"days"`
+	Years int32  `json:"years"`
+}
+
+// validateChecks reports the first invalid check setting
+func validateChecks(checks *ChecksConfig) error {
+	if checks == nil {
+		return nil
+	}
+	if checks.Interval < 0 {
+		return fmt.Errorf("checks.interval cannot be negative")
+	}
+	if checks.Timeout < 0 {
+		return fmt.Errorf("checks.timeout cannot be negative")
+	}
+	if al := checks.AccessLog; al != nil {
+		if al.TargetBucket == "" {
+			return fmt.Errorf("checks.access_log.target_bucket is required")
+		}
+		if al.Delay < 0 || al.MaxWait < 0 {
+			return fmt.Errorf("checks.access_log delays cannot be negative")
+		}
+		if al.Delay > 0 && al.MaxWait > 0 && al.MaxWait < al.Delay {
+			return fmt.Errorf("checks.access_log.max_wait must not be shorter than delay")
+		}
+	}
+	if r := checks.Restore; r != nil && r.Key == "" {
+		return fmt.Errorf("checks.restore.key is required")
+	}
+	if inv := checks.Inventory; inv != nil && inv.SampleSize < 0 {
+		return fmt.Errorf("checks.inventory.sample_size cannot be negative")
+	}
+	if tags := checks.Tags; tags != nil {
+		if len(tags.Required) == 0 {
+			return fmt.Errorf("checks.tags.required must list at least one tag")
+		}
+		for _, key := range tags.Required {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("checks.tags.required cannot contain empty tag keys")
+			}
+		}
+	}
+	if cors := checks.CORS; cors != nil {
+		if err := validateCORSCheck(cors); err != nil {
+			return err
+		}
+	}
+	if ws := checks.Website; ws != nil && ws.URL != "" {
+		if u, err := url.Parse(ws.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("checks.website.url must be an http or https URL, got %q", ws.URL)
+		}
+	}
+	if ol := checks.ObjectLock; ol != nil {
+		switch strings.ToUpper(ol.Mode) {
+		case "", "GOVERNANCE", "COMPLIANCE":
+		default:
+			return fmt.Errorf("checks.object_lock.mode must be GOVERNANCE or COMPLIANCE, got %q", ol.Mode)
+		}
+		if ol.Days < 0 || ol.Years < 0 {
+			return fmt.Errorf("checks.object_lock retention cannot be negative")
+		}
+		if ol.Days > 0 && ol.Years > 0 {
+			return fmt.Errorf("checks.object_lock accepts days or years, not both")
+		}
+	}
+	return nil
+}
+
+// corsMethods are the methods S3 accepts in CORS rules
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// validateCORSCheck reports the first invalid CORS check setting
+func validateCORSCheck(cors *CORSCheckConfig) error {
+	if len(cors.Rules) == 0 && cors.Origin == "" {
+		return fmt.Errorf("checks.cors needs rules, an origin for the preflight or both")
+	}
+	for i, rule := range cors.Rules {
+		if len(rule.AllowedOrigins) == 0 || len(rule.AllowedMethods) == 0 {
+			return fmt.Errorf("checks.cors.rules[%d] needs allowed_origins and allowed_methods", i)
+		}
+		for _, method := range rule.AllowedMethods {
+			if !slices.Contains(corsMethods, strings.ToUpper(method)) {
+				return fmt.Errorf("checks.cors.rules[%d] method must be one of %s, got %q", i, strings.Join(corsMethods, ", "), method)
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			return fmt.Errorf("checks.cors.rules[%d].max_age_seconds cannot be negative", i)
+		}
+	}
+	if cors.Origin == "" && (cors.Method != "" || cors.URL != "") {
+		return fmt.Errorf("checks.cors method and url need an origin")
+	}
+	if cors.Method != "" && !slices.Contains(corsMethods, strings.ToUpper(cors.Method)) {
+		return fmt.Errorf("checks.cors.method must be one of %s, got %q", strings.Join(corsMethods, ", "), cors.Method)
+	}
+	if cors.URL != "" {
+		if u, err := url.Parse(cors.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("checks.cors.url must be an http or https URL, got %q", cors.URL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// validateClientCert requires client_cert and client_key together and checks that they
+// hold a matching PEM certificate and private key
+func validateClientCert(e S3EndpointConfig) error {
+	if e.ClientCert == "" && e.ClientKey == "" {
+		return nil
+	}
+	if e.ClientCert == "" || e.ClientKey == "" {
+		return fmt.Errorf("client_cert and client_key must be set together")
+	}
+	if _, err := tls.LoadX509KeyPair(e.ClientCert, e.ClientKey); err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCloudWatchNamespace is the namespace of the metrics published to CloudWatch
+const DefaultCloudWatchNamespace = "KeyAwsExporter"
+
+// CloudWatchConfig publishes key validity and latency to CloudWatch with PutMetricData,
+// loaded from CLOUDWATCH_JSON. With role_name set, the metrics of an endpoint with an
+// account_id are published in that account through arn:<partition>:iam::<account>:role/<role_name>;
+// other endpoints publish with the configured keys.
+type CloudWatchConfig struct {
+	Namespace    string `json:"namespace"`
+	Region       string `json:"region"`
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	// Endpoint overrides the URL the SDK resolves for the region
+	Endpoint string `json:"endpoint"`
+	// UseFIPSEndpoint publishes through the FIPS endpoint, e.g. monitoring-fips.us-east-2.amazonaws.com
+	UseFIPSEndpoint bool   `json:"use_fips_endpoint"`
+	RoleName        string `json:"role_name"`
+	ExternalID      string `json:"external_id"`
+	Partition       string `json:"partition"`
+	STSEndpoint     string `json:"sts_endpoint"`
+	// Dimensions are added to every datum next to Endpoint, e.g. {"Environment": "prod"}
+	Dimensions map[string]string `json:"dimensions"`
+}
+
+// validateCloudWatch applies defaults and reports the first invalid setting
+func validateCloudWatch(c *CloudWatchConfig) error {
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("access_key and secret_key are required")
+	}
+	if strings.HasPrefix(c.Namespace, "AWS/") {
+		return fmt.Errorf("namespace cannot start with the reserved AWS/ prefix")
+	}
+	if strings.ContainsAny(c.RoleName, ":") {
+		return fmt.Errorf("role_name must be a role name, not an ARN, e.g. \"s3-key-metrics\"")
+	}
+	// PutMetricData accepts 30 dimensions per datum, one of which is Endpoint
+	if len(c.Dimensions) > 29 {
+		return fmt.Errorf("at most 29 dimensions can be added")
+	}
+	for name, value := range c.Dimensions {
+		if name == "" || value == "" || name == "Endpoint" {
+			return fmt.Errorf("dimension %q must have a name other than Endpoint and a value", name)
+		}
+	}
+	if c.Namespace == "" {
+		c.Namespace = DefaultCloudWatchNamespace
+	}
+	if c.Region == "" {
+		c.Region = DefaultS3Region
+	}
+	if c.Partition == "" {
+		c.Partition = "aws"
+	}
+	c.Endpoint = strings.TrimRight(c.Endpoint, "/")
+	if c.UseFIPSEndpoint && c.Endpoint != "" {
+		return fmt.Errorf("use_fips_endpoint selects the AWS FIPS endpoint and cannot be combined with endpoint %s", c.Endpoint)
+	}
+	return nil
+}
@@ -3,41 +3,270 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 const (
-	DefaultPort                 = 8080
-	DefaultS3Region             = "us-east-1"
-	ShutdownTimeout             = 30 * time.Second
-	DefaultValidationTimeout    = 10 * time.Second
-	DefaultAutoValidateInterval = 0
+	DefaultPort                  = 8080
+	DefaultS3Region              = "us-east-1"
+	ShutdownTimeout              = 30 * time.Second
+	DefaultValidationTimeout     = 10 * time.Second
+	DefaultAutoValidateInterval  = 0
+	DefaultDeepValidateInterval  = time.Hour
+	DefaultHistorySize           = 100
+	DefaultCycleHistorySize      = 20
+	DefaultAnomalyMinSamples     = 10
+	DefaultIdempotencyKeyTTL     = 10 * time.Minute
+	DefaultClientIdleTimeout     = 30 * time.Minute
+	DefaultClientMaxLifetime     = time.Hour
+	DefaultWarmUpRampFactor      = 2
+	DefaultWarmUpBatchInterval   = 5 * time.Second
+	DefaultCanaryTTL             = time.Hour
+	DefaultCanaryCleanupInterval = time.Hour
+)
+
+// HTTPWriteTimeout is the server's write timeout. Handlers that run longer, such as a
+// /validate request with its budget, extend their own deadline.
+const HTTPWriteTimeout = 20 * time.Second
+
+// ResponseWriteMargin is the time left to write a response once its work is done
+const ResponseWriteMargin = 5 * time.Second
+
+// DefaultValidateRequestTimeout leaves a /validate response time to be written within
+// the server's write timeout
+const DefaultValidateRequestTimeout = HTTPWriteTimeout - ResponseWriteMargin
+
+// LogMode controls how validation outcomes are logged
+type LogMode string
+
+// Log modes accepted in LOG_MODE
+const (
+	// LogModeAll logs every validation outcome
+
+	LogModeAll LogMode = "all"
+	// LogModeChanges only logs when an endpoint or provider changes state
+	LogModeChanges LogMode = "changes"
+)
+
+// Probe depths accepted in the probe_depth endpoint setting
+const (
+	ProbeDepthShallow = "shallow"
+	ProbeDepthDeep    = "deep"
+)
+
+// Address families accepted in the ip_family endpoint setting
+const (
+	IPFamilyAuto = "auto"
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
 )
 
 // S3EndpointConfig represents configuration for a single S3 endpoint
 type S3EndpointConfig struct {
-	Name               string `json:"name"`
-	Endpoint           string `json:"endpoint"`
-	Region             string `json:"region"`
-	Bucket             string `json:"bucket"`
-	AccessKey          string `json:"access_key"`
-	SecretKey          string `json:"secret_key"`
-	SessionToken       string `json:"session_token"`
-	UsePathStyle       bool   `json:"use_path_style"`
-	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	Name               string            `json:"name"`
+	Endpoint           string            `json:"endpoint"`
+	Region             string            `json:"region"`
+	Bucket             string            `json:"bucket"`
+	AccessKey          string            `json:"access_key"`
+	SecretKey          string            `json:"secret_key"`
+	SessionToken       string            `json:"session_token"`
+	Secondary          *Credentials      `json:"secondary"`
+	UsePathStyle       bool              `json:"use_path_style"`
+	UseAccelerate      bool              `json:"use_accelerate"`
+	UseDualStack       bool              `json:"use_dual_stack"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify"`
+	ProbeDepth         string            `json:"probe_depth"`
+	FallbackRegions    []string          `json:"fallback_regions"`
+	Provider           string            `json:"provider"`
+	UserAgent          string            `json:"user_agent"`
+	RequestHeaders     map[string]string `json:"request_headers"`
+	Checks             *ChecksConfig     `json:"checks"`
+	IPFamily           string            `json:"ip_family"`
+	DNSServers         []string          `json:"dns_servers"`
+	Resolve            map[string]string `json:"resolve"`
+	SOCKS5Proxy        *SOCKS5Proxy      `json:"socks5_proxy"`
+	Severity           string            `json:"severity"`
+	Labels             map[string]string `json:"labels"`
+	// Annotations describe the endpoint to responders, e.g. owner, runbook_url and description
+	Annotations map[string]string `json:"annotations"`
+	// KeyCreatedAt declares when the access key was issued; KeyAgeFromIAM asks IAM instead
+	KeyCreatedAt  Timestamp `json:"key_created_at"`
+	KeyAgeFromIAM bool      `json:"key_age_from_iam"`
+	IAMEndpoint   string    `json:"iam_endpoint"`
+	// KeyMaxAge is the rotation policy of the key; 0 falls back to KEY_MAX_AGE
+	KeyMaxAge Duration `json:"key_max_age"`
+	// Rotation opts the endpoint into automatic IAM key rotation
+	Rotation *RotationConfig `json:"rotation"`
+	// ExpectedPermissions maps operations to "allowed" or "denied"; mismatches are drift
+	ExpectedPermissions map[string]string `json:"expected_permissions"`
+	// UseARNRegion routes requests to the region of an access point ARN given as bucket
+	UseARNRegion bool `json:"use_arn_region"`
+	// ChecksumAlgorithm is the additional checksum write probes upload with, e.g. CRC32C
+	ChecksumAlgorithm string `json:"checksum_algorithm"`
+	// RoleARN is assumed with the access key, validating the role's access instead
+	RoleARN     string `json:"role_arn"`
+	ExternalID  string `json:"external_id"`
+	STSEndpoint string `json:"sts_endpoint"`
+	// AccountID is the AWS account owning the bucket, exported as s3_endpoint_account_info
+	AccountID string `json:"account_id"`
+	// ClientCert and ClientKey are PEM files presented to endpoints requiring mutual TLS
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+	// UseFIPSEndpoint sends requests to the FIPS endpoint, e.g. s3-fips.us-gov-west-1.amazonaws.com
+	UseFIPSEndpoint bool `json:"use_fips_endpoint"`
+	// PrivateCIDRs are the ranges the endpoint's VPC endpoint answers from; empty counts
+	// any private address as s3_via_private_endpoint
+	PrivateCIDRs []string `json:"private_cidrs"`
+	// RetryMode, MaxAttempts and MaxBackoff override the SDK's retries; unset keeps its defaults
+	RetryMode   string   `json:"retry_mode"`
+	MaxAttempts int      `json:"max_attempts"`
+	MaxBackoff  Duration `json:"max_backoff"`
+	// Plugin validates the endpoint with an external program instead of S3 requests
+	Plugin *PluginConfig `json:"plugin"`
+	// Hedge sends a second shallow probe when the first is slower than the endpoint's p95
+	Hedge *HedgeConfig `json:"hedge"`
+	// RoundTrip verifies that deep probe objects read back as they were written
+	RoundTrip *RoundTripConfig `json:"roundtrip"`
+	// Success redefines when the shallow probe counts as successful
+	Success *SuccessConfig `json:"success"`
+}
+
+// Credentials is an additional access key validated alongside the endpoint's own, e.g.
+// the new key during a rotation
+type Credentials struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+}
+
+// SOCKS5Proxy routes an endpoint's traffic through a SOCKS5 proxy, e.g. a bastion tunnel
+type SOCKS5Proxy struct {
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 type Config struct {
-	Port                 int
-	Endpoints            []S3EndpointConfig
-	ValidationTimeout    time.Duration
+	Port              int
+	Endpoints         []S3EndpointConfig
+	ValidationTimeout time.Duration
+	// ValidateRequestTimeout caps a whole manual /validate request
+	ValidateRequestTimeout time.Duration
+	// IdempotencyKeyTTL is how long POST /validate results are replayed per Idempotency-Key; 0 disables
+	IdempotencyKeyTTL time.Duration
+	// ValidateRateLimit is how many validation requests a client may make per minute; 0 disables
+	ValidateRateLimit int
+	// ValidateRateBurst is how many of those may come at once; 0 uses ValidateRateLimit
+	ValidateRateBurst    int
 	MetricsPath          string
 	AutoValidateInterval time.Duration
+	DeepValidateInterval time.Duration
+	// PermissionCheckInterval is how often expected_permissions are asserted; 0 disables
+	PermissionCheckInterval time.Duration
+	LogMode                 LogMode
+	HistorySize             int
+	// CycleHistorySize is how many auto-validation cycles are kept for /cycles
+	CycleHistorySize int
+	// HistoryDB persists every result in SQLite for /history range queries; nil disables it
+	HistoryDB *HistoryDBConfig
+	// LatencyAnomalyFactor flags validations slower than factor × the rolling median; 0 disables
+	LatencyAnomalyFactor     float64
+	LatencyAnomalyMinSamples int
+	// KeyMaxAge is the default key rotation policy; 0 disables s3_key_rotation_due
+	KeyMaxAge time.Duration
+	// WarmUpInitialBatch ramps the first scheduled runs up from this many endpoints,
+	// growing each batch by WarmUpRampFactor after a WarmUpBatchInterval pause; 0 validates
+	// every endpoint at once
+	WarmUpInitialBatch  int
+	WarmUpRampFactor    float64
+	WarmUpBatchInterval time.Duration
+	// ReplicaID names this exporter in canary object keys so replicas sharing a bucket
+	// never collide; empty uses the host name
+	ReplicaID string
+	// CanaryTTL is the age after which a canary left behind by a probe counts as leaked
+	CanaryTTL time.Duration
+	// CanaryCleanupInterval is how often leaked canaries are deleted; 0 disables it
+	CanaryCleanupInterval time.Duration
+	// ReadOnly refuses every probe, check and rotation that writes
+	ReadOnly bool
+	// SigningKeyFile is a PEM Ed25519 private key signing validate responses and webhooks; empty disables signing
+	SigningKeyFile string
+	// SlackSigningSecret verifies requests to /slack/command; empty disables the route
+	SlackSigningSecret string
+	// CredentialsAPITokens are the bearer tokens of /validate/credentials; empty disables the route
+	CredentialsAPITokens []string
+	// CredentialsAPIAllowedEndpoints are the S3-compatible URLs /validate/credentials may validate against besides AWS
+	CredentialsAPIAllowedEndpoints []string
+	// OIDC requires a JWT from an OIDC provider on the API routes; nil leaves them open
+	OIDC *OIDCConfig
+	// RBAC limits the roles of authenticated callers to routes and endpoints; nil authorizes by permission
+	RBAC *RBACConfig
+	// FailureInjection serves /admin/inject-failure for chaos testing alert routing
+	FailureInjection bool
+	// ClientIdleTimeout drops S3 clients no validation used for this long; 0 keeps them
+	ClientIdleTimeout time.Duration
+	// ClientMaxLifetime rebuilds S3 clients, with new connections and credentials, once this old; 0 never does
+	ClientMaxLifetime time.Duration
+	// ResponseTimeBuckets overrides the s3_response_time_seconds bucket bounds, in seconds
+	ResponseTimeBuckets []float64
+	// ResponseTimeMsCompat keeps exporting the deprecated s3_response_time_milliseconds
+	ResponseTimeMsCompat bool
+	// NativeHistograms adds Prometheus native histograms to the duration metrics
+	NativeHistograms bool
+	// CORSAllowedOrigins lists the browser origins allowed to call the API ("*" for any); empty disables CORS
+	CORSAllowedOrigins []string
+	// CORSAllowedMethods and CORSAllowedHeaders answer preflight requests; empty uses the defaults
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+	Reports            *ReportsConfig
+	Notifications      *NotificationsConfig
+	// AllowedClientCIDRs limits the routes that run validations and /admin to these clients; empty allows any
+	AllowedClientCIDRs []netip.Prefix
+	// TrustedProxies are the proxies whose X-Forwarded-For or X-Real-IP names the real client
+	TrustedProxies []netip.Prefix
+	// Discovery creates endpoints for tagged buckets of an account; nil disables it
+	Discovery *DiscoveryConfig
+	// Organizations creates endpoints in every account of an organization; nil disables it
+	Organizations *OrganizationsConfig
+	// ProbePricing prices probe requests per provider for s3_probe_estimated_cost_usd; empty disables the estimate
+	ProbePricing map[string]ProbePrice
+	// StatsD sends validation results to a DogStatsD agent; nil disables it
+	StatsD *StatsDConfig
+	// ResultsLog appends every validation result to a JSON Lines file; nil disables it
+	ResultsLog *ResultsLogConfig
+	// RemoteWrite pushes the exporter's series to a remote write receiver; nil disables it
+	RemoteWrite *RemoteWriteConfig
+	// CloudWatch publishes key validity and latency with PutMetricData; nil disables it
+	CloudWatch *CloudWatchConfig
+	// Snapshot periodically writes metrics or results to an S3 object; nil disables it
+	Snapshot *SnapshotConfig
+	// FakeS3 validates every endpoint against a fake S3 instead of the configured one
+	FakeS3 FakeS3Config
+	// FIPSMode requires a binary built with FIPS 140 cryptography and refuses endpoints
+	// that skip TLS verification
+	FIPSMode bool
+	// EndpointRules resolve the S3, STS and IAM URLs of endpoints, and the CloudWatch
+	// publisher's URLs, that leave them unset
+	EndpointRules EndpointRules
+	// ResultRules reclassify, re-grade or suppress results before metrics and notifications
+	ResultRules []ResultRule
+	// EndpointPairs compare two endpoints serving the same bucket on every run
+	EndpointPairs []EndpointPair
+}
+
+// discovers reports whether endpoints are created at runtime, so none need to be configured
+func (c *Config) discovers() bool {
+	return c.Discovery != nil || c.Organizations != nil
 }
 
 // LoadConfig loads configuration from environment variables
@@ -48,10 +277,297 @@ func LoadConfig() (*Config, error) {
 	}
 
 	cfg := &Config{
-		Port:                 getEnvInt("EXPORTER_PORT", DefaultPort),
-		ValidationTimeout:    getEnvDuration("VALIDATION_TIMEOUT", DefaultValidationTimeout),
-		MetricsPath:          "/metrics",
-		AutoValidateInterval: getEnvDuration("AUTO_VALIDATE_INTERVAL", DefaultAutoValidateInterval),
+		Port:                     getEnvInt("EXPORTER_PORT", DefaultPort),
+		ValidationTimeout:        getEnvDuration("VALIDATION_TIMEOUT", DefaultValidationTimeout),
+		ValidateRequestTimeout:   getEnvDuration("VALIDATE_REQUEST_TIMEOUT", DefaultValidateRequestTimeout),
+		IdempotencyKeyTTL:        getEnvDuration("IDEMPOTENCY_KEY_TTL", DefaultIdempotencyKeyTTL),
+		ValidateRateLimit:        getEnvInt("VALIDATE_RATE_LIMIT", 0),
+		ValidateRateBurst:        getEnvInt("VALIDATE_RATE_BURST", 0),
+		MetricsPath:              "/metrics",
+		AutoValidateInterval:     getEnvDuration("AUTO_VALIDATE_INTERVAL", DefaultAutoValidateInterval),
+		DeepValidateInterval:     getEnvDuration("DEEP_VALIDATE_INTERVAL", DefaultDeepValidateInterval),
+		PermissionCheckInterval:  getEnvDuration("PERMISSION_CHECK_INTERVAL", DefaultPermissionCheckInterval),
+		LogMode:                  LogMode(getEnv("LOG_MODE", string(LogModeAll))),
+		HistorySize:              getEnvInt("HISTORY_SIZE", DefaultHistorySize),
+		CycleHistorySize:         getEnvInt("CYCLE_HISTORY_SIZE", DefaultCycleHistorySize),
+		LatencyAnomalyFactor:     getEnvFloat("LATENCY_ANOMALY_FACTOR", 0),
+		LatencyAnomalyMinSamples: getEnvInt("LATENCY_ANOMALY_MIN_SAMPLES", DefaultAnomalyMinSamples),
+		KeyMaxAge:                getEnvDuration("KEY_MAX_AGE", 0),
+		WarmUpInitialBatch:       getEnvInt("WARMUP_INITIAL_BATCH", 0),
+		WarmUpRampFactor:         getEnvFloat("WARMUP_RAMP_FACTOR", DefaultWarmUpRampFactor),
+		WarmUpBatchInterval:      getEnvDuration("WARMUP_BATCH_INTERVAL", DefaultWarmUpBatchInterval),
+		ReplicaID:                getEnv("REPLICA_ID", ""),
+		CanaryTTL:                getEnvDuration("CANARY_TTL", DefaultCanaryTTL),
+		CanaryCleanupInterval:    getEnvDuration("CANARY_CLEANUP_INTERVAL", DefaultCanaryCleanupInterval),
+		ReadOnly:                 getEnvBool("READ_ONLY", false),
+		FailureInjection:         getEnvBool("FAILURE_INJECTION", false),
+		SlackSigningSecret:       getEnv("SLACK_SIGNING_SECRET", ""),
+		CredentialsAPITokens:     getEnvList("CREDENTIALS_API_TOKENS"),
+		SigningKeyFile:           getEnv("RESULT_SIGNING_KEY_FILE", ""),
+		FIPSMode:                 getEnvBool("FIPS_MODE", false),
+		ClientIdleTimeout:        getEnvDuration("CLIENT_IDLE_TIMEOUT", DefaultClientIdleTimeout),
+		ClientMaxLifetime:        getEnvDuration("CLIENT_MAX_LIFETIME", DefaultClientMaxLifetime),
+		ResponseTimeMsCompat:     getEnvBool("RESPONSE_TIME_MS_COMPAT", false),
+		NativeHistograms:         getEnvBool("NATIVE_HISTOGRAMS", false),
+		CORSAllowedOrigins:       getEnvList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedHeaders:       getEnvList("CORS_ALLOWED_HEADERS"),
+	}
+	cfg.CredentialsAPIAllowedEndpoints = getEnvList("CREDENTIALS_API_ALLOWED_ENDPOINTS")
+	for _, method := range getEnvList("CORS_ALLOWED_METHODS") {
+		cfg.CORSAllowedMethods = append(cfg.CORSAllowedMethods, strings.ToUpper(method))
+	}
+	if err := validateCORSOrigins(cfg.CORSAllowedOrigins); err != nil {
+		return nil, err
+	}
+	allowedClients, err := getEnvCIDRs("ALLOWED_CLIENT_CIDRS")
+	if err != nil {
+		return nil, err
+	}
+	trustedProxies, err := getEnvCIDRs("TRUSTED_PROXIES")
+	if err != nil {
+		return nil, err
+	}
+	cfg.AllowedClientCIDRs, cfg.TrustedProxies = allowedClients, trustedProxies
+
+	buckets, err := getEnvFloatList("RESPONSE_TIME_BUCKETS")
+	if err != nil {
+		return nil, err
+	}
+	for i, bound := range buckets {
+		if bound <= 0 || (i > 0 && bound <= buckets[i-1]) {
+			return nil, fmt.Errorf("RESPONSE_TIME_BUCKETS must be positive and increasing, got %v", buckets)
+		}
+	}
+	cfg.ResponseTimeBuckets = buckets
+
+	if cfg.ValidateRateLimit < 0 || cfg.ValidateRateBurst < 0 {
+		return nil, fmt.Errorf("VALIDATE_RATE_LIMIT and VALIDATE_RATE_BURST cannot be negative")
+	}
+
+	if cfg.LogMode != LogModeAll && cfg.LogMode != LogModeChanges {
+		return nil, fmt.Errorf("LOG_MODE must be %q or %q, got %q", LogModeAll, LogModeChanges, cfg.LogMode)
+	}
+
+	if cfg.ValidateRequestTimeout <= 0 {
+		return nil, fmt.Errorf("VALIDATE_REQUEST_TIMEOUT must be positive, got %s", cfg.ValidateRequestTimeout)
+	}
+
+	if cfg.HistorySize <= 0 {
+		return nil, fmt.Errorf("HISTORY_SIZE must be positive, got %d", cfg.HistorySize)
+	}
+
+	if cfg.CycleHistorySize <= 0 {
+		return nil, fmt.Errorf("CYCLE_HISTORY_SIZE must be positive, got %d", cfg.CycleHistorySize)
+	}
+
+	if cfg.LatencyAnomalyFactor != 0 && cfg.LatencyAnomalyFactor <= 1 {
+		return nil, fmt.Errorf("LATENCY_ANOMALY_FACTOR must be greater than 1 (or 0 to disable), got %v", cfg.LatencyAnomalyFactor)
+	}
+
+	if cfg.LatencyAnomalyMinSamples <= 0 {
+		return nil, fmt.Errorf("LATENCY_ANOMALY_MIN_SAMPLES must be positive, got %d", cfg.LatencyAnomalyMinSamples)
+	}
+
+	if cfg.WarmUpInitialBatch < 0 {
+		return nil, fmt.Errorf("WARMUP_INITIAL_BATCH cannot be negative, got %d", cfg.WarmUpInitialBatch)
+	}
+	if cfg.WarmUpInitialBatch > 0 && (cfg.WarmUpRampFactor < 1 || cfg.WarmUpBatchInterval <= 0) {
+		return nil, fmt.Errorf("WARMUP_RAMP_FACTOR must be at least 1 and WARMUP_BATCH_INTERVAL positive, got %v and %s", cfg.WarmUpRampFactor, cfg.WarmUpBatchInterval)
+	}
+
+	// A canary still in use by a probe must never look leaked
+	if cfg.CanaryTTL <= cfg.ValidationTimeout {
+		return nil, fmt.Errorf("CANARY_TTL must be longer than VALIDATION_TIMEOUT (%s), got %s", cfg.ValidationTimeout, cfg.CanaryTTL)
+	}
+	if cfg.CanaryCleanupInterval < 0 {
+		return nil, fmt.Errorf("CANARY_CLEANUP_INTERVAL cannot be negative, got %s", cfg.CanaryCleanupInterval)
+	}
+
+	if cfg.KeyMaxAge < 0 {
+		return nil, fmt.Errorf("KEY_MAX_AGE cannot be negative, got %s", cfg.KeyMaxAge)
+	}
+
+	if cfg.ClientIdleTimeout < 0 || cfg.ClientMaxLifetime < 0 {
+		return nil, fmt.Errorf("CLIENT_IDLE_TIMEOUT and CLIENT_MAX_LIFETIME cannot be negative")
+	}
+
+	if reportsJSON := os.Getenv("REPORTS_JSON"); reportsJSON != "" {
+		cfg.Reports = &ReportsConfig{}
+		if err := json.Unmarshal([]byte(reportsJSON), cfg.Reports); err != nil {
+			return nil, fmt.Errorf("failed to parse REPORTS_JSON: %w", err)
+		}
+		if err := validateReports(cfg.Reports); err != nil {
+			return nil, fmt.Errorf("REPORTS_JSON: %w", err)
+		}
+	}
+
+	if notificationsJSON := os.Getenv("NOTIFICATIONS_JSON"); notificationsJSON != "" {
+		cfg.Notifications = &NotificationsConfig{}
+		if err := json.Unmarshal([]byte(notificationsJSON), cfg.Notifications); err != nil {
+			return nil, fmt.Errorf("failed to parse NOTIFICATIONS_JSON: %w", err)
+		}
+		if err := validateNotifications(cfg.Notifications); err != nil {
+			return nil, fmt.Errorf("NOTIFICATIONS_JSON: %w", err)
+		}
+	}
+
+	if pricingJSON := os.Getenv("PROBE_PRICING_JSON"); pricingJSON != "" {
+		if err := json.Unmarshal([]byte(pricingJSON), &cfg.ProbePricing); err != nil {
+			return nil, fmt.Errorf("failed to parse PROBE_PRICING_JSON: %w", err)
+		}
+		if err := validateProbePricing(cfg.ProbePricing); err != nil {
+			return nil, fmt.Errorf("PROBE_PRICING_JSON: %w", err)
+		}
+	}
+
+	if address := getEnv("STATSD_ADDRESS", ""); address != "" {
+		cfg.StatsD = &StatsDConfig{
+			Address: address,
+			Prefix:  getEnv("STATSD_PREFIX", DefaultStatsDPrefix),
+			Tags:    getEnvList("STATSD_TAGS"),
+		}
+		if err := validateStatsD(cfg.StatsD); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := getEnv("HISTORY_DB_PATH", ""); path != "" {
+		cfg.HistoryDB = &HistoryDBConfig{
+			Path:            path,
+			Retention:       getEnvDuration("HISTORY_DB_RETENTION", DefaultHistoryDBRetention),
+			RollupRetention: getEnvDuration("HISTORY_DB_ROLLUP_RETENTION", DefaultHistoryDBRollupRetention),
+		}
+		if err := validateHistoryDB(cfg.HistoryDB); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := getEnv("RESULTS_LOG_FILE", ""); path != "" {
+		cfg.ResultsLog = &ResultsLogConfig{
+			Path:       path,
+			MaxSize:    int64(getEnvInt("RESULTS_LOG_MAX_SIZE_MB", DefaultResultsLogMaxSizeMB)) << 20,
+			MaxBackups: getEnvInt("RESULTS_LOG_MAX_BACKUPS", DefaultResultsLogMaxBackups),
+		}
+		if err := validateResultsLog(cfg.ResultsLog); err != nil {
+			return nil, err
+		}
+	}
+
+	if remoteWriteJSON := os.Getenv("REMOTE_WRITE_JSON"); remoteWriteJSON != "" {
+		cfg.RemoteWrite = &RemoteWriteConfig{}
+		if err := json.Unmarshal([]byte(remoteWriteJSON), cfg.RemoteWrite); err != nil {
+			return nil, fmt.Errorf("failed to parse REMOTE_WRITE_JSON: %w", err)
+		}
+		if err := validateRemoteWrite(cfg.RemoteWrite); err != nil {
+			return nil, fmt.Errorf("REMOTE_WRITE_JSON: %w", err)
+		}
+	}
+
+	if cloudWatchJSON := os.Getenv("CLOUDWATCH_JSON"); cloudWatchJSON != "" {
+		cfg.CloudWatch = &CloudWatchConfig{}
+		if err := json.Unmarshal([]byte(cloudWatchJSON), cfg.CloudWatch); err != nil {
+			return nil, fmt.Errorf("failed to parse CLOUDWATCH_JSON: %w", err)
+		}
+		if err := validateCloudWatch(cfg.CloudWatch); err != nil {
+			return nil, fmt.Errorf("CLOUDWATCH_JSON: %w", err)
+		}
+	}
+
+	if oidcJSON := os.Getenv("OIDC_JSON"); oidcJSON != "" {
+		cfg.OIDC = &OIDCConfig{}
+		if err := json.Unmarshal([]byte(oidcJSON), cfg.OIDC); err != nil {
+			return nil, fmt.Errorf("failed to parse OIDC_JSON: %w", err)
+		}
+		if err := validateOIDC(cfg.OIDC); err != nil {
+			return nil, fmt.Errorf("OIDC_JSON: %w", err)
+		}
+	}
+
+	if rbacJSON := os.Getenv("RBAC_JSON"); rbacJSON != "" {
+		if cfg.OIDC == nil {
+			return nil, fmt.Errorf("RBAC_JSON requires OIDC_JSON to authenticate callers")
+		}
+		cfg.RBAC = &RBACConfig{}
+		if err := json.Unmarshal([]byte(rbacJSON), cfg.RBAC); err != nil {
+			return nil, fmt.Errorf("failed to parse RBAC_JSON: %w", err)
+		}
+		if err := validateRBAC(cfg.RBAC); err != nil {
+			return nil, fmt.Errorf("RBAC_JSON: %w", err)
+		}
+	}
+	if cfg.OIDC != nil && cfg.RBAC == nil && len(cfg.OIDC.RolePermissions) == 0 {
+		return nil, fmt.Errorf("OIDC_JSON: role_permissions must grant at least one role a permission, unless RBAC_JSON is set")
+	}
+
+	if snapshotJSON := os.Getenv("SNAPSHOT_EXPORT_JSON"); snapshotJSON != "" {
+		cfg.Snapshot = &SnapshotConfig{}
+		if err := json.Unmarshal([]byte(snapshotJSON), cfg.Snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse SNAPSHOT_EXPORT_JSON: %w", err)
+		}
+		if err := validateSnapshot(cfg.Snapshot); err != nil {
+			return nil, fmt.Errorf("SNAPSHOT_EXPORT_JSON: %w", err)
+		}
+	}
+
+	cfg.FakeS3 = FakeS3Config{
+		Enabled: getEnvBool("FAKE_S3", false),
+		Address: getEnv("FAKE_S3_ADDRESS", DefaultFakeS3Address),
+		Target:  getEnv("FAKE_S3_TARGET", ""),
+	}
+	if failuresJSON := os.Getenv("FAKE_S3_FAILURES_JSON"); failuresJSON != "" {
+		if err := json.Unmarshal([]byte(failuresJSON), &cfg.FakeS3.Failures); err != nil {
+			return nil, fmt.Errorf("failed to parse FAKE_S3_FAILURES_JSON: %w", err)
+		}
+		if err := validateFakeS3Failures(cfg.FakeS3.Failures); err != nil {
+			return nil, fmt.Errorf("FAKE_S3_FAILURES_JSON: %w", err)
+		}
+	}
+
+	if discoveryJSON := os.Getenv("DISCOVERY_JSON"); discoveryJSON != "" {
+		cfg.Discovery = &DiscoveryConfig{}
+		if err := json.Unmarshal([]byte(discoveryJSON), cfg.Discovery); err != nil {
+			return nil, fmt.Errorf("failed to parse DISCOVERY_JSON: %w", err)
+		}
+		if err := validateDiscovery(cfg.Discovery); err != nil {
+			return nil, fmt.Errorf("DISCOVERY_JSON: %w", err)
+		}
+	}
+
+	if organizationsJSON := os.Getenv("ORGANIZATIONS_JSON"); organizationsJSON != "" {
+		cfg.Organizations = &OrganizationsConfig{}
+		if err := json.Unmarshal([]byte(organizationsJSON), cfg.Organizations); err != nil {
+			return nil, fmt.Errorf("failed to parse ORGANIZATIONS_JSON: %w", err)
+		}
+		if err := validateOrganizations(cfg.Organizations); err != nil {
+			return nil, fmt.Errorf("ORGANIZATIONS_JSON: %w", err)
+		}
+	}
+
+	if resultRulesJSON := os.Getenv("RESULT_RULES_JSON"); resultRulesJSON != "" {
+		if err := json.Unmarshal([]byte(resultRulesJSON), &cfg.ResultRules); err != nil {
+			return nil, fmt.Errorf("failed to parse RESULT_RULES_JSON: %w", err)
+		}
+		if err := validateResultRules(cfg.ResultRules); err != nil {
+			return nil, fmt.Errorf("RESULT_RULES_JSON: %w", err)
+		}
+	}
+
+	if pairsJSON := os.Getenv("ENDPOINT_PAIRS_JSON"); pairsJSON != "" {
+		if err := json.Unmarshal([]byte(pairsJSON), &cfg.EndpointPairs); err != nil {
+			return nil, fmt.Errorf("failed to parse ENDPOINT_PAIRS_JSON: %w", err)
+		}
+		if err := validateEndpointPairs(cfg.EndpointPairs); err != nil {
+			return nil, fmt.Errorf("ENDPOINT_PAIRS_JSON: %w", err)
+		}
+	}
+
+	rules, err := loadEndpointRules()
+	if err != nil {
+		return nil, err
+	}
+	cfg.EndpointRules = rules
+	if cfg.CloudWatch != nil {
+		*cfg.CloudWatch = rules.applyCloudWatch(*cfg.CloudWatch)
 	}
 
 	// Try to load multiple endpoints from JSON config first
@@ -61,7 +577,7 @@ func LoadConfig() (*Config, error) {
 			return nil, fmt.Errorf("failed to parse S3_ENDPOINTS_JSON: %w", err)
 		}
 
-		if len(endpoints) == 0 {
+		if len(endpoints) == 0 && !cfg.discovers() {
 			return nil, fmt.Errorf("S3_ENDPOINTS_JSON must contain at least one endpoint")
 		}
 
@@ -73,10 +589,95 @@ func LoadConfig() (*Config, error) {
 			if endpoints[i].Region == "" {
 				endpoints[i].Region = DefaultS3Region
 			}
+			if endpoints[i].ProbeDepth == "" {
+				endpoints[i].ProbeDepth = ProbeDepthShallow
+			}
+			if endpoints[i].IPFamily == "" {
+				endpoints[i].IPFamily = IPFamilyAuto
+			}
+			if endpoints[i].Severity == "" {
+				endpoints[i].Severity = SeverityWarning
+			}
+			endpoints[i] = cfg.EndpointRules.Apply(endpoints[i])
 			// Validate required fields
-			if endpoints[i].Bucket == "" || endpoints[i].AccessKey == "" || endpoints[i].SecretKey == "" {
+			if endpoints[i].Plugin != nil {
+				if err := validatePlugin(endpoints[i]); err != nil {
+					return nil, fmt.Errorf("endpoint %d: %w", i, err)
+				}
+			} else if endpoints[i].Bucket == "" || endpoints[i].Rotation == nil && (endpoints[i].AccessKey == "" || endpoints[i].SecretKey == "") {
+				// Rotated endpoints read their keys from the rotation store
 				return nil, fmt.Errorf("endpoint %d: bucket, access_key, and secret_key are required", i)
 			}
+			if !validProbeDepth(endpoints[i].ProbeDepth) {
+				return nil, fmt.Errorf("endpoint %d: probe_depth must be %q or %q, got %q", i, ProbeDepthShallow, ProbeDepthDeep, endpoints[i].ProbeDepth)
+			}
+			if !validIPFamily(endpoints[i].IPFamily) {
+				return nil, fmt.Errorf("endpoint %d: ip_family must be %q, %q or %q, got %q", i, IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6, endpoints[i].IPFamily)
+			}
+			if !validSeverity(endpoints[i].Severity) {
+				return nil, fmt.Errorf("endpoint %d: severity must be %q, %q or %q, got %q", i, SeverityCritical, SeverityWarning, SeverityInfo, endpoints[i].Severity)
+			}
+			if err := validateRequestHeaders(endpoints[i].RequestHeaders); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateDNS(endpoints[i].DNSServers, endpoints[i].Resolve); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateSOCKS5Proxy(endpoints[i].SOCKS5Proxy); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateClientCert(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if _, err := ParsePrivateCIDRs(endpoints[i].PrivateCIDRs); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateRetry(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateHedge(&endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateRoundTrip(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateSuccess(&endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateChecks(endpoints[i].Checks); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateDirectoryBucket(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateEndpointVariants(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateFIPSMode(endpoints[i], cfg.FIPSMode); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateBucketARN(endpoints[i]); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			endpoints[i].ChecksumAlgorithm = strings.ToUpper(endpoints[i].ChecksumAlgorithm)
+			if err := validateChecksumAlgorithm(endpoints[i].ChecksumAlgorithm); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateKeyAge(endpoints[i], time.Now()); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateSecondary(endpoints[i].Secondary); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateRotation(&endpoints[i], cfg.KeyMaxAge); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateExpectedPermissions(endpoints[i].ExpectedPermissions); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
+			if err := validateRoleARN(endpoints[i].RoleARN); err != nil {
+				return nil, fmt.Errorf("endpoint %d: %w", i, err)
+			}
 		}
 
 		cfg.Endpoints = endpoints
@@ -84,15 +685,98 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Fall back to legacy single endpoint configuration
+	envMaps := make(map[string]map[string]string)
+	for _, key := range []string{"S3_REQUEST_HEADERS", "S3_RESOLVE", "S3_LABELS", "S3_ANNOTATIONS", "S3_EXPECTED_PERMISSIONS"} {
+		values, err := getEnvMap(key)
+		if err != nil {
+			return nil, err
+		}
+		envMaps[key] = values
+	}
 	singleEndpoint := S3EndpointConfig{
-		Endpoint:           getEnv("S3_ENDPOINT", ""),
-		Region:             getEnv("S3_REGION", DefaultS3Region),
-		Bucket:             getEnv("S3_BUCKET", ""),
-		AccessKey:          getEnv("S3_ACCESS_KEY", ""),
-		SecretKey:          getEnv("S3_SECRET_KEY", ""),
-		SessionToken:       getEnv("S3_SESSION_TOKEN", ""),
-		UsePathStyle:       getEnvBool("S3_USE_PATH_STYLE", false),
-		InsecureSkipVerify: getEnvBool("S3_INSECURE_SKIP_VERIFY", false),
+		Endpoint:            getEnv("S3_ENDPOINT", ""),
+		Region:              getEnv("S3_REGION", DefaultS3Region),
+		Bucket:              getEnv("S3_BUCKET", ""),
+		AccessKey:           getEnv("S3_ACCESS_KEY", ""),
+		SecretKey:           getEnv("S3_SECRET_KEY", ""),
+		SessionToken:        getEnv("S3_SESSION_TOKEN", ""),
+		UsePathStyle:        getEnvBool("S3_USE_PATH_STYLE", false),
+		UseAccelerate:       getEnvBool("S3_USE_ACCELERATE", false),
+		UseDualStack:        getEnvBool("S3_USE_DUAL_STACK", false),
+		UseFIPSEndpoint:     getEnvBool("S3_USE_FIPS_ENDPOINT", false),
+		UseARNRegion:        getEnvBool("S3_USE_ARN_REGION", false),
+		ChecksumAlgorithm:   strings.ToUpper(getEnv("S3_CHECKSUM_ALGORITHM", "")),
+		InsecureSkipVerify:  getEnvBool("S3_INSECURE_SKIP_VERIFY", false),
+		ProbeDepth:          getEnv("S3_PROBE_DEPTH", ProbeDepthShallow),
+		FallbackRegions:     getEnvList("S3_FALLBACK_REGIONS"),
+		Provider:            getEnv("S3_PROVIDER", ""),
+		UserAgent:           getEnv("S3_USER_AGENT", ""),
+		RequestHeaders:      envMaps["S3_REQUEST_HEADERS"],
+		IPFamily:            getEnv("S3_IP_FAMILY", IPFamilyAuto),
+		DNSServers:          getEnvList("S3_DNS_SERVERS"),
+		Resolve:             envMaps["S3_RESOLVE"],
+		Severity:            getEnv("S3_SEVERITY", SeverityWarning),
+		Labels:              envMaps["S3_LABELS"],
+		Annotations:         envMaps["S3_ANNOTATIONS"],
+		KeyAgeFromIAM:       getEnvBool("S3_KEY_AGE_FROM_IAM", false),
+		IAMEndpoint:         getEnv("S3_IAM_ENDPOINT", ""),
+		KeyMaxAge:           Duration(getEnvDuration("S3_KEY_MAX_AGE", 0)),
+		ExpectedPermissions: envMaps["S3_EXPECTED_PERMISSIONS"],
+		RoleARN:             getEnv("S3_ROLE_ARN", ""),
+		ExternalID:          getEnv("S3_EXTERNAL_ID", ""),
+		STSEndpoint:         getEnv("S3_STS_ENDPOINT", ""),
+		AccountID:           getEnv("S3_ACCOUNT_ID", ""),
+		ClientCert:          getEnv("S3_CLIENT_CERT", ""),
+		ClientKey:           getEnv("S3_CLIENT_KEY", ""),
+		PrivateCIDRs:        getEnvList("S3_PRIVATE_CIDRS"),
+		RetryMode:           getEnv("S3_RETRY_MODE", ""),
+		MaxAttempts:         getEnvInt("S3_MAX_ATTEMPTS", 0),
+		MaxBackoff:          Duration(getEnvDuration("S3_MAX_BACKOFF", 0)),
+	}
+
+	if createdAt := getEnv("S3_KEY_CREATED_AT", ""); createdAt != "" {
+		parsed, err := parseTimestamp(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("S3_KEY_CREATED_AT: %w", err)
+		}
+		singleEndpoint.KeyCreatedAt = Timestamp(parsed)
+	}
+
+	if checksJSON := os.Getenv("S3_CHECKS_JSON"); checksJSON != "" {
+		singleEndpoint.Checks = &ChecksConfig{}
+		if err := json.Unmarshal([]byte(checksJSON), singleEndpoint.Checks); err != nil {
+			return nil, fmt.Errorf("failed to parse S3_CHECKS_JSON: %w", err)
+		}
+	}
+
+	if secondaryKey := getEnv("S3_SECONDARY_ACCESS_KEY", ""); secondaryKey != "" {
+		singleEndpoint.Secondary = &Credentials{
+			AccessKey:    secondaryKey,
+			SecretKey:    getEnv("S3_SECONDARY_SECRET_KEY", ""),
+			SessionToken: getEnv("S3_SECONDARY_SESSION_TOKEN", ""),
+		}
+	}
+
+	if rotationJSON := os.Getenv("S3_ROTATION_JSON"); rotationJSON != "" {
+		singleEndpoint.Rotation = &RotationConfig{}
+		if err := json.Unmarshal([]byte(rotationJSON), singleEndpoint.Rotation); err != nil {
+			return nil, fmt.Errorf("failed to parse S3_ROTATION_JSON: %w", err)
+		}
+	}
+
+	if proxyAddress := getEnv("S3_SOCKS5_PROXY", ""); proxyAddress != "" {
+		singleEndpoint.SOCKS5Proxy = &SOCKS5Proxy{
+			Address:  proxyAddress,
+			Username: getEnv("S3_SOCKS5_USERNAME", ""),
+			Password: getEnv("S3_SOCKS5_PASSWORD", ""),
+		}
+	}
+
+	singleEndpoint = cfg.EndpointRules.Apply(singleEndpoint)
+
+	// Discovery alone is enough to run
+	if singleEndpoint.Bucket == "" && cfg.discovers() {
+		return cfg, nil
 	}
 
 	// Validate required fields for legacy mode
@@ -100,20 +784,180 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("S3_BUCKET environment variable is required (or use S3_ENDPOINTS_JSON for multiple endpoints)")
 	}
 
-	if singleEndpoint.AccessKey == "" {
+	// Rotated keys are read from the rotation store instead
+	if singleEndpoint.AccessKey == "" && singleEndpoint.Rotation == nil {
 		return nil, fmt.Errorf("S3_ACCESS_KEY environment variable is required")
 	}
 
-	if singleEndpoint.SecretKey == "" {
+	if singleEndpoint.SecretKey == "" && singleEndpoint.Rotation == nil {
 		return nil, fmt.Errorf("S3_SECRET_KEY environment variable is required")
 	}
 
+	if !validProbeDepth(singleEndpoint.ProbeDepth) {
+		return nil, fmt.Errorf("S3_PROBE_DEPTH must be %q or %q, got %q", ProbeDepthShallow, ProbeDepthDeep, singleEndpoint.ProbeDepth)
+	}
+
+	if !validIPFamily(singleEndpoint.IPFamily) {
+		return nil, fmt.Errorf("S3_IP_FAMILY must be %q, %q or %q, got %q", IPFamilyAuto, IPFamilyIPv4, IPFamilyIPv6, singleEndpoint.IPFamily)
+	}
+
+	if !validSeverity(singleEndpoint.Severity) {
+		return nil, fmt.Errorf("S3_SEVERITY must be %q, %q or %q, got %q", SeverityCritical, SeverityWarning, SeverityInfo, singleEndpoint.Severity)
+	}
+
+	if err := validateRequestHeaders(singleEndpoint.RequestHeaders); err != nil {
+		return nil, fmt.Errorf("S3_REQUEST_HEADERS: %w", err)
+	}
+
+	if err := validateDNS(singleEndpoint.DNSServers, singleEndpoint.Resolve); err != nil {
+		return nil, fmt.Errorf("S3_DNS_SERVERS/S3_RESOLVE: %w", err)
+	}
+
+	if err := validateSOCKS5Proxy(singleEndpoint.SOCKS5Proxy); err != nil {
+		return nil, fmt.Errorf("S3_SOCKS5_PROXY: %w", err)
+	}
+
+	if err := validateClientCert(singleEndpoint); err != nil {
+		return nil, fmt.Errorf("S3_CLIENT_CERT/S3_CLIENT_KEY: %w", err)
+	}
+
+	if _, err := ParsePrivateCIDRs(singleEndpoint.PrivateCIDRs); err != nil {
+		return nil, fmt.Errorf("S3_PRIVATE_CIDRS: %w", err)
+	}
+
+	if err := validateRetry(singleEndpoint); err != nil {
+		return nil, fmt.Errorf("S3_RETRY_MODE/S3_MAX_ATTEMPTS/S3_MAX_BACKOFF: %w", err)
+	}
+
+	if err := validateChecks(singleEndpoint.Checks); err != nil {
+		return nil, fmt.Errorf("S3_CHECKS_JSON: %w", err)
+	}
+
+	if err := validateDirectoryBucket(singleEndpoint); err != nil {
+		return nil, fmt.Errorf("S3_BUCKET: %w", err)
+	}
+
+	if err := validateEndpointVariants(singleEndpoint); err != nil {
+		return nil, fmt.Errorf("S3_USE_ACCELERATE/S3_USE_DUAL_STACK/S3_USE_FIPS_ENDPOINT: %w", err)
+	}
+
+	if err := validateFIPSMode(singleEndpoint, cfg.FIPSMode); err != nil {
+		return nil, fmt.Errorf("S3_INSECURE_SKIP_VERIFY: %w", err)
+	}
+
+	if err := validateBucketARN(singleEndpoint); err != nil {
+		return nil, fmt.Errorf("S3_BUCKET: %w", err)
+	}
+
+	if err := validateRoleARN(singleEndpoint.RoleARN); err != nil {
+		return nil, fmt.Errorf("S3_ROLE_ARN: %w", err)
+	}
+
+	if err := validateChecksumAlgorithm(singleEndpoint.ChecksumAlgorithm); err != nil {
+		return nil, fmt.Errorf("S3_CHECKSUM_ALGORITHM: %w", err)
+	}
+
+	if err := validateSecondary(singleEndpoint.Secondary); err != nil {
+		return nil, fmt.Errorf("S3_SECONDARY_ACCESS_KEY: %w", err)
+	}
+
+	if err := validateKeyAge(singleEndpoint, time.Now()); err != nil {
+		return nil, fmt.Errorf("S3_KEY_CREATED_AT/S3_KEY_AGE_FROM_IAM: %w", err)
+	}
+
+	if err := validateRotation(&singleEndpoint, cfg.KeyMaxAge); err != nil {
+		return nil, fmt.Errorf("S3_ROTATION_JSON: %w", err)
+	}
+
+	if err := validateExpectedPermissions(singleEndpoint.ExpectedPermissions); err != nil {
+		return nil, fmt.Errorf("S3_EXPECTED_PERMISSIONS: %w", err)
+	}
+
 	singleEndpoint.Name = singleEndpoint.Bucket
 	cfg.Endpoints = []S3EndpointConfig{singleEndpoint}
 
 	return cfg, nil
 }
 
+func validProbeDepth(depth string) bool {
+	return depth == ProbeDepthShallow || depth == ProbeDepthDeep
+}
+
+func validIPFamily(family string) bool {
+	return family == IPFamilyAuto || family == IPFamilyIPv4 || family == IPFamilyIPv6
+}
+
+// validateRequestHeaders rejects headers that would break request signing or routing
+func validateRequestHeaders(headers map[string]string) error {
+	for name := range headers {
+		switch strings.ToLower(name) {
+		case "":
+			return fmt.Errorf("request header names cannot be empty")
+		case "authorization", "host", "user-agent":
+			return fmt.Errorf("request header %q cannot be overridden", name)
+		}
+	}
+	return nil
+}
+
+// validateDNS checks that DNS servers and static host mappings use IP addresses
+func validateDNS(servers []string, resolve map[string]string) error {
+	for _, server := range servers {
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("dns server %q must be an IP address with optional port", server)
+		}
+	}
+	for host, ip := range resolve {
+		if host == "" {
+			return fmt.Errorf("resolve entries need a hostname")
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("resolve entry for %q must map to an IP address, got %q", host, ip)
+		}
+	}
+	return nil
+}
+
+// validateSecondary requires both halves of the secondary key pair
+func validateSecondary(secondary *Credentials) error {
+	if secondary == nil {
+		return nil
+	}
+	if secondary.AccessKey == "" || secondary.SecretKey == "" {
+		return fmt.Errorf("secondary credentials need access_key and secret_key")
+	}
+	return nil
+}
+
+// validateSOCKS5Proxy requires a host:port address and a username when a password is set
+// checksumAlgorithms are the additional checksums S3 accepts on uploads
+var checksumAlgorithms = []string{"CRC32", "CRC32C", "CRC64NVME", "SHA1", "SHA256"}
+
+// validateChecksumAlgorithm rejects checksum algorithms S3 does not know
+func validateChecksumAlgorithm(algorithm string) error {
+	if algorithm == "" || slices.Contains(checksumAlgorithms, algorithm) {
+		return nil
+	}
+	return fmt.Errorf("checksum_algorithm must be one of %s, got %q", strings.Join(checksumAlgorithms, ", "), algorithm)
+}
+
+func validateSOCKS5Proxy(proxy *SOCKS5Proxy) error {
+	if proxy == nil {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(proxy.Address); err != nil {
+		return fmt.Errorf("socks5 proxy address must be host:port, got %q", proxy.Address)
+	}
+	if proxy.Password != "" && proxy.Username == "" {
+		return fmt.Errorf("socks5 proxy password requires a username")
+	}
+	return nil
+}
+
 func loadDotEnv() error {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -152,6 +996,17 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		floatVal, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return defaultValue
+		}
+		return floatVal
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		duration, err := time.ParseDuration(value)
@@ -163,6 +1018,85 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// validateCORSOrigins accepts "*" or origins in the scheme://host[:port] form browsers send
+func validateCORSOrigins(origins []string) error {
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS: %q must be \"*\" or an origin such as https://dashboard.example.com", origin)
+		}
+	}
+	return nil
+}
+
+// getEnvList splits a comma-separated variable, dropping empty items
+func getEnvList(key string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvCIDRs parses a comma-separated list of CIDRs; a bare address is a single host
+func getEnvCIDRs(key string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range getEnvList(key) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid CIDR %q", key, item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// getEnvFloatList parses a comma-separated list of numbers
+func getEnvFloatList(key string) ([]float64, error) {
+	var values []float64
+	for _, item := range getEnvList(key) {
+		value, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid number %q", key, item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// getEnvMap parses a comma-separated list of key=value pairs
+func getEnvMap(key string) (map[string]string, error) {
+	items := getEnvList(key)
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(items))
+	for _, item := range items {
+		k, v, found := strings.Cut(item, "=")
+		k = strings.TrimSpace(k)
+		if !found || k == "" {
+			return nil, fmt.Errorf("%s: invalid entry %q, expected key=value", key, item)
+		}
+		values[k] = strings.TrimSpace(v)
+	}
+	return values, nil
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		switch value {
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -169,3 +176,1738 @@ func TestLoadConfig_LoadsDotEnv(t *testing.T) {
 		t.Fatalf("expected auto interval from .env, got %v", cfg.AutoValidateInterval)
 	}
 }
+
+func TestLoadConfig_ProbeDepth(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"cheap","bucket":"a","access_key":"AK","secret_key":"SK"},{"name":"thorough","bucket":"b","access_key":"AK","secret_key":"SK","probe_depth":"deep"}]`)
+	t.Setenv("DEEP_VALIDATE_INTERVAL", "90m")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Endpoints[0].ProbeDepth != ProbeDepthShallow {
+		t.Fatalf("expected probe depth to default to shallow, got %s", cfg.Endpoints[0].ProbeDepth)
+	}
+	if cfg.Endpoints[1].ProbeDepth != ProbeDepthDeep {
+		t.Fatalf("expected deep probe depth, got %s", cfg.Endpoints[1].ProbeDepth)
+	}
+	if cfg.DeepValidateInterval != 90*time.Minute {
+		t.Fatalf("expected deep interval 90m, got %v", cfg.DeepValidateInterval)
+	}
+}
+
+func TestLoadConfig_InvalidProbeDepth(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","probe_depth":"medium"}]`)
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown probe depth")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_PROBE_DEPTH", "medium")
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown legacy probe depth")
+	}
+}
+
+func TestLoadConfig_FallbackRegions(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","fallback_regions":["us-west-2","eu-west-1"]}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.Endpoints[0].FallbackRegions; len(got) != 2 || got[0] != "us-west-2" || got[1] != "eu-west-1" {
+		t.Fatalf("unexpected fallback regions: %v", got)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_FALLBACK_REGIONS", "us-west-2, ,eu-west-1")
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.Endpoints[0].FallbackRegions; len(got) != 2 || got[1] != "eu-west-1" {
+		t.Fatalf("unexpected legacy fallback regions: %v", got)
+	}
+}
+
+func TestLoadConfig_LogMode(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.LogMode != LogModeAll {
+		t.Fatalf("expected default log mode %q, got %q", LogModeAll, cfg.LogMode)
+	}
+
+	t.Setenv("LOG_MODE", "changes")
+	if cfg, err = LoadConfig(); err != nil || cfg.LogMode != LogModeChanges {
+		t.Fatalf("expected changes log mode, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("LOG_MODE", "verbose")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown log mode")
+	}
+}
+
+func TestLoadConfig_ReadOnly(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.ReadOnly {
+		t.Fatalf("expected read-only to be off by default, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("READ_ONLY", "true")
+	if cfg, err = LoadConfig(); err != nil || !cfg.ReadOnly {
+		t.Fatalf("expected read-only mode, got %v (err %v)", cfg, err)
+	}
+}
+
+func TestLoadConfig_ValidateRequestTimeout(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.ValidateRequestTimeout != DefaultValidateRequestTimeout {
+		t.Fatalf("expected the default request budget, got %v (err %v)", cfg, err)
+	}
+	if DefaultValidateRequestTimeout >= HTTPWriteTimeout {
+		t.Fatalf("expected the default budget %s to leave time within the write timeout %s", DefaultValidateRequestTimeout, HTTPWriteTimeout)
+	}
+
+	t.Setenv("VALIDATE_REQUEST_TIMEOUT", "45s")
+	if cfg, err = LoadConfig(); err != nil || cfg.ValidateRequestTimeout != 45*time.Second {
+		t.Fatalf("expected a 45s request budget, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("VALIDATE_REQUEST_TIMEOUT", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected an unbounded request budget to be rejected")
+	}
+}
+
+func TestLoadConfig_IdempotencyKeyTTL(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.IdempotencyKeyTTL != DefaultIdempotencyKeyTTL {
+		t.Fatalf("expected the default idempotency key TTL, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("IDEMPOTENCY_KEY_TTL", "0")
+	if cfg, err = LoadConfig(); err != nil || cfg.IdempotencyKeyTTL != 0 {
+		t.Fatalf("expected idempotency keys to be disabled, got %v (err %v)", cfg, err)
+	}
+}
+
+func TestLoadConfig_Histograms(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("RESPONSE_TIME_BUCKETS", "0.05, 0.1,0.5")
+	t.Setenv("RESPONSE_TIME_MS_COMPAT", "true")
+	t.Setenv("NATIVE_HISTOGRAMS", "true")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.ResponseTimeBuckets) != 3 || cfg.ResponseTimeBuckets[1] != 0.1 || !cfg.ResponseTimeMsCompat || !cfg.NativeHistograms {
+		t.Fatalf("unexpected histogram settings %+v", cfg)
+	}
+
+	for _, buckets := range []string{"0.1,fast", "0.5,0.1", "0,1"} {
+		t.Setenv("RESPONSE_TIME_BUCKETS", buckets)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected RESPONSE_TIME_BUCKETS=%q to be rejected", buckets)
+		}
+	}
+}
+
+func TestLoadConfig_ExpectedPermissions(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","expected_permissions":{"ListObjectsV2":"allowed","DeleteObject":"denied"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.Endpoints[0].ExpectedPermissions["DeleteObject"]; got != PermissionDenied {
+		t.Fatalf("expected DeleteObject to be expected denied, got %q", got)
+	}
+	if cfg.PermissionCheckInterval != DefaultPermissionCheckInterval {
+		t.Fatalf("expected default permission check interval, got %s", cfg.PermissionCheckInterval)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","expected_permissions":{"DeleteBucket":"denied"}}]`)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "unknown operation") {
+		t.Fatalf("expected unknown operation error, got %v", err)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","expected_permissions":{"PutObject":"maybe"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for an invalid verdict")
+	}
+}
+
+func TestLoadConfig_LegacyExpectedPermissions(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "a")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_EXPECTED_PERMISSIONS", "GetObject=allowed,PutObject=denied")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.Endpoints[0].ExpectedPermissions; len(got) != 2 || got["PutObject"] != PermissionDenied {
+		t.Fatalf("unexpected expected permissions %v", got)
+	}
+}
+
+func TestLoadConfig_RequestTagging(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","user_agent":"storage-monitor/1.0","request_headers":{"X-Team":"platform"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].UserAgent != "storage-monitor/1.0" {
+		t.Fatalf("unexpected user agent: %s", cfg.Endpoints[0].UserAgent)
+	}
+	if cfg.Endpoints[0].RequestHeaders["X-Team"] != "platform" {
+		t.Fatalf("unexpected request headers: %v", cfg.Endpoints[0].RequestHeaders)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","request_headers":{"Authorization":"nope"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when overriding Authorization")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_REQUEST_HEADERS", "X-Team=platform, X-Env = prod")
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.Endpoints[0].RequestHeaders; got["X-Team"] != "platform" || got["X-Env"] != "prod" {
+		t.Fatalf("unexpected legacy request headers: %v", got)
+	}
+
+	t.Setenv("S3_REQUEST_HEADERS", "X-Team=platform, X-Env")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "S3_REQUEST_HEADERS") {
+		t.Fatalf("expected an error for a header without a value, got %v", err)
+	}
+}
+
+func TestLoadConfig_AccessLogCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"interval":"30m","timeout":"5m","access_log":{"target_bucket":"logs","target_prefix":"a/","delay":"2h"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	checks := cfg.Endpoints[0].Checks
+	if checks == nil || time.Duration(checks.Interval) != 30*time.Minute || time.Duration(checks.Timeout) != 5*time.Minute {
+		t.Fatalf("unexpected checks config: %+v", checks)
+	}
+	if checks.AccessLog.TargetBucket != "logs" || time.Duration(checks.AccessLog.Delay) != 2*time.Hour {
+		t.Fatalf("unexpected access log config: %+v", checks.AccessLog)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"access_log":{"target_prefix":"a/"}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when target_bucket is missing")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"interval":"soon"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for invalid duration")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"timeout":"-1m"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a negative timeout")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_CHECKS_JSON", `{"access_log":{"target_bucket":"logs"}}`)
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].Checks == nil || cfg.Endpoints[0].Checks.AccessLog.TargetBucket != "logs" {
+		t.Fatalf("unexpected legacy checks config: %+v", cfg.Endpoints[0].Checks)
+	}
+}
+
+func TestLoadConfig_ObjectLockCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"object_lock":{"mode":"compliance","days":365}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if lock := cfg.Endpoints[0].Checks.ObjectLock; lock == nil || lock.Days != 365 {
+		t.Fatalf("unexpected object lock config: %+v", lock)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"object_lock":{"mode":"forever"}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown retention mode")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"object_lock":{"days":30,"years":1}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when both days and years are set")
+	}
+}
+
+func TestLoadConfig_TagsCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"tags":{"required":["cost-center","owner"]}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tags := cfg.Endpoints[0].Checks.Tags; tags == nil || len(tags.Required) != 2 {
+		t.Fatalf("unexpected tags config: %+v", tags)
+	}
+
+	for _, tags := range []string{`{}`, `{"required":["owner",""]}`} {
+		t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"tags":`+tags+`}}]`)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected tags %s to be rejected", tags)
+		}
+	}
+}
+
+func TestLoadConfig_CORSCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"cors":{"rules":[{"allowed_origins":["https://www.example.com"],"allowed_methods":["GET","HEAD"],"max_age_seconds":3000}],"origin":"https://www.example.com"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cors := cfg.Endpoints[0].Checks.CORS; cors == nil || len(cors.Rules) != 1 || cors.Rules[0].MaxAgeSeconds != 3000 {
+		t.Fatalf("unexpected cors config: %+v", cors)
+	}
+
+	for _, cors := range []string{
+		`{}`,
+		`{"rules":[{"allowed_origins":["*"]}]}`,
+		`{"rules":[{"allowed_origins":["*"],"allowed_methods":["PATCH"]}]}`,
+		`{"rules":[{"allowed_origins":["*"],"allowed_methods":["GET"]}],"url":"https://cdn.example.com/"}`,
+		`{"origin":"https://www.example.com","url":"cdn.example.com"}`,
+	} {
+		t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"cors":`+cors+`}}]`)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected cors %s to be rejected", cors)
+		}
+	}
+}
+
+func TestLoadConfig_WebsiteCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"website":{"index_document":"index.html"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ws := cfg.Endpoints[0].Checks.Website; ws == nil || ws.IndexDocument != "index.html" {
+		t.Fatalf("unexpected website config: %+v", ws)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"website":{"url":"www.example.com"}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a website url without scheme")
+	}
+}
+
+func TestLoadConfig_PublicAccessCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"public_access":true}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.Endpoints[0].Checks.PublicAccess {
+		t.Fatalf("expected public access check to be enabled")
+	}
+}
+
+func TestLoadConfig_KMSCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"kms":{"key_id":"alias/backups"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if kms := cfg.Endpoints[0].Checks.KMS; kms == nil || kms.KeyID != "alias/backups" {
+		t.Fatalf("unexpected kms config: %+v", kms)
+	}
+}
+
+func TestLoadConfig_RestoreCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"restore":{"key":"dr/backup.tar"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if restore := cfg.Endpoints[0].Checks.Restore; restore == nil || restore.Key != "dr/backup.tar" {
+		t.Fatalf("unexpected restore config: %+v", restore)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"restore":{}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected a restore check without a key to be rejected")
+	}
+}
+
+func TestLoadConfig_InventoryCheck(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"inventory":{"prefix":"backups/","sample_size":500}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if inv := cfg.Endpoints[0].Checks.Inventory; inv == nil || inv.Prefix != "backups/" || inv.SampleSize != 500 {
+		t.Fatalf("unexpected inventory config: %+v", inv)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checks":{"inventory":{"sample_size":-1}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected a negative sample size to be rejected")
+	}
+}
+
+func TestLoadConfig_ProbePricing(t *testing.T) {
+	t.Setenv("S3_BUCKET", "a")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("PROBE_PRICING_JSON", `{"default":{"class_a_per_1000":0.005,"class_b_per_1000":0.0004}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if price := cfg.ProbePricing[DefaultPricingProvider]; price.ClassA != 0.005 || price.ClassB != 0.0004 {
+		t.Fatalf("unexpected pricing: %+v", cfg.ProbePricing)
+	}
+
+	t.Setenv("PROBE_PRICING_JSON", `{"aws":{"class_a_per_1000":-1}}`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected negative prices to be rejected")
+	}
+}
+
+func TestLoadConfig_DiscoveryOnly(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("DISCOVERY_JSON", `{"access_key":"AK","secret_key":"SK","tags":{"monitor":"true"},"role_arn":"arn:aws:iam::123456789012:role/monitor","template":{"probe_depth":"deep"}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected discovery alone to be enough, got %v", err)
+	}
+	if len(cfg.Endpoints) != 0 || cfg.Discovery == nil {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Discovery.Interval != Duration(DefaultDiscoveryInterval) || cfg.Discovery.Region != DefaultS3Region {
+		t.Fatalf("expected discovery defaults, got %+v", cfg.Discovery)
+	}
+
+	for name, value := range map[string]string{
+		"no tags":       `{"access_key":"AK","secret_key":"SK"}`,
+		"no keys":       `{"tags":{"monitor":"true"}}`,
+		"bad role":      `{"access_key":"AK","secret_key":"SK","tags":{"monitor":"true"},"role_arn":"monitor"}`,
+		"template keys": `{"access_key":"AK","secret_key":"SK","tags":{"monitor":"true"},"template":{"bucket":"x"}}`,
+	} {
+		t.Setenv("DISCOVERY_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestLoadConfig_ClientCert(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"b","access_key":"AK","secret_key":"SK","client_cert":"`+certFile+`","client_key":"`+keyFile+`"}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Endpoints[0].ClientCert != certFile || cfg.Endpoints[0].ClientKey != keyFile {
+		t.Fatalf("expected the client certificate, got %+v", cfg.Endpoints[0])
+	}
+
+	for _, value := range []string{
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","client_cert":"` + certFile + `"}]`,
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","client_cert":"` + keyFile + `","client_key":"` + certFile + `"}]`,
+	} {
+		t.Setenv("S3_ENDPOINTS_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", value)
+		}
+	}
+}
+
+func TestLoadConfig_FakeS3(t *testing.T) {
+	t.Setenv("FAKE_S3", "true")
+	t.Setenv("FAKE_S3_FAILURES_JSON", `{"prod":{"error":"throttled","every":3,"operations":["PutObject"]},"dr":{"latency":"2s"}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if !cfg.FakeS3.Enabled || cfg.FakeS3.Address != DefaultFakeS3Address {
+		t.Fatalf("expected fake S3 mode on the default address, got %+v", cfg.FakeS3)
+	}
+	if prod := cfg.FakeS3.Failures["prod"]; prod.Error != "throttled" || prod.Every != 3 || time.Duration(cfg.FakeS3.Failures["dr"].Latency) != 2*time.Second {
+		t.Fatalf("unexpected failures %+v", cfg.FakeS3.Failures)
+	}
+
+	for _, value := range []string{`{"prod":{"error":"exploded"}}`, `{"prod":{}}`, `{"prod":{"error":"timeout","every":-1}}`} {
+		t.Setenv("FAKE_S3_FAILURES_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", value)
+		}
+	}
+}
+
+func TestLoadConfig_Organizations(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("ORGANIZATIONS_JSON", `{"access_key":"AK","secret_key":"SK","role_name":"s3-key-audit","buckets":[{"bucket":"cloudtrail-{account_id}"}]}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected the organization sweep alone to be enough, got %v", err)
+	}
+	org := cfg.Organizations
+	if org.Endpoint != "" || org.Region != DefaultS3Region || org.Partition != "aws" || org.Buckets[0].Name != "cloudtrail-{account_id}" || org.Buckets[0].ProbeDepth != ProbeDepthShallow {
+		t.Fatalf("expected organization defaults, got %+v", org)
+	}
+
+	for name, value := range map[string]string{
+		"no role":     `{"access_key":"AK","secret_key":"SK","buckets":[{"bucket":"b"}]}`,
+		"no buckets":  `{"access_key":"AK","secret_key":"SK","role_name":"audit"}`,
+		"bad account": `{"access_key":"AK","secret_key":"SK","role_name":"audit","accounts":["123"],"buckets":[{"bucket":"b"}]}`,
+		"bucket keys": `{"access_key":"AK","secret_key":"SK","role_name":"audit","buckets":[{"bucket":"b","access_key":"X"}]}`,
+		"duplicate":   `{"access_key":"AK","secret_key":"SK","role_name":"audit","buckets":[{"bucket":"b"},{"bucket":"b"}]}`,
+	} {
+		t.Setenv("ORGANIZATIONS_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestLoadConfig_IPFamily(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","ip_family":"ipv6"},{"bucket":"b","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].IPFamily != IPFamilyIPv6 || cfg.Endpoints[1].IPFamily != IPFamilyAuto {
+		t.Fatalf("unexpected ip families: %q, %q", cfg.Endpoints[0].IPFamily, cfg.Endpoints[1].IPFamily)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","ip_family":"ipx"}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown ip family")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_IP_FAMILY", "ipv4")
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].IPFamily != IPFamilyIPv4 {
+		t.Fatalf("unexpected legacy ip family: %q", cfg.Endpoints[0].IPFamily)
+	}
+}
+
+func TestLoadConfig_DNS(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","dns_servers":["10.0.0.2","10.0.0.3:5353"],"resolve":{"s3.new.example.com":"10.1.2.3"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.Endpoints[0].DNSServers) != 2 || cfg.Endpoints[0].Resolve["s3.new.example.com"] != "10.1.2.3" {
+		t.Fatalf("unexpected dns settings: %v %v", cfg.Endpoints[0].DNSServers, cfg.Endpoints[0].Resolve)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","dns_servers":["dns.example.com"]}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a hostname dns server")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","resolve":{"s3.new.example.com":"gateway"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a non-IP resolve target")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_DNS_SERVERS", "10.0.0.2")
+	t.Setenv("S3_RESOLVE", "s3.new.example.com=10.1.2.3")
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].DNSServers[0] != "10.0.0.2" || cfg.Endpoints[0].Resolve["s3.new.example.com"] != "10.1.2.3" {
+		t.Fatalf("unexpected legacy dns settings: %v %v", cfg.Endpoints[0].DNSServers, cfg.Endpoints[0].Resolve)
+	}
+}
+
+func TestLoadConfig_SOCKS5Proxy(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","socks5_proxy":{"address":"bastion:1080","username":"monitor","password":"secret"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if proxy := cfg.Endpoints[0].SOCKS5Proxy; proxy == nil || proxy.Address != "bastion:1080" || proxy.Username != "monitor" {
+		t.Fatalf("unexpected proxy config: %+v", proxy)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","socks5_proxy":{"address":"bastion"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a proxy address without port")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_SOCKS5_PROXY", "127.0.0.1:1080")
+	t.Setenv("S3_SOCKS5_PASSWORD", "secret")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a password without username")
+	}
+
+	t.Setenv("S3_SOCKS5_USERNAME", "monitor")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if proxy := cfg.Endpoints[0].SOCKS5Proxy; proxy == nil || proxy.Address != "127.0.0.1:1080" || proxy.Password != "secret" {
+		t.Fatalf("unexpected legacy proxy config: %+v", proxy)
+	}
+}
+
+func TestLoadConfig_HistorySize(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.HistorySize != DefaultHistorySize {
+		t.Fatalf("expected default history size, got %d", cfg.HistorySize)
+	}
+
+	t.Setenv("HISTORY_SIZE", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a non-positive history size")
+	}
+}
+
+func TestLoadConfig_Reports(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("REPORTS_JSON", `{"email": {"from": "exporter@example.com", "to": ["ops@example.com"], "smtp": {"address": "smtp.example.com:587"}}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Reports == nil || cfg.Reports.Email == nil {
+		t.Fatalf("expected email digest config")
+	}
+	if cfg.Reports.Email.Schedule != DefaultDigestSchedule {
+		t.Fatalf("expected default schedule, got %q", cfg.Reports.Email.Schedule)
+	}
+
+	invalid := []string{
+		`{"email": {"schedule": "0 25 * * *", "from": "a@example.com", "to": ["b@example.com"], "smtp": {"address": "smtp:25"}}}`,
+		`{"email": {"from": "a@example.com", "to": ["b@example.com"], "smtp": {"address": "smtp"}}}`,
+		`{"email": {"from": "a@example.com", "to": [], "smtp": {"address": "smtp:25"}}}`,
+		`{"email": {"from": "not an address", "to": ["b@example.com"], "smtp": {"address": "smtp:25"}}}`,
+	}
+	for _, value := range invalid {
+		t.Setenv("REPORTS_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected error for %s", value)
+		}
+	}
+}
+
+func TestLoadConfig_Notifications(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name": "a", "bucket": "a", "access_key": "AK", "secret_key": "SK", "severity": "critical", "labels": {"team": "storage"}}, {"bucket": "b", "access_key": "AK", "secret_key": "SK"}]`)
+	t.Setenv("NOTIFICATIONS_JSON", `{"channels": [
+		{"type": "opsgenie", "severities": ["critical"], "opsgenie": {"api_key": "key"}},
+		{"name": "storage-team", "type": "teams", "teams": {"webhook_url": "https://example.webhook.office.com/x"}}
+	]}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Endpoints[0].Labels["team"] != "storage" {
+		t.Fatalf("expected endpoint labels, got %v", cfg.Endpoints[0].Labels)
+	}
+	if cfg.Endpoints[0].Severity != SeverityCritical || cfg.Endpoints[1].Severity != SeverityWarning {
+		t.Fatalf("unexpected severities: %q, %q", cfg.Endpoints[0].Severity, cfg.Endpoints[1].Severity)
+	}
+	channels := cfg.Notifications.Channels
+	if len(channels) != 2 || channels[0].Name != ChannelOpsgenie || channels[0].Opsgenie.APIURL != DefaultOpsgenieAPIURL {
+		t.Fatalf("unexpected channels: %+v", channels)
+	}
+
+	t.Setenv("NOTIFICATIONS_JSON", `{"channels": [{"type": "exec", "exec": {"command": ["/usr/local/bin/notify", "--json"]}}]}`)
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ex := cfg.Notifications.Channels[0].Exec; time.Duration(ex.Timeout) != DefaultExecTimeout || ex.MaxConcurrent != DefaultExecMaxConcurrent {
+		t.Fatalf("expected exec defaults, got %+v", ex)
+	}
+
+	invalid := []string{
+		`{"channels": [{"type": "pager"}]}`,
+		`{"channels": [{"type": "exec", "exec": {"command": []}}]}`,
+		`{"channels": [{"type": "exec", "exec": {"command": ["/bin/true"], "timeout": "-1s"}}]}`,
+		`{"channels": [{"type": "opsgenie", "opsgenie": {}}]}`,
+		`{"channels": [{"type": "opsgenie", "opsgenie": {"api_key": "k", "priority": "P9"}}]}`,
+		`{"channels": [{"type": "teams", "teams": {"webhook_url": "not a url"}}]}`,
+		`{"channels": [{"type": "teams", "severities": ["urgent"], "teams": {"webhook_url": "https://example.com"}}]}`,
+		`{"channels": [{"type": "teams", "teams": {"webhook_url": "https://example.com"}}, {"type": "teams", "teams": {"webhook_url": "https://example.com"}}]}`,
+	}
+	for _, value := range invalid {
+		t.Setenv("NOTIFICATIONS_JSON", value)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected error for %s", value)
+		}
+	}
+
+	t.Setenv("NOTIFICATIONS_JSON", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket": "b", "access_key": "AK", "secret_key": "SK", "severity": "urgent"}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for an unknown endpoint severity")
+	}
+}
+
+func TestLoadConfig_LatencyAnomaly(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.LatencyAnomalyFactor != 0 || cfg.LatencyAnomalyMinSamples != DefaultAnomalyMinSamples {
+		t.Fatalf("expected anomaly detection disabled by default, got %v/%d", cfg.LatencyAnomalyFactor, cfg.LatencyAnomalyMinSamples)
+	}
+
+	t.Setenv("LATENCY_ANOMALY_FACTOR", "5")
+	if cfg, err = LoadConfig(); err != nil || cfg.LatencyAnomalyFactor != 5 {
+		t.Fatalf("expected factor 5, got %v (%v)", cfg, err)
+	}
+
+	t.Setenv("LATENCY_ANOMALY_FACTOR", "0.5")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a factor below 1")
+	}
+
+	t.Setenv("LATENCY_ANOMALY_FACTOR", "5")
+	t.Setenv("LATENCY_ANOMALY_MIN_SAMPLES", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for non-positive min samples")
+	}
+}
+
+func TestLoadConfig_KeyAge(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[
+		{"name":"declared","bucket":"b1","access_key":"AK","secret_key":"SK","key_created_at":"2024-03-05","key_max_age":"2160h"},
+		{"name":"iam","bucket":"b2","access_key":"AK","secret_key":"SK","key_age_from_iam":true}
+	]`)
+	t.Setenv("KEY_MAX_AGE", "720h")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.KeyMaxAge != 720*time.Hour {
+		t.Fatalf("expected default max age 720h, got %s", cfg.KeyMaxAge)
+	}
+	declared := cfg.Endpoints[0]
+	if want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC); !time.Time(declared.KeyCreatedAt).Equal(want) {
+		t.Fatalf("expected key_created_at %v, got %v", want, time.Time(declared.KeyCreatedAt))
+	}
+	if time.Duration(declared.KeyMaxAge) != 2160*time.Hour {
+		t.Fatalf("expected key_max_age 2160h, got %s", time.Duration(declared.KeyMaxAge))
+	}
+	if !cfg.Endpoints[1].KeyAgeFromIAM {
+		t.Fatalf("expected key_age_from_iam to be set")
+	}
+
+	invalid := []string{
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","key_created_at":"yesterday"}]`,
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","key_created_at":"2999-01-01"}]`,
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","key_created_at":"2024-03-05","key_age_from_iam":true}]`,
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","key_age_from_iam":true,"iam_endpoint":"iam.local"}]`,
+	}
+	for _, endpoints := range invalid {
+		t.Setenv("S3_ENDPOINTS_JSON", endpoints)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected error for %s", endpoints)
+		}
+	}
+}
+
+func TestLoadConfig_LegacyKeyAge(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_KEY_CREATED_AT", "2024-03-05T10:00:00Z")
+	t.Setenv("S3_KEY_MAX_AGE", "24h")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	endpoint := cfg.Endpoints[0]
+	if time.Time(endpoint.KeyCreatedAt).IsZero() || time.Duration(endpoint.KeyMaxAge) != 24*time.Hour {
+		t.Fatalf("expected key age settings, got %+v", endpoint)
+	}
+
+	t.Setenv("S3_KEY_AGE_FROM_IAM", "true")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when both key_created_at and IAM lookup are set")
+	}
+}
+
+func TestLoadConfig_SecondaryCredentials(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"b","access_key":"OLD","secret_key":"SK","secondary":{"access_key":"NEW","secret_key":"SK2"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if secondary := cfg.Endpoints[0].Secondary; secondary == nil || secondary.AccessKey != "NEW" || secondary.SecretKey != "SK2" {
+		t.Fatalf("expected secondary credentials, got %+v", secondary)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"b","access_key":"OLD","secret_key":"SK","secondary":{"access_key":"NEW"}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for a secondary key without secret")
+	}
+}
+
+func TestLoadConfig_LegacySecondaryCredentials(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "OLD")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_SECONDARY_ACCESS_KEY", "NEW")
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error without S3_SECONDARY_SECRET_KEY")
+	}
+
+	t.Setenv("S3_SECONDARY_SECRET_KEY", "SK2")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if secondary := cfg.Endpoints[0].Secondary; secondary == nil || secondary.AccessKey != "NEW" {
+		t.Fatalf("expected secondary credentials, got %+v", secondary)
+	}
+}
+
+func TestLoadConfig_Rotation(t *testing.T) {
+	t.Setenv("KEY_MAX_AGE", "2160h")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"b","region":"eu-west-1","key_age_from_iam":true,
+		"rotation":{"store":{"type":"secretsmanager","secret_id":"s3/backup"}}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	store := cfg.Endpoints[0].Rotation.Store
+	if store.Region != "eu-west-1" || store.AccessKeyField != DefaultAccessKeyField || store.SecretKeyField != DefaultSecretKeyField {
+		t.Fatalf("expected store defaults to be filled in, got %+v", store)
+	}
+	if after := cfg.Endpoints[0].Rotation.DeactivateAfter; time.Duration(after) != DefaultDeactivateAfter {
+		t.Fatalf("expected the default deactivation delay, got %s", time.Duration(after))
+	}
+
+	invalid := []string{
+		// no IAM key age
+		`[{"bucket":"b","rotation":{"store":{"type":"kubernetes","name":"s3"}}}]`,
+		// unknown store
+		`[{"bucket":"b","key_age_from_iam":true,"rotation":{"store":{"type":"vault"}}}]`,
+		// missing secret name
+		`[{"bucket":"b","key_age_from_iam":true,"rotation":{"store":{"type":"kubernetes"}}}]`,
+		// session credentials
+		`[{"bucket":"b","session_token":"T","key_age_from_iam":true,"rotation":{"store":{"type":"kubernetes","name":"s3"}}}]`,
+		// inline keys, which a restart would bring back after they were deactivated
+		`[{"bucket":"b","access_key":"AK","secret_key":"SK","key_age_from_iam":true,"rotation":{"store":{"type":"kubernetes","name":"s3"}}}]`,
+		// negative deactivation delay
+		`[{"bucket":"b","key_age_from_iam":true,"rotation":{"store":{"type":"kubernetes","name":"s3"},"deactivate_after":"-1h"}}]`,
+		// manual secondary slot
+		`[{"bucket":"b","key_age_from_iam":true,"secondary":{"access_key":"A2","secret_key":"S2"},"rotation":{"store":{"type":"kubernetes","name":"s3"}}}]`,
+	}
+	for _, endpoints := range invalid {
+		t.Setenv("S3_ENDPOINTS_JSON", endpoints)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected error for %s", endpoints)
+		}
+	}
+
+	t.Setenv("KEY_MAX_AGE", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"b","key_age_from_iam":true,"rotation":{"store":{"type":"kubernetes","name":"s3"}}}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error without a rotation policy")
+	}
+}
+
+func TestLoadConfig_CORS(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://dash.example.com, http://localhost:3000")
+	t.Setenv("CORS_ALLOWED_METHODS", "get,post")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[1] != "http://localhost:3000" {
+		t.Fatalf("unexpected origins: %v", cfg.CORSAllowedOrigins)
+	}
+	if len(cfg.CORSAllowedMethods) != 2 || cfg.CORSAllowedMethods[0] != "GET" {
+		t.Fatalf("expected methods to be upper-cased, got %v", cfg.CORSAllowedMethods)
+	}
+
+	for _, origin := range []string{"dash.example.com", "https://dash.example.com/app", "ftp://dash.example.com"} {
+		t.Setenv("CORS_ALLOWED_ORIGINS", origin)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected origin %q to be rejected", origin)
+		}
+	}
+}
+
+func TestLoadConfig_ValidateRateLimit(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("VALIDATE_RATE_LIMIT", "30")
+	t.Setenv("VALIDATE_RATE_BURST", "5")
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.ValidateRateLimit != 30 || cfg.ValidateRateBurst != 5 {
+		t.Fatalf("expected a 30/min limit with bursts of 5, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("VALIDATE_RATE_LIMIT", "-1")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected a negative rate limit to be rejected")
+	}
+}
+
+func TestLoadConfig_ClientPool(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.ClientIdleTimeout != DefaultClientIdleTimeout || cfg.ClientMaxLifetime != DefaultClientMaxLifetime {
+		t.Fatalf("expected default client pool limits, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("CLIENT_IDLE_TIMEOUT", "0")
+	t.Setenv("CLIENT_MAX_LIFETIME", "6h")
+	if cfg, err = LoadConfig(); err != nil || cfg.ClientIdleTimeout != 0 || cfg.ClientMaxLifetime != 6*time.Hour {
+		t.Fatalf("expected configured client pool limits, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("CLIENT_MAX_LIFETIME", "-1m")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected a negative max lifetime to be rejected")
+	}
+}
+
+func TestLoadConfig_Annotations(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket": "a", "access_key": "AK", "secret_key": "SK", "annotations": {"owner": "team-storage", "runbook_url": "https://runbooks.example.com/a"}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.Endpoints[0].Annotations["owner"] != "team-storage" {
+		t.Fatalf("expected endpoint annotations, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "legacy")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_ANNOTATIONS", "owner=alice, description=Nightly backups")
+	if cfg, err = LoadConfig(); err != nil || cfg.Endpoints[0].Annotations["description"] != "Nightly backups" {
+		t.Fatalf("expected S3_ANNOTATIONS to be parsed, got %v (err %v)", cfg, err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	cfg := &Config{
+		ValidationTimeout:    10 * time.Second,
+		AutoValidateInterval: 5 * time.Second,
+		DeepValidateInterval: time.Hour,
+		ClientIdleTimeout:    time.Second,
+		WarmUpInitialBatch:   1,
+		WarmUpRampFactor:     2,
+		WarmUpBatchInterval:  5 * time.Second,
+		Endpoints: []S3EndpointConfig{
+			{Name: "aws", InsecureSkipVerify: true, AccessKey: "AKIASHARED"},
+			{Name: "minio", Endpoint: "https://minio.storage.svc:9000", InsecureSkipVerify: true, AccessKey: "AKIAOTHER"},
+			{Name: "lab", Endpoint: "http://10.0.0.5:9000", InsecureSkipVerify: true, Secondary: &Credentials{AccessKey: "AKIASHARED"}},
+			{Name: "wasabi", Endpoint: "https://s3.wasabisys.com", InsecureSkipVerify: true},
+		},
+		Notifications: &NotificationsConfig{Channels: []ChannelConfig{
+			{Name: "pager", Type: ChannelExec, Exec: &ExecConfig{Command: []string{"/bin/true"}, MaxConcurrent: 100}},
+		}},
+		EndpointPairs: []EndpointPair{{Name: "migration", Old: "minio", New: "minio-next"}},
+	}
+
+	reasons := make(map[string][]string)
+	for _, warning := range Lint(cfg) {
+		reasons[warning.Reason] = append(reasons[warning.Reason], warning.Endpoint)
+		if strings.Contains(warning.Message, "AKIASHARED") {
+			t.Fatalf("expected access keys to be masked, got %q", warning.Message)
+		}
+	}
+
+	if got := reasons[WarningInsecurePublicHost]; len(got) != 2 || got[0] != "aws" || got[1] != "wasabi" {
+		t.Fatalf("expected insecure_skip_verify warnings for public hosts only, got %v", got)
+	}
+	if got := reasons[WarningIntervalBelowTimeout]; len(got) != 1 {
+		t.Fatalf("expected one interval warning, got %v", got)
+	}
+	if got := reasons[WarningSharedCredentials]; len(got) != 2 || got[0] != "aws" || got[1] != "lab" {
+		t.Fatalf("expected both endpoints sharing a key to be reported, got %v", got)
+	}
+	if got := reasons[WarningHighConcurrency]; len(got) != 1 {
+		t.Fatalf("expected a high concurrency warning for the exec channel, got %v", got)
+	}
+	if got := reasons[WarningClientIdleTimeout]; len(got) != 1 {
+		t.Fatalf("expected a warning for clients evicted between runs, got %v", got)
+	}
+	if got := reasons[WarningWarmUpAboveInterval]; len(got) != 1 {
+		t.Fatalf("expected a warning for a warm-up outlasting the interval, got %v", got)
+	}
+	if got := reasons[WarningPairUnknownEndpoint]; len(got) != 1 {
+		t.Fatalf("expected a warning for the pair's missing endpoint, got %v", got)
+	}
+
+	if warnings := Lint(&Config{ValidationTimeout: time.Second, Endpoints: []S3EndpointConfig{{Name: "ok", AccessKey: "AK"}}}); len(warnings) != 0 {
+		t.Fatalf("expected a plain config to lint clean, got %+v", warnings)
+	}
+}
+
+func TestValidateDirectoryBucket(t *testing.T) {
+	valid := S3EndpointConfig{Bucket: "logs--usw2-az1--x-s3", Checks: &ChecksConfig{KMS: &KMSCheckConfig{}}}
+	if err := validateDirectoryBucket(valid); err != nil {
+		t.Fatalf("expected a directory bucket with supported settings to pass, got %v", err)
+	}
+	if err := validateDirectoryBucket(S3EndpointConfig{Bucket: "plain", UsePathStyle: true}); err != nil {
+		t.Fatalf("expected general purpose buckets to be left alone, got %v", err)
+	}
+
+	for name, endpoint := range map[string]S3EndpointConfig{
+		"name":          {Bucket: "logs--x-s3"},
+		"path style":    {Bucket: "logs--usw2-az1--x-s3", UsePathStyle: true},
+		"fallback":      {Bucket: "logs--usw2-az1--x-s3", FallbackRegions: []string{"us-east-1"}},
+		"regional host": {Bucket: "logs--usw2-az1--x-s3", Endpoint: "https://s3.us-west-2.amazonaws.com"},
+		"checks":        {Bucket: "logs--usw2-az1--x-s3", Checks: &ChecksConfig{PublicAccess: true}},
+		"restore":       {Bucket: "logs--usw2-az1--x-s3", Checks: &ChecksConfig{Restore: &RestoreCheckConfig{Key: "k"}}},
+	} {
+		if err := validateDirectoryBucket(endpoint); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestValidateEndpointVariants(t *testing.T) {
+	if err := validateEndpointVariants(S3EndpointConfig{Bucket: "uploads", UseAccelerate: true, UseDualStack: true}); err != nil {
+		t.Fatalf("expected accelerated dual-stack AWS endpoints to pass, got %v", err)
+	}
+
+	for name, endpoint := range map[string]S3EndpointConfig{
+		"accelerate custom endpoint": {Bucket: "uploads", UseAccelerate: true, Endpoint: "http://minio:9000"},
+		"accelerate path style":      {Bucket: "uploads", UseAccelerate: true, UsePathStyle: true},
+		"accelerate dotted bucket":   {Bucket: "uploads.example.com", UseAccelerate: true},
+		"dual-stack custom endpoint": {Bucket: "uploads", UseDualStack: true, Endpoint: "http://minio:9000"},
+		"fips custom endpoint":       {Bucket: "uploads", UseFIPSEndpoint: true, Endpoint: "http://minio:9000"},
+		"fips accelerate":            {Bucket: "uploads", UseFIPSEndpoint: true, UseAccelerate: true},
+	} {
+		if err := validateEndpointVariants(endpoint); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+
+	t.Setenv("S3_BUCKET", "uploads")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_USE_ACCELERATE", "true")
+	cfg, err := LoadConfig()
+	if err != nil || !cfg.Endpoints[0].UseAccelerate {
+		t.Fatalf("expected S3_USE_ACCELERATE to be loaded, got %v (err %v)", cfg, err)
+	}
+}
+
+func TestLoadConfig_FIPS(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"gov","bucket":"records","region":"us-gov-west-1","access_key":"AK","secret_key":"SK","use_fips_endpoint":true,"use_dual_stack":true}]`)
+	t.Setenv("FIPS_MODE", "true")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected FIPS dual-stack endpoints to load, got %v", err)
+	}
+	if !cfg.FIPSMode || !cfg.Endpoints[0].UseFIPSEndpoint {
+		t.Fatalf("expected FIPS mode and the FIPS endpoint, got %+v", cfg)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"lab","bucket":"records","endpoint":"https://minio:9000","access_key":"AK","secret_key":"SK","insecure_skip_verify":true}]`)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "FIPS mode") {
+		t.Fatalf("expected insecure_skip_verify to be rejected in FIPS mode, got %v", err)
+	}
+
+	t.Setenv("FIPS_MODE", "false")
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("expected insecure_skip_verify outside FIPS mode to load, got %v", err)
+	}
+}
+
+func TestValidateBucketARN(t *testing.T) {
+	mrap := "arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap"
+	if err := validateBucketARN(S3EndpointConfig{Bucket: mrap, UseARNRegion: true, Checks: &ChecksConfig{KMS: &KMSCheckConfig{}}}); err != nil {
+		t.Fatalf("expected a Multi-Region Access Point to pass, got %v", err)
+	}
+	accessPoint := "arn:aws:s3:eu-west-1:123456789012:accesspoint/reports"
+	if err := validateBucketARN(S3EndpointConfig{Bucket: accessPoint, Region: "eu-west-1", UseDualStack: true}); err != nil {
+		t.Fatalf("expected an access point in the endpoint's region to pass, got %v", err)
+	}
+	if err := validateBucketARN(S3EndpointConfig{Bucket: accessPoint, Region: "us-east-1", UseARNRegion: true}); err != nil {
+		t.Fatalf("expected use_arn_region to allow an access point in another region, got %v", err)
+	}
+	olap := "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redacted"
+	if err := validateBucketARN(S3EndpointConfig{Bucket: olap, Region: "eu-west-1", ProbeDepth: ProbeDepthShallow}); err != nil {
+		t.Fatalf("expected an Object Lambda access point to pass, got %v", err)
+	}
+	if err := validateBucketARN(S3EndpointConfig{Bucket: "plain"}); err != nil {
+		t.Fatalf("expected plain bucket names to be left alone, got %v", err)
+	}
+
+	for name, endpoint := range map[string]S3EndpointConfig{
+		"arn region without arn":  {Bucket: "plain", UseARNRegion: true},
+		"malformed":               {Bucket: "arn:aws:s3:::bucket"},
+		"not an access point":     {Bucket: "arn:aws:s3::123456789012:bucket/name.mrap"},
+		"custom endpoint":         {Bucket: mrap, Endpoint: "https://s3.example.com"},
+		"path style":              {Bucket: mrap, UsePathStyle: true},
+		"fallback regions":        {Bucket: mrap, FallbackRegions: []string{"eu-west-1"}},
+		"bucket checks":           {Bucket: mrap, Checks: &ChecksConfig{ObjectLock: &ObjectLockCheckConfig{}}},
+		"object lambda deep":      {Bucket: olap, Region: "eu-west-1", ProbeDepth: ProbeDepthDeep},
+		"object lambda kms":       {Bucket: olap, Region: "eu-west-1", Checks: &ChecksConfig{KMS: &KMSCheckConfig{}}},
+		"mrap dual-stack":         {Bucket: mrap, UseDualStack: true},
+		"mrap fips":               {Bucket: mrap, UseFIPSEndpoint: true},
+		"other region":            {Bucket: accessPoint, Region: "us-east-1"},
+		"access point accelerate": {Bucket: accessPoint, Region: "eu-west-1", UseAccelerate: true},
+	} {
+		if err := validateBucketARN(endpoint); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestLoadConfig_ChecksumAlgorithm(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checksum_algorithm":"crc32c"}]`)
+	cfg, err := LoadConfig()
+	if err != nil || cfg.Endpoints[0].ChecksumAlgorithm != "CRC32C" {
+		t.Fatalf("expected the checksum algorithm to be upper-cased, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","checksum_algorithm":"MD5"}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected an unknown checksum algorithm to be rejected")
+	}
+}
+
+func TestEndpointRules(t *testing.T) {
+	rules := EndpointRules{
+		{Service: ServiceS3, Region: "us-gov-*", URL: "https://vpce-0a1b-s3.s3.{region}.vpce.amazonaws.com"},
+		{Service: ServiceS3, URL: "https://s3.{region}.internal"},
+		{Service: ServiceSTS, URL: "https://vpce-0c2d-sts.sts.{region}.vpce.amazonaws.com"},
+	}
+
+	if url, ok := rules.Resolve(ServiceS3, "us-gov-west-1"); !ok || url != "https://vpce-0a1b-s3.s3.us-gov-west-1.vpce.amazonaws.com" {
+		t.Fatalf("expected the first matching rule, got %q", url)
+	}
+	if url, _ := rules.Resolve(ServiceS3, "eu-west-1"); url != "https://s3.eu-west-1.internal" {
+		t.Fatalf("expected the catch-all rule, got %q", url)
+	}
+	if _, ok := rules.Resolve(ServiceIAM, "eu-west-1"); ok {
+		t.Fatalf("expected no IAM rule")
+	}
+
+	applied := rules.Apply(S3EndpointConfig{Bucket: "b", Region: "eu-west-1", RoleARN: "arn:aws:iam::123456789012:role/audit"})
+	if applied.Endpoint != "https://s3.eu-west-1.internal" || applied.STSEndpoint != "https://vpce-0c2d-sts.sts.eu-west-1.vpce.amazonaws.com" || applied.IAMEndpoint != "" {
+		t.Fatalf("expected the S3 and STS URLs to be resolved, got %+v", applied)
+	}
+	for name, endpoint := range map[string]S3EndpointConfig{
+		"explicit endpoint": {Bucket: "b", Region: "eu-west-1", Endpoint: "https://minio:9000"},
+		"accelerate":        {Bucket: "b", Region: "eu-west-1", UseAccelerate: true},
+		"access point":      {Bucket: "arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap", Region: "eu-west-1"},
+	} {
+		if got := rules.Apply(endpoint); got.Endpoint != endpoint.Endpoint {
+			t.Fatalf("expected %s to keep its S3 endpoint, got %q", name, got.Endpoint)
+		}
+	}
+
+	for name, invalid := range map[string]EndpointRules{
+		"unknown service": {{Service: "dynamodb", URL: "https://ddb.internal"}},
+		"bad pattern":     {{Service: ServiceS3, Region: "us-[", URL: "https://s3.internal"}},
+		"bad url":         {{Service: ServiceS3, URL: "s3.internal"}},
+	} {
+		if err := validateEndpointRules(invalid); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestLoadConfig_EndpointRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`[{"service":"s3","url":"https://bucket.vpce-0a1b.s3.{region}.vpce.amazonaws.com"}]`), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	t.Setenv("ENDPOINT_RULES_FILE", path)
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","region":"eu-west-1","access_key":"AK","secret_key":"SK"},{"bucket":"b","endpoint":"https://minio:9000","access_key":"AK","secret_key":"SK"}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected the rules file to load, got %v", err)
+	}
+	if got := cfg.Endpoints[0].Endpoint; got != "https://bucket.vpce-0a1b.s3.eu-west-1.vpce.amazonaws.com" {
+		t.Fatalf("expected the rule to resolve the endpoint, got %q", got)
+	}
+	if got := cfg.Endpoints[1].Endpoint; got != "https://minio:9000" {
+		t.Fatalf("expected the explicit endpoint to be kept, got %q", got)
+	}
+
+	t.Setenv("ENDPOINT_RULES_JSON", `[]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected ENDPOINT_RULES_JSON and ENDPOINT_RULES_FILE together to be rejected")
+	}
+}
+
+func TestLoadConfig_PrivateCIDRs(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","private_cidrs":["10.20.0.0/16","fd00:ec2::/32"]}]`)
+	cfg, err := LoadConfig()
+	if err != nil || len(cfg.Endpoints[0].PrivateCIDRs) != 2 {
+		t.Fatalf("expected private_cidrs to load, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","private_cidrs":["10.20.0.0"]}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected an address without a prefix length to be rejected")
+	}
+}
+
+func TestLoadConfig_Retry(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","retry_mode":"adaptive","max_attempts":5,"max_backoff":"2s"}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	endpoint := cfg.Endpoints[0]
+	if endpoint.RetryMode != RetryModeAdaptive || endpoint.MaxAttempts != 5 || time.Duration(endpoint.MaxBackoff) != 2*time.Second {
+		t.Fatalf("unexpected retry settings: %+v", endpoint)
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"bucket":"a","access_key":"AK","secret_key":"SK","retry_mode":"legacy"}]`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected an unknown retry_mode to be rejected")
+	}
+
+	t.Setenv("S3_ENDPOINTS_JSON", "")
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("S3_MAX_ATTEMPTS", "-1")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected negative S3_MAX_ATTEMPTS to be rejected")
+	}
+}
+
+func TestLoadConfig_RemoteWrite(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("REMOTE_WRITE_JSON", `{"url":"https://mimir.example.com/api/v1/push","basic_auth":{"username":"u","password":"p"},"external_labels":{"cluster":"prod"}}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rw := cfg.RemoteWrite; rw == nil || time.Duration(rw.Interval) != DefaultRemoteWriteInterval || time.Duration(rw.Timeout) != DefaultRemoteWriteTimeout {
+		t.Fatalf("expected remote write with default interval and timeout, got %+v", cfg.RemoteWrite)
+	}
+
+	for _, invalid := range []string{
+		`{"url":"mimir:9009"}`,
+		`{"url":"https://mimir","basic_auth":{"username":"u"},"bearer_token":"t"}`,
+		`{"url":"https://mimir","external_labels":{"__tenant":"x"}}`,
+	} {
+		t.Setenv("REMOTE_WRITE_JSON", invalid)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestLoadConfig_CloudWatch(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("CLOUDWATCH_JSON", `{"access_key":"CW","secret_key":"S","region":"eu-west-1","role_name":"s3-key-metrics"}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cw := cfg.CloudWatch
+	if cw == nil || cw.Namespace != DefaultCloudWatchNamespace || cw.Endpoint != "" || cw.Partition != "aws" {
+		t.Fatalf("expected CloudWatch with defaults, got %+v", cfg.CloudWatch)
+	}
+
+	t.Setenv("ENDPOINT_RULES_JSON", `[{"service":"monitoring","url":"https://vpce-1.monitoring.{region}.vpce.amazonaws.com"},{"service":"sts","url":"https://vpce-2.sts.{region}.vpce.amazonaws.com"}]`)
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cw := cfg.CloudWatch; cw.Endpoint != "https://vpce-1.monitoring.eu-west-1.vpce.amazonaws.com" || cw.STSEndpoint != "https://vpce-2.sts.eu-west-1.vpce.amazonaws.com" {
+		t.Fatalf("expected CloudWatch URLs from the endpoint rules, got %+v", cw)
+	}
+
+	t.Setenv("CLOUDWATCH_JSON", `{"access_key":"CW","secret_key":"S","region":"us-east-2","use_fips_endpoint":true}`)
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cw := cfg.CloudWatch; cw.Endpoint != "" || !cw.UseFIPSEndpoint {
+		t.Fatalf("expected a FIPS publisher to keep the SDK's resolution, got %+v", cw)
+	}
+	t.Setenv("ENDPOINT_RULES_JSON", "")
+
+	for _, invalid := range []string{
+		`{"secret_key":"S"}`,
+		`{"access_key":"CW","secret_key":"S","namespace":"AWS/S3"}`,
+		`{"access_key":"CW","secret_key":"S","role_name":"arn:aws:iam::111111111111:role/x"}`,
+		`{"access_key":"CW","secret_key":"S","dimensions":{"Endpoint":"x"}}`,
+		`{"access_key":"CW","secret_key":"S","use_fips_endpoint":true,"endpoint":"https://monitoring.internal"}`,
+	} {
+		t.Setenv("CLOUDWATCH_JSON", invalid)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestLoadConfig_ResultsLog(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("RESULTS_LOG_FILE", "/var/log/results.jsonl")
+	t.Setenv("RESULTS_LOG_MAX_SIZE_MB", "10")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rl := cfg.ResultsLog; rl == nil || rl.MaxSize != 10<<20 || rl.MaxBackups != DefaultResultsLogMaxBackups {
+		t.Fatalf("expected a 10MB results log with default backups, got %+v", cfg.ResultsLog)
+	}
+
+	t.Setenv("RESULTS_LOG_MAX_SIZE_MB", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected a zero size to be rejected")
+	}
+}
+
+func TestLoadConfig_HistoryDB(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("HISTORY_DB_PATH", "/var/lib/exporter/history.db")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db := cfg.HistoryDB; db == nil || db.Retention != DefaultHistoryDBRetention || db.RollupRetention != DefaultHistoryDBRollupRetention {
+		t.Fatalf("expected the history database with the default retentions, got %+v", cfg.HistoryDB)
+	}
+
+	t.Setenv("HISTORY_DB_RETENTION", "-1h")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected a negative retention to be rejected")
+	}
+}
+
+func TestLoadConfig_Snapshot(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("SNAPSHOT_EXPORT_JSON", `{"access_key":"AK","secret_key":"SK","bucket":"drop","format":"json"}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := cfg.Snapshot; s == nil || s.Key != "key-aws-exporter/results.json" || time.Duration(s.Interval) != DefaultSnapshotInterval || s.Region != DefaultS3Region {
+		t.Fatalf("expected a JSON snapshot with defaults, got %+v", cfg.Snapshot)
+	}
+
+	for _, invalid := range []string{
+		`{"access_key":"AK","secret_key":"SK"}`,
+		`{"access_key":"AK","secret_key":"SK","bucket":"drop","format":"yaml"}`,
+	} {
+		t.Setenv("SNAPSHOT_EXPORT_JSON", invalid)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestLoadConfig_CredentialsAPI(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("CREDENTIALS_API_TOKENS", "token-a, token-b")
+	t.Setenv("CREDENTIALS_API_ALLOWED_ENDPOINTS", "https://minio.internal:9000")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.CredentialsAPITokens) != 2 || cfg.CredentialsAPITokens[1] != "token-b" {
+		t.Fatalf("expected both tokens, got %v", cfg.CredentialsAPITokens)
+	}
+	if len(cfg.CredentialsAPIAllowedEndpoints) != 1 {
+		t.Fatalf("expected one allowed endpoint, got %v", cfg.CredentialsAPIAllowedEndpoints)
+	}
+}
+
+func TestLoadConfig_OIDC(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("OIDC_JSON", `{"issuer":"https://sso.example.com/realms/ops","audience":"key-aws-exporter","role_permissions":{"s3-operators":["read","validate"]}}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o := cfg.OIDC; o == nil || o.RolesClaim != DefaultOIDCRolesClaim || time.Duration(o.Leeway) != DefaultOIDCLeeway {
+		t.Fatalf("expected OIDC with the default roles claim and leeway, got %+v", cfg.OIDC)
+	}
+
+	for _, invalid := range []string{
+		`{"audience":"a","role_permissions":{"r":["read"]}}`,
+		`{"issuer":"sso.example.com","audience":"a","role_permissions":{"r":["read"]}}`,
+		`{"issuer":"https://sso.example.com","audience":"a"}`,
+		`{"issuer":"https://sso.example.com","audience":"a","role_permissions":{"r":["write"]}}`,
+	} {
+		t.Setenv("OIDC_JSON", invalid)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestLoadConfig_RBAC(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("RBAC_JSON", `{"roles":{"team-a":{"routes":["POST /validate/{endpoint}"],"endpoints":["team-a-*"]}}}`)
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected RBAC without OIDC to be rejected")
+	}
+
+	// RBAC replaces role_permissions, so OIDC no longer needs them
+	t.Setenv("OIDC_JSON", `{"issuer":"https://sso.example.com","audience":"a"}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if role := cfg.RBAC.Roles["team-a"]; len(role.Routes) != 1 || role.Endpoints[0] != "team-a-*" {
+		t.Fatalf("expected the team-a role, got %+v", cfg.RBAC)
+	}
+
+	for _, invalid := range []string{
+		`{"roles":{}}`,
+		`{"roles":{"r":{"endpoints":["a"]}}}`,
+		`{"roles":{"r":{"routes":["/validate"]}}}`,
+		`{"roles":{"r":{"routes":["post /validate"]}}}`,
+		`{"roles":{"r":{"routes":["*"],"endpoints":["[a"]}}}`,
+	} {
+		t.Setenv("RBAC_JSON", invalid)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", invalid)
+		}
+	}
+
+	t.Setenv("RBAC_JSON", "")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected OIDC without role_permissions or RBAC to be rejected")
+	}
+}
+
+func TestLoadConfig_ClientAllowlist(t *testing.T) {
+	t.Setenv("S3_BUCKET", "bucket")
+	t.Setenv("S3_ACCESS_KEY", "AK")
+	t.Setenv("S3_SECRET_KEY", "SK")
+	t.Setenv("ALLOWED_CLIENT_CIDRS", "10.20.0.0/16, 192.168.1.7, fd00::/8")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1/24")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AllowedClientCIDRs) != 3 || cfg.AllowedClientCIDRs[1].String() != "192.168.1.7/32" {
+		t.Fatalf("expected three ranges with the bare address as a host, got %v", cfg.AllowedClientCIDRs)
+	}
+	if len(cfg.TrustedProxies) != 1 || cfg.TrustedProxies[0].String() != "10.0.0.0/24" {
+		t.Fatalf("expected the proxy range to be masked, got %v", cfg.TrustedProxies)
+	}
+
+	t.Setenv("ALLOWED_CLIENT_CIDRS", "10.20.0.0/33")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected an invalid CIDR to be rejected")
+	}
+}
+
+func TestLoadConfig_Plugin(t *testing.T) {
+	t.Setenv("S3_BUCKET", "")
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"vendor","plugin":{"exec":["/usr/local/bin/check-vendor","--fast"],"settings":{"tenant":"acme"}}}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("expected a plugin endpoint without bucket or keys to load, got %v", err)
+	}
+	if plugin := cfg.Endpoints[0].Plugin; len(plugin.Exec) != 2 || string(plugin.Settings) != `{"tenant":"acme"}` {
+		t.Fatalf("unexpected plugin: %+v", plugin)
+	}
+
+	for _, endpoints := range []string{
+		`[{"name":"vendor","plugin":{"exec":[]}}]`,
+		`[{"plugin":{"exec":["/bin/check"]}}]`,
+		`[{"name":"vendor","key_age_from_iam":true,"plugin":{"exec":["/bin/check"]}}]`,
+	} {
+		t.Setenv("S3_ENDPOINTS_JSON", endpoints)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", endpoints)
+		}
+	}
+}
+
+func TestLoadConfig_ResultRules(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"scratch-a","bucket":"scratch-a","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("RESULT_RULES_JSON", `[{"when":"endpoint.startsWith(\"scratch-\") && error_code == \"NoSuchBucket\"","severity":"warning"},{"name":"quiet","when":"!is_valid","suppress":true}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ResultRules) != 2 || cfg.ResultRules[0].Name != "rule-0" || !cfg.ResultRules[1].Suppress {
+		t.Fatalf("unexpected result rules: %+v", cfg.ResultRules)
+	}
+
+	for _, rules := range []string{
+		`[{"severity":"warning"}]`,
+		`[{"when":"is_valid &&","severity":"warning"}]`,
+		`[{"when":"owner == \"x\"","severity":"warning"}]`,
+		`[{"when":"!is_valid"}]`,
+		`[{"when":"!is_valid","severity":"urgent"}]`,
+		`{"when":"!is_valid"}`,
+	} {
+		t.Setenv("RESULT_RULES_JSON", rules)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", rules)
+		}
+	}
+}
+
+func TestLoadConfig_WarmUp(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"a","bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("WARMUP_INITIAL_BATCH", "10")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WarmUpInitialBatch != 10 || cfg.WarmUpRampFactor != DefaultWarmUpRampFactor || cfg.WarmUpBatchInterval != DefaultWarmUpBatchInterval {
+		t.Fatalf("unexpected warm-up settings: %d, %v, %s", cfg.WarmUpInitialBatch, cfg.WarmUpRampFactor, cfg.WarmUpBatchInterval)
+	}
+
+	for name, value := range map[string]string{
+		"WARMUP_INITIAL_BATCH":  "-1",
+		"WARMUP_RAMP_FACTOR":    "0.5",
+		"WARMUP_BATCH_INTERVAL": "0s",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := LoadConfig(); err == nil {
+				t.Fatalf("expected %s=%s to be rejected", name, value)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Hedge(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"a","bucket":"a","access_key":"AK","secret_key":"SK","hedge":{"min_delay":"200ms"}}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hedge := cfg.Endpoints[0].Hedge; time.Duration(hedge.MinDelay) != 200*time.Millisecond || hedge.MinSamples != DefaultHedgeMinSamples {
+		t.Fatalf("unexpected hedge settings: %+v", hedge)
+	}
+
+	for _, hedge := range []string{`{"min_delay":"-1s"}`, `{"min_samples":-1}`} {
+		t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"a","bucket":"a","access_key":"AK","secret_key":"SK","hedge":`+hedge+`}]`)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected hedge %s to be rejected", hedge)
+		}
+	}
+}
+
+func TestLoadConfig_EndpointPairs(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"old","bucket":"data","access_key":"AK","secret_key":"SK"},{"name":"new","bucket":"data","access_key":"AK","secret_key":"SK"}]`)
+	t.Setenv("ENDPOINT_PAIRS_JSON", `[{"old":"old","new":"new"},{"name":"reverse","old":"new","new":"old"}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.EndpointPairs) != 2 || cfg.EndpointPairs[0].Name != "old-vs-new" {
+		t.Fatalf("unexpected pairs: %+v", cfg.EndpointPairs)
+	}
+
+	for _, pairs := range []string{
+		`[{"old":"old"}]`,
+		`[{"old":"old","new":"old"}]`,
+		`[{"name":"p","old":"old","new":"new"},{"name":"p","old":"new","new":"old"}]`,
+		`{"old":"old","new":"new"}`,
+	} {
+		t.Setenv("ENDPOINT_PAIRS_JSON", pairs)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected %s to be rejected", pairs)
+		}
+	}
+}
+
+func TestLoadConfig_RoundTrip(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"gw","bucket":"data","access_key":"AK","secret_key":"SK","roundtrip":{"sse":"aws:kms","kms_key_id":"alias/data","metadata":{"team":"storage"}}}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rt := cfg.Endpoints[0].RoundTrip; rt.SSE != "aws:kms" || rt.KMSKeyID != "alias/data" || rt.Metadata["team"] != "storage" {
+		t.Fatalf("unexpected roundtrip settings: %+v", rt)
+	}
+
+	for _, roundTrip := range []string{`{"sse":"aes256"}`, `{"kms_key_id":"alias/data"}`, `{"sse":"AES256","kms_key_id":"alias/data"}`, `{"metadata":{"":"x"}}`} {
+		t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"gw","bucket":"data","access_key":"AK","secret_key":"SK","roundtrip":`+roundTrip+`}]`)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected roundtrip %s to be rejected", roundTrip)
+		}
+	}
+}
+
+func TestLoadConfig_Canaries(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"a","bucket":"a","access_key":"AK","secret_key":"SK"}]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CanaryTTL != DefaultCanaryTTL || cfg.CanaryCleanupInterval != DefaultCanaryCleanupInterval || cfg.ReplicaID != "" {
+		t.Fatalf("unexpected canary defaults: ttl=%s interval=%s replica=%q", cfg.CanaryTTL, cfg.CanaryCleanupInterval, cfg.ReplicaID)
+	}
+
+	t.Setenv("REPLICA_ID", "exporter-0")
+	t.Setenv("CANARY_TTL", "15m")
+	t.Setenv("CANARY_CLEANUP_INTERVAL", "0")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReplicaID != "exporter-0" || cfg.CanaryTTL != 15*time.Minute || cfg.CanaryCleanupInterval != 0 {
+		t.Fatalf("unexpected canary settings: ttl=%s interval=%s replica=%q", cfg.CanaryTTL, cfg.CanaryCleanupInterval, cfg.ReplicaID)
+	}
+
+	for env, value := range map[string]string{"CANARY_TTL": "5s", "CANARY_CLEANUP_INTERVAL": "-1m"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if _, err := LoadConfig(); err == nil {
+				t.Fatalf("expected %s=%s to be rejected", env, value)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Success(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[
+		{"name":"backups","bucket":"a","access_key":"AK","secret_key":"SK","success":{"expect":"is_valid && object_count >= 10","prefix":"daily/"}},
+		{"name":"denied","bucket":"a","access_key":"AK","secret_key":"SK","success":{"expect":"error_code == \"AccessDenied\""}}
+	]`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if success := cfg.Endpoints[0].Success; success.MaxKeys != DefaultSuccessMaxKeys || success.Prefix != "daily/" {
+		t.Fatalf("expected a full listing for a criterion counting objects, got %+v", success)
+	}
+	if success := cfg.Endpoints[1].Success; success.MaxKeys != 1 {
+		t.Fatalf("expected a single-key listing otherwise, got %+v", success)
+	}
+
+	for _, success := range []string{`{}`, `{"expect":"is_valid &&"}`, `{"expect":"bucket == \"a\""}`, `{"expect":"is_valid","max_keys":1001}`} {
+		t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"a","bucket":"a","access_key":"AK","secret_key":"SK","success":`+success+`}]`)
+		if _, err := LoadConfig(); err == nil {
+			t.Fatalf("expected success %s to be rejected", success)
+		}
+	}
+}
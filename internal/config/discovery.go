@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultDiscoveryInterval is how often buckets are rediscovered when no interval is configured
+const DefaultDiscoveryInterval = time.Hour
+
+// DiscoveryConfig creates endpoints for the buckets of an account carrying every tag.
+// Discovered endpoints validate with the discovery keys, or with the role they assume.
+type DiscoveryConfig struct {
+	Endpoint     string            `json:"endpoint"`
+	Region       string            `json:"region"`
+	AccessKey    string            `json:"access_key"`
+	SecretKey    string            `json:"secret_key"`
+	SessionToken string            `json:"session_token"`
+	UsePathStyle bool              `json:"use_path_style"`
+	Tags         map[string]string `json:"tags"`
+	Interval     Duration          `json:"interval"`
+	// NamePrefix is prepended to bucket names to form endpoint names
+	NamePrefix string `json:"name_prefix"`
+	// RoleARN is assumed to validate every discovered bucket; the bucket's RoleTag tag
+	// overrides it, so each bucket can be validated with its own role
+	RoleARN    string `json:"role_arn"`
+	RoleTag    string `json:"role_tag"`
+	ExternalID string `json:"external_id"`
+	// Template holds the remaining settings of discovered endpoints, e.g. probe_depth or checks
+	Template *S3EndpointConfig `json:"template"`
+}
+
+// validateDiscovery applies defaults and reports the first invalid discovery setting
+func validateDiscovery(d *DiscoveryConfig) error {
+	if d.AccessKey == "" || d.SecretKey == "" {
+		return fmt.Errorf("access_key and secret_key are required")
+	}
+	if len(d.Tags) == 0 {
+		return fmt.Errorf("tags must select the buckets to monitor, e.g. {\"monitor\": \"true\"}")
+	}
+	if d.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if d.Interval == 0 {
+		d.Interval = Duration(DefaultDiscoveryInterval)
+	}
+	if d.Region == "" {
+		d.Region = DefaultS3Region
+	}
+	if err := validateRoleARN(d.RoleARN); err != nil {
+		return err
+	}
+	if t := d.Template; t != nil {
+		if t.Bucket != "" || t.Name != "" || t.AccessKey != "" || t.SecretKey != "" || t.Secondary != nil || t.Rotation != nil {
+			return fmt.Errorf("template cannot set name, bucket, credentials, secondary or rotation")
+		}
+		if err := validateChecks(t.Checks); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateRoleARN accepts an empty value or an IAM role ARN
+func validateRoleARN(roleARN string) error {
+	if roleARN == "" {
+		return nil
+	}
+	if !strings.HasPrefix(roleARN, "arn:") || !strings.Contains(roleARN, ":iam::") || !strings.Contains(roleARN, ":role/") {
+		return fmt.Errorf("role_arn must be an IAM role ARN like arn:aws:iam::123456789012:role/name, got %q", roleARN)
+	}
+	return nil
+}
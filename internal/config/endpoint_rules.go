@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Services endpoint rules resolve
+const (
+	ServiceS3         = "s3"
+	ServiceSTS        = "sts"
+	ServiceIAM        = "iam"
+	ServiceCloudWatch = "monitoring"
+)
+
+// EndpointRule resolves the URL of a service in the regions matching Region
+type EndpointRule struct {
+	Service string `json:"service"`
+	// Region is a glob such as us-gov-*; empty matches every region
+	Region string `json:"region"`
+	// URL may contain {region}, replaced with the endpoint's region
+	URL string `json:"url"`
+}
+
+// EndpointRules model private link and VPC endpoint setups once instead of repeating
+// URLs in every endpoint. The first rule matching a service and region wins.
+type EndpointRules []EndpointRule
+
+// Resolve returns the URL of service in region
+func (r EndpointRules) Resolve(service, region string) (string, bool) {
+	for _, rule := range r {
+		if rule.Service != service {
+			continue
+		}
+		if matched, _ := path.Match(rule.Region, region); rule.Region != "" && !matched {
+			continue
+		}
+		return strings.ReplaceAll(rule.URL, "{region}", region), true
+	}
+	return "", false
+}
+
+// Apply fills the endpoint's unset S3, STS and IAM URLs from the rules. Endpoints
+// selecting an AWS endpoint variant or addressing an access point ARN keep the SDK's
+// resolution for S3, since neither can be combined with a custom URL.
+func (r EndpointRules) Apply(endpoint S3EndpointConfig) S3EndpointConfig {
+	variant := endpoint.UseAccelerate || endpoint.UseDualStack || endpoint.UseFIPSEndpoint
+	if endpoint.Endpoint == "" && !variant && !strings.HasPrefix(endpoint.Bucket, "arn:") {
+		if url, ok := r.Resolve(ServiceS3, endpoint.Region); ok {
+			endpoint.Endpoint = url
+		}
+	}
+	if endpoint.STSEndpoint == "" && endpoint.RoleARN != "" {
+		if url, ok := r.Resolve(ServiceSTS, endpoint.Region); ok {
+			endpoint.STSEndpoint = url
+		}
+	}
+	if endpoint.IAMEndpoint == "" {
+		if url, ok := r.Resolve(ServiceIAM, endpoint.Region); ok {
+			endpoint.IAMEndpoint = url
+		}
+	}
+	return endpoint
+}
+
+// applyCloudWatch fills the CloudWatch publisher's unset monitoring and STS URLs from
+// the rules. A publisher using the FIPS endpoint keeps the SDK's resolution for
+// CloudWatch, like S3 endpoints selecting a variant.
+func (r EndpointRules) applyCloudWatch(c CloudWatchConfig) CloudWatchConfig {
+	if c.Endpoint == "" && !c.UseFIPSEndpoint {
+		if url, ok := r.Resolve(ServiceCloudWatch, c.Region); ok {
+			c.Endpoint = url
+		}
+	}
+	if c.STSEndpoint == "" && c.RoleName != "" {
+		if url, ok := r.Resolve(ServiceSTS, c.Region); ok {
+			c.STSEndpoint = url
+		}
+	}
+	return c
+}
+
+// loadEndpointRules reads the rules from ENDPOINT_RULES_JSON or the file named by
+// ENDPOINT_RULES_FILE
+func loadEndpointRules() (EndpointRules, error) {
+	rulesJSON, rulesFile := os.Getenv("ENDPOINT_RULES_JSON"), os.Getenv("ENDPOINT_RULES_FILE")
+	source := "ENDPOINT_RULES_JSON"
+	switch {
+	case rulesJSON != "" && rulesFile != "":
+		return nil, fmt.Errorf("set ENDPOINT_RULES_JSON or ENDPOINT_RULES_FILE, not both")
+	case rulesFile != "":
+		data, err := os.ReadFile(rulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ENDPOINT_RULES_FILE: %w", err)
+		}
+		rulesJSON, source = string(data), "ENDPOINT_RULES_FILE"
+	case rulesJSON == "":
+		return nil, nil
+	}
+
+	var rules EndpointRules
+	if err := json.Unmarshal([]byte(rulesJSON), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	if err := validateEndpointRules(rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return rules, nil
+}
+
+// validateEndpointRules reports the first rule that cannot be resolved
+func validateEndpointRules(rules EndpointRules) error {
+	for i, rule := range rules {
+		switch rule.Service {
+		case ServiceS3, ServiceSTS, ServiceIAM, ServiceCloudWatch:
+		default:
+			return fmt.Errorf("rule %d: service must be %q, %q, %q or %q, got %q", i, ServiceS3, ServiceSTS, ServiceIAM, ServiceCloudWatch, rule.Service)
+		}
+		if _, err := path.Match(rule.Region, ""); err != nil {
+			return fmt.Errorf("rule %d: invalid region pattern %q", i, rule.Region)
+		}
+		if err := validateURL(strings.ReplaceAll(rule.URL, "{region}", "us-east-1")); err != nil {
+			return fmt.Errorf("rule %d: url %w", i, err)
+		}
+	}
+	return nil
+}
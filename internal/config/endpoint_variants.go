@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateEndpointVariants rejects use_accelerate, use_dual_stack and use_fips_endpoint
+// settings the SDK cannot resolve an endpoint for. All select AWS endpoint variants, so
+// none can be combined with a custom endpoint.
+func validateEndpointVariants(endpoint S3EndpointConfig) error {
+	if endpoint.UseAccelerate {
+		if endpoint.Endpoint != "" {
+			return fmt.Errorf("use_accelerate selects the AWS accelerate endpoint and cannot be combined with endpoint %s", endpoint.Endpoint)
+		}
+		if endpoint.UsePathStyle {
+			return fmt.Errorf("use_accelerate requires virtual-hosted addressing; unset use_path_style")
+		}
+		if strings.Contains(endpoint.Bucket, ".") {
+			return fmt.Errorf("transfer acceleration is not available for bucket %q: names with dots cannot be accelerated", endpoint.Bucket)
+		}
+	}
+	if endpoint.UseDualStack && endpoint.Endpoint != "" {
+		return fmt.Errorf("use_dual_stack selects the AWS dual-stack endpoint and cannot be combined with endpoint %s", endpoint.Endpoint)
+	}
+	if endpoint.UseFIPSEndpoint {
+		if endpoint.Endpoint != "" {
+			return fmt.Errorf("use_fips_endpoint selects the AWS FIPS endpoint and cannot be combined with endpoint %s", endpoint.Endpoint)
+		}
+		if endpoint.UseAccelerate {
+			return fmt.Errorf("transfer acceleration has no FIPS endpoint; unset use_accelerate or use_fips_endpoint")
+		}
+	}
+	return nil
+}
+
+// validateFIPSMode rejects endpoints that skip TLS verification when FIPS_MODE is set
+func validateFIPSMode(endpoint S3EndpointConfig, fipsMode bool) error {
+	if fipsMode && endpoint.InsecureSkipVerify {
+		return fmt.Errorf("insecure_skip_verify is not allowed in FIPS mode")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// directoryBucketSuffix ends the names of S3 Express One Zone directory buckets
+const directoryBucketSuffix = "--x-s3"
+
+// directoryBucketPattern is base-name--zone-id--x-s3, e.g. logs--usw2-az1--x-s3
+var directoryBucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*--[a-z0-9]+(-[a-z0-9]+)+--x-s3$`)
+
+// validateDirectoryBucket rejects settings S3 Express One Zone directory buckets cannot
+// be validated with. Other buckets are left alone.
+func validateDirectoryBucket(endpoint S3EndpointConfig) error {
+	if !strings.HasSuffix(endpoint.Bucket, directoryBucketSuffix) {
+		return nil
+	}
+	if !directoryBucketPattern.MatchString(endpoint.Bucket) {
+		return fmt.Errorf("directory bucket %q must be named base-name--zone-id--x-s3, e.g. logs--usw2-az1--x-s3", endpoint.Bucket)
+	}
+	if endpoint.UsePathStyle {
+		return fmt.Errorf("directory buckets only support virtual-hosted addressing; unset use_path_style")
+	}
+	if endpoint.UseAccelerate {
+		return fmt.Errorf("directory buckets do not support transfer acceleration; unset use_accelerate")
+	}
+	if len(endpoint.FallbackRegions) > 0 {
+		return fmt.Errorf("directory buckets live in a single zone; unset fallback_regions")
+	}
+	if endpoint.Endpoint != "" {
+		if u, err := url.Parse(endpoint.Endpoint); err == nil && strings.HasSuffix(u.Hostname(), ".amazonaws.com") && !strings.HasPrefix(u.Hostname(), "s3express-") {
+			return fmt.Errorf("endpoint %s is not zonal; leave endpoint empty so requests go to the bucket's zone", endpoint.Endpoint)
+		}
+	}
+	if unsupported := bucketLevelChecks(endpoint.Checks); len(unsupported) > 0 {
+		return fmt.Errorf("directory buckets do not support the %s checks", strings.Join(unsupported, ", "))
+	}
+	if endpoint.Checks != nil && endpoint.Checks.Restore != nil {
+		return fmt.Errorf("directory buckets hold no archived objects; remove the restore check")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DefaultFakeS3Address is where the in-process fake S3 listens
+const DefaultFakeS3Address = "127.0.0.1:0"
+
+// FakeS3Errors are the failures the fake S3 can inject
+var FakeS3Errors = []string{
+	"access_denied", "invalid_key", "signature_mismatch", "expired_token", "clock_skew",
+	"bucket_not_found", "throttled", "internal_error", "timeout", "network",
+}
+
+// FakeS3Config redirects every endpoint to a fake S3 for demos and end-to-end tests
+type FakeS3Config struct {
+	Enabled bool
+	Address string
+	// Target forwards requests that are not failed to an S3 emulator such as LocalStack;
+	// empty serves them from memory
+	Target string
+	// Failures injects failures by endpoint name
+	Failures map[string]FakeS3Failure
+}
+
+// FakeS3Failure is a deterministic failure injected into an endpoint's requests
+type FakeS3Failure struct {
+	Error      string   `json:"error"`      // one of FakeS3Errors; empty only adds latency
+	Every      int      `json:"every"`      // fail every Nth request; 0 fails them all
+	Operations []string `json:"operations"` // fail only these operations, e.g. PutObject
+	Latency    Duration `json:"latency"`    // delay before answering
+}
+
+// validateFakeS3Failures reports the first failure that cannot be injected
+func validateFakeS3Failures(failures map[string]FakeS3Failure) error {
+	for name, failure := range failures {
+		if failure.Error != "" && !slices.Contains(FakeS3Errors, failure.Error) {
+			return fmt.Errorf("%s: unknown error %q, expected one of %v", name, failure.Error, FakeS3Errors)
+		}
+		if failure.Error == "" && failure.Latency == 0 {
+			return fmt.Errorf("%s: error or latency is required", name)
+		}
+		if failure.Every < 0 || failure.Latency < 0 {
+			return fmt.Errorf("%s: every and latency cannot be negative", name)
+		}
+	}
+	return nil
+}
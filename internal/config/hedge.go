@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultHedgeMinSamples is how many results an endpoint needs before its p95 is trusted
+// to decide when to hedge
+const DefaultHedgeMinSamples = 10
+
+// HedgeConfig sends a second shallow probe when the first has not answered within the
+// endpoint's p95 response time and takes whichever answers first, so a slow backend
+// behind a flaky load balancer does not show up as latency spikes
+type HedgeConfig struct {
+	// MinDelay is the shortest wait before hedging, so endpoints with a tiny p95 are not
+	// probed twice on jitter alone
+	MinDelay Duration `json:"min_delay"`
+	// MinSamples is how many results the p95 needs; no hedge is sent before
+	MinSamples int `json:"min_samples"`
+}
+
+// validateHedge applies defaults and rejects invalid hedging settings
+func validateHedge(endpoint *S3EndpointConfig) error {
+	hedge := endpoint.Hedge
+	if hedge == nil {
+		return nil
+	}
+	if hedge.MinDelay < 0 {
+		return fmt.Errorf("hedge.min_delay cannot be negative, got %s", time.Duration(hedge.MinDelay))
+	}
+	if hedge.MinSamples < 0 {
+		return fmt.Errorf("hedge.min_samples cannot be negative, got %d", hedge.MinSamples)
+	}
+	if hedge.MinSamples == 0 {
+		hedge.MinSamples = DefaultHedgeMinSamples
+	}
+	return nil
+}
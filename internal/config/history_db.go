@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// History database retention defaults
+const (
+	// DefaultHistoryDBRetention is how long raw results are kept
+	DefaultHistoryDBRetention = 30 * 24 * time.Hour
+	// DefaultHistoryDBRollupRetention is how long daily availability rollups are kept
+	DefaultHistoryDBRollupRetention = 365 * 24 * time.Hour
+)
+
+// HistoryDBConfig persists every validation result in a SQLite database, enabling
+// range queries on /history that survive restarts
+type HistoryDBConfig struct {
+	Path string
+	// Retention is how long results are kept; older ones are pruned periodically
+	Retention time.Duration
+	// RollupRetention is how long the daily availability rollups built from the
+	// results are kept
+	RollupRetention time.Duration
+}
+
+// validateHistoryDB reports a non-positive retention
+func validateHistoryDB(h *HistoryDBConfig) error {
+	if h.Retention <= 0 {
+		return fmt.Errorf("HISTORY_DB_RETENTION must be positive, got %s", h.Retention)
+	}
+	if h.RollupRetention <= 0 {
+		return fmt.Errorf("HISTORY_DB_ROLLUP_RETENTION must be positive, got %s", h.RollupRetention)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Timestamp is a time.Time that unmarshals from RFC 3339 strings or plain dates such as "2024-03-05"
+type Timestamp time.Time
+
+// UnmarshalJSON accepts RFC 3339 timestamps and YYYY-MM-DD dates
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("timestamp must be a string like \"2024-03-05\": %w", err)
+	}
+	parsed, err := parseTimestamp(value)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp(parsed)
+	return nil
+}
+
+// parseTimestamp parses an RFC 3339 timestamp or a date at midnight UTC
+func parseTimestamp(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp %q must be RFC 3339 or YYYY-MM-DD", value)
+	}
+	return parsed, nil
+}
+
+// validateKeyAge checks the key creation source and rotation policy of an endpoint
+func validateKeyAge(endpoint S3EndpointConfig, now time.Time) error {
+	created := time.Time(endpoint.KeyCreatedAt)
+	if !created.IsZero() && endpoint.KeyAgeFromIAM {
+		return fmt.Errorf("key_created_at and key_age_from_iam are mutually exclusive")
+	}
+	if created.After(now) {
+		return fmt.Errorf("key_created_at %s is in the future", created.Format(time.RFC3339))
+	}
+	if endpoint.KeyMaxAge < 0 {
+		return fmt.Errorf("key_max_age cannot be negative")
+	}
+	if endpoint.IAMEndpoint != "" {
+		if err := validateURL(endpoint.IAMEndpoint); err != nil {
+			return fmt.Errorf("iam_endpoint: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reasons reported by Lint, used as the s3_config_warning reason label
+const (
+	WarningInsecurePublicHost   = "insecure_skip_verify_public_host"
+	WarningIntervalBelowTimeout = "interval_shorter_than_timeout"
+	WarningSharedCredentials    = "shared_credentials"
+	WarningHighConcurrency      = "high_concurrency"
+	WarningClientIdleTimeout    = "client_idle_timeout_below_interval"
+	WarningWarmUpAboveInterval  = "warm_up_longer_than_interval"
+	WarningPairUnknownEndpoint  = "pair_unknown_endpoint"
+)
+
+// Concurrency above which Lint warns: every endpoint is probed at once per run, and
+// each exec notification slot is a process that may run at the same time
+const (
+	maxConcurrentEndpoints = 200
+	maxExecConcurrency     = 32
+)
+
+// privateHostSuffixes are DNS suffixes that only resolve inside private networks
+var privateHostSuffixes = []string{".local", ".localhost", ".internal", ".lan", ".home.arpa", ".svc", ".cluster.local"}
+
+// Warning is a setting that is valid but probably not what the operator intended
+type Warning struct {
+	Reason   string `json:"reason"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Lint reports risky settings in a loaded configuration. Unlike LoadConfig errors they
+// do not stop the exporter.
+func Lint(cfg *Config) []Warning {
+	var warnings []Warning
+
+	for _, endpoint := range cfg.Endpoints {
+		if endpoint.InsecureSkipVerify && !isPrivateHost(endpoint.Endpoint) {
+			host := endpoint.Endpoint
+			if host == "" {
+				host = "AWS S3"
+			}
+			warnings = append(warnings, Warning{
+				Reason:   WarningInsecurePublicHost,
+				Endpoint: endpoint.Name,
+				Message:  fmt.Sprintf("insecure_skip_verify disables TLS verification for %s, which is not a private host", host),
+			})
+		}
+	}
+
+	intervals := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{"AUTO_VALIDATE_INTERVAL", cfg.AutoValidateInterval},
+		{"DEEP_VALIDATE_INTERVAL", cfg.DeepValidateInterval},
+		{"PERMISSION_CHECK_INTERVAL", cfg.PermissionCheckInterval},
+	}
+	for _, iv := range intervals {
+		if iv.interval > 0 && iv.interval < cfg.ValidationTimeout {
+			warnings = append(warnings, Warning{
+				Reason:  WarningIntervalBelowTimeout,
+				Message: fmt.Sprintf("%s (%s) is shorter than VALIDATION_TIMEOUT (%s), so slow runs overlap or are cut short", iv.name, iv.interval, cfg.ValidationTimeout),
+			})
+		}
+	}
+
+	if cfg.ClientIdleTimeout > 0 && cfg.AutoValidateInterval > cfg.ClientIdleTimeout {
+		warnings = append(warnings, Warning{
+			Reason:  WarningClientIdleTimeout,
+			Message: fmt.Sprintf("CLIENT_IDLE_TIMEOUT (%s) is shorter than AUTO_VALIDATE_INTERVAL (%s), so every run builds new clients and connections", cfg.ClientIdleTimeout, cfg.AutoValidateInterval),
+		})
+	}
+
+	if cfg.WarmUpInitialBatch > 0 && cfg.AutoValidateInterval > 0 {
+		ramp := time.Duration(warmUpPauses(len(cfg.Endpoints), cfg.WarmUpInitialBatch, cfg.WarmUpRampFactor)) * cfg.WarmUpBatchInterval
+		if ramp >= cfg.AutoValidateInterval {
+			warnings = append(warnings, Warning{
+				Reason:  WarningWarmUpAboveInterval,
+				Message: fmt.Sprintf("ramping up %d endpoints takes at least %s, more than AUTO_VALIDATE_INTERVAL (%s), so the last batches wait for the next run", len(cfg.Endpoints), ramp, cfg.AutoValidateInterval),
+			})
+		}
+	}
+
+	warnings = append(warnings, lintSharedCredentials(cfg.Endpoints)...)
+	if !cfg.discovers() {
+		warnings = append(warnings, lintEndpointPairs(cfg.EndpointPairs, cfg.Endpoints)...)
+	}
+
+	if len(cfg.Endpoints) > maxConcurrentEndpoints {
+		warnings = append(warnings, Warning{
+			Reason:  WarningHighConcurrency,
+			Message: fmt.Sprintf("%d endpoints are probed concurrently on every run (more than %d); expect bursts of connections and throttling", len(cfg.Endpoints), maxConcurrentEndpoints),
+		})
+	}
+	if cfg.Notifications != nil {
+		for _, channel := range cfg.Notifications.Channels {
+			if channel.Exec != nil && channel.Exec.MaxConcurrent > maxExecConcurrency {
+				warnings = append(warnings, Warning{
+					Reason:  WarningHighConcurrency,
+					Message: fmt.Sprintf("channel %q may run %d commands at once (more than %d)", channel.Name, channel.Exec.MaxConcurrent, maxExecConcurrency),
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// warmUpPauses returns how many pauses ramping up n endpoints takes
+func warmUpPauses(n, initial int, factor float64) int {
+	pauses := -1
+	for size := float64(initial); n > 0; size *= factor {
+		n -= int(math.Ceil(size))
+		pauses++
+	}
+	return max(pauses, 0)
+}
+
+// lintEndpointPairs warns about pairs naming an endpoint that is not configured, which
+// are never compared
+func lintEndpointPairs(pairs []EndpointPair, endpoints []S3EndpointConfig) []Warning {
+	configured := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		configured[endpoint.Name] = true
+	}
+	var warnings []Warning
+	for _, pair := range pairs {
+		for _, name := range []string{pair.Old, pair.New} {
+			if !configured[name] {
+				warnings = append(warnings, Warning{
+					Reason:  WarningPairUnknownEndpoint,
+					Message: fmt.Sprintf("pair %q compares endpoint %q, which is not configured, so it is never compared", pair.Name, name),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// lintSharedCredentials warns once per endpoint whose access key another endpoint also uses
+func lintSharedCredentials(endpoints []S3EndpointConfig) []Warning {
+	users := make(map[string][]string)
+	for _, endpoint := range endpoints {
+		keys := []string{endpoint.AccessKey}
+		if endpoint.Secondary != nil {
+			keys = append(keys, endpoint.Secondary.AccessKey)
+		}
+		for _, key := range keys {
+			if key != "" && !slices.Contains(users[key], endpoint.Name) {
+				users[key] = append(users[key], endpoint.Name)
+			}
+		}
+	}
+
+	var warnings []Warning
+	keys := slices.Sorted(maps.Keys(users))
+	for _, endpoint := range endpoints {
+		for _, key := range keys {
+			names := users[key]
+			if len(names) < 2 || !slices.Contains(names, endpoint.Name) {
+				continue
+			}
+			others := slices.DeleteFunc(slices.Clone(names), func(name string) bool { return name == endpoint.Name })
+			warnings = append(warnings, Warning{
+				Reason:   WarningSharedCredentials,
+				Endpoint: endpoint.Name,
+				Message:  fmt.Sprintf("access key %s is also used by %s; one leak or rotation affects all of them", MaskAccessKey(key), strings.Join(others, ", ")),
+			})
+		}
+	}
+	return warnings
+}
+
+// isPrivateHost reports whether an endpoint URL points at a loopback, private or
+// cluster-internal address. An empty endpoint is AWS S3, which is public.
+func isPrivateHost(endpoint string) bool {
+	if endpoint == "" {
+		return false
+	}
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	host = strings.ToLower(host)
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range privateHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskAccessKey keeps the first four characters of an access key, enough to tell keys
+// apart in logs without revealing them
+func MaskAccessKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return key[:4] + strings.Repeat("*", len(key)-4)
+}
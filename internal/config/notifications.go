@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Endpoint severities accepted in the severity endpoint setting
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Notification channel types
+const (
+	ChannelOpsgenie = "opsgenie"
+	ChannelTeams    = "teams"
+	ChannelExec     = "exec"
+)
+
+// Exec channel defaults
+const (
+	DefaultExecTimeout       = 30 * time.Second
+	DefaultExecMaxConcurrent = 4
+)
+
+// DefaultOpsgenieAPIURL is the Opsgenie API of the US region
+const DefaultOpsgenieAPIURL = "https://api.opsgenie.com"
+
+// NotificationsConfig lists the channels notified when an endpoint's keys turn
+// invalid or recover, loaded from NOTIFICATIONS_JSON
+type NotificationsConfig struct {
+	Channels []ChannelConfig `json:"channels"`
+}
+
+// ChannelConfig is one notification channel. Severities limits it to endpoints of
+// those severities; empty means all.
+type ChannelConfig struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Severities []string        `json:"severities"`
+	Template   string          `json:"template"` // Go template replacing the default payload
+	Opsgenie   *OpsgenieConfig `json:"opsgenie"`
+	Teams      *TeamsConfig    `json:"teams"`
+	Exec       *ExecConfig     `json:"exec"`
+}
+
+// OpsgenieConfig creates an alert per failing endpoint and closes it on recovery
+type OpsgenieConfig struct {
+	APIKey   string   `json:"api_key"`
+	APIURL   string   `json:"api_url"`  // e.g. https://api.eu.opsgenie.com
+	Priority string   `json:"priority"` // P1-P5; derived from the endpoint severity when empty
+	Team     string   `json:"team"`     // optional responder team name
+	Tags     []string `json:"tags"`
+}
+
+// TeamsConfig posts an Adaptive Card to a Microsoft Teams webhook
+type TeamsConfig struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// ExecConfig runs a local command with the event as JSON on stdin
+type ExecConfig struct {
+	Command       []string `json:"command"` // program and arguments, run without a shell
+	Timeout       Duration `json:"timeout"`
+	MaxConcurrent int      `json:"max_concurrent"`
+}
+
+func validSeverity(severity string) bool {
+	return severity == SeverityCritical || severity == SeverityWarning || severity == SeverityInfo
+}
+
+// validateNotifications applies defaults and reports the first invalid channel
+func validateNotifications(notifications *NotificationsConfig) error {
+	names := make(map[string]bool)
+	for i := range notifications.Channels {
+		channel := &notifications.Channels[i]
+		if channel.Name == "" {
+			channel.Name = channel.Type
+		}
+		if names[channel.Name] {
+			return fmt.Errorf("channel %d: duplicate name %q", i, channel.Name)
+		}
+		names[channel.Name] = true
+
+		for _, severity := range channel.Severities {
+			if !validSeverity(severity) {
+				return fmt.Errorf("channel %q: unknown severity %q", channel.Name, severity)
+			}
+		}
+
+		switch channel.Type {
+		case ChannelOpsgenie:
+			og := channel.Opsgenie
+			if og == nil || og.APIKey == "" {
+				return fmt.Errorf("channel %q: opsgenie.api_key is required", channel.Name)
+			}
+			if og.APIURL == "" {
+				og.APIURL = DefaultOpsgenieAPIURL
+			}
+			if err := validateURL(og.APIURL); err != nil {
+				return fmt.Errorf("channel %q: opsgenie.api_url: %w", channel.Name, err)
+			}
+			switch og.Priority {
+			case "", "P1", "P2", "P3", "P4", "P5":
+			default:
+				return fmt.Errorf("channel %q: opsgenie.priority must be P1-P5, got %q", channel.Name, og.Priority)
+			}
+		case ChannelTeams:
+			if channel.Teams == nil || channel.Teams.WebhookURL == "" {
+				return fmt.Errorf("channel %q: teams.webhook_url is required", channel.Name)
+			}
+			if err := validateURL(channel.Teams.WebhookURL); err != nil {
+				return fmt.Errorf("channel %q: teams.webhook_url: %w", channel.Name, err)
+			}
+		case ChannelExec:
+			ex := channel.Exec
+			if ex == nil || len(ex.Command) == 0 || ex.Command[0] == "" {
+				return fmt.Errorf("channel %q: exec.command is required", channel.Name)
+			}
+			if ex.Timeout < 0 || ex.MaxConcurrent < 0 {
+				return fmt.Errorf("channel %q: exec.timeout and exec.max_concurrent cannot be negative", channel.Name)
+			}
+			if ex.Timeout == 0 {
+				ex.Timeout = Duration(DefaultExecTimeout)
+			}
+			if ex.MaxConcurrent == 0 {
+				ex.MaxConcurrent = DefaultExecMaxConcurrent
+			}
+		default:
+			return fmt.Errorf("channel %d: unknown type %q", i, channel.Type)
+		}
+	}
+	return nil
+}
+
+// validateURL requires an absolute http(s) URL
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http(s) URL, got %q", raw)
+	}
+	return nil
+}
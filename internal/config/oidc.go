@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"key-aws-exporter/internal/auth"
+)
+
+// DefaultOIDCRolesClaim is the claim roles are read from when roles_claim is not set
+const DefaultOIDCRolesClaim = "roles"
+
+// DefaultOIDCLeeway is the clock skew tolerated on exp and nbf
+const DefaultOIDCLeeway = time.Minute
+
+// OIDCConfig requires API callers to present a JWT issued by an OIDC provider, loaded
+// from OIDC_JSON. The roles in the token's roles_claim are mapped to permissions by
+// role_permissions, e.g. {"s3-operators": ["read", "validate"], "platform": ["admin"]},
+// unless RBAC_JSON authorizes them instead.
+type OIDCConfig struct {
+	Issuer string `json:"issuer"`
+	// Audience must be one of the token's aud values
+	Audience string `json:"audience"`
+	// JWKSURL skips discovery through <issuer>/.well-known/openid-configuration
+	JWKSURL string `json:"jwks_url"`
+	// RolesClaim names the claim listing the caller's roles; dots reach into nested
+	// objects, e.g. "realm_access.roles"
+	RolesClaim      string              `json:"roles_claim"`
+	RolePermissions map[string][]string `json:"role_permissions"`
+	Leeway          Duration            `json:"leeway"`
+}
+
+// validateOIDC applies defaults and reports the first invalid setting
+func validateOIDC(c *OIDCConfig) error {
+	if c.Issuer == "" || c.Audience == "" {
+		return fmt.Errorf("issuer and audience are required")
+	}
+	if !strings.HasPrefix(c.Issuer, "https://") && !strings.HasPrefix(c.Issuer, "http://") {
+		return fmt.Errorf("issuer must be a URL, got %q", c.Issuer)
+	}
+	for role, permissions := range c.RolePermissions {
+		for _, permission := range permissions {
+			if !slices.Contains(auth.Permissions, auth.Permission(permission)) {
+				return fmt.Errorf("role %q: permission must be one of %v, got %q", role, auth.Permissions, permission)
+			}
+		}
+	}
+	if c.Leeway < 0 {
+		return fmt.Errorf("leeway cannot be negative")
+	}
+	if c.RolesClaim == "" {
+		c.RolesClaim = DefaultOIDCRolesClaim
+	}
+	if c.Leeway == 0 {
+		c.Leeway = Duration(DefaultOIDCLeeway)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AccountIDPlaceholder is replaced with the account ID in the names and buckets of
+// per-account endpoints
+const AccountIDPlaceholder = "{account_id}"
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// OrganizationsConfig validates a set of buckets in every account of an AWS organization,
+// assuming the same audit role in each account
+type OrganizationsConfig struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	// Endpoint overrides the Organizations API the SDK resolves for Region
+	Endpoint   string `json:"endpoint"`
+	Region     string `json:"region"`
+	RoleName   string `json:"role_name"`
+	ExternalID string `json:"external_id"`
+	Partition  string `json:"partition"`
+	// Accounts limits the sweep to these account IDs; empty sweeps every active account
+	Accounts        []string `json:"accounts"`
+	ExcludeAccounts []string `json:"exclude_accounts"`
+	// Buckets are validated in every account; {account_id} is replaced in name and bucket
+	Buckets  []S3EndpointConfig `json:"buckets"`
+	Interval Duration           `json:"interval"`
+}
+
+// validateOrganizations applies defaults and reports the first invalid setting
+func validateOrganizations(o *OrganizationsConfig) error {
+	if o.AccessKey == "" || o.SecretKey == "" {
+		return fmt.Errorf("access_key and secret_key are required")
+	}
+	if o.RoleName == "" || strings.ContainsAny(o.RoleName, ":") {
+		return fmt.Errorf("role_name must name the audit role present in every account, e.g. \"s3-key-audit\"")
+	}
+	if len(o.Buckets) == 0 {
+		return fmt.Errorf("buckets must list at least one bucket to validate per account")
+	}
+	for _, id := range append(append([]string(nil), o.Accounts...), o.ExcludeAccounts...) {
+		if !accountIDPattern.MatchString(id) {
+			return fmt.Errorf("account IDs must have 12 digits, got %q", id)
+		}
+	}
+	if o.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if o.Interval == 0 {
+		o.Interval = Duration(DefaultDiscoveryInterval)
+	}
+	if o.Region == "" {
+		o.Region = DefaultS3Region
+	}
+	if o.Partition == "" {
+		o.Partition = "aws"
+	}
+
+	names := make(map[string]bool, len(o.Buckets))
+	for i := range o.Buckets {
+		b := &o.Buckets[i]
+		if b.Bucket == "" {
+			return fmt.Errorf("buckets %d: bucket is required", i)
+		}
+		if b.AccessKey != "" || b.SecretKey != "" || b.Secondary != nil || b.Rotation != nil || b.RoleARN != "" {
+			return fmt.Errorf("buckets %d: credentials, secondary, rotation and role_arn come from the organization", i)
+		}
+		if b.Name == "" {
+			b.Name = b.Bucket
+		}
+		if names[b.Name] {
+			return fmt.Errorf("buckets %d: duplicate name %q", i, b.Name)
+		}
+		names[b.Name] = true
+		if b.Region == "" {
+			b.Region = DefaultS3Region
+		}
+		if b.ProbeDepth == "" {
+			b.ProbeDepth = ProbeDepthShallow
+		}
+		if !validProbeDepth(b.ProbeDepth) {
+			return fmt.Errorf("buckets %d: probe_depth must be %q or %q, got %q", i, ProbeDepthShallow, ProbeDepthDeep, b.ProbeDepth)
+		}
+		if b.IPFamily == "" {
+			b.IPFamily = IPFamilyAuto
+		}
+		if b.Severity == "" {
+			b.Severity = SeverityWarning
+		}
+		if err := validateChecks(b.Checks); err != nil {
+			return fmt.Errorf("buckets %d: %w", i, err)
+		}
+	}
+	return nil
+}
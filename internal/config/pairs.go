@@ -0,0 +1,34 @@
+package config
+
+import "fmt"
+
+// EndpointPair compares two endpoints serving the same bucket, e.g. the old and the new
+// gateway during a storage migration, to decide when traffic can be cut over
+type EndpointPair struct {
+	Name string `json:"name"`
+	Old  string `json:"old"` // endpoint name of the gateway being migrated away from
+	New  string `json:"new"` // endpoint name of the gateway being migrated to
+}
+
+// validateEndpointPairs applies default names and rejects incomplete or duplicate pairs.
+// Endpoints are matched at runtime, since discovery may add them after startup.
+func validateEndpointPairs(pairs []EndpointPair) error {
+	seen := make(map[string]bool, len(pairs))
+	for i := range pairs {
+		pair := &pairs[i]
+		if pair.Old == "" || pair.New == "" {
+			return fmt.Errorf("pair %d: old and new are required", i)
+		}
+		if pair.Old == pair.New {
+			return fmt.Errorf("pair %d: old and new must be different endpoints, got %q twice", i, pair.Old)
+		}
+		if pair.Name == "" {
+			pair.Name = pair.Old + "-vs-" + pair.New
+		}
+		if seen[pair.Name] {
+			return fmt.Errorf("pair %d: duplicate name %q", i, pair.Name)
+		}
+		seen[pair.Name] = true
+	}
+	return nil
+}
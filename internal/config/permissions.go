@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPermissionCheckInterval is how often expected permissions are asserted
+const DefaultPermissionCheckInterval = time.Hour
+
+// Expected permission verdicts
+const (
+	PermissionAllowed = "allowed"
+	PermissionDenied  = "denied"
+)
+
+// PermissionOperations are the operations permission discovery reports on
+var PermissionOperations = []string{"ListObjectsV2", "GetObject", "PutObject", "DeleteObject", "MultipartUpload", "GetBucketPolicy"}
+
+// validateExpectedPermissions checks that every expectation names a known operation
+// and is either allowed or denied
+func validateExpectedPermissions(expected map[string]string) error {
+	operations := make([]string, 0, len(expected))
+	for operation := range expected {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	for _, operation := range operations {
+		known := false
+		for _, candidate := range PermissionOperations {
+			if operation == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("expected_permissions: unknown operation %q, expected one of %s", operation, strings.Join(PermissionOperations, ", "))
+		}
+		if verdict := expected[operation]; verdict != PermissionAllowed && verdict != PermissionDenied {
+			return fmt.Errorf("expected_permissions: %s must be %q or %q, got %q", operation, PermissionAllowed, PermissionDenied, verdict)
+		}
+	}
+	return nil
+}
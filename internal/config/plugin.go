@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PluginConfig replaces the S3 probe of an endpoint with an external program, e.g. a
+// check of a vendor-specific API. The program gets the endpoint as JSON on stdin and
+// prints its verdict as JSON on stdout; see the README for the contract.
+type PluginConfig struct {
+	// Exec is the program and its arguments, run without a shell
+	Exec []string `json:"exec"`
+	// Settings are passed to the program as they are
+	Settings json.RawMessage `json:"settings"`
+}
+
+// validatePlugin reports an invalid plugin; endpoints with one need a name but no
+// bucket or keys, which are only passed on to the program
+func validatePlugin(endpoint S3EndpointConfig) error {
+	plugin := endpoint.Plugin
+	if len(plugin.Exec) == 0 || plugin.Exec[0] == "" {
+		return fmt.Errorf("plugin.exec is required")
+	}
+	if endpoint.Name == "" {
+		return fmt.Errorf("name is required for plugin endpoints")
+	}
+	// Key ages and rotation go through IAM with the endpoint's S3 client
+	if endpoint.KeyAgeFromIAM {
+		return fmt.Errorf("key_age_from_iam is not supported for plugin endpoints")
+	}
+	return nil
+}
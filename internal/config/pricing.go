@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+// DefaultPricingProvider is the PROBE_PRICING_JSON entry used for endpoints whose
+// provider has no entry of its own, or that declare no provider
+const DefaultPricingProvider = "default"
+
+// ProbePrice is a provider's request pricing in USD per 1,000 requests
+type ProbePrice struct {
+	ClassA float64 `json:"class_a_per_1000"` // PUT, COPY, POST and LIST requests
+	ClassB float64 `json:"class_b_per_1000"` // GET, HEAD and other requests; DELETE is free
+}
+
+// validateProbePricing reports the first invalid price
+func validateProbePricing(pricing map[string]ProbePrice) error {
+	for provider, price := range pricing {
+		if price.ClassA < 0 || price.ClassB < 0 {
+			return fmt.Errorf("%s: prices cannot be negative", provider)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// ParsePrivateCIDRs parses the ranges an endpoint's VPC endpoint answers from
+func ParsePrivateCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("private_cidrs: invalid CIDR %q", cidr)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"key-aws-exporter/internal/auth"
+)
+
+// RBACConfig limits each role of an authenticated caller to some routes and endpoints,
+// loaded from RBAC_JSON. It replaces the permissions of OIDC role_permissions, e.g.
+// {"roles": {"team-a": {"routes": ["POST /validate/{endpoint}"], "endpoints": ["team-a-*"]},
+// "platform-admins": {"routes": ["*"]}}}.
+type RBACConfig struct {
+	Roles map[string]auth.Role `json:"roles"`
+}
+
+// validateRBAC reports the first invalid role
+func validateRBAC(c *RBACConfig) error {
+	if len(c.Roles) == 0 {
+		return fmt.Errorf("at least one role is required")
+	}
+	for name, role := range c.Roles {
+		if len(role.Routes) == 0 {
+			return fmt.Errorf("role %q: routes are required", name)
+		}
+		for _, route := range role.Routes {
+			if route == auth.AllRoutes {
+				continue
+			}
+			method, routePath, ok := strings.Cut(route, " ")
+			if !ok || method == "" || method != strings.ToUpper(method) || !strings.HasPrefix(routePath, "/") {
+				return fmt.Errorf("role %q: route must be \"*\" or a method and path, e.g. \"POST /validate/{endpoint}\", got %q", name, route)
+			}
+		}
+		for _, glob := range role.Endpoints {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("role %q: invalid endpoint pattern %q", name, glob)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Remote write defaults
+const (
+	DefaultRemoteWriteInterval = 30 * time.Second
+	DefaultRemoteWriteTimeout  = 10 * time.Second
+)
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// RemoteWriteConfig pushes the exporter's series to a Prometheus remote write receiver
+// such as Mimir or VictoriaMetrics, loaded from REMOTE_WRITE_JSON
+type RemoteWriteConfig struct {
+	URL      string   `json:"url"`
+	Interval Duration `json:"interval"`
+	Timeout  Duration `json:"timeout"`
+	// BasicAuth and BearerToken authenticate the pushes; at most one may be set
+	BasicAuth   *BasicAuthConfig `json:"basic_auth"`
+	BearerToken string           `json:"bearer_token"`
+	// Headers are added to every push, e.g. X-Scope-OrgID for Mimir tenants
+	Headers map[string]string `json:"headers"`
+	// ExternalLabels are added to every series that does not have them; job defaults
+	// to key-aws-exporter
+	ExternalLabels map[string]string `json:"external_labels"`
+}
+
+// BasicAuthConfig is a username and password sent as HTTP basic authentication
+type BasicAuthConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// validateRemoteWrite applies defaults and reports the first invalid setting
+func validateRemoteWrite(rw *RemoteWriteConfig) error {
+	parsed, err := url.Parse(rw.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, got %q", rw.URL)
+	}
+	if rw.Interval < 0 || rw.Timeout < 0 {
+		return fmt.Errorf("interval and timeout cannot be negative")
+	}
+	if rw.Interval == 0 {
+		rw.Interval = Duration(DefaultRemoteWriteInterval)
+	}
+	if rw.Timeout == 0 {
+		rw.Timeout = Duration(DefaultRemoteWriteTimeout)
+	}
+	if rw.BasicAuth != nil && rw.BearerToken != "" {
+		return fmt.Errorf("basic_auth and bearer_token cannot both be set")
+	}
+	if rw.BasicAuth != nil && rw.BasicAuth.Username == "" {
+		return fmt.Errorf("basic_auth requires a username")
+	}
+	for name := range rw.ExternalLabels {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("external label name %q is not a valid Prometheus label name", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+
+	"key-aws-exporter/internal/schedule"
+)
+
+// DefaultDigestSchedule sends the email digest every Monday at 08:00
+const DefaultDigestSchedule = "0 8 * * 1"
+
+// ReportsConfig holds scheduled reports, loaded from REPORTS_JSON
+type ReportsConfig struct {
+	Email *EmailDigestConfig `json:"email"`
+}
+
+// EmailDigestConfig emails a summary of failing and flapping endpoints on a cron schedule
+type EmailDigestConfig struct {
+	Schedule string     `json:"schedule"` // cron expression in the exporter's local time
+	Subject  string     `json:"subject"`
+	Template string     `json:"template"` // Go template replacing the default body
+	From     string     `json:"from"`
+	To       []string   `json:"to"`
+	SMTP     SMTPConfig `json:"smtp"`
+}
+
+// SMTPConfig is the relay used to send emails. Plain connections are upgraded with
+// STARTTLS when the server offers it; implicit_tls dials TLS directly (port 465).
+type SMTPConfig struct {
+	Address     string `json:"address"` // host:port
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	ImplicitTLS bool   `json:"implicit_tls"`
+}
+
+// validateReports applies defaults and reports the first invalid report setting
+func validateReports(reports *ReportsConfig) error {
+	if reports == nil || reports.Email == nil {
+		return nil
+	}
+	email := reports.Email
+	if email.Schedule == "" {
+		email.Schedule = DefaultDigestSchedule
+	}
+	if _, err := schedule.Parse(email.Schedule); err != nil {
+		return fmt.Errorf("email.schedule: %w", err)
+	}
+	if _, _, err := net.SplitHostPort(email.SMTP.Address); err != nil {
+		return fmt.Errorf("email.smtp.address must be host:port, got %q", email.SMTP.Address)
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		return fmt.Errorf("email.from: %w", err)
+	}
+	if len(email.To) == 0 {
+		return fmt.Errorf("email.to needs at least one recipient")
+	}
+	for _, to := range email.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("email.to %q: %w", to, err)
+		}
+	}
+	return nil
+}
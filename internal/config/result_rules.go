@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+
+	"key-aws-exporter/internal/expr"
+)
+
+// ResultRuleVars are the variables a result rule's when expression may read
+var ResultRuleVars = []expr.Var{
+	{Name: "endpoint", Type: expr.String},
+	{Name: "bucket", Type: expr.String},
+	{Name: "region", Type: expr.String},
+	{Name: "provider", Type: expr.String},
+	{Name: "labels", Type: expr.StringMap},
+	{Name: "severity", Type: expr.String},
+	{Name: "depth", Type: expr.String},
+	{Name: "is_valid", Type: expr.Bool},
+	{Name: "error_type", Type: expr.String},
+	{Name: "error_code", Type: expr.String},
+	{Name: "http_status", Type: expr.Int},
+	{Name: "message", Type: expr.String},
+	{Name: "response_time_ms", Type: expr.Int},
+}
+
+var resultRuleEnv = expr.MustNewEnv(ResultRuleVars...)
+
+// ResultRule post-processes the results its when expression matches, before they reach
+// metrics and notifications, e.g. to treat NoSuchBucket on scratch endpoints as a
+// warning. Rules loaded from RESULT_RULES_JSON apply in order, each to the result as
+// the rules before it left it.
+type ResultRule struct {
+	Name string `json:"name"`
+	// When is a CEL expression over ResultRuleVars
+	When string `json:"when"`
+	// ErrorType reclassifies failed results
+	ErrorType string `json:"error_type"`
+	// Severity overrides the endpoint's severity for notifications about the result
+	Severity string `json:"severity"`
+	// Suppress keeps failed results from metrics, history and notifications; callers
+	// of the API still get them
+	Suppress bool `json:"suppress"`
+}
+
+// Compile parses and type-checks the rule's when expression
+func (r ResultRule) Compile() (*expr.Program, error) {
+	return resultRuleEnv.Compile(r.When)
+}
+
+// validateResultRules applies default names and reports the first invalid rule
+func validateResultRules(rules []ResultRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i)
+		}
+		if rule.When == "" {
+			return fmt.Errorf("rule %q: when is required", rule.Name)
+		}
+		if _, err := rule.Compile(); err != nil {
+			return fmt.Errorf("rule %q: invalid when: %w", rule.Name, err)
+		}
+		if rule.ErrorType == "" && rule.Severity == "" && !rule.Suppress {
+			return fmt.Errorf("rule %q: set error_type, severity or suppress", rule.Name)
+		}
+		if rule.Severity != "" && !validSeverity(rule.Severity) {
+			return fmt.Errorf("rule %q: severity must be %q, %q or %q, got %q", rule.Name, SeverityCritical, SeverityWarning, SeverityInfo, rule.Severity)
+		}
+	}
+	return nil
+}
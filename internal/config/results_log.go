@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+// Results log defaults
+const (
+	DefaultResultsLogMaxSizeMB  = 100
+	DefaultResultsLogMaxBackups = 5
+)
+
+// ResultsLogConfig appends every validation result as a JSON line to a file, rotated
+// by size, for loading validation history into a warehouse
+type ResultsLogConfig struct {
+	Path string
+	// MaxSize is the size in bytes at which the file is rotated to Path.1
+	MaxSize int64
+	// MaxBackups is how many rotated files are kept; the oldest is deleted
+	MaxBackups int
+}
+
+// validateResultsLog reports a non-positive size or negative backup count
+func validateResultsLog(r *ResultsLogConfig) error {
+	if r.MaxSize <= 0 {
+		return fmt.Errorf("RESULTS_LOG_MAX_SIZE_MB must be positive")
+	}
+	if r.MaxBackups < 0 {
+		return fmt.Errorf("RESULTS_LOG_MAX_BACKUPS cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry modes of the AWS SDK
+const (
+	RetryModeStandard = "standard"
+	RetryModeAdaptive = "adaptive"
+)
+
+// validateRetry rejects retry settings the SDK cannot apply
+func validateRetry(endpoint S3EndpointConfig) error {
+	if endpoint.RetryMode != "" && endpoint.RetryMode != RetryModeStandard && endpoint.RetryMode != RetryModeAdaptive {
+		return fmt.Errorf("retry_mode must be %q or %q, got %q", RetryModeStandard, RetryModeAdaptive, endpoint.RetryMode)
+	}
+	if endpoint.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts cannot be negative, got %d", endpoint.MaxAttempts)
+	}
+	if endpoint.MaxBackoff < 0 {
+		return fmt.Errorf("max_backoff cannot be negative, got %s", time.Duration(endpoint.MaxBackoff))
+	}
+	return nil
+}
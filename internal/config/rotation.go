@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Secret store types accepted in rotation.store.type
+const (
+	StoreSecretsManager = "secretsmanager"
+	StoreKubernetes     = "kubernetes"
+)
+
+// Default secret fields holding the rotated key pair
+const (
+	DefaultAccessKeyField = "access_key"
+	DefaultSecretKeyField = "secret_key"
+)
+
+// DefaultDeactivateAfter is how long a replaced key stays active after a rotation
+const DefaultDeactivateAfter = time.Hour
+
+// RotationConfig opts an endpoint into automatic IAM access key rotation once its key
+// is older than the rotation policy. The endpoint's key pair is read from Store.
+type RotationConfig struct {
+	Store SecretStoreConfig `json:"store"`
+	// DeleteInactiveKeys frees the second IAM key slot by deleting keys already deactivated
+	DeleteInactiveKeys bool `json:"delete_inactive_keys"`
+	// DeactivateAfter keeps the replaced key active while workloads pick up the new one
+	DeactivateAfter Duration `json:"deactivate_after"`
+}
+
+// SecretStoreConfig selects where a rotated key pair is written
+type SecretStoreConfig struct {
+	Type           string `json:"type"`
+	AccessKeyField string `json:"access_key_field"`
+	SecretKeyField string `json:"secret_key_field"`
+	// SecretID and Region address an AWS Secrets Manager secret
+	SecretID string `json:"secret_id"`
+	Region   string `json:"region"`
+	// Namespace and Name address a Kubernetes Secret; the namespace defaults to the pod's
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// APIURL overrides the Secrets Manager or Kubernetes API endpoint
+	APIURL string `json:"api_url"`
+}
+
+// validateRotation checks that rotation is possible for the endpoint and fills in
+// store defaults. defaultMaxAge is the global KEY_MAX_AGE.
+func validateRotation(endpoint *S3EndpointConfig, defaultMaxAge time.Duration) error {
+	rotation := endpoint.Rotation
+	if rotation == nil {
+		return nil
+	}
+	if !endpoint.KeyAgeFromIAM {
+		return fmt.Errorf("rotation requires key_age_from_iam")
+	}
+	if endpoint.KeyMaxAge == 0 && defaultMaxAge == 0 {
+		return fmt.Errorf("rotation requires key_max_age or KEY_MAX_AGE")
+	}
+	if endpoint.Secondary != nil {
+		return fmt.Errorf("rotation manages the secondary slot itself; remove secondary")
+	}
+	if endpoint.SessionToken != "" {
+		return fmt.Errorf("rotation needs long-term IAM user keys, not session credentials")
+	}
+	// Keys given inline would still be the replaced, deactivated key after a restart
+	if endpoint.AccessKey != "" || endpoint.SecretKey != "" {
+		return fmt.Errorf("rotation reads the key pair from rotation.store; remove access_key and secret_key")
+	}
+	if rotation.DeactivateAfter < 0 {
+		return fmt.Errorf("rotation.deactivate_after cannot be negative, got %s", time.Duration(rotation.DeactivateAfter))
+	}
+	if rotation.DeactivateAfter == 0 {
+		rotation.DeactivateAfter = Duration(DefaultDeactivateAfter)
+	}
+
+	store := &rotation.Store
+	if store.AccessKeyField == "" {
+		store.AccessKeyField = DefaultAccessKeyField
+	}
+	if store.SecretKeyField == "" {
+		store.SecretKeyField = DefaultSecretKeyField
+	}
+	switch store.Type {
+	case StoreSecretsManager:
+		if store.SecretID == "" {
+			return fmt.Errorf("rotation.store.secret_id is required")
+		}
+		if store.Region == "" {
+			store.Region = endpoint.Region
+		}
+	case StoreKubernetes:
+		if store.Name == "" {
+			return fmt.Errorf("rotation.store.name is required")
+		}
+	default:
+		return fmt.Errorf("rotation.store.type must be %q or %q, got %q", StoreSecretsManager, StoreKubernetes, store.Type)
+	}
+	if store.APIURL != "" {
+		if err := validateURL(store.APIURL); err != nil {
+			return fmt.Errorf("rotation.store.api_url: %w", err)
+		}
+	}
+	return nil
+}
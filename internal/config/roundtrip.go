@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Server-side encryption modes a round-trip verification can request
+var roundTripSSEModes = []string{"AES256", "aws:kms", "aws:kms:dsse"}
+
+// RoundTripConfig makes deep probes verify that their probe object reads back identical
+// to what was written: the body byte for byte, plus the encryption and metadata set here
+type RoundTripConfig struct {
+	// SSE is requested on upload and expected back: AES256, aws:kms or aws:kms:dsse
+	SSE string `json:"sse"`
+	// KMSKeyID is the key requested with aws:kms; key IDs and ARNs are also expected back
+	KMSKeyID string `json:"kms_key_id"`
+	// Metadata is written as user metadata and expected back unchanged
+	Metadata map[string]string `json:"metadata"`
+}
+
+// validateRoundTrip rejects encryption settings S3 would refuse on upload
+func validateRoundTrip(endpoint S3EndpointConfig) error {
+	roundTrip := endpoint.RoundTrip
+	if roundTrip == nil {
+		return nil
+	}
+	if roundTrip.SSE != "" && !slices.Contains(roundTripSSEModes, roundTrip.SSE) {
+		return fmt.Errorf("roundtrip.sse must be one of %v, got %q", roundTripSSEModes, roundTrip.SSE)
+	}
+	if roundTrip.KMSKeyID != "" && roundTrip.SSE != "aws:kms" && roundTrip.SSE != "aws:kms:dsse" {
+		return fmt.Errorf("roundtrip.kms_key_id requires sse aws:kms or aws:kms:dsse")
+	}
+	for key := range roundTrip.Metadata {
+		if key == "" {
+			return fmt.Errorf("roundtrip.metadata keys cannot be empty")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Snapshot formats
+const (
+	SnapshotFormatPrometheus = "prometheus"
+	SnapshotFormatJSON       = "json"
+)
+
+// DefaultSnapshotInterval is how often snapshots are written when no interval is configured
+const DefaultSnapshotInterval = time.Minute
+
+// SnapshotConfig periodically writes the exporter's state to an S3 object, so a
+// collector in another network segment can pull it without reaching the exporter.
+// Loaded from SNAPSHOT_EXPORT_JSON.
+type SnapshotConfig struct {
+	Endpoint     string `json:"endpoint"`
+	Region       string `json:"region"`
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	UsePathStyle bool   `json:"use_path_style"`
+	Bucket       string `json:"bucket"`
+	// Key is overwritten on every write; it defaults to key-aws-exporter/metrics.prom
+	// or key-aws-exporter/results.json depending on Format
+	Key string `json:"key"`
+	// Format is prometheus for the /metrics text exposition or json for the latest
+	// result of every endpoint
+	Format   string   `json:"format"`
+	Interval Duration `json:"interval"`
+}
+
+// validateSnapshot applies defaults and reports the first invalid setting
+func validateSnapshot(s *SnapshotConfig) error {
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return fmt.Errorf("access_key and secret_key are required")
+	}
+	if s.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	switch s.Format {
+	case "":
+		s.Format = SnapshotFormatPrometheus
+	case SnapshotFormatPrometheus, SnapshotFormatJSON:
+	default:
+		return fmt.Errorf("format must be %q or %q, got %q", SnapshotFormatPrometheus, SnapshotFormatJSON, s.Format)
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("interval cannot be negative")
+	}
+	if s.Interval == 0 {
+		s.Interval = Duration(DefaultSnapshotInterval)
+	}
+	if s.Region == "" {
+		s.Region = DefaultS3Region
+	}
+	s.Key = strings.TrimPrefix(s.Key, "/")
+	if s.Key == "" {
+		s.Key = "key-aws-exporter/metrics.prom"
+		if s.Format == SnapshotFormatJSON {
+			s.Key = "key-aws-exporter/results.json"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultStatsDPrefix namespaces the metrics sent to StatsD
+const DefaultStatsDPrefix = "s3."
+
+// StatsDConfig sends validation results to a DogStatsD agent
+type StatsDConfig struct {
+	// Address is host:port for UDP or unix:///path for a Unix datagram socket
+	Address string
+	Prefix  string
+	// Tags are added to every metric, e.g. env:prod
+	Tags []string
+}
+
+// validateStatsD reports an unusable address or tag
+func validateStatsD(s *StatsDConfig) error {
+	if path, ok := strings.CutPrefix(s.Address, "unix://"); ok {
+		if path == "" {
+			return fmt.Errorf("STATSD_ADDRESS needs a socket path after unix://")
+		}
+	} else if !strings.Contains(s.Address, ":") {
+		return fmt.Errorf("STATSD_ADDRESS must be host:port or unix:///path, got %q", s.Address)
+	}
+	for _, tag := range s.Tags {
+		if strings.ContainsAny(tag, "|,#\n") {
+			return fmt.Errorf("STATSD_TAGS: tag %q cannot contain '|', ',', '#' or newlines", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"key-aws-exporter/internal/expr"
+)
+
+// SuccessVars are the variables a success criterion's expect expression may read
+var SuccessVars = []expr.Var{
+	{Name: "is_valid", Type: expr.Bool},
+	{Name: "error_type", Type: expr.String},
+	{Name: "error_code", Type: expr.String},
+	{Name: "http_status", Type: expr.Int},
+	{Name: "response_time_ms", Type: expr.Int},
+	{Name: "object_count", Type: expr.Int},
+}
+
+var successEnv = expr.MustNewEnv(SuccessVars...)
+
+// Objects a success criterion's listing may count
+const (
+	DefaultSuccessMaxKeys = 1000
+	maxSuccessMaxKeys     = 1000
+)
+
+// SuccessConfig replaces "the call did not fail" as the definition of a successful
+// shallow probe, e.g. to expect objects under a prefix, an AccessDenied for a key that
+// must not read the bucket, or a latency bound
+type SuccessConfig struct {
+	// Expect is a CEL expression over SuccessVars
+	Expect string `json:"expect"`
+	// Prefix is listed instead of the bucket root
+	Prefix string `json:"prefix"`
+	// MaxKeys bounds object_count; 0 lists DefaultSuccessMaxKeys objects when Expect
+	// reads object_count and one otherwise
+	MaxKeys int32 `json:"max_keys"`
+}
+
+// Compile parses and type-checks the criterion's expect expression
+func (s SuccessConfig) Compile() (*expr.Program, error) {
+	return successEnv.Compile(s.Expect)
+}
+
+// validateSuccess compiles the endpoint's success criterion and applies the default
+// listing size
+func validateSuccess(endpoint *S3EndpointConfig) error {
+	success := endpoint.Success
+	if success == nil {
+		return nil
+	}
+	if endpoint.Plugin != nil {
+		return fmt.Errorf("success does not apply to plugin endpoints")
+	}
+	if success.Expect == "" {
+		return fmt.Errorf("success.expect is required")
+	}
+	prog, err := success.Compile()
+	if err != nil {
+		return fmt.Errorf("invalid success.expect: %w", err)
+	}
+	if success.MaxKeys < 0 || success.MaxKeys > maxSuccessMaxKeys {
+		return fmt.Errorf("success.max_keys must be between 1 and %d, got %d", maxSuccessMaxKeys, success.MaxKeys)
+	}
+	if success.MaxKeys == 0 {
+		success.MaxKeys = 1
+		if slices.Contains(prog.Vars(), "object_count") {
+			success.MaxKeys = DefaultSuccessMaxKeys
+		}
+	}
+	return nil
+}
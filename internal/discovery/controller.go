@@ -0,0 +1,115 @@
+// Package discovery keeps validation endpoints in sync with the tagged buckets of an
+// account, or with the accounts of an AWS organization
+package discovery
+
+import (
+	"context"
+	"sync"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Controller creates an endpoint for every bucket carrying the discovery tags and
+// removes it once the bucket is gone or untagged
+type Controller struct {
+	cfg      *config.DiscoveryConfig
+	log      *logrus.Logger
+	discover func(context.Context, s3.BucketDiscovery) (*s3.BucketDiscoveryResult, error)
+
+	mu sync.Mutex
+	registry
+}
+
+// NewController sets up discovery next to the explicitly configured endpoints
+func NewController(cfg *config.DiscoveryConfig, static []config.S3EndpointConfig, manager EndpointManager, log *logrus.Logger) *Controller {
+	return &Controller{
+		cfg:      cfg,
+		log:      log,
+		discover: s3.DiscoverBuckets,
+		registry: newRegistry(static, manager, log),
+	}
+}
+
+// Endpoints returns the number of endpoints created by discovery
+func (c *Controller) Endpoints() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.endpoints)
+}
+
+// Sync lists the tagged buckets and adds, updates or removes their endpoints. When the
+// listing fails the current endpoints are kept.
+func (c *Controller) Sync(ctx context.Context) error {
+	result, err := c.discover(ctx, s3.BucketDiscovery{
+		Endpoint:     c.cfg.Endpoint,
+		Region:       c.cfg.Region,
+		AccessKey:    c.cfg.AccessKey,
+		SecretKey:    c.cfg.SecretKey,
+		SessionToken: c.cfg.SessionToken,
+		UsePathStyle: c.cfg.UsePathStyle,
+		Tags:         c.cfg.Tags,
+	})
+	if err != nil {
+		metrics.RecordDiscoveryFailure(SourceTags)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wanted := make(map[string]config.S3EndpointConfig, len(result.Buckets))
+	for _, bucket := range result.Buckets {
+		endpoint := c.endpointFor(bucket)
+		wanted[endpoint.Name] = endpoint
+	}
+	// Buckets whose tags could not be read this time keep their endpoints
+	for bucket, err := range result.Unreadable {
+		name := c.cfg.NamePrefix + bucket
+		if endpoint, ok := c.endpoints[name]; ok {
+			wanted[name] = endpoint
+			c.log.WithError(err).WithField("endpoint", name).Warn("Could not read bucket tags, keeping the discovered endpoint")
+		}
+	}
+
+	c.registry.apply(wanted)
+	metrics.SetDiscoveredEndpoints(SourceTags, len(c.endpoints))
+	return nil
+}
+
+// endpointFor builds the endpoint of a discovered bucket from the template
+func (c *Controller) endpointFor(bucket s3.DiscoveredBucket) config.S3EndpointConfig {
+	var endpoint config.S3EndpointConfig
+	if c.cfg.Template != nil {
+		endpoint = *c.cfg.Template
+	}
+	endpoint.Name = c.cfg.NamePrefix + bucket.Name
+	endpoint.Bucket = bucket.Name
+	endpoint.Region = bucket.Region
+	endpoint.AccessKey = c.cfg.AccessKey
+	endpoint.SecretKey = c.cfg.SecretKey
+	endpoint.SessionToken = c.cfg.SessionToken
+	if endpoint.Endpoint == "" {
+		endpoint.Endpoint = c.cfg.Endpoint
+		endpoint.UsePathStyle = endpoint.UsePathStyle || c.cfg.UsePathStyle
+	}
+	if endpoint.ProbeDepth == "" {
+		endpoint.ProbeDepth = config.ProbeDepthShallow
+	}
+	if endpoint.IPFamily == "" {
+		endpoint.IPFamily = config.IPFamilyAuto
+	}
+	if endpoint.Severity == "" {
+		endpoint.Severity = config.SeverityWarning
+	}
+
+	endpoint.RoleARN = c.cfg.RoleARN
+	endpoint.ExternalID = c.cfg.ExternalID
+	if role, ok := bucket.Tags[c.cfg.RoleTag]; ok && c.cfg.RoleTag != "" {
+		endpoint.RoleARN = role
+	}
+	return endpoint
+}
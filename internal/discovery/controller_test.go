@@ -0,0 +1,101 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type fakeManager struct {
+	endpoints map[string]config.S3EndpointConfig
+	adds      int
+}
+
+func (m *fakeManager) AddEndpoint(endpointCfg config.S3EndpointConfig) {
+	m.endpoints[endpointCfg.Name] = endpointCfg
+	m.adds++
+}
+
+func (m *fakeManager) RemoveEndpoint(endpointName string) bool {
+	_, ok := m.endpoints[endpointName]
+	delete(m.endpoints, endpointName)
+	return ok
+}
+
+func newTestController(cfg *config.DiscoveryConfig, static []config.S3EndpointConfig, result **s3.BucketDiscoveryResult, err *error) (*Controller, *fakeManager) {
+	manager := &fakeManager{endpoints: make(map[string]config.S3EndpointConfig)}
+	c := NewController(cfg, static, manager, logrus.New())
+	c.discover = func(context.Context, s3.BucketDiscovery) (*s3.BucketDiscoveryResult, error) {
+		return *result, *err
+	}
+	return c, manager
+}
+
+func TestSyncAddsAndRemovesEndpoints(t *testing.T) {
+	cfg := &config.DiscoveryConfig{
+		AccessKey:  "AK",
+		SecretKey:  "SK",
+		NamePrefix: "prod-",
+		RoleARN:    "arn:aws:iam::123456789012:role/default",
+		RoleTag:    "monitor-role",
+		Template:   &config.S3EndpointConfig{ProbeDepth: config.ProbeDepthDeep, Provider: "aws"},
+	}
+	result := &s3.BucketDiscoveryResult{Buckets: []s3.DiscoveredBucket{
+		{Name: "logs", Region: "eu-west-1"},
+		{Name: "backups", Region: "us-east-1", Tags: map[string]string{"monitor-role": "arn:aws:iam::123456789012:role/backups"}},
+	}}
+	var err error
+	c, manager := newTestController(cfg, nil, &result, &err)
+
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	logs, ok := manager.endpoints["prod-logs"]
+	if !ok || logs.Bucket != "logs" || logs.Region != "eu-west-1" || logs.AccessKey != "AK" || logs.ProbeDepth != config.ProbeDepthDeep || logs.Provider != "aws" {
+		t.Fatalf("unexpected discovered endpoint %+v", logs)
+	}
+	if logs.RoleARN != cfg.RoleARN || manager.endpoints["prod-backups"].RoleARN != "arn:aws:iam::123456789012:role/backups" {
+		t.Fatalf("expected the role tag to override the default role, got %q and %q", logs.RoleARN, manager.endpoints["prod-backups"].RoleARN)
+	}
+
+	if err := c.Sync(context.Background()); err != nil || manager.adds != 2 {
+		t.Fatalf("expected unchanged buckets not to be re-registered, got %d adds (%v)", manager.adds, err)
+	}
+
+	result = &s3.BucketDiscoveryResult{Buckets: result.Buckets[:1]}
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, ok := manager.endpoints["prod-backups"]; ok || c.Endpoints() != 1 {
+		t.Fatalf("expected the untagged bucket's endpoint to be removed, got %v", manager.endpoints)
+	}
+}
+
+func TestSyncKeepsEndpointsOnFailure(t *testing.T) {
+	result := &s3.BucketDiscoveryResult{Buckets: []s3.DiscoveredBucket{{Name: "logs"}, {Name: "static"}}}
+	var err error
+	static := []config.S3EndpointConfig{{Name: "static"}}
+	c, manager := newTestController(&config.DiscoveryConfig{}, static, &result, &err)
+
+	c.Sync(context.Background())
+	if len(manager.endpoints) != 1 {
+		t.Fatalf("expected explicitly configured endpoints to win, got %v", manager.endpoints)
+	}
+
+	err = errors.New("access denied")
+	if c.Sync(context.Background()) == nil || len(manager.endpoints) != 1 {
+		t.Fatalf("expected a failed listing to keep the endpoints")
+	}
+
+	err = nil
+	result = &s3.BucketDiscoveryResult{Unreadable: map[string]error{"logs": errors.New("throttled")}}
+	c.Sync(context.Background())
+	if _, ok := manager.endpoints["logs"]; !ok {
+		t.Fatalf("expected a bucket with unreadable tags to keep its endpoint")
+	}
+}
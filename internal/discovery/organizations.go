@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OrganizationController validates the configured buckets in every account of an AWS
+// organization. Each account's endpoints assume the audit role in that account with the
+// management keys and are labelled with the account ID.
+type OrganizationController struct {
+	cfg          *config.OrganizationsConfig
+	log          *logrus.Logger
+	listAccounts func(context.Context) ([]Account, error)
+
+	mu sync.Mutex
+	registry
+}
+
+// NewOrganizationController sets up the sweep next to the explicitly configured endpoints
+func NewOrganizationController(cfg *config.OrganizationsConfig, static []config.S3EndpointConfig, manager EndpointManager, log *logrus.Logger) *OrganizationController {
+	client := newOrganizationsClient(cfg)
+	return &OrganizationController{
+		cfg: cfg,
+		log: log,
+		listAccounts: func(ctx context.Context) ([]Account, error) {
+			return listAccounts(ctx, client)
+		},
+		registry: newRegistry(static, manager, log),
+	}
+}
+
+// Endpoints returns the number of endpoints created for the organization's accounts
+func (c *OrganizationController) Endpoints() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.endpoints)
+}
+
+// Sync lists the organization's accounts and adds, updates or removes the endpoints of
+// each. When the listing fails the current endpoints are kept.
+func (c *OrganizationController) Sync(ctx context.Context) error {
+	accounts, err := c.listAccounts(ctx)
+	if err != nil {
+		metrics.RecordDiscoveryFailure(SourceOrganizations)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wanted := make(map[string]config.S3EndpointConfig)
+	swept := 0
+	for _, account := range accounts {
+		if !c.sweeps(account.ID) {
+			continue
+		}
+		swept++
+		for _, bucket := range c.cfg.Buckets {
+			endpoint := c.endpointFor(account, bucket)
+			wanted[endpoint.Name] = endpoint
+		}
+	}
+
+	c.registry.apply(wanted)
+	metrics.SetDiscoveredEndpoints(SourceOrganizations, len(c.endpoints))
+	c.log.WithFields(logrus.Fields{
+		"accounts":  swept,
+		"endpoints": len(c.endpoints),
+	}).Debug("Swept organization accounts")
+	return nil
+}
+
+// sweeps reports whether the account is selected by accounts and exclude_accounts
+func (c *OrganizationController) sweeps(accountID string) bool {
+	if len(c.cfg.Accounts) > 0 && !slices.Contains(c.cfg.Accounts, accountID) {
+		return false
+	}
+	return !slices.Contains(c.cfg.ExcludeAccounts, accountID)
+}
+
+// endpointFor builds the endpoint of a configured bucket in an account. Names without
+// the account ID placeholder are prefixed with the account ID to keep them unique, and
+// the account name is added to the labels for notification templates.
+func (c *OrganizationController) endpointFor(account Account, bucket config.S3EndpointConfig) config.S3EndpointConfig {
+	endpoint := bucket
+	if strings.Contains(bucket.Name, config.AccountIDPlaceholder) {
+		endpoint.Name = strings.ReplaceAll(bucket.Name, config.AccountIDPlaceholder, account.ID)
+	} else {
+		endpoint.Name = account.ID + "/" + bucket.Name
+	}
+	endpoint.Bucket = strings.ReplaceAll(bucket.Bucket, config.AccountIDPlaceholder, account.ID)
+	endpoint.AccessKey = c.cfg.AccessKey
+	endpoint.SecretKey = c.cfg.SecretKey
+	endpoint.SessionToken = c.cfg.SessionToken
+	endpoint.RoleARN = fmt.Sprintf("arn:%s:iam::%s:role/%s", c.cfg.Partition, account.ID, c.cfg.RoleName)
+	endpoint.ExternalID = c.cfg.ExternalID
+	endpoint.AccountID = account.ID
+	if account.Name != "" {
+		endpoint.Labels = maps.Clone(bucket.Labels)
+		if endpoint.Labels == nil {
+			endpoint.Labels = make(map[string]string, 1)
+		}
+		endpoint.Labels["account_name"] = account.Name
+	}
+	return endpoint
+}
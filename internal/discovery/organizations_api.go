@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+)
+
+// Account is an active account of the organization
+type Account struct {
+	ID   string
+	Name string
+}
+
+// newOrganizationsClient builds an Organizations client signing with the management
+// account keys. Without an endpoint the SDK resolves the partition's global endpoint
+// from the region.
+func newOrganizationsClient(cfg *config.OrganizationsConfig) *organizations.Client {
+	return organizations.NewFromConfig(aws.Config{
+		Region: cfg.Region,
+		Credentials: credentials.StaticCredentialsProvider{Value: aws.Credentials{
+			AccessKeyID:     cfg.AccessKey,
+			SecretAccessKey: cfg.SecretKey,
+			SessionToken:    cfg.SessionToken,
+		}},
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}, func(o *organizations.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+}
+
+// listAccounts returns every active account; the keys need organizations:ListAccounts
+func listAccounts(ctx context.Context, client organizations.ListAccountsAPIClient) ([]Account, error) {
+	var accounts []Account
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range page.Accounts {
+			if active(account) {
+				accounts = append(accounts, Account{ID: aws.ToString(account.Id), Name: aws.ToString(account.Name)})
+			}
+		}
+	}
+	return accounts, nil
+}
+
+// active reports whether the account is active. State replaced Status, which older
+// endpoints and partitions may still return alone.
+func active(account types.Account) bool {
+	if account.State != "" {
+		return account.State == types.AccountStateActive
+	}
+	return account.Status == "" || account.Status == types.AccountStatusActive
+}
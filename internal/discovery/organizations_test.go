@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/sirupsen/logrus"
+)
+
+// testOrganizationsClient returns a client of the Organizations API at endpoint
+func testOrganizationsClient(endpoint string) *organizations.Client {
+	return newOrganizationsClient(&config.OrganizationsConfig{AccessKey: "AK", SecretKey: "SK", Region: "us-east-1", Endpoint: endpoint})
+}
+
+func TestListAccountsPagesAndSkipsSuspended(t *testing.T) {
+	var targets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targets = append(targets, r.Header.Get("X-Amz-Target"))
+		if !strings.Contains(r.Header.Get("Authorization"), "/organizations/") {
+			t.Errorf("expected an Organizations signature, got %q", r.Header.Get("Authorization"))
+		}
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if in["NextToken"] == "" {
+			w.Write([]byte(`{"Accounts":[{"Id":"111111111111","Name":"prod","State":"ACTIVE","Status":"ACTIVE"},{"Id":"222222222222","State":"SUSPENDED","Status":"SUSPENDED"},{"Id":"444444444444","State":"PENDING_CLOSURE","Status":"ACTIVE"}],"NextToken":"p2"}`))
+			return
+		}
+		w.Write([]byte(`{"Accounts":[{"Id":"333333333333","Name":"dev","Status":"ACTIVE"}]}`))
+	}))
+	defer server.Close()
+
+	accounts, err := listAccounts(context.Background(), testOrganizationsClient(server.URL))
+	if err != nil {
+		t.Fatalf("list accounts: %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].ID != "111111111111" || accounts[1].ID != "333333333333" {
+		t.Fatalf("expected the active accounts of both pages, got %+v", accounts)
+	}
+	if len(targets) != 2 || targets[0] != "AWSOrganizationsV20161128.ListAccounts" {
+		t.Fatalf("unexpected requests %v", targets)
+	}
+}
+
+func TestListAccountsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.organizations#AccessDeniedException","Message":"not the management account"}`))
+	}))
+	defer server.Close()
+
+	_, err := listAccounts(context.Background(), testOrganizationsClient(server.URL))
+	if err == nil || !strings.Contains(err.Error(), "AccessDeniedException: not the management account") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+}
+
+func TestOrganizationSync(t *testing.T) {
+	cfg := &config.OrganizationsConfig{
+		AccessKey:       "AK",
+		SecretKey:       "SK",
+		RoleName:        "s3-key-audit",
+		Partition:       "aws",
+		ExcludeAccounts: []string{"999999999999"},
+		Buckets: []config.S3EndpointConfig{
+			{Name: "trail-{account_id}", Bucket: "cloudtrail-{account_id}", Region: "us-east-1"},
+			{Name: "backups", Bucket: "backups-{account_id}", Region: "eu-west-1", Labels: map[string]string{"team": "storage"}},
+		},
+	}
+	manager := &fakeManager{endpoints: make(map[string]config.S3EndpointConfig)}
+	c := NewOrganizationController(cfg, nil, manager, logrus.New())
+	accounts := []Account{{ID: "111111111111", Name: "prod"}, {ID: "999999999999"}}
+	var listErr error
+	c.listAccounts = func(context.Context) ([]Account, error) { return accounts, listErr }
+
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(manager.endpoints) != 2 {
+		t.Fatalf("expected two endpoints for the one swept account, got %v", manager.endpoints)
+	}
+	trail := manager.endpoints["trail-111111111111"]
+	if trail.Bucket != "cloudtrail-111111111111" || trail.RoleARN != "arn:aws:iam::111111111111:role/s3-key-audit" || trail.AccountID != "111111111111" {
+		t.Fatalf("unexpected account endpoint %+v", trail)
+	}
+	backups := manager.endpoints["111111111111/backups"]
+	if backups.Bucket != "backups-111111111111" || backups.Labels["account_name"] != "prod" || backups.Labels["team"] != "storage" {
+		t.Fatalf("expected a prefixed name and the account name label, got %+v", backups)
+	}
+	if _, ok := cfg.Buckets[1].Labels["account_name"]; ok {
+		t.Fatalf("expected the configured labels to be left alone")
+	}
+
+	listErr = errors.New("throttled")
+	if c.Sync(context.Background()) == nil || len(manager.endpoints) != 2 {
+		t.Fatalf("expected a failed listing to keep the endpoints")
+	}
+
+	listErr = nil
+	accounts = nil
+	c.Sync(context.Background())
+	if len(manager.endpoints) != 0 || c.Endpoints() != 0 {
+		t.Fatalf("expected the endpoints of a closed account to be removed, got %v", manager.endpoints)
+	}
+}
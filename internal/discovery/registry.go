@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"reflect"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sources of discovered endpoints, used as the source label of the discovery metrics
+const (
+	SourceTags          = "tags"
+	SourceOrganizations = "organizations"
+)
+
+// EndpointManager is the subset of the validator manager the controllers drive
+type EndpointManager interface {
+	AddEndpoint(endpointCfg config.S3EndpointConfig)
+	RemoveEndpoint(endpointName string) bool
+}
+
+// registry tracks the endpoints a controller created. Endpoints configured explicitly
+// always win over discovered ones with the same name.
+type registry struct {
+	manager   EndpointManager
+	log       *logrus.Logger
+	static    map[string]bool
+	endpoints map[string]config.S3EndpointConfig // key: endpoint name; only discovered endpoints
+}
+
+func newRegistry(static []config.S3EndpointConfig, manager EndpointManager, log *logrus.Logger) registry {
+	r := registry{
+		manager:   manager,
+		log:       log,
+		static:    make(map[string]bool, len(static)),
+		endpoints: make(map[string]config.S3EndpointConfig),
+	}
+	for _, endpoint := range static {
+		r.static[endpoint.Name] = true
+	}
+	return r
+}
+
+// apply adds or updates the wanted endpoints and removes the discovered ones no longer
+// wanted. Wanted endpoints shadowed by explicit ones are skipped.
+func (r *registry) apply(wanted map[string]config.S3EndpointConfig) {
+	for name, endpoint := range wanted {
+		if r.static[name] {
+			r.log.WithField("endpoint", name).Debug("Discovered bucket is already configured explicitly")
+			delete(wanted, name)
+			continue
+		}
+		if current, ok := r.endpoints[name]; ok && reflect.DeepEqual(current, endpoint) {
+			continue
+		}
+		r.manager.AddEndpoint(endpoint)
+		r.endpoints[name] = endpoint
+		r.log.WithFields(logrus.Fields{
+			"endpoint": name,
+			"region":   endpoint.Region,
+			"role_arn": endpoint.RoleARN,
+		}).Info("Discovered S3 endpoint")
+	}
+	for name := range r.endpoints {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		r.manager.RemoveEndpoint(name)
+		delete(r.endpoints, name)
+		r.log.WithField("endpoint", name).Info("Removed discovered S3 endpoint")
+	}
+}
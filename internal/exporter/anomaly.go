@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// anomalyWindow is how many recent successful latencies form the baseline
+const anomalyWindow = 50
+
+// LatencyAnomaly compares a validation's latency with the endpoint's rolling median
+type LatencyAnomaly struct {
+	Anomalous  bool
+	LatencyMs  int64
+	BaselineMs int64
+	Factor     float64
+}
+
+// Describe explains the verdict in one line
+func (a LatencyAnomaly) Describe() string {
+	ratio := 0.0
+	if a.BaselineMs > 0 {
+		ratio = float64(a.LatencyMs) / float64(a.BaselineMs)
+	}
+	return fmt.Sprintf("validation took %dms, %.1fx the %dms median (threshold %.1fx)", a.LatencyMs, ratio, a.BaselineMs, a.Factor)
+}
+
+// latencyDetector keeps a rolling window of successful latencies per endpoint and probe
+// depth and flags results slower than factor × the window's median. Deep probes write
+// and read objects, so they are judged only against other deep probes.
+type latencyDetector struct {
+	factor     float64
+	minSamples int
+
+	mu      sync.Mutex
+	samples map[baselineKey][]int64 // oldest first
+}
+
+// baselineKey identifies the window of one endpoint's probes of one depth
+type baselineKey struct {
+	endpoint string
+	depth    s3.ProbeDepth
+}
+
+func newBaselineKey(endpointName string, depth s3.ProbeDepth) baselineKey {
+	if depth == "" {
+		depth = s3.ProbeDepthShallow
+	}
+	return baselineKey{endpoint: endpointName, depth: depth}
+}
+
+func newLatencyDetector(factor float64, minSamples int) *latencyDetector {
+	if minSamples <= 0 {
+		minSamples = config.DefaultAnomalyMinSamples
+	}
+	return &latencyDetector{
+		factor:     factor,
+		minSamples: minSamples,
+		samples:    make(map[baselineKey][]int64),
+	}
+}
+
+// observe judges a successful result against the baseline of its depth and then adds
+// it to that window. It reports false for failures and while the baseline is too small.
+func (d *latencyDetector) observe(endpointName string, result *s3.ValidationResult) (LatencyAnomaly, bool) {
+	if result == nil || !result.IsValid {
+		return LatencyAnomaly{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := newBaselineKey(endpointName, result.Depth)
+	window := d.samples[key]
+	var anomaly LatencyAnomaly
+	ready := len(window) >= d.minSamples
+	if ready {
+		baseline := median(window)
+		anomaly = LatencyAnomaly{
+			Anomalous:  float64(result.ResponseTimeMs) > d.factor*float64(baseline),
+			LatencyMs:  result.ResponseTimeMs,
+			BaselineMs: baseline,
+			Factor:     d.factor,
+		}
+	}
+
+	window = append(window, result.ResponseTimeMs)
+	if len(window) > anomalyWindow {
+		window = window[len(window)-anomalyWindow:]
+	}
+	d.samples[key] = window
+	return anomaly, ready
+}
+
+// forget drops the baselines of a removed endpoint
+func (d *latencyDetector) forget(endpointName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key := range d.samples {
+		if key.endpoint == endpointName {
+			delete(d.samples, key)
+		}
+	}
+}
+
+// median returns the middle value of the samples (the lower one for even counts)
+func median(samples []int64) int64 {
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)/2]
+}
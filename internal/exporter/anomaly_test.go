@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func validResult(ms int64) *s3.ValidationResult {
+	return &s3.ValidationResult{IsValid: true, ResponseTimeMs: ms, CheckedAt: time.Now()}
+}
+
+func TestLatencyDetector(t *testing.T) {
+	d := newLatencyDetector(5, 3)
+
+	for _, ms := range []int64{100, 120, 110} {
+		if _, ok := d.observe("bucket", validResult(ms)); ok {
+			t.Fatalf("expected no verdict before %d samples", d.minSamples)
+		}
+	}
+
+	anomaly, ok := d.observe("bucket", validResult(400))
+	if !ok || anomaly.Anomalous || anomaly.BaselineMs != 110 {
+		t.Fatalf("expected a normal verdict against the 110ms median, got %+v (%v)", anomaly, ok)
+	}
+
+	anomaly, ok = d.observe("bucket", validResult(900))
+	if !ok || !anomaly.Anomalous || anomaly.LatencyMs != 900 {
+		t.Fatalf("expected 900ms to be anomalous, got %+v", anomaly)
+	}
+	if !strings.Contains(anomaly.Describe(), "900ms") {
+		t.Fatalf("unexpected description %q", anomaly.Describe())
+	}
+
+	if _, ok := d.observe("bucket", &s3.ValidationResult{ResponseTimeMs: 5000}); ok {
+		t.Fatalf("expected failed validations to be ignored")
+	}
+
+	d.forget("bucket")
+	if _, ok := d.observe("bucket", validResult(100)); ok {
+		t.Fatalf("expected the baseline to be dropped")
+	}
+}
+
+func TestLatencyDetectorWindowIsBounded(t *testing.T) {
+	d := newLatencyDetector(5, 1)
+	for i := 0; i < anomalyWindow*2; i++ {
+		d.observe("bucket", validResult(int64(i)))
+	}
+	if got := len(d.samples[newBaselineKey("bucket", "")]); got != anomalyWindow {
+		t.Fatalf("expected %d samples, got %d", anomalyWindow, got)
+	}
+}
+
+func TestLatencyDetectorKeepsBaselinePerDepth(t *testing.T) {
+	d := newLatencyDetector(5, 3)
+	for _, ms := range []int64{100, 120, 110} {
+		d.observe("bucket", validResult(ms))
+	}
+
+	deep := validResult(2000)
+	deep.Depth = s3.ProbeDepthDeep
+	if anomaly, ok := d.observe("bucket", deep); ok || anomaly.Anomalous {
+		t.Fatalf("expected a deep probe not to be judged against the shallow baseline, got %+v", anomaly)
+	}
+
+	anomaly, ok := d.observe("bucket", validResult(130))
+	if !ok || anomaly.Anomalous || anomaly.BaselineMs != 110 {
+		t.Fatalf("expected the shallow baseline to be unaffected by the deep probe, got %+v (%v)", anomaly, ok)
+	}
+}
+
+func TestValidatorManagerAttachesAnomalies(t *testing.T) {
+	metrics.LatencyAnomaly.Reset()
+
+	cfg := &config.Config{
+		ValidationTimeout:        time.Second,
+		LatencyAnomalyFactor:     5,
+		LatencyAnomalyMinSamples: 2,
+		Endpoints:                []config.S3EndpointConfig{{Name: "slow"}},
+	}
+	log, hook := test.NewNullLogger()
+	vm := NewValidatorManager(cfg, log)
+
+	stub := &stubValidator{result: validResult(100)}
+	vm.mu.Lock()
+	vm.validators["slow"] = stub
+	vm.mu.Unlock()
+
+	vm.ValidateAll(context.Background())
+	results := vm.ValidateAll(context.Background())
+	if _, ok := results.Anomalies["slow"]; ok {
+		t.Fatalf("expected no verdict before the baseline is ready")
+	}
+
+	stub.result = validResult(1000)
+	results = vm.ValidateAll(context.Background())
+	if anomaly, ok := results.Anomalies["slow"]; !ok || !anomaly.Anomalous {
+		t.Fatalf("expected an anomaly, got %+v", results.Anomalies)
+	}
+	if got := testutil.ToFloat64(metrics.LatencyAnomaly.WithLabelValues("slow")); got != 1 {
+		t.Fatalf("expected s3_latency_anomaly to be 1, got %v", got)
+	}
+
+	found := false
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "S3 validation latency anomaly" && entry.Level == logrus.WarnLevel {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a warning for the anomaly")
+	}
+}
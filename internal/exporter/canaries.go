@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// canaryCleaner is implemented by validators that can delete leaked canary objects
+type canaryCleaner interface {
+	CleanCanaries(ctx context.Context, timeout time.Duration) *s3.CanaryCleanup
+}
+
+// writesCanaries reports whether an endpoint's probes, checks or scheduled permission
+// discovery write canary objects
+func writesCanaries(endpointCfg config.S3EndpointConfig, depth s3.ProbeDepth) bool {
+	if endpointCfg.Plugin != nil {
+		return false
+	}
+	if len(endpointCfg.ExpectedPermissions) > 0 {
+		return true
+	}
+	checks := endpointCfg.Checks
+	return depth == s3.ProbeDepthDeep || checks != nil && (checks.KMS != nil || checks.AccessLog != nil)
+}
+
+// CleanCanaries deletes the expired canaries of every endpoint that writes them and
+// returns the number deleted per endpoint. Canaries outlive their probe only when the
+// exporter crashed or was cut off mid-probe; any replica cleans up after any other.
+func (vm *ValidatorManager) CleanCanaries(ctx context.Context) map[string]int {
+	type job struct {
+		name    string
+		bucket  string
+		cleaner canaryCleaner
+	}
+	vm.mu.RLock()
+	var jobs []job
+	for name, validator := range vm.validators {
+		cleaner, ok := validator.(canaryCleaner)
+		if meta := vm.meta[name]; ok && meta.writesCanaries {
+			jobs = append(jobs, job{name: name, bucket: meta.bucket, cleaner: cleaner})
+		}
+	}
+	vm.mu.RUnlock()
+
+	deleted := make(map[string]int, len(jobs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			cleanup := j.cleaner.CleanCanaries(ctx, vm.probeTimeout(ctx))
+			log := vm.log.WithField("endpoint", j.name)
+			if cleanup.Err != nil {
+				log.WithError(cleanup.Err).Warn("Canary cleanup incomplete")
+			}
+			if len(cleanup.Deleted) == 0 {
+				return
+			}
+			log.WithField("deleted", len(cleanup.Deleted)).Info("Deleted leaked canary objects")
+			vm.metrics.RecordCanaryOrphansCleaned(j.name, len(cleanup.Deleted))
+			mu.Lock()
+			deleted[j.name] = len(cleanup.Deleted)
+			mu.Unlock()
+		}(j)
+	}
+	wg.Wait()
+	return deleted
+}
+
+// CleanCanaries deletes expired canaries with the primary credentials; both sets
+// probe the same bucket
+func (sv *slottedValidator) CleanCanaries(ctx context.Context, timeout time.Duration) *s3.CanaryCleanup {
+	return cleanCanaries(ctx, sv.primary, timeout)
+}
+
+// CleanCanaries deletes the wrapped validator's expired canaries
+func (hv *hedgedValidator) CleanCanaries(ctx context.Context, timeout time.Duration) *s3.CanaryCleanup {
+	return cleanCanaries(ctx, hv.inner, timeout)
+}
+
+// cleanCanaries forwards to v when it can clean canaries
+func cleanCanaries(ctx context.Context, v bucketValidator, timeout time.Duration) *s3.CanaryCleanup {
+	cleaner, ok := v.(canaryCleaner)
+	if !ok {
+		return &s3.CanaryCleanup{Err: errors.New("validator cannot clean canaries")}
+	}
+	return cleaner.CleanCanaries(ctx, timeout)
+}
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// cleaningValidator reports a fixed janitor outcome and counts its runs
+type cleaningValidator struct {
+	stubValidator
+	cleanup *s3.CanaryCleanup
+	runs    int
+}
+
+func (c *cleaningValidator) CleanCanaries(ctx context.Context, timeout time.Duration) *s3.CanaryCleanup {
+	c.runs++
+	return c.cleanup
+}
+
+func TestValidatorManagerCleanCanaries(t *testing.T) {
+	vm := NewValidatorManager(&config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "canary-deep", ProbeDepth: string(s3.ProbeDepthDeep)},
+			{Name: "canary-kms", Checks: &config.ChecksConfig{KMS: &config.KMSCheckConfig{}}},
+			{Name: "canary-shallow"},
+		},
+	}, logrus.New())
+	deep := &cleaningValidator{cleanup: &s3.CanaryCleanup{Deleted: []string{"a", "b"}}}
+	kms := &cleaningValidator{cleanup: &s3.CanaryCleanup{Err: errors.New("AccessDenied")}}
+	shallow := &cleaningValidator{cleanup: &s3.CanaryCleanup{}}
+	vm.mu.Lock()
+	vm.validators["canary-deep"] = deep
+	vm.validators["canary-kms"] = kms
+	vm.validators["canary-shallow"] = shallow
+	vm.mu.Unlock()
+
+	deleted := vm.CleanCanaries(context.Background())
+
+	if len(deleted) != 1 || deleted["canary-deep"] != 2 {
+		t.Fatalf("expected two canaries deleted from the deep endpoint, got %v", deleted)
+	}
+	if kms.runs != 1 || shallow.runs != 0 {
+		t.Fatalf("expected only endpoints writing canaries to be cleaned, got kms=%d shallow=%d", kms.runs, shallow.runs)
+	}
+	if got := testutil.ToFloat64(metrics.CanaryOrphansCleaned.WithLabelValues("canary-deep")); got != 2 {
+		t.Fatalf("expected s3_canary_orphans_cleaned_total to be 2, got %v", got)
+	}
+	for _, name := range []string{"canary-deep", "canary-kms", "canary-shallow"} {
+		metrics.UnregisterEndpoint(name)
+	}
+}
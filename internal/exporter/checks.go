@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// checkOptions translates the endpoint's checks configuration into validator options
+func checkOptions(checks *config.ChecksConfig) []s3.Option {
+	if checks == nil {
+		return nil
+	}
+
+	opts := []s3.Option{
+		s3.WithCheckInterval(time.Duration(checks.Interval)),
+		s3.WithCheckTimeout(time.Duration(checks.Timeout)),
+	}
+	if al := checks.AccessLog; al != nil {
+		opts = append(opts, s3.WithAccessLogCheck(s3.AccessLogCheckConfig{
+			TargetBucket: al.TargetBucket,
+			TargetPrefix: al.TargetPrefix,
+			Delay:        time.Duration(al.Delay),
+			MaxWait:      time.Duration(al.MaxWait),
+		}))
+	}
+	if ol := checks.ObjectLock; ol != nil {
+		opts = append(opts, s3.WithObjectLockCheck(s3.ObjectLockCheckConfig{
+			Mode:  ol.Mode,
+			Days:  ol.Days,
+			Years: ol.Years,
+		}))
+	}
+	if checks.PublicAccess {
+		opts = append(opts, s3.WithPublicAccessCheck())
+	}
+	if checks.KMS != nil {
+		opts = append(opts, s3.WithKMSKeyCheck(checks.KMS.KeyID))
+	}
+	if checks.Restore != nil {
+		opts = append(opts, s3.WithRestoreCheck(s3.RestoreCheckConfig{Key: checks.Restore.Key}))
+	}
+	if checks.Tags != nil {
+		opts = append(opts, s3.WithTagsCheck(checks.Tags.Required))
+	}
+	if cors := checks.CORS; cors != nil {
+		rules := make([]s3.CORSRule, len(cors.Rules))
+		for i, rule := range cors.Rules {
+			rules[i] = s3.CORSRule{
+				AllowedOrigins: rule.AllowedOrigins,
+				AllowedMethods: rule.AllowedMethods,
+				AllowedHeaders: rule.AllowedHeaders,
+				ExposeHeaders:  rule.ExposeHeaders,
+				MaxAgeSeconds:  rule.MaxAgeSeconds,
+			}
+		}
+		opts = append(opts, s3.WithCORSCheck(s3.CORSCheckConfig{
+			Rules:  rules,
+			Origin: cors.Origin,
+			Method: cors.Method,
+			URL:    cors.URL,
+		}))
+	}
+	if ws := checks.Website; ws != nil {
+		opts = append(opts, s3.WithWebsiteCheck(s3.WebsiteCheckConfig{IndexDocument: ws.IndexDocument, URL: ws.URL}))
+	}
+	if inv := checks.Inventory; inv != nil {
+		opts = append(opts, s3.WithInventoryCheck(s3.InventoryCheckConfig{
+			Prefix:     inv.Prefix,
+			SampleSize: inv.SampleSize,
+		}))
+	}
+	return opts
+}
@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// costMonth is the billing month probe costs are projected over, as in AWS pricing
+const costMonth = 730 * time.Hour
+
+// probeSpend is what an endpoint's probes cost since the first observed validation
+type probeSpend struct {
+	since time.Time
+	usd   float64
+}
+
+// probeCostEstimator projects each endpoint's monthly probe request cost from what its
+// probes have cost so far. The first validation only starts the clock: its requests
+// were made before the observed interval.
+type probeCostEstimator struct {
+	pricing map[string]config.ProbePrice
+
+	mu    sync.Mutex
+	spend map[string]*probeSpend
+}
+
+func newProbeCostEstimator(pricing map[string]config.ProbePrice) *probeCostEstimator {
+	return &probeCostEstimator{pricing: pricing, spend: make(map[string]*probeSpend)}
+}
+
+// price returns the pricing for a declared provider, falling back to the default entry
+func (e *probeCostEstimator) price(provider string) (config.ProbePrice, bool) {
+	if price, ok := e.pricing[provider]; ok && provider != "" {
+		return price, true
+	}
+	price, ok := e.pricing[config.DefaultPricingProvider]
+	return price, ok
+}
+
+// observe adds the cost of a result's requests and returns the monthly projection.
+// It reports false without pricing for the provider and until a second validation.
+func (e *probeCostEstimator) observe(endpointName, provider string, result *s3.ValidationResult) (float64, bool) {
+	price, ok := e.price(provider)
+	if !ok || result == nil {
+		return 0, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spend, seen := e.spend[endpointName]
+	if !seen {
+		e.spend[endpointName] = &probeSpend{since: result.CheckedAt}
+		return 0, false
+	}
+	for _, op := range probeOperations(result) {
+		spend.usd += requestPrice(price, op.Operation)
+	}
+
+	elapsed := result.CheckedAt.Sub(spend.since)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return spend.usd * float64(costMonth) / float64(elapsed), true
+}
+
+func (e *probeCostEstimator) forget(endpointName string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.spend, endpointName)
+}
+
+// requestPrice is the price of one request: LIST and writes are class A, DELETE is
+// free and everything else is class B
+func requestPrice(price config.ProbePrice, operation string) float64 {
+	switch {
+	case operation == s3.OperationDeleteObject:
+		return 0
+	case strings.HasPrefix(operation, "List"), strings.HasPrefix(operation, "Put"),
+		strings.HasPrefix(operation, "Copy"), operation == s3.OperationMultipartUpload:
+		return price.ClassA / 1000
+	default:
+		return price.ClassB / 1000
+	}
+}
+
+// probeOperations returns the requests made for a result, including the secondary
+// credential slot's
+func probeOperations(result *s3.ValidationResult) []s3.OperationTiming {
+	if result.Secondary == nil {
+		return result.Operations
+	}
+	return append(append([]s3.OperationTiming(nil), result.Operations...), result.Secondary.Operations...)
+}
+
+// estimateCosts attaches the projected monthly probe costs before sinks see the batch
+func (vm *ValidatorManager) estimateCosts(results *ValidationResults) {
+	if vm.costs == nil {
+		return
+	}
+	for name, result := range results.Results {
+		vm.mu.RLock()
+		meta := vm.meta[name]
+		vm.mu.RUnlock()
+
+		provider := ""
+		if meta.declared {
+			provider = meta.provider
+		}
+		usd, ok := vm.costs.observe(name, provider, result)
+		if !ok {
+			continue
+		}
+		if results.ProbeCosts == nil {
+			results.ProbeCosts = make(map[string]float64)
+		}
+		results.ProbeCosts[name] = usd
+	}
+}
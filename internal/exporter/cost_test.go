@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+func TestProbeCostEstimatorProjectsMonthlyCost(t *testing.T) {
+	estimator := newProbeCostEstimator(map[string]config.ProbePrice{
+		"aws": {ClassA: 5, ClassB: 0.4},
+	})
+	start := time.Unix(1700000000, 0)
+	deep := func(at time.Duration) *s3.ValidationResult {
+		return &s3.ValidationResult{CheckedAt: start.Add(at), Operations: []s3.OperationTiming{
+			{Operation: s3.OperationListObjects}, {Operation: s3.OperationPutObject},
+			{Operation: s3.OperationGetObject}, {Operation: s3.OperationDeleteObject},
+		}}
+	}
+
+	if _, ok := estimator.observe("bucket", "aws", deep(0)); ok {
+		t.Fatalf("expected the first validation to only start the clock")
+	}
+	usd, ok := estimator.observe("bucket", "aws", deep(time.Hour))
+	if !ok {
+		t.Fatalf("expected an estimate after the second validation")
+	}
+	// Two class A requests and one class B request per hour, DELETE is free
+	want := (2*5.0/1000 + 0.4/1000) * 730
+	if math.Abs(usd-want) > 1e-9 {
+		t.Fatalf("expected %v USD per month, got %v", want, usd)
+	}
+}
+
+func TestProbeCostEstimatorPricing(t *testing.T) {
+	estimator := newProbeCostEstimator(map[string]config.ProbePrice{
+		"r2":                          {ClassA: 4.5, ClassB: 0.36},
+		config.DefaultPricingProvider: {ClassA: 5, ClassB: 0.4},
+	})
+	if price, _ := estimator.price("r2"); price.ClassA != 4.5 {
+		t.Fatalf("expected the provider's own pricing, got %+v", price)
+	}
+	if price, ok := estimator.price("minio"); !ok || price.ClassA != 5 {
+		t.Fatalf("expected the default pricing for unlisted providers, got %+v", price)
+	}
+	if price, ok := estimator.price(""); !ok || price.ClassA != 5 {
+		t.Fatalf("expected the default pricing without a provider, got %+v", price)
+	}
+
+	unpriced := newProbeCostEstimator(map[string]config.ProbePrice{"r2": {}})
+	if _, ok := unpriced.observe("bucket", "minio", &s3.ValidationResult{}); ok {
+		t.Fatalf("expected no estimate without pricing")
+	}
+}
+
+func TestProbeOperationsIncludeSecondarySlot(t *testing.T) {
+	result := &s3.ValidationResult{
+		Operations: []s3.OperationTiming{{Operation: s3.OperationListObjects}},
+		Secondary:  &s3.ValidationResult{Operations: []s3.OperationTiming{{Operation: s3.OperationListObjects}}},
+	}
+	if got := len(probeOperations(result)); got != 2 {
+		t.Fatalf("expected both slots' requests, got %d", got)
+	}
+}
@@ -0,0 +1,26 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// ValidateCredentials validates credentials submitted by another service against the
+// bucket of endpointCfg, which is never registered. Like ValidateEndpointWith, the result
+// is returned but not published, so callers' keys never reach metrics, history or sinks;
+// the client is built outside the shared pool so the credentials are not kept either.
+// Every such client sends through one HTTP client, so requests reuse its connections
+// instead of each leaving an idle transport behind. A zero timeout uses the manager's.
+func (vm *ValidatorManager) ValidateCredentials(ctx context.Context, endpointCfg config.S3EndpointConfig, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	for _, rewrite := range vm.rewrites {
+		endpointCfg = rewrite(endpointCfg)
+	}
+	validator := newValidator(endpointCfg, endpointCfg.AccessKey, endpointCfg.SecretKey, endpointCfg.SessionToken, append(vm.endpointOptions(endpointCfg), s3.WithHTTPClient(vm.submitted)))
+	if timeout <= 0 {
+		timeout = vm.timeout
+	}
+	return validateWith(ctx, validator, timeout, opts)
+}
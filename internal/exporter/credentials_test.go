@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<ListBucketResult><Name>shared</Name></ListBucketResult>`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ValidationTimeout: 5 * time.Second}
+	vm := NewValidatorManager(cfg, logrus.New())
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+
+	result := vm.ValidateCredentials(context.Background(), config.S3EndpointConfig{
+		Name:         "submitted",
+		Endpoint:     server.URL,
+		Region:       "us-east-1",
+		Bucket:       "shared",
+		AccessKey:    "ASIASUBMITTED",
+		SecretKey:    "secret",
+		SessionToken: "token",
+		UsePathStyle: true,
+	}, 0, s3.ProbeOptions{})
+	if !result.IsValid {
+		t.Fatalf("expected the submitted credentials to validate, got %+v", result)
+	}
+	if !strings.Contains(authorization, "ASIASUBMITTED") {
+		t.Fatalf("expected the request to be signed with the submitted key, got %q", authorization)
+	}
+	if len(sink.batches) != 0 || vm.GetEndpointCount() != 0 || vm.clients.Len() != 0 {
+		t.Fatalf("expected nothing to be published, registered or pooled, got %d batches, %d endpoints, %d clients", len(sink.batches), vm.GetEndpointCount(), vm.clients.Len())
+	}
+}
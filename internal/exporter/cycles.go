@@ -0,0 +1,225 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// Kinds of endpoint state changes between two cycles
+const (
+	CycleChangeBroke        = "broke"         // valid before, invalid now
+	CycleChangeRecovered    = "recovered"     // invalid before, valid now
+	CycleChangeErrorChanged = "error_changed" // invalid in both, with another error type
+	CycleChangeAdded        = "added"         // first validated in this cycle
+	CycleChangeRemoved      = "removed"       // no longer configured
+)
+
+var (
+	// ErrCycleNotFound is returned for cycles that never ran or were evicted
+	ErrCycleNotFound = errors.New("cycle not found")
+	// ErrNoPreviousCycle is returned when a cycle's predecessor is not retained
+	ErrNoPreviousCycle = errors.New("previous cycle not retained")
+)
+
+// CycleEndpoint is an endpoint's state at the end of a cycle
+type CycleEndpoint struct {
+	IsValid   bool
+	ErrorType string
+	Message   string
+	CheckedAt time.Time
+}
+
+// Cycle is a snapshot of one auto-validation run
+type Cycle struct {
+	ID        int64
+	Timestamp time.Time
+	Endpoints map[string]CycleEndpoint
+	// Unfinished endpoints did not finish before the cycle's deadline and keep no state
+	Unfinished []string
+}
+
+// CycleChange is an endpoint whose state differs between two cycles
+type CycleChange struct {
+	Endpoint string
+	Kind     string
+	Previous *CycleEndpoint // nil for added endpoints
+	Current  *CycleEndpoint // nil for removed endpoints
+}
+
+// CycleDiff lists the endpoints that changed state between a cycle and its predecessor
+type CycleDiff struct {
+	Previous Cycle
+	Current  Cycle
+	Changes  []CycleChange // sorted by endpoint name
+}
+
+// cycleTracker keeps the snapshots of the most recent auto-validation cycles
+type cycleTracker struct {
+	size int
+
+	mu     sync.RWMutex
+	nextID int64
+	cycles []Cycle // oldest first
+}
+
+func newCycleTracker(size int) *cycleTracker {
+	if size <= 0 {
+		size = config.DefaultCycleHistorySize
+	}
+	return &cycleTracker{size: size, nextID: 1}
+}
+
+// record stores the results of a cycle and returns its snapshot
+func (t *cycleTracker) record(results *ValidationResults) Cycle {
+	cycle := Cycle{
+		Timestamp: results.Timestamp,
+		Endpoints: make(map[string]CycleEndpoint, len(results.Results)),
+	}
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		if IsUnfinished(result.ErrorType) {
+			cycle.Unfinished = append(cycle.Unfinished, name)
+			continue
+		}
+		cycle.Endpoints[name] = CycleEndpoint{
+			IsValid:   result.IsValid,
+			ErrorType: result.ErrorType,
+			Message:   result.Message,
+			CheckedAt: result.CheckedAt,
+		}
+	}
+	sort.Strings(cycle.Unfinished)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cycle.ID = t.nextID
+	t.nextID++
+	t.cycles = append(t.cycles, cycle)
+	if len(t.cycles) > t.size {
+		t.cycles = t.cycles[len(t.cycles)-t.size:]
+	}
+	return cycle
+}
+
+// latest returns the most recent cycle
+func (t *cycleTracker) latest() (Cycle, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.cycles) == 0 {
+		return Cycle{}, false
+	}
+	return t.cycles[len(t.cycles)-1], true
+}
+
+// get returns the cycle with the given ID if it is still retained
+func (t *cycleTracker) get(id int64) (Cycle, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.cycles) == 0 {
+		return Cycle{}, false
+	}
+	index := int(id - t.cycles[0].ID)
+	if index < 0 || index >= len(t.cycles) {
+		return Cycle{}, false
+	}
+	return t.cycles[index], true
+}
+
+// diff compares the cycle with the given ID against the one before it
+func (t *cycleTracker) diff(id int64) (CycleDiff, error) {
+	current, ok := t.get(id)
+	if !ok {
+		return CycleDiff{}, ErrCycleNotFound
+	}
+	previous, ok := t.get(id - 1)
+	if !ok {
+		return CycleDiff{}, ErrNoPreviousCycle
+	}
+	return CycleDiff{
+		Previous: previous,
+		Current:  current,
+		Changes:  diffCycles(previous, current),
+	}, nil
+}
+
+// diffCycles lists the endpoints whose state differs between two cycles. Endpoints
+// that did not finish in either cycle are skipped: their state is unknown, not changed.
+func diffCycles(previous, current Cycle) []CycleChange {
+	unfinished := make(map[string]bool, len(previous.Unfinished)+len(current.Unfinished))
+	for _, name := range previous.Unfinished {
+		unfinished[name] = true
+	}
+	for _, name := range current.Unfinished {
+		unfinished[name] = true
+	}
+
+	var changes []CycleChange
+	for name, now := range current.Endpoints {
+		if unfinished[name] {
+			continue
+		}
+		before, existed := previous.Endpoints[name]
+		kind := ""
+		switch {
+		case !existed:
+			kind = CycleChangeAdded
+		case before.IsValid && !now.IsValid:
+			kind = CycleChangeBroke
+		case !before.IsValid && now.IsValid:
+			kind = CycleChangeRecovered
+		case !now.IsValid && before.ErrorType != now.ErrorType:
+			kind = CycleChangeErrorChanged
+		default:
+			continue
+		}
+		change := CycleChange{Endpoint: name, Kind: kind, Current: &now}
+		if existed {
+			change.Previous = &before
+		}
+		changes = append(changes, change)
+	}
+	for name, before := range previous.Endpoints {
+		if _, exists := current.Endpoints[name]; exists || unfinished[name] {
+			continue
+		}
+		changes = append(changes, CycleChange{Endpoint: name, Kind: CycleChangeRemoved, Previous: &before})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Endpoint < changes[j].Endpoint })
+	return changes
+}
+
+// ValidateCycle is ValidateAll for scheduled runs: it also keeps a snapshot of the
+// results, so GET /cycles can tell what changed since the previous run. The first cycle
+// is ramped up when warm-up is configured.
+func (vm *ValidatorManager) ValidateCycle(ctx context.Context) *ValidationResults {
+	all := func(string) bool { return true }
+	probe := func(v bucketValidator) *s3.ValidationResult {
+		return v.ValidateKeys(ctx, vm.probeTimeout(ctx))
+	}
+	var results *ValidationResults
+	if vm.warmUp.first(false) {
+		results = vm.validateRamped(ctx, all, probe, s3.ProbeDepthShallow)
+	} else {
+		results = vm.validateEach(ctx, all, probe, nil, s3.ProbeDepthShallow)
+	}
+	vm.cycles.record(results)
+	return results
+}
+
+// LatestCycle returns the snapshot of the most recent auto-validation cycle
+func (vm *ValidatorManager) LatestCycle() (Cycle, bool) {
+	return vm.cycles.latest()
+}
+
+// CycleDiff compares a retained cycle with the one before it
+func (vm *ValidatorManager) CycleDiff(id int64) (CycleDiff, error) {
+	return vm.cycles.diff(id)
+}
@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+func cycleResults(at time.Time, results map[string]*s3.ValidationResult) *ValidationResults {
+	return &ValidationResults{Timestamp: at, Results: results}
+}
+
+func TestCycleTrackerDiff(t *testing.T) {
+	tracker := newCycleTracker(10)
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.record(cycleResults(at, map[string]*s3.ValidationResult{
+		"steady":     {IsValid: true, CheckedAt: at},
+		"breaking":   {IsValid: true, CheckedAt: at},
+		"recovering": {IsValid: false, ErrorType: "network", CheckedAt: at},
+		"switching":  {IsValid: false, ErrorType: "network", CheckedAt: at},
+		"removed":    {IsValid: true, CheckedAt: at},
+		"slow":       {IsValid: true, CheckedAt: at},
+	}))
+	second := tracker.record(cycleResults(at.Add(time.Minute), map[string]*s3.ValidationResult{
+		"steady":     {IsValid: true, CheckedAt: at},
+		"breaking":   {IsValid: false, ErrorType: "access_denied", CheckedAt: at},
+		"recovering": {IsValid: true, CheckedAt: at},
+		"switching":  {IsValid: false, ErrorType: "throttled", CheckedAt: at},
+		"added":      {IsValid: true, CheckedAt: at},
+		"slow":       {IsValid: false, ErrorType: ErrorTypeTimedOut, CheckedAt: at},
+	}))
+
+	if second.ID != 2 || len(second.Unfinished) != 1 || second.Unfinished[0] != "slow" {
+		t.Fatalf("unexpected snapshot: %+v", second)
+	}
+	diff, err := tracker.diff(second.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"added":      CycleChangeAdded,
+		"breaking":   CycleChangeBroke,
+		"recovering": CycleChangeRecovered,
+		"removed":    CycleChangeRemoved,
+		"switching":  CycleChangeErrorChanged,
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, change := range diff.Changes {
+		if want[change.Endpoint] != change.Kind {
+			t.Fatalf("unexpected change for %s: %s", change.Endpoint, change.Kind)
+		}
+		if i > 0 && diff.Changes[i-1].Endpoint > change.Endpoint {
+			t.Fatalf("expected changes sorted by endpoint")
+		}
+	}
+
+	if _, err := tracker.diff(1); !errors.Is(err, ErrNoPreviousCycle) {
+		t.Fatalf("expected the first cycle to have no predecessor, got %v", err)
+	}
+	if _, err := tracker.diff(3); !errors.Is(err, ErrCycleNotFound) {
+		t.Fatalf("expected an unknown cycle to be rejected, got %v", err)
+	}
+}
+
+func TestCycleTrackerEvictsOldCycles(t *testing.T) {
+	tracker := newCycleTracker(2)
+	for i := 0; i < 3; i++ {
+		tracker.record(cycleResults(time.Now(), nil))
+	}
+
+	if _, ok := tracker.get(1); ok {
+		t.Fatalf("expected the oldest cycle to be evicted")
+	}
+	if latest, ok := tracker.latest(); !ok || latest.ID != 3 {
+		t.Fatalf("expected cycle 3 to be the latest, got %+v", latest)
+	}
+	if _, err := tracker.diff(2); !errors.Is(err, ErrNoPreviousCycle) {
+		t.Fatalf("expected the evicted predecessor to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// Error types of endpoints whose probe had not finished when the caller's context ended:
+// a deadline such as the budget of a /validate request, or a cancellation such as
+// shutdown. Such results are returned to the caller but never published, so they do not
+// affect validity metrics or notifications.
+const (
+	ErrorTypeTimedOut = "timed_out"
+	ErrorTypeCanceled = "canceled"
+)
+
+// ErrorTypeSkipped is the result of an endpoint a scheduled run skipped because its probe
+// from an earlier run was still in flight. It is not published either.
+const ErrorTypeSkipped = "skipped"
+
+// IsUnfinished reports whether an error type marks a result that was returned to the
+// caller without being published
+func IsUnfinished(errorType string) bool {
+	return errorType == ErrorTypeTimedOut || errorType == ErrorTypeCanceled || errorType == ErrorTypeSkipped
+}
+
+// probeTimeout is the per-endpoint timeout: the configured validation timeout, shortened
+// to whatever is left of a deadline on ctx
+func (vm *ValidatorManager) probeTimeout(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return vm.timeout
+	}
+	if remaining := deadline.Sub(vm.clock.Now()); remaining < vm.timeout {
+		return max(remaining, 0)
+	}
+	return vm.timeout
+}
+
+// cutShort reports whether a failed result was caused by the caller's context ending
+// rather than by the endpoint
+func cutShort(ctx context.Context, result *s3.ValidationResult) bool {
+	return result != nil && !result.IsValid && ctx.Err() != nil && s3.IsConnectivityError(result.ErrorType)
+}
+
+// unfinishedResult is reported for an endpoint that did not finish before ctx ended
+func unfinishedResult(ctx context.Context, now time.Time) *s3.ValidationResult {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &s3.ValidationResult{
+			IsValid:   false,
+			Message:   "validation did not finish before the deadline",
+			CheckedAt: now,
+			ErrorType: ErrorTypeTimedOut,
+		}
+	}
+	return &s3.ValidationResult{
+		IsValid:   false,
+		Message:   "validation was canceled before the endpoint finished",
+		CheckedAt: now,
+		ErrorType: ErrorTypeCanceled,
+	}
+}
+
+// skippedResult is reported for an endpoint a scheduled run skipped
+func skippedResult(now time.Time) *s3.ValidationResult {
+	return &s3.ValidationResult{
+		IsValid:   false,
+		Message:   "validation skipped while the probe of an earlier run is still running",
+		CheckedAt: now,
+		ErrorType: ErrorTypeSkipped,
+	}
+}
@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// hangingValidator ignores its context until released, like a stuck connection
+type hangingValidator struct {
+	release  chan struct{}
+	timeouts chan time.Duration
+}
+
+func (h *hangingValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	h.timeouts <- timeout
+	<-h.release
+	return &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}
+}
+
+func (h *hangingValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return h.ValidateKeys(ctx, timeout)
+}
+
+func TestValidateAllReportsUnfinishedEndpointsAsTimedOut(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Minute,
+		Endpoints:         []config.S3EndpointConfig{{Name: "fast"}, {Name: "stuck"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	stuck := &hangingValidator{release: make(chan struct{}), timeouts: make(chan time.Duration, 1)}
+	defer close(stuck.release)
+
+	vm.mu.Lock()
+	vm.validators["fast"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.validators["stuck"] = stuck
+	vm.mu.Unlock()
+
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results := vm.ValidateAll(ctx)
+
+	if !results.Results["fast"].IsValid {
+		t.Fatalf("expected the finished endpoint to be reported, got %+v", results.Results["fast"])
+	}
+	if result := results.Results["stuck"]; result == nil || result.ErrorType != ErrorTypeTimedOut {
+		t.Fatalf("expected the unfinished endpoint to be timed_out, got %+v", result)
+	}
+	if timeout := <-stuck.timeouts; timeout <= 0 || timeout > 50*time.Millisecond {
+		t.Fatalf("expected the probe timeout to be cut to the request deadline, got %s", timeout)
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0].Results) != 1 || sink.batches[0].Results["stuck"] != nil {
+		t.Fatalf("expected only the finished endpoint to be published, got %+v", sink.batches)
+	}
+}
+
+func TestValidateAllDropsFailuresCausedByTheDeadline(t *testing.T) {
+	cfg := &config.Config{ValidationTimeout: time.Minute, Endpoints: []config.S3EndpointConfig{{Name: "slow"}}}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	vm.validators["slow"] = &contextValidator{}
+	vm.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results := vm.ValidateAll(ctx)
+
+	if result := results.Results["slow"]; result == nil || result.ErrorType != ErrorTypeTimedOut {
+		t.Fatalf("expected a deadline-induced timeout to be reported as timed_out, got %+v", result)
+	}
+}
+
+// contextValidator fails with a timeout once its context ends, like the real validator
+type contextValidator struct{}
+
+func (contextValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	<-ctx.Done()
+	return &s3.ValidationResult{IsValid: false, ErrorType: "timeout", CheckedAt: time.Now()}
+}
+
+func (c contextValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return c.ValidateKeys(ctx, timeout)
+}
+
+func TestValidateAllReportsCanceledEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Minute,
+		Endpoints:         []config.S3EndpointConfig{{Name: "done"}, {Name: "stuck"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	stuck := &hangingValidator{release: make(chan struct{}), timeouts: make(chan time.Duration, 1)}
+	defer close(stuck.release)
+
+	vm.mu.Lock()
+	vm.validators["done"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.validators["stuck"] = stuck
+	vm.mu.Unlock()
+
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+	metrics.ValidationsUnfinished.Reset()
+
+	// Cancel, like a shutdown would, as soon as the healthy endpoint has finished
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := vm.ValidateAllStreaming(ctx, func(name string, _ *s3.ValidationResult) {
+		if name == "done" {
+			cancel()
+		}
+	})
+
+	if result := results.Results["stuck"]; result == nil || result.ErrorType != ErrorTypeCanceled {
+		t.Fatalf("expected the unfinished endpoint to be canceled, got %+v", result)
+	}
+	if len(sink.batches) != 1 || sink.batches[0].Results["done"] == nil {
+		t.Fatalf("expected the completed endpoint to be published, got %+v", sink.batches)
+	}
+	if got := testutil.ToFloat64(metrics.ValidationsUnfinished.WithLabelValues("stuck", ErrorTypeCanceled)); got != 1 {
+		t.Fatalf("expected the canceled validation to be counted, got %v", got)
+	}
+}
+
+func TestProbeTimeoutUsesManagerClock(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	vm := NewValidatorManager(&config.Config{ValidationTimeout: time.Minute}, logrus.New(), WithClock(clock.NewFake(now)))
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(20*time.Second))
+	defer cancel()
+	if timeout := vm.probeTimeout(ctx); timeout != 20*time.Second {
+		t.Fatalf("expected the timeout to be what is left by the manager clock, got %s", timeout)
+	}
+	if timeout := vm.probeTimeout(context.Background()); timeout != time.Minute {
+		t.Fatalf("expected the validation timeout without a deadline, got %s", timeout)
+	}
+}
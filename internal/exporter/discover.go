@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// ErrEndpointNotFound is returned for actions on an endpoint that is not configured
+var ErrEndpointNotFound = errors.New("endpoint not found")
+
+// permissionDiscoverer is implemented by validators that can map their credentials' permissions
+type permissionDiscoverer interface {
+	Discover(ctx context.Context, timeout time.Duration) *s3.DiscoveryResult
+}
+
+// Discover maps the permissions of the primary credentials
+func (sv *slottedValidator) Discover(ctx context.Context, timeout time.Duration) *s3.DiscoveryResult {
+	discoverer, ok := sv.primary.(permissionDiscoverer)
+	if !ok {
+		return &s3.DiscoveryResult{}
+	}
+	return discoverer.Discover(ctx, timeout)
+}
+
+// DiscoverEndpoint tries a battery of S3 operations with the endpoint's credentials and
+// exports the resulting permission matrix as s3_permission
+func (vm *ValidatorManager) DiscoverEndpoint(ctx context.Context, endpointName string) (*s3.DiscoveryResult, error) {
+	vm.mu.RLock()
+	validator, exists := vm.validators[endpointName]
+	vm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %q", ErrEndpointNotFound, endpointName)
+	}
+	discoverer, ok := validator.(permissionDiscoverer)
+	if !ok {
+		return nil, fmt.Errorf("endpoint %q does not support permission discovery", endpointName)
+	}
+
+	// Discovery writes canaries, so the janitor covers the bucket from now on
+	vm.mu.Lock()
+	if meta, ok := vm.meta[endpointName]; ok && !meta.writesCanaries {
+		meta.writesCanaries = true
+		vm.meta[endpointName] = meta
+	}
+	vm.mu.Unlock()
+
+	result := discoverer.Discover(ctx, vm.timeout)
+	for _, permission := range result.Permissions {
+		known := permission.Status == s3.PermissionAllowed || permission.Status == s3.PermissionDenied
+		vm.metrics.SetPermission(endpointName, permission.Operation, known, permission.Status == s3.PermissionAllowed)
+	}
+	vm.log.WithField("endpoint", endpointName).Debug("Permission discovery finished")
+	return result, nil
+}
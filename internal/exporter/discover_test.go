@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// discoveringValidator reports a fixed permission matrix
+type discoveringValidator struct {
+	stubValidator
+	permissions []s3.PermissionResult
+}
+
+func (d *discoveringValidator) Discover(ctx context.Context, timeout time.Duration) *s3.DiscoveryResult {
+	return &s3.DiscoveryResult{CheckedAt: time.Now(), Permissions: d.permissions}
+}
+
+func TestDiscoverEndpointExportsPermissions(t *testing.T) {
+	vm := NewValidatorManager(&config.Config{ValidationTimeout: time.Second}, logrus.New())
+	vm.mu.Lock()
+	vm.validators["disc-a"] = &slottedValidator{
+		primary: &discoveringValidator{permissions: []s3.PermissionResult{
+			{Operation: s3.OperationListObjects, Status: s3.PermissionAllowed},
+			{Operation: s3.OperationPutObject, Status: s3.PermissionDenied},
+			{Operation: s3.OperationGetBucketPolicy, Status: s3.PermissionUnknown},
+		}},
+		secondary: &stubValidator{},
+	}
+	vm.meta["disc-a"] = endpointMeta{}
+	vm.mu.Unlock()
+
+	result, err := vm.DiscoverEndpoint(context.Background(), "disc-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Permissions) != 3 {
+		t.Fatalf("expected the primary credentials' matrix, got %+v", result.Permissions)
+	}
+	if got := testutil.ToFloat64(metrics.Permission.WithLabelValues("disc-a", s3.OperationListObjects)); got != 1 {
+		t.Fatalf("expected ListObjectsV2 to be allowed, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.Permission.WithLabelValues("disc-a", s3.OperationPutObject)); got != 0 {
+		t.Fatalf("expected PutObject to be denied, got %v", got)
+	}
+	if !vm.meta["disc-a"].writesCanaries {
+		t.Fatal("expected the janitor to cover the discovered endpoint's bucket")
+	}
+	metrics.UnregisterEndpoint("disc-a")
+}
+
+func TestDiscoverEndpointUnknown(t *testing.T) {
+	vm := NewValidatorManager(&config.Config{ValidationTimeout: time.Second}, logrus.New())
+
+	if _, err := vm.DiscoverEndpoint(context.Background(), "missing"); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("expected ErrEndpointNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+)
+
+// Probes reported in s3_probe_hedges_total
+const (
+	HedgeWinnerPrimary = "primary"
+	HedgeWinnerHedge   = "hedge"
+)
+
+// hedgedValidator sends a second shallow probe when the first has not answered within
+// the endpoint's p95 latency and takes whichever succeeds first, so one slow backend
+// behind a load balancer does not show up as a latency spike. Deep probes write to the
+// bucket and are never hedged.
+type hedgedValidator struct {
+	inner bucketValidator
+	name  string
+	delay func() (time.Duration, bool) // how long to wait before hedging; false disables it
+	clock clock.Clock
+
+	metrics *metrics.Metrics
+}
+
+// hedgeDelay returns the endpoint's p95 latency of successful shallow probes, at least
+// cfg.MinDelay. It reports false until the endpoint has cfg.MinSamples such results in
+// its history.
+func (vm *ValidatorManager) hedgeDelay(name string, cfg config.HedgeConfig) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		latency, ok := vm.history.ShallowLatency(name)
+		if !ok || latency.Samples < cfg.MinSamples {
+			return 0, false
+		}
+		return max(time.Duration(latency.P95Ms)*time.Millisecond, time.Duration(cfg.MinDelay)), true
+	}
+}
+
+// ValidateKeys runs the shallow check, hedging it once it outlasts the delay. Once
+// hedged, the first valid answer wins; a failure is reported only when both probes fail,
+// and then it is the first one.
+func (hv *hedgedValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	delay, ok := hv.delay()
+	if !ok {
+		return hv.inner.ValidateKeys(ctx, timeout)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the probe that lost
+	start := hv.clock.Now()
+	type answer struct {
+		result *s3.ValidationResult
+		winner string
+	}
+	answers := make(chan answer, 2)
+	probe := func(winner string) {
+		answers <- answer{hv.inner.ValidateKeys(ctx, timeout), winner}
+	}
+	go probe(HedgeWinnerPrimary)
+
+	timer := hv.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case first := <-answers:
+		return first.result
+	case <-timer.C():
+	}
+
+	go probe(HedgeWinnerHedge)
+	first := <-answers
+	if !first.result.IsValid {
+		if second := <-answers; second.result.IsValid {
+			first = second
+		}
+	}
+	hv.metrics.RecordProbeHedge(hv.name, first.winner)
+
+	// Report the time the caller waited, not just the winning probe's own
+	elapsed := hv.clock.Since(start)
+	first.result.Duration = elapsed
+	first.result.ResponseTimeMs = elapsed.Milliseconds()
+	return first.result
+}
+
+// ValidateDeep runs the deep probe without hedging
+func (hv *hedgedValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return hv.inner.ValidateDeep(ctx, timeout)
+}
+
+// ValidateWith runs a single validation with probe overrides without hedging
+func (hv *hedgedValidator) ValidateWith(ctx context.Context, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	return validateWith(ctx, hv.inner, timeout, opts)
+}
+
+// KeyCreatedAt reports the creation date of the wrapped validator's key
+func (hv *hedgedValidator) KeyCreatedAt(ctx context.Context) (time.Time, error) {
+	dater, ok := hv.inner.(keyDater)
+	if !ok {
+		return time.Time{}, fmt.Errorf("validator cannot look up key creation dates")
+	}
+	return dater.KeyCreatedAt(ctx)
+}
+
+// Refresh drops the wrapped validator's cached state
+func (hv *hedgedValidator) Refresh() {
+	if r, ok := hv.inner.(refresher); ok {
+		r.Refresh()
+	}
+}
+
+// Discover maps the permissions of the wrapped validator's credentials
+func (hv *hedgedValidator) Discover(ctx context.Context, timeout time.Duration) *s3.DiscoveryResult {
+	discoverer, ok := hv.inner.(permissionDiscoverer)
+	if !ok {
+		return &s3.DiscoveryResult{}
+	}
+	return discoverer.Discover(ctx, timeout)
+}
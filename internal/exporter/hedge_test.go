@@ -0,0 +1,190 @@
+package exporter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// slowFirstValidator stalls its first probe until the probe is canceled and answers
+// every later one at once, like a load balancer with one slow backend
+type slowFirstValidator struct {
+	calls    atomic.Int32
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func (s *slowFirstValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	if s.calls.Add(1) == 1 {
+		close(s.started)
+		<-ctx.Done()
+		close(s.canceled)
+		return &s3.ValidationResult{IsValid: false, ErrorType: ErrorTypeCanceled}
+	}
+	return &s3.ValidationResult{IsValid: true, ResponseTimeMs: 5, Duration: 5 * time.Millisecond}
+}
+
+func (s *slowFirstValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return s.ValidateKeys(ctx, timeout)
+}
+
+func TestHedgedValidatorTakesFirstAnswer(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	inner := &slowFirstValidator{started: make(chan struct{}), canceled: make(chan struct{})}
+	hv := &hedgedValidator{
+		inner: inner,
+		name:  "flaky",
+		delay: func() (time.Duration, bool) { return 300 * time.Millisecond, true },
+		clock: clk,
+
+		metrics: metrics.Default,
+	}
+	metrics.ProbeHedges.Reset()
+
+	done := make(chan *s3.ValidationResult)
+	go func() { done <- hv.ValidateKeys(context.Background(), time.Second) }()
+	<-inner.started
+	clk.BlockUntil(1)
+	clk.Advance(300 * time.Millisecond)
+	result := <-done
+
+	if !result.IsValid || inner.calls.Load() != 2 {
+		t.Fatalf("expected the hedge to answer, got %+v after %d probes", result, inner.calls.Load())
+	}
+	if result.ResponseTimeMs != 300 {
+		t.Fatalf("expected the response time to include the wait before hedging, got %dms", result.ResponseTimeMs)
+	}
+	<-inner.canceled
+	if got := testutil.ToFloat64(metrics.ProbeHedges.WithLabelValues("flaky", HedgeWinnerHedge)); got != 1 {
+		t.Fatalf("expected one hedge won by the second probe, got %v", got)
+	}
+}
+
+// failingHedgeValidator answers its first probe once released and fails every later one
+type failingHedgeValidator struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *failingHedgeValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	if s.calls.Add(1) == 1 {
+		close(s.started)
+		<-s.release
+		return &s3.ValidationResult{IsValid: true}
+	}
+	return &s3.ValidationResult{IsValid: false, ErrorType: "network_error"}
+}
+
+func (s *failingHedgeValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return s.ValidateKeys(ctx, timeout)
+}
+
+func TestHedgedValidatorPrefersValidAnswer(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	inner := &failingHedgeValidator{started: make(chan struct{}), release: make(chan struct{})}
+	hv := &hedgedValidator{
+		inner: inner,
+		name:  "flaky",
+		delay: func() (time.Duration, bool) { return 300 * time.Millisecond, true },
+		clock: clk,
+
+		metrics: metrics.Default,
+	}
+	metrics.ProbeHedges.Reset()
+
+	done := make(chan *s3.ValidationResult)
+	go func() { done <- hv.ValidateKeys(context.Background(), time.Second) }()
+	<-inner.started
+	clk.BlockUntil(1)
+	clk.Advance(300 * time.Millisecond)
+	for inner.calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.release)
+
+	if result := <-done; !result.IsValid {
+		t.Fatalf("expected the slow valid answer to win over the failed hedge, got %+v", result)
+	}
+	if got := testutil.ToFloat64(metrics.ProbeHedges.WithLabelValues("flaky", HedgeWinnerPrimary)); got != 1 {
+		t.Fatalf("expected the primary probe to win, got %v", got)
+	}
+}
+
+func TestHedgedValidatorSkipsFastOrUnknownEndpoints(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	metrics.ProbeHedges.Reset()
+
+	fast := &hedgedValidator{
+		inner: &stubValidator{result: &s3.ValidationResult{IsValid: true}},
+		name:  "fast",
+		delay: func() (time.Duration, bool) { return time.Second, true },
+		clock: clk,
+
+		metrics: metrics.Default,
+	}
+	if result := fast.ValidateKeys(context.Background(), time.Second); !result.IsValid {
+		t.Fatalf("expected the primary answer, got %+v", result)
+	}
+
+	inner := &slowFirstValidator{started: make(chan struct{}), canceled: make(chan struct{})}
+	unknown := &hedgedValidator{
+		inner: inner,
+		name:  "unknown",
+		delay: func() (time.Duration, bool) { return 0, false },
+		clock: clk,
+
+		metrics: metrics.Default,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if unknown.ValidateKeys(ctx, time.Second); inner.calls.Load() != 1 {
+		t.Fatalf("expected a single probe without enough history, got %d", inner.calls.Load())
+	}
+	if got := testutil.CollectAndCount(metrics.ProbeHedges); got != 0 {
+		t.Fatalf("expected no hedges, got %d series", got)
+	}
+}
+
+func TestHedgeDelayFollowsP95(t *testing.T) {
+	vm := NewValidatorManager(&config.Config{ValidationTimeout: time.Second, HistorySize: 100}, logrus.New())
+	delay := vm.hedgeDelay("logs", config.HedgeConfig{MinDelay: config.Duration(50 * time.Millisecond), MinSamples: 10})
+
+	record := func(from, to int) {
+		for ms := from; ms <= to; ms++ {
+			vm.history.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{
+				"logs": {IsValid: true, ResponseTimeMs: int64(ms), CheckedAt: time.Unix(int64(ms), 0)},
+			}})
+		}
+	}
+	record(1, 9)
+	for ms := 1000; ms < 1020; ms++ {
+		// Neither failures nor deep probes count towards the hedge delay
+		vm.history.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{
+			"logs": {IsValid: ms%2 == 0, Depth: s3.ProbeDepthDeep, ResponseTimeMs: int64(ms)},
+		}})
+		vm.history.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{
+			"logs": {IsValid: false, ResponseTimeMs: int64(ms)},
+		}})
+	}
+	if _, ok := delay(); ok {
+		t.Fatal("expected no hedging before min_samples results")
+	}
+	record(10, 20)
+	if got, ok := delay(); !ok || got != 50*time.Millisecond {
+		t.Fatalf("expected min_delay to floor a fast p95, got %s", got)
+	}
+	record(100, 119)
+	if got, ok := delay(); !ok || got < 100*time.Millisecond {
+		t.Fatalf("expected the p95 once it exceeds min_delay, got %s", got)
+	}
+}
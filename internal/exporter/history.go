@@ -0,0 +1,145 @@
+package exporter
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// HistoryEntry is a compact record of one validation result
+type HistoryEntry struct {
+	CheckedAt      time.Time
+	IsValid        bool
+	ErrorType      string
+	Message        string
+	ResponseTimeMs int64
+	Depth          s3.ProbeDepth // empty is shallow
+}
+
+// LatencySummary holds rolling response time percentiles over the history buffer
+type LatencySummary struct {
+	Samples int
+	P50Ms   int64
+	P95Ms   int64
+	P99Ms   int64
+}
+
+// HistorySink keeps the most recent results per endpoint in a fixed-size ring buffer
+type HistorySink struct {
+	size int
+
+	mu      sync.RWMutex
+	entries map[string][]HistoryEntry // oldest first
+}
+
+// NewHistorySink creates a history buffer holding size results per endpoint
+func NewHistorySink(size int) *HistorySink {
+	if size <= 0 {
+		size = config.DefaultHistorySize
+	}
+	return &HistorySink{
+		size:    size,
+		entries: make(map[string][]HistoryEntry),
+	}
+}
+
+// Consume appends every result in the batch to its endpoint's history
+func (h *HistorySink) Consume(results *ValidationResults) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		entries := append(h.entries[name], HistoryEntry{
+			CheckedAt:      result.CheckedAt,
+			IsValid:        result.IsValid,
+			ErrorType:      result.ErrorType,
+			Message:        result.Message,
+			ResponseTimeMs: result.ResponseTimeMs,
+			Depth:          result.Depth,
+		})
+		if len(entries) > h.size {
+			entries = entries[len(entries)-h.size:]
+		}
+		h.entries[name] = entries
+	}
+}
+
+// History returns a copy of the endpoint's history, oldest first
+func (h *HistorySink) History(endpointName string) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]HistoryEntry(nil), h.entries[endpointName]...)
+}
+
+// Endpoints returns the names of endpoints with recorded history, sorted
+func (h *HistorySink) Endpoints() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.entries))
+	for name := range h.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Latency computes p50/p95/p99 response times over the endpoint's history.
+// It reports false when no results were recorded yet.
+func (h *HistorySink) Latency(endpointName string) (LatencySummary, bool) {
+	return h.latency(endpointName, func(HistoryEntry) bool { return true })
+}
+
+// ShallowLatency is Latency over the endpoint's successful shallow probes only, the
+// ones a hedge races; failures and deep probes would skew the percentiles
+func (h *HistorySink) ShallowLatency(endpointName string) (LatencySummary, bool) {
+	return h.latency(endpointName, func(entry HistoryEntry) bool {
+		return entry.IsValid && entry.Depth != s3.ProbeDepthDeep
+	})
+}
+
+// latency computes the percentiles over the history entries accepted by keep
+func (h *HistorySink) latency(endpointName string, keep func(HistoryEntry) bool) (LatencySummary, bool) {
+	h.mu.RLock()
+	samples := make([]int64, 0, len(h.entries[endpointName]))
+	for _, entry := range h.entries[endpointName] {
+		if keep(entry) {
+			samples = append(samples, entry.ResponseTimeMs)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(samples) == 0 {
+		return LatencySummary{}, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return LatencySummary{
+		Samples: len(samples),
+		P50Ms:   percentile(samples, 50),
+		P95Ms:   percentile(samples, 95),
+		P99Ms:   percentile(samples, 99),
+	}, true
+}
+
+// Forget drops the history of a removed endpoint
+func (h *HistorySink) Forget(endpointName string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.entries, endpointName)
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Status filters of a history query
+const (
+	HistoryStatusValid   = "valid"
+	HistoryStatusInvalid = "invalid"
+)
+
+// ErrNoHistoryStore is returned by history range queries without a persistent store
+var ErrNoHistoryStore = errors.New("no persistent history store is configured")
+
+// HistoryQuery selects persisted results; zero fields do not filter
+type HistoryQuery struct {
+	Endpoint string
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+	Status   string    // HistoryStatusValid or HistoryStatusInvalid
+	Limit    int       // maximum number of records, oldest first
+}
+
+// HistoryRecord is a persisted result of an endpoint
+type HistoryRecord struct {
+	Endpoint string
+	HistoryEntry
+}
+
+// DailyAvailability summarizes one UTC day of an endpoint's results
+type DailyAvailability struct {
+	Day           time.Time // midnight UTC
+	Checks        int
+	Failures      int
+	UptimePercent float64
+	P95Ms         int64
+}
+
+// HistoryStore persists validation results beyond the process lifetime, where the
+// in-memory history only keeps the last HISTORY_SIZE results
+type HistoryStore interface {
+	ResultSink
+	// Query returns the matching records ordered by check time, oldest first
+	Query(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error)
+	// DailyAvailability returns the endpoint's daily rollups for days in [from, to), oldest first
+	DailyAvailability(ctx context.Context, endpointName string, from, to time.Time) ([]DailyAvailability, error)
+}
+
+// WithHistoryStore records every result in store and serves range queries from it
+func WithHistoryStore(store HistoryStore) ManagerOption {
+	return func(vm *ValidatorManager) {
+		vm.store = store
+		vm.sinks = append(vm.sinks, store)
+	}
+}
+
+// QueryHistory returns persisted results matching query, or ErrNoHistoryStore
+func (vm *ValidatorManager) QueryHistory(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error) {
+	if vm.store == nil {
+		return nil, ErrNoHistoryStore
+	}
+	return vm.store.Query(ctx, query)
+}
+
+// AvailabilityHistory returns the endpoint's daily rollups, or ErrNoHistoryStore
+func (vm *ValidatorManager) AvailabilityHistory(ctx context.Context, endpointName string, from, to time.Time) ([]DailyAvailability, error) {
+	if vm.store == nil {
+		return nil, ErrNoHistoryStore
+	}
+	return vm.store.DailyAvailability(ctx, endpointName, from, to)
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHistorySinkKeepsMostRecent(t *testing.T) {
+	history := NewHistorySink(3)
+
+	for i := 1; i <= 5; i++ {
+		consumeOne(history, "bucket", &s3.ValidationResult{IsValid: i%2 == 0, CheckedAt: time.Unix(int64(i), 0), ResponseTimeMs: int64(i * 10)})
+	}
+
+	entries := history.History("bucket")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].ResponseTimeMs != 30 || entries[2].ResponseTimeMs != 50 {
+		t.Fatalf("expected the 3 most recent results oldest first, got %+v", entries)
+	}
+}
+
+func TestHistorySinkLatencyPercentiles(t *testing.T) {
+	history := NewHistorySink(100)
+	for i := 1; i <= 100; i++ {
+		consumeOne(history, "bucket", &s3.ValidationResult{CheckedAt: time.Now(), ResponseTimeMs: int64(i)})
+	}
+
+	latency, ok := history.Latency("bucket")
+	if !ok {
+		t.Fatalf("expected latency summary")
+	}
+	if latency.Samples != 100 || latency.P50Ms != 50 || latency.P95Ms != 95 || latency.P99Ms != 99 {
+		t.Fatalf("unexpected percentiles: %+v", latency)
+	}
+
+	if _, ok := history.Latency("unknown"); ok {
+		t.Fatalf("expected no summary without history")
+	}
+}
+
+func TestRemoveEndpointForgetsHistory(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "one"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	vm.mu.Lock()
+	vm.validators["one"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now(), ResponseTimeMs: 12}}
+	vm.mu.Unlock()
+
+	vm.ValidateAll(context.Background())
+	if latency, ok := vm.Latency("one"); !ok || latency.P50Ms != 12 {
+		t.Fatalf("expected history to be recorded, got %+v", latency)
+	}
+
+	vm.RemoveEndpoint("one")
+	if len(vm.History("one")) != 0 {
+		t.Fatalf("expected history to be dropped with the endpoint")
+	}
+}
@@ -0,0 +1,132 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaxInjectionDuration bounds how long a failure can be injected, so a forgotten chaos
+// test cannot silence an endpoint for good
+const MaxInjectionDuration = 24 * time.Hour
+
+// InjectableErrorTypes are the error types a failure can be injected with
+var InjectableErrorTypes = []string{
+	"access_denied", "bucket_not_found", "token_expired", "clock_skew", "throttled",
+	"timeout", "network", "transform_failed", "criterion_failed", "config_error", "unknown",
+}
+
+// ErrInvalidInjection is returned for an injected failure with an unknown error type
+// or a duration out of range
+var ErrInvalidInjection = errors.New("invalid failure injection")
+
+// injectedFailure is a synthetic failure reported for an endpoint until it expires
+type injectedFailure struct {
+	errorType string
+	expires   time.Time
+}
+
+// failureInjector holds the failures injected per endpoint for chaos testing
+type failureInjector struct {
+	clock   clock.Clock
+	metrics *metrics.Metrics
+
+	mu       sync.Mutex
+	failures map[string]injectedFailure
+}
+
+func newFailureInjector(clk clock.Clock, m *metrics.Metrics) *failureInjector {
+	return &failureInjector{clock: clk, metrics: m, failures: make(map[string]injectedFailure)}
+}
+
+// set injects a failure into an endpoint's results until the returned time
+func (f *failureInjector) set(endpointName, errorType string, duration time.Duration) time.Time {
+	expires := f.clock.Now().Add(duration)
+	f.mu.Lock()
+	f.failures[endpointName] = injectedFailure{errorType: errorType, expires: expires}
+	f.mu.Unlock()
+	f.metrics.SetFailureInjected(endpointName, errorType)
+	return expires
+}
+
+// clear stops injecting into an endpoint and reports whether a failure was active
+func (f *failureInjector) clear(endpointName string) bool {
+	f.mu.Lock()
+	failure, ok := f.failures[endpointName]
+	delete(f.failures, endpointName)
+	f.mu.Unlock()
+	if ok {
+		f.metrics.SetFailureInjected(endpointName, "")
+	}
+	return ok && f.clock.Now().Before(failure.expires)
+}
+
+// result returns the synthetic result of an endpoint with an active injected failure.
+// Expired failures are dropped on the way.
+func (f *failureInjector) result(endpointName string) (*s3.ValidationResult, bool) {
+	now := f.clock.Now()
+	f.mu.Lock()
+	failure, ok := f.failures[endpointName]
+	expired := ok && !now.Before(failure.expires)
+	if expired {
+		delete(f.failures, endpointName)
+	}
+	f.mu.Unlock()
+
+	if expired {
+		f.metrics.SetFailureInjected(endpointName, "")
+	}
+	if !ok || expired {
+		return nil, false
+	}
+	return &s3.ValidationResult{
+		IsValid:   false,
+		Message:   fmt.Sprintf("injected %s failure until %s", failure.errorType, failure.expires.UTC().Format(time.RFC3339)),
+		CheckedAt: now,
+		ErrorType: failure.errorType,
+	}, true
+}
+
+// InjectFailure makes an endpoint report failures of errorType instead of probing for
+// duration, so alert routing and runbooks can be tested without breaking keys. The
+// results flow through metrics, history and notifications like real ones.
+func (vm *ValidatorManager) InjectFailure(endpointName, errorType string, duration time.Duration) (time.Time, error) {
+	if !slices.Contains(InjectableErrorTypes, errorType) {
+		return time.Time{}, fmt.Errorf("%w: error_type must be one of %v, got %q", ErrInvalidInjection, InjectableErrorTypes, errorType)
+	}
+	if duration <= 0 || duration > MaxInjectionDuration {
+		return time.Time{}, fmt.Errorf("%w: duration must be positive and at most %s, got %s", ErrInvalidInjection, MaxInjectionDuration, duration)
+	}
+
+	vm.mu.RLock()
+	_, exists := vm.validators[endpointName]
+	vm.mu.RUnlock()
+	if !exists {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrEndpointNotFound, endpointName)
+	}
+
+	expires := vm.injections.set(endpointName, errorType, duration)
+	vm.log.WithFields(logrus.Fields{
+		"endpoint_name": endpointName,
+		"error_type":    errorType,
+		"expires_at":    expires,
+	}).Warn("Injecting synthetic validation failures")
+	return expires, nil
+}
+
+// ClearInjectedFailure stops an injected failure early and reports whether one was active
+func (vm *ValidatorManager) ClearInjectedFailure(endpointName string) bool {
+	cleared := vm.injections.clear(endpointName)
+	if cleared {
+		vm.log.WithField("endpoint_name", endpointName).Info("Stopped injecting validation failures")
+	}
+	return cleared
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestInjectFailure(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	cfg := &config.Config{ValidationTimeout: time.Second, Endpoints: []config.S3EndpointConfig{{Name: "chaos"}, {Name: "calm"}}}
+	vm := NewValidatorManager(cfg, logrus.New(), WithClock(clk))
+	vm.mu.Lock()
+	vm.validators["chaos"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: clk.Now()}}
+	vm.validators["calm"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: clk.Now()}}
+	vm.mu.Unlock()
+
+	if _, err := vm.InjectFailure("chaos", "exploded", time.Minute); !errors.Is(err, ErrInvalidInjection) {
+		t.Fatalf("expected an unknown error type to be rejected, got %v", err)
+	}
+	if _, err := vm.InjectFailure("chaos", "access_denied", 48*time.Hour); !errors.Is(err, ErrInvalidInjection) {
+		t.Fatalf("expected an overlong duration to be rejected, got %v", err)
+	}
+	if _, err := vm.InjectFailure("missing", "access_denied", time.Minute); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("expected an unknown endpoint to be reported, got %v", err)
+	}
+
+	expires, err := vm.InjectFailure("chaos", "access_denied", time.Minute)
+	if err != nil || !expires.Equal(clk.Now().Add(time.Minute)) {
+		t.Fatalf("inject failure: %v %v", expires, err)
+	}
+	results := vm.ValidateAll(context.Background())
+	if chaos := results.Results["chaos"]; chaos.IsValid || chaos.ErrorType != "access_denied" {
+		t.Fatalf("expected the injected failure, got %+v", chaos)
+	}
+	if !results.Results["calm"].IsValid {
+		t.Fatalf("expected other endpoints to be probed as usual")
+	}
+	if got := testutil.ToFloat64(metrics.KeysValid.WithLabelValues("chaos")); got != 0 {
+		t.Fatalf("expected the injected failure to reach the metrics, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.FailureInjected.WithLabelValues("chaos", "access_denied")); got != 1 {
+		t.Fatalf("expected the injection to be marked, got %v", got)
+	}
+
+	clk.Advance(time.Minute)
+	if result := vm.ValidateEndpoint(context.Background(), "chaos"); !result.IsValid {
+		t.Fatalf("expected probing to resume once the injection expired, got %+v", result)
+	}
+	if got := testutil.CollectAndCount(metrics.FailureInjected); got != 0 {
+		t.Fatalf("expected the mark to be cleared on expiry, got %d series", got)
+	}
+
+	vm.InjectFailure("chaos", "throttled", time.Hour)
+	if !vm.ClearInjectedFailure("chaos") || vm.ClearInjectedFailure("chaos") {
+		t.Fatalf("expected only an active injection to be cleared")
+	}
+	if result := vm.ValidateEndpoint(context.Background(), "chaos"); !result.IsValid {
+		t.Fatalf("expected probing to resume once the injection was cleared, got %+v", result)
+	}
+}
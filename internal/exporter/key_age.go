@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+)
+
+// iamRetryInterval spaces out IAM lookups that failed, e.g. for lack of iam:ListAccessKeys
+const iamRetryInterval = time.Hour
+
+// KeyAge describes how old an endpoint's access key is relative to its rotation policy
+type KeyAge struct {
+	CreatedAt   time.Time
+	Age         time.Duration
+	MaxAge      time.Duration // 0 when no rotation policy applies
+	RotationDue bool
+}
+
+// keyDater is implemented by validators that can look up their key's creation date
+type keyDater interface {
+	KeyCreatedAt(ctx context.Context) (time.Time, error)
+}
+
+// keyAgeState is the key creation source and rotation policy of one endpoint
+type keyAgeState struct {
+	createdAt  time.Time
+	maxAge     time.Duration
+	fromIAM    bool
+	lastLookup time.Time
+}
+
+// keyAgeTracker remembers key creation dates, declared in config or fetched from IAM
+type keyAgeTracker struct {
+	mu        sync.Mutex
+	endpoints map[string]*keyAgeState
+	clock     clock.Clock
+}
+
+func newKeyAgeTracker(clk clock.Clock) *keyAgeTracker {
+	return &keyAgeTracker{endpoints: make(map[string]*keyAgeState), clock: clk}
+}
+
+// set registers an endpoint; endpoints with neither a date nor an IAM lookup are not tracked
+func (t *keyAgeTracker) set(name string, createdAt time.Time, fromIAM bool, maxAge time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if createdAt.IsZero() && !fromIAM {
+		delete(t.endpoints, name)
+		return
+	}
+	t.endpoints[name] = &keyAgeState{createdAt: createdAt, maxAge: maxAge, fromIAM: fromIAM}
+}
+
+func (t *keyAgeTracker) forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.endpoints, name)
+}
+
+// lookup fetches the creation date from IAM once the endpoint's keys are known to work.
+// Failed lookups are retried after iamRetryInterval and reported to the caller. The
+// timeout only applies once a lookup is due, so the common no-op path stays allocation free.
+func (t *keyAgeTracker) lookup(ctx context.Context, name string, validator bucketValidator, result *s3.ValidationResult, timeout time.Duration) error {
+	dater, ok := validator.(keyDater)
+	if !ok || result == nil || !result.IsValid {
+		return nil
+	}
+
+	t.mu.Lock()
+	state, tracked := t.endpoints[name]
+	due := tracked && state.fromIAM && state.createdAt.IsZero() &&
+		(state.lastLookup.IsZero() || t.clock.Now().Sub(state.lastLookup) >= iamRetryInterval)
+	if due {
+		state.lastLookup = t.clock.Now()
+	}
+	t.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	createdAt, err := dater.KeyCreatedAt(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if current, ok := t.endpoints[name]; ok && current == state {
+		state.createdAt = createdAt
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// age reports the key age of an endpoint whose creation date is known
+func (t *keyAgeTracker) age(name string) (KeyAge, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.endpoints[name]
+	if !ok || state.createdAt.IsZero() {
+		return KeyAge{}, false
+	}
+	age := KeyAge{
+		CreatedAt: state.createdAt,
+		Age:       t.clock.Now().Sub(state.createdAt),
+		MaxAge:    state.maxAge,
+	}
+	age.RotationDue = age.MaxAge > 0 && age.Age > age.MaxAge
+	return age, true
+}
+
+// KeyAge reports the current key age of an endpoint whose key creation date is known
+func (vm *ValidatorManager) KeyAge(endpointName string) (KeyAge, bool) {
+	return vm.keyAges.age(endpointName)
+}
@@ -0,0 +1,132 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+type datedValidator struct {
+	stubValidator
+	createdAt time.Time
+	err       error
+	lookups   int
+}
+
+func (d *datedValidator) KeyCreatedAt(ctx context.Context) (time.Time, error) {
+	d.lookups++
+	return d.createdAt, d.err
+}
+
+func TestKeyAgeTrackerDeclaredDate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	tracker := newKeyAgeTracker(clk)
+
+	tracker.set("declared", now.Add(-100*24*time.Hour), false, 90*24*time.Hour)
+	tracker.set("untracked", time.Time{}, false, 90*24*time.Hour)
+
+	age, ok := tracker.age("declared")
+	if !ok {
+		t.Fatalf("expected a key age for the declared endpoint")
+	}
+	if age.Age != 100*24*time.Hour || !age.RotationDue {
+		t.Fatalf("expected a 100 day old key due for rotation, got %+v", age)
+	}
+	if _, ok := tracker.age("untracked"); ok {
+		t.Fatalf("expected no key age without a creation date")
+	}
+
+	tracker.set("declared", now.Add(-time.Hour), false, 0)
+	if age, _ := tracker.age("declared"); age.RotationDue {
+		t.Fatalf("expected no rotation due without a policy, got %+v", age)
+	}
+}
+
+func TestKeyAgeTrackerIAMLookup(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	tracker := newKeyAgeTracker(clk)
+	tracker.set("iam", time.Time{}, true, 0)
+
+	validator := &datedValidator{err: errors.New("access denied")}
+	valid := &s3.ValidationResult{IsValid: true}
+
+	if err := tracker.lookup(context.Background(), "iam", validator, &s3.ValidationResult{IsValid: false}, time.Second); err != nil || validator.lookups != 0 {
+		t.Fatalf("expected no lookup while the keys are invalid")
+	}
+	if err := tracker.lookup(context.Background(), "iam", validator, valid, time.Second); err == nil {
+		t.Fatalf("expected the lookup error to be reported")
+	}
+	if err := tracker.lookup(context.Background(), "iam", validator, valid, time.Second); err != nil || validator.lookups != 1 {
+		t.Fatalf("expected the failed lookup not to be retried yet, got %d lookups", validator.lookups)
+	}
+
+	clk.Advance(iamRetryInterval)
+	validator.err = nil
+	validator.createdAt = clk.Now().Add(-48 * time.Hour)
+	if err := tracker.lookup(context.Background(), "iam", validator, valid, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if age, ok := tracker.age("iam"); !ok || age.Age != 48*time.Hour {
+		t.Fatalf("expected a 48h old key, got %+v", age)
+	}
+
+	_ = tracker.lookup(context.Background(), "iam", validator, valid, time.Second)
+	if validator.lookups != 2 {
+		t.Fatalf("expected the creation date to be cached, got %d lookups", validator.lookups)
+	}
+}
+
+func TestValidatorManagerPublishesKeyAge(t *testing.T) {
+	metrics.KeyAge.Reset()
+	metrics.KeyRotationDue.Reset()
+
+	created := time.Now().Add(-72 * time.Hour)
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		KeyMaxAge:         24 * time.Hour,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "declared", KeyCreatedAt: config.Timestamp(created)},
+			{Name: "iam", KeyAgeFromIAM: true, KeyMaxAge: config.Duration(30 * 24 * time.Hour)},
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	vm.validators["declared"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.validators["iam"] = &datedValidator{
+		stubValidator: stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}},
+		createdAt:     created,
+	}
+	vm.mu.Unlock()
+
+	results := vm.ValidateAll(context.Background())
+
+	if len(results.KeyAges) != 2 {
+		t.Fatalf("expected key ages for both endpoints, got %v", results.KeyAges)
+	}
+	if got := testutil.ToFloat64(metrics.KeyRotationDue.WithLabelValues("declared")); got != 1 {
+		t.Fatalf("expected rotation due under the default policy, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.KeyRotationDue.WithLabelValues("iam")); got != 0 {
+		t.Fatalf("expected the endpoint policy to override the default, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.KeyAge.WithLabelValues("iam")); got < (72 * time.Hour).Seconds() {
+		t.Fatalf("expected an age of at least 72h, got %vs", got)
+	}
+
+	vm.RemoveEndpoint("iam")
+	if _, ok := vm.keyAges.age("iam"); ok {
+		t.Fatalf("expected the removed endpoint to be forgotten")
+	}
+}
@@ -0,0 +1,320 @@
+package exporter
+
+import (
+	"sort"
+	"sync"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type endpointState struct {
+	valid     bool
+	errorType string
+}
+
+func (s endpointState) name() string {
+	if s.valid {
+		return "valid"
+	}
+	return "invalid"
+}
+
+// LogSink logs validation outcomes. It remembers the last state per endpoint and
+// provider so config.LogModeChanges can emit a single line per transition.
+type LogSink struct {
+	log  *logrus.Logger
+	mode config.LogMode
+
+	mu        sync.Mutex
+	endpoints map[string]endpointState
+	providers map[string]bool
+	checks    map[checkKey]bool
+	anomalous map[string]bool
+	secondary map[string]bool // last validity of secondary credentials
+	drifted   map[string]bool // endpoints whose permissions currently drift
+}
+
+type checkKey struct {
+	endpoint string
+	check    string
+}
+
+// NewLogSink creates a sink that logs results in the given mode
+func NewLogSink(log *logrus.Logger, mode config.LogMode) *LogSink {
+	if mode == "" {
+		mode = config.LogModeAll
+	}
+	return &LogSink{
+		log:       log,
+		mode:      mode,
+		endpoints: make(map[string]endpointState),
+		providers: make(map[string]bool),
+		checks:    make(map[checkKey]bool),
+		anomalous: make(map[string]bool),
+		secondary: make(map[string]bool),
+		drifted:   make(map[string]bool),
+	}
+}
+
+// Consume logs provider outages and every endpoint result in the batch
+func (s *LogSink) Consume(results *ValidationResults) {
+	if results.Deep() {
+		s.logDeep(results)
+		return
+	}
+
+	unreachable := results.UnreachableProviders()
+	rolledUp := results.RolledUpEndpoints(unreachable)
+
+	for provider, endpoints := range results.Providers {
+		s.logProvider(provider, unreachable[provider], endpoints)
+	}
+
+	for name, result := range results.Results {
+		if result != nil {
+			s.logResult(name, result, rolledUp[name])
+			s.logSecondary(name, result.Secondary)
+			s.logChecks(name, result.Checks)
+		}
+	}
+
+	for name, anomaly := range results.Anomalies {
+		s.logAnomaly(name, anomaly)
+	}
+
+	for name, drift := range results.PermissionDrift {
+		s.logDrift(name, drift)
+	}
+}
+
+// logDeep logs deep probe outcomes in config.LogModeAll. They leave the remembered states
+// alone, so changes mode keeps describing the key rather than the write path.
+func (s *LogSink) logDeep(results *ValidationResults) {
+	if s.log == nil || s.mode == config.LogModeChanges {
+		return
+	}
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		entry := s.log.WithFields(logrus.Fields{
+			"endpoint": name,
+			"depth":    s3.ProbeDepthDeep,
+		})
+		if result.IsValid {
+			entry.WithField("response_time", result.ResponseTimeMs).Info("S3 deep probe successful")
+		} else {
+			entry.WithFields(logrus.Fields{
+				"message": result.Message,
+				"error":   failureType(result),
+			}).Warn("S3 deep probe failed")
+		}
+	}
+}
+
+// logDrift logs when an endpoint's permissions start contradicting expected_permissions
+// and when they match again
+func (s *LogSink) logDrift(endpointName string, drift PermissionDrift) {
+	drifted := drift.Drifted()
+	s.mu.Lock()
+	previous := s.drifted[endpointName]
+	s.drifted[endpointName] = drifted
+	s.mu.Unlock()
+
+	if s.log == nil || previous == drifted {
+		return
+	}
+
+	entry := s.log.WithFields(logrus.Fields{
+		"endpoint": endpointName,
+		"message":  drift.Describe(),
+	})
+	if drifted {
+		entry.Warn("S3 permissions drifted from expectations")
+	} else {
+		entry.Info("S3 permissions match expectations again")
+	}
+}
+
+// logAnomaly logs when an endpoint's latency becomes anomalous and when it returns to normal
+func (s *LogSink) logAnomaly(endpointName string, anomaly LatencyAnomaly) {
+	s.mu.Lock()
+	previous := s.anomalous[endpointName]
+	s.anomalous[endpointName] = anomaly.Anomalous
+	s.mu.Unlock()
+
+	if s.log == nil || previous == anomaly.Anomalous {
+		return
+	}
+
+	entry := s.log.WithFields(logrus.Fields{
+		"endpoint":    endpointName,
+		"latency_ms":  anomaly.LatencyMs,
+		"baseline_ms": anomaly.BaselineMs,
+		"message":     anomaly.Describe(),
+	})
+	if anomaly.Anomalous {
+		entry.Warn("S3 validation latency anomaly")
+	} else {
+		entry.Info("S3 validation latency back to normal")
+	}
+}
+
+// logResult logs one validation outcome. Rolled-up failures are reported at provider
+// level, so they neither count as a transition nor update the remembered state.
+func (s *LogSink) logResult(endpointName string, result *s3.ValidationResult, rolledUp bool) {
+	current := endpointState{valid: result.IsValid}
+	if !result.IsValid {
+		current.errorType = failureType(result)
+	}
+
+	s.mu.Lock()
+	previous, seen := s.endpoints[endpointName]
+	changed := false
+	if !rolledUp {
+		changed = !seen || previous != current
+		s.endpoints[endpointName] = current
+	}
+	s.mu.Unlock()
+
+	if s.log == nil {
+		return
+	}
+
+	if s.mode == config.LogModeChanges {
+		if !changed {
+			return
+		}
+		previousState := "unknown"
+		if seen {
+			previousState = previous.name()
+		}
+		entry := s.log.WithFields(logrus.Fields{
+			"endpoint":       endpointName,
+			"previous_state": previousState,
+			"previous_error": previous.errorType,
+			"state":          current.name(),
+			"error":          current.errorType,
+			"message":        result.Message,
+		})
+		if current.valid {
+			entry.Info("S3 endpoint state changed")
+		} else {
+			entry.Warn("S3 endpoint state changed")
+		}
+		return
+	}
+
+	switch {
+	case result.IsValid:
+		s.log.WithFields(logrus.Fields{
+			"endpoint":      endpointName,
+			"response_time": result.ResponseTimeMs,
+		}).Info("S3 key validation successful")
+	case rolledUp:
+		s.log.WithFields(logrus.Fields{
+			"endpoint": endpointName,
+			"message":  result.Message,
+			"error":    current.errorType,
+		}).Debug("S3 key validation failed (provider unreachable)")
+	default:
+		s.log.WithFields(logrus.Fields{
+			"endpoint": endpointName,
+			"message":  result.Message,
+			"error":    current.errorType,
+		}).Warn("S3 key validation failed")
+	}
+}
+
+// logSecondary logs the outcome for the secondary credentials; in changes mode only
+// validity flips are logged
+func (s *LogSink) logSecondary(endpointName string, result *s3.ValidationResult) {
+	if result == nil {
+		return
+	}
+	s.mu.Lock()
+	previous, seen := s.secondary[endpointName]
+	s.secondary[endpointName] = result.IsValid
+	s.mu.Unlock()
+
+	if s.log == nil || (s.mode == config.LogModeChanges && seen && previous == result.IsValid) {
+		return
+	}
+
+	entry := s.log.WithFields(logrus.Fields{
+		"endpoint": endpointName,
+		"slot":     SlotSecondary,
+		"message":  result.Message,
+	})
+	if result.IsValid {
+		entry.Info("S3 secondary credentials valid")
+	} else {
+		entry.WithField("error", failureType(result)).Warn("S3 secondary credentials invalid")
+	}
+}
+
+// logChecks logs bucket check verdicts; in changes mode only verdict flips are logged
+func (s *LogSink) logChecks(endpointName string, checks []s3.CheckResult) {
+	for _, check := range checks {
+		key := checkKey{endpoint: endpointName, check: check.Name}
+		s.mu.Lock()
+		previous, seen := s.checks[key]
+		s.checks[key] = check.Passed
+		s.mu.Unlock()
+
+		if s.log == nil {
+			continue
+		}
+		if s.mode == config.LogModeChanges && seen && previous == check.Passed {
+			continue
+		}
+
+		entry := s.log.WithFields(logrus.Fields{
+			"endpoint": endpointName,
+			"check":    check.Name,
+			"message":  check.Message,
+		})
+		switch {
+		case check.Passed:
+			entry.Info("S3 bucket check passed")
+		case check.Pending:
+			entry.Info("S3 bucket check pending")
+		case check.Critical:
+			entry.WithField("critical", true).Error("S3 bucket check failed")
+		default:
+			entry.Warn("S3 bucket check failed")
+		}
+	}
+}
+
+// logProvider logs provider reachability; in changes mode only outages and recoveries are logged
+func (s *LogSink) logProvider(provider string, down bool, endpoints []string) {
+	s.mu.Lock()
+	wasDown := s.providers[provider]
+	s.providers[provider] = down
+	s.mu.Unlock()
+
+	if s.log == nil {
+		return
+	}
+
+	if s.mode == config.LogModeChanges && down == wasDown {
+		return
+	}
+	if !down {
+		if s.mode == config.LogModeChanges {
+			s.log.WithField("provider", provider).Info("S3 provider reachable again")
+		}
+		return
+	}
+
+	sorted := append([]string(nil), endpoints...)
+	sort.Strings(sorted)
+	s.log.WithFields(logrus.Fields{
+		"provider":  provider,
+		"endpoints": sorted,
+	}).Warn("S3 provider unreachable")
+}
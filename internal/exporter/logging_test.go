@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"testing"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogSinkChangesMode(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeChanges)
+
+	valid := &s3.ValidationResult{IsValid: true, Message: "ok"}
+	denied := &s3.ValidationResult{IsValid: false, Message: "denied", ErrorType: "access_denied"}
+	expired := &s3.ValidationResult{IsValid: false, Message: "expired", ErrorType: "token_expired"}
+
+	consumeOne(sink, "bucket", valid)
+	consumeOne(sink, "bucket", valid)
+	consumeOne(sink, "bucket", denied)
+	consumeOne(sink, "bucket", denied)
+	consumeOne(sink, "bucket", expired)
+
+	entries := hook.AllEntries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 transition lines, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Data["previous_state"] != "unknown" || first.Data["state"] != "valid" {
+		t.Fatalf("unexpected first transition: %v", first.Data)
+	}
+
+	second := entries[1]
+	if second.Level != logrus.WarnLevel || second.Data["previous_state"] != "valid" || second.Data["error"] != "access_denied" {
+		t.Fatalf("unexpected valid->invalid transition: %v", second.Data)
+	}
+
+	third := entries[2]
+	if third.Data["previous_error"] != "access_denied" || third.Data["error"] != "token_expired" {
+		t.Fatalf("expected error type change to be logged, got %v", third.Data)
+	}
+}
+
+func TestLogSinkAllMode(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeAll)
+
+	valid := &s3.ValidationResult{IsValid: true, Message: "ok"}
+	consumeOne(sink, "bucket", valid)
+	consumeOne(sink, "bucket", valid)
+
+	if len(hook.AllEntries()) != 2 {
+		t.Fatalf("expected a log line per validation, got %d", len(hook.AllEntries()))
+	}
+}
+
+func TestLogSinkProviderTransitions(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeChanges)
+
+	sink.logProvider("minio", false, []string{"a"})
+	sink.logProvider("minio", true, []string{"a"})
+	sink.logProvider("minio", true, []string{"a"})
+	sink.logProvider("minio", false, []string{"a"})
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected outage and recovery lines only, got %d", len(entries))
+	}
+	if entries[0].Message != "S3 provider unreachable" || entries[1].Message != "S3 provider reachable again" {
+		t.Fatalf("unexpected provider log lines: %q, %q", entries[0].Message, entries[1].Message)
+	}
+}
+
+func TestLogSinkCheckTransitions(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeChanges)
+
+	failed := []s3.CheckResult{{Name: s3.CheckAccessLog, Message: "missing"}}
+	passed := []s3.CheckResult{{Name: s3.CheckAccessLog, Passed: true, Message: "delivered"}}
+
+	sink.logChecks("bucket", failed)
+	sink.logChecks("bucket", failed)
+	sink.logChecks("bucket", passed)
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected a line per verdict change, got %d", len(entries))
+	}
+	if entries[0].Level != logrus.WarnLevel || entries[0].Message != "S3 bucket check failed" {
+		t.Fatalf("unexpected failed check line: %v %q", entries[0].Level, entries[0].Message)
+	}
+	if entries[1].Data["check"] != s3.CheckAccessLog || entries[1].Message != "S3 bucket check passed" {
+		t.Fatalf("unexpected passed check line: %v", entries[1].Data)
+	}
+}
+
+func TestLogSinkCriticalCheckLogsError(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeAll)
+
+	sink.logChecks("bucket", []s3.CheckResult{{Name: s3.CheckPublicAccess, Critical: true, Message: "bucket policy is public"}})
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Level != logrus.ErrorLevel || entry.Data["critical"] != true {
+		t.Fatalf("expected critical check failure at error level, got %+v", entry)
+	}
+}
+
+func TestLogSinkChangesModeIgnoresDeepBatches(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeChanges)
+
+	consumeOne(sink, "bucket", &s3.ValidationResult{IsValid: true})
+	sink.Consume(&ValidationResults{
+		Depth:   s3.ProbeDepthDeep,
+		Results: map[string]*s3.ValidationResult{"bucket": {ErrorType: "access_denied"}},
+	})
+	consumeOne(sink, "bucket", &s3.ValidationResult{IsValid: true})
+
+	if len(hook.AllEntries()) != 1 {
+		t.Fatalf("expected only the first transition to be logged, got %d lines", len(hook.AllEntries()))
+	}
+}
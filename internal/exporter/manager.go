@@ -3,105 +3,585 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
 	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/version"
+	"key-aws-exporter/pkg/clock"
 	"key-aws-exporter/pkg/metrics"
 	"key-aws-exporter/pkg/s3"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/sirupsen/logrus"
 )
 
-// ValidatorManager manages multiple S3 validators
+// bucketValidator is the subset of the S3 validator used by the manager
 type bucketValidator interface {
 	ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult
+	ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult
+}
+
+// endpointMeta holds the per-endpoint settings the manager needs besides the validator
+type endpointMeta struct {
+	depth    s3.ProbeDepth
+	provider string // host group used for summaries: the declared provider or the endpoint host
+	declared bool   // provider was set explicitly, enabling failure roll-up
+
+	expectedPermissions map[string]string // operation to allowed or denied, from expected_permissions
+	annotations         map[string]string // owner, runbook_url and other notes for responders
+	writesCanaries      bool              // deep probes or checks write canary objects to the bucket
+	accountID           string            // AWS account owning the bucket, when known
+
+	// read by result rules and EndpointMetadata
+	bucket   string
+	region   string
+	severity string
+	labels   map[string]string
 }
 
 // ValidatorManager manages multiple S3 validators
 type ValidatorManager struct {
-	validators map[string]bucketValidator
-	mu         sync.RWMutex
-	log        *logrus.Logger
-	timeout    time.Duration
+	validators  map[string]bucketValidator
+	meta        map[string]endpointMeta
+	lastValid   map[string]bool // latest known key validity; absent until first checked
+	sinks       []ResultSink
+	history     *HistorySink
+	store       HistoryStore // nil unless a persistent history store is configured
+	cycles      *cycleTracker
+	anomalies   *latencyDetector // nil when latency anomaly detection is disabled
+	keyAges     *keyAgeTracker
+	outages     *outageTracker
+	overruns    *overrunTracker
+	pairs       *pairTracker // nil unless endpoint pairs are configured
+	costs       *probeCostEstimator
+	injections  *failureInjector
+	rewrites    []func(config.S3EndpointConfig) config.S3EndpointConfig
+	warmUp      *warmUp // nil unless the first scheduled runs are ramped up
+	resultRules []resultRule
+	clients     *s3.ClientPool // shared by endpoints with identical credentials and transport
+	submitted   aws.HTTPClient // shared by validations of submitted credentials
+	keyMaxAge   time.Duration  // rotation policy for endpoints without key_max_age
+	readOnly    bool           // fail probes and checks that write
+	replicaID   string         // names this exporter in canary keys
+	canaryTTL   time.Duration  // age after which canaries count as leaked
+	clock       clock.Clock
+	metrics     *metrics.Metrics
+	mu          sync.RWMutex
+	log         *logrus.Logger
+	timeout     time.Duration
 }
 
 // ValidationResults contains results for all endpoints
 type ValidationResults struct {
 	Timestamp time.Time
+	// Depth is the probe depth of a scheduled run, empty for on-demand runs. Deep
+	// batches judge the write path rather than the key, so they leave validity state alone.
+	Depth     s3.ProbeDepth
 	Results   map[string]*s3.ValidationResult // key: endpoint name
+	Providers map[string][]string             // key: declared provider, value: endpoints probed in this run
+	Anomalies map[string]LatencyAnomaly       // key: endpoint name; only endpoints with a baseline
+	KeyAges   map[string]KeyAge               // key: endpoint name; only endpoints with a known key creation date
+	// Recoveries holds the outages ended by this batch; key: endpoint name
+	Recoveries map[string]Recovery
+	// PermissionDrift is only set by AssertPermissions; key: endpoint name
+	PermissionDrift map[string]PermissionDrift
+	// ProbeCosts is the projected monthly probe request cost in USD; key: endpoint name,
+	// only endpoints with pricing observed over at least two validations
+	ProbeCosts map[string]float64
+	// Comparisons holds the endpoint pairs compared by this batch; key: pair name
+	Comparisons map[string]PairComparison
+}
+
+// ManagerOption customizes optional validator manager settings
+type ManagerOption func(*ValidatorManager)
+
+// WithClock sets the clock used for result timestamps, check intervals and key ages
+func WithClock(c clock.Clock) ManagerOption {
+	return func(vm *ValidatorManager) {
+		vm.clock = c
+	}
+}
+
+// WithMetrics records into m instead of the collectors registered on the Prometheus
+// default registry
+func WithMetrics(m *metrics.Metrics) ManagerOption {
+	return func(vm *ValidatorManager) {
+		vm.metrics = m
+	}
+}
+
+// WithEndpointRewrite transforms every endpoint before its validator is built, e.g. to
+// point it at a fake S3. Rewrites run in the order they are given.
+func WithEndpointRewrite(rewrite func(config.S3EndpointConfig) config.S3EndpointConfig) ManagerOption {
+	return func(vm *ValidatorManager) {
+		vm.rewrites = append(vm.rewrites, rewrite)
+	}
 }
 
 // NewValidatorManager creates a new validator manager
-func NewValidatorManager(cfg *config.Config, log *logrus.Logger) *ValidatorManager {
+func NewValidatorManager(cfg *config.Config, log *logrus.Logger, opts ...ManagerOption) *ValidatorManager {
+	history := NewHistorySink(cfg.HistorySize)
 	vm := &ValidatorManager{
-		validators: make(map[string]bucketValidator),
-		log:        log,
-		timeout:    cfg.ValidationTimeout,
+		validators:  make(map[string]bucketValidator),
+		meta:        make(map[string]endpointMeta),
+		lastValid:   make(map[string]bool),
+		history:     history,
+		cycles:      newCycleTracker(cfg.CycleHistorySize),
+		keyMaxAge:   cfg.KeyMaxAge,
+		readOnly:    cfg.ReadOnly,
+		replicaID:   cfg.ReplicaID,
+		canaryTTL:   cfg.CanaryTTL,
+		clock:       clock.Real,
+		metrics:     metrics.Default,
+		log:         log,
+		timeout:     cfg.ValidationTimeout,
+		resultRules: compileResultRules(cfg.ResultRules, log),
+		sinks: []ResultSink{
+			NewLogSink(log, cfg.LogMode),
+			history,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(vm)
+	}
+	vm.sinks = append([]ResultSink{NewMetricsSink(vm.metrics)}, vm.sinks...)
+	vm.clients = s3.NewClientPool(
+		s3.WithIdleTimeout(cfg.ClientIdleTimeout),
+		s3.WithMaxLifetime(cfg.ClientMaxLifetime),
+		s3.WithPoolClock(vm.clock),
+	)
+	vm.submitted = awshttp.NewBuildableClient()
+	vm.keyAges = newKeyAgeTracker(vm.clock)
+	vm.outages = newOutageTracker()
+	vm.overruns = newOverrunTracker()
+	vm.injections = newFailureInjector(vm.clock, vm.metrics)
+
+	if cfg.LatencyAnomalyFactor > 0 {
+		vm.anomalies = newLatencyDetector(cfg.LatencyAnomalyFactor, cfg.LatencyAnomalyMinSamples)
+	}
+	if cfg.WarmUpInitialBatch > 0 {
+		vm.warmUp = &warmUp{
+			initial:  cfg.WarmUpInitialBatch,
+			factor:   cfg.WarmUpRampFactor,
+			interval: cfg.WarmUpBatchInterval,
+		}
+	}
+	if len(cfg.EndpointPairs) > 0 {
+		vm.pairs = newPairTracker(cfg.EndpointPairs)
+	}
+	if len(cfg.ProbePricing) > 0 {
+		vm.costs = newProbeCostEstimator(cfg.ProbePricing)
 	}
 
 	// Initialize validators for each endpoint
 	for _, endpointCfg := range cfg.Endpoints {
-		validator := s3.NewS3Validator(
-			endpointCfg.Endpoint,
-			endpointCfg.Region,
-			endpointCfg.Bucket,
-			endpointCfg.AccessKey,
-			endpointCfg.SecretKey,
-			endpointCfg.SessionToken,
-			endpointCfg.UsePathStyle,
-			endpointCfg.InsecureSkipVerify,
-		)
-		vm.validators[endpointCfg.Name] = validator
-		metrics.RegisterEndpoint(endpointCfg.Name)
-
-		log.WithFields(logrus.Fields{
-			"endpoint_name": endpointCfg.Name,
-			"bucket":        endpointCfg.Bucket,
-			"region":        endpointCfg.Region,
-		}).Debug("Registered S3 validator")
+		vm.AddEndpoint(endpointCfg)
 	}
 
 	return vm
 }
 
-// ValidateAll validates all endpoints and returns results
+// AddSink registers an additional consumer for every batch of validation results
+func (vm *ValidatorManager) AddSink(sink ResultSink) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.sinks = append(vm.sinks, sink)
+}
+
+// AddEndpoint registers a validator for the endpoint, replacing any existing one with the same name
+func (vm *ValidatorManager) AddEndpoint(endpointCfg config.S3EndpointConfig) {
+	for _, rewrite := range vm.rewrites {
+		endpointCfg = rewrite(endpointCfg)
+	}
+	opts := append(vm.endpointOptions(endpointCfg), s3.WithClientPool(vm.clients))
+	build := func(accessKey, secretKey, sessionToken string) bucketValidator {
+		if endpointCfg.Plugin != nil {
+			return newPluginValidator(endpointCfg, accessKey, secretKey, sessionToken, vm.readOnly, vm.clock)
+		}
+		return newValidator(endpointCfg, accessKey, secretKey, sessionToken, opts)
+	}
+
+	validator := build(endpointCfg.AccessKey, endpointCfg.SecretKey, endpointCfg.SessionToken)
+	if secondary := endpointCfg.Secondary; secondary != nil {
+		validator = &slottedValidator{
+			primary:   validator,
+			secondary: build(secondary.AccessKey, secondary.SecretKey, secondary.SessionToken),
+		}
+	}
+	if hedge := endpointCfg.Hedge; hedge != nil {
+		validator = &hedgedValidator{
+			inner:   validator,
+			name:    endpointCfg.Name,
+			delay:   vm.hedgeDelay(endpointCfg.Name, *hedge),
+			clock:   vm.clock,
+			metrics: vm.metrics,
+		}
+	}
+
+	depth := s3.ProbeDepth(endpointCfg.ProbeDepth)
+	if depth == "" {
+		depth = s3.ProbeDepthShallow
+	}
+	meta := endpointMeta{
+		depth:    depth,
+		provider: providerHost(endpointCfg),
+		declared: endpointCfg.Provider != "",
+
+		expectedPermissions: endpointCfg.ExpectedPermissions,
+		annotations:         endpointCfg.Annotations,
+		writesCanaries:      writesCanaries(endpointCfg, depth),
+		accountID:           endpointCfg.AccountID,
+
+		bucket:   endpointCfg.Bucket,
+		region:   endpointCfg.Region,
+		severity: endpointCfg.Severity,
+		labels:   endpointCfg.Labels,
+	}
+
+	vm.mu.Lock()
+	previous, replaced := vm.meta[endpointCfg.Name]
+	vm.validators[endpointCfg.Name] = validator
+	vm.meta[endpointCfg.Name] = meta
+	delete(vm.lastValid, endpointCfg.Name)
+	orphaned := replaced && previous.provider != meta.provider && !vm.providerInUseLocked(previous.provider)
+	vm.refreshKeyCountsLocked()
+	vm.mu.Unlock()
+
+	maxAge := time.Duration(endpointCfg.KeyMaxAge)
+	if maxAge == 0 {
+		maxAge = vm.keyMaxAge
+	}
+	vm.keyAges.set(endpointCfg.Name, time.Time(endpointCfg.KeyCreatedAt), endpointCfg.KeyAgeFromIAM, maxAge)
+
+	if vm.readOnly && depth == s3.ProbeDepthDeep {
+		vm.log.WithField("endpoint", endpointCfg.Name).Warn("Deep probes write to the bucket and will fail while READ_ONLY is set")
+	}
+
+	vm.metrics.RegisterEndpoint(endpointCfg.Name)
+	vm.metrics.SetEndpointInfo(endpointCfg.Name, endpointCfg.Annotations)
+	vm.metrics.SetEndpointAccount(endpointCfg.Name, endpointCfg.AccountID)
+	if meta.declared {
+		vm.metrics.SetProviderUnreachable(meta.provider, false)
+	}
+	if orphaned {
+		vm.metrics.UnregisterProvider(previous.provider)
+	}
+
+	vm.log.WithFields(logrus.Fields{
+		"endpoint_name": endpointCfg.Name,
+		"bucket":        endpointCfg.Bucket,
+		"region":        endpointCfg.Region,
+		"probe_depth":   depth,
+		"fallbacks":     endpointCfg.FallbackRegions,
+	}).Debug("Registered S3 validator")
+}
+
+// endpointOptions returns the validator options of an endpoint, apart from the shared
+// client pool
+func (vm *ValidatorManager) endpointOptions(endpointCfg config.S3EndpointConfig) []s3.Option {
+	opts := []s3.Option{
+		s3.WithUserAgent(userAgent(endpointCfg)),
+		s3.WithRequestHeaders(endpointCfg.RequestHeaders),
+		s3.WithIPFamily(s3.IPFamily(endpointCfg.IPFamily)),
+		s3.WithDNSServers(endpointCfg.DNSServers),
+		s3.WithResolve(endpointCfg.Resolve),
+		s3.WithClock(vm.clock),
+		s3.WithCanaryNaming(vm.replicaID, vm.canaryTTL),
+	}
+	if proxy := endpointCfg.SOCKS5Proxy; proxy != nil {
+		opts = append(opts, s3.WithSOCKS5Proxy(s3.SOCKS5Proxy{
+			Address:  proxy.Address,
+			Username: proxy.Username,
+			Password: proxy.Password,
+		}))
+	}
+	if endpointCfg.ClientCert != "" {
+		opts = append(opts, s3.WithClientCertificate(endpointCfg.ClientCert, endpointCfg.ClientKey))
+	}
+	if endpointCfg.IAMEndpoint != "" {
+		opts = append(opts, s3.WithIAMEndpoint(endpointCfg.IAMEndpoint))
+	}
+	if endpointCfg.RoleARN != "" {
+		opts = append(opts, s3.WithAssumeRole(endpointCfg.RoleARN, endpointCfg.ExternalID, endpointCfg.STSEndpoint))
+	}
+	if endpointCfg.UseAccelerate {
+		opts = append(opts, s3.WithAccelerate())
+	}
+	if endpointCfg.UseDualStack {
+		opts = append(opts, s3.WithDualStack())
+	}
+	if endpointCfg.UseFIPSEndpoint {
+		opts = append(opts, s3.WithFIPS())
+	}
+	if endpointCfg.RetryMode != "" || endpointCfg.MaxAttempts > 0 || endpointCfg.MaxBackoff > 0 {
+		opts = append(opts, s3.WithRetry(endpointCfg.RetryMode, endpointCfg.MaxAttempts, time.Duration(endpointCfg.MaxBackoff)))
+	}
+	// Validated on load, so an error can only leave the default ranges in place
+	if cidrs, err := config.ParsePrivateCIDRs(endpointCfg.PrivateCIDRs); err == nil && cidrs != nil {
+		opts = append(opts, s3.WithPrivateCIDRs(cidrs))
+	}
+	if endpointCfg.UseARNRegion {
+		opts = append(opts, s3.WithUseARNRegion())
+	}
+	if endpointCfg.ChecksumAlgorithm != "" {
+		opts = append(opts, s3.WithChecksumAlgorithm(endpointCfg.ChecksumAlgorithm))
+	}
+	if rt := endpointCfg.RoundTrip; rt != nil {
+		opts = append(opts, s3.WithRoundTripVerification(s3.RoundTrip{SSE: rt.SSE, KMSKeyID: rt.KMSKeyID, Metadata: rt.Metadata}))
+	}
+	if success := endpointCfg.Success; success != nil {
+		// LoadConfig rejects criteria that do not compile
+		if expect, err := success.Compile(); err != nil {
+			vm.log.WithError(err).WithField("endpoint", endpointCfg.Name).Error("Ignoring invalid success criterion")
+		} else {
+			opts = append(opts, s3.WithSuccessCriterion(s3.SuccessCriterion{Expect: expect, Prefix: success.Prefix, MaxKeys: success.MaxKeys}))
+		}
+	}
+	opts = append(opts, checkOptions(endpointCfg.Checks)...)
+	if vm.readOnly {
+		opts = append(opts, s3.WithReadOnly())
+	}
+	return opts
+}
+
+// newValidator builds the validator of an endpoint signing with the given credentials
+func newValidator(endpointCfg config.S3EndpointConfig, accessKey, secretKey, sessionToken string, opts []s3.Option) bucketValidator {
+	primary := s3.NewS3Validator(
+		endpointCfg.Endpoint,
+		endpointCfg.Region,
+		endpointCfg.Bucket,
+		accessKey,
+		secretKey,
+		sessionToken,
+		endpointCfg.UsePathStyle,
+		endpointCfg.InsecureSkipVerify,
+		opts...,
+	)
+	if len(endpointCfg.FallbackRegions) > 0 {
+		return s3.NewRegionFailoverValidator(primary, endpointCfg.FallbackRegions)
+	}
+	return primary
+}
+
+// RemoveEndpoint drops the validator for an endpoint and deletes its metric series.
+// It reports whether the endpoint was configured.
+func (vm *ValidatorManager) RemoveEndpoint(endpointName string) bool {
+	vm.mu.Lock()
+	_, exists := vm.validators[endpointName]
+	meta, hadMeta := vm.meta[endpointName]
+	delete(vm.validators, endpointName)
+	delete(vm.meta, endpointName)
+	delete(vm.lastValid, endpointName)
+	orphaned := hadMeta && !vm.providerInUseLocked(meta.provider)
+	vm.refreshKeyCountsLocked()
+	vm.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	vm.metrics.UnregisterEndpoint(endpointName)
+	vm.history.Forget(endpointName)
+	vm.keyAges.forget(endpointName)
+	vm.outages.forget(endpointName)
+	if vm.pairs != nil {
+		vm.pairs.forget(endpointName)
+	}
+	vm.injections.clear(endpointName)
+	if vm.anomalies != nil {
+		vm.anomalies.forget(endpointName)
+	}
+	if vm.costs != nil {
+		vm.costs.forget(endpointName)
+	}
+	if orphaned {
+		vm.metrics.UnregisterProvider(meta.provider)
+	}
+
+	vm.log.WithField("endpoint_name", endpointName).Debug("Removed S3 validator")
+	return true
+}
+
+// Deep reports whether the batch holds deep probe results
+func (r *ValidationResults) Deep() bool {
+	return r.Depth == s3.ProbeDepthDeep
+}
+
+// ResultFunc receives an endpoint's result as soon as its probe finishes, before the
+// batch is published to the sinks. Calls are serialized.
+type ResultFunc func(endpointName string, result *s3.ValidationResult)
+
+// ValidateAll runs the shallow credential check against all endpoints and returns results
 func (vm *ValidatorManager) ValidateAll(ctx context.Context) *ValidationResults {
-	results := &ValidationResults{
-		Timestamp: time.Now(),
-		Results:   make(map[string]*s3.ValidationResult),
+	return vm.ValidateAllStreaming(ctx, nil)
+}
+
+// ValidateAllStreaming is ValidateAll that also hands every result to fn as it completes
+func (vm *ValidatorManager) ValidateAllStreaming(ctx context.Context, fn ResultFunc) *ValidationResults {
+	return vm.validateEach(ctx, func(string) bool { return true }, func(v bucketValidator) *s3.ValidationResult {
+		return v.ValidateKeys(ctx, vm.probeTimeout(ctx))
+	}, fn, "")
+}
+
+// ValidateDeep runs the deep probe against endpoints configured with probe_depth "deep".
+// The first run is ramped up when warm-up is configured.
+func (vm *ValidatorManager) ValidateDeep(ctx context.Context) *ValidationResults {
+	include := func(name string) bool { return vm.meta[name].depth == s3.ProbeDepthDeep }
+	probe := func(v bucketValidator) *s3.ValidationResult {
+		return v.ValidateDeep(ctx, vm.probeTimeout(ctx))
+	}
+	if vm.warmUp.first(true) {
+		return vm.validateRamped(ctx, include, probe, s3.ProbeDepthDeep)
 	}
+	return vm.validateEach(ctx, include, probe, nil, s3.ProbeDepthDeep)
+}
 
-	// Create channel for results
-	resultsChan := make(chan struct {
-		name   string
-		result *s3.ValidationResult
-	}, len(vm.validators))
+// validateEach runs probe in parallel for every endpoint accepted by include.
+// include is called with the read lock held; onResult may be nil. scheduled is the depth
+// of a scheduled run, which skips endpoints whose probe from an earlier run is still in
+// flight, or empty for on-demand runs.
+func (vm *ValidatorManager) validateEach(ctx context.Context, include func(name string) bool, probe func(bucketValidator) *s3.ValidationResult, onResult ResultFunc, scheduled s3.ProbeDepth) *ValidationResults {
+	type job struct {
+		name      string
+		validator bucketValidator
+		result    *s3.ValidationResult
+	}
 
-	var wg sync.WaitGroup
+	results := &ValidationResults{
+		Timestamp: vm.clock.Now(),
+		Depth:     scheduled,
+		Providers: make(map[string][]string),
+	}
 
 	vm.mu.RLock()
+	jobs := make([]job, 0, len(vm.validators))
+	var skipped []string
 	for name, validator := range vm.validators {
-		wg.Add(1)
-		go func(endpointName string, v bucketValidator) {
-			defer wg.Done()
-			result := v.ValidateKeys(ctx, vm.timeout)
-			resultsChan <- struct {
-				name   string
-				result *s3.ValidationResult
-			}{endpointName, result}
-		}(name, validator)
+		if !include(name) {
+			continue
+		}
+		if scheduled != "" && !vm.startScheduled(name, scheduled) {
+			skipped = append(skipped, name)
+			continue
+		}
+		if meta := vm.meta[name]; meta.declared {
+			results.Providers[meta.provider] = append(results.Providers[meta.provider], name)
+		}
+		jobs = append(jobs, job{name: name, validator: validator})
 	}
 	vm.mu.RUnlock()
 
-	wg.Wait()
-	close(resultsChan)
+	// Each probe writes only its own slot. Once ctx ends the batch is collected without
+	// waiting for stragglers, which then discard their results.
+	var wg sync.WaitGroup
+	var collectMu sync.Mutex
+	collected := false
+	wg.Add(len(jobs))
+	for i := range jobs {
+		go func(j *job) {
+			defer wg.Done()
+			if scheduled != "" {
+				defer vm.overruns.finish(j.name, scheduled)
+			}
+			result, injected := vm.injections.result(j.name)
+			if !injected {
+				result = probe(j.validator)
+			}
+			vm.lookupKeyAge(ctx, j.name, j.validator, result)
+
+			collectMu.Lock()
+			defer collectMu.Unlock()
+			if collected || cutShort(ctx, result) {
+				return
+			}
+			j.result = result
+			if onResult != nil {
+				onResult(j.name, result)
+			}
+		}(&jobs[i])
+	}
 
-	for item := range resultsChan {
-		results.Results[item.name] = item.result
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
 	}
+	collectMu.Lock()
+	collected = true
+	collectMu.Unlock()
 
-	return results
+	results.Results = make(map[string]*s3.ValidationResult, len(jobs))
+	var unfinished []string
+	for _, j := range jobs {
+		if j.result == nil {
+			unfinished = append(unfinished, j.name)
+			continue
+		}
+		results.Results[j.name] = j.result
+	}
+
+	// Whatever completed is published even when the run was cut off, so metrics never
+	// go without an update for a whole interval
+	vm.publish(results)
+	if len(unfinished) == 0 && len(skipped) == 0 {
+		return results
+	}
+
+	// Unfinished and skipped endpoints only reach the caller, so a short request budget,
+	// a shutdown or an overrun cannot flip their metrics or trigger notifications
+	partial := *results
+	partial.Results = make(map[string]*s3.ValidationResult, len(jobs)+len(skipped))
+	for name, result := range results.Results {
+		partial.Results[name] = result
+	}
+	now := vm.clock.Now()
+	for _, name := range unfinished {
+		result := unfinishedResult(ctx, now)
+		partial.Results[name] = result
+		vm.metrics.RecordValidationUnfinished(name, result.ErrorType)
+		if onResult != nil {
+			onResult(name, result)
+		}
+	}
+	for _, name := range skipped {
+		result := skippedResult(now)
+		partial.Results[name] = result
+		if onResult != nil {
+			onResult(name, result)
+		}
+	}
+	if len(unfinished) > 0 {
+		vm.log.WithFields(logrus.Fields{
+			"endpoints": len(unfinished),
+			"reason":    ctx.Err(),
+		}).Warn("Validation run ended before all endpoints finished")
+	}
+	return &partial
+}
+
+// startScheduled marks the endpoint's probe of a scheduled run in flight, reporting
+// false and counting a skipped cycle while the probe of an earlier run still is
+func (vm *ValidatorManager) startScheduled(name string, depth s3.ProbeDepth) bool {
+	ok, skipped := vm.overruns.start(name, depth)
+	fields := logrus.Fields{"endpoint": name, "depth": depth, "runs": skipped}
+	switch {
+	case !ok:
+		vm.metrics.RecordCycleSkipped(name, string(depth))
+		if skipped == sustainedOverrun {
+			vm.log.WithFields(fields).Warn("Endpoint validations keep overrunning the interval; skipping runs until the probe returns")
+		}
+	case skipped >= sustainedOverrun:
+		vm.log.WithFields(fields).Info("Endpoint validations caught up after overrunning the interval")
+	}
+	return ok
 }
 
 // ValidateEndpoint validates a specific endpoint
@@ -111,15 +591,67 @@ func (vm *ValidatorManager) ValidateEndpoint(ctx context.Context, endpointName s
 	vm.mu.RUnlock()
 
 	if !exists {
-		return &s3.ValidationResult{
-			IsValid:   false,
-			Message:   fmt.Sprintf("endpoint '%s' not found", endpointName),
-			CheckedAt: time.Now(),
-			ErrorType: "endpoint_not_found",
-		}
+		return vm.endpointNotFound(endpointName)
 	}
 
-	return validator.ValidateKeys(ctx, vm.timeout)
+	result, injected := vm.injections.result(endpointName)
+	if !injected {
+		result = validator.ValidateKeys(ctx, vm.timeout)
+	}
+	vm.lookupKeyAge(ctx, endpointName, validator, result)
+	vm.publish(&ValidationResults{
+		Timestamp: result.CheckedAt,
+		Results:   map[string]*s3.ValidationResult{endpointName: result},
+	})
+	return result
+}
+
+// endpointNotFound is the result for a name without a configured endpoint
+func (vm *ValidatorManager) endpointNotFound(endpointName string) *s3.ValidationResult {
+	return &s3.ValidationResult{
+		IsValid:   false,
+		Message:   fmt.Sprintf("endpoint '%s' not found", endpointName),
+		CheckedAt: vm.clock.Now(),
+		ErrorType: "endpoint_not_found",
+	}
+}
+
+// Annotations returns the annotations configured for an endpoint
+func (vm *ValidatorManager) Annotations(endpointName string) map[string]string {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	return maps.Clone(vm.meta[endpointName].annotations)
+}
+
+// EndpointMetadata is the configuration of an endpoint that sinks copy into what they emit
+type EndpointMetadata struct {
+	Bucket      string
+	Severity    string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// EndpointMetadata returns the configuration of a configured or discovered endpoint
+func (vm *ValidatorManager) EndpointMetadata(endpointName string) (EndpointMetadata, bool) {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	meta, ok := vm.meta[endpointName]
+	if !ok {
+		return EndpointMetadata{}, false
+	}
+	return EndpointMetadata{
+		Bucket:      meta.bucket,
+		Severity:    meta.severity,
+		Labels:      maps.Clone(meta.labels),
+		Annotations: maps.Clone(meta.annotations),
+	}, true
+}
+
+// AccountID returns the AWS account configured or discovered for an endpoint, or ""
+func (vm *ValidatorManager) AccountID(endpointName string) string {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	return vm.meta[endpointName].accountID
 }
 
 // GetEndpoints returns list of configured endpoint names
@@ -141,37 +673,97 @@ func (vm *ValidatorManager) GetEndpointCount() int {
 	return len(vm.validators)
 }
 
-// RecordResult updates metrics and logs for a validation outcome
-func RecordResult(log *logrus.Logger, endpointName string, result *s3.ValidationResult) {
-	if result == nil {
-		return
+// History returns the recent results of an endpoint, oldest first
+func (vm *ValidatorManager) History(endpointName string) []HistoryEntry {
+	return vm.history.History(endpointName)
+}
+
+// HistoryEndpoints returns the endpoints with recorded history
+func (vm *ValidatorManager) HistoryEndpoints() []string {
+	return vm.history.Endpoints()
+}
+
+// Latency returns rolling response time percentiles for an endpoint
+func (vm *ValidatorManager) Latency(endpointName string) (LatencySummary, bool) {
+	return vm.history.Latency(endpointName)
+}
+
+// publish applies the result rules, updates the manager's own state and fans the
+// results out to every sink. Failures a rule suppressed stay in results but reach
+// neither the state nor the sinks. Deep batches leave key validity, outages and pair
+// comparisons alone.
+func (vm *ValidatorManager) publish(results *ValidationResults) {
+	batch := vm.applyResultRules(results)
+	if !batch.Deep() {
+		vm.trackResults(batch)
+		vm.trackOutages(batch)
+		vm.comparePairs(batch)
 	}
+	vm.detectAnomalies(batch)
+	vm.attachKeyAges(batch)
+	vm.estimateCosts(batch)
+
+	vm.mu.RLock()
+	sinks := append([]ResultSink(nil), vm.sinks...)
+	vm.mu.RUnlock()
 
-	metrics.RecordValidationAttempt(endpointName, result.IsValid)
-	metrics.SetLastValidationTime(endpointName, float64(result.CheckedAt.Unix()))
-	metrics.RecordResponseTime(endpointName, "ListObjectsV2", float64(result.ResponseTimeMs))
-	metrics.RecordValidationDuration(endpointName, result.Duration)
+	for _, sink := range sinks {
+		sink.Consume(batch)
+	}
+	if batch != results {
+		// Hand the caller what the pipeline attached, keeping the suppressed results
+		attached := *batch
+		attached.Results = results.Results
+		*results = attached
+	}
+}
 
-	if result.IsValid {
-		metrics.RecordValidationSuccess(endpointName)
-		if log != nil {
-			log.WithFields(logrus.Fields{
-				"endpoint":      endpointName,
-				"response_time": result.ResponseTimeMs,
-			}).Info("S3 key validation successful")
+// detectAnomalies attaches latency anomaly verdicts to the batch before sinks see it
+func (vm *ValidatorManager) detectAnomalies(results *ValidationResults) {
+	if vm.anomalies == nil {
+		return
+	}
+	for name, result := range results.Results {
+		anomaly, ok := vm.anomalies.observe(name, result)
+		if !ok {
+			continue
 		}
-	} else {
-		errorType := result.ErrorType
-		if errorType == "" {
-			errorType = "unknown"
+		if results.Anomalies == nil {
+			results.Anomalies = make(map[string]LatencyAnomaly)
 		}
-		metrics.RecordValidationFailure(endpointName, errorType)
-		if log != nil {
-			log.WithFields(logrus.Fields{
-				"endpoint": endpointName,
-				"message":  result.Message,
-				"error":    errorType,
-			}).Warn("S3 key validation failed")
+		results.Anomalies[name] = anomaly
+	}
+}
+
+// lookupKeyAge fetches the key creation date from IAM for endpoints configured to use it
+func (vm *ValidatorManager) lookupKeyAge(ctx context.Context, endpointName string, validator bucketValidator, result *s3.ValidationResult) {
+	if err := vm.keyAges.lookup(ctx, endpointName, validator, result, vm.timeout); err != nil {
+		vm.log.WithFields(logrus.Fields{
+			"endpoint": endpointName,
+			"error":    err,
+		}).Warn("Failed to look up access key creation date in IAM")
+	}
+}
+
+// attachKeyAges adds the current key age of every endpoint in the batch
+func (vm *ValidatorManager) attachKeyAges(results *ValidationResults) {
+	for name := range results.Results {
+		age, ok := vm.keyAges.age(name)
+		if !ok {
+			continue
+		}
+		if results.KeyAges == nil {
+			results.KeyAges = make(map[string]KeyAge)
 		}
+		results.KeyAges[name] = age
+	}
+}
+
+// userAgent returns the configured User-Agent prefix or one naming the exporter,
+// its version and the endpoint
+func userAgent(endpointCfg config.S3EndpointConfig) string {
+	if endpointCfg.UserAgent != "" {
+		return endpointCfg.UserAgent
 	}
+	return fmt.Sprintf("%s/%s endpoint/%s", version.Name, version.Version, endpointCfg.Name)
 }
@@ -2,12 +2,17 @@ package exporter
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
 	"key-aws-exporter/pkg/s3"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,6 +24,10 @@ func (s *stubValidator) ValidateKeys(ctx context.Context, timeout time.Duration)
 	return s.result
 }
 
+func (s *stubValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return s.result
+}
+
 func TestValidatorManagerValidateAll(t *testing.T) {
 	cfg := &config.Config{
 		ValidationTimeout: time.Second,
@@ -48,6 +57,28 @@ func TestValidatorManagerValidateAll(t *testing.T) {
 	}
 }
 
+func TestValidatorManagerValidateAllStreaming(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "one"}, {Name: "two"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	vm.validators["one"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.validators["two"] = &stubValidator{result: &s3.ValidationResult{IsValid: false, CheckedAt: time.Now()}}
+	vm.mu.Unlock()
+
+	streamed := make(map[string]*s3.ValidationResult)
+	results := vm.ValidateAllStreaming(context.Background(), func(name string, result *s3.ValidationResult) {
+		streamed[name] = result
+	})
+
+	if len(streamed) != 2 || streamed["one"] != results.Results["one"] || streamed["two"] != results.Results["two"] {
+		t.Fatalf("expected every result to be streamed, got %v", streamed)
+	}
+}
+
 func TestValidatorManagerValidateEndpoint(t *testing.T) {
 	cfg := &config.Config{ValidationTimeout: time.Second}
 	vm := NewValidatorManager(cfg, logrus.New())
@@ -88,3 +119,152 @@ func TestValidatorManagerGetters(t *testing.T) {
 		t.Fatalf("expected endpoint count 2")
 	}
 }
+
+func TestValidatorManagerRemoveEndpoint(t *testing.T) {
+	cfg := &config.Config{
+		Endpoints: []config.S3EndpointConfig{{Name: "keep"}, {Name: "drop"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	before := testutil.CollectAndCount(metrics.EndpointConfigured)
+
+	if !vm.RemoveEndpoint("drop") {
+		t.Fatalf("expected RemoveEndpoint to report existing endpoint")
+	}
+	if vm.RemoveEndpoint("drop") {
+		t.Fatalf("expected second RemoveEndpoint to report missing endpoint")
+	}
+	if vm.GetEndpointCount() != 1 {
+		t.Fatalf("expected 1 endpoint after removal, got %d", vm.GetEndpointCount())
+	}
+
+	if after := testutil.CollectAndCount(metrics.EndpointConfigured); after != before-1 {
+		t.Fatalf("expected removed endpoint series to be deleted, had %d now %d", before, after)
+	}
+}
+
+func TestValidatorManagerValidateDeepOnlyDeepEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "cheap", ProbeDepth: config.ProbeDepthShallow},
+			{Name: "thorough", ProbeDepth: config.ProbeDepthDeep},
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	vm.validators["cheap"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, Depth: s3.ProbeDepthShallow}}
+	vm.validators["thorough"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, Depth: s3.ProbeDepthDeep}}
+	vm.mu.Unlock()
+
+	results := vm.ValidateDeep(context.Background())
+	if len(results.Results) != 1 {
+		t.Fatalf("expected only the deep endpoint to be probed, got %d results", len(results.Results))
+	}
+	if _, ok := results.Results["thorough"]; !ok {
+		t.Fatalf("expected result for deep endpoint")
+	}
+
+	if all := vm.ValidateAll(context.Background()); len(all.Results) != 2 {
+		t.Fatalf("expected shallow validation for every endpoint, got %d", len(all.Results))
+	}
+}
+
+func TestValidatorManagerDeepFailureKeepsValidity(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "thorough", ProbeDepth: config.ProbeDepthDeep}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	stub := &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.mu.Lock()
+	vm.validators["thorough"] = stub
+	vm.mu.Unlock()
+
+	vm.ValidateAll(context.Background())
+	stub.result = &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", Depth: s3.ProbeDepthDeep, CheckedAt: time.Now()}
+	results := vm.ValidateDeep(context.Background())
+
+	if !results.Deep() {
+		t.Fatalf("expected the batch to be marked deep")
+	}
+	if len(results.Recoveries) != 0 || !vm.lastValid["thorough"] {
+		t.Fatalf("expected a failed deep probe to leave key validity alone, got %v", vm.lastValid)
+	}
+}
+
+func TestUserAgentDefaultsToExporterAndEndpoint(t *testing.T) {
+	got := userAgent(config.S3EndpointConfig{Name: "prod"})
+	if !strings.HasPrefix(got, "key-aws-exporter/") || !strings.HasSuffix(got, " endpoint/prod") {
+		t.Fatalf("unexpected default user agent: %s", got)
+	}
+
+	if got := userAgent(config.S3EndpointConfig{Name: "prod", UserAgent: "custom/1.0"}); got != "custom/1.0" {
+		t.Fatalf("expected configured user agent, got %s", got)
+	}
+}
+
+// newBenchmarkManager returns a manager with n stub endpoints that log nowhere
+func newBenchmarkManager(b *testing.B, n int, mode config.LogMode) *ValidatorManager {
+	b.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	vm := NewValidatorManager(&config.Config{ValidationTimeout: time.Second, LogMode: mode}, log)
+
+	vm.mu.Lock()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("endpoint-%04d", i)
+		vm.validators[name] = &stubValidator{result: &s3.ValidationResult{IsValid: i%10 != 0, Message: "ok", CheckedAt: time.Now()}}
+		vm.meta[name] = endpointMeta{depth: s3.ProbeDepthShallow, provider: "stub"}
+	}
+	vm.mu.Unlock()
+	b.Cleanup(func() {
+		for i := 0; i < n; i++ {
+			metrics.UnregisterEndpoint(fmt.Sprintf("endpoint-%04d", i))
+		}
+	})
+	return vm
+}
+
+func BenchmarkValidateAll(b *testing.B) {
+	// changes mode is the steady state of the pipeline itself; all mode adds a log line per endpoint
+	for _, mode := range []config.LogMode{config.LogModeChanges, config.LogModeAll} {
+		b.Run("log="+string(mode), func(b *testing.B) {
+			vm := newBenchmarkManager(b, 1000, mode)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vm.ValidateAll(ctx)
+			}
+		})
+	}
+}
+
+func BenchmarkValidateDeepSkipsShallowEndpoints(b *testing.B) {
+	vm := newBenchmarkManager(b, 1000, config.LogModeChanges)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm.ValidateDeep(ctx)
+	}
+}
+
+func TestValidatorManagerAnnotations(t *testing.T) {
+	annotations := map[string]string{"owner": "team-storage", "runbook_url": "https://runbooks.example.com/a"}
+	cfg := &config.Config{Endpoints: []config.S3EndpointConfig{{Name: "annotated-a", Annotations: annotations}, {Name: "annotated-b"}}}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	if got := vm.Annotations("annotated-a"); got["owner"] != "team-storage" {
+		t.Fatalf("expected the configured annotations, got %v", got)
+	}
+	if got := vm.Annotations("annotated-b"); len(got) != 0 {
+		t.Fatalf("expected no annotations, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.EndpointInfo.WithLabelValues("annotated-a", "team-storage", "https://runbooks.example.com/a", "")); got != 1 {
+		t.Fatalf("expected s3_endpoint_info for the annotated endpoint, got %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// Recovery describes an outage that ended with a successful validation
+type Recovery struct {
+	Since    time.Time // CheckedAt of the first failed validation
+	Duration time.Duration
+}
+
+// outageTracker remembers since when each failing endpoint has been failing
+type outageTracker struct {
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+func newOutageTracker() *outageTracker {
+	return &outageTracker{since: make(map[string]time.Time)}
+}
+
+// observe starts an outage on the first failure and ends it on the next success,
+// reporting the outage that success ended
+func (t *outageTracker) observe(endpointName string, result *s3.ValidationResult) (Recovery, bool) {
+	if result == nil {
+		return Recovery{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	since, failing := t.since[endpointName]
+	switch {
+	case !result.IsValid && !failing:
+		t.since[endpointName] = result.CheckedAt
+	case result.IsValid && failing:
+		delete(t.since, endpointName)
+		return Recovery{Since: since, Duration: max(result.CheckedAt.Sub(since), 0)}, true
+	}
+	return Recovery{}, false
+}
+
+func (t *outageTracker) forget(endpointName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.since, endpointName)
+}
+
+// trackOutages attaches the outages ended by this batch before sinks see it
+func (vm *ValidatorManager) trackOutages(results *ValidationResults) {
+	for name, result := range results.Results {
+		recovery, ok := vm.outages.observe(name, result)
+		if !ok {
+			continue
+		}
+		if results.Recoveries == nil {
+			results.Recoveries = make(map[string]Recovery)
+		}
+		results.Recoveries[name] = recovery
+	}
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestOutageTrackerMeasuresFromFirstFailure(t *testing.T) {
+	tracker := newOutageTracker()
+	start := time.Unix(1700000000, 0)
+
+	steps := []struct {
+		valid bool
+		at    time.Duration
+	}{{true, 0}, {false, time.Minute}, {false, 2 * time.Minute}, {true, 10 * time.Minute}, {true, 11 * time.Minute}}
+
+	var recoveries []Recovery
+	for _, step := range steps {
+		result := &s3.ValidationResult{IsValid: step.valid, CheckedAt: start.Add(step.at)}
+		if recovery, ok := tracker.observe("bucket", result); ok {
+			recoveries = append(recoveries, recovery)
+		}
+	}
+
+	if len(recoveries) != 1 {
+		t.Fatalf("expected exactly one recovery, got %+v", recoveries)
+	}
+	if recoveries[0].Duration != 9*time.Minute || !recoveries[0].Since.Equal(start.Add(time.Minute)) {
+		t.Fatalf("expected a 9m outage starting at the first failure, got %+v", recoveries[0])
+	}
+}
+
+func TestValidateEndpointRecordsRecovery(t *testing.T) {
+	cfg := &config.Config{ValidationTimeout: time.Second, Endpoints: []config.S3EndpointConfig{{Name: "flaky"}}}
+	vm := NewValidatorManager(cfg, logrus.New())
+	stub := &stubValidator{}
+	vm.mu.Lock()
+	vm.validators["flaky"] = stub
+	vm.mu.Unlock()
+	metrics.Outages.Reset()
+	metrics.OutageDuration.Reset()
+
+	start := time.Unix(1700000000, 0)
+	stub.result = &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", CheckedAt: start}
+	vm.ValidateEndpoint(context.Background(), "flaky")
+	stub.result = &s3.ValidationResult{IsValid: true, CheckedAt: start.Add(5 * time.Minute)}
+	vm.ValidateEndpoint(context.Background(), "flaky")
+
+	if got := testutil.ToFloat64(metrics.Outages.WithLabelValues("flaky")); got != 1 {
+		t.Fatalf("expected one ended outage, got %v", got)
+	}
+	if count := testutil.CollectAndCount(metrics.OutageDuration); count != 1 {
+		t.Fatalf("expected the outage duration to be observed, got %d series", count)
+	}
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"sync"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// sustainedOverrun is how many scheduled runs in a row may skip an endpoint before the
+// overrun is logged as a warning
+const sustainedOverrun = 3
+
+// overrunKey is an endpoint and the depth of the scheduled runs probing it
+type overrunKey struct {
+	endpoint string
+	depth    s3.ProbeDepth
+}
+
+// overrunTracker keeps scheduled runs from overlapping per endpoint. A run is bounded by
+// its interval, but a probe it stopped waiting for keeps running until its own timeout;
+// the next run skips the endpoint instead of probing it a second time.
+type overrunTracker struct {
+	mu       sync.Mutex
+	inFlight map[overrunKey]bool
+	skipped  map[overrunKey]int // consecutive runs that skipped the endpoint
+}
+
+func newOverrunTracker() *overrunTracker {
+	return &overrunTracker{
+		inFlight: make(map[overrunKey]bool),
+		skipped:  make(map[overrunKey]int),
+	}
+}
+
+// start marks the endpoint's probe in flight. It reports false when the previous probe
+// is still running, along with how many runs in a row have now skipped the endpoint;
+// on success, skipped is the length of the streak that just ended.
+func (t *overrunTracker) start(endpoint string, depth s3.ProbeDepth) (ok bool, skipped int) {
+	key := overrunKey{endpoint, depth}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight[key] {
+		t.skipped[key]++
+		return false, t.skipped[key]
+	}
+	t.inFlight[key] = true
+	skipped = t.skipped[key]
+	delete(t.skipped, key)
+	return true, skipped
+}
+
+// finish marks the endpoint's probe returned
+func (t *overrunTracker) finish(endpoint string, depth s3.ProbeDepth) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inFlight, overrunKey{endpoint, depth})
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestOverrunTrackerCountsSkippedRuns(t *testing.T) {
+	tracker := newOverrunTracker()
+	if ok, _ := tracker.start("logs", s3.ProbeDepthShallow); !ok {
+		t.Fatal("expected the first probe to start")
+	}
+	if ok, _ := tracker.start("logs", s3.ProbeDepthDeep); !ok {
+		t.Fatal("expected a deep probe to start while a shallow one runs")
+	}
+	for want := 1; want <= 2; want++ {
+		if ok, skipped := tracker.start("logs", s3.ProbeDepthShallow); ok || skipped != want {
+			t.Fatalf("expected skip %d, got ok=%v skipped=%d", want, ok, skipped)
+		}
+	}
+	tracker.finish("logs", s3.ProbeDepthShallow)
+	if ok, skipped := tracker.start("logs", s3.ProbeDepthShallow); !ok || skipped != 2 {
+		t.Fatalf("expected the probe to start after a streak of 2, got ok=%v skipped=%d", ok, skipped)
+	}
+}
+
+func TestValidateCycleSkipsEndpointsStillInFlight(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Minute,
+		CycleHistorySize:  5,
+		Endpoints:         []config.S3EndpointConfig{{Name: "fast"}, {Name: "stuck"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	stuck := &hangingValidator{release: make(chan struct{}), timeouts: make(chan time.Duration, 3)}
+	vm.mu.Lock()
+	vm.validators["fast"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.validators["stuck"] = stuck
+	vm.mu.Unlock()
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+	metrics.CyclesSkipped.Reset()
+
+	runCycle := func() *ValidationResults {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		return vm.ValidateCycle(ctx)
+	}
+
+	if result := runCycle().Results["stuck"]; result.ErrorType != ErrorTypeTimedOut {
+		t.Fatalf("expected the first run to time out on the stuck endpoint, got %+v", result)
+	}
+	results := runCycle()
+	if result := results.Results["stuck"]; result.ErrorType != ErrorTypeSkipped {
+		t.Fatalf("expected the second run to skip the endpoint still in flight, got %+v", result)
+	}
+	if !results.Results["fast"].IsValid {
+		t.Fatalf("expected the other endpoint to be validated, got %+v", results.Results["fast"])
+	}
+	if len(stuck.timeouts) != 1 {
+		t.Fatalf("expected the stuck endpoint to be probed once, got %d probes", len(stuck.timeouts))
+	}
+	if got := testutil.ToFloat64(metrics.CyclesSkipped.WithLabelValues("stuck", "shallow")); got != 1 {
+		t.Fatalf("expected one skipped cycle, got %v", got)
+	}
+	if batch := sink.batches[1]; batch.Results["stuck"] != nil {
+		t.Fatalf("expected the skipped endpoint not to be published, got %+v", batch.Results)
+	}
+	if cycle, _ := vm.LatestCycle(); len(cycle.Unfinished) != 1 || cycle.Unfinished[0] != "stuck" {
+		t.Fatalf("expected the skipped endpoint to be listed as unfinished, got %+v", cycle)
+	}
+
+	// On-demand runs are not held back by scheduled probes
+	onDemand := make(chan struct{})
+	go func() {
+		defer close(onDemand)
+		vm.ValidateAll(context.Background())
+	}()
+	<-stuck.timeouts
+
+	close(stuck.release)
+	<-onDemand
+	for deadline := time.Now().Add(time.Second); ; {
+		if ok, _ := vm.overruns.start("stuck", s3.ProbeDepthShallow); ok {
+			vm.overruns.finish("stuck", s3.ProbeDepthShallow)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the stuck probe to finish after its release")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if result := runCycle().Results["stuck"]; !result.IsValid {
+		t.Fatalf("expected the endpoint to be probed again once its probe returned, got %+v", result)
+	}
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// Endpoint pair states reported in s3_endpoint_pair_state
+const (
+	PairBothOK     = "both_ok"
+	PairOnlyOldOK  = "only_old_ok"
+	PairOnlyNewOK  = "only_new_ok"
+	PairBothFailed = "both_failed"
+)
+
+// PairComparison is the outcome of the latest validations of both endpoints of a pair
+type PairComparison struct {
+	Old   string // endpoint name
+	New   string // endpoint name
+	State string
+	// LatencyDelta is the new endpoint's validation duration minus the old one's; only
+	// set when both succeeded
+	LatencyDelta time.Duration
+}
+
+// pairTracker remembers the latest result of every endpoint that belongs to a pair, so a
+// pair is compared even when its endpoints were validated in different batches
+type pairTracker struct {
+	pairs []config.EndpointPair
+
+	mu     sync.Mutex
+	latest map[string]*s3.ValidationResult
+}
+
+func newPairTracker(pairs []config.EndpointPair) *pairTracker {
+	return &pairTracker{
+		pairs:  pairs,
+		latest: make(map[string]*s3.ValidationResult),
+	}
+}
+
+// observe records the batch and compares every pair with an endpoint in it, once both
+// of its endpoints have a result
+func (t *pairTracker) observe(results map[string]*s3.ValidationResult) map[string]PairComparison {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var comparisons map[string]PairComparison
+	for _, pair := range t.pairs {
+		oldResult, oldInBatch := results[pair.Old]
+		newResult, newInBatch := results[pair.New]
+		if !oldInBatch && !newInBatch {
+			continue
+		}
+		if oldInBatch && oldResult != nil {
+			t.latest[pair.Old] = oldResult
+		}
+		if newInBatch && newResult != nil {
+			t.latest[pair.New] = newResult
+		}
+		oldResult, newResult = t.latest[pair.Old], t.latest[pair.New]
+		if oldResult == nil || newResult == nil {
+			continue
+		}
+		if comparisons == nil {
+			comparisons = make(map[string]PairComparison)
+		}
+		comparisons[pair.Name] = comparePair(pair, oldResult, newResult)
+	}
+	return comparisons
+}
+
+func (t *pairTracker) forget(endpointName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.latest, endpointName)
+}
+
+func comparePair(pair config.EndpointPair, oldResult, newResult *s3.ValidationResult) PairComparison {
+	comparison := PairComparison{Old: pair.Old, New: pair.New}
+	switch {
+	case oldResult.IsValid && newResult.IsValid:
+		comparison.State = PairBothOK
+		comparison.LatencyDelta = newResult.Duration - oldResult.Duration
+	case oldResult.IsValid:
+		comparison.State = PairOnlyOldOK
+	case newResult.IsValid:
+		comparison.State = PairOnlyNewOK
+	default:
+		comparison.State = PairBothFailed
+	}
+	return comparison
+}
+
+// comparePairs attaches the comparisons of pairs with an endpoint in this batch before
+// sinks see it
+func (vm *ValidatorManager) comparePairs(results *ValidationResults) {
+	if vm.pairs == nil {
+		return
+	}
+	if comparisons := vm.pairs.observe(results.Results); comparisons != nil {
+		results.Comparisons = comparisons
+	}
+}
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEndpointPairsCompareLatestResults(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "old-gw"}, {Name: "new-gw"}, {Name: "other"}},
+		EndpointPairs:     []config.EndpointPair{{Name: "migration", Old: "old-gw", New: "new-gw"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	oldGateway := &stubValidator{result: &s3.ValidationResult{IsValid: true, Duration: 120 * time.Millisecond, CheckedAt: time.Now()}}
+	newGateway := &stubValidator{result: &s3.ValidationResult{IsValid: true, Duration: 200 * time.Millisecond, CheckedAt: time.Now()}}
+	vm.mu.Lock()
+	vm.validators["old-gw"] = oldGateway
+	vm.validators["new-gw"] = newGateway
+	vm.validators["other"] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	vm.mu.Unlock()
+	metrics.EndpointPairState.Reset()
+	metrics.EndpointPairLatencyDelta.Reset()
+
+	results := vm.ValidateAll(context.Background())
+	comparison, ok := results.Comparisons["migration"]
+	if !ok || comparison.State != PairBothOK || comparison.LatencyDelta != 80*time.Millisecond {
+		t.Fatalf("expected both gateways ok with the new one 80ms slower, got %+v", results.Comparisons)
+	}
+	if got := testutil.ToFloat64(metrics.EndpointPairLatencyDelta.WithLabelValues("migration")); got != 0.08 {
+		t.Fatalf("expected a latency delta of 0.08s, got %v", got)
+	}
+
+	// A single endpoint's result is compared with the other side's latest one
+	newGateway.result = &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", CheckedAt: time.Now()}
+	vm.ValidateEndpoint(context.Background(), "new-gw")
+	if got := testutil.ToFloat64(metrics.EndpointPairState.WithLabelValues("migration", "old-gw", "new-gw", PairOnlyOldOK)); got != 1 {
+		t.Fatalf("expected the pair state to be only_old_ok, got %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.EndpointPairState); got != 1 {
+		t.Fatalf("expected a single current state series, got %d", got)
+	}
+	if got := testutil.ToFloat64(metrics.EndpointPairLatencyDelta.WithLabelValues("migration")); got != 0.08 {
+		t.Fatalf("expected the latency delta of the last successful comparison to stay, got %v", got)
+	}
+
+	vm.RemoveEndpoint("old-gw")
+	newGateway.result = &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}
+	vm.ValidateEndpoint(context.Background(), "new-gw")
+	if got := testutil.ToFloat64(metrics.EndpointPairState.WithLabelValues("migration", "old-gw", "new-gw", PairOnlyOldOK)); got != 1 {
+		t.Fatalf("expected no comparison after the old gateway was removed, got %v", got)
+	}
+}
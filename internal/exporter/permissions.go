@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// PermissionAssertion compares one discovered permission with the configured expectation
+type PermissionAssertion struct {
+	Operation string
+	Expected  string // allowed or denied
+	Actual    string // discovery status: allowed, denied, unknown or skipped
+}
+
+// Known reports whether discovery reached a verdict for the operation
+func (a PermissionAssertion) Known() bool {
+	return a.Actual == s3.PermissionAllowed || a.Actual == s3.PermissionDenied
+}
+
+// Drifted reports whether the verdict contradicts the expectation
+func (a PermissionAssertion) Drifted() bool {
+	return a.Known() && a.Actual != a.Expected
+}
+
+// PermissionDrift is the outcome of asserting an endpoint's expected permissions
+type PermissionDrift struct {
+	CheckedAt  time.Time
+	Assertions []PermissionAssertion // sorted by operation
+}
+
+// Drifted reports whether any operation contradicts its expectation
+func (d PermissionDrift) Drifted() bool {
+	for _, assertion := range d.Assertions {
+		if assertion.Drifted() {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe lists the drifted operations in one line
+func (d PermissionDrift) Describe() string {
+	var mismatches []string
+	for _, assertion := range d.Assertions {
+		if assertion.Drifted() {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %s but expected %s", assertion.Operation, assertion.Actual, assertion.Expected))
+		}
+	}
+	if len(mismatches) == 0 {
+		return "permissions match expectations"
+	}
+	return strings.Join(mismatches, "; ")
+}
+
+// assertPermissions compares a discovery result with the expected verdicts
+func assertPermissions(expected map[string]string, result *s3.DiscoveryResult) PermissionDrift {
+	actual := make(map[string]string, len(result.Permissions))
+	for _, permission := range result.Permissions {
+		actual[permission.Operation] = permission.Status
+	}
+
+	drift := PermissionDrift{CheckedAt: result.CheckedAt}
+	for operation, verdict := range expected {
+		status, ok := actual[operation]
+		if !ok {
+			status = s3.PermissionUnknown
+		}
+		drift.Assertions = append(drift.Assertions, PermissionAssertion{Operation: operation, Expected: verdict, Actual: status})
+	}
+	sort.Slice(drift.Assertions, func(i, j int) bool {
+		return drift.Assertions[i].Operation < drift.Assertions[j].Operation
+	})
+	return drift
+}
+
+// AssertPermissions runs permission discovery for every endpoint with expected_permissions
+// and publishes the drift to the sinks
+func (vm *ValidatorManager) AssertPermissions(ctx context.Context) *ValidationResults {
+	vm.mu.RLock()
+	expectations := make(map[string]map[string]string)
+	for name, meta := range vm.meta {
+		if len(meta.expectedPermissions) > 0 {
+			expectations[name] = meta.expectedPermissions
+		}
+	}
+	vm.mu.RUnlock()
+
+	results := &ValidationResults{
+		Timestamp:       vm.clock.Now(),
+		PermissionDrift: make(map[string]PermissionDrift, len(expectations)),
+	}
+	if len(expectations) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, expected := range expectations {
+		wg.Add(1)
+		go func(name string, expected map[string]string) {
+			defer wg.Done()
+			discovered, err := vm.DiscoverEndpoint(ctx, name)
+			if err != nil {
+				vm.log.WithField("endpoint", name).WithError(err).Warn("Permission assertion skipped")
+				return
+			}
+			drift := assertPermissions(expected, discovered)
+			mu.Lock()
+			results.PermissionDrift[name] = drift
+			mu.Unlock()
+		}(name, expected)
+	}
+	wg.Wait()
+
+	vm.publish(results)
+	return results
+}
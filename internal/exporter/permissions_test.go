@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAssertPermissions(t *testing.T) {
+	drift := assertPermissions(
+		map[string]string{
+			s3.OperationDeleteObject:    config.PermissionDenied,
+			s3.OperationListObjects:     config.PermissionAllowed,
+			s3.OperationGetBucketPolicy: config.PermissionDenied,
+		},
+		&s3.DiscoveryResult{Permissions: []s3.PermissionResult{
+			{Operation: s3.OperationListObjects, Status: s3.PermissionAllowed},
+			{Operation: s3.OperationDeleteObject, Status: s3.PermissionAllowed},
+			{Operation: s3.OperationGetBucketPolicy, Status: s3.PermissionUnknown},
+		}},
+	)
+
+	if !drift.Drifted() {
+		t.Fatalf("expected drift, got %+v", drift)
+	}
+	if got := drift.Describe(); got != "DeleteObject is allowed but expected denied" {
+		t.Fatalf("unexpected description %q", got)
+	}
+	if len(drift.Assertions) != 3 || drift.Assertions[0].Operation != s3.OperationDeleteObject {
+		t.Fatalf("expected assertions sorted by operation, got %+v", drift.Assertions)
+	}
+	if policy := drift.Assertions[1]; policy.Known() || policy.Drifted() {
+		t.Fatalf("expected an unknown verdict not to count as drift, got %+v", policy)
+	}
+}
+
+func TestValidatorManagerAssertPermissions(t *testing.T) {
+	vm := NewValidatorManager(&config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "drift-a", ExpectedPermissions: map[string]string{s3.OperationPutObject: config.PermissionDenied}},
+			{Name: "drift-b"},
+		},
+	}, logrus.New())
+	vm.mu.Lock()
+	vm.validators["drift-a"] = &discoveringValidator{permissions: []s3.PermissionResult{
+		{Operation: s3.OperationPutObject, Status: s3.PermissionAllowed},
+	}}
+	vm.validators["drift-b"] = &discoveringValidator{}
+	vm.mu.Unlock()
+
+	results := vm.AssertPermissions(context.Background())
+
+	if len(results.PermissionDrift) != 1 || !results.PermissionDrift["drift-a"].Drifted() {
+		t.Fatalf("expected drift for the endpoint with expectations only, got %+v", results.PermissionDrift)
+	}
+	if got := testutil.ToFloat64(metrics.PermissionDrift.WithLabelValues("drift-a", s3.OperationPutObject)); got != 1 {
+		t.Fatalf("expected s3_permission_drift to be 1, got %v", got)
+	}
+	metrics.UnregisterEndpoint("drift-a")
+	metrics.UnregisterEndpoint("drift-b")
+}
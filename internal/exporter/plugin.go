@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+)
+
+// ErrorTypePlugin is reported when a probe plugin fails or answers with invalid output
+const ErrorTypePlugin = "plugin_error"
+
+// PluginOperationOther replaces operation names a plugin reports outside
+// pluginOperations, since they become metric labels and plugins may print anything
+const PluginOperationOther = "other"
+
+// pluginOperations are the operation names plugin timings are reported under as they are
+var pluginOperations = []string{
+	s3.OperationListObjects,
+	s3.OperationGetObject,
+	s3.OperationPutObject,
+	s3.OperationDeleteObject,
+	s3.OperationMultipartUpload,
+	s3.OperationGetBucketPolicy,
+}
+
+const (
+	// pluginOutputLimit caps how much of a plugin's stdout is read
+	pluginOutputLimit = 64 << 10
+	// pluginStderrLimit caps how much of a plugin's stderr is kept for error messages
+	pluginStderrLimit = 1024
+	// pluginWaitDelay is how long a killed plugin may hold its output pipes open
+	pluginWaitDelay = time.Second
+)
+
+// pluginRequest is the JSON document written to a plugin's stdin
+type pluginRequest struct {
+	Endpoint     string            `json:"endpoint"`
+	URL          string            `json:"url,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	Bucket       string            `json:"bucket,omitempty"`
+	AccessKey    string            `json:"access_key,omitempty"`
+	SecretKey    string            `json:"secret_key,omitempty"`
+	SessionToken string            `json:"session_token,omitempty"`
+	Depth        s3.ProbeDepth     `json:"depth"`
+	ReadOnly     bool              `json:"read_only"`
+	TimeoutMs    int64             `json:"timeout_ms"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Settings     json.RawMessage   `json:"settings,omitempty"`
+}
+
+// pluginResponse is the verdict a plugin prints on stdout
+type pluginResponse struct {
+	IsValid    bool   `json:"is_valid"`
+	Message    string `json:"message"`
+	ErrorType  string `json:"error_type"`
+	Operations []struct {
+		Operation  string `json:"operation"`
+		DurationMs int64  `json:"duration_ms"`
+	} `json:"operations"`
+}
+
+// pluginValidator validates an endpoint by running its plugin program
+type pluginValidator struct {
+	command []string
+	request pluginRequest
+	clock   clock.Clock
+}
+
+// newPluginValidator creates the validator of a plugin endpoint signing with the given
+// credentials. readOnly tells the plugin not to write, like READ_ONLY does for the
+// built-in probes.
+func newPluginValidator(endpointCfg config.S3EndpointConfig, accessKey, secretKey, sessionToken string, readOnly bool, clk clock.Clock) *pluginValidator {
+	return &pluginValidator{
+		command: endpointCfg.Plugin.Exec,
+		request: pluginRequest{
+			Endpoint:     endpointCfg.Name,
+			URL:          endpointCfg.Endpoint,
+			Region:       endpointCfg.Region,
+			Bucket:       endpointCfg.Bucket,
+			AccessKey:    accessKey,
+			SecretKey:    secretKey,
+			SessionToken: sessionToken,
+			ReadOnly:     readOnly,
+			Labels:       endpointCfg.Labels,
+			Settings:     endpointCfg.Plugin.Settings,
+		},
+		clock: clk,
+	}
+}
+
+// ValidateKeys runs the plugin for the shallow check
+func (pv *pluginValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return pv.run(ctx, timeout, s3.ProbeDepthShallow)
+}
+
+// ValidateDeep runs the plugin for the deep probe
+func (pv *pluginValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return pv.run(ctx, timeout, s3.ProbeDepthDeep)
+}
+
+// run executes the plugin, bounded by timeout, and turns its verdict into a result.
+// Plugins exiting non-zero or printing anything but a verdict fail with plugin_error.
+func (pv *pluginValidator) run(ctx context.Context, timeout time.Duration, depth s3.ProbeDepth) *s3.ValidationResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := pv.clock.Now()
+	result := &s3.ValidationResult{CheckedAt: start, Depth: depth}
+	fail := func(errorType, format string, args ...any) *s3.ValidationResult {
+		result.ErrorType = errorType
+		result.Message = fmt.Sprintf(format, args...)
+		result.Duration = pv.clock.Since(start)
+		result.ResponseTimeMs = result.Duration.Milliseconds()
+		return result
+	}
+
+	req := pv.request
+	req.Depth = depth
+	req.TimeoutMs = timeout.Milliseconds()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fail(ErrorTypePlugin, "failed to encode plugin request: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pv.command[0], pv.command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &limitedBuffer{buf: &stdout, limit: pluginOutputLimit}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: pluginStderrLimit}
+	cmd.WaitDelay = pluginWaitDelay
+
+	runErr := cmd.Run()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fail("timeout", "plugin %s did not finish within %s", pv.command[0], timeout)
+	case ctx.Err() != nil:
+		return fail("canceled", "plugin %s was canceled", pv.command[0])
+	case runErr != nil:
+		return fail(ErrorTypePlugin, "plugin %s failed: %v: %s", pv.command[0], runErr, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var verdict pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &verdict); err != nil {
+		return fail(ErrorTypePlugin, "plugin %s printed an invalid verdict: %v", pv.command[0], err)
+	}
+	result.IsValid = verdict.IsValid
+	result.Message = verdict.Message
+	result.Duration = pv.clock.Since(start)
+	result.ResponseTimeMs = result.Duration.Milliseconds()
+	for _, op := range verdict.Operations {
+		result.Operations = append(result.Operations, s3.OperationTiming{
+			Operation: pluginOperation(op.Operation),
+			Duration:  time.Duration(op.DurationMs) * time.Millisecond,
+		})
+	}
+	if !verdict.IsValid {
+		result.ErrorType = pluginErrorType(verdict.ErrorType)
+		if verdict.ErrorType != "" && result.ErrorType != verdict.ErrorType {
+			result.Message = fmt.Sprintf("%s (plugin error type %q)", result.Message, verdict.ErrorType)
+		}
+	}
+	return result
+}
+
+// pluginErrorType keeps an error type the built-in probes also report and maps any
+// other to unknown, since it becomes a metric label and plugins may print anything
+func pluginErrorType(errorType string) string {
+	if slices.Contains(InjectableErrorTypes, errorType) {
+		return errorType
+	}
+	return "unknown"
+}
+
+// pluginOperation keeps an operation name the built-in probes also report and maps any
+// other to PluginOperationOther
+func pluginOperation(operation string) string {
+	if slices.Contains(pluginOperations, operation) {
+		return operation
+	}
+	return PluginOperationOther
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest, so a
+// chatty plugin cannot exhaust memory
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pluginEndpoint returns an endpoint whose plugin runs script with sh
+func pluginEndpoint(name, script string) config.S3EndpointConfig {
+	return config.S3EndpointConfig{
+		Name:      name,
+		Bucket:    "vendor-bucket",
+		AccessKey: "AKIAPLUGIN",
+		SecretKey: "secret",
+		Plugin: &config.PluginConfig{
+			Exec:     []string{"sh", "-c", script},
+			Settings: json.RawMessage(`{"api":"https://vendor.example.com"}`),
+		},
+	}
+}
+
+func TestPluginEndpointFeedsPipeline(t *testing.T) {
+	requestFile := filepath.Join(t.TempDir(), "request.json")
+	script := `cat > ` + requestFile + `; echo '{"is_valid": true, "message": "vendor API accepted the key", "operations": [{"operation": "GetAccount", "duration_ms": 12}, {"operation": "GetObject", "duration_ms": 3}]}'`
+	cfg := &config.Config{ValidationTimeout: 5 * time.Second, ReadOnly: true, Endpoints: []config.S3EndpointConfig{pluginEndpoint("vendor", script)}}
+	vm := NewValidatorManager(cfg, logrus.New())
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+
+	results := vm.ValidateAll(context.Background())
+	result := results.Results["vendor"]
+	if !result.IsValid || result.Message != "vendor API accepted the key" || result.Depth != s3.ProbeDepthShallow {
+		t.Fatalf("expected the plugin verdict, got %+v", result)
+	}
+	want := []s3.OperationTiming{{Operation: PluginOperationOther, Duration: 12 * time.Millisecond}, {Operation: s3.OperationGetObject, Duration: 3 * time.Millisecond}}
+	if !slices.Equal(result.Operations, want) {
+		t.Fatalf("expected the plugin's timings with unknown operations as other, got %+v", result.Operations)
+	}
+	if len(sink.batches) != 1 {
+		t.Fatalf("expected the result to reach the sinks, got %d batches", len(sink.batches))
+	}
+
+	raw, err := os.ReadFile(requestFile)
+	if err != nil {
+		t.Fatalf("read request: %v", err)
+	}
+	var req pluginRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		t.Fatalf("expected a JSON request on stdin, got %q: %v", raw, err)
+	}
+	if req.Endpoint != "vendor" || req.AccessKey != "AKIAPLUGIN" || req.TimeoutMs != 5000 || !req.ReadOnly || !strings.Contains(string(req.Settings), "vendor.example.com") {
+		t.Fatalf("expected the endpoint, keys, timeout, read-only mode and settings to be passed, got %+v", req)
+	}
+}
+
+func TestPluginFailures(t *testing.T) {
+	tests := []struct {
+		name, script, errorType, message string
+	}{
+		{"rejected", `echo '{"is_valid": false, "message": "key revoked", "error_type": "access_denied"}'`, "access_denied", "key revoked"},
+		{"untyped", `echo '{"is_valid": false}'`, "unknown", ""},
+		{"unlisted", `echo '{"is_valid": false, "message": "quota", "error_type": "quota-4711"}'`, "unknown", `quota (plugin error type "quota-4711")`},
+		{"crashed", `echo 'vendor API down' >&2; exit 3`, ErrorTypePlugin, "vendor API down"},
+		{"garbled", `echo 'not json'`, ErrorTypePlugin, "invalid verdict"},
+		{"hanging", `sleep 5`, "timeout", "did not finish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := pluginEndpoint(tt.name, tt.script)
+			validator := newPluginValidator(endpoint, endpoint.AccessKey, endpoint.SecretKey, "", false, clock.Real)
+			result := validator.ValidateDeep(context.Background(), 200*time.Millisecond)
+			if result.IsValid || result.ErrorType != tt.errorType || !strings.Contains(result.Message, tt.message) {
+				t.Fatalf("expected %s failure mentioning %q, got %+v", tt.errorType, tt.message, result)
+			}
+			if result.Depth != s3.ProbeDepthDeep {
+				t.Fatalf("expected the deep depth to be reported, got %q", result.Depth)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// optionsValidator is implemented by validators that accept probe overrides
+type optionsValidator interface {
+	ValidateWith(ctx context.Context, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult
+}
+
+// validateWith runs v with probe overrides, failing when v does not support them
+func validateWith(ctx context.Context, v bucketValidator, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	if ov, ok := v.(optionsValidator); ok {
+		return ov.ValidateWith(ctx, timeout, opts)
+	}
+	return &s3.ValidationResult{
+		IsValid:   false,
+		Message:   fmt.Sprintf("validator %T does not support probe options", v),
+		CheckedAt: time.Now(),
+		ErrorType: "config_error",
+	}
+}
+
+// ValidateEndpointWith validates an endpoint once with probe overrides; a zero timeout
+// keeps the configured one. The result is returned but not published: a run with other
+// settings says nothing about the endpoint as configured, so it must not change metrics,
+// history or notifications.
+func (vm *ValidatorManager) ValidateEndpointWith(ctx context.Context, endpointName string, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	vm.mu.RLock()
+	validator, exists := vm.validators[endpointName]
+	vm.mu.RUnlock()
+
+	if !exists {
+		return vm.endpointNotFound(endpointName)
+	}
+	if timeout <= 0 {
+		timeout = vm.timeout
+	}
+	return validateWith(ctx, validator, timeout, opts)
+}
@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type optionsStubValidator struct {
+	stubValidator
+	timeout time.Duration
+	opts    s3.ProbeOptions
+}
+
+func (o *optionsStubValidator) ValidateWith(ctx context.Context, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	o.timeout, o.opts = timeout, opts
+	return &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}
+}
+
+func TestValidateEndpointWith(t *testing.T) {
+	cfg := &config.Config{ValidationTimeout: time.Second, Endpoints: []config.S3EndpointConfig{{Name: "one"}, {Name: "plain"}}}
+	vm := NewValidatorManager(cfg, logrus.New())
+	one := &optionsStubValidator{}
+
+	vm.mu.Lock()
+	vm.validators["one"] = one
+	vm.validators["plain"] = &stubValidator{}
+	vm.mu.Unlock()
+
+	opts := s3.ProbeOptions{Depth: s3.ProbeDepthDeep, Prefix: "diag/"}
+	if result := vm.ValidateEndpointWith(context.Background(), "one", 0, opts); !result.IsValid {
+		t.Fatalf("expected the override run to pass, got %+v", result)
+	}
+	if one.timeout != time.Second || one.opts != opts {
+		t.Fatalf("expected the configured timeout and the given options, got %s %+v", one.timeout, one.opts)
+	}
+	if history := vm.History("one"); len(history) != 0 {
+		t.Fatalf("expected an override run not to be published")
+	}
+
+	if result := vm.ValidateEndpointWith(context.Background(), "plain", time.Minute, opts); result.IsValid || result.ErrorType != "config_error" {
+		t.Fatalf("expected validators without option support to fail, got %+v", result)
+	}
+	if result := vm.ValidateEndpointWith(context.Background(), "missing", 0, opts); result.ErrorType != "endpoint_not_found" {
+		t.Fatalf("expected an unknown endpoint to be reported, got %+v", result)
+	}
+}
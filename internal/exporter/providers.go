@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// UnreachableProviders returns the declared providers whose probed endpoints all
+// failed with connectivity errors in this run
+func (r *ValidationResults) UnreachableProviders() map[string]bool {
+	unreachable := make(map[string]bool)
+	for provider, endpoints := range r.Providers {
+		down := len(endpoints) > 0
+		for _, name := range endpoints {
+			result := r.Results[name]
+			if result == nil || result.IsValid || !s3.IsConnectivityError(result.ErrorType) {
+				down = false
+				break
+			}
+		}
+		if down {
+			unreachable[provider] = true
+		}
+	}
+	return unreachable
+}
+
+// RolledUpEndpoints returns the endpoints whose failures are covered by an unreachable provider
+func (r *ValidationResults) RolledUpEndpoints(unreachable map[string]bool) map[string]bool {
+	rolledUp := make(map[string]bool)
+	for provider, endpoints := range r.Providers {
+		if !unreachable[provider] {
+			continue
+		}
+		for _, name := range endpoints {
+			rolledUp[name] = true
+		}
+	}
+	return rolledUp
+}
+
+// ProviderSummary aggregates the latest key validity of every endpoint sharing a host
+type ProviderSummary struct {
+	Host      string
+	Endpoints []string
+	Valid     int
+	Invalid   int
+	Unchecked int
+}
+
+// ProviderSummaries returns per-provider key posture sorted by host
+func (vm *ValidatorManager) ProviderSummaries() []ProviderSummary {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+
+	summaries := vm.summarizeProvidersLocked()
+	sorted := make([]ProviderSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		sort.Strings(summary.Endpoints)
+		sorted = append(sorted, *summary)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Host < sorted[j].Host })
+	return sorted
+}
+
+// trackResults stores the latest validity per endpoint and refreshes the count gauges.
+// Endpoints of an unreachable provider keep their previous state, matching MetricsSink.
+func (vm *ValidatorManager) trackResults(results *ValidationResults) {
+	unreachable := results.UnreachableProviders()
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	for name, result := range results.Results {
+		meta, ok := vm.meta[name]
+		if !ok || result == nil {
+			continue
+		}
+		if meta.declared && unreachable[meta.provider] {
+			continue
+		}
+		vm.lastValid[name] = result.IsValid
+	}
+	if len(results.Results) > 0 {
+		vm.refreshKeyCountsLocked()
+	}
+}
+
+// providerCount is the valid/invalid tally of one provider
+type providerCount struct {
+	valid, invalid int
+}
+
+// refreshKeyCountsLocked publishes valid/invalid counts for every provider and across
+// all endpoints. It only tallies, unlike summarizeProvidersLocked, as it runs after
+// every batch. Callers must hold vm.mu.
+func (vm *ValidatorManager) refreshKeyCountsLocked() {
+	counts := make(map[string]providerCount)
+	var total providerCount
+	for name, meta := range vm.meta {
+		count := counts[meta.provider]
+		if valid, checked := vm.lastValid[name]; checked {
+			if valid {
+				count.valid++
+				total.valid++
+			} else {
+				count.invalid++
+				total.invalid++
+			}
+		}
+		counts[meta.provider] = count
+	}
+	for host, count := range counts {
+		vm.metrics.SetProviderKeyCounts(host, count.valid, count.invalid)
+	}
+	vm.metrics.SetEndpointCounts(total.valid, total.invalid)
+}
+
+func (vm *ValidatorManager) summarizeProvidersLocked() map[string]*ProviderSummary {
+	summaries := make(map[string]*ProviderSummary)
+	for name, meta := range vm.meta {
+		summary, ok := summaries[meta.provider]
+		if !ok {
+			summary = &ProviderSummary{Host: meta.provider}
+			summaries[meta.provider] = summary
+		}
+		summary.Endpoints = append(summary.Endpoints, name)
+
+		valid, checked := vm.lastValid[name]
+		switch {
+		case !checked:
+			summary.Unchecked++
+		case valid:
+			summary.Valid++
+		default:
+			summary.Invalid++
+		}
+	}
+	return summaries
+}
+
+// providerInUseLocked reports whether any endpoint still belongs to the provider.
+// Callers must hold vm.mu.
+func (vm *ValidatorManager) providerInUseLocked(provider string) bool {
+	for _, meta := range vm.meta {
+		if meta.provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// providerHost returns the host group for an endpoint: the declared provider, the
+// host of a custom endpoint URL, or the regional AWS S3 host
+func providerHost(endpointCfg config.S3EndpointConfig) string {
+	if endpointCfg.Provider != "" {
+		return endpointCfg.Provider
+	}
+	if endpointCfg.Endpoint != "" {
+		if u, err := url.Parse(endpointCfg.Endpoint); err == nil && u.Host != "" {
+			return u.Host
+		}
+		return endpointCfg.Endpoint
+	}
+	return fmt.Sprintf("s3.%s.amazonaws.com", endpointCfg.Region)
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestValidateAllGroupsByProvider(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "tenant-a", Provider: "minio.internal"},
+			{Name: "tenant-b", Provider: "minio.internal"},
+			{Name: "standalone"},
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	for name := range vm.validators {
+		vm.validators[name] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	}
+	vm.mu.Unlock()
+
+	results := vm.ValidateAll(context.Background())
+
+	if len(results.Providers) != 1 || len(results.Providers["minio.internal"]) != 2 {
+		t.Fatalf("expected both tenants grouped under minio.internal, got %v", results.Providers)
+	}
+}
+
+func TestUnreachableProviders(t *testing.T) {
+	results := &ValidationResults{
+		Results: map[string]*s3.ValidationResult{
+			"a1": {ErrorType: "network"},
+			"a2": {ErrorType: "timeout"},
+			"b1": {ErrorType: "network"},
+			"b2": {ErrorType: "access_denied"},
+			"c1": {IsValid: true},
+		},
+		Providers: map[string][]string{
+			"down":    {"a1", "a2"},
+			"partial": {"b1", "b2"},
+			"up":      {"c1"},
+		},
+	}
+
+	unreachable := results.UnreachableProviders()
+
+	if !unreachable["down"] {
+		t.Fatalf("expected provider with only connectivity failures to be unreachable")
+	}
+	if unreachable["partial"] || unreachable["up"] {
+		t.Fatalf("expected only the fully failed provider to be unreachable, got %v", unreachable)
+	}
+}
+
+func TestRemoveEndpointUnregistersOrphanedProvider(t *testing.T) {
+	metrics.ProviderUnreachable.Reset()
+
+	cfg := &config.Config{
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "p1", Provider: "host-x"},
+			{Name: "p2", Provider: "host-x"},
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.RemoveEndpoint("p1")
+	if count := testutil.CollectAndCount(metrics.ProviderUnreachable); count != 1 {
+		t.Fatalf("expected provider series to remain while in use, got %d", count)
+	}
+
+	vm.RemoveEndpoint("p2")
+	if count := testutil.CollectAndCount(metrics.ProviderUnreachable); count != 0 {
+		t.Fatalf("expected provider series to be removed with its last endpoint, got %d", count)
+	}
+}
+
+func TestProviderSummaries(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "minio-a", Endpoint: "http://minio:9000"},
+			{Name: "minio-b", Endpoint: "http://minio:9000"},
+			{Name: "minio-c", Endpoint: "http://minio:9000"},
+			{Name: "aws", Region: "eu-west-1"},
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	vm.validators["minio-a"] = &stubValidator{result: &s3.ValidationResult{IsValid: true}}
+	vm.validators["minio-b"] = &stubValidator{result: &s3.ValidationResult{IsValid: false, ErrorType: "access_denied"}}
+	vm.validators["minio-c"] = &stubValidator{result: &s3.ValidationResult{IsValid: true}}
+	vm.validators["aws"] = &stubValidator{result: &s3.ValidationResult{IsValid: true}}
+	vm.mu.Unlock()
+
+	before := vm.ProviderSummaries()
+	if len(before) != 2 || before[0].Unchecked != 3 {
+		t.Fatalf("expected unchecked endpoints before validation, got %+v", before)
+	}
+
+	vm.ValidateAll(context.Background())
+	summaries := vm.ProviderSummaries()
+
+	if summaries[0].Host != "minio:9000" || summaries[1].Host != "s3.eu-west-1.amazonaws.com" {
+		t.Fatalf("unexpected provider hosts: %+v", summaries)
+	}
+	if summaries[0].Valid != 2 || summaries[0].Invalid != 1 {
+		t.Fatalf("expected 2 valid and 1 invalid on minio, got %+v", summaries[0])
+	}
+	if got := testutil.ToFloat64(metrics.ProviderKeysInvalidCount.WithLabelValues("minio:9000")); got != 1 {
+		t.Fatalf("expected invalid count gauge 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.ProviderKeysValidCount.WithLabelValues("s3.eu-west-1.amazonaws.com")); got != 1 {
+		t.Fatalf("expected valid count gauge 1 for aws, got %v", got)
+	}
+	if valid, invalid := testutil.ToFloat64(metrics.EndpointsValid), testutil.ToFloat64(metrics.EndpointsInvalid); valid != 3 || invalid != 1 {
+		t.Fatalf("expected 3 valid and 1 invalid endpoints in total, got %v and %v", valid, invalid)
+	}
+}
@@ -0,0 +1,34 @@
+package exporter
+
+// refresher is implemented by validators that cache clients or check schedules
+type refresher interface {
+	Refresh()
+}
+
+// RefreshEndpoint drops the endpoint's cached client and check schedule, so its next
+// validation resolves credentials again over new connections. It reports false for
+// unknown endpoints.
+func (vm *ValidatorManager) RefreshEndpoint(endpointName string) bool {
+	vm.mu.RLock()
+	validator, exists := vm.validators[endpointName]
+	vm.mu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	if r, ok := validator.(refresher); ok {
+		r.Refresh()
+	}
+	return true
+}
+
+// RefreshAll drops the cached clients and check schedules of every endpoint
+func (vm *ValidatorManager) RefreshAll() {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	for _, validator := range vm.validators {
+		if r, ok := validator.(refresher); ok {
+			r.Refresh()
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type refreshingValidator struct {
+	stubValidator
+	refreshes int
+}
+
+func (r *refreshingValidator) Refresh() {
+	r.refreshes++
+}
+
+func TestRefreshEndpoint(t *testing.T) {
+	cfg := &config.Config{ValidationTimeout: time.Second, Endpoints: []config.S3EndpointConfig{{Name: "one"}, {Name: "two"}}}
+	vm := NewValidatorManager(cfg, logrus.New())
+	one, two := &refreshingValidator{}, &refreshingValidator{}
+
+	vm.mu.Lock()
+	vm.validators["one"] = one
+	vm.validators["two"] = &slottedValidator{primary: two, secondary: &stubValidator{}}
+	vm.mu.Unlock()
+
+	if !vm.RefreshEndpoint("one") || one.refreshes != 1 || two.refreshes != 0 {
+		t.Fatalf("expected only the named endpoint to be refreshed, got %d and %d", one.refreshes, two.refreshes)
+	}
+	if vm.RefreshEndpoint("missing") {
+		t.Fatalf("expected an unknown endpoint to be reported")
+	}
+
+	vm.RefreshAll()
+	if one.refreshes != 2 || two.refreshes != 1 {
+		t.Fatalf("expected every endpoint, including credential slots, to be refreshed, got %d and %d", one.refreshes, two.refreshes)
+	}
+}
@@ -0,0 +1,182 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// resultLogRecord is one line of the results log. Fields are flat so the file loads
+// into a warehouse table without transformation.
+type resultLogRecord struct {
+	Timestamp           time.Time            `json:"timestamp"`
+	CheckedAt           time.Time            `json:"checked_at"`
+	Endpoint            string               `json:"endpoint"`
+	IsValid             bool                 `json:"is_valid"`
+	ErrorType           string               `json:"error_type,omitempty"`
+	Message             string               `json:"message"`
+	ResponseTimeMs      int64                `json:"response_time_ms"`
+	Region              string               `json:"region,omitempty"`
+	Depth               string               `json:"depth,omitempty"`
+	Retries             int                  `json:"retries"`
+	RemoteIP            string               `json:"remote_ip,omitempty"`
+	ProviderUnreachable bool                 `json:"provider_unreachable"`
+	Checks              []resultLogCheck     `json:"checks,omitempty"`
+	Secondary           *resultLogCredential `json:"secondary,omitempty"`
+}
+
+type resultLogCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+}
+
+type resultLogCredential struct {
+	IsValid   bool   `json:"is_valid"`
+	ErrorType string `json:"error_type,omitempty"`
+}
+
+// ResultsLogSink appends every validation result to a JSON Lines file, rotating it to
+// path.1, path.2, ... once it reaches the configured size
+type ResultsLogSink struct {
+	cfg config.ResultsLogConfig
+	log *logrus.Logger
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewResultsLogSink opens cfg.Path for appending, creating it when missing
+func NewResultsLogSink(cfg config.ResultsLogConfig, log *logrus.Logger) (*ResultsLogSink, error) {
+	s := &ResultsLogSink{cfg: cfg, log: log}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the file
+func (s *ResultsLogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// Consume writes one line per result, endpoints in name order
+func (s *ResultsLogSink) Consume(results *ValidationResults) {
+	rolledUp := results.RolledUpEndpoints(results.UnreachableProviders())
+
+	names := make([]string, 0, len(results.Results))
+	for name, result := range results.Results {
+		if result != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, name := range names {
+		if err := encoder.Encode(newResultLogRecord(results.Timestamp, name, results.Results[name], rolledUp[name])); err != nil {
+			s.log.WithError(err).WithField("endpoint", name).Warn("Failed to encode result for the results log")
+		}
+	}
+	if buf.Len() == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(buf.Bytes()); err != nil {
+		s.log.WithError(err).Warn("Failed to write to the results log")
+	}
+}
+
+func newResultLogRecord(timestamp time.Time, name string, result *s3.ValidationResult, rolledUp bool) resultLogRecord {
+	record := resultLogRecord{
+		Timestamp:           timestamp,
+		CheckedAt:           result.CheckedAt,
+		Endpoint:            name,
+		IsValid:             result.IsValid,
+		ErrorType:           result.ErrorType,
+		Message:             result.Message,
+		ResponseTimeMs:      result.ResponseTimeMs,
+		Region:              result.Region,
+		Depth:               string(result.Depth),
+		Retries:             result.Retries,
+		RemoteIP:            result.RemoteIP,
+		ProviderUnreachable: rolledUp,
+	}
+	for _, check := range result.Checks {
+		if !check.Pending {
+			record.Checks = append(record.Checks, resultLogCheck{Name: check.Name, Passed: check.Passed})
+		}
+	}
+	if secondary := result.Secondary; secondary != nil {
+		record.Secondary = &resultLogCredential{IsValid: secondary.IsValid, ErrorType: secondary.ErrorType}
+	}
+	return record
+}
+
+// write appends data, rotating first when it would grow the file past the limit. A
+// batch larger than the limit still goes into a single file.
+func (s *ResultsLogSink) write(data []byte) error {
+	if s.size > 0 && s.size+int64(len(data)) > s.cfg.MaxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest beyond MaxBackups, moves the
+// current file to path.1 and starts a new one
+func (s *ResultsLogSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	path := s.cfg.Path
+	if s.cfg.MaxBackups == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	} else {
+		for i := s.cfg.MaxBackups - 1; i >= 1; i-- {
+			if err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.Rename(path, path+".1"); err != nil {
+			return err
+		}
+	}
+	return s.open()
+}
+
+func (s *ResultsLogSink) open() error {
+	file, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open results log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open results log: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func readResultLines(t *testing.T, path string) []resultLogRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	var records []resultLogRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record resultLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestResultsLogSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	sink, err := NewResultsLogSink(config.ResultsLogConfig{Path: path, MaxSize: 1 << 20, MaxBackups: 1}, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sink.Consume(&ValidationResults{
+		Timestamp: now,
+		Results: map[string]*s3.ValidationResult{
+			"b": {IsValid: false, ErrorType: "network", CheckedAt: now},
+			"a": {IsValid: true, ResponseTimeMs: 42, Checks: []s3.CheckResult{{Name: "versioning", Passed: true}, {Name: "restore", Pending: true}}},
+		},
+		Providers: map[string][]string{"minio": {"b"}},
+	})
+	sink.Consume(&ValidationResults{Timestamp: now, Results: map[string]*s3.ValidationResult{"a": {IsValid: true}}})
+
+	records := readResultLines(t, path)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(records))
+	}
+	if records[0].Endpoint != "a" || records[0].ResponseTimeMs != 42 || len(records[0].Checks) != 1 {
+		t.Fatalf("unexpected first record %+v", records[0])
+	}
+	if records[1].Endpoint != "b" || records[1].IsValid || !records[1].ProviderUnreachable || !records[1].Timestamp.Equal(now) {
+		t.Fatalf("unexpected second record %+v", records[1])
+	}
+}
+
+func TestResultsLogSinkRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	sink, err := NewResultsLogSink(config.ResultsLogConfig{Path: path, MaxSize: 300, MaxBackups: 2}, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 5; i++ {
+		sink.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{"a": {IsValid: true, Message: "ok"}}})
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 300 {
+			t.Fatalf("%s grew to %d bytes past the limit", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 backups, stat .3: %v", err)
+	}
+}
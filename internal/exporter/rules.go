@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/expr"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// resultRule is a config.ResultRule with its when expression compiled
+type resultRule struct {
+	config.ResultRule
+	when *expr.Program
+}
+
+// compileResultRules compiles the rules, skipping the ones LoadConfig would have rejected
+func compileResultRules(rules []config.ResultRule, log *logrus.Logger) []resultRule {
+	compiled := make([]resultRule, 0, len(rules))
+	for _, rule := range rules {
+		when, err := rule.Compile()
+		if err != nil {
+			log.WithError(err).WithField("rule", rule.Name).Error("Ignoring invalid result rule")
+			continue
+		}
+		compiled = append(compiled, resultRule{ResultRule: rule, when: when})
+	}
+	return compiled
+}
+
+// resultVars are the values of config.ResultRuleVars for a result
+func resultVars(name string, meta endpointMeta, result *s3.ValidationResult) map[string]any {
+	region := result.Region
+	if region == "" {
+		region = meta.region
+	}
+	severity := result.Severity
+	if severity == "" {
+		severity = meta.severity
+	}
+	vars := map[string]any{
+		"endpoint":         name,
+		"bucket":           meta.bucket,
+		"region":           region,
+		"provider":         meta.provider,
+		"labels":           meta.labels,
+		"severity":         severity,
+		"depth":            string(result.Depth),
+		"is_valid":         result.IsValid,
+		"error_type":       result.ErrorType,
+		"error_code":       "",
+		"http_status":      0,
+		"message":          result.Message,
+		"response_time_ms": result.ResponseTimeMs,
+	}
+	if result.Error != nil {
+		vars["error_code"] = result.Error.Code
+		vars["http_status"] = result.Error.HTTPStatus
+	}
+	return vars
+}
+
+// applyResultRules runs the result rules over the batch. It returns the batch sinks
+// should see: results itself, or a copy without the failures a rule suppressed, which
+// stay in results for the caller.
+func (vm *ValidatorManager) applyResultRules(results *ValidationResults) *ValidationResults {
+	if len(vm.resultRules) == 0 {
+		return results
+	}
+
+	var suppressed []string
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		vm.mu.RLock()
+		meta := vm.meta[name]
+		vm.mu.RUnlock()
+
+		for _, rule := range vm.resultRules {
+			matched, err := rule.when.EvalBool(resultVars(name, meta, result))
+			if err != nil {
+				vm.log.WithError(err).WithFields(logrus.Fields{
+					"rule":     rule.Name,
+					"endpoint": name,
+				}).Warn("Failed to evaluate result rule")
+				continue
+			}
+			if !matched {
+				continue
+			}
+			if rule.ErrorType != "" && !result.IsValid {
+				result.ErrorType = rule.ErrorType
+				if result.Error != nil {
+					detail := *result.Error
+					detail.Type = rule.ErrorType
+					result.Error = &detail
+				}
+			}
+			if rule.Severity != "" {
+				result.Severity = rule.Severity
+			}
+			if rule.Suppress && !result.IsValid && result.SuppressedBy == "" {
+				result.SuppressedBy = rule.Name
+				suppressed = append(suppressed, name)
+				vm.metrics.RecordResultSuppressed(name, rule.Name)
+			}
+		}
+	}
+	if len(suppressed) == 0 {
+		return results
+	}
+
+	batch := *results
+	batch.Results = make(map[string]*s3.ValidationResult, len(results.Results))
+	for name, result := range results.Results {
+		if result == nil || result.SuppressedBy == "" {
+			batch.Results[name] = result
+		}
+	}
+	vm.log.WithField("endpoints", suppressed).Debug("Suppressed failed results by result rule")
+	return &batch
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestResultRules(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints: []config.S3EndpointConfig{
+			{Name: "scratch-a", Bucket: "scratch-a", Severity: config.SeverityCritical},
+			{Name: "prod", Bucket: "prod", Severity: config.SeverityCritical, Labels: map[string]string{"team": "data"}},
+		},
+		ResultRules: []config.ResultRule{
+			{Name: "scratch-missing", When: `endpoint.startsWith("scratch-") && error_code == "NoSuchBucket"`, ErrorType: "scratch_missing", Severity: config.SeverityWarning},
+			{Name: "quiet-scratch", When: `severity == "warning" && !is_valid`, Suppress: true},
+			{Name: "data-info", When: `"team" in labels && labels.team == "data" && http_status == 403`, Severity: config.SeverityInfo},
+			{Name: "broken", When: `labels.owner == "x"`, Suppress: true}, // fails on the missing key
+		},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+	vm.mu.Lock()
+	vm.validators["scratch-a"] = &stubValidator{result: &s3.ValidationResult{
+		ErrorType: "bucket_not_found",
+		Error:     &s3.ErrorDetail{Type: "bucket_not_found", Code: "NoSuchBucket", HTTPStatus: 404},
+		CheckedAt: time.Now(),
+	}}
+	vm.validators["prod"] = &stubValidator{result: &s3.ValidationResult{
+		ErrorType: "access_denied",
+		Error:     &s3.ErrorDetail{Type: "access_denied", Code: "AccessDenied", HTTPStatus: 403},
+		CheckedAt: time.Now(),
+	}}
+	vm.mu.Unlock()
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+	metrics.ResultsSuppressed.Reset()
+
+	results := vm.ValidateAll(context.Background())
+
+	scratch := results.Results["scratch-a"]
+	if scratch == nil || scratch.ErrorType != "scratch_missing" || scratch.Error.Type != "scratch_missing" {
+		t.Fatalf("expected scratch-a to be reclassified, got %+v", scratch)
+	}
+	if scratch.Severity != config.SeverityWarning || scratch.SuppressedBy != "quiet-scratch" {
+		t.Fatalf("expected scratch-a to be downgraded and suppressed, got %+v", scratch)
+	}
+	if prod := results.Results["prod"]; prod.Severity != config.SeverityInfo || prod.SuppressedBy != "" {
+		t.Fatalf("expected prod to be re-graded only, got %+v", prod)
+	}
+
+	batch := sink.batches[0]
+	if _, ok := batch.Results["scratch-a"]; ok || len(batch.Results) != 1 {
+		t.Fatalf("expected only prod to reach the sinks, got %v", batch.Results)
+	}
+	if got := testutil.ToFloat64(metrics.ResultsSuppressed.WithLabelValues("scratch-a", "quiet-scratch")); got != 1 {
+		t.Fatalf("expected one suppressed result, got %v", got)
+	}
+	if _, known := vm.lastValid["scratch-a"]; known {
+		t.Fatal("expected the suppressed failure to leave the endpoint's state alone")
+	}
+}
+
+func TestCompileResultRulesSkipsInvalid(t *testing.T) {
+	rules := compileResultRules([]config.ResultRule{
+		{Name: "ok", When: `!is_valid`, Suppress: true},
+		{Name: "unknown-name", When: `owner == "x"`, Suppress: true},
+		{Name: "syntax", When: `is_valid &&`, Suppress: true},
+		{Name: "types", When: `response_time_ms > "x"`, Suppress: true},
+	}, logrus.New())
+	if len(rules) != 1 || rules[0].Name != "ok" {
+		t.Fatalf("expected only the valid rule, got %+v", rules)
+	}
+}
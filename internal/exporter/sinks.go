@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+)
+
+// ResultSink receives every batch of validation results produced by the manager.
+// Sinks are called sequentially in registration order and must not modify the results.
+// Sinks tracking key validity ignore deep batches (ValidationResults.Deep). The manager
+// always feeds the metrics, log and history sinks; notifications and the other
+// integrations are registered with AddSink.
+type ResultSink interface {
+	Consume(results *ValidationResults)
+}
+
+// MetricsSink publishes validation results as Prometheus metrics. Endpoints of an
+// unreachable provider are rolled up into s3_provider_unreachable instead of flipping
+// every endpoint's key validity.
+type MetricsSink struct {
+	metrics *metrics.Metrics
+}
+
+// NewMetricsSink creates a sink that records results into m
+func NewMetricsSink(m *metrics.Metrics) *MetricsSink {
+	return &MetricsSink{metrics: m}
+}
+
+// Consume records metrics for every result in the batch
+func (s *MetricsSink) Consume(results *ValidationResults) {
+	unreachable := results.UnreachableProviders()
+	rolledUp := results.RolledUpEndpoints(unreachable)
+	if results.Deep() {
+		s.recordDeep(results, rolledUp)
+		return
+	}
+
+	for provider := range results.Providers {
+		s.metrics.SetProviderUnreachable(provider, unreachable[provider])
+	}
+
+	for name, result := range results.Results {
+		if result != nil {
+			s.record(name, result, rolledUp[name])
+		}
+	}
+
+	for name, anomaly := range results.Anomalies {
+		s.metrics.SetLatencyAnomaly(name, anomaly.Anomalous, float64(anomaly.BaselineMs))
+	}
+
+	for name, recovery := range results.Recoveries {
+		s.metrics.RecordOutage(name, recovery.Duration)
+	}
+
+	for name, usd := range results.ProbeCosts {
+		s.metrics.SetProbeEstimatedCost(name, usd)
+	}
+
+	for name, comparison := range results.Comparisons {
+		s.metrics.SetEndpointPair(name, comparison.Old, comparison.New, comparison.State, comparison.LatencyDelta, comparison.State == PairBothOK)
+	}
+
+	for name, age := range results.KeyAges {
+		s.metrics.SetKeyAge(name, age.Age, age.MaxAge > 0, age.RotationDue)
+	}
+
+	for name, drift := range results.PermissionDrift {
+		for _, assertion := range drift.Assertions {
+			s.metrics.SetPermissionDrift(name, assertion.Operation, assertion.Known(), assertion.Drifted())
+		}
+	}
+}
+
+// recordDeep records deep probe outcomes only into the depth-labelled probe metrics,
+// plus the requests they sent, so a failing write path never flips key validity
+func (s *MetricsSink) recordDeep(results *ValidationResults, rolledUp map[string]bool) {
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		if !rolledUp[name] {
+			s.metrics.RecordProbeResult(name, string(s3.ProbeDepthDeep), result.IsValid, result.Duration)
+		}
+		for _, op := range probeOperations(result) {
+			s.metrics.RecordProbeRequest(name, op.Operation)
+		}
+	}
+	for name, usd := range results.ProbeCosts {
+		s.metrics.SetProbeEstimatedCost(name, usd)
+	}
+}
+
+func (s *MetricsSink) record(endpointName string, result *s3.ValidationResult, rolledUp bool) {
+	s.metrics.RecordValidationAttempt(endpointName, result.IsValid)
+	s.metrics.SetLastValidationTime(endpointName, float64(result.CheckedAt.Unix()))
+	s.metrics.RecordValidationDuration(endpointName, result.Duration)
+	s.metrics.SetClockSkewDetected(endpointName, s3.IsClockSkewError(result.ErrorType))
+	if result.IPFamily != "" {
+		s.metrics.SetIPFamily(endpointName, string(result.IPFamily))
+	}
+	if result.ViaPrivateEndpoint != nil {
+		s.metrics.SetViaPrivateEndpoint(endpointName, *result.ViaPrivateEndpoint)
+	}
+	if result.Depth != "" && !rolledUp {
+		s.metrics.RecordProbeResult(endpointName, string(result.Depth), result.IsValid, result.Duration)
+	}
+	for _, op := range result.Operations {
+		s.metrics.RecordResponseTime(endpointName, op.Operation, op.Duration)
+	}
+	for _, op := range probeOperations(result) {
+		s.metrics.RecordProbeRequest(endpointName, op.Operation)
+	}
+	s.metrics.RecordProbeRetries(endpointName, result.Retries)
+	for _, check := range result.Checks {
+		s.metrics.RecordCheckResult(endpointName, check.Name, check.Passed)
+		s.metrics.RecordCheckPending(endpointName, check.Name, check.Pending)
+		s.metrics.RecordCheckCounts(endpointName, check.Name, check.Counts)
+	}
+
+	if !rolledUp {
+		s.metrics.SetCredentialSlotValid(endpointName, SlotPrimary, result.IsValid)
+		if result.Secondary != nil {
+			s.metrics.SetCredentialSlotValid(endpointName, SlotSecondary, result.Secondary.IsValid)
+		} else {
+			s.metrics.UnregisterCredentialSlot(endpointName, SlotSecondary)
+		}
+	}
+
+	switch {
+	case result.IsValid:
+		s.metrics.RecordValidationSuccess(endpointName)
+		s.metrics.SetLastSuccessfulValidationTime(endpointName, float64(result.CheckedAt.Unix()))
+		if result.Region != "" {
+			s.metrics.SetActiveRegion(endpointName, result.Region)
+		}
+	case rolledUp:
+		// The validity gauge keeps its last value; the provider gauge carries the outage
+		s.metrics.CountValidationFailure(endpointName, failureType(result))
+	default:
+		s.metrics.RecordValidationFailure(endpointName, failureType(result))
+	}
+}
+
+func failureType(result *s3.ValidationResult) string {
+	if result.ErrorType == "" {
+		return "unknown"
+	}
+	return result.ErrorType
+}
@@ -0,0 +1,200 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMetricsSinkRecordsChecks(t *testing.T) {
+	metrics.AccessLoggingWorking.Reset()
+
+	consumeOne(NewMetricsSink(metrics.Default), "audited", &s3.ValidationResult{
+		IsValid:   true,
+		CheckedAt: time.Now(),
+		Checks:    []s3.CheckResult{{Name: s3.CheckAccessLog, Passed: true}},
+	})
+
+	if got := testutil.ToFloat64(metrics.AccessLoggingWorking.WithLabelValues("audited")); got != 1 {
+		t.Fatalf("expected access logging gauge to be 1, got %v", got)
+	}
+}
+
+func TestMetricsSinkObservesOperationsAndDuration(t *testing.T) {
+	metrics.ResponseTime.Reset()
+	metrics.ValidationDuration.Reset()
+
+	consumeOne(NewMetricsSink(metrics.Default), "timed", &s3.ValidationResult{
+		IsValid:   true,
+		CheckedAt: time.Now(),
+		Duration:  150 * time.Millisecond,
+		Operations: []s3.OperationTiming{
+			{Operation: s3.OperationListObjects, Duration: 40 * time.Millisecond},
+			{Operation: "GetObject", Duration: 60 * time.Millisecond},
+		},
+	})
+
+	if count := testutil.CollectAndCount(metrics.ResponseTime); count != 2 {
+		t.Fatalf("expected one response time series per operation, got %d", count)
+	}
+	if count := testutil.CollectAndCount(metrics.ValidationDuration); count != 1 {
+		t.Fatalf("expected validation duration to be observed, got %d series", count)
+	}
+}
+
+func TestMetricsSinkFlagsClockSkew(t *testing.T) {
+	metrics.ClockSkewDetected.Reset()
+
+	sink := NewMetricsSink(metrics.Default)
+	consumeOne(sink, "skewed", &s3.ValidationResult{CheckedAt: time.Now(), ErrorType: "clock_skew"})
+
+	if got := testutil.ToFloat64(metrics.ClockSkewDetected.WithLabelValues("skewed")); got != 1 {
+		t.Fatalf("expected clock skew to be flagged, got %v", got)
+	}
+
+	consumeOne(sink, "skewed", &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()})
+
+	if got := testutil.ToFloat64(metrics.ClockSkewDetected.WithLabelValues("skewed")); got != 0 {
+		t.Fatalf("expected clock skew flag to clear after a successful validation, got %v", got)
+	}
+}
+
+func TestMetricsSinkSetsIPFamily(t *testing.T) {
+	metrics.IPFamilyInfo.Reset()
+
+	consumeOne(NewMetricsSink(metrics.Default), "v6-only", &s3.ValidationResult{IsValid: true, CheckedAt: time.Now(), IPFamily: s3.IPFamilyIPv6})
+
+	if got := testutil.ToFloat64(metrics.IPFamilyInfo.WithLabelValues("v6-only", "ipv6")); got != 1 {
+		t.Fatalf("expected ipv6 family to be recorded, got %v", got)
+	}
+}
+
+func TestMetricsSinkSetsActiveRegion(t *testing.T) {
+	metrics.ActiveRegionInfo.Reset()
+
+	sink := NewMetricsSink(metrics.Default)
+	consumeOne(sink, "multi-region", &s3.ValidationResult{IsValid: true, CheckedAt: time.Now(), Region: "us-west-2"})
+	consumeOne(sink, "multi-region", &s3.ValidationResult{IsValid: false, CheckedAt: time.Now(), Region: "us-east-1", ErrorType: "network"})
+
+	if got := testutil.ToFloat64(metrics.ActiveRegionInfo.WithLabelValues("multi-region", "us-west-2")); got != 1 {
+		t.Fatalf("expected last successful region to stay active, got %v", got)
+	}
+	if count := testutil.CollectAndCount(metrics.ActiveRegionInfo); count != 1 {
+		t.Fatalf("expected failed validations not to change the active region, got %d series", count)
+	}
+}
+
+func TestMetricsSinkKeepsLastSuccessDuringFailures(t *testing.T) {
+	metrics.LastSuccessfulValidationTimestamp.Reset()
+	good := time.Unix(1700000000, 0)
+
+	sink := NewMetricsSink(metrics.Default)
+	consumeOne(sink, "flaky", &s3.ValidationResult{IsValid: true, CheckedAt: good})
+	consumeOne(sink, "flaky", &s3.ValidationResult{IsValid: false, CheckedAt: good.Add(time.Minute), ErrorType: "access_denied"})
+
+	if got := testutil.ToFloat64(metrics.LastSuccessfulValidationTimestamp.WithLabelValues("flaky")); got != float64(good.Unix()) {
+		t.Fatalf("expected the last success to survive a failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.LastValidationTimestamp.WithLabelValues("flaky")); got != float64(good.Add(time.Minute).Unix()) {
+		t.Fatalf("expected the last attempt to move on, got %v", got)
+	}
+}
+
+func TestMetricsSinkRollsUpUnreachableProvider(t *testing.T) {
+	metrics.ProviderUnreachable.Reset()
+
+	// Seed the gauges as if earlier validations succeeded
+	metrics.RecordValidationSuccess("rollup-a")
+	metrics.RecordValidationSuccess("rollup-b")
+	metrics.RecordValidationSuccess("solo")
+
+	NewMetricsSink(metrics.Default).Consume(&ValidationResults{
+		Results: map[string]*s3.ValidationResult{
+			"rollup-a": {ErrorType: "network", CheckedAt: time.Now()},
+			"rollup-b": {ErrorType: "network", CheckedAt: time.Now()},
+			"solo":     {ErrorType: "network", CheckedAt: time.Now()},
+		},
+		Providers: map[string][]string{"shared-host": {"rollup-a", "rollup-b"}},
+	})
+
+	if got := testutil.ToFloat64(metrics.ProviderUnreachable.WithLabelValues("shared-host")); got != 1 {
+		t.Fatalf("expected shared-host to be flagged unreachable, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.KeysValid.WithLabelValues("rollup-a")); got != 1 {
+		t.Fatalf("expected rolled-up endpoint to keep its validity gauge, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.ValidationFailures.WithLabelValues("rollup-a", "network")); got < 1 {
+		t.Fatalf("expected rolled-up failure to still be counted")
+	}
+	if got := testutil.ToFloat64(metrics.KeysValid.WithLabelValues("solo")); got != 0 {
+		t.Fatalf("expected endpoint without provider to be marked invalid, got %v", got)
+	}
+}
+
+func TestMetricsSinkDeepBatchKeepsValidity(t *testing.T) {
+	metrics.RecordValidationSuccess("deep-only")
+
+	NewMetricsSink(metrics.Default).Consume(&ValidationResults{
+		Depth: s3.ProbeDepthDeep,
+		Results: map[string]*s3.ValidationResult{
+			"deep-only": {ErrorType: "access_denied", Depth: s3.ProbeDepthDeep, CheckedAt: time.Now()},
+		},
+	})
+
+	if got := testutil.ToFloat64(metrics.KeysValid.WithLabelValues("deep-only")); got != 1 {
+		t.Fatalf("expected a failed deep probe to keep key validity, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.ValidationFailures.WithLabelValues("deep-only", "access_denied")); got != 0 {
+		t.Fatalf("expected no validation failure for a deep probe, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.ProbeSuccess.WithLabelValues("deep-only", "deep")); got != 0 {
+		t.Fatalf("expected the deep probe gauge to report the failure, got %v", got)
+	}
+}
+
+func TestValidatorManagerFansOutToSinks(t *testing.T) {
+	cfg := &config.Config{
+		ValidationTimeout: time.Second,
+		Endpoints:         []config.S3EndpointConfig{{Name: "one"}, {Name: "two"}},
+	}
+	vm := NewValidatorManager(cfg, logrus.New())
+
+	vm.mu.Lock()
+	for name := range vm.validators {
+		vm.validators[name] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}}
+	}
+	vm.mu.Unlock()
+
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+
+	vm.ValidateAll(context.Background())
+	vm.ValidateEndpoint(context.Background(), "one")
+	vm.ValidateEndpoint(context.Background(), "missing")
+
+	if len(sink.batches) != 2 {
+		t.Fatalf("expected 2 batches (missing endpoints are not published), got %d", len(sink.batches))
+	}
+	if len(sink.batches[0].Results) != 2 || len(sink.batches[1].Results) != 1 {
+		t.Fatalf("unexpected batch sizes: %d and %d", len(sink.batches[0].Results), len(sink.batches[1].Results))
+	}
+}
+
+type recordingSink struct {
+	batches []*ValidationResults
+}
+
+func (r *recordingSink) Consume(results *ValidationResults) {
+	r.batches = append(r.batches, results)
+}
+
+func consumeOne(sink ResultSink, endpointName string, result *s3.ValidationResult) {
+	sink.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{endpointName: result}})
+}
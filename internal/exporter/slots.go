@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// Credential slots reported in s3_credential_slot_valid
+const (
+	SlotPrimary   = "primary"
+	SlotSecondary = "secondary"
+)
+
+// slottedValidator validates an endpoint's primary and secondary credentials in parallel.
+// The primary result stays the endpoint's result; the secondary one is attached to it so
+// a rotation can be confirmed before the old key is revoked.
+type slottedValidator struct {
+	primary   bucketValidator
+	secondary bucketValidator
+}
+
+// ValidateKeys runs the shallow check with both credential sets
+func (sv *slottedValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return sv.run(func(v bucketValidator) *s3.ValidationResult {
+		return v.ValidateKeys(ctx, timeout)
+	})
+}
+
+// ValidateDeep runs the deep probe with both credential sets
+func (sv *slottedValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *s3.ValidationResult {
+	return sv.run(func(v bucketValidator) *s3.ValidationResult {
+		return v.ValidateDeep(ctx, timeout)
+	})
+}
+
+// ValidateWith runs a single validation with probe overrides using both credential sets
+func (sv *slottedValidator) ValidateWith(ctx context.Context, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	return sv.run(func(v bucketValidator) *s3.ValidationResult {
+		return validateWith(ctx, v, timeout, opts)
+	})
+}
+
+// KeyCreatedAt reports the creation date of the primary key
+func (sv *slottedValidator) KeyCreatedAt(ctx context.Context) (time.Time, error) {
+	dater, ok := sv.primary.(keyDater)
+	if !ok {
+		return time.Time{}, fmt.Errorf("validator cannot look up key creation dates")
+	}
+	return dater.KeyCreatedAt(ctx)
+}
+
+// Refresh drops the cached state of both credential sets
+func (sv *slottedValidator) Refresh() {
+	for _, v := range []bucketValidator{sv.primary, sv.secondary} {
+		if r, ok := v.(refresher); ok {
+			r.Refresh()
+		}
+	}
+}
+
+func (sv *slottedValidator) run(probe func(bucketValidator) *s3.ValidationResult) *s3.ValidationResult {
+	var secondary *s3.ValidationResult
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		secondary = probe(sv.secondary)
+	}()
+
+	result := probe(sv.primary)
+	wg.Wait()
+	result.Secondary = secondary
+	return result
+}
@@ -0,0 +1,99 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSlottedValidatorAttachesSecondary(t *testing.T) {
+	sv := &slottedValidator{
+		primary:   &stubValidator{result: &s3.ValidationResult{IsValid: true, Message: "old key ok"}},
+		secondary: &stubValidator{result: &s3.ValidationResult{IsValid: false, Message: "new key denied", ErrorType: "access_denied"}},
+	}
+
+	result := sv.ValidateKeys(context.Background(), time.Second)
+	if !result.IsValid || result.Message != "old key ok" {
+		t.Fatalf("expected the primary result, got %+v", result)
+	}
+	if result.Secondary == nil || result.Secondary.IsValid {
+		t.Fatalf("expected a failed secondary result, got %+v", result.Secondary)
+	}
+
+	if deep := sv.ValidateDeep(context.Background(), time.Second); deep.Secondary == nil {
+		t.Fatalf("expected the deep probe to cover the secondary slot")
+	}
+}
+
+func TestSlottedValidatorKeyCreatedAtUsesPrimary(t *testing.T) {
+	created := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	sv := &slottedValidator{
+		primary:   &datedValidator{createdAt: created},
+		secondary: &stubValidator{},
+	}
+
+	got, err := sv.KeyCreatedAt(context.Background())
+	if err != nil || !got.Equal(created) {
+		t.Fatalf("expected the primary key date, got %v (%v)", got, err)
+	}
+
+	if _, err := (&slottedValidator{primary: &stubValidator{}}).KeyCreatedAt(context.Background()); err == nil {
+		t.Fatalf("expected an error when the primary cannot look up dates")
+	}
+}
+
+func TestMetricsSinkRecordsCredentialSlots(t *testing.T) {
+	metrics.CredentialSlotValid.Reset()
+
+	consumeOne(NewMetricsSink(metrics.Default), "rotating", &s3.ValidationResult{
+		IsValid:   true,
+		CheckedAt: time.Now(),
+		Secondary: &s3.ValidationResult{IsValid: false},
+	})
+
+	if got := testutil.ToFloat64(metrics.CredentialSlotValid.WithLabelValues("rotating", SlotPrimary)); got != 1 {
+		t.Fatalf("expected primary slot valid, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.CredentialSlotValid.WithLabelValues("rotating", SlotSecondary)); got != 0 {
+		t.Fatalf("expected secondary slot invalid, got %v", got)
+	}
+
+	consumeOne(NewMetricsSink(metrics.Default), "rotating", &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()})
+	if count := testutil.CollectAndCount(metrics.CredentialSlotValid); count != 1 {
+		t.Fatalf("expected the secondary series to go away with the secondary slot, got %d", count)
+	}
+}
+
+func TestLogSinkSecondaryTransitions(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	sink := NewLogSink(log, config.LogModeChanges)
+
+	withSecondary := func(valid bool) *s3.ValidationResult {
+		return &s3.ValidationResult{IsValid: true, Secondary: &s3.ValidationResult{IsValid: valid, ErrorType: "access_denied"}}
+	}
+
+	consumeOne(sink, "rotating", withSecondary(false))
+	consumeOne(sink, "rotating", withSecondary(false))
+	consumeOne(sink, "rotating", withSecondary(true))
+
+	var secondary []*logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["slot"] == SlotSecondary {
+			secondary = append(secondary, entry)
+		}
+	}
+	if len(secondary) != 2 {
+		t.Fatalf("expected 2 secondary transitions, got %d", len(secondary))
+	}
+	if secondary[0].Level != logrus.WarnLevel || secondary[1].Level != logrus.InfoLevel {
+		t.Fatalf("unexpected secondary log levels: %v, %v", secondary[0].Level, secondary[1].Level)
+	}
+}
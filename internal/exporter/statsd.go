@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxStatsDPacket keeps datagrams below the usual MTU, the size DogStatsD clients default to
+const maxStatsDPacket = 1432
+
+// StatsDSink sends validation results to a DogStatsD agent as tagged counters, gauges
+// and timers, for hosts where nothing scrapes /metrics. Like MetricsSink, endpoints of
+// an unreachable provider report the provider outage instead of their own validity.
+type StatsDSink struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+	log    *logrus.Logger
+}
+
+// NewStatsDSink connects to the agent at cfg.Address; UDP and Unix datagram sockets
+// are connectionless, so this only fails for unresolvable addresses
+func NewStatsDSink(cfg config.StatsDConfig, log *logrus.Logger) (*StatsDSink, error) {
+	network, address := "udp", cfg.Address
+	if path, ok := strings.CutPrefix(cfg.Address, "unix://"); ok {
+		network, address = "unixgram", path
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open StatsD socket: %w", err)
+	}
+	return &StatsDSink{conn: conn, prefix: cfg.Prefix, tags: cfg.Tags, log: log}, nil
+}
+
+// Close closes the socket
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+// Consume sends one set of metrics per result in a shallow batch
+func (s *StatsDSink) Consume(results *ValidationResults) {
+	if results.Deep() {
+		return
+	}
+	unreachable := results.UnreachableProviders()
+	rolledUp := results.RolledUpEndpoints(unreachable)
+
+	var batch statsDBatch
+	for provider := range results.Providers {
+		batch.add(s.line("provider_unreachable", boolValue(unreachable[provider]), "g", statsDTag("provider", provider)))
+	}
+	for name, result := range results.Results {
+		if result != nil {
+			s.record(&batch, name, result, rolledUp[name])
+		}
+	}
+	for _, packet := range batch.packets() {
+		if _, err := s.conn.Write(packet); err != nil {
+			s.log.WithError(err).Debug("Failed to send metrics to StatsD")
+			return
+		}
+	}
+}
+
+func (s *StatsDSink) record(batch *statsDBatch, endpointName string, result *s3.ValidationResult, rolledUp bool) {
+	endpoint := statsDTag("endpoint", endpointName)
+	status := "status:invalid"
+	if result.IsValid {
+		status = "status:valid"
+	}
+	batch.add(s.line("validation.attempts", "1", "c", endpoint, status))
+	batch.add(s.line("validation.duration", strconv.FormatInt(result.Duration.Milliseconds(), 10), "ms", endpoint, status))
+	if !result.IsValid {
+		batch.add(s.line("validation.failures", "1", "c", endpoint, statsDTag("error_type", failureType(result))))
+	}
+	if !rolledUp {
+		batch.add(s.line("keys_valid", boolValue(result.IsValid), "g", endpoint))
+	}
+	for _, check := range result.Checks {
+		if check.Pending {
+			continue
+		}
+		batch.add(s.line("check.passed", boolValue(check.Passed), "g", endpoint, statsDTag("check", check.Name)))
+	}
+}
+
+// line formats one DogStatsD datagram line: name:value|type|#tags
+func (s *StatsDSink) line(name, value, kind string, tags ...string) string {
+	tags = append(tags, s.tags...)
+	return fmt.Sprintf("%s%s:%s|%s|#%s", s.prefix, name, value, kind, strings.Join(tags, ","))
+}
+
+// statsDTag formats a tag, replacing the characters that delimit tags and metrics
+func statsDTag(name, value string) string {
+	return name + ":" + statsDTagReplacer.Replace(value)
+}
+
+var statsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// statsDBatch packs lines into as few datagrams as fit maxStatsDPacket
+type statsDBatch struct {
+	lines []string
+}
+
+func (b *statsDBatch) add(line string) {
+	b.lines = append(b.lines, line)
+}
+
+func (b *statsDBatch) packets() [][]byte {
+	var packets [][]byte
+	var current []byte
+	for _, line := range b.lines {
+		if len(current) > 0 && len(current)+1+len(line) > maxStatsDPacket {
+			packets = append(packets, current)
+			current = nil
+		}
+		if len(current) > 0 {
+			current = append(current, '\n')
+		}
+		current = append(current, line...)
+	}
+	if len(current) > 0 {
+		packets = append(packets, current)
+	}
+	return packets
+}
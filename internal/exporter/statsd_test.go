@@ -0,0 +1,99 @@
+package exporter
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// listenStatsD returns a UDP agent and a func reading the lines of the next datagram
+func listenStatsD(t *testing.T) (string, func() []string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func() []string {
+		buf := make([]byte, 65536)
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected a datagram: %v", err)
+		}
+		lines := strings.Split(string(buf[:n]), "\n")
+		sort.Strings(lines)
+		return lines
+	}
+}
+
+func TestStatsDSinkSendsTaggedMetrics(t *testing.T) {
+	address, read := listenStatsD(t)
+	sink, err := NewStatsDSink(config.StatsDConfig{Address: address, Prefix: "s3.", Tags: []string{"env:prod"}}, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Consume(&ValidationResults{Results: map[string]*s3.ValidationResult{
+		"team,a": {IsValid: false, ErrorType: "access_denied", Duration: 120 * time.Millisecond},
+	}})
+
+	want := []string{
+		"s3.keys_valid:0|g|#endpoint:team_a,env:prod",
+		"s3.validation.attempts:1|c|#endpoint:team_a,status:invalid,env:prod",
+		"s3.validation.duration:120|ms|#endpoint:team_a,status:invalid,env:prod",
+		"s3.validation.failures:1|c|#endpoint:team_a,error_type:access_denied,env:prod",
+	}
+	if got := read(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected lines:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestStatsDSinkRollsUpUnreachableProviders(t *testing.T) {
+	address, read := listenStatsD(t)
+	sink, err := NewStatsDSink(config.StatsDConfig{Address: address}, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Consume(&ValidationResults{
+		Results:   map[string]*s3.ValidationResult{"a": {IsValid: false, ErrorType: "network"}},
+		Providers: map[string][]string{"minio": {"a"}},
+	})
+
+	lines := read()
+	for _, line := range lines {
+		if strings.HasPrefix(line, "keys_valid") {
+			t.Fatalf("expected no validity gauge for a rolled-up endpoint, got %q", line)
+		}
+	}
+	if lines[0] != "provider_unreachable:1|g|#provider:minio" {
+		t.Fatalf("expected the provider outage, got %v", lines)
+	}
+}
+
+func TestStatsDBatchSplitsPackets(t *testing.T) {
+	var batch statsDBatch
+	line := strings.Repeat("x", 600)
+	for i := 0; i < 5; i++ {
+		batch.add(line)
+	}
+	packets := batch.packets()
+	if len(packets) != 3 {
+		t.Fatalf("expected 3 packets of at most %d bytes, got %d", maxStatsDPacket, len(packets))
+	}
+	for _, packet := range packets {
+		if len(packet) > maxStatsDPacket {
+			t.Fatalf("packet of %d bytes exceeds the limit", len(packet))
+		}
+	}
+}
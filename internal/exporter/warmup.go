@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// warmUp ramps the first shallow and deep runs after startup up in growing batches, so a
+// deployment with hundreds of endpoints does not hit shared providers all at once
+type warmUp struct {
+	initial  int           // endpoints in the first batch
+	factor   float64       // growth of each batch over the one before
+	interval time.Duration // pause between batches
+
+	shallowDone atomic.Bool
+	deepDone    atomic.Bool
+}
+
+// rampBatches splits names into batches of initial, initial×factor, … endpoints. Names
+// are interleaved across providers first, so each batch spreads over as many providers
+// as it can.
+func rampBatches(names []string, provider func(name string) string, initial int, factor float64) [][]string {
+	byProvider := make(map[string][]string)
+	var providers []string
+	for _, name := range slices.Sorted(slices.Values(names)) {
+		p := provider(name)
+		if _, seen := byProvider[p]; !seen {
+			providers = append(providers, p)
+		}
+		byProvider[p] = append(byProvider[p], name)
+	}
+	slices.Sort(providers)
+
+	ordered := make([]string, 0, len(names))
+	for i := 0; len(ordered) < len(names); i++ {
+		for _, p := range providers {
+			if i < len(byProvider[p]) {
+				ordered = append(ordered, byProvider[p][i])
+			}
+		}
+	}
+
+	var batches [][]string
+	size := float64(initial)
+	for len(ordered) > 0 {
+		n := min(int(math.Ceil(size)), len(ordered))
+		batches = append(batches, ordered[:n])
+		ordered = ordered[n:]
+		size *= factor
+	}
+	return batches
+}
+
+// first reports whether this is the first shallow or deep run, which is ramped up;
+// a nil warmUp disables ramping
+func (w *warmUp) first(deep bool) bool {
+	if w == nil {
+		return false
+	}
+	if deep {
+		return w.deepDone.CompareAndSwap(false, true)
+	}
+	return w.shallowDone.CompareAndSwap(false, true)
+}
+
+// validateRamped runs probe for the endpoints accepted by include, batch by batch, and
+// merges the batches' results. Each batch is published as soon as it completes. Endpoints
+// whose batch had not started when ctx ended are reported unfinished.
+func (vm *ValidatorManager) validateRamped(ctx context.Context, include func(name string) bool, probe func(bucketValidator) *s3.ValidationResult, depth s3.ProbeDepth) *ValidationResults {
+	vm.mu.RLock()
+	var names []string
+	for name := range vm.validators {
+		if include(name) {
+			names = append(names, name)
+		}
+	}
+	provider := func(name string) string { return vm.meta[name].provider }
+	batches := rampBatches(names, provider, vm.warmUp.initial, vm.warmUp.factor)
+	vm.mu.RUnlock()
+
+	merged := &ValidationResults{
+		Timestamp: vm.clock.Now(),
+		Depth:     depth,
+		Results:   make(map[string]*s3.ValidationResult, len(names)),
+		Providers: make(map[string][]string),
+	}
+	vm.log.WithFields(logrus.Fields{
+		"endpoints": len(names),
+		"batches":   len(batches),
+	}).Info("Warming up validation in growing batches")
+
+	for i, batch := range batches {
+		if i > 0 && !vm.pauseWarmUp(ctx) {
+			vm.reportNotStarted(ctx, merged, batches[i:])
+			break
+		}
+		inBatch := make(map[string]bool, len(batch))
+		for _, name := range batch {
+			inBatch[name] = true
+		}
+		merged.merge(vm.validateEach(ctx, func(name string) bool { return inBatch[name] }, probe, nil, depth))
+	}
+	return merged
+}
+
+// pauseWarmUp waits between batches, reporting false when ctx ended first
+func (vm *ValidatorManager) pauseWarmUp(ctx context.Context) bool {
+	timer := vm.clock.NewTimer(vm.warmUp.interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reportNotStarted adds the endpoints of batches that never ran as unfinished
+func (vm *ValidatorManager) reportNotStarted(ctx context.Context, merged *ValidationResults, batches [][]string) {
+	now := vm.clock.Now()
+	for _, batch := range batches {
+		for _, name := range batch {
+			result := unfinishedResult(ctx, now)
+			merged.Results[name] = result
+			vm.metrics.RecordValidationUnfinished(name, result.ErrorType)
+		}
+	}
+}
+
+// merge adds the results of another batch of the same run
+func (r *ValidationResults) merge(batch *ValidationResults) {
+	for name, result := range batch.Results {
+		r.Results[name] = result
+	}
+	for provider, endpoints := range batch.Providers {
+		r.Providers[provider] = append(r.Providers[provider], endpoints...)
+	}
+	mergeInto(&r.Anomalies, batch.Anomalies)
+	mergeInto(&r.KeyAges, batch.KeyAges)
+	mergeInto(&r.Recoveries, batch.Recoveries)
+	mergeInto(&r.PermissionDrift, batch.PermissionDrift)
+	mergeInto(&r.ProbeCosts, batch.ProbeCosts)
+	mergeInto(&r.Comparisons, batch.Comparisons)
+}
+
+func mergeInto[V any](dst *map[string]V, src map[string]V) {
+	if len(src) == 0 {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]V, len(src))
+	}
+	maps.Copy(*dst, src)
+}
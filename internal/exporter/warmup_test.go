@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRampBatchesInterleavesProviders(t *testing.T) {
+	names := []string{"a1", "a2", "a3", "b1", "b2", "c1"}
+	provider := func(name string) string { return name[:1] }
+
+	batches := rampBatches(names, provider, 1, 2)
+	want := [][]string{{"a1"}, {"b1", "c1"}, {"a2", "b2", "a3"}}
+	if len(batches) != len(want) {
+		t.Fatalf("expected %v, got %v", want, batches)
+	}
+	for i := range want {
+		if !slices.Equal(batches[i], want[i]) {
+			t.Fatalf("expected %v, got %v", want, batches)
+		}
+	}
+
+	if batches := rampBatches(names, provider, 4, 1); len(batches) != 2 || len(batches[1]) != 2 {
+		t.Fatalf("expected fixed batches of 4, got %v", batches)
+	}
+}
+
+func newWarmUpManager(t *testing.T, n int, clk clock.Clock) (*ValidatorManager, *recordingSink) {
+	t.Helper()
+	cfg := &config.Config{
+		ValidationTimeout:   time.Second,
+		WarmUpInitialBatch:  1,
+		WarmUpRampFactor:    2,
+		WarmUpBatchInterval: 5 * time.Second,
+	}
+	for i := range n {
+		cfg.Endpoints = append(cfg.Endpoints, config.S3EndpointConfig{Name: fmt.Sprintf("bucket-%d", i)})
+	}
+	vm := NewValidatorManager(cfg, logrus.New(), WithClock(clk))
+	vm.mu.Lock()
+	for name := range vm.validators {
+		vm.validators[name] = &stubValidator{result: &s3.ValidationResult{IsValid: true, CheckedAt: clk.Now()}}
+	}
+	vm.mu.Unlock()
+	sink := &recordingSink{}
+	vm.AddSink(sink)
+	return vm, sink
+}
+
+func TestValidateCycleWarmsUpOnce(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	vm, sink := newWarmUpManager(t, 5, clk)
+	baseline := clk.Waiters()
+
+	done := make(chan *ValidationResults)
+	go func() { done <- vm.ValidateCycle(context.Background()) }()
+
+	for i, size := range []int{1, 2} {
+		clk.BlockUntil(baseline + 1)
+		if len(sink.batches) != i+1 || len(sink.batches[i].Results) != size {
+			t.Fatalf("expected batch %d of %d endpoints before the pause, got %d batches", i, size, len(sink.batches))
+		}
+		clk.Advance(5 * time.Second)
+	}
+	results := <-done
+	if len(sink.batches) != 3 || len(sink.batches[2].Results) != 2 {
+		t.Fatalf("expected a last batch of 2 endpoints, got %d batches", len(sink.batches))
+	}
+	if len(results.Results) != 5 {
+		t.Fatalf("expected the merged results of all 5 endpoints, got %d", len(results.Results))
+	}
+	if cycle, ok := vm.LatestCycle(); !ok || len(cycle.Endpoints) != 5 {
+		t.Fatalf("expected the warm-up to be recorded as one cycle, got %+v", cycle)
+	}
+
+	vm.ValidateCycle(context.Background())
+	if len(sink.batches) != 4 || len(sink.batches[3].Results) != 5 {
+		t.Fatalf("expected later cycles to validate every endpoint at once, got %d batches", len(sink.batches))
+	}
+}
+
+func TestWarmUpReportsBatchesNotStarted(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	vm, sink := newWarmUpManager(t, 3, clk)
+	baseline := clk.Waiters()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *ValidationResults)
+	go func() { done <- vm.ValidateCycle(ctx) }()
+
+	clk.BlockUntil(baseline + 1)
+	cancel()
+	results := <-done
+
+	if len(sink.batches) != 1 {
+		t.Fatalf("expected only the first batch to be published, got %d", len(sink.batches))
+	}
+	var canceled int
+	for _, result := range results.Results {
+		if result.ErrorType == ErrorTypeCanceled {
+			canceled++
+		}
+	}
+	if len(results.Results) != 3 || canceled != 2 {
+		t.Fatalf("expected 2 of 3 endpoints reported canceled, got %d of %d", canceled, len(results.Results))
+	}
+}
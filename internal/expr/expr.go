@@ -0,0 +1,111 @@
+// Package expr compiles conditions written in the Common Expression Language (CEL) with
+// github.com/google/cel-go. Every kind of condition declares the variables it may read
+// and their types in an Env, so unknown names and type mismatches are rejected when the
+// configuration is loaded rather than when a result is evaluated.
+package expr
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// Types of declared variables
+var (
+	Bool      = cel.BoolType
+	Int       = cel.IntType
+	String    = cel.StringType
+	StringMap = cel.MapType(cel.StringType, cel.StringType)
+)
+
+// Var declares a variable an expression may read
+type Var struct {
+	Name string
+	Type *cel.Type
+}
+
+// Env is the set of variables expressions are compiled against
+type Env struct {
+	env *cel.Env
+}
+
+// NewEnv declares vars for the expressions compiled in the environment, which also
+// offers the CEL string extensions such as lowerAscii and upperAscii
+func NewEnv(vars ...Var) (*Env, error) {
+	opts := []cel.EnvOption{ext.Strings()}
+	for _, v := range vars {
+		opts = append(opts, cel.Variable(v.Name, v.Type))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Env{env: env}, nil
+}
+
+// MustNewEnv is NewEnv for environments declared in package variables; it panics on
+// invalid declarations
+func MustNewEnv(vars ...Var) *Env {
+	env, err := NewEnv(vars...)
+	if err != nil {
+		panic(fmt.Sprintf("expr: %v", err))
+	}
+	return env
+}
+
+// Program is a compiled condition, safe for concurrent use
+type Program struct {
+	src  string
+	prg  cel.Program
+	vars []string
+}
+
+// Compile parses and type-checks src, which must yield a bool
+func (e *Env) Compile(src string) (*Program, error) {
+	ast, issues := e.env.Compile(src)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("expected a bool expression, got %s", ast.OutputType())
+	}
+	prg, err := e.env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars []string
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		// Variable references carry a name and no function overloads
+		if ref.Name != "" && len(ref.OverloadIDs) == 0 && !slices.Contains(vars, ref.Name) {
+			vars = append(vars, ref.Name)
+		}
+	}
+	slices.Sort(vars)
+	return &Program{src: src, prg: prg, vars: vars}, nil
+}
+
+// String returns the source of the expression
+func (p *Program) String() string {
+	return p.src
+}
+
+// Vars returns the names of the variables the expression reads, sorted
+func (p *Program) Vars() []string {
+	return slices.Clone(p.vars)
+}
+
+// EvalBool evaluates the condition with vars, which must hold every declared variable
+func (p *Program) EvalBool(vars map[string]any) (bool, error) {
+	out, _, err := p.prg.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool, got %s", out.Type())
+	}
+	return b, nil
+}
@@ -0,0 +1,102 @@
+package expr
+
+import (
+	"slices"
+	"testing"
+)
+
+var testEnv = MustNewEnv(
+	Var{"endpoint", String},
+	Var{"error_type", String},
+	Var{"http_status", Int},
+	Var{"is_valid", Bool},
+	Var{"labels", StringMap},
+	Var{"region", String},
+)
+
+var testVars = map[string]any{
+	"endpoint":    "scratch-logs",
+	"error_type":  "NoSuchBucket",
+	"http_status": 404,
+	"is_valid":    false,
+	"labels":      map[string]string{"team": "data"},
+	"region":      "eu-west-1",
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`endpoint.startsWith("scratch-") && error_type == "NoSuchBucket"`, true},
+		{`endpoint.matches("^scratch-[a-z]+$")`, true},
+		{`!is_valid && http_status >= 400 && http_status < 500`, true},
+		{`error_type in ["NoSuchBucket", "AccessDenied"]`, true},
+		{`"team" in labels && labels.team == "data"`, true},
+		{`!("owner" in labels) || labels["owner"] == ""`, true},
+		{`size(endpoint) == 12`, true},
+		{`http_status / 100 == 4`, true}, // integer division, as in CEL
+		{`7 / 2 == 3`, true},
+		{`7.0 / 2.0 == 3.5`, true},
+		{`(is_valid ? "ok" : error_type.lowerAscii()) == "nosuchbucket"`, true},
+		{`region in ["us-east-1"]`, false},
+	}
+	for _, tt := range tests {
+		prog, err := testEnv.Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := prog.EvalBool(testVars)
+		if err != nil {
+			t.Errorf("EvalBool(%q): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EvalBool(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`endpoint ==`,
+		`(is_valid && is_valid`,
+		`owner == "x"`,           // undeclared variable
+		`http_status == "404"`,   // int compared with a string
+		`http_status < 500.0`,    // int compared with a double
+		`endpoint.unknownFunc()`, // unknown function
+		`endpoint`,               // not a bool
+		`size(endpoint)`,         // not a bool
+	} {
+		if _, err := testEnv.Compile(src); err == nil {
+			t.Errorf("Compile(%q): expected an error", src)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, src := range []string{
+		`labels.owner == "x"`, // missing map key
+		`http_status / 0 == 1`,
+	} {
+		prog, err := testEnv.Compile(src)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", src, err)
+		}
+		if _, err := prog.EvalBool(testVars); err == nil {
+			t.Errorf("EvalBool(%q): expected an error", src)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	prog, err := testEnv.Compile(`endpoint.startsWith("a") && labels.team == "x" || endpoint == region`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prog.Vars(); !slices.Equal(got, []string{"endpoint", "labels", "region"}) {
+		t.Fatalf("unexpected vars %v", got)
+	}
+}
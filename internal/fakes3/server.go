@@ -0,0 +1,219 @@
+// Package fakes3 serves a fake S3 with deterministic failure injection, so demos and
+// end-to-end tests of dashboards and alerts run without real credentials.
+package fakes3
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+// injectedError is an S3 error response the fake answers a failed request with
+type injectedError struct {
+	status int
+	code   string
+}
+
+// injectedErrors maps config.FakeS3Errors to responses; timeout and network are
+// simulated on the connection instead
+var injectedErrors = map[string]injectedError{
+	"access_denied":      {http.StatusForbidden, "AccessDenied"},
+	"invalid_key":        {http.StatusForbidden, "InvalidAccessKeyId"},
+	"signature_mismatch": {http.StatusForbidden, "SignatureDoesNotMatch"},
+	"expired_token":      {http.StatusBadRequest, "ExpiredToken"},
+	"clock_skew":         {http.StatusForbidden, "RequestTimeTooSkewed"},
+	"bucket_not_found":   {http.StatusNotFound, "NoSuchBucket"},
+	"throttled":          {http.StatusServiceUnavailable, "SlowDown"},
+	"internal_error":     {http.StatusInternalServerError, "InternalError"},
+}
+
+// Server is a fake S3. Every endpoint is served under its own path prefix, so failures
+// are injected per endpoint even when endpoints share a bucket.
+type Server struct {
+	failures map[string]config.FakeS3Failure
+	proxy    *httputil.ReverseProxy // forwards to the target; nil serves from memory
+	store    *store
+
+	mu       sync.Mutex
+	requests map[string]int // requests subject to each endpoint's failure
+	url      string
+	server   *http.Server
+}
+
+// New creates a fake S3 injecting cfg's failures
+func New(cfg config.FakeS3Config) (*Server, error) {
+	s := &Server{
+		failures: cfg.Failures,
+		store:    newStore(),
+		requests: make(map[string]int),
+	}
+	if cfg.Target != "" {
+		target, err := url.Parse(cfg.Target)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid fake S3 target %q", cfg.Target)
+		}
+		s.proxy = &httputil.ReverseProxy{Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = target.Host
+		}}
+	}
+	return s, nil
+}
+
+// Listen serves the fake on addr in the background and returns its URL
+func (s *Server) Listen(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.mu.Lock()
+	s.url = "http://" + listener.Addr().String()
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	server := s.server
+	s.mu.Unlock()
+
+	go server.Serve(listener)
+	return s.url, nil
+}
+
+// Close stops serving
+func (s *Server) Close() error {
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Close()
+}
+
+// Rewrite points an endpoint at the fake, which accepts any keys. The bucket, probe
+// settings and checks are kept; the transport settings that only make sense against the
+// real endpoint are dropped.
+func (s *Server) Rewrite(endpoint config.S3EndpointConfig) config.S3EndpointConfig {
+	s.mu.Lock()
+	base := s.url
+	s.mu.Unlock()
+
+	endpoint.Endpoint = base + "/" + endpointToken(endpoint.Name)
+	endpoint.UsePathStyle = true
+	endpoint.UseAccelerate = false
+	endpoint.UseDualStack = false
+	endpoint.UseFIPSEndpoint = false
+	endpoint.FallbackRegions = nil
+	endpoint.DNSServers = nil
+	endpoint.Resolve = nil
+	endpoint.SOCKS5Proxy = nil
+	endpoint.RoleARN = ""
+	endpoint.KeyAgeFromIAM = false
+	return endpoint
+}
+
+// endpointToken encodes an endpoint name as a single path segment
+func endpointToken(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+// ServeHTTP answers a request made to /<endpoint token>/<bucket>[/<key>]
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	name, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "unknown endpoint")
+		return
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	op := operation(r, key)
+
+	if failure, ok := s.failures[string(name)]; ok && s.inject(r.Context(), w, string(name), op, failure) {
+		return
+	}
+
+	if s.proxy != nil {
+		r.URL.Path = "/" + rest
+		r.URL.RawPath = ""
+		s.proxy.ServeHTTP(w, r)
+		return
+	}
+	s.store.serve(w, r, op, bucket, key)
+}
+
+// inject applies an endpoint's failure to a request and reports whether it answered it
+func (s *Server) inject(ctx context.Context, w http.ResponseWriter, name, op string, failure config.FakeS3Failure) bool {
+	if len(failure.Operations) > 0 && !slices.Contains(failure.Operations, op) {
+		return false
+	}
+	if failure.Latency > 0 {
+		select {
+		case <-time.After(time.Duration(failure.Latency)):
+		case <-ctx.Done():
+			return true
+		}
+	}
+	if failure.Error == "" || !s.due(name, failure.Every) {
+		return false
+	}
+
+	switch failure.Error {
+	case "timeout":
+		// Hold the request until the client gives up
+		<-ctx.Done()
+	case "network":
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	default:
+		injected := injectedErrors[failure.Error]
+		writeError(w, injected.status, injected.code, "injected by the fake S3")
+	}
+	return true
+}
+
+// due counts a request against an endpoint's failure and reports whether it fails:
+// every Nth request does, or all of them when every is 0 or 1
+func (s *Server) due(name string, every int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[name]++
+	return every <= 1 || s.requests[name]%every == 0
+}
+
+// operation names the S3 operation of a request the way failures select them
+func operation(r *http.Request, key string) string {
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		return "HeadBucket"
+	case key == "" && r.Method == http.MethodGet && query.Has("location"):
+		return "GetBucketLocation"
+	case key == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		return "ListObjectsV2"
+	case key == "" && r.Method == http.MethodGet && len(query) == 0:
+		return "ListObjects"
+	case key == "":
+		return "Unsupported"
+	case r.Method == http.MethodGet:
+		return "GetObject"
+	case r.Method == http.MethodHead:
+		return "HeadObject"
+	case r.Method == http.MethodPut:
+		return "PutObject"
+	case r.Method == http.MethodDelete:
+		return "DeleteObject"
+	}
+	return "Unsupported"
+}
@@ -0,0 +1,108 @@
+package fakes3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+)
+
+// startFake serves a fake S3 with failures and returns a validator factory for it
+func startFake(t *testing.T, cfg config.FakeS3Config) func(name string) *s3.S3Validator {
+	t.Helper()
+	fake, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new fake: %v", err)
+	}
+	if _, err := fake.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { fake.Close() })
+
+	return func(name string) *s3.S3Validator {
+		endpoint := fake.Rewrite(config.S3EndpointConfig{Name: name, Bucket: "shared", Region: "us-east-1", Endpoint: "https://s3.amazonaws.com", AccessKey: "demo", SecretKey: "demo"})
+		return s3.NewS3Validator(endpoint.Endpoint, endpoint.Region, endpoint.Bucket, endpoint.AccessKey, endpoint.SecretKey, "", endpoint.UsePathStyle, false)
+	}
+}
+
+func TestFakeServesProbes(t *testing.T) {
+	validator := startFake(t, config.FakeS3Config{})("team/prod")
+
+	if result := validator.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected a shallow probe to pass, got %+v", result)
+	}
+	if result := validator.ValidateDeep(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected a deep probe to write, read and delete, got %+v", result)
+	}
+	read := validator.ValidateWith(context.Background(), 5*time.Second, s3.ProbeOptions{Operation: s3.OperationGetObject})
+	if !read.IsValid {
+		t.Fatalf("expected a read of a missing key to pass, got %+v", read)
+	}
+}
+
+func TestFakeInjectsFailuresPerEndpoint(t *testing.T) {
+	validatorFor := startFake(t, config.FakeS3Config{Failures: map[string]config.FakeS3Failure{
+		"denied":  {Error: "access_denied"},
+		"flaky":   {Error: "access_denied", Every: 2},
+		"no-put":  {Error: "invalid_key", Operations: []string{"PutObject"}},
+		"stalled": {Error: "timeout"},
+	}})
+	ctx := context.Background()
+
+	if result := validatorFor("denied").ValidateKeys(ctx, 5*time.Second); result.IsValid || result.ErrorType != "access_denied" {
+		t.Fatalf("expected access_denied, got %+v", result)
+	}
+	if result := validatorFor("healthy").ValidateKeys(ctx, 5*time.Second); !result.IsValid {
+		t.Fatalf("expected endpoints sharing the bucket to be unaffected, got %+v", result)
+	}
+
+	flaky := validatorFor("flaky")
+	var outcomes []bool
+	for range 4 {
+		outcomes = append(outcomes, flaky.ValidateKeys(ctx, 5*time.Second).IsValid)
+	}
+	if !outcomes[0] || outcomes[1] || !outcomes[2] || outcomes[3] {
+		t.Fatalf("expected every second request to fail, got %v", outcomes)
+	}
+
+	noPut := validatorFor("no-put")
+	if result := noPut.ValidateKeys(ctx, 5*time.Second); !result.IsValid {
+		t.Fatalf("expected listing to pass when only writes fail, got %+v", result)
+	}
+	if result := noPut.ValidateDeep(ctx, 5*time.Second); result.IsValid || result.ErrorType != "access_denied" {
+		t.Fatalf("expected the write to fail, got %+v", result)
+	}
+
+	if result := validatorFor("stalled").ValidateKeys(ctx, 200*time.Millisecond); result.IsValid || result.ErrorType != "timeout" {
+		t.Fatalf("expected a timeout, got %+v", result)
+	}
+}
+
+func TestFakeForwardsToTarget(t *testing.T) {
+	var paths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<ListBucketResult><Name>shared</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+	}))
+	defer target.Close()
+
+	validatorFor := startFake(t, config.FakeS3Config{Target: target.URL, Failures: map[string]config.FakeS3Failure{
+		"denied": {Error: "access_denied"},
+	}})
+
+	if result := validatorFor("localstack").ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected the target's answer, got %+v", result)
+	}
+	if result := validatorFor("denied").ValidateKeys(context.Background(), 5*time.Second); result.IsValid {
+		t.Fatalf("expected the failure to be injected before forwarding")
+	}
+	if len(paths) != 1 || !strings.HasPrefix(paths[0], "/shared") {
+		t.Fatalf("expected one forwarded request without the endpoint prefix, got %v", paths)
+	}
+}
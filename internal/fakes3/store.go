@@ -0,0 +1,196 @@
+package fakes3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// object is a stored object
+type object struct {
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+// store keeps objects in memory. Buckets exist as soon as they are used, so any
+// configured bucket can be probed without setting it up first.
+type store struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]object
+}
+
+func newStore() *store {
+	return &store{buckets: make(map[string]map[string]object)}
+}
+
+// listBucketResult is the ListObjectsV2 response
+type listBucketResult struct {
+	XMLName     xml.Name       `xml:"ListBucketResult"`
+	Name        string         `xml:"Name"`
+	Prefix      string         `xml:"Prefix"`
+	KeyCount    int            `xml:"KeyCount"`
+	MaxKeys     int            `xml:"MaxKeys"`
+	IsTruncated bool           `xml:"IsTruncated"`
+	Contents    []listedObject `xml:"Contents"`
+}
+
+type listedObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// serve answers an operation on the stored objects
+func (s *store) serve(w http.ResponseWriter, r *http.Request, op, bucket, key string) {
+	switch op {
+	case "HeadBucket":
+		w.WriteHeader(http.StatusOK)
+	case "GetBucketLocation":
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+	case "ListObjects", "ListObjectsV2":
+		s.list(w, r, bucket)
+	case "GetObject", "HeadObject":
+		s.get(w, r, op, bucket, key)
+	case "PutObject":
+		s.put(w, r, bucket, key)
+	case "DeleteObject":
+		s.mu.Lock()
+		delete(s.buckets[bucket], key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "the fake S3 only serves the operations probes make")
+	}
+}
+
+// list answers a listing of up to max-keys objects under prefix
+func (s *store) list(w http.ResponseWriter, r *http.Request, bucket string) {
+	prefix := r.URL.Query().Get("prefix")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(r.URL.Query().Get("max-keys")); err == nil && n >= 0 && n < maxKeys {
+		maxKeys = n
+	}
+
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	result := listBucketResult{Name: bucket, Prefix: prefix, MaxKeys: maxKeys, IsTruncated: len(keys) > maxKeys}
+	for _, key := range keys[:min(len(keys), maxKeys)] {
+		obj := s.buckets[bucket][key]
+		result.Contents = append(result.Contents, listedObject{
+			Key:          key,
+			LastModified: obj.modified.UTC().Format(time.RFC3339),
+			ETag:         obj.etag,
+			Size:         len(obj.body),
+			StorageClass: "STANDARD",
+		})
+	}
+	s.mu.Unlock()
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(result)
+}
+
+// get answers a read of an object, honoring single byte ranges
+func (s *store) get(w http.ResponseWriter, r *http.Request, op, bucket, key string) {
+	s.mu.Lock()
+	obj, ok := s.buckets[bucket][key]
+	s.mu.Unlock()
+	if !ok {
+		if op == "HeadObject" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if op == "HeadObject" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.body)))
+		return
+	}
+	http.ServeContent(w, r, key, obj.modified, bytes.NewReader(obj.body))
+}
+
+// put stores an object
+func (s *store) put(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	sum := md5.Sum(body)
+	obj := object{body: body, etag: `"` + hex.EncodeToString(sum[:]) + `"`, modified: time.Now()}
+
+	s.mu.Lock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]object)
+	}
+	s.buckets[bucket][key] = obj
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", obj.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// readBody reads a request body, decoding the aws-chunked encoding the SDK uses to
+// send trailing checksums
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read chunk header: %w", err)
+		}
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q", size)
+		}
+		if n == 0 {
+			// The trailers that follow only carry checksums
+			return body, nil
+		}
+		chunk := make([]byte, n+2) // the chunk and its CRLF
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, fmt.Errorf("read chunk: %w", err)
+		}
+		body = append(body, chunk[:n]...)
+	}
+}
+
+// writeError answers with an S3 XML error
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, message)
+}
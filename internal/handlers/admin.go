@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigLintResponse lists the risky settings found in the loaded configuration
+type ConfigLintResponse struct {
+	Warnings []config.Warning `json:"warnings"`
+}
+
+// NewAdminConfigHandler returns a handler reporting the configuration warnings found
+// at startup. It never includes settings themselves, so no credentials can leak.
+func NewAdminConfigHandler(warnings []config.Warning, log *logrus.Logger) http.HandlerFunc {
+	response := ConfigLintResponse{Warnings: warnings}
+	if response.Warnings == nil {
+		response.Warnings = []config.Warning{}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode config warnings: %v", err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAdminConfigHandler(t *testing.T) {
+	warnings := []config.Warning{{Reason: config.WarningSharedCredentials, Endpoint: "a", Message: "shared"}}
+	rr := httptest.NewRecorder()
+	NewAdminConfigHandler(warnings, logrus.New())(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+
+	var response ConfigLintResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK || len(response.Warnings) != 1 || response.Warnings[0].Reason != config.WarningSharedCredentials {
+		t.Fatalf("unexpected response: %d %+v", rr.Code, response)
+	}
+
+	rr = httptest.NewRecorder()
+	NewAdminConfigHandler(nil, logrus.New())(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if body := rr.Body.String(); body != "{\"warnings\":[]}\n" {
+		t.Fatalf("expected an empty list without warnings, got %s", body)
+	}
+}
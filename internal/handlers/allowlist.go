@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/netip"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// allowlistedPublicRoutes skip authentication but still make the exporter send S3
+// requests, so they are limited to allowed clients like the validation routes.
+// /slack/command is exempt: Slack calls it from its own, changing addresses, and the
+// signing secret already proves the request came from Slack.
+var allowlistedPublicRoutes = map[string]bool{
+	"POST /validate/credentials": true,
+}
+
+// WithClientAllowlist wraps next so the routes that run validations or probes, submitted
+// credential checks included, and the /admin routes only serve clients in allowed,
+// answering others with 403. Reading results, /metrics, /health and /slack/command stay
+// reachable. Behind a proxy, wrap the result with WithTrustedProxies so the real client
+// is checked.
+func WithClientAllowlist(next http.Handler, allowed []netip.Prefix, m *metrics.Metrics, log *logrus.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission, required := requiredPermission(r)
+		restricted := required && permission != auth.PermissionRead || allowlistedPublicRoutes[r.Method+" "+r.URL.Path]
+		if !restricted {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, ok := clientAddr(r)
+		if !ok || !containsAddr(allowed, client) {
+			log.WithFields(logrus.Fields{
+				"client": clientIP(r),
+				"path":   r.URL.Path,
+			}).Warn("Refused request from a client outside the allowlist")
+			m.RecordAuthRejected("client_ip")
+			http.Error(w, "client address not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
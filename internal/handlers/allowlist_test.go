@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithClientAllowlist(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}
+	handler := WithTrustedProxies(WithClientAllowlist(next, allowed, metrics.Default, logrus.New()), trusted)
+
+	tests := []struct {
+		name, method, path, remote, forwarded string
+		want                                  int
+	}{
+		{"allowed client", http.MethodPost, "/validate", "10.20.1.5:4000", "", http.StatusOK},
+		{"other client", http.MethodPost, "/validate", "192.168.1.5:4000", "", http.StatusForbidden},
+		{"endpoint route", http.MethodGet, "/validate/bucket-a", "192.168.1.5:4000", "", http.StatusForbidden},
+		{"admin route", http.MethodGet, "/admin/config", "192.168.1.5:4000", "", http.StatusForbidden},
+		{"discovery", http.MethodPost, "/endpoints/bucket-a/discover", "192.168.1.5:4000", "", http.StatusForbidden},
+		{"cached results", http.MethodGet, "/validate", "192.168.1.5:4000", "", http.StatusOK},
+		{"metrics", http.MethodGet, "/metrics", "192.168.1.5:4000", "", http.StatusOK},
+		{"slack", http.MethodPost, "/slack/command", "192.168.1.5:4000", "", http.StatusOK},
+		{"submitted credentials", http.MethodPost, "/validate/credentials", "192.168.1.5:4000", "", http.StatusForbidden},
+		{"submitted credentials from allowed client", http.MethodPost, "/validate/credentials", "10.20.1.5:4000", "", http.StatusOK},
+		{"behind trusted proxy", http.MethodPost, "/validate", "10.0.0.2:4000", "10.20.1.5", http.StatusOK},
+		{"through two trusted proxies", http.MethodPost, "/validate", "10.0.0.2:4000", "10.20.1.5, 10.0.0.3", http.StatusOK},
+		{"outside client behind trusted proxy", http.MethodPost, "/validate", "10.0.0.2:4000", "192.168.1.5", http.StatusForbidden},
+		{"spoofed hop before the real client", http.MethodPost, "/validate", "10.0.0.2:4000", "10.20.1.5, 192.168.1.5", http.StatusForbidden},
+		{"header from an untrusted peer", http.MethodPost, "/validate", "192.168.1.5:4000", "10.20.1.5", http.StatusForbidden},
+		{"IPv4-mapped address", http.MethodPost, "/validate", "[::ffff:10.20.1.5]:4000", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, rr.Code)
+		}
+	}
+}
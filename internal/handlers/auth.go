@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// publicRoutes are served without authentication: probes, the public key responses are
+// verified with, and routes that verify their callers themselves
+var publicRoutes = map[string]bool{
+	"GET /health":                true,
+	"HEAD /health":               true,
+	"GET /signing-key":           true,
+	"POST /slack/command":        true,
+	"POST /validate/credentials": true,
+}
+
+// requiredPermission returns the permission a request needs, or false for public routes
+func requiredPermission(r *http.Request) (auth.Permission, bool) {
+	path := r.URL.Path
+	if r.Method == http.MethodOptions || publicRoutes[r.Method+" "+path] {
+		return "", false
+	}
+	switch {
+	case strings.HasPrefix(path, "/admin/"):
+		return auth.PermissionAdmin, true
+	case path == "/validate" && r.Method == http.MethodPost,
+		strings.HasPrefix(path, "/validate/"),
+		strings.HasPrefix(path, "/endpoints/") && strings.HasSuffix(path, "/discover"):
+		return auth.PermissionValidate, true
+	default:
+		return auth.PermissionRead, true
+	}
+}
+
+// AuthOption customizes WithAuth
+type AuthOption func(*authSettings)
+
+type authSettings struct {
+	authorizer auth.Authorizer
+	metrics    *metrics.Metrics
+}
+
+// WithAuthorizer replaces the permission check, e.g. with RBAC limiting roles to some
+// routes and endpoints
+func WithAuthorizer(authorizer auth.Authorizer) AuthOption {
+	return func(s *authSettings) {
+		s.authorizer = authorizer
+	}
+}
+
+// WithAuthMetrics records refused requests into m instead of the collectors registered
+// on the Prometheus default registry
+func WithAuthMetrics(m *metrics.Metrics) AuthOption {
+	return func(s *authSettings) {
+		s.metrics = m
+	}
+}
+
+// WithAuth wraps mux so every route but /health and the routes verifying callers
+// themselves requires a principal from authn: /admin needs admin, the routes that run
+// validations or probes need validate, and everything else, /metrics included, needs
+// read. Callers without valid credentials get 401, callers not authorized 403, and 503
+// is served when authn cannot check credentials at all, e.g. because the identity
+// provider is unreachable.
+func WithAuth(mux *http.ServeMux, authn auth.Authenticator, log *logrus.Logger, opts ...AuthOption) http.Handler {
+	settings := authSettings{authorizer: auth.PermissionAuthorizer{}, metrics: metrics.Default}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission, required := requiredPermission(r)
+		if !required {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := authn.Authenticate(r)
+		switch {
+		case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+			log.WithError(err).WithFields(logrus.Fields{
+				"client": clientIP(r),
+				"path":   r.URL.Path,
+			}).Debug("Rejected unauthenticated request")
+			settings.metrics.RecordAuthRejected("unauthenticated")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="key-aws-exporter"`)
+			http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+			return
+		case err != nil:
+			log.WithError(err).Error("Failed to authenticate request")
+			settings.metrics.RecordAuthRejected("unavailable")
+			http.Error(w, "authentication is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		_, route := mux.Handler(r)
+		req := auth.Request{Route: route, Endpoint: routeEndpoint(route, r), Permission: permission}
+		if !settings.authorizer.Authorize(principal, req) {
+			log.WithFields(logrus.Fields{
+				"subject":    principal.Subject,
+				"client":     clientIP(r),
+				"route":      route,
+				"endpoint":   req.Endpoint,
+				"permission": permission,
+			}).Info("Refused unauthorized request")
+			settings.metrics.RecordAuthRejected("forbidden")
+			http.Error(w, "not authorized for this route", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// routeEndpoint returns the endpoint a request targets: the {endpoint} segment of its
+// route, or the endpoint query parameter of routes without one
+func routeEndpoint(route string, r *http.Request) string {
+	if _, routePath, ok := strings.Cut(route, " "); ok {
+		route = routePath
+	}
+	requestSegments := strings.Split(r.URL.EscapedPath(), "/")
+	for i, segment := range strings.Split(route, "/") {
+		if segment != "{endpoint}" || i >= len(requestSegments) {
+			continue
+		}
+		if name, err := url.PathUnescape(requestSegments[i]); err == nil {
+			return name
+		}
+	}
+	return r.URL.Query().Get("endpoint")
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"key-aws-exporter/internal/auth"
+
+	"github.com/sirupsen/logrus"
+)
+
+// tokenAuthenticator maps bearer tokens to principals
+type tokenAuthenticator map[string]*auth.Principal
+
+func (a tokenAuthenticator) Authenticate(r *http.Request) (*auth.Principal, error) {
+	token, ok := auth.BearerToken(r)
+	if !ok {
+		return nil, auth.ErrNoCredentials
+	}
+	if token == "broken" {
+		return nil, errors.New("identity provider unreachable")
+	}
+	principal, ok := a[token]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown token", auth.ErrInvalidCredentials)
+	}
+	return principal, nil
+}
+
+func principalWith(permissions ...auth.Permission) *auth.Principal {
+	p := &auth.Principal{Subject: "svc", Permissions: make(map[auth.Permission]bool)}
+	for _, permission := range permissions {
+		p.Permissions[permission] = true
+	}
+	return p
+}
+
+// authTestMux serves the routes the tests call, recording the subject of the last principal
+func authTestMux() (*http.ServeMux, *string) {
+	var subject string
+	mux := http.NewServeMux()
+	for _, route := range []string{
+		"GET /health", "POST /slack/command", "POST /validate/credentials", "GET /metrics", "GET /validate",
+		"POST /validate", "GET /validate/{endpoint}", "POST /validate/{endpoint}", "POST /endpoints/{endpoint}/discover",
+		"GET /history", "GET /admin/config",
+	} {
+		mux.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+				subject = principal.Subject
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+	return mux, &subject
+}
+
+func TestWithAuth(t *testing.T) {
+	authn := tokenAuthenticator{
+		"viewer":   principalWith(auth.PermissionRead),
+		"operator": principalWith(auth.PermissionRead, auth.PermissionValidate),
+		"admin":    principalWith(auth.PermissionAdmin),
+	}
+	mux, subject := authTestMux()
+	handler := WithAuth(mux, authn, logrus.New())
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodGet, "/health", "", http.StatusOK},
+		{http.MethodPost, "/slack/command", "", http.StatusOK},
+		{http.MethodPost, "/validate/credentials", "", http.StatusOK},
+		{http.MethodGet, "/metrics", "", http.StatusUnauthorized},
+		{http.MethodGet, "/metrics", "unknown", http.StatusUnauthorized},
+		{http.MethodGet, "/metrics", "broken", http.StatusServiceUnavailable},
+		{http.MethodGet, "/metrics", "viewer", http.StatusOK},
+		{http.MethodGet, "/validate", "viewer", http.StatusOK},
+		{http.MethodPost, "/validate", "viewer", http.StatusForbidden},
+		{http.MethodGet, "/validate/bucket-a", "viewer", http.StatusForbidden},
+		{http.MethodPost, "/endpoints/bucket-a/discover", "viewer", http.StatusForbidden},
+		{http.MethodPost, "/validate/bucket-a", "operator", http.StatusOK},
+		{http.MethodGet, "/admin/config", "operator", http.StatusForbidden},
+		{http.MethodGet, "/admin/config", "admin", http.StatusOK},
+		{http.MethodPost, "/validate", "admin", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rr := serveWithToken(handler, tt.method, tt.path, tt.token)
+		if rr.Code != tt.want {
+			t.Errorf("%s %s with %q: expected %d, got %d", tt.method, tt.path, tt.token, tt.want, rr.Code)
+		}
+		if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: expected a WWW-Authenticate challenge", tt.method, tt.path)
+		}
+	}
+	if *subject != "svc" {
+		t.Fatalf("expected the principal to reach the handler, got %q", *subject)
+	}
+}
+
+func serveWithToken(handler http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestWithAuthRBAC(t *testing.T) {
+	authn := tokenAuthenticator{
+		"team-a":   {Subject: "a", Roles: []string{"team-a"}},
+		"platform": {Subject: "p", Roles: []string{"viewers", "platform"}},
+		"nobody":   {Subject: "n", Roles: []string{"unknown"}},
+	}
+	rbac := auth.NewRBAC(map[string]auth.Role{
+		"team-a": {
+			Routes:    []string{"GET /validate/{endpoint}", "POST /validate/{endpoint}", "GET /history", auth.AllRoutes},
+			Endpoints: []string{"team-a-*"},
+		},
+		"platform": {Routes: []string{auth.AllRoutes}},
+	})
+	mux, _ := authTestMux()
+	handler := WithAuth(mux, authn, logrus.New(), WithAuthorizer(rbac))
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodPost, "/validate/team-a-logs", "team-a", http.StatusOK},
+		{http.MethodPost, "/validate/team%2Da-logs", "team-a", http.StatusOK},
+		{http.MethodPost, "/validate/team-b-logs", "team-a", http.StatusForbidden},
+		{http.MethodPost, "/endpoints/team-a-logs/discover", "team-a", http.StatusOK},
+		{http.MethodPost, "/endpoints/team-b-logs/discover", "team-a", http.StatusForbidden},
+		{http.MethodGet, "/history?endpoint=team-a-logs", "team-a", http.StatusOK},
+		{http.MethodGet, "/history?endpoint=team-b-logs", "team-a", http.StatusForbidden},
+		{http.MethodGet, "/history", "team-a", http.StatusOK},
+		{http.MethodGet, "/validate", "team-a", http.StatusForbidden},
+		{http.MethodGet, "/admin/config", "team-a", http.StatusForbidden},
+		{http.MethodGet, "/admin/config", "platform", http.StatusOK},
+		{http.MethodPost, "/validate/team-b-logs", "platform", http.StatusOK},
+		{http.MethodGet, "/metrics", "nobody", http.StatusForbidden},
+		{http.MethodGet, "/health", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rr := serveWithToken(handler, tt.method, tt.path, tt.token); rr.Code != tt.want {
+			t.Errorf("%s %s with %q: expected %d, got %d", tt.method, tt.path, tt.token, tt.want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EndpointStatusResponse is the latest state of one configured endpoint
+type EndpointStatusResponse struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	CheckedAt string `json:"checked_at,omitempty"`
+	ErrorType string `json:"error_type,omitempty"`
+	// Annotations are the endpoint's configured owner, runbook_url and other notes
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type EndpointsResponse struct {
+	Endpoints []EndpointStatusResponse `json:"endpoints"`
+}
+
+// NewCachedResultsHandler returns a handler serving the latest recorded result of every
+// endpoint without probing, for dashboards that poll GET /validate. Endpoints that have
+// not been validated yet are left out. Responses carry an ETag and Last-Modified, so
+// conditional requests get 304 until a new result is recorded.
+func NewCachedResultsHandler(manager ReportSource, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		response := MultiValidationResponse{Results: make(map[string]ValidationResponse)}
+		for _, name := range manager.GetEndpoints() {
+			entries := manager.History(name)
+			if len(entries) == 0 {
+				continue
+			}
+			latest := entries[len(entries)-1]
+			result := &s3.ValidationResult{
+				IsValid:        latest.IsValid,
+				Message:        latest.Message,
+				CheckedAt:      latest.CheckedAt,
+				ResponseTimeMs: latest.ResponseTimeMs,
+				ErrorType:      latest.ErrorType,
+			}
+			validation := newValidationResponse(result)
+			validation.Latency = latencyFor(manager, name)
+			validation.Annotations = annotationsFor(manager, name)
+			response.Results[name] = validation
+			response.Summary.count(result)
+			if latest.CheckedAt.After(response.Timestamp) {
+				response.Timestamp = latest.CheckedAt.UTC()
+			}
+		}
+
+		writeCachedJSON(w, r, log, response, response, response.Timestamp)
+	}
+}
+
+// NewEndpointsHandler returns a handler listing the configured endpoints with their
+// latest status: valid, invalid or unchecked. Like GET /history it supports conditional
+// requests.
+func NewEndpointsHandler(manager ReportSource, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		response := EndpointsResponse{Endpoints: []EndpointStatusResponse{}}
+		var modified time.Time
+		for _, name := range manager.GetEndpoints() {
+			status := EndpointStatusResponse{Name: name, Status: "unchecked", Annotations: annotationsFor(manager, name)}
+			if entries := manager.History(name); len(entries) > 0 {
+				latest := entries[len(entries)-1]
+				status.Status = "invalid"
+				if latest.IsValid {
+					status.Status = "valid"
+				}
+				status.CheckedAt = latest.CheckedAt.UTC().Format(time.RFC3339)
+				status.ErrorType = latest.ErrorType
+				if latest.CheckedAt.After(modified) {
+					modified = latest.CheckedAt
+				}
+			}
+			response.Endpoints = append(response.Endpoints, status)
+		}
+
+		writeCachedJSON(w, r, log, response, response, modified)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+func getWith(handler http.HandlerFunc, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+func TestHistoryHandlerConditionalRequests(t *testing.T) {
+	manager := newStubHistoryManager()
+	handler := NewHistoryHandler(manager, logrus.New())
+
+	first := getWith(handler, "/history", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" || first.Header().Get("Last-Modified") != time.Unix(1730000060, 0).UTC().Format(http.TimeFormat) {
+		t.Fatalf("expected an ETag and the latest check as Last-Modified, got %v", first.Header())
+	}
+
+	if rr := getWith(handler, "/history", map[string]string{"If-None-Match": etag}); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("expected 304 without a body for a current ETag, got %d", rr.Code)
+	}
+	if rr := getWith(handler, "/history", map[string]string{"If-None-Match": `"other", W/` + etag}); rr.Code != http.StatusNotModified {
+		t.Fatalf("expected any listed ETag to match, got %d", rr.Code)
+	}
+	if rr := getWith(handler, "/history", map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")}); rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 when nothing changed since Last-Modified, got %d", rr.Code)
+	}
+
+	manager.history["bucket-a"] = append(manager.history["bucket-a"], exporter.HistoryEntry{CheckedAt: time.Unix(1730000120, 0), IsValid: true})
+	if rr := getWith(handler, "/history", map[string]string{"If-None-Match": etag}); rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Fatalf("expected a new result to change the ETag, got %d", rr.Code)
+	}
+}
+
+func TestCachedResultsHandler(t *testing.T) {
+	manager := &stubReportSource{stubHistoryManager: newStubHistoryManager(), endpoints: []string{"bucket-a", "bucket-b"}}
+	handler := NewCachedResultsHandler(manager, logrus.New())
+
+	rr := getWith(handler, "/validate", nil)
+	var response MultiValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Results) != 1 || response.Results["bucket-a"].IsValid || response.Results["bucket-a"].ErrorType != "timeout" {
+		t.Fatalf("expected the latest result of the validated endpoint only, got %+v", response.Results)
+	}
+	if response.Summary.TotalEndpoints != 1 || response.Summary.Failed != 1 || !response.Timestamp.Equal(time.Unix(1730000060, 0)) {
+		t.Fatalf("unexpected summary or timestamp: %+v %s", response.Summary, response.Timestamp)
+	}
+	if manager.validateEndpointFunc != nil || manager.validateAllFunc != nil {
+		t.Fatalf("expected cached results not to validate")
+	}
+
+	if again := getWith(handler, "/validate", map[string]string{"If-None-Match": rr.Header().Get("ETag")}); again.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for unchanged results, got %d", again.Code)
+	}
+}
+
+func TestEndpointsHandler(t *testing.T) {
+	manager := &stubReportSource{stubHistoryManager: newStubHistoryManager(), endpoints: []string{"bucket-a", "bucket-b"}}
+	handler := NewEndpointsHandler(manager, logrus.New())
+
+	rr := getWith(handler, "/endpoints", nil)
+	var response EndpointsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Endpoints) != 2 || response.Endpoints[0].Status != "invalid" || response.Endpoints[1].Status != "unchecked" {
+		t.Fatalf("unexpected endpoints: %+v", response.Endpoints)
+	}
+
+	etag := rr.Header().Get("ETag")
+	if again := getWith(handler, "/endpoints", map[string]string{"If-None-Match": etag}); again.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for an unchanged list, got %d", again.Code)
+	}
+	manager.endpoints = append(manager.endpoints, "bucket-c")
+	if again := getWith(handler, "/endpoints", map[string]string{"If-None-Match": etag}); again.Code != http.StatusOK {
+		t.Fatalf("expected a new endpoint to invalidate the ETag, got %d", again.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientAddrKey struct{}
+
+// WithTrustedProxies wraps next so rate limiting, the client allowlist and logs see the
+// real client of requests relayed by the proxies in trusted. Forwarding headers are only
+// believed on connections from a trusted proxy: X-Forwarded-For is read from the right,
+// skipping trusted hops, so entries a client prepended itself are never used; without
+// it, X-Real-IP names the client.
+func WithTrustedProxies(next http.Handler, trusted []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := realClientAddr(r, trusted); ok {
+			r = r.WithContext(context.WithValue(r.Context(), clientAddrKey{}, addr))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP identifies the client a request is accounted to and logged as
+func clientIP(r *http.Request) string {
+	if addr, ok := r.Context().Value(clientAddrKey{}).(netip.Addr); ok {
+		return addr.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientAddr is clientIP parsed, false when the address is not an IP
+func clientAddr(r *http.Request) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(clientIP(r))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// realClientAddr returns the address of the client behind the proxies in trusted
+func realClientAddr(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	addr, ok := remoteAddr(r)
+	if !ok || !containsAddr(trusted, addr) {
+		return addr, ok
+	}
+	forwarded := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	if forwarded == "" {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap(), true
+		}
+		return addr, true
+	}
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !containsAddr(trusted, addr) {
+			break
+		}
+	}
+	return addr, true
+}
+
+// remoteAddr parses the address of the connection
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
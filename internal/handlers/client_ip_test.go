@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+func TestWithTrustedProxies(t *testing.T) {
+	var seen string
+	handler := WithTrustedProxies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = clientIP(r)
+	}), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24"), netip.MustParsePrefix("fd00::/8")})
+
+	tests := []struct {
+		name, remote string
+		headers      map[string]string
+		want         string
+	}{
+		{"direct client", "192.168.1.5:4000", nil, "192.168.1.5"},
+		{"untrusted peer", "192.168.1.5:4000", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"}, "192.168.1.5"},
+		{"forwarded for", "10.0.0.2:4000", map[string]string{"X-Forwarded-For": "203.0.113.9"}, "203.0.113.9"},
+		{"spoofed first hop", "10.0.0.2:4000", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9, 10.0.0.3"}, "203.0.113.9"},
+		{"forwarded for wins over real IP", "10.0.0.2:4000", map[string]string{"X-Forwarded-For": "203.0.113.9", "X-Real-IP": "1.2.3.4"}, "203.0.113.9"},
+		{"real IP", "10.0.0.2:4000", map[string]string{"X-Real-IP": "203.0.113.9"}, "203.0.113.9"},
+		{"IPv6 proxy", "[fd00::1]:4000", map[string]string{"X-Real-IP": "2001:db8::7"}, "2001:db8::7"},
+		{"trusted proxy without headers", "10.0.0.2:4000", nil, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/validate", nil)
+		req.RemoteAddr = tt.remote
+		for name, value := range tt.headers {
+			req.Header.Set(name, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != tt.want {
+			t.Errorf("%s: expected client %s, got %s", tt.name, tt.want, seen)
+		}
+	}
+}
+
+func TestRateLimitUsesRealClient(t *testing.T) {
+	limiter := newRateLimiter(1, 1, clock.NewFake(time.Unix(0, 0)))
+	handler := WithTrustedProxies(limiter.limit("validate", func(w http.ResponseWriter, r *http.Request) {}),
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")})
+
+	request := func(client string) int {
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", client)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if request("203.0.113.1") != http.StatusOK || request("203.0.113.2") != http.StatusOK {
+		t.Fatal("expected clients behind the same proxy to have their own allowance")
+	}
+	if code := request("203.0.113.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the second request of a client to be limited, got %d", code)
+	}
+}
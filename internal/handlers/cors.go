@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used when CORSOptions leaves methods or headers empty
+var (
+	DefaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	DefaultCORSHeaders = []string{"Content-Type", IdempotencyKeyHeader, "If-None-Match"}
+)
+
+// corsExposedHeaders are the response headers dashboards read beyond the CORS-safelisted ones
+var corsExposedHeaders = strings.Join([]string{
+	"ETag", idempotentReplayedHeader, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}, ", ")
+
+// corsMaxAge is how long browsers may cache a preflight answer
+const corsMaxAge = 10 * time.Minute
+
+// CORSOptions configures which browser origins may call the API. An origin of "*"
+// allows any origin; credentials are never allowed.
+type CORSOptions struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+// WithCORS wraps next with CORS handling, so dashboards served from another origin can
+// call the API directly. Preflight requests are answered here; a preflight from an
+// origin, method or header that is not allowed gets 403. Requests without an Origin
+// header pass through untouched.
+func WithCORS(next http.Handler, opts CORSOptions) http.Handler {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSMethods
+	}
+	headers := opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSHeaders
+	}
+	allowedMethods := strings.Join(methods, ", ")
+	allowedHeaders := strings.Join(headers, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !anyOrigin {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		allowed := anyOrigin || slices.Contains(opts.AllowedOrigins, origin)
+		if !allowed {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) {
+			http.Error(w, "method not allowed by CORS policy", http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !slices.ContainsFunc(headers, func(allowed string) bool { return strings.EqualFold(allowed, header) }) {
+				http.Error(w, "header not allowed by CORS policy", http.StatusForbidden)
+				return
+			}
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+		w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsRequest(handler http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/validate", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestWithCORS(t *testing.T) {
+	var served int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := WithCORS(next, CORSOptions{AllowedOrigins: []string{"https://dash.example.com"}})
+
+	rr := corsRequest(handler, http.MethodPost, "https://dash.example.com", nil)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" || rr.Header().Get("Vary") != "Origin" {
+		t.Fatalf("expected the allowed origin to be echoed, got %v", rr.Header())
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Fatalf("expected ETag and replay headers to be exposed")
+	}
+
+	rr = corsRequest(handler, http.MethodGet, "https://evil.example.com", nil)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" || served != 2 {
+		t.Fatalf("expected other origins to be served without CORS headers, got %v", rr.Header())
+	}
+	if rr := corsRequest(handler, http.MethodGet, "", nil); rr.Header().Get("Access-Control-Allow-Origin") != "" || served != 3 {
+		t.Fatalf("expected same-origin requests to pass through untouched")
+	}
+
+	preflight := map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type, idempotency-key"}
+	rr = corsRequest(handler, http.MethodOptions, "https://dash.example.com", preflight)
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Methods") == "" || rr.Header().Get("Access-Control-Max-Age") == "" {
+		t.Fatalf("expected the preflight to be answered, got %d %v", rr.Code, rr.Header())
+	}
+	if served != 3 {
+		t.Fatalf("expected preflights not to reach the API")
+	}
+
+	for _, tc := range []struct {
+		origin  string
+		headers map[string]string
+	}{
+		{"https://evil.example.com", preflight},
+		{"https://dash.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"}},
+		{"https://dash.example.com", map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"}},
+	} {
+		if rr := corsRequest(handler, http.MethodOptions, tc.origin, tc.headers); rr.Code != http.StatusForbidden {
+			t.Fatalf("expected a rejected preflight for %s %v, got %d", tc.origin, tc.headers, rr.Code)
+		}
+	}
+}
+
+func TestWithCORSAnyOrigin(t *testing.T) {
+	handler := WithCORS(http.NotFoundHandler(), CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+
+	rr := corsRequest(handler, http.MethodGet, "https://any.example.com", nil)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Vary") != "" {
+		t.Fatalf("expected a wildcard origin without Vary, got %v", rr.Header())
+	}
+	if rr := corsRequest(handler, http.MethodOptions, "https://any.example.com", map[string]string{"Access-Control-Request-Method": "POST"}); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected configured methods to replace the defaults, got %d", rr.Code)
+	}
+}
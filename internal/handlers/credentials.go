@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxCredentialsBody bounds the POST /validate/credentials body
+const maxCredentialsBody = 16 << 10
+
+// CredentialsValidator is implemented by managers that validate credentials submitted
+// by other services without registering them
+type CredentialsValidator interface {
+	ValidateCredentials(ctx context.Context, endpointCfg config.S3EndpointConfig, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult
+}
+
+// CredentialsRequest is the POST /validate/credentials body: the credentials to check,
+// the bucket to check them against and, optionally, how to probe it
+type CredentialsRequest struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token,omitempty"`
+	Bucket       string `json:"bucket"`
+	Region       string `json:"region"`
+	// Endpoint is an S3-compatible service URL; empty uses AWS
+	Endpoint     string `json:"endpoint,omitempty"`
+	UsePathStyle bool   `json:"use_path_style,omitempty"`
+	ProbeOptionsRequest
+}
+
+// CredentialsAPIOptions configures who may call POST /validate/credentials and where the
+// submitted credentials may be sent
+type CredentialsAPIOptions struct {
+	// Tokens are the bearer tokens accepted from calling services
+	Tokens []string
+	// AllowedEndpoints lists the S3-compatible URLs a request may name; AWS is always
+	// allowed. Anything else is refused, so callers cannot make the exporter send
+	// requests to arbitrary hosts.
+	AllowedEndpoints []string
+}
+
+type credentialsHandler struct {
+	manager CredentialsValidator
+	tokens  [][]byte
+	allowed map[string]bool
+	log     *logrus.Logger
+}
+
+// NewValidateCredentialsHandler returns a handler validating short-lived credentials
+// submitted by another service against a bucket of its choice. Requests must carry one
+// of opts.Tokens as a bearer token. The verdict is served with 200 whatever its outcome,
+// so 401 and 403 keep meaning the caller was refused; it is never published to metrics,
+// history or result sinks.
+// Expected route: POST /validate/credentials
+func NewValidateCredentialsHandler(manager CredentialsValidator, opts CredentialsAPIOptions, log *logrus.Logger) http.HandlerFunc {
+	h := &credentialsHandler{
+		manager: manager,
+		allowed: make(map[string]bool, len(opts.AllowedEndpoints)),
+		log:     log,
+	}
+	for _, token := range opts.Tokens {
+		h.tokens = append(h.tokens, []byte(token))
+	}
+	for _, endpoint := range opts.AllowedEndpoints {
+		h.allowed[strings.TrimSuffix(endpoint, "/")] = true
+	}
+	return h.serve
+}
+
+func (h *credentialsHandler) serve(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="validate-credentials"`)
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var body CredentialsRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCredentialsBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	endpointCfg, err := h.endpointConfig(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	probe, timeout, err := body.ProbeOptionsRequest.parse()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if timeout > 0 {
+		extendWriteDeadline(w, timeout+config.ResponseWriteMargin)
+	}
+
+	result := h.manager.ValidateCredentials(r.Context(), endpointCfg, timeout, probe)
+	// The access key is left out: the verdict belongs to the caller, not the logs
+	h.log.WithFields(logrus.Fields{
+		"bucket":     endpointCfg.Bucket,
+		"valid":      result.IsValid,
+		"error_type": result.ErrorType,
+	}).Info("Validated submitted credentials")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(newValidationResponse(result)); err != nil {
+		h.log.Errorf("Failed to encode credentials validation response: %v", err)
+	}
+}
+
+// authorized reports whether the request carries one of the configured tokens, comparing
+// every token in constant time
+func (h *credentialsHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	matched := 0
+	for _, want := range h.tokens {
+		matched |= subtle.ConstantTimeCompare([]byte(token), want)
+	}
+	return matched == 1
+}
+
+// endpointConfig checks the request and turns it into the endpoint to validate
+func (h *credentialsHandler) endpointConfig(body CredentialsRequest) (config.S3EndpointConfig, error) {
+	switch {
+	case body.AccessKey == "" || body.SecretKey == "":
+		return config.S3EndpointConfig{}, fmt.Errorf("access_key and secret_key are required")
+	case body.Bucket == "":
+		return config.S3EndpointConfig{}, fmt.Errorf("bucket is required")
+	case body.Region == "":
+		return config.S3EndpointConfig{}, fmt.Errorf("region is required")
+	}
+	endpoint := strings.TrimSuffix(body.Endpoint, "/")
+	if endpoint != "" && !h.allowed[endpoint] {
+		return config.S3EndpointConfig{}, fmt.Errorf("endpoint %q is not allowed", body.Endpoint)
+	}
+	return config.S3EndpointConfig{
+		Name:         "submitted",
+		Endpoint:     endpoint,
+		Region:       body.Region,
+		Bucket:       body.Bucket,
+		AccessKey:    body.AccessKey,
+		SecretKey:    body.SecretKey,
+		SessionToken: body.SessionToken,
+		UsePathStyle: body.UsePathStyle,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type credentialsManager struct {
+	calls   int
+	cfg     config.S3EndpointConfig
+	timeout time.Duration
+	opts    s3.ProbeOptions
+	delay   time.Duration
+}
+
+func (c *credentialsManager) ValidateCredentials(ctx context.Context, endpointCfg config.S3EndpointConfig, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	time.Sleep(c.delay)
+	c.calls++
+	c.cfg, c.timeout, c.opts = endpointCfg, timeout, opts
+	return &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", Message: "denied", CheckedAt: time.Now()}
+}
+
+func postCredentials(handler http.HandlerFunc, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/validate/credentials", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+func TestValidateCredentialsHandler(t *testing.T) {
+	mgr := &credentialsManager{}
+	handler := NewValidateCredentialsHandler(mgr, CredentialsAPIOptions{
+		Tokens:           []string{"token-a", "token-b"},
+		AllowedEndpoints: []string{"https://minio.internal:9000/"},
+	}, logrus.New())
+
+	body := `{"access_key":"ASIA1","secret_key":"s","session_token":"t","bucket":"shared","region":"eu-west-1",` +
+		`"endpoint":"https://minio.internal:9000","use_path_style":true,"depth":"deep","timeout":"20s"}`
+	rr := postCredentials(handler, "token-b", body)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected a failed verdict to be served with 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response ValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.IsValid || response.ErrorType != "access_denied" {
+		t.Fatalf("expected the manager's verdict, got %+v", response)
+	}
+	if rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("expected the verdict not to be cached, got %q", rr.Header().Get("Cache-Control"))
+	}
+	if mgr.cfg.AccessKey != "ASIA1" || mgr.cfg.SessionToken != "t" || mgr.cfg.Bucket != "shared" || !mgr.cfg.UsePathStyle ||
+		mgr.timeout != 20*time.Second || mgr.opts.Depth != s3.ProbeDepthDeep {
+		t.Fatalf("expected the request to reach the manager, got %+v %s %+v", mgr.cfg, mgr.timeout, mgr.opts)
+	}
+
+	for _, token := range []string{"", "token-c", "token-a "} {
+		if rr := postCredentials(handler, token, body); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("expected token %q to be refused, got %d", token, rr.Code)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"secret_key":"s","bucket":"b","region":"r"}`,
+		`{"access_key":"a","secret_key":"s","region":"r"}`,
+		`{"access_key":"a","secret_key":"s","bucket":"b"}`,
+		`{"access_key":"a","secret_key":"s","bucket":"b","region":"r","endpoint":"http://169.254.169.254"}`,
+		`{"access_key":"a","secret_key":"s","bucket":"b","region":"r","depth":"thorough"}`,
+		`{"access_key":"a","secret_key":"s","bucket":"b","region":"r","role_arn":"x"}`,
+		`not json`,
+	} {
+		if rr := postCredentials(handler, "token-a", invalid); rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", invalid, rr.Code)
+		}
+	}
+	if mgr.calls != 1 {
+		t.Fatalf("expected refused requests not to validate, got %d calls", mgr.calls)
+	}
+}
+
+func TestValidateCredentialsHandlerTimeoutOutlastsWriteTimeout(t *testing.T) {
+	handler := NewValidateCredentialsHandler(&credentialsManager{delay: 150 * time.Millisecond}, CredentialsAPIOptions{Tokens: []string{"token"}}, logrus.New())
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/validate/credentials",
+		strings.NewReader(`{"access_key":"ASIA1","secret_key":"s","bucket":"shared","region":"eu-west-1","timeout":"1s"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected the verdict to be written within the requested timeout: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the verdict, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CycleReporter exposes the snapshots of recent auto-validation cycles
+type CycleReporter interface {
+	LatestCycle() (exporter.Cycle, bool)
+	CycleDiff(id int64) (exporter.CycleDiff, error)
+}
+
+// CycleEndpointResponse is an endpoint's state at the end of a cycle
+type CycleEndpointResponse struct {
+	Status    string `json:"status"`
+	CheckedAt string `json:"checked_at"`
+	ErrorType string `json:"error_type,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type CycleResponse struct {
+	ID         int64                            `json:"id"`
+	Timestamp  string                           `json:"timestamp"`
+	Summary    ValidationSummary                `json:"summary"`
+	Endpoints  map[string]CycleEndpointResponse `json:"endpoints"`
+	Unfinished []string                         `json:"unfinished,omitempty"`
+}
+
+type CycleChangeResponse struct {
+	Endpoint string                 `json:"endpoint"`
+	Change   string                 `json:"change"`
+	Previous *CycleEndpointResponse `json:"previous,omitempty"`
+	Current  *CycleEndpointResponse `json:"current,omitempty"`
+	// Annotations are the endpoint's configured owner, runbook_url and other notes
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type CycleDiffResponse struct {
+	ID                int64                 `json:"id"`
+	Timestamp         string                `json:"timestamp"`
+	PreviousID        int64                 `json:"previous_id"`
+	PreviousTimestamp string                `json:"previous_timestamp"`
+	Changes           []CycleChangeResponse `json:"changes"`
+}
+
+func newCycleEndpointResponse(endpoint exporter.CycleEndpoint) CycleEndpointResponse {
+	status := "invalid"
+	if endpoint.IsValid {
+		status = "valid"
+	}
+	return CycleEndpointResponse{
+		Status:    status,
+		CheckedAt: endpoint.CheckedAt.UTC().Format(time.RFC3339),
+		ErrorType: endpoint.ErrorType,
+		Message:   endpoint.Message,
+	}
+}
+
+// NewLatestCycleHandler returns a handler serving the snapshot of the most recent
+// auto-validation cycle. It answers 404 until the first cycle finished.
+// Expected route: GET /cycles/latest
+func NewLatestCycleHandler(manager CycleReporter, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cycle, ok := manager.LatestCycle()
+		if !ok {
+			http.Error(w, "no auto-validation cycle has finished yet", http.StatusNotFound)
+			return
+		}
+
+		response := CycleResponse{
+			ID:         cycle.ID,
+			Timestamp:  cycle.Timestamp.UTC().Format(time.RFC3339),
+			Endpoints:  make(map[string]CycleEndpointResponse, len(cycle.Endpoints)),
+			Unfinished: cycle.Unfinished,
+		}
+		for name, endpoint := range cycle.Endpoints {
+			response.Endpoints[name] = newCycleEndpointResponse(endpoint)
+			response.Summary.TotalEndpoints++
+			if endpoint.IsValid {
+				response.Summary.Successful++
+			} else {
+				response.Summary.Failed++
+			}
+		}
+		// Unfinished endpoints count as timed out, like in POST /validate responses
+		response.Summary.TotalEndpoints += len(cycle.Unfinished)
+		response.Summary.Failed += len(cycle.Unfinished)
+		response.Summary.TimedOut += len(cycle.Unfinished)
+
+		writeCachedJSON(w, r, log, response, response, cycle.Timestamp)
+	}
+}
+
+// NewCycleDiffHandler returns a handler listing the endpoints that changed state between
+// a cycle and the one before it. The id is a cycle ID or "latest"; cycles evicted from
+// the CYCLE_HISTORY_SIZE buffer, and the very first cycle, answer 404.
+// Expected route: GET /cycles/{id}/diff
+func NewCycleDiffHandler(manager CycleReporter, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var id int64
+		if raw := r.PathValue("id"); raw == "latest" {
+			latest, ok := manager.LatestCycle()
+			if !ok {
+				http.Error(w, "no auto-validation cycle has finished yet", http.StatusNotFound)
+				return
+			}
+			id = latest.ID
+		} else {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "cycle id must be a positive integer or \"latest\"", http.StatusBadRequest)
+				return
+			}
+			id = parsed
+		}
+
+		diff, err := manager.CycleDiff(id)
+		if errors.Is(err, exporter.ErrCycleNotFound) || errors.Is(err, exporter.ErrNoPreviousCycle) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to diff validation cycles")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		response := CycleDiffResponse{
+			ID:                diff.Current.ID,
+			Timestamp:         diff.Current.Timestamp.UTC().Format(time.RFC3339),
+			PreviousID:        diff.Previous.ID,
+			PreviousTimestamp: diff.Previous.Timestamp.UTC().Format(time.RFC3339),
+			Changes:           make([]CycleChangeResponse, 0, len(diff.Changes)),
+		}
+		for _, change := range diff.Changes {
+			entry := CycleChangeResponse{
+				Endpoint:    change.Endpoint,
+				Change:      change.Kind,
+				Annotations: annotationsFor(manager, change.Endpoint),
+			}
+			if change.Previous != nil {
+				previous := newCycleEndpointResponse(*change.Previous)
+				entry.Previous = &previous
+			}
+			if change.Current != nil {
+				current := newCycleEndpointResponse(*change.Current)
+				entry.Current = &current
+			}
+			response.Changes = append(response.Changes, entry)
+		}
+
+		writeCachedJSON(w, r, log, response, response, diff.Current.Timestamp)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stubCycleReporter reports the given cycles; diffs compare each with the one before
+type stubCycleReporter struct {
+	cycles []exporter.Cycle
+	diff   exporter.CycleDiff
+}
+
+func (s *stubCycleReporter) LatestCycle() (exporter.Cycle, bool) {
+	if len(s.cycles) == 0 {
+		return exporter.Cycle{}, false
+	}
+	return s.cycles[len(s.cycles)-1], true
+}
+
+func (s *stubCycleReporter) CycleDiff(id int64) (exporter.CycleDiff, error) {
+	if id != s.diff.Current.ID || len(s.cycles) == 0 {
+		return exporter.CycleDiff{}, exporter.ErrCycleNotFound
+	}
+	return s.diff, nil
+}
+
+func newStubCycles() *stubCycleReporter {
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	previous := exporter.Cycle{ID: 4, Timestamp: at, Endpoints: map[string]exporter.CycleEndpoint{
+		"bucket-a": {IsValid: true, CheckedAt: at},
+	}}
+	current := exporter.Cycle{ID: 5, Timestamp: at.Add(time.Minute), Endpoints: map[string]exporter.CycleEndpoint{
+		"bucket-a": {IsValid: false, ErrorType: "access_denied", Message: "Access Denied", CheckedAt: at.Add(time.Minute)},
+	}, Unfinished: []string{"bucket-b"}}
+	return &stubCycleReporter{
+		cycles: []exporter.Cycle{previous, current},
+		diff: exporter.CycleDiff{Previous: previous, Current: current, Changes: []exporter.CycleChange{{
+			Endpoint: "bucket-a",
+			Kind:     exporter.CycleChangeBroke,
+			Previous: &exporter.CycleEndpoint{IsValid: true, CheckedAt: at},
+			Current:  &exporter.CycleEndpoint{IsValid: false, ErrorType: "access_denied", CheckedAt: at.Add(time.Minute)},
+		}}},
+	}
+}
+
+func TestLatestCycleHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewLatestCycleHandler(newStubCycles(), logrus.New()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/cycles/latest", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var response CycleResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.ID != 5 || response.Endpoints["bucket-a"].Status != "invalid" || response.Endpoints["bucket-a"].ErrorType != "access_denied" {
+		t.Fatalf("unexpected cycle: %+v", response)
+	}
+	if response.Summary.TotalEndpoints != 2 || response.Summary.Failed != 2 || response.Summary.TimedOut != 1 {
+		t.Fatalf("expected the unfinished endpoint to count as timed out, got %+v", response.Summary)
+	}
+}
+
+func TestCycleDiffHandler(t *testing.T) {
+	handler := NewCycleDiffHandler(newStubCycles(), logrus.New())
+	for _, id := range []string{"5", "latest"} {
+		rr := serveRoute("/cycles/{id}/diff", handler, httptest.NewRequest(http.MethodGet, "/cycles/"+id+"/diff", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", id, rr.Code)
+		}
+		var response CycleDiffResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.ID != 5 || response.PreviousID != 4 || len(response.Changes) != 1 {
+			t.Fatalf("%s: unexpected diff: %+v", id, response)
+		}
+		change := response.Changes[0]
+		if change.Change != exporter.CycleChangeBroke || change.Previous.Status != "valid" || change.Current.Status != "invalid" {
+			t.Fatalf("%s: unexpected change: %+v", id, change)
+		}
+	}
+
+	cases := map[string]int{"4": http.StatusNotFound, "abc": http.StatusBadRequest, "0": http.StatusBadRequest}
+	for id, want := range cases {
+		rr := serveRoute("/cycles/{id}/diff", handler, httptest.NewRequest(http.MethodGet, "/cycles/"+id+"/diff", nil))
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d, got %d", id, want, rr.Code)
+		}
+	}
+}
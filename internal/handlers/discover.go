@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Discoverer runs permission discovery for an endpoint
+type Discoverer interface {
+	DiscoverEndpoint(ctx context.Context, endpointName string) (*s3.DiscoveryResult, error)
+}
+
+type PermissionResponse struct {
+	Operation  string `json:"operation"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type DiscoveryResponse struct {
+	Endpoint    string               `json:"endpoint"`
+	CheckedAt   string               `json:"checked_at"`
+	DurationMs  int64                `json:"duration_ms"`
+	Permissions []PermissionResponse `json:"permissions"`
+}
+
+// NewDiscoverHandler returns a handler running permission discovery for one endpoint.
+// Expected route: POST /endpoints/{endpoint}/discover
+func NewDiscoverHandler(manager Discoverer, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpointName := r.PathValue("endpoint")
+		if endpointName == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		result, err := manager.DiscoverEndpoint(r.Context(), endpointName)
+		if errors.Is(err, exporter.ErrEndpointNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := DiscoveryResponse{
+			Endpoint:    endpointName,
+			CheckedAt:   result.CheckedAt.UTC().Format(time.RFC3339),
+			DurationMs:  result.Duration.Milliseconds(),
+			Permissions: make([]PermissionResponse, 0, len(result.Permissions)),
+		}
+		for _, permission := range result.Permissions {
+			response.Permissions = append(response.Permissions, PermissionResponse{
+				Operation:  permission.Operation,
+				Status:     permission.Status,
+				Message:    permission.Message,
+				DurationMs: permission.Duration.Milliseconds(),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode discovery response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubDiscoverer struct {
+	discovered []string
+}
+
+func (s *stubDiscoverer) DiscoverEndpoint(ctx context.Context, endpointName string) (*s3.DiscoveryResult, error) {
+	if endpointName != "bucket-a" {
+		return nil, fmt.Errorf("%w: %q", exporter.ErrEndpointNotFound, endpointName)
+	}
+	s.discovered = append(s.discovered, endpointName)
+	return &s3.DiscoveryResult{
+		CheckedAt: time.Now(),
+		Permissions: []s3.PermissionResult{
+			{Operation: s3.OperationListObjects, Status: s3.PermissionAllowed},
+			{Operation: s3.OperationPutObject, Status: s3.PermissionDenied, Message: "AccessDenied"},
+		},
+	}, nil
+}
+
+func TestDiscoverHandlerDiscover(t *testing.T) {
+	manager := &stubDiscoverer{}
+	handler := NewDiscoverHandler(manager, logrus.New())
+
+	rr := serveRoute(discoverRoute, handler, httptest.NewRequest(http.MethodPost, "/endpoints/bucket-a/discover", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response DiscoveryResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Endpoint != "bucket-a" || len(response.Permissions) != 2 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if denied := response.Permissions[1]; denied.Operation != s3.OperationPutObject || denied.Status != s3.PermissionDenied {
+		t.Fatalf("expected PutObject to be denied, got %+v", denied)
+	}
+}
+
+func TestDiscoverHandlerErrors(t *testing.T) {
+	manager := &stubDiscoverer{}
+	handler := NewDiscoverHandler(manager, logrus.New())
+
+	cases := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/endpoints/bucket-a/discover", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/endpoints/missing/discover", http.StatusNotFound},
+		{http.MethodPost, "/endpoints/bucket-a/unknown", http.StatusNotFound},
+		{http.MethodPost, "/endpoints/bucket-a", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		rr := serveRoute(discoverRoute, handler, httptest.NewRequest(tc.method, tc.path, nil))
+		if rr.Code != tc.want {
+			t.Fatalf("%s %s: expected %d, got %d", tc.method, tc.path, tc.want, rr.Code)
+		}
+	}
+	if len(manager.discovered) != 0 {
+		t.Fatalf("expected no discovery to run, got %v", manager.discovered)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Output formats of the validate-all response
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatText = "text"
+)
+
+// negotiateFormat picks the response format from ?format= or, failing that, the Accept
+// header. It reports false when the requested format is not supported.
+func negotiateFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch strings.ToLower(format) {
+		case formatJSON, formatCSV, formatText:
+			return strings.ToLower(format), true
+		default:
+			return "", false
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json", "*/*", "application/*":
+			return formatJSON, true
+		case "text/csv":
+			return formatCSV, true
+		case "text/plain", "application/openmetrics-text", "text/*":
+			return formatText, true
+		}
+	}
+	return "", false
+}
+
+// sortedEndpoints returns the response's endpoint names in a stable order
+func sortedEndpoints(response MultiValidationResponse) []string {
+	names := make([]string, 0, len(response.Results))
+	for name := range response.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCSV renders one row per endpoint, suitable for `column -s, -t`
+func writeCSV(w io.Writer, response MultiValidationResponse) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"endpoint", "is_valid", "error_type", "response_time_ms", "checked_at", "message"}); err != nil {
+		return err
+	}
+	for _, name := range sortedEndpoints(response) {
+		result := response.Results[name]
+		if err := out.Write([]string{
+			name,
+			strconv.FormatBool(result.IsValid),
+			result.ErrorType,
+			strconv.FormatInt(result.ResponseTimeMs, 10),
+			result.CheckedAt,
+			result.Message,
+		}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// labelValueEscaper escapes what the text exposition format requires in label values;
+// everything else, UTF-8 included, is written as is
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// writeText renders the response in the Prometheus text exposition format
+func writeText(w io.Writer, response MultiValidationResponse) error {
+	names := sortedEndpoints(response)
+	var b strings.Builder
+
+	b.WriteString("# HELP s3_validate_is_valid Whether the endpoint's keys validated in this request (1 = valid, 0 = invalid)\n")
+	b.WriteString("# TYPE s3_validate_is_valid gauge\n")
+	for _, name := range names {
+		value := 0
+		if response.Results[name].IsValid {
+			value = 1
+		}
+		fmt.Fprintf(&b, "s3_validate_is_valid{endpoint=\"%s\",error_type=\"%s\"} %d\n", labelValue(name), labelValue(response.Results[name].ErrorType), value)
+	}
+
+	b.WriteString("# HELP s3_validate_response_time_milliseconds Validation response time in this request\n")
+	b.WriteString("# TYPE s3_validate_response_time_milliseconds gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "s3_validate_response_time_milliseconds{endpoint=\"%s\"} %d\n", labelValue(name), response.Results[name].ResponseTimeMs)
+	}
+
+	b.WriteString("# HELP s3_validate_endpoints Endpoints validated in this request by outcome\n")
+	b.WriteString("# TYPE s3_validate_endpoints gauge\n")
+	fmt.Fprintf(&b, "s3_validate_endpoints{outcome=\"successful\"} %d\n", response.Summary.Successful)
+	fmt.Fprintf(&b, "s3_validate_endpoints{outcome=\"failed\"} %d\n", response.Summary.Failed)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
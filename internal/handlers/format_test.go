@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newFormatTestManager() *stubManager {
+	baseTime := time.Unix(1730000000, 0)
+	return &stubManager{
+		validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+			return &exporter.ValidationResults{
+				Timestamp: baseTime,
+				Results: map[string]*s3.ValidationResult{
+					"beta":  {IsValid: false, Message: "denied, twice", ErrorType: "access_denied", CheckedAt: baseTime, ResponseTimeMs: 40},
+					"alpha": {IsValid: true, Message: "ok", CheckedAt: baseTime, ResponseTimeMs: 12},
+				},
+			}
+		},
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{name: "default", want: formatJSON, ok: true},
+		{name: "accept csv", accept: "text/csv", want: formatCSV, ok: true},
+		{name: "accept text", accept: "text/plain;q=0.9", want: formatText, ok: true},
+		{name: "accept wildcard", accept: "*/*", want: formatJSON, ok: true},
+		{name: "first supported wins", accept: "application/xml, text/csv", want: formatCSV, ok: true},
+		{name: "query beats accept", query: "text", accept: "text/csv", want: formatText, ok: true},
+		{name: "unknown query", query: "xml", ok: false},
+		{name: "unknown accept", accept: "application/xml", ok: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/validate"
+			if tt.query != "" {
+				target += "?format=" + tt.query
+			}
+			req := httptest.NewRequest(http.MethodPost, target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			got, ok := negotiateFormat(req)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestValidateAllHandlerCSV(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+
+	NewValidateAllHandler(newFormatTestManager(), logrus.New())(rr, req)
+
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("expected CSV content type, got %q", ct)
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d records", len(records))
+	}
+	if records[0][0] != "endpoint" || records[1][0] != "alpha" || records[2][0] != "beta" {
+		t.Fatalf("unexpected row order: %v", records)
+	}
+	if records[2][1] != "false" || records[2][2] != "access_denied" || records[2][5] != "denied, twice" {
+		t.Fatalf("unexpected row for beta: %v", records[2])
+	}
+}
+
+func TestValidateAllHandlerText(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/validate?format=text", nil)
+	rr := httptest.NewRecorder()
+
+	NewValidateAllHandler(newFormatTestManager(), logrus.New())(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("expected plain text content type, got %q", ct)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"# TYPE s3_validate_is_valid gauge",
+		`s3_validate_is_valid{endpoint="alpha",error_type=""} 1`,
+		`s3_validate_is_valid{endpoint="beta",error_type="access_denied"} 0`,
+		`s3_validate_response_time_milliseconds{endpoint="beta"} 40`,
+		`s3_validate_endpoints{outcome="failed"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in body:\n%s", want, body)
+		}
+	}
+}
+
+func TestLabelValueEscapesOnlyTextFormatSpecials(t *testing.T) {
+	got := labelValue("équipe\t\"a\"\\b\nc")
+	want := `équipe` + "\t" + `\"a\"\\b\nc`
+	if got != want {
+		t.Fatalf("labelValue() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateAllHandlerNotAcceptable(t *testing.T) {
+	called := false
+	mgr := &stubManager{
+		validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+			called = true
+			return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{}}
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/validate?format=xml", nil)
+	rr := httptest.NewRecorder()
+
+	NewValidateAllHandler(mgr, logrus.New())(rr, req)
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406, got %d", rr.Code)
+	}
+	if called {
+		t.Fatalf("expected no validation for an unsupported format")
+	}
+}
@@ -3,11 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
+	"key-aws-exporter/internal/config"
 	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/internal/signing"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
 	"key-aws-exporter/pkg/s3"
 
 	"github.com/sirupsen/logrus"
@@ -21,23 +26,207 @@ type Validator interface {
 }
 
 type ValidationResponse struct {
-	IsValid        bool   `json:"is_valid"`
-	Message        string `json:"message"`
-	CheckedAt      string `json:"checked_at"`
-	ResponseTimeMs int64  `json:"response_time_ms"`
-	ErrorType      string `json:"error_type,omitempty"`
+	IsValid        bool             `json:"is_valid"`
+	Message        string           `json:"message"`
+	CheckedAt      string           `json:"checked_at"`
+	ResponseTimeMs int64            `json:"response_time_ms"`
+	ErrorType      string           `json:"error_type,omitempty"`
+	Error          *ErrorResponse   `json:"error,omitempty"`
+	Checks         []CheckResponse  `json:"checks,omitempty"`
+	Latency        *LatencyResponse `json:"latency,omitempty"`
+	// Secondary is the outcome for the endpoint's secondary credentials, when configured
+	Secondary *ValidationResponse `json:"secondary,omitempty"`
+	// StatusReason explains the HTTP status of a failed /validate/{endpoint} response
+	StatusReason string `json:"status_reason,omitempty"`
+	// Annotations are the endpoint's configured owner, runbook_url and other notes
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Severity is the severity a result rule gave the result
+	Severity string `json:"severity,omitempty"`
+	// SuppressedBy names the result rule that kept the failure from metrics and notifications
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+}
+
+// ErrorResponse is the machine-readable cause of a failed validation
+type ErrorResponse struct {
+	Type         string `json:"type"`
+	AWSErrorCode string `json:"aws_error_code,omitempty"`
+	HTTPStatus   int    `json:"http_status,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+	Operation    string `json:"operation,omitempty"`
+	Retryable    bool   `json:"retryable"`
+}
+
+// newErrorResponse describes a failure from its error chain, or from the error type
+// alone for failures the exporter produced itself, such as an unknown endpoint
+func newErrorResponse(result *s3.ValidationResult) *ErrorResponse {
+	if result.IsValid {
+		return nil
+	}
+	if detail := result.Error; detail != nil {
+		return &ErrorResponse{
+			Type:         detail.Type,
+			AWSErrorCode: detail.Code,
+			HTTPStatus:   detail.HTTPStatus,
+			RequestID:    detail.RequestID,
+			Operation:    detail.Operation,
+			Retryable:    detail.Retryable,
+		}
+	}
+	if result.ErrorType == "" {
+		return nil
+	}
+	return &ErrorResponse{
+		Type:      result.ErrorType,
+		Retryable: s3.IsRetryableErrorType(result.ErrorType) || exporter.IsUnfinished(result.ErrorType),
+	}
+}
+
+// AnnotationReporter exposes the annotations configured per endpoint
+type AnnotationReporter interface {
+	Annotations(endpointName string) map[string]string
+}
+
+// annotationsFor returns the endpoint's annotations when the manager reports them
+func annotationsFor(manager any, endpointName string) map[string]string {
+	if reporter, ok := manager.(AnnotationReporter); ok {
+		return reporter.Annotations(endpointName)
+	}
+	return nil
+}
+
+// LatencyReporter exposes rolling latency percentiles computed from the history buffer
+type LatencyReporter interface {
+	Latency(endpointName string) (exporter.LatencySummary, bool)
+}
+
+// LatencyResponse summarizes recent response times of an endpoint
+type LatencyResponse struct {
+	Samples int   `json:"samples"`
+	P50Ms   int64 `json:"p50_ms"`
+	P95Ms   int64 `json:"p95_ms"`
+	P99Ms   int64 `json:"p99_ms"`
+}
+
+// latencyFor returns the endpoint's latency summary if the manager tracks history
+func latencyFor(manager any, endpointName string) *LatencyResponse {
+	reporter, ok := manager.(LatencyReporter)
+	if !ok {
+		return nil
+	}
+	summary, ok := reporter.Latency(endpointName)
+	if !ok {
+		return nil
+	}
+	return &LatencyResponse{
+		Samples: summary.Samples,
+		P50Ms:   summary.P50Ms,
+		P95Ms:   summary.P95Ms,
+		P99Ms:   summary.P99Ms,
+	}
+}
+
+// CheckResponse reports a bucket check verdict produced during the validation
+type CheckResponse struct {
+	Name      string         `json:"name"`
+	Passed    bool           `json:"passed"`
+	Critical  bool           `json:"critical,omitempty"`
+	Pending   bool           `json:"pending,omitempty"`
+	Message   string         `json:"message"`
+	Counts    map[string]int `json:"counts,omitempty"`
+	CheckedAt string         `json:"checked_at"`
+}
+
+func newValidationResponse(result *s3.ValidationResult) ValidationResponse {
+	response := ValidationResponse{
+		IsValid:        result.IsValid,
+		Message:        result.Message,
+		CheckedAt:      result.CheckedAt.UTC().Format(time.RFC3339),
+		ResponseTimeMs: result.ResponseTimeMs,
+		ErrorType:      result.ErrorType,
+		Error:          newErrorResponse(result),
+		Severity:       result.Severity,
+		SuppressedBy:   result.SuppressedBy,
+	}
+	for _, check := range result.Checks {
+		response.Checks = append(response.Checks, CheckResponse{
+			Name:      check.Name,
+			Passed:    check.Passed,
+			Critical:  check.Critical,
+			Pending:   check.Pending,
+			Message:   check.Message,
+			Counts:    check.Counts,
+			CheckedAt: check.CheckedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if result.Secondary != nil {
+		secondary := newValidationResponse(result.Secondary)
+		response.Secondary = &secondary
+	}
+	return response
 }
 
 type MultiValidationResponse struct {
 	Timestamp time.Time                     `json:"timestamp"`
 	Results   map[string]ValidationResponse `json:"results"`
 	Summary   ValidationSummary             `json:"summary"`
+	// Replayed marks results of an earlier run with the same Idempotency-Key
+	Replayed bool `json:"replayed,omitempty"`
 }
 
 type ValidationSummary struct {
 	TotalEndpoints int `json:"total_endpoints"`
 	Successful     int `json:"successful"`
 	Failed         int `json:"failed"`
+	// TimedOut counts the failed endpoints that did not finish within the request budget
+	TimedOut int `json:"timed_out,omitempty"`
+}
+
+// count adds a result to the summary
+func (s *ValidationSummary) count(result *s3.ValidationResult) {
+	s.TotalEndpoints++
+	switch {
+	case result.IsValid:
+		s.Successful++
+	case result.ErrorType == exporter.ErrorTypeTimedOut:
+		s.TimedOut++
+		s.Failed++
+	default:
+		s.Failed++
+	}
+}
+
+// Refresher drops cached clients and check schedules so ?force=true validations start
+// from scratch
+type Refresher interface {
+	RefreshEndpoint(endpointName string) bool
+	RefreshAll()
+}
+
+// boolQuery parses an optional boolean query parameter
+func boolQuery(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// ProviderReporter exposes per-provider key posture
+type ProviderReporter interface {
+	ProviderSummaries() []exporter.ProviderSummary
+}
+
+type ProviderHealth struct {
+	Host      string   `json:"host"`
+	Endpoints []string `json:"endpoints"`
+	Valid     int      `json:"valid"`
+	Invalid   int      `json:"invalid"`
+	Unchecked int      `json:"unchecked"`
+}
+
+type ProvidersResponse struct {
+	Time      string           `json:"time"`
+	Providers []ProviderHealth `json:"providers"`
 }
 
 type HealthResponse struct {
@@ -49,7 +238,7 @@ type HealthResponse struct {
 // NewHealthCheckHandler returns a handler for health checks
 func NewHealthCheckHandler(manager Validator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
@@ -68,43 +257,197 @@ func NewHealthCheckHandler(manager Validator) http.HandlerFunc {
 	}
 }
 
-// NewValidateAllHandler returns a handler for validating all endpoints
-func NewValidateAllHandler(manager Validator, log *logrus.Logger) http.HandlerFunc {
+// NewProvidersHandler returns a handler summarizing key validity per provider host
+func NewProvidersHandler(manager ProviderReporter, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		summaries := manager.ProviderSummaries()
+		response := ProvidersResponse{
+			Time:      time.Now().UTC().Format(time.RFC3339),
+			Providers: make([]ProviderHealth, 0, len(summaries)),
+		}
+		for _, summary := range summaries {
+			response.Providers = append(response.Providers, ProviderHealth{
+				Host:      summary.Host,
+				Endpoints: summary.Endpoints,
+				Valid:     summary.Valid,
+				Invalid:   summary.Invalid,
+				Unchecked: summary.Unchecked,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode providers response: %v", err)
+		}
+	}
+}
+
+// ValidateAllOption customizes the validate-all handler
+type ValidateAllOption func(*validateAllSettings)
+
+type validateAllSettings struct {
+	budget         time.Duration
+	idempotencyTTL time.Duration
+	ratePerMinute  int
+	rateBurst      int
+	signer         *signing.Signer
+	metrics        *metrics.Metrics
+}
+
+// WithRequestBudget caps how long a validate-all request may take overall. Endpoints
+// still running when it passes are reported as timed_out.
+func WithRequestBudget(budget time.Duration) ValidateAllOption {
+	return func(s *validateAllSettings) {
+		s.budget = budget
+	}
+}
+
+// WithMetrics records rate limited requests into m instead of the collectors registered
+// on the Prometheus default registry
+func WithMetrics(m *metrics.Metrics) ValidateAllOption {
+	return func(s *validateAllSettings) {
+		s.metrics = m
+	}
+}
+
+// WithIdempotencyTTL sets how long results are replayed to requests repeating an
+// Idempotency-Key; 0 ignores the header
+func WithIdempotencyTTL(ttl time.Duration) ValidateAllOption {
+	return func(s *validateAllSettings) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+// requestBudget combines the server budget with an optional ?timeout= from the client,
+// returning the shorter of the two; 0 means unbounded
+func requestBudget(r *http.Request, server time.Duration) (time.Duration, error) {
+	value := r.URL.Query().Get("timeout")
+	if value == "" {
+		return server, nil
+	}
+	client, err := time.ParseDuration(value)
+	if err != nil || client <= 0 {
+		return 0, fmt.Errorf("timeout must be a positive duration such as 30s")
+	}
+	if server > 0 && server < client {
+		return server, nil
+	}
+	return client, nil
+}
+
+// NewValidateAllHandler returns a handler for validating all endpoints. The response is
+// JSON by default; CSV and Prometheus text are negotiated via ?format= or Accept, and
+// ?stream=true streams NDJSON as endpoints complete. Requests carrying an
+// Idempotency-Key share one run per key; ?force=true bypasses that and every cached client.
+func NewValidateAllHandler(manager Validator, log *logrus.Logger, opts ...ValidateAllOption) http.HandlerFunc {
+	settings := validateAllSettings{idempotencyTTL: defaultIdempotencyTTL}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	var idempotency *idempotencyCache
+	if settings.idempotencyTTL > 0 {
+		idempotency = newIdempotencyCache(settings.idempotencyTTL, clock.Real)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
+		format, ok := negotiateFormat(r)
+		if !ok {
+			http.Error(w, "supported formats: json, csv, text", http.StatusNotAcceptable)
+			return
+		}
+
+		stream, err := streamRequested(r)
+		if err != nil {
+			http.Error(w, "stream must be true or false", http.StatusBadRequest)
+			return
+		}
+		if stream && format != formatJSON {
+			http.Error(w, "stream is only supported for JSON", http.StatusBadRequest)
+			return
+		}
+		force, err := boolQuery(r, "force")
+		if err != nil {
+			http.Error(w, "force must be true or false", http.StatusBadRequest)
+			return
+		}
+		budget, err := requestBudget(r, settings.budget)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+			return
+		}
+
 		ctx := r.Context()
-		results := manager.ValidateAll(ctx)
+		if budget > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, budget)
+			defer cancel()
+			extendWriteDeadline(w, budget+config.ResponseWriteMargin)
+		}
+
+		validate := func() *exporter.ValidationResults {
+			if stream {
+				return streamValidateAll(ctx, w, manager, log)
+			}
+			return manager.ValidateAll(ctx)
+		}
+
+		if refresher, ok := manager.(Refresher); ok && force {
+			log.Info("Forced validation of all endpoints: rebuilding S3 clients")
+			refresher.RefreshAll()
+		}
+
+		var results *exporter.ValidationResults
+		var replayed bool
+		if key != "" && idempotency != nil && !force {
+			results, replayed, err = idempotency.do(ctx, idempotencyScope(r, key), validate)
+			if err != nil {
+				http.Error(w, "a validation with this Idempotency-Key is still in progress", http.StatusConflict)
+				return
+			}
+		} else {
+			results = validate()
+		}
+		if replayed {
+			w.Header().Set(idempotentReplayedHeader, "true")
+		}
+
+		if stream {
+			if replayed {
+				streamReplay(w, results, log)
+			}
+			return
+		}
 
 		// Build response
 		response := MultiValidationResponse{
 			Timestamp: results.Timestamp,
-			Results:   make(map[string]ValidationResponse),
-			Summary: ValidationSummary{
-				TotalEndpoints: len(results.Results),
-			},
+			Results:   make(map[string]ValidationResponse, len(results.Results)),
+			Replayed:  replayed,
 		}
 
 		// Process results
 		for endpointName, result := range results.Results {
-			response.Results[endpointName] = ValidationResponse{
-				IsValid:        result.IsValid,
-				Message:        result.Message,
-				CheckedAt:      result.CheckedAt.UTC().Format(time.RFC3339),
-				ResponseTimeMs: result.ResponseTimeMs,
-				ErrorType:      result.ErrorType,
-			}
-
-			exporter.RecordResult(log, endpointName, result)
-
-			if result.IsValid {
-				response.Summary.Successful++
-			} else {
-				response.Summary.Failed++
-			}
+			endpointResponse := newValidationResponse(result)
+			endpointResponse.Latency = latencyFor(manager, endpointName)
+			endpointResponse.Annotations = annotationsFor(manager, endpointName)
+			response.Results[endpointName] = endpointResponse
+			response.Summary.count(result)
 		}
 
 		// Determine status code (200 if all successful, 207 if mixed, 401 if all failed)
@@ -115,15 +458,28 @@ func NewValidateAllHandler(manager Validator, log *logrus.Logger) http.HandlerFu
 			statusCode = http.StatusUnauthorized
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(statusCode)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+		switch format {
+		case formatCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.WriteHeader(statusCode)
+			err = writeCSV(w, response)
+		case formatText:
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			w.WriteHeader(statusCode)
+			err = writeText(w, response)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			err = json.NewEncoder(w).Encode(response)
+		}
+		if err != nil {
 			log.Errorf("Failed to encode validate all response: %v", err)
 		}
 	}
 }
 
-// NewValidateEndpointHandler returns a handler for validating a specific endpoint
+// NewValidateEndpointHandler returns a handler for validating a specific endpoint. A POST
+// body with probe options overrides depth, operation, timeout and prefix for that run only.
 func NewValidateEndpointHandler(manager Validator, log *logrus.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost && r.Method != http.MethodGet {
@@ -131,39 +487,55 @@ func NewValidateEndpointHandler(manager Validator, log *logrus.Logger) http.Hand
 			return
 		}
 
-		// Extract endpoint name from URL path
-		// Expected format: /validate/{endpoint}
-		parts := strings.Split(r.URL.Path, "/")
-		if len(parts) < 3 {
+		// Expected route: /validate/{endpoint}
+		endpointName := r.PathValue("endpoint")
+		if endpointName == "" {
 			http.Error(w, "endpoint name is required", http.StatusBadRequest)
 			return
 		}
 
-		endpointName := parts[len(parts)-1]
-		if endpointName == "" {
-			http.Error(w, "endpoint name cannot be empty", http.StatusBadRequest)
+		force, err := boolQuery(r, "force")
+		if err != nil {
+			http.Error(w, "force must be true or false", http.StatusBadRequest)
+			return
+		}
+		if refresher, ok := manager.(Refresher); ok && force {
+			log.WithField("endpoint", endpointName).Info("Forced validation: rebuilding S3 client")
+			refresher.RefreshEndpoint(endpointName)
+		}
+
+		opts, timeout, override, err := decodeProbeOptions(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		optionsValidator, supported := manager.(OptionsValidator)
+		if override && !supported {
+			http.Error(w, "probe options are not supported", http.StatusNotImplemented)
 			return
 		}
 
 		ctx := r.Context()
-		result := manager.ValidateEndpoint(ctx, endpointName)
+		var result *s3.ValidationResult
+		if override {
+			if timeout > 0 {
+				extendWriteDeadline(w, timeout+config.ResponseWriteMargin)
+			}
+			log.WithField("endpoint", endpointName).Infof("Validating with probe options %+v", opts)
+			result = optionsValidator.ValidateEndpointWith(ctx, endpointName, timeout, opts)
+		} else {
+			result = manager.ValidateEndpoint(ctx, endpointName)
+		}
 
-		exporter.RecordResult(log, endpointName, result)
+		response := newValidationResponse(result)
+		response.Latency = latencyFor(manager, endpointName)
+		response.Annotations = annotationsFor(manager, endpointName)
 
-		response := ValidationResponse{
-			IsValid:        result.IsValid,
-			Message:        result.Message,
-			CheckedAt:      result.CheckedAt.UTC().Format(time.RFC3339),
-			ResponseTimeMs: result.ResponseTimeMs,
-			ErrorType:      result.ErrorType,
-		}
+		status := statusForResult(result.IsValid, result.ErrorType)
+		response.StatusReason = status.reason
 
 		w.Header().Set("Content-Type", "application/json")
-		statusCode := http.StatusOK
-		if !result.IsValid {
-			statusCode = http.StatusUnauthorized
-		}
-		w.WriteHeader(statusCode)
+		w.WriteHeader(status.code)
 
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			log.Errorf("Failed to encode validate endpoint response: %v", err)
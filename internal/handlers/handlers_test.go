@@ -149,31 +149,311 @@ func TestValidateEndpointHandler(t *testing.T) {
 
 	handler := NewValidateEndpointHandler(mgr, logger)
 
-	req := httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil)
-	rr := httptest.NewRecorder()
-	handler(rr, req)
+	rr := serveRoute(validateEndpointRoute, handler, httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil))
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
 
-	reqPost := httptest.NewRequest(http.MethodPost, "/validate/broken", nil)
-	rrPost := httptest.NewRecorder()
-	handler(rrPost, reqPost)
+	rrPost := serveRoute(validateEndpointRoute, handler, httptest.NewRequest(http.MethodPost, "/validate/broken", nil))
 	if rrPost.Code != http.StatusUnauthorized {
 		t.Fatalf("expected 401 when validation fails, got %d", rrPost.Code)
 	}
 
-	reqMissing := httptest.NewRequest(http.MethodPost, "/validate/", nil)
 	rrMissing := httptest.NewRecorder()
-	handler(rrMissing, reqMissing)
+	handler(rrMissing, httptest.NewRequest(http.MethodPost, "/validate/", nil))
 	if rrMissing.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400 when endpoint missing, got %d", rrMissing.Code)
 	}
 
-	reqInvalidMethod := httptest.NewRequest(http.MethodDelete, "/validate/bucket-a", nil)
-	rrInvalidMethod := httptest.NewRecorder()
-	handler(rrInvalidMethod, reqInvalidMethod)
+	rrInvalidMethod := serveRoute(validateEndpointRoute, handler, httptest.NewRequest(http.MethodDelete, "/validate/bucket-a", nil))
 	if rrInvalidMethod.Code != http.StatusMethodNotAllowed {
 		t.Fatalf("expected 405 for invalid method, got %d", rrInvalidMethod.Code)
 	}
 }
+
+func TestValidateEndpointHandlerStatusByErrorType(t *testing.T) {
+	cases := []struct {
+		errorType string
+		want      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"token_expired", http.StatusUnauthorized},
+		{"access_denied", http.StatusForbidden},
+		{"bucket_not_found", http.StatusNotFound},
+		{"endpoint_not_found", http.StatusNotFound},
+		{"timeout", http.StatusGatewayTimeout},
+		{exporter.ErrorTypeTimedOut, http.StatusGatewayTimeout},
+		{"network", http.StatusBadGateway},
+		{"config_error", http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		mgr := &stubManager{
+			validateEndpointFunc: func(ctx context.Context, name string) *s3.ValidationResult {
+				return &s3.ValidationResult{IsValid: false, Message: "failed", ErrorType: tc.errorType, CheckedAt: time.Now()}
+			},
+		}
+		rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodPost, "/validate/bucket-a", nil))
+		if rr.Code != tc.want {
+			t.Fatalf("error type %q: expected %d, got %d", tc.errorType, tc.want, rr.Code)
+		}
+
+		var response ValidationResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if response.StatusReason == "" {
+			t.Fatalf("error type %q: expected the status to be explained in status_reason", tc.errorType)
+		}
+	}
+}
+
+func TestValidateEndpointHandlerIncludesChecks(t *testing.T) {
+	mgr := &stubManager{
+		validateEndpointFunc: func(ctx context.Context, name string) *s3.ValidationResult {
+			return &s3.ValidationResult{
+				IsValid:   true,
+				CheckedAt: time.Now(),
+				Checks:    []s3.CheckResult{{Name: s3.CheckAccessLog, Message: "missing", CheckedAt: time.Now()}},
+			}
+		},
+	}
+
+	rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil))
+
+	var response ValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Checks) != 1 || response.Checks[0].Name != s3.CheckAccessLog || response.Checks[0].Passed {
+		t.Fatalf("unexpected checks in response: %+v", response.Checks)
+	}
+	if !response.IsValid {
+		t.Fatalf("expected failed check not to affect key validity")
+	}
+}
+
+func TestValidateEndpointHandlerIncludesSecondary(t *testing.T) {
+	mgr := &stubManager{
+		validateEndpointFunc: func(ctx context.Context, name string) *s3.ValidationResult {
+			return &s3.ValidationResult{
+				IsValid:   true,
+				CheckedAt: time.Now(),
+				Secondary: &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", CheckedAt: time.Now()},
+			}
+		},
+	}
+
+	rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil))
+
+	var response ValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the primary slot to decide the status, got %d", rr.Code)
+	}
+	if response.Secondary == nil || response.Secondary.IsValid || response.Secondary.ErrorType != "access_denied" {
+		t.Fatalf("unexpected secondary result: %+v", response.Secondary)
+	}
+}
+
+type stubProviderReporter struct {
+	summaries []exporter.ProviderSummary
+}
+
+func (s *stubProviderReporter) ProviderSummaries() []exporter.ProviderSummary {
+	return s.summaries
+}
+
+func TestProvidersHandler(t *testing.T) {
+	reporter := &stubProviderReporter{summaries: []exporter.ProviderSummary{
+		{Host: "minio.internal:9000", Endpoints: []string{"a", "b", "c"}, Valid: 2, Invalid: 1},
+	}}
+	handler := NewProvidersHandler(reporter, logrus.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var resp ProvidersResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Providers) != 1 || resp.Providers[0].Valid != 2 || resp.Providers[0].Invalid != 1 {
+		t.Fatalf("unexpected providers response: %+v", resp.Providers)
+	}
+
+	reqPost := httptest.NewRequest(http.MethodPost, "/providers", nil)
+	rrPost := httptest.NewRecorder()
+	handler(rrPost, reqPost)
+	if rrPost.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rrPost.Code)
+	}
+}
+
+func TestRequestBudget(t *testing.T) {
+	cases := []struct {
+		query  string
+		server time.Duration
+		want   time.Duration
+		err    bool
+	}{
+		{query: "", server: 0, want: 0},
+		{query: "", server: time.Minute, want: time.Minute},
+		{query: "?timeout=5s", server: 0, want: 5 * time.Second},
+		{query: "?timeout=5m", server: time.Minute, want: time.Minute},
+		{query: "?timeout=5s", server: time.Minute, want: 5 * time.Second},
+		{query: "?timeout=-1s", err: true},
+		{query: "?timeout=soon", err: true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/validate"+tc.query, nil)
+		got, err := requestBudget(req, tc.server)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Fatalf("%q with server budget %s: got %s, %v", tc.query, tc.server, got, err)
+		}
+	}
+}
+
+func TestValidateAllHandlerAppliesRequestBudget(t *testing.T) {
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("expected the request budget to set a deadline")
+		}
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"ok":    {IsValid: true, CheckedAt: time.Now()},
+			"stuck": {IsValid: false, ErrorType: exporter.ErrorTypeTimedOut, CheckedAt: time.Now()},
+		}}
+	}}
+
+	req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	rr := httptest.NewRecorder()
+	NewValidateAllHandler(mgr, logrus.New(), WithRequestBudget(time.Second))(rr, req)
+
+	var resp MultiValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Summary != (ValidationSummary{TotalEndpoints: 2, Successful: 1, Failed: 1, TimedOut: 1}) {
+		t.Fatalf("unexpected summary %+v", resp.Summary)
+	}
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207 for partial results, got %d", rr.Code)
+	}
+}
+
+func TestValidateAllHandlerBudgetOutlastsWriteTimeout(t *testing.T) {
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		time.Sleep(150 * time.Millisecond)
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"ok": {IsValid: true, CheckedAt: time.Now()},
+		}}
+	}}
+
+	server := httptest.NewUnstartedServer(NewValidateAllHandler(mgr, logrus.New(), WithRequestBudget(time.Second)))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/validate", "", nil)
+	if err != nil {
+		t.Fatalf("expected the response to be written within the request budget: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body MultiValidationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Summary.Successful != 1 {
+		t.Fatalf("unexpected summary %+v", body.Summary)
+	}
+}
+
+type refreshingManager struct {
+	stubManager
+	refreshed    []string
+	refreshedAll int
+}
+
+func (m *refreshingManager) RefreshEndpoint(name string) bool {
+	m.refreshed = append(m.refreshed, name)
+	return true
+}
+
+func (m *refreshingManager) RefreshAll() {
+	m.refreshedAll++
+}
+
+func TestValidateHandlersForceRefresh(t *testing.T) {
+	runs := 0
+	mgr := &refreshingManager{stubManager: stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		runs++
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{}}
+	}}}
+	validateAll := NewValidateAllHandler(mgr, logrus.New())
+
+	for _, target := range []string{"/validate", "/validate?force=true"} {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		validateAll(httptest.NewRecorder(), req)
+	}
+	if mgr.refreshedAll != 1 || runs != 2 {
+		t.Fatalf("expected the forced request to refresh clients and skip the replay, got %d refreshes and %d runs", mgr.refreshedAll, runs)
+	}
+
+	rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodPost, "/validate/bucket-a?force=1", nil))
+	if rr.Code != http.StatusOK || len(mgr.refreshed) != 1 || mgr.refreshed[0] != "bucket-a" {
+		t.Fatalf("expected the endpoint to be refreshed before validating, got %d %v", rr.Code, mgr.refreshed)
+	}
+
+	rr = serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodPost, "/validate/bucket-a?force=maybe", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid force flag, got %d", rr.Code)
+	}
+}
+
+func TestValidationResponseErrorObject(t *testing.T) {
+	response := newValidationResponse(&s3.ValidationResult{
+		IsValid:   false,
+		ErrorType: "access_denied",
+		CheckedAt: time.Now(),
+		Error:     &s3.ErrorDetail{Type: "access_denied", Code: "AccessDenied", HTTPStatus: http.StatusForbidden, RequestID: "REQ"},
+	})
+	if response.Error == nil || response.Error.AWSErrorCode != "AccessDenied" || response.Error.HTTPStatus != http.StatusForbidden || response.Error.Retryable {
+		t.Fatalf("expected the error chain to be reported, got %+v", response.Error)
+	}
+
+	response = newValidationResponse(&s3.ValidationResult{IsValid: false, ErrorType: exporter.ErrorTypeTimedOut, CheckedAt: time.Now()})
+	if response.Error == nil || response.Error.Type != exporter.ErrorTypeTimedOut || !response.Error.Retryable {
+		t.Fatalf("expected an error object derived from the error type, got %+v", response.Error)
+	}
+
+	if response = newValidationResponse(&s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}); response.Error != nil {
+		t.Fatalf("expected no error object for a valid result, got %+v", response.Error)
+	}
+}
+
+type annotatedManager struct {
+	stubManager
+}
+
+func (a *annotatedManager) Annotations(name string) map[string]string {
+	return map[string]string{"owner": "owner-of-" + name}
+}
+
+func TestValidateEndpointHandlerIncludesAnnotations(t *testing.T) {
+	rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(&annotatedManager{}, logrus.New()), httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil))
+
+	var response ValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Annotations["owner"] != "owner-of-bucket-a" {
+		t.Fatalf("expected the endpoint's annotations, got %v", response.Annotations)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HistoryReporter exposes the recent validation history kept by the manager
+type HistoryReporter interface {
+	LatencyReporter
+	History(endpointName string) []exporter.HistoryEntry
+	HistoryEndpoints() []string
+}
+
+// HistoryQuerier serves range queries from the persistent history store
+type HistoryQuerier interface {
+	QueryHistory(ctx context.Context, query exporter.HistoryQuery) ([]exporter.HistoryRecord, error)
+}
+
+// Limits of a history range query
+const (
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 10000
+)
+
+type HistoryEntryResponse struct {
+	CheckedAt      string `json:"checked_at"`
+	IsValid        bool   `json:"is_valid"`
+	ErrorType      string `json:"error_type,omitempty"`
+	Message        string `json:"message,omitempty"`
+	ResponseTimeMs int64  `json:"response_time_ms"`
+}
+
+type EndpointHistory struct {
+	Latency *LatencyResponse       `json:"latency,omitempty"`
+	Entries []HistoryEntryResponse `json:"entries"`
+}
+
+type HistoryResponse struct {
+	Time      string                     `json:"time"`
+	Endpoints map[string]EndpointHistory `json:"endpoints"`
+	// Truncated is set when a range query matched more than limit results
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewHistoryHandler returns a handler listing recent results and latency percentiles.
+// ?endpoint=name limits the response to one endpoint. With from, to, status or limit,
+// results are read from the persistent history store instead, oldest first. Responses
+// carry an ETag and Last-Modified, so conditional requests get 304 until a new result
+// is recorded.
+func NewHistoryHandler(manager HistoryReporter, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if params := r.URL.Query(); params.Has("from") || params.Has("to") || params.Has("status") || params.Has("limit") {
+			serveHistoryRange(w, r, manager, log)
+			return
+		}
+
+		names := manager.HistoryEndpoints()
+		if endpoint := r.URL.Query().Get("endpoint"); endpoint != "" {
+			if len(manager.History(endpoint)) == 0 {
+				http.Error(w, "no history for endpoint", http.StatusNotFound)
+				return
+			}
+			names = []string{endpoint}
+		}
+
+		response := HistoryResponse{
+			Time:      time.Now().UTC().Format(time.RFC3339),
+			Endpoints: make(map[string]EndpointHistory, len(names)),
+		}
+		var modified time.Time
+		for _, name := range names {
+			entries := manager.History(name)
+			history := EndpointHistory{
+				Latency: latencyFor(manager, name),
+				Entries: make([]HistoryEntryResponse, 0, len(entries)),
+			}
+			for _, entry := range entries {
+				if entry.CheckedAt.After(modified) {
+					modified = entry.CheckedAt
+				}
+				history.Entries = append(history.Entries, HistoryEntryResponse{
+					CheckedAt:      entry.CheckedAt.UTC().Format(time.RFC3339),
+					IsValid:        entry.IsValid,
+					ErrorType:      entry.ErrorType,
+					Message:        entry.Message,
+					ResponseTimeMs: entry.ResponseTimeMs,
+				})
+			}
+			response.Endpoints[name] = history
+		}
+
+		writeCachedJSON(w, r, log, response, response.Endpoints, modified)
+	}
+}
+
+// serveHistoryRange answers a range query from the persistent history store
+func serveHistoryRange(w http.ResponseWriter, r *http.Request, manager any, log *logrus.Logger) {
+	querier, ok := manager.(HistoryQuerier)
+	if !ok {
+		http.Error(w, exporter.ErrNoHistoryStore.Error(), http.StatusNotImplemented)
+		return
+	}
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// One extra record tells whether the limit cut the results short
+	limit := query.Limit
+	query.Limit++
+	records, err := querier.QueryHistory(r.Context(), query)
+	if errors.Is(err, exporter.ErrNoHistoryStore) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("History query failed")
+		http.Error(w, "history query failed", http.StatusInternalServerError)
+		return
+	}
+
+	response := HistoryResponse{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Endpoints: make(map[string]EndpointHistory),
+		Truncated: len(records) > limit,
+	}
+	if response.Truncated {
+		records = records[:limit]
+	}
+	var modified time.Time
+	for _, record := range records {
+		if record.CheckedAt.After(modified) {
+			modified = record.CheckedAt
+		}
+		history := response.Endpoints[record.Endpoint]
+		history.Entries = append(history.Entries, HistoryEntryResponse{
+			CheckedAt:      record.CheckedAt.UTC().Format(time.RFC3339),
+			IsValid:        record.IsValid,
+			ErrorType:      record.ErrorType,
+			Message:        record.Message,
+			ResponseTimeMs: record.ResponseTimeMs,
+		})
+		response.Endpoints[record.Endpoint] = history
+	}
+
+	writeCachedJSON(w, r, log, response, response.Endpoints, modified)
+}
+
+// parseHistoryQuery reads endpoint, from and to (RFC 3339), status and limit
+func parseHistoryQuery(r *http.Request) (exporter.HistoryQuery, error) {
+	params := r.URL.Query()
+	query := exporter.HistoryQuery{Endpoint: params.Get("endpoint"), Limit: defaultHistoryLimit}
+	for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := params.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 time, e.g. 2026-03-01T00:00:00Z", name)
+			}
+			*target = parsed
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return query, fmt.Errorf("from must be before to")
+	}
+	switch status := params.Get("status"); status {
+	case "", exporter.HistoryStatusValid, exporter.HistoryStatusInvalid:
+		query.Status = status
+	default:
+		return query, fmt.Errorf("status must be %q or %q", exporter.HistoryStatusValid, exporter.HistoryStatusInvalid)
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubHistoryManager struct {
+	stubManager
+	history map[string][]exporter.HistoryEntry
+}
+
+func (s *stubHistoryManager) History(name string) []exporter.HistoryEntry {
+	return s.history[name]
+}
+
+func (s *stubHistoryManager) HistoryEndpoints() []string {
+	names := make([]string, 0, len(s.history))
+	for name := range s.history {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *stubHistoryManager) Latency(name string) (exporter.LatencySummary, bool) {
+	if len(s.history[name]) == 0 {
+		return exporter.LatencySummary{}, false
+	}
+	return exporter.LatencySummary{Samples: len(s.history[name]), P50Ms: 20, P95Ms: 90, P99Ms: 120}, true
+}
+
+func newStubHistoryManager() *stubHistoryManager {
+	return &stubHistoryManager{history: map[string][]exporter.HistoryEntry{
+		"bucket-a": {
+			{CheckedAt: time.Unix(1730000000, 0), IsValid: true, ResponseTimeMs: 20},
+			{CheckedAt: time.Unix(1730000060, 0), IsValid: false, ErrorType: "timeout", ResponseTimeMs: 120},
+		},
+	}}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	handler := NewHistoryHandler(newStubHistoryManager(), logrus.New())
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/history", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var response HistoryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	history := response.Endpoints["bucket-a"]
+	if len(history.Entries) != 2 || history.Entries[1].ErrorType != "timeout" {
+		t.Fatalf("unexpected history entries: %+v", history.Entries)
+	}
+	if history.Latency == nil || history.Latency.P95Ms != 90 {
+		t.Fatalf("expected latency summary, got %+v", history.Latency)
+	}
+
+	rrMissing := httptest.NewRecorder()
+	handler(rrMissing, httptest.NewRequest(http.MethodGet, "/history?endpoint=unknown", nil))
+	if rrMissing.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an endpoint without history, got %d", rrMissing.Code)
+	}
+
+	rrMethod := httptest.NewRecorder()
+	handler(rrMethod, httptest.NewRequest(http.MethodPost, "/history", nil))
+	if rrMethod.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rrMethod.Code)
+	}
+}
+
+func TestValidateEndpointHandlerIncludesLatency(t *testing.T) {
+	mgr := newStubHistoryManager()
+	mgr.validateEndpointFunc = func(ctx context.Context, name string) *s3.ValidationResult {
+		return &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}
+	}
+
+	rr := serveRoute(validateEndpointRoute, NewValidateEndpointHandler(mgr, logrus.New()), httptest.NewRequest(http.MethodGet, "/validate/bucket-a", nil))
+
+	var response ValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Latency == nil || response.Latency.P50Ms != 20 || response.Latency.Samples != 2 {
+		t.Fatalf("expected latency percentiles in response, got %+v", response.Latency)
+	}
+}
+
+type stubHistoryStoreManager struct {
+	*stubHistoryManager
+	queries []exporter.HistoryQuery
+	records []exporter.HistoryRecord
+}
+
+func (s *stubHistoryStoreManager) QueryHistory(ctx context.Context, query exporter.HistoryQuery) ([]exporter.HistoryRecord, error) {
+	s.queries = append(s.queries, query)
+	return s.records[:min(len(s.records), query.Limit)], nil
+}
+
+func TestHistoryHandlerRangeQuery(t *testing.T) {
+	mgr := &stubHistoryStoreManager{
+		stubHistoryManager: newStubHistoryManager(),
+		records: []exporter.HistoryRecord{
+			{Endpoint: "bucket-a", HistoryEntry: exporter.HistoryEntry{CheckedAt: time.Unix(1730000000, 0), ErrorType: "timeout"}},
+			{Endpoint: "bucket-b", HistoryEntry: exporter.HistoryEntry{CheckedAt: time.Unix(1730000060, 0), ErrorType: "access_denied"}},
+			{Endpoint: "bucket-a", HistoryEntry: exporter.HistoryEntry{CheckedAt: time.Unix(1730000120, 0), ErrorType: "timeout"}},
+		},
+	}
+	handler := NewHistoryHandler(mgr, logrus.New())
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/history?from=2024-10-27T00:00:00Z&to=2024-10-28T00:00:00Z&status=invalid&limit=2", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response HistoryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.Truncated || len(response.Endpoints["bucket-a"].Entries) != 1 || len(response.Endpoints["bucket-b"].Entries) != 1 {
+		t.Fatalf("expected 2 of 3 records and truncated, got %+v", response)
+	}
+	query := mgr.queries[0]
+	if query.Status != exporter.HistoryStatusInvalid || !query.From.Equal(time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC)) || query.Limit != 3 {
+		t.Fatalf("unexpected query %+v", query)
+	}
+
+	for _, target := range []string{"/history?from=yesterday", "/history?status=broken", "/history?limit=0", "/history?from=2024-10-28T00:00:00Z&to=2024-10-27T00:00:00Z"} {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestHistoryHandlerRangeQueryWithoutStore(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHistoryHandler(newStubHistoryManager(), logrus.New())(rr, httptest.NewRequest(http.MethodGet, "/history?status=valid", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without a history store, got %d", rr.Code)
+	}
+}
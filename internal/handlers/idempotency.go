@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+)
+
+// IdempotencyKeyHeader lets clients retry POST /validate without starting another run
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentReplayedHeader marks a response served from an earlier run with the same key
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the keys kept in memory
+const maxIdempotencyKeyLength = 255
+
+// defaultIdempotencyTTL is how long results are replayed when no TTL is configured
+const defaultIdempotencyTTL = 10 * time.Minute
+
+// idempotencyEntry is a validate-all run started under a key. done is closed once
+// results is set; expires is zero while the run is in progress.
+type idempotencyEntry struct {
+	done    chan struct{}
+	results *exporter.ValidationResults
+	expires time.Time
+}
+
+// idempotencyCache remembers validate-all runs by Idempotency-Key, so a retried request
+// joins the run in progress or replays its results instead of probing every endpoint again
+type idempotencyCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyCache(ttl time.Duration, clk clock.Clock) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, clock: clock.OrReal(clk), entries: make(map[string]*idempotencyEntry)}
+}
+
+// do runs validate under key, or returns the results of the run already started under
+// it, waiting for that run while ctx allows. replayed reports the latter. Runs cut short
+// by a deadline or cancellation are handed to the requests waiting on them but not kept,
+// so a later retry validates again.
+func (c *idempotencyCache) do(ctx context.Context, key string, validate func() *exporter.ValidationResults) (results *exporter.ValidationResults, replayed bool, err error) {
+	c.mu.Lock()
+	c.expireLocked(c.clock.Now())
+	if entry, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-entry.done:
+			return entry.results, true, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+	entry := &idempotencyEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		entry.results = results
+		entry.expires = c.clock.Now().Add(c.ttl)
+		if results == nil || !completed(results) {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		close(entry.done)
+	}()
+	return validate(), false, nil
+}
+
+// idempotencyScope qualifies key with the authenticated principal, so a caller can only
+// join or replay runs it started itself, never another principal's results. Without
+// authentication every caller shares one scope.
+func idempotencyScope(r *http.Request, key string) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok && principal != nil {
+		return principal.Subject + "\x00" + key
+	}
+	return key
+}
+
+// expireLocked drops finished runs older than the TTL
+func (c *idempotencyCache) expireLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// completed reports whether every endpoint of a run finished
+func completed(results *exporter.ValidationResults) bool {
+	for _, result := range results.Results {
+		if exporter.IsUnfinished(result.ErrorType) {
+			return false
+		}
+	}
+	return true
+}
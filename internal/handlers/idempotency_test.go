@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+func validResults() *exporter.ValidationResults {
+	return &exporter.ValidationResults{
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Results:   map[string]*s3.ValidationResult{"a": {IsValid: true, Message: "ok", CheckedAt: time.Now()}},
+	}
+}
+
+func TestIdempotencyCacheJoinsRunInProgress(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute, nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	firstDone := make(chan *exporter.ValidationResults)
+	go func() {
+		results, _, _ := cache.do(context.Background(), "key", func() *exporter.ValidationResults {
+			close(started)
+			<-release
+			return validResults()
+		})
+		firstDone <- results
+	}()
+	<-started
+
+	joined := make(chan bool)
+	go func() {
+		_, replayed, err := cache.do(context.Background(), "key", func() *exporter.ValidationResults {
+			t.Errorf("expected the retry to join the run in progress")
+			return validResults()
+		})
+		joined <- replayed && err == nil
+	}()
+
+	close(release)
+	first := <-firstDone
+	if !<-joined {
+		t.Fatalf("expected the retry to be served the first run's results")
+	}
+	if first == nil || !first.Results["a"].IsValid {
+		t.Fatalf("expected the first run's results, got %+v", first)
+	}
+}
+
+func TestIdempotencyCacheExpiresResults(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	cache := newIdempotencyCache(time.Minute, clk)
+	var runs int
+	validate := func() *exporter.ValidationResults {
+		runs++
+		return validResults()
+	}
+
+	cache.do(context.Background(), "key", validate)
+	if _, replayed, _ := cache.do(context.Background(), "key", validate); !replayed || runs != 1 {
+		t.Fatalf("expected results to be replayed within the TTL, got replayed=%v runs=%d", replayed, runs)
+	}
+
+	clk.Advance(time.Minute)
+	if _, replayed, _ := cache.do(context.Background(), "key", validate); replayed || runs != 2 {
+		t.Fatalf("expected a new run once the TTL passed, got replayed=%v runs=%d", replayed, runs)
+	}
+}
+
+func TestIdempotencyCacheDoesNotKeepUnfinishedRuns(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute, nil)
+	var runs int
+	validate := func() *exporter.ValidationResults {
+		runs++
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"a": {IsValid: false, ErrorType: exporter.ErrorTypeTimedOut},
+		}}
+	}
+
+	cache.do(context.Background(), "key", validate)
+	if _, replayed, _ := cache.do(context.Background(), "key", validate); replayed || runs != 2 {
+		t.Fatalf("expected a retry after a timed-out run to validate again, got replayed=%v runs=%d", replayed, runs)
+	}
+}
+
+func TestValidateAllHandlerReplaysIdempotentRequests(t *testing.T) {
+	var runs atomic.Int32
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		runs.Add(1)
+		return validResults()
+	}}
+	handler := NewValidateAllHandler(mgr, logrus.New())
+
+	post := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	if rr := post("run-1"); rr.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatalf("expected the first request not to be marked as replayed")
+	}
+	rr := post("run-1")
+	var response MultiValidationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK || !response.Replayed || rr.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Fatalf("expected the retry to be a replay, got %d %+v", rr.Code, response)
+	}
+	if len(response.Results) != 1 || !response.Results["a"].IsValid {
+		t.Fatalf("expected the original results, got %+v", response.Results)
+	}
+
+	post("run-2")
+	post("")
+	if got := runs.Load(); got != 3 {
+		t.Fatalf("expected one run per key plus one without a key, got %d", got)
+	}
+
+	streamed := httptest.NewRequest(http.MethodPost, "/validate?stream=true", nil)
+	streamed.Header.Set(IdempotencyKeyHeader, "run-1")
+	rrStream := httptest.NewRecorder()
+	handler(rrStream, streamed)
+	results, summary := readStream(t, rrStream)
+	if len(results) != 1 || !summary.Replayed || runs.Load() != 3 {
+		t.Fatalf("expected a streamed replay of the original run, got %+v %+v", results, summary)
+	}
+}
+
+func TestValidateAllHandlerScopesIdempotencyKeysByPrincipal(t *testing.T) {
+	var runs atomic.Int32
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		runs.Add(1)
+		return validResults()
+	}}
+	handler := NewValidateAllHandler(mgr, logrus.New())
+
+	post := func(subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		req.Header.Set(IdempotencyKeyHeader, "shared")
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: subject}))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	post("alice")
+	if rr := post("bob"); rr.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("expected another principal's key not to replay the first run")
+	}
+	if rr := post("alice"); rr.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Fatal("expected the same principal's retry to be a replay")
+	}
+	if got := runs.Load(); got != 2 {
+		t.Fatalf("expected one run per principal, got %d", got)
+	}
+}
+
+func TestValidateAllHandlerIdempotencyConflict(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		close(started)
+		<-release
+		return validResults()
+	}}
+	handler := NewValidateAllHandler(mgr, logrus.New())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		req.Header.Set(IdempotencyKeyHeader, "slow")
+		handler(httptest.NewRecorder(), req)
+	}()
+	<-started
+
+	req := httptest.NewRequest(http.MethodPost, "/validate?timeout=10ms", nil)
+	req.Header.Set(IdempotencyKeyHeader, "slow")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	close(release)
+	<-done
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when the run in progress outlasts the retry, got %d", rr.Code)
+	}
+}
+
+func TestValidateAllHandlerIdempotencyDisabled(t *testing.T) {
+	var runs int
+	mgr := &stubManager{validateAllFunc: func(ctx context.Context) *exporter.ValidationResults {
+		runs++
+		return validResults()
+	}}
+	handler := NewValidateAllHandler(mgr, logrus.New(), WithIdempotencyTTL(0))
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		handler(httptest.NewRecorder(), req)
+	}
+	if runs != 2 {
+		t.Fatalf("expected the key to be ignored when disabled, got %d runs", runs)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultInjectionDuration is how long a failure is injected without a duration parameter
+const defaultInjectionDuration = 10 * time.Minute
+
+// FailureInjector makes endpoints report synthetic failures
+type FailureInjector interface {
+	InjectFailure(endpointName, errorType string, duration time.Duration) (time.Time, error)
+	ClearInjectedFailure(endpointName string) bool
+}
+
+// InjectionResponse describes the failure injected into an endpoint
+type InjectionResponse struct {
+	Endpoint  string `json:"endpoint"`
+	ErrorType string `json:"error_type"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// NewInjectFailureHandler returns a handler injecting failures for chaos testing. POST
+// takes error_type and an optional duration (default 10m) as query or form parameters;
+// DELETE stops the injection early.
+// Expected routes: POST and DELETE /admin/inject-failure/{endpoint}
+func NewInjectFailureHandler(injector FailureInjector, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpointName := r.PathValue("endpoint")
+		if endpointName == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodDelete:
+			if !injector.ClearInjectedFailure(endpointName) {
+				http.Error(w, "no failure is injected into "+endpointName, http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodPost:
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		duration := defaultInjectionDuration
+		if value := r.FormValue("duration"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				http.Error(w, "invalid duration: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			duration = parsed
+		}
+		errorType := r.FormValue("error_type")
+
+		expires, err := injector.InjectFailure(endpointName, errorType, duration)
+		switch {
+		case errors.Is(err, exporter.ErrEndpointNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, exporter.ErrInvalidInjection):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		response := InjectionResponse{Endpoint: endpointName, ErrorType: errorType, ExpiresAt: expires.UTC().Format(time.RFC3339)}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode injection response: %v", err)
+		}
+	}
+}
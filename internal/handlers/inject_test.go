@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubInjector struct {
+	errorType string
+	duration  time.Duration
+	active    bool
+}
+
+func (s *stubInjector) InjectFailure(endpointName, errorType string, duration time.Duration) (time.Time, error) {
+	switch {
+	case endpointName != "chaos":
+		return time.Time{}, fmt.Errorf("%w: %q", exporter.ErrEndpointNotFound, endpointName)
+	case errorType != "access_denied":
+		return time.Time{}, fmt.Errorf("%w: bad type", exporter.ErrInvalidInjection)
+	}
+	s.errorType, s.duration, s.active = errorType, duration, true
+	return time.Unix(1700000600, 0), nil
+}
+
+func (s *stubInjector) ClearInjectedFailure(endpointName string) bool {
+	active := s.active
+	s.active = false
+	return active
+}
+
+func TestInjectFailureHandler(t *testing.T) {
+	injector := &stubInjector{}
+	mux := http.NewServeMux()
+	handler := NewInjectFailureHandler(injector, logrus.New())
+	mux.HandleFunc("POST /admin/inject-failure/{endpoint}", handler)
+	mux.HandleFunc("DELETE /admin/inject-failure/{endpoint}", handler)
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	rr := serve(http.MethodPost, "/admin/inject-failure/chaos?error_type=access_denied")
+	var response InjectionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with the injection, got %d %v", rr.Code, err)
+	}
+	if response.ErrorType != "access_denied" || response.ExpiresAt != "2023-11-14T22:23:20Z" || injector.duration != defaultInjectionDuration {
+		t.Fatalf("unexpected injection %+v with duration %s", response, injector.duration)
+	}
+
+	serve(http.MethodPost, "/admin/inject-failure/chaos?error_type=access_denied&duration=90s")
+	if injector.duration != 90*time.Second {
+		t.Fatalf("expected the requested duration, got %s", injector.duration)
+	}
+
+	for target, code := range map[string]int{
+		"/admin/inject-failure/chaos?error_type=access_denied&duration=soon": http.StatusBadRequest,
+		"/admin/inject-failure/chaos?error_type=exploded":                    http.StatusBadRequest,
+		"/admin/inject-failure/missing?error_type=access_denied":             http.StatusNotFound,
+	} {
+		if rr := serve(http.MethodPost, target); rr.Code != code {
+			t.Fatalf("expected %d for %s, got %d", code, target, rr.Code)
+		}
+	}
+
+	if rr := serve(http.MethodDelete, "/admin/inject-failure/chaos"); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 when clearing an injection, got %d", rr.Code)
+	}
+	if rr := serve(http.MethodDelete, "/admin/inject-failure/chaos"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without an active injection, got %d", rr.Code)
+	}
+}
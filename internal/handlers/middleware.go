@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/oidc"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithMiddleware wraps mux in the middleware cfg enables, innermost first: OIDC
+// authentication, the client allowlist, CORS and the trusted proxies resolving the
+// client address for all of them. Refused requests are counted in m.
+func WithMiddleware(mux *http.ServeMux, cfg *config.Config, m *metrics.Metrics, log *logrus.Logger) http.Handler {
+	var handler http.Handler = mux
+	if cfg.OIDC != nil {
+		authOpts := []AuthOption{WithAuthMetrics(m)}
+		if cfg.RBAC != nil {
+			authOpts = append(authOpts, WithAuthorizer(auth.NewRBAC(cfg.RBAC.Roles)))
+		}
+		handler = WithAuth(mux, oidc.NewVerifier(*cfg.OIDC, log), log, authOpts...)
+		log.WithFields(logrus.Fields{
+			"issuer":   cfg.OIDC.Issuer,
+			"audience": cfg.OIDC.Audience,
+			"rbac":     cfg.RBAC != nil,
+		}).Info("OIDC authentication enabled")
+	}
+	if len(cfg.AllowedClientCIDRs) > 0 {
+		handler = WithClientAllowlist(handler, cfg.AllowedClientCIDRs, m, log)
+		log.WithField("allowed", cfg.AllowedClientCIDRs).Info("Validation and admin routes limited to allowed clients")
+	}
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		handler = WithCORS(handler, CORSOptions{
+			AllowedOrigins: cfg.CORSAllowedOrigins,
+			AllowedMethods: cfg.CORSAllowedMethods,
+			AllowedHeaders: cfg.CORSAllowedHeaders,
+		})
+	}
+	if len(cfg.TrustedProxies) > 0 {
+		handler = WithTrustedProxies(handler, cfg.TrustedProxies)
+	}
+	return handler
+}
+
+// RouterOptions returns the options of the validation routes set by cfg, recording
+// into m
+func RouterOptions(cfg *config.Config, m *metrics.Metrics) []ValidateAllOption {
+	return []ValidateAllOption{
+		WithRequestBudget(cfg.ValidateRequestTimeout),
+		WithIdempotencyTTL(cfg.IdempotencyKeyTTL),
+		WithRateLimit(cfg.ValidateRateLimit, cfg.ValidateRateBurst),
+		WithMetrics(m),
+	}
+}
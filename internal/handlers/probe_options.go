@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+)
+
+// OptionsValidator is implemented by managers that validate with per-run probe overrides
+type OptionsValidator interface {
+	ValidateEndpointWith(ctx context.Context, endpointName string, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult
+}
+
+// maxProbeOptionsBody bounds the POST /validate/{endpoint} body
+const maxProbeOptionsBody = 4 << 10
+
+// maxProbeTimeout bounds the timeout a request body may ask for. A request asking for a
+// timeout extends its write deadline to match, past the server's write timeout.
+const maxProbeTimeout = 5 * time.Minute
+
+// ProbeOptionsRequest is the optional POST /validate/{endpoint} body overriding how that
+// single run probes the bucket
+type ProbeOptionsRequest struct {
+	Depth     string `json:"depth,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Timeout   string `json:"timeout,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+}
+
+// decodeProbeOptions reads probe overrides from the request body. ok is false for
+// requests without a body, which validate as configured.
+func decodeProbeOptions(w http.ResponseWriter, r *http.Request) (opts s3.ProbeOptions, timeout time.Duration, ok bool, err error) {
+	if r.Method != http.MethodPost || r.Body == nil || r.Body == http.NoBody {
+		return opts, 0, false, nil
+	}
+
+	var body ProbeOptionsRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProbeOptionsBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return opts, 0, false, nil
+		}
+		return opts, 0, false, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	opts, timeout, err = body.parse()
+	if err != nil {
+		return opts, 0, false, err
+	}
+	return opts, timeout, true, nil
+}
+
+// parse checks the overrides and converts them to probe options and a timeout
+func (body ProbeOptionsRequest) parse() (opts s3.ProbeOptions, timeout time.Duration, err error) {
+	if body.Timeout != "" {
+		timeout, err = time.ParseDuration(body.Timeout)
+		if err != nil || timeout <= 0 || timeout > maxProbeTimeout {
+			return opts, 0, fmt.Errorf("timeout must be a positive duration up to %s", maxProbeTimeout)
+		}
+	}
+
+	opts = s3.ProbeOptions{Depth: s3.ProbeDepth(body.Depth), Operation: body.Operation, Prefix: body.Prefix}
+	if err := opts.Validate(); err != nil {
+		return opts, 0, err
+	}
+	return opts, timeout, nil
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type optionsManager struct {
+	stubManager
+	calls   int
+	timeout time.Duration
+	opts    s3.ProbeOptions
+	delay   time.Duration
+}
+
+func (o *optionsManager) ValidateEndpointWith(ctx context.Context, name string, timeout time.Duration, opts s3.ProbeOptions) *s3.ValidationResult {
+	time.Sleep(o.delay)
+	o.calls++
+	o.timeout, o.opts = timeout, opts
+	return &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", CheckedAt: time.Now()}
+}
+
+func postOptions(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	return serveRoute(validateEndpointRoute, handler, httptest.NewRequest(http.MethodPost, "/validate/bucket-a", strings.NewReader(body)))
+}
+
+func TestValidateEndpointHandlerProbeOptions(t *testing.T) {
+	mgr := &optionsManager{}
+	handler := NewValidateEndpointHandler(mgr, logrus.New())
+
+	rr := postOptions(handler, `{"depth":"shallow","operation":"GetObject","timeout":"45s","prefix":"logs/"}`)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected the override result to keep the status mapping, got %d", rr.Code)
+	}
+	want := s3.ProbeOptions{Depth: s3.ProbeDepthShallow, Operation: s3.OperationGetObject, Prefix: "logs/"}
+	if mgr.calls != 1 || mgr.timeout != 45*time.Second || mgr.opts != want {
+		t.Fatalf("expected the body to reach the manager, got %d calls %s %+v", mgr.calls, mgr.timeout, mgr.opts)
+	}
+
+	if rr := postOptions(handler, ""); rr.Code != http.StatusOK || mgr.calls != 1 {
+		t.Fatalf("expected a request without a body to validate as configured, got %d", rr.Code)
+	}
+
+	for _, body := range []string{
+		`{"depth":"thorough"}`,
+		`{"operation":"HeadBucket"}`,
+		`{"timeout":"-1s"}`,
+		`{"timeout":"1h"}`,
+		`{"unknown":true}`,
+		`not json`,
+	} {
+		if rr := postOptions(handler, body); rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, rr.Code)
+		}
+	}
+	if mgr.calls != 1 {
+		t.Fatalf("expected rejected bodies not to validate, got %d calls", mgr.calls)
+	}
+}
+
+func TestValidateEndpointHandlerProbeOptionsUnsupported(t *testing.T) {
+	handler := NewValidateEndpointHandler(&stubManager{}, logrus.New())
+	if rr := postOptions(handler, `{"depth":"deep"}`); rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 when the manager cannot apply probe options, got %d", rr.Code)
+	}
+}
+
+func TestValidateEndpointHandlerProbeTimeoutOutlastsWriteTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(validateEndpointRoute, NewValidateEndpointHandler(&optionsManager{delay: 150 * time.Millisecond}, logrus.New()))
+	server := httptest.NewUnstartedServer(mux)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/validate/bucket-a", "application/json", strings.NewReader(`{"timeout":"1s"}`))
+	if err != nil {
+		t.Fatalf("expected the response to be written within the requested timeout: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the probe result, got %d", resp.StatusCode)
+	}
+}
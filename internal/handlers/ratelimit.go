@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+)
+
+// maxRateLimitClients bounds the buckets kept in memory; full buckets are dropped first,
+// then the least recently used
+const maxRateLimitClients = 10000
+
+// WithRateLimit limits each client to perMinute validation requests with bursts of up
+// to burst, answering the rest with 429. NewRouter applies it to the routes that run
+// validations; 0 disables the limit and a burst below 1 defaults to perMinute.
+func WithRateLimit(perMinute, burst int) ValidateAllOption {
+	return func(s *validateAllSettings) {
+		s.ratePerMinute = perMinute
+		s.rateBurst = burst
+	}
+}
+
+// tokenBucket is one client's allowance; tokens is the count at updated
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateDecision is the outcome of one request against a client's bucket
+type rateDecision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration // until the next request is allowed
+	reset      time.Duration // until the bucket is full again
+}
+
+// rateLimiter is a token bucket per client, refilled at rate tokens per second
+type rateLimiter struct {
+	rate  float64
+	burst int
+	clock clock.Clock
+
+	metrics *metrics.Metrics
+
+	mu         sync.Mutex
+	clients    map[string]*tokenBucket
+	maxClients int
+}
+
+func newRateLimiter(perMinute, burst int, clk clock.Clock) *rateLimiter {
+	if burst < 1 {
+		burst = perMinute
+	}
+	return &rateLimiter{
+		rate:       float64(perMinute) / 60,
+		burst:      burst,
+		clock:      clock.OrReal(clk),
+		metrics:    metrics.Default,
+		clients:    make(map[string]*tokenBucket),
+		maxClients: maxRateLimitClients,
+	}
+}
+
+// take spends a token from the client's bucket if one is available
+func (l *rateLimiter) take(client string) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	bucket, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= l.maxClients {
+			l.pruneLocked(now)
+		}
+		bucket = &tokenBucket{tokens: float64(l.burst), updated: now}
+		l.clients[client] = bucket
+	}
+	bucket.tokens = math.Min(float64(l.burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	decision := rateDecision{limit: l.burst}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.allowed = true
+	} else {
+		decision.retryAfter = l.until(1 - bucket.tokens)
+	}
+	decision.remaining = int(bucket.tokens)
+	decision.reset = l.until(float64(l.burst) - bucket.tokens)
+	return decision
+}
+
+// until returns how long refilling tokens takes
+func (l *rateLimiter) until(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// pruneLocked drops buckets that have refilled completely, which behave like new ones.
+// When every client is still spending, the least recently updated buckets go until
+// there is room for one more, so the map stays bounded under a flood of new clients.
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	for client, bucket := range l.clients {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.clients, client)
+		}
+	}
+	if len(l.clients) < l.maxClients {
+		return
+	}
+
+	clients := slices.Collect(maps.Keys(l.clients))
+	slices.SortFunc(clients, func(a, b string) int {
+		return l.clients[a].updated.Compare(l.clients[b].updated)
+	})
+	for _, client := range clients[:len(clients)-l.maxClients+1] {
+		delete(l.clients, client)
+	}
+}
+
+// limit wraps next so requests over the client's allowance get 429 with Retry-After.
+// Every response carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset,
+// the seconds until the allowance is full again.
+func (l *rateLimiter) limit(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decision := l.take(clientIP(r))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.reset)))
+		if !decision.allowed {
+			l.metrics.RecordRateLimited(route)
+			retryAfter := ceilSeconds(decision.retryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, fmt.Sprintf("rate limit exceeded, retry in %ds", retryAfter), http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
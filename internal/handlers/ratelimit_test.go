@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	limiter := newRateLimiter(6, 2, clk)
+
+	for i := range 2 {
+		if d := limiter.take("a"); !d.allowed || d.remaining != 1-i {
+			t.Fatalf("expected request %d within the burst, got %+v", i, d)
+		}
+	}
+	denied := limiter.take("a")
+	if denied.allowed || denied.retryAfter != 10*time.Second || denied.reset != 20*time.Second {
+		t.Fatalf("expected a denial with a 10s retry and 20s reset at 6/min, got %+v", denied)
+	}
+	if d := limiter.take("b"); !d.allowed {
+		t.Fatalf("expected clients to be limited separately")
+	}
+
+	clk.Advance(10 * time.Second)
+	if d := limiter.take("a"); !d.allowed || d.remaining != 0 {
+		t.Fatalf("expected one token after 10s, got %+v", d)
+	}
+}
+
+func TestRateLimiterEvictsLeastRecentlyUsedAtCap(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	limiter := newRateLimiter(1, 5, clk)
+	limiter.maxClients = 3
+
+	for _, client := range []string{"a", "b", "c"} {
+		limiter.take(client)
+		clk.Advance(time.Second)
+	}
+	limiter.take("a") // now b is the least recently used
+	limiter.take("d")
+
+	if len(limiter.clients) != 3 {
+		t.Fatalf("expected the cap to hold, got %d clients", len(limiter.clients))
+	}
+	if _, ok := limiter.clients["b"]; ok {
+		t.Fatalf("expected the least recently used bucket to be evicted")
+	}
+	for _, client := range []string{"a", "c", "d"} {
+		if _, ok := limiter.clients[client]; !ok {
+			t.Fatalf("expected %s to be kept", client)
+		}
+	}
+}
+
+func TestRateLimitedRouteHeaders(t *testing.T) {
+	before := testutil.ToFloat64(metrics.HTTPRateLimited.WithLabelValues("validate"))
+	limiter := newRateLimiter(60, 1, clock.NewFake(time.Unix(1700000000, 0)))
+	handler := limiter.limit("validate", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		req.RemoteAddr = "10.0.0.1:5555"
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := post()
+	if rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "1" || rr.Header().Get("X-RateLimit-Remaining") != "0" || rr.Header().Get("X-RateLimit-Reset") != "1" {
+		t.Fatalf("expected rate limit headers on an allowed request, got %d %v", rr.Code, rr.Header())
+	}
+	rr = post()
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", rr.Code, rr.Header())
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRateLimited.WithLabelValues("validate")) - before; got != 1 {
+		t.Fatalf("expected one rejection to be counted, got %v", got)
+	}
+}
+
+func TestRouterRateLimitsValidationRoutes(t *testing.T) {
+	router := NewRouter(newStubRouterManager(), logrus.New(), WithRateLimit(1, 1))
+
+	serve := func(method, path string) int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr.Code
+	}
+	if code := serve(http.MethodPost, "/validate"); code != http.StatusOK {
+		t.Fatalf("expected the first validation to pass, got %d", code)
+	}
+	if code := serve(http.MethodPost, "/validate/bucket-a"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected validation routes to share the limit, got %d", code)
+	}
+	if code := serve(http.MethodGet, "/validate"); code != http.StatusOK {
+		t.Fatalf("expected cached results not to be limited, got %d", code)
+	}
+}
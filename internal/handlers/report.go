@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed templates/report.html
+var reportTemplates embed.FS
+
+var reportTemplate = template.Must(template.ParseFS(reportTemplates, "templates/report.html"))
+
+// Status colors of the HTML report
+const (
+	reportColorValid     = "#2e7d32"
+	reportColorInvalid   = "#c62828"
+	reportColorUnchecked = "#757575"
+)
+
+// ReportSource provides the configured endpoints and their recent history
+type ReportSource interface {
+	HistoryReporter
+	GetEndpoints() []string
+}
+
+type reportError struct {
+	ErrorType string
+	Message   string
+	At        string
+}
+
+type reportRow struct {
+	Name           string
+	Status         string
+	Color          string
+	CheckedAt      string
+	ResponseTimeMs int64
+	Latency        *LatencyResponse
+	LastError      *reportError
+}
+
+type reportPage struct {
+	Title       string
+	GeneratedAt string
+	Valid       int
+	Invalid     int
+	Unchecked   int
+	Rows        []reportRow
+}
+
+// NewReportHandler returns a handler rendering the latest state of every endpoint as
+// a self-contained HTML table. It reads the history buffer and never triggers validation.
+func NewReportHandler(manager ReportSource, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		page := reportPage{
+			Title:       "S3 key validation report",
+			GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		}
+		for _, name := range manager.GetEndpoints() {
+			row := newReportRow(name, manager)
+			switch row.Status {
+			case "valid":
+				page.Valid++
+			case "invalid":
+				page.Invalid++
+			default:
+				page.Unchecked++
+			}
+			page.Rows = append(page.Rows, row)
+		}
+
+		var buf bytes.Buffer
+		if err := reportTemplate.Execute(&buf, page); err != nil {
+			log.Errorf("Failed to render report: %v", err)
+			http.Error(w, "failed to render report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Errorf("Failed to write report: %v", err)
+		}
+	}
+}
+
+// newReportRow summarizes the endpoint's latest result and most recent failure
+func newReportRow(name string, manager ReportSource) reportRow {
+	row := reportRow{Name: name, Status: "unchecked", Color: reportColorUnchecked}
+
+	entries := manager.History(name)
+	if len(entries) == 0 {
+		return row
+	}
+
+	latest := entries[len(entries)-1]
+	row.CheckedAt = latest.CheckedAt.UTC().Format(time.RFC3339)
+	row.ResponseTimeMs = latest.ResponseTimeMs
+	row.Latency = latencyFor(manager, name)
+	if latest.IsValid {
+		row.Status, row.Color = "valid", reportColorValid
+	} else {
+		row.Status, row.Color = "invalid", reportColorInvalid
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].IsValid {
+			row.LastError = &reportError{
+				ErrorType: entries[i].ErrorType,
+				Message:   entries[i].Message,
+				At:        entries[i].CheckedAt.UTC().Format(time.RFC3339),
+			}
+			break
+		}
+	}
+	return row
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubReportSource struct {
+	*stubHistoryManager
+	endpoints []string
+}
+
+func (s *stubReportSource) GetEndpoints() []string {
+	return s.endpoints
+}
+
+func TestReportHandler(t *testing.T) {
+	history := newStubHistoryManager()
+	history.history["bucket-b"] = []exporter.HistoryEntry{
+		{CheckedAt: time.Unix(1730000000, 0), IsValid: false, ErrorType: "access_denied", Message: "<denied>", ResponseTimeMs: 30},
+		{CheckedAt: time.Unix(1730000060, 0), IsValid: true, ResponseTimeMs: 25},
+	}
+	source := &stubReportSource{stubHistoryManager: history, endpoints: []string{"bucket-a", "bucket-b", "bucket-c"}}
+
+	rr := httptest.NewRecorder()
+	NewReportHandler(source, logrus.New())(rr, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected HTML content type, got %q", ct)
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		"1 valid, 1 invalid, 1 unchecked",
+		reportColorInvalid,
+		reportColorValid,
+		reportColorUnchecked,
+		"<code>timeout</code>",
+		"90 / 120 ms",
+		"&lt;denied&gt;",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in report:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "<denied>") {
+		t.Fatalf("expected error messages to be escaped")
+	}
+}
+
+func TestReportHandlerMethodNotAllowed(t *testing.T) {
+	source := &stubReportSource{stubHistoryManager: newStubHistoryManager()}
+
+	rr := httptest.NewRecorder()
+	NewReportHandler(source, logrus.New())(rr, httptest.NewRequest(http.MethodPost, "/report", nil))
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+}
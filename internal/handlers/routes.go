@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Manager is everything the HTTP API needs from the validator manager
+type Manager interface {
+	Validator
+	ProviderReporter
+	ReportSource
+	Discoverer
+	CycleReporter
+}
+
+// NewRouter returns a mux serving the HTTP API. Routes match on method and path, so
+// unknown paths get 404 and known paths with another method get 405 with an Allow
+// header. An endpoint name is one path segment; percent-encode names containing '/',
+// e.g. /validate/team%2Fbackups. With WithRateLimit, the routes that run validations
+// share one per-client limit; with WithSigner, the routes returning results are signed.
+func NewRouter(manager Manager, log *logrus.Logger, opts ...ValidateAllOption) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", NewHealthCheckHandler(manager))
+	mux.HandleFunc("GET /providers", NewProvidersHandler(manager, log))
+	mux.HandleFunc("GET /history", NewHistoryHandler(manager, log))
+	mux.HandleFunc("GET /report", NewReportHandler(manager, log))
+	mux.HandleFunc("GET /cycles/latest", NewLatestCycleHandler(manager, log))
+	mux.HandleFunc("GET /cycles/{id}/diff", NewCycleDiffHandler(manager, log))
+	if reporter, ok := manager.(AvailabilityReporter); ok {
+		mux.HandleFunc("GET /sla/history/{endpoint}", NewSLAHistoryHandler(reporter, log))
+	}
+	validateAll := NewValidateAllHandler(manager, log, opts...)
+	validateEndpoint := NewValidateEndpointHandler(manager, log)
+
+	var settings validateAllSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if settings.ratePerMinute > 0 {
+		limiter := newRateLimiter(settings.ratePerMinute, settings.rateBurst, clock.Real)
+		if settings.metrics != nil {
+			limiter.metrics = settings.metrics
+		}
+		validateAll = limiter.limit("validate", validateAll)
+		validateEndpoint = limiter.limit("validate_endpoint", validateEndpoint)
+	}
+	cachedResults := NewCachedResultsHandler(manager, log)
+	if settings.signer != nil {
+		validateAll = signResponses(settings.signer, validateAll)
+		validateEndpoint = signResponses(settings.signer, validateEndpoint)
+		cachedResults = signResponses(settings.signer, cachedResults)
+		mux.HandleFunc("GET /signing-key", NewSigningKeyHandler(settings.signer, log))
+	}
+
+	mux.HandleFunc("GET /validate", cachedResults)
+	mux.HandleFunc("POST /validate", validateAll)
+	mux.HandleFunc("GET /validate/{endpoint}", validateEndpoint)
+	mux.HandleFunc("POST /validate/{endpoint}", validateEndpoint)
+
+	mux.HandleFunc("GET /endpoints", NewEndpointsHandler(manager, log))
+	mux.HandleFunc("POST /endpoints/{endpoint}/discover", NewDiscoverHandler(manager, log))
+	return mux
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Routes without a method, so tests also reach the handlers' own method checks
+const (
+	validateEndpointRoute = "/validate/{endpoint}"
+	discoverRoute         = "/endpoints/{endpoint}/discover"
+)
+
+// serveRoute serves req through a mux with handler registered under pattern, which is
+// what fills in the path values the handlers read
+func serveRoute(pattern string, handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, handler)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+type stubRouterManager struct {
+	*stubReportSource
+	stubProviderReporter
+	stubDiscoverer
+	stubCycleReporter
+	validated []string
+}
+
+func newStubRouterManager() *stubRouterManager {
+	m := &stubRouterManager{stubReportSource: &stubReportSource{stubHistoryManager: newStubHistoryManager()}}
+	m.validateEndpointFunc = func(ctx context.Context, name string) *s3.ValidationResult {
+		m.validated = append(m.validated, name)
+		return &s3.ValidationResult{IsValid: true, CheckedAt: time.Now()}
+	}
+	return m
+}
+
+func TestRouterMatchesMethodAndPath(t *testing.T) {
+	router := NewRouter(newStubRouterManager(), logrus.New())
+
+	cases := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/health", http.StatusOK},
+		{http.MethodHead, "/health", http.StatusOK},
+		{http.MethodDelete, "/health", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/providers", http.StatusOK},
+		{http.MethodGet, "/history", http.StatusOK},
+		{http.MethodGet, "/report", http.StatusOK},
+		{http.MethodGet, "/cycles/latest", http.StatusNotFound},
+		{http.MethodPost, "/cycles/latest", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/cycles/1/diff", http.StatusNotFound},
+		{http.MethodPost, "/validate", http.StatusOK},
+		{http.MethodGet, "/validate", http.StatusOK},
+		{http.MethodPut, "/validate", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/validate/bucket-a", http.StatusOK},
+		{http.MethodPost, "/validate/bucket-a", http.StatusOK},
+		{http.MethodPut, "/validate/bucket-a", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/validate/", http.StatusNotFound},
+		{http.MethodGet, "/endpoints", http.StatusOK},
+		{http.MethodPost, "/endpoints/bucket-a/discover", http.StatusOK},
+		{http.MethodGet, "/endpoints/bucket-a/discover", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/endpoints/bucket-a/unknown", http.StatusNotFound},
+		{http.MethodGet, "/unknown", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		if rr.Code != tc.want {
+			t.Fatalf("%s %s: expected %d, got %d", tc.method, tc.path, tc.want, rr.Code)
+		}
+		if tc.want == http.StatusMethodNotAllowed && rr.Header().Get("Allow") == "" {
+			t.Fatalf("%s %s: expected an Allow header with the 405", tc.method, tc.path)
+		}
+	}
+}
+
+func TestRouterEndpointNames(t *testing.T) {
+	manager := newStubRouterManager()
+	router := NewRouter(manager, logrus.New())
+
+	for _, path := range []string{"/validate/team%2Fbackups", "/validate/a/b"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if path == "/validate/a/b" && rr.Code != http.StatusNotFound {
+			t.Fatalf("expected a nested path to be 404 instead of validating its last segment, got %d", rr.Code)
+		}
+	}
+
+	if len(manager.validated) != 1 || manager.validated[0] != "team/backups" {
+		t.Fatalf("expected only the percent-encoded name to be validated, got %v", manager.validated)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/signing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithSigner signs validate responses with an Ed25519 key and serves its public key at
+// GET /signing-key. NewRouter applies it to the routes returning validation results;
+// streamed responses are not signed.
+func WithSigner(signer *signing.Signer) ValidateAllOption {
+	return func(s *validateAllSettings) {
+		s.signer = signer
+	}
+}
+
+// SigningKeyResponse is the public key signed responses are verified with
+type SigningKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // raw 32-byte key, base64 encoded
+	PEM       string `json:"pem"`
+}
+
+// NewSigningKeyHandler returns a handler serving the public key of signer
+func NewSigningKeyHandler(signer *signing.Signer, log *logrus.Logger) http.HandlerFunc {
+	response := SigningKeyResponse{
+		Algorithm: "ed25519",
+		KeyID:     signer.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(signer.PublicKey()),
+		PEM:       signer.PublicKeyPEM(),
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Errorf("Failed to encode signing key: %v", err)
+		}
+	}
+}
+
+// signResponses buffers the response of next and signs its body. Streamed responses
+// pass through unsigned, since their body is not known until the stream ends.
+func signResponses(signer *signing.Signer, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if stream, _ := streamRequested(r); stream {
+			next(w, r)
+			return
+		}
+
+		buffered := &bufferedResponse{underlying: w, header: make(http.Header), status: http.StatusOK}
+		next(buffered, r)
+
+		for name, values := range buffered.header {
+			w.Header()[name] = values
+		}
+		if buffered.body.Len() > 0 {
+			signer.SetHeaders(w.Header(), buffered.body.Bytes())
+		}
+		w.WriteHeader(buffered.status)
+		w.Write(buffered.body.Bytes())
+	}
+}
+
+// bufferedResponse collects a response so it can be signed before it is sent
+type bufferedResponse struct {
+	underlying  http.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(data)
+}
+
+// SetWriteDeadline moves the deadline of the underlying response, which is written once
+// the handler returns
+func (b *bufferedResponse) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(b.underlying).SetWriteDeadline(deadline)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"key-aws-exporter/internal/signing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRouterSignsResults(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := signing.NewSigner(key)
+	router := NewRouter(newStubRouterManager(), logrus.New(), WithSigner(signer))
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	rr := serve(http.MethodGet, "/signing-key")
+	var published SigningKeyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&published); err != nil || published.KeyID != signer.KeyID() {
+		t.Fatalf("expected the signing key, got %+v %v", published, err)
+	}
+	publicKey, _ := base64.StdEncoding.DecodeString(published.PublicKey)
+
+	for _, target := range []string{"/validate", "/validate/prod"} {
+		rr := serve(http.MethodPost, target)
+		if !signing.Verify(publicKey, rr.Body.Bytes(), rr.Header().Get(signing.SignatureHeader)) {
+			t.Fatalf("expected %s to be signed with the published key, got headers %v", target, rr.Header())
+		}
+		if rr.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("expected the handler's headers to be kept, got %v", rr.Header())
+		}
+	}
+
+	if rr := serve(http.MethodPost, "/validate?stream=true"); rr.Header().Get(signing.SignatureHeader) != "" {
+		t.Fatalf("expected streamed responses to be left unsigned")
+	}
+	if rr := serve(http.MethodGet, "/health"); rr.Header().Get(signing.SignatureHeader) != "" {
+		t.Fatalf("expected only result routes to be signed")
+	}
+}
+
+func TestRouterWithoutSigner(t *testing.T) {
+	router := NewRouter(newStubRouterManager(), logrus.New())
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/signing-key", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected no signing key without a signer, got %d", rr.Code)
+	}
+}
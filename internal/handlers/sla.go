@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slaDefaultDays is the range served when from is not given, the rollup retention default
+const slaDefaultDays = 365
+
+// AvailabilityReporter exposes the daily availability rollups of the history database
+type AvailabilityReporter interface {
+	AvailabilityHistory(ctx context.Context, endpointName string, from, to time.Time) ([]exporter.DailyAvailability, error)
+}
+
+type DailyAvailabilityResponse struct {
+	Day           string  `json:"day"`
+	Checks        int     `json:"checks"`
+	Failures      int     `json:"failures"`
+	UptimePercent float64 `json:"uptime_percent"`
+	P95Ms         int64   `json:"p95_ms"`
+}
+
+// AvailabilitySummary totals the days of the response
+type AvailabilitySummary struct {
+	Days          int     `json:"days"`
+	Checks        int     `json:"checks"`
+	Failures      int     `json:"failures"`
+	UptimePercent float64 `json:"uptime_percent"`
+	// MaxDailyP95Ms is the worst daily p95, since percentiles cannot be combined
+	MaxDailyP95Ms int64 `json:"max_daily_p95_ms"`
+}
+
+type SLAHistoryResponse struct {
+	Endpoint string                      `json:"endpoint"`
+	From     string                      `json:"from"`
+	To       string                      `json:"to"`
+	Summary  AvailabilitySummary         `json:"summary"`
+	Days     []DailyAvailabilityResponse `json:"days"`
+}
+
+// NewSLAHistoryHandler returns a handler serving an endpoint's daily availability
+// rollups. ?from= and ?to= are inclusive UTC dates (YYYY-MM-DD); the default is the
+// last 365 days up to today.
+// Expected route: GET /sla/history/{endpoint}
+func NewSLAHistoryHandler(manager AvailabilityReporter, log *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpointName := r.PathValue("endpoint")
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		from, err := parseDay(r.URL.Query().Get("from"), today.AddDate(0, 0, 1-slaDefaultDays))
+		if err != nil {
+			http.Error(w, "from must be a date, e.g. 2026-01-01", http.StatusBadRequest)
+			return
+		}
+		to, err := parseDay(r.URL.Query().Get("to"), today)
+		if err != nil {
+			http.Error(w, "to must be a date, e.g. 2026-12-31", http.StatusBadRequest)
+			return
+		}
+		if to.Before(from) {
+			http.Error(w, "from must not be after to", http.StatusBadRequest)
+			return
+		}
+
+		days, err := manager.AvailabilityHistory(r.Context(), endpointName, from, to.AddDate(0, 0, 1))
+		if errors.Is(err, exporter.ErrNoHistoryStore) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			log.WithError(err).WithField("endpoint", endpointName).Error("Availability query failed")
+			http.Error(w, "availability query failed", http.StatusInternalServerError)
+			return
+		}
+
+		response := SLAHistoryResponse{
+			Endpoint: endpointName,
+			From:     from.Format(time.DateOnly),
+			To:       to.Format(time.DateOnly),
+			Days:     make([]DailyAvailabilityResponse, 0, len(days)),
+		}
+		for _, day := range days {
+			response.Days = append(response.Days, DailyAvailabilityResponse{
+				Day:           day.Day.Format(time.DateOnly),
+				Checks:        day.Checks,
+				Failures:      day.Failures,
+				UptimePercent: day.UptimePercent,
+				P95Ms:         day.P95Ms,
+			})
+			response.Summary.Days++
+			response.Summary.Checks += day.Checks
+			response.Summary.Failures += day.Failures
+			response.Summary.MaxDailyP95Ms = max(response.Summary.MaxDailyP95Ms, day.P95Ms)
+		}
+		if response.Summary.Checks > 0 {
+			response.Summary.UptimePercent = float64(response.Summary.Checks-response.Summary.Failures) / float64(response.Summary.Checks) * 100
+		}
+		writeCachedJSON(w, r, log, response, response, time.Time{})
+	}
+}
+
+// parseDay parses a YYYY-MM-DD date as midnight UTC, or returns fallback when empty
+func parseDay(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubAvailability struct {
+	days     []exporter.DailyAvailability
+	err      error
+	endpoint string
+	from, to time.Time
+}
+
+func (s *stubAvailability) AvailabilityHistory(ctx context.Context, endpointName string, from, to time.Time) ([]exporter.DailyAvailability, error) {
+	s.endpoint, s.from, s.to = endpointName, from, to
+	return s.days, s.err
+}
+
+const slaHistoryRoute = "/sla/history/{endpoint}"
+
+func TestSLAHistoryHandler(t *testing.T) {
+	stub := &stubAvailability{days: []exporter.DailyAvailability{
+		{Day: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Checks: 288, Failures: 0, UptimePercent: 100, P95Ms: 210},
+		{Day: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Checks: 288, Failures: 12, UptimePercent: 95.83, P95Ms: 480},
+	}}
+	rr := serveRoute(slaHistoryRoute, NewSLAHistoryHandler(stub, logrus.New()), httptest.NewRequest(http.MethodGet, "/sla/history/prod?from=2026-03-01&to=2026-03-31", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response SLAHistoryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if stub.endpoint != "prod" || !stub.to.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected an exclusive bound after the to date, got %s %s", stub.endpoint, stub.to)
+	}
+	summary := response.Summary
+	if len(response.Days) != 2 || response.Days[1].Day != "2026-03-02" || summary.Checks != 576 || summary.Failures != 12 || summary.MaxDailyP95Ms != 480 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if summary.UptimePercent < 97.9 || summary.UptimePercent > 98 {
+		t.Fatalf("expected overall uptime of about 97.92%%, got %v", summary.UptimePercent)
+	}
+
+	for _, target := range []string{"/sla/history/prod?from=March", "/sla/history/prod?from=2026-03-02&to=2026-03-01"} {
+		if rr := serveRoute(slaHistoryRoute, NewSLAHistoryHandler(stub, logrus.New()), httptest.NewRequest(http.MethodGet, target, nil)); rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestSLAHistoryHandlerWithoutStore(t *testing.T) {
+	stub := &stubAvailability{err: exporter.ErrNoHistoryStore}
+	rr := serveRoute(slaHistoryRoute, NewSLAHistoryHandler(stub, logrus.New()), httptest.NewRequest(http.MethodGet, "/sla/history/prod", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without a history database, got %d", rr.Code)
+	}
+	if days := stub.to.Sub(stub.from).Hours() / 24; days != slaDefaultDays {
+		t.Fatalf("expected the default range to span %d days, got %v", slaDefaultDays, days)
+	}
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// slackMaxSkew is how far a request timestamp may be from now, limiting replays
+	slackMaxSkew = 5 * time.Minute
+	// slackMaxBody caps the form body of a slash command
+	slackMaxBody = 64 << 10
+	// slackValidateTimeout bounds a validation started from chat; its results are posted
+	// to the command's response_url, which Slack accepts for 30 minutes
+	slackValidateTimeout = 5 * time.Minute
+)
+
+// SlackCommander is what slash commands need from the manager
+type SlackCommander interface {
+	Validator
+	CycleReporter
+}
+
+// slackMessage is the reply to a slash command, immediately or via response_url
+type slackMessage struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+type slackCommandHandler struct {
+	manager SlackCommander
+	secret  []byte
+	clock   clock.Clock
+	client  *http.Client
+	log     *logrus.Logger
+
+	// validatingAll is set while a "/s3check all" run is in flight; chat can start at
+	// most one at a time
+	validatingAll atomic.Bool
+}
+
+// NewSlackCommandHandler returns a handler for the /s3check Slack slash command, verifying
+// every request with the app's signing secret. "/s3check <endpoint>" and "/s3check all"
+// acknowledge at once and post the results to the command's response_url when the
+// validation finishes, with at most one "all" run in flight; "/s3check changes" answers with the endpoints that changed state
+// in the latest auto-validation cycle.
+// Expected route: POST /slack/command
+func NewSlackCommandHandler(manager SlackCommander, signingSecret string, log *logrus.Logger) http.HandlerFunc {
+	h := &slackCommandHandler{
+		manager: manager,
+		secret:  []byte(signingSecret),
+		clock:   clock.Real,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		log:     log,
+	}
+	return h.serve
+}
+
+func (h *slackCommandHandler) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, slackMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := h.verify(r.Header, body); err != nil {
+		h.log.WithError(err).Warn("Rejected Slack command with an invalid signature")
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	command := r.PostForm.Get("command")
+	responseURL := r.PostForm.Get("response_url")
+	args := strings.Fields(r.PostForm.Get("text"))
+	logger := h.log.WithFields(logrus.Fields{
+		"command": command,
+		"text":    r.PostForm.Get("text"),
+		"user":    r.PostForm.Get("user_name"),
+	})
+
+	switch {
+	case len(args) != 1 || args[0] == "help":
+		h.reply(w, slackMessage{ResponseType: "ephemeral", Text: slackUsage(command)})
+	case args[0] == "changes":
+		h.reply(w, slackMessage{ResponseType: "in_channel", Text: h.changes()})
+	case responseURL == "":
+		http.Error(w, "missing response_url", http.StatusBadRequest)
+	case args[0] == "all" && !h.validatingAll.CompareAndSwap(false, true):
+		h.reply(w, slackMessage{ResponseType: "ephemeral", Text: "A check of all endpoints is already running; its results will be posted here when it finishes."})
+	case args[0] == "all":
+		logger.Info("Validating all endpoints from Slack")
+		h.reply(w, slackMessage{ResponseType: "in_channel", Text: fmt.Sprintf("Checking %d endpoints…", h.manager.GetEndpointCount())})
+		go func() {
+			defer h.validatingAll.Store(false)
+			h.respond(responseURL, logger, func(ctx context.Context) string {
+				return formatSlackResults(h.manager.ValidateAll(ctx))
+			})
+		}()
+	default:
+		endpointName := args[0]
+		logger.Info("Validating endpoint from Slack")
+		h.reply(w, slackMessage{ResponseType: "in_channel", Text: fmt.Sprintf("Checking `%s`…", endpointName)})
+		go h.respond(responseURL, logger, func(ctx context.Context) string {
+			return formatSlackResult(endpointName, h.manager.ValidateEndpoint(ctx, endpointName), annotationsFor(h.manager, endpointName))
+		})
+	}
+}
+
+// verify checks the v0 signature Slack computes over the timestamp and raw body
+func (h *slackCommandHandler) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-Slack-Request-Timestamp %q", timestamp)
+	}
+	if skew := h.clock.Since(time.Unix(seconds, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return fmt.Errorf("request timestamp is %s away from now", skew.Round(time.Second))
+	}
+
+	mac := hmac.New(sha256.New, h.secret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// reply answers the slash command request itself
+func (h *slackCommandHandler) reply(w http.ResponseWriter, message slackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(message); err != nil {
+		h.log.Errorf("Failed to encode Slack response: %v", err)
+	}
+}
+
+// respond runs a validation detached from the request and posts its outcome to responseURL
+func (h *slackCommandHandler) respond(responseURL string, logger *logrus.Entry, validate func(ctx context.Context) string) {
+	ctx, cancel := context.WithTimeout(context.Background(), slackValidateTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(slackMessage{ResponseType: "in_channel", Text: validate(ctx)})
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode Slack results")
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		logger.WithError(err).Warn("Invalid Slack response_url")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to post results to Slack")
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.WithField("status", resp.StatusCode).Warn("Slack rejected the command results")
+	}
+}
+
+// changes describes the endpoints that changed state in the latest cycle
+func (h *slackCommandHandler) changes() string {
+	latest, ok := h.manager.LatestCycle()
+	if !ok {
+		return "No auto-validation cycle has finished yet."
+	}
+	diff, err := h.manager.CycleDiff(latest.ID)
+	if err != nil {
+		return fmt.Sprintf("Cycle %d has no previous cycle to compare with.", latest.ID)
+	}
+	if len(diff.Changes) == 0 {
+		return fmt.Sprintf("No endpoint changed state in cycle %d (%s).", latest.ID, latest.Timestamp.UTC().Format(time.RFC3339))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d endpoints changed state in cycle %d (%s)*", len(diff.Changes), latest.ID, latest.Timestamp.UTC().Format(time.RFC3339))
+	for _, change := range diff.Changes {
+		fmt.Fprintf(&b, "\n%s `%s` %s", slackChangeEmoji(change.Kind), change.Endpoint, strings.ReplaceAll(change.Kind, "_", " "))
+		if current := change.Current; current != nil && !current.IsValid {
+			fmt.Fprintf(&b, ": %s", slackFailure(current.ErrorType, current.Message))
+		}
+		b.WriteString(slackAnnotations(annotationsFor(h.manager, change.Endpoint)))
+	}
+	return b.String()
+}
+
+func slackUsage(command string) string {
+	if command == "" {
+		command = "/s3check"
+	}
+	return fmt.Sprintf("Usage:\n`%[1]s <endpoint>` validate one endpoint\n`%[1]s all` validate every endpoint\n`%[1]s changes` endpoints that changed state in the last cycle", command)
+}
+
+// formatSlackResults lists every result, failures first
+func formatSlackResults(results *exporter.ValidationResults) string {
+	names := make([]string, 0, len(results.Results))
+	valid := 0
+	for name, result := range results.Results {
+		names = append(names, name)
+		if result.IsValid {
+			valid++
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := results.Results[names[i]], results.Results[names[j]]
+		if a.IsValid != b.IsValid {
+			return !a.IsValid
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d/%d endpoints valid*", valid, len(names))
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(slackResultLine(name, results.Results[name]))
+	}
+	return b.String()
+}
+
+// formatSlackResult describes one endpoint's result with its annotations
+func formatSlackResult(endpointName string, result *s3.ValidationResult, annotations map[string]string) string {
+	return slackResultLine(endpointName, result) + slackAnnotations(annotations)
+}
+
+func slackResultLine(endpointName string, result *s3.ValidationResult) string {
+	if result.IsValid {
+		return fmt.Sprintf(":white_check_mark: `%s` valid (%dms)", endpointName, result.ResponseTimeMs)
+	}
+	return fmt.Sprintf(":x: `%s` %s", endpointName, slackFailure(result.ErrorType, result.Message))
+}
+
+func slackFailure(errorType, message string) string {
+	if errorType == "" {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", errorType, message)
+}
+
+// slackAnnotations points responders at the endpoint's owner and runbook
+func slackAnnotations(annotations map[string]string) string {
+	var parts []string
+	if owner := annotations["owner"]; owner != "" {
+		parts = append(parts, "owner: "+owner)
+	}
+	if runbook := annotations["runbook_url"]; runbook != "" {
+		parts = append(parts, fmt.Sprintf("<%s|runbook>", runbook))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func slackChangeEmoji(kind string) string {
+	switch kind {
+	case exporter.CycleChangeBroke:
+		return ":x:"
+	case exporter.CycleChangeRecovered:
+		return ":white_check_mark:"
+	case exporter.CycleChangeErrorChanged:
+		return ":warning:"
+	default:
+		return ":information_source:"
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testSlackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+type stubSlackManager struct {
+	stubManager
+	*stubCycleReporter
+}
+
+// slackRequest builds a slash command request signed at the given time
+func slackRequest(form url.Values, at time.Time, secret string) *http.Request {
+	body := form.Encode()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/command", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func newTestSlackHandler(manager SlackCommander, clk clock.Clock) http.HandlerFunc {
+	h := &slackCommandHandler{manager: manager, secret: []byte(testSlackSecret), clock: clk, client: http.DefaultClient, log: logrus.New()}
+	return h.serve
+}
+
+func TestSlackCommandRejectsBadSignatures(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	handler := newTestSlackHandler(&stubSlackManager{stubCycleReporter: newStubCycles()}, clock.NewFake(now))
+	form := url.Values{"command": {"/s3check"}, "text": {"help"}}
+
+	cases := map[string]*http.Request{
+		"wrong secret": slackRequest(form, now, "other"),
+		"stale":        slackRequest(form, now.Add(-10*time.Minute), testSlackSecret),
+	}
+	tampered := slackRequest(form, now, testSlackSecret)
+	tampered.Body = http.NoBody
+	cases["tampered body"] = tampered
+
+	for name, req := range cases {
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %d", name, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler(rr, slackRequest(form, now, testSlackSecret))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Usage") {
+		t.Fatalf("expected usage for a valid request, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestSlackCommandPostsResultsToResponseURL(t *testing.T) {
+	posted := make(chan slackMessage, 1)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		posted <- message
+	}))
+	defer slack.Close()
+
+	manager := &stubSlackManager{stubCycleReporter: newStubCycles()}
+	manager.validateEndpointFunc = func(ctx context.Context, name string) *s3.ValidationResult {
+		return &s3.ValidationResult{IsValid: false, ErrorType: "access_denied", Message: "Access Denied", CheckedAt: time.Now()}
+	}
+	handler := newTestSlackHandler(manager, clock.Real)
+
+	rr := httptest.NewRecorder()
+	handler(rr, slackRequest(url.Values{"command": {"/s3check"}, "text": {"bucket-a"}, "response_url": {slack.URL}}, time.Now(), testSlackSecret))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Checking `bucket-a`") {
+		t.Fatalf("expected an immediate acknowledgement, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	select {
+	case message := <-posted:
+		if message.ResponseType != "in_channel" || !strings.Contains(message.Text, ":x: `bucket-a` access_denied: Access Denied") {
+			t.Fatalf("unexpected results message: %+v", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected results to be posted to the response_url")
+	}
+}
+
+func TestSlackCommandRunsOneValidateAllAtATime(t *testing.T) {
+	posted := make(chan slackMessage, 2)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		posted <- message
+	}))
+	defer slack.Close()
+
+	release := make(chan struct{})
+	manager := &stubSlackManager{stubCycleReporter: newStubCycles()}
+	manager.validateAllFunc = func(ctx context.Context) *exporter.ValidationResults {
+		<-release
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{}}
+	}
+	handler := newTestSlackHandler(manager, clock.Real)
+	all := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, slackRequest(url.Values{"command": {"/s3check"}, "text": {"all"}, "response_url": {slack.URL}}, time.Now(), testSlackSecret))
+		return rr
+	}
+
+	if rr := all(); !strings.Contains(rr.Body.String(), "Checking") {
+		t.Fatalf("expected the first run to start, got %s", rr.Body.String())
+	}
+	if rr := all(); !strings.Contains(rr.Body.String(), "already running") || !strings.Contains(rr.Body.String(), "ephemeral") {
+		t.Fatalf("expected the second run to be refused, got %s", rr.Body.String())
+	}
+
+	close(release)
+	select {
+	case <-posted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the first run's results to be posted")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for rr := all(); !strings.Contains(rr.Body.String(), "Checking"); rr = all() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a new run once the first finished, got %s", rr.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSlackCommandChanges(t *testing.T) {
+	handler := newTestSlackHandler(&stubSlackManager{stubCycleReporter: newStubCycles()}, clock.Real)
+
+	rr := httptest.NewRecorder()
+	handler(rr, slackRequest(url.Values{"command": {"/s3check"}, "text": {"changes"}}, time.Now(), testSlackSecret))
+
+	var message slackMessage
+	if err := json.NewDecoder(rr.Body).Decode(&message); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.Contains(message.Text, "1 endpoints changed state in cycle 5") || !strings.Contains(message.Text, "`bucket-a` broke: access_denied") {
+		t.Fatalf("unexpected changes message: %q", message.Text)
+	}
+}
+
+func TestFormatSlackResultsListsFailuresFirst(t *testing.T) {
+	text := formatSlackResults(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+		"a-ok":     {IsValid: true, ResponseTimeMs: 12},
+		"b-broken": {IsValid: false, ErrorType: "network", Message: "timeout"},
+	}})
+	lines := strings.Split(text, "\n")
+	if len(lines) != 3 || lines[0] != "*1/2 endpoints valid*" || !strings.Contains(lines[1], "b-broken") {
+		t.Fatalf("unexpected formatting: %q", text)
+	}
+}
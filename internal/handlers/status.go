@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"key-aws-exporter/internal/exporter"
+)
+
+// endpointStatus is the HTTP status a failed single-endpoint validation is served with,
+// and the reason reported alongside it in the response
+type endpointStatus struct {
+	code   int
+	reason string
+}
+
+// failureStatuses maps error types to statuses, so callers can tell a rejected key from
+// an unreachable endpoint without parsing the payload
+var failureStatuses = map[string]endpointStatus{
+	"access_denied":            {http.StatusForbidden, "access_denied: the credentials were rejected or lack permission"},
+	"bucket_not_found":         {http.StatusNotFound, "bucket_not_found: the bucket does not exist"},
+	"endpoint_not_found":       {http.StatusNotFound, "endpoint_not_found: no endpoint is configured with this name"},
+	"timeout":                  {http.StatusGatewayTimeout, "timeout: the endpoint did not answer in time"},
+	exporter.ErrorTypeTimedOut: {http.StatusGatewayTimeout, "timed_out: validation did not finish within the request budget"},
+	"network":                  {http.StatusBadGateway, "network: the endpoint could not be reached"},
+	"transform_failed":         {http.StatusBadGateway, "transform_failed: the Object Lambda function failed"},
+	"criterion_failed":         {http.StatusBadGateway, "criterion_failed: the endpoint answered but missed the success criterion"},
+	"throttled":                {http.StatusServiceUnavailable, "throttled: the endpoint is rate limiting requests"},
+	exporter.ErrorTypeCanceled: {http.StatusServiceUnavailable, "canceled: validation was canceled before the endpoint finished"},
+	"config_error":             {http.StatusInternalServerError, "config_error: the endpoint is misconfigured"},
+	exporter.ErrorTypePlugin:   {http.StatusBadGateway, "plugin_error: the probe plugin failed"},
+}
+
+// defaultFailureStatus covers invalid keys and error types without a more specific status
+var defaultFailureStatus = endpointStatus{http.StatusUnauthorized, "the credentials are not valid"}
+
+// statusForResult returns the status a single-endpoint validation result is served with
+func statusForResult(isValid bool, errorType string) endpointStatus {
+	if isValid {
+		return endpointStatus{code: http.StatusOK}
+	}
+	if status, ok := failureStatuses[errorType]; ok {
+		return status
+	}
+	return defaultFailureStatus
+}
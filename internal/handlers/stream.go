@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ndjsonContentType is the media type of streamed validate responses
+const ndjsonContentType = "application/x-ndjson"
+
+// streamWriteTimeout is how long writing one line of a streamed response may take. The
+// write deadline is pushed back before every line, so a stream over many endpoints may
+// run longer than the server's write timeout.
+const streamWriteTimeout = 10 * time.Second
+
+// StreamingValidator hands every result to fn as soon as its endpoint is validated
+type StreamingValidator interface {
+	ValidateAllStreaming(ctx context.Context, fn exporter.ResultFunc) *exporter.ValidationResults
+}
+
+// StreamedResult is one NDJSON line of a streamed validate response
+type StreamedResult struct {
+	Endpoint string `json:"endpoint"`
+	ValidationResponse
+}
+
+// StreamSummary is the last NDJSON line of a streamed validate response
+type StreamSummary struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Summary   ValidationSummary `json:"summary"`
+	// Replayed marks results of an earlier run with the same Idempotency-Key
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// streamRequested reports whether the client asked for ?stream=true
+func streamRequested(r *http.Request) (bool, error) {
+	return boolQuery(r, "stream")
+}
+
+// streamValidateAll writes one NDJSON line per endpoint as results complete, then a
+// summary line. The status is always 200 because it is sent before any result is known.
+func streamValidateAll(ctx context.Context, w http.ResponseWriter, manager Validator, log *logrus.Logger) *exporter.ValidationResults {
+	return writeStream(w, log, false, func(emit exporter.ResultFunc) *exporter.ValidationResults {
+		if streaming, ok := manager.(StreamingValidator); ok {
+			return streaming.ValidateAllStreaming(ctx, emit)
+		}
+		results := manager.ValidateAll(ctx)
+		emitSorted(results, emit)
+		return results
+	})
+}
+
+// streamReplay writes the results of an earlier run in the streamed format
+func streamReplay(w http.ResponseWriter, results *exporter.ValidationResults, log *logrus.Logger) {
+	writeStream(w, log, true, func(emit exporter.ResultFunc) *exporter.ValidationResults {
+		emitSorted(results, emit)
+		return results
+	})
+}
+
+// writeStream writes the lines emitted by validate, then the summary line
+func writeStream(w http.ResponseWriter, log *logrus.Logger, replayed bool, validate func(emit exporter.ResultFunc) *exporter.ValidationResults) *exporter.ValidationResults {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	extendWriteDeadline(w, streamWriteTimeout)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	controller := http.NewResponseController(w)
+	var summary ValidationSummary
+	var writeErr error
+
+	results := validate(func(endpointName string, result *s3.ValidationResult) {
+		summary.count(result)
+		if writeErr != nil {
+			return
+		}
+		extendWriteDeadline(w, streamWriteTimeout)
+		writeErr = encoder.Encode(StreamedResult{Endpoint: endpointName, ValidationResponse: newValidationResponse(result)})
+		if writeErr == nil {
+			_ = controller.Flush()
+		}
+	})
+
+	if writeErr == nil {
+		extendWriteDeadline(w, streamWriteTimeout)
+		writeErr = encoder.Encode(StreamSummary{Timestamp: results.Timestamp, Summary: summary, Replayed: replayed})
+	}
+	if writeErr != nil {
+		log.Errorf("Failed to stream validate all response: %v", writeErr)
+	}
+	return results
+}
+
+// extendWriteDeadline lets the response be written for another d, even past the
+// server's write timeout. Writers that cannot change their deadline keep it.
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+}
+
+// emitSorted hands every result to emit in endpoint name order
+func emitSorted(results *exporter.ValidationResults, emit exporter.ResultFunc) {
+	names := make([]string, 0, len(results.Results))
+	for name := range results.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		emit(name, results.Results[name])
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubStreamingManager struct {
+	stubManager
+	results map[string]*s3.ValidationResult
+	order   []string
+}
+
+func (s *stubStreamingManager) ValidateAllStreaming(ctx context.Context, fn exporter.ResultFunc) *exporter.ValidationResults {
+	for _, name := range s.order {
+		fn(name, s.results[name])
+	}
+	return &exporter.ValidationResults{Timestamp: time.Unix(1700000000, 0).UTC(), Results: s.results}
+}
+
+// readStream decodes every result line and the trailing summary
+func readStream(t *testing.T, rr *httptest.ResponseRecorder) ([]StreamedResult, StreamSummary) {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != ndjsonContentType {
+		t.Fatalf("expected content type %s, got %q", ndjsonContentType, ct)
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if len(lines) == 0 {
+		t.Fatalf("expected at least a summary line")
+	}
+
+	results := make([]StreamedResult, 0, len(lines)-1)
+	for _, line := range lines[:len(lines)-1] {
+		var result StreamedResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			t.Fatalf("failed to decode result line %s: %v", line, err)
+		}
+		results = append(results, result)
+	}
+	var summary StreamSummary
+	if err := json.Unmarshal(lines[len(lines)-1], &summary); err != nil {
+		t.Fatalf("failed to decode summary line: %v", err)
+	}
+	return results, summary
+}
+
+func TestValidateAllHandlerStreamsInCompletionOrder(t *testing.T) {
+	mgr := &stubStreamingManager{
+		results: map[string]*s3.ValidationResult{
+			"slow": {IsValid: true, Message: "ok", CheckedAt: time.Now()},
+			"fast": {IsValid: false, Message: "denied", ErrorType: "access_denied", CheckedAt: time.Now()},
+		},
+		order: []string{"fast", "slow"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/validate?stream=true", nil)
+	rr := httptest.NewRecorder()
+	NewValidateAllHandler(mgr, logrus.New())(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a streamed response, got %d", rr.Code)
+	}
+	results, summary := readStream(t, rr)
+	if len(results) != 2 || results[0].Endpoint != "fast" || results[1].Endpoint != "slow" {
+		t.Fatalf("expected results in completion order, got %+v", results)
+	}
+	if results[0].IsValid || results[0].ErrorType != "access_denied" {
+		t.Fatalf("expected the failed result to keep its error, got %+v", results[0])
+	}
+	if summary.Summary != (ValidationSummary{TotalEndpoints: 2, Successful: 1, Failed: 1}) {
+		t.Fatalf("unexpected summary %+v", summary.Summary)
+	}
+	if !summary.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("expected the batch timestamp, got %s", summary.Timestamp)
+	}
+}
+
+func TestValidateAllHandlerStreamsWithoutStreamingManager(t *testing.T) {
+	mgr := &stubManager{validateAllFunc: func(context.Context) *exporter.ValidationResults {
+		return &exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"b": {IsValid: true, CheckedAt: time.Now()},
+			"a": {IsValid: true, CheckedAt: time.Now()},
+		}}
+	}}
+
+	req := httptest.NewRequest(http.MethodPost, "/validate?stream=1", nil)
+	rr := httptest.NewRecorder()
+	NewValidateAllHandler(mgr, logrus.New())(rr, req)
+
+	results, summary := readStream(t, rr)
+	if len(results) != 2 || results[0].Endpoint != "a" || results[1].Endpoint != "b" {
+		t.Fatalf("expected results sorted by endpoint, got %+v", results)
+	}
+	if summary.Summary.Successful != 2 {
+		t.Fatalf("unexpected summary %+v", summary.Summary)
+	}
+}
+
+func TestValidateAllHandlerStreamRejectsBadRequests(t *testing.T) {
+	for _, target := range []string{"/validate?stream=maybe", "/validate?stream=true&format=csv"} {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		rr := httptest.NewRecorder()
+		NewValidateAllHandler(&stubManager{}, logrus.New())(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestValidateAllHandlerStreamOutlastsWriteTimeout(t *testing.T) {
+	mgr := &stubStreamingManager{
+		results: map[string]*s3.ValidationResult{
+			"a": {IsValid: true, CheckedAt: time.Now()},
+			"b": {IsValid: true, CheckedAt: time.Now()},
+		},
+		order: []string{"a", "b"},
+	}
+	slow := &slowStreamingManager{stubStreamingManager: mgr, delay: 150 * time.Millisecond}
+
+	server := httptest.NewUnstartedServer(NewValidateAllHandler(slow, logrus.New()))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/validate?stream=true", "", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("stream was cut off: %v", err)
+	}
+	if lines != 3 {
+		t.Fatalf("expected two results and a summary past the write timeout, got %d lines", lines)
+	}
+}
+
+// slowStreamingManager waits before every result, like endpoints slower than the
+// server's write timeout
+type slowStreamingManager struct {
+	*stubStreamingManager
+	delay time.Duration
+}
+
+func (s *slowStreamingManager) ValidateAllStreaming(ctx context.Context, fn exporter.ResultFunc) *exporter.ValidationResults {
+	return s.stubStreamingManager.ValidateAllStreaming(ctx, func(name string, result *s3.ValidationResult) {
+		time.Sleep(s.delay)
+		fn(name, result)
+	})
+}
@@ -0,0 +1,153 @@
+package historydb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+)
+
+// day is the format of the day column, which sorts chronologically
+const day = "2006-01-02"
+
+const rollupSchema = `
+CREATE TABLE IF NOT EXISTS daily_rollups (
+	endpoint TEXT    NOT NULL,
+	day      TEXT    NOT NULL, -- YYYY-MM-DD, UTC
+	checks   INTEGER NOT NULL,
+	failures INTEGER NOT NULL,
+	p95_ms   INTEGER NOT NULL,
+	PRIMARY KEY (endpoint, day)
+);
+`
+
+// rollupKey identifies the results of one endpoint on one UTC day
+type rollupKey struct {
+	endpoint string
+	day      string
+}
+
+type rollupSamples struct {
+	checks    int
+	failures  int
+	latencies []int64
+}
+
+// Rollup rebuilds the daily rollups of every day whose results are all still retained,
+// today included. Days partly pruned keep the rollup built while they were complete,
+// so Rollup must run more often than Retention spans a day.
+func (s *Store) Rollup(ctx context.Context) error {
+	// The first day starting at or after the raw retention cutoff
+	cutoff := s.clock.Now().UTC().Add(-s.retention)
+	first := cutoff.Truncate(24 * time.Hour)
+	if first.Before(cutoff) {
+		first = first.Add(24 * time.Hour)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT endpoint, checked_at, is_valid, response_time_ms FROM results WHERE checked_at >= ?", first.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to read results for rollups: %w", err)
+	}
+	samples := make(map[rollupKey]*rollupSamples)
+	for rows.Next() {
+		var endpoint string
+		var checkedAt, responseTimeMs int64
+		var valid bool
+		if err := rows.Scan(&endpoint, &checkedAt, &valid, &responseTimeMs); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read results for rollups: %w", err)
+		}
+		key := rollupKey{endpoint: endpoint, day: time.UnixMilli(checkedAt).UTC().Format(day)}
+		sample := samples[key]
+		if sample == nil {
+			sample = &rollupSamples{}
+			samples[key] = sample
+		}
+		sample.checks++
+		if !valid {
+			sample.failures++
+		}
+		sample.latencies = append(sample.latencies, responseTimeMs)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read results for rollups: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR REPLACE INTO daily_rollups (endpoint, day, checks, failures, p95_ms) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for key, sample := range samples {
+		if _, err := stmt.ExecContext(ctx, key.endpoint, key.day, sample.checks, sample.failures, p95(sample.latencies)); err != nil {
+			return fmt.Errorf("failed to write rollup: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DailyAvailability returns the endpoint's rollups for days in [from, to), oldest first;
+// a zero from or to does not bound the range
+func (s *Store) DailyAvailability(ctx context.Context, endpointName string, from, to time.Time) ([]exporter.DailyAvailability, error) {
+	statement := "SELECT day, checks, failures, p95_ms FROM daily_rollups WHERE endpoint = ?"
+	args := []any{endpointName}
+	if !from.IsZero() {
+		statement += " AND day >= ?"
+		args = append(args, from.UTC().Format(day))
+	}
+	if !to.IsZero() {
+		statement += " AND day < ?"
+		args = append(args, to.UTC().Format(day))
+	}
+	rows, err := s.db.QueryContext(ctx, statement+" ORDER BY day", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var days []exporter.DailyAvailability
+	for rows.Next() {
+		var date string
+		var rollup exporter.DailyAvailability
+		if err := rows.Scan(&date, &rollup.Checks, &rollup.Failures, &rollup.P95Ms); err != nil {
+			return nil, fmt.Errorf("failed to read rollups: %w", err)
+		}
+		if rollup.Day, err = time.Parse(day, date); err != nil {
+			return nil, fmt.Errorf("invalid rollup day %q: %w", date, err)
+		}
+		if rollup.Checks > 0 {
+			rollup.UptimePercent = float64(rollup.Checks-rollup.Failures) / float64(rollup.Checks) * 100
+		}
+		days = append(days, rollup)
+	}
+	return days, rows.Err()
+}
+
+// pruneRollups deletes the rollups of days ending before the rollup retention
+func (s *Store) pruneRollups(ctx context.Context) (int64, error) {
+	cutoff := s.clock.Now().UTC().Add(-s.rollupRetention).Format(day)
+	res, err := s.db.ExecContext(ctx, "DELETE FROM daily_rollups WHERE day < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune rollups: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// p95 returns the nearest-rank 95th percentile of latencies
+func p95(latencies []int64) int64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+	return latencies[max(rank, 0)]
+}
@@ -0,0 +1,73 @@
+package historydb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+)
+
+func TestRollupSummarizesDays(t *testing.T) {
+	clk := clock.NewFake(start.Add(12 * time.Hour))
+	store := openStore(t, filepath.Join(t.TempDir(), "history.db"), clk)
+
+	// 20 checks of endpoint a on 2026-03-01, one failing, latencies 10..200ms
+	for i := 0; i < 20; i++ {
+		store.Consume(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"a": {IsValid: i != 7, CheckedAt: start.Add(time.Duration(i) * time.Minute), ResponseTimeMs: int64(10 * (i + 1))},
+		}})
+	}
+	if err := store.Rollup(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	days, err := store.DailyAvailability(t.Context(), "a", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("expected one day, got %+v", days)
+	}
+	got := days[0]
+	if !got.Day.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || got.Checks != 20 || got.Failures != 1 || got.UptimePercent != 95 || got.P95Ms != 190 {
+		t.Fatalf("unexpected rollup %+v", got)
+	}
+}
+
+func TestRollupsOutliveRawResults(t *testing.T) {
+	clk := clock.NewFake(start.Add(4 * time.Hour))
+	store := openStore(t, filepath.Join(t.TempDir(), "history.db"), clk)
+	seed(store)
+	if err := store.Rollup(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Two days later the raw results are past the 24h retention, the rollup is not
+	clk.Advance(48 * time.Hour)
+	if _, err := store.Prune(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if records, _ := store.Query(t.Context(), exporter.HistoryQuery{}); len(records) != 0 {
+		t.Fatalf("expected the raw results to be pruned, got %d", len(records))
+	}
+	days, err := store.DailyAvailability(t.Context(), "a", start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(days) != 1 || days[0].Checks != 4 || days[0].Failures != 2 {
+		t.Fatalf("expected the day's rollup to remain, got %+v", days)
+	}
+
+	// Past the 7 day rollup retention the rollup goes as well
+	clk.Advance(7 * 24 * time.Hour)
+	if _, err := store.Prune(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if days, _ := store.DailyAvailability(t.Context(), "a", time.Time{}, time.Time{}); len(days) != 0 {
+		t.Fatalf("expected the rollup to be pruned, got %+v", days)
+	}
+}
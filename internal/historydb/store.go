@@ -0,0 +1,185 @@
+// Package historydb persists validation results in a SQLite database, so the history
+// survives restarts and can be queried by time range.
+package historydb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+)
+
+// writeTimeout bounds the insert of one batch of results
+const writeTimeout = 10 * time.Second
+
+const schema = `
+CREATE TABLE IF NOT EXISTS results (
+	id               INTEGER PRIMARY KEY AUTOINCREMENT,
+	endpoint         TEXT    NOT NULL,
+	checked_at       INTEGER NOT NULL, -- Unix milliseconds
+	is_valid         INTEGER NOT NULL,
+	error_type       TEXT    NOT NULL DEFAULT '',
+	message          TEXT    NOT NULL DEFAULT '',
+	response_time_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_checked_at ON results (checked_at);
+CREATE INDEX IF NOT EXISTS results_endpoint_checked_at ON results (endpoint, checked_at);
+`
+
+// Store is a result sink writing every result to SQLite and serving range queries
+// and daily availability rollups
+type Store struct {
+	db              *sql.DB
+	retention       time.Duration
+	rollupRetention time.Duration
+	clock           clock.Clock
+	log             *logrus.Logger
+}
+
+// Option customizes a Store
+type Option func(*Store)
+
+// WithClock sets the clock pruning measures retention against
+func WithClock(clk clock.Clock) Option {
+	return func(s *Store) {
+		s.clock = clk
+	}
+}
+
+// Open opens or creates the database at cfg.Path and its schema
+func Open(cfg config.HistoryDBConfig, log *logrus.Logger, opts ...Option) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// SQLite allows one writer; a single connection serializes writes instead of
+	// failing them with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema + rollupSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history schema: %w", err)
+	}
+
+	s := &Store{db: db, retention: cfg.Retention, rollupRetention: cfg.RollupRetention, clock: clock.Real, log: log}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Consume inserts a shallow batch in one transaction; deep probes do not count toward availability
+func (s *Store) Consume(results *exporter.ValidationResults) {
+	if results.Deep() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := s.insert(ctx, results); err != nil {
+		s.log.WithError(err).Warn("Failed to write results to the history database")
+	}
+}
+
+func (s *Store) insert(ctx context.Context, results *exporter.ValidationResults) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO results (endpoint, checked_at, is_valid, error_type, message, response_time_ms) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for name, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, name, result.CheckedAt.UnixMilli(), result.IsValid, result.ErrorType, result.Message, result.ResponseTimeMs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Query returns the results matching query, oldest first
+func (s *Store) Query(ctx context.Context, query exporter.HistoryQuery) ([]exporter.HistoryRecord, error) {
+	var where []string
+	var args []any
+	if query.Endpoint != "" {
+		where = append(where, "endpoint = ?")
+		args = append(args, query.Endpoint)
+	}
+	if !query.From.IsZero() {
+		where = append(where, "checked_at >= ?")
+		args = append(args, query.From.UnixMilli())
+	}
+	if !query.To.IsZero() {
+		where = append(where, "checked_at < ?")
+		args = append(args, query.To.UnixMilli())
+	}
+	switch query.Status {
+	case exporter.HistoryStatusValid:
+		where = append(where, "is_valid = 1")
+	case exporter.HistoryStatusInvalid:
+		where = append(where, "is_valid = 0")
+	}
+
+	statement := "SELECT endpoint, checked_at, is_valid, error_type, message, response_time_ms FROM results"
+	if len(where) > 0 {
+		statement += " WHERE " + strings.Join(where, " AND ")
+	}
+	statement += " ORDER BY checked_at, id"
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []exporter.HistoryRecord
+	for rows.Next() {
+		var record exporter.HistoryRecord
+		var checkedAt int64
+		if err := rows.Scan(&record.Endpoint, &checkedAt, &record.IsValid, &record.ErrorType, &record.Message, &record.ResponseTimeMs); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		record.CheckedAt = time.UnixMilli(checkedAt).UTC()
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// Prune rolls up the retained days, then deletes the results and rollups past their
+// retention. It reports how many results were removed.
+func (s *Store) Prune(ctx context.Context) (int64, error) {
+	if err := s.Rollup(ctx); err != nil {
+		return 0, err
+	}
+	if _, err := s.pruneRollups(ctx); err != nil {
+		return 0, err
+	}
+	cutoff := s.clock.Now().Add(-s.retention).UnixMilli()
+	res, err := s.db.ExecContext(ctx, "DELETE FROM results WHERE checked_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,104 @@
+package historydb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+var start = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func openStore(t *testing.T, path string, clk clock.Clock) *Store {
+	t.Helper()
+	store, err := Open(config.HistoryDBConfig{Path: path, Retention: 24 * time.Hour, RollupRetention: 7 * 24 * time.Hour}, logrus.New(), WithClock(clk))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// seed records four hourly runs; endpoint a fails every other run
+func seed(store *Store) {
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		a := &s3.ValidationResult{IsValid: true, CheckedAt: at, ResponseTimeMs: int64(10 * (i + 1))}
+		if i%2 == 1 {
+			a.IsValid, a.ErrorType = false, "access_denied"
+		}
+		store.Consume(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"a": a,
+			"b": {IsValid: true, CheckedAt: at},
+		}})
+	}
+}
+
+func TestStoreQueriesByRangeAndStatus(t *testing.T) {
+	store := openStore(t, filepath.Join(t.TempDir(), "history.db"), clock.NewFake(start))
+	seed(store)
+
+	records, err := store.Query(t.Context(), exporter.HistoryQuery{
+		Endpoint: "a",
+		From:     start.Add(time.Hour),
+		To:       start.Add(4 * time.Hour),
+		Status:   exporter.HistoryStatusInvalid,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 || records[0].ErrorType != "access_denied" || !records[0].CheckedAt.Equal(start.Add(time.Hour)) || !records[1].CheckedAt.Equal(start.Add(3*time.Hour)) {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	all, err := store.Query(t.Context(), exporter.HistoryQuery{Limit: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 || !all[0].CheckedAt.Equal(start) || !all[2].CheckedAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected the 3 oldest records, got %+v", all)
+	}
+}
+
+func TestStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := Open(config.HistoryDBConfig{Path: path, Retention: time.Hour}, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seed(store)
+	store.Close()
+
+	records, err := openStore(t, path, clock.Real).Query(t.Context(), exporter.HistoryQuery{Endpoint: "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected 4 persisted records, got %d", len(records))
+	}
+}
+
+func TestStorePrunesPastRetention(t *testing.T) {
+	clk := clock.NewFake(start.Add(26 * time.Hour))
+	store := openStore(t, filepath.Join(t.TempDir(), "history.db"), clk)
+	seed(store)
+
+	removed, err := store.Prune(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Results older than start+2h fall outside the 24h retention
+	if removed != 4 {
+		t.Fatalf("expected 4 pruned results, got %d", removed)
+	}
+	records, _ := store.Query(t.Context(), exporter.HistoryQuery{})
+	if len(records) != 4 || !records[0].CheckedAt.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("unexpected remaining records %+v", records)
+	}
+}
@@ -0,0 +1,3 @@
+// Package integration exercises the exporter end to end against a real MinIO server.
+// The tests are behind the integration build tag; run them with make integration.
+package integration
@@ -0,0 +1,156 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/internal/handlers"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// loadManager loads endpointsJSON through the regular config path and builds a manager
+func loadManager(t *testing.T, endpointsJSON string) *exporter.ValidatorManager {
+	t.Helper()
+	t.Setenv("S3_ENDPOINTS_JSON", endpointsJSON)
+	t.Setenv("VALIDATION_TIMEOUT", "10s")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return exporter.NewValidatorManager(cfg, logrus.New())
+}
+
+// scrape returns the exporter's /metrics output
+func scrape(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(promhttp.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func expectMetric(t *testing.T, metrics, line string) {
+	t.Helper()
+	for _, got := range strings.Split(metrics, "\n") {
+		if got == line {
+			return
+		}
+	}
+	t.Fatalf("expected metric line %q in /metrics output", line)
+}
+
+func TestValidKeys(t *testing.T) {
+	minio := startMinIO(t)
+	bucket := minio.createBucket(t)
+	manager := loadManager(t, fmt.Sprintf(
+		`[{"name":"it-valid","endpoint":%q,"bucket":%q,"access_key":%q,"secret_key":%q,"use_path_style":true}]`,
+		minio.endpoint, bucket, minio.accessKey, minio.secretKey))
+
+	server := httptest.NewServer(handlers.NewRouter(manager, logrus.New()))
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/validate/it-valid", "application/json", nil)
+	if err != nil {
+		t.Fatalf("validate request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response handlers.ValidationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !response.IsValid {
+		t.Fatalf("expected valid keys, got %d: %+v", resp.StatusCode, response)
+	}
+
+	metrics := scrape(t)
+	expectMetric(t, metrics, `s3_keys_valid{bucket="it-valid"} 1`)
+	expectMetric(t, metrics, `s3_probe_success{bucket="it-valid",depth="shallow"} 1`)
+}
+
+func TestInvalidSecretKey(t *testing.T) {
+	minio := startMinIO(t)
+	bucket := minio.createBucket(t)
+	manager := loadManager(t, fmt.Sprintf(
+		`[{"name":"it-invalid","endpoint":%q,"bucket":%q,"access_key":%q,"secret_key":"wrong-secret","use_path_style":true}]`,
+		minio.endpoint, bucket, minio.accessKey))
+
+	result := manager.ValidateEndpoint(context.Background(), "it-invalid")
+	if result.IsValid || result.ErrorType != "access_denied" {
+		t.Fatalf("expected an access_denied failure, got %+v", result)
+	}
+	expectMetric(t, scrape(t), `s3_keys_valid{bucket="it-invalid"} 0`)
+}
+
+func TestMissingBucket(t *testing.T) {
+	minio := startMinIO(t)
+	manager := loadManager(t, fmt.Sprintf(
+		`[{"name":"it-missing","endpoint":%q,"bucket":"it-does-not-exist","access_key":%q,"secret_key":%q,"use_path_style":true}]`,
+		minio.endpoint, minio.accessKey, minio.secretKey))
+
+	result := manager.ValidateEndpoint(context.Background(), "it-missing")
+	if result.IsValid || result.ErrorType != "bucket_not_found" {
+		t.Fatalf("expected a bucket_not_found failure, got %+v", result)
+	}
+}
+
+func TestDeepProbeCleansUp(t *testing.T) {
+	minio := startMinIO(t)
+	bucket := minio.createBucket(t)
+	manager := loadManager(t, fmt.Sprintf(
+		`[{"name":"it-deep","endpoint":%q,"bucket":%q,"access_key":%q,"secret_key":%q,"use_path_style":true,"probe_depth":"deep"}]`,
+		minio.endpoint, bucket, minio.accessKey, minio.secretKey))
+
+	results := manager.ValidateDeep(context.Background())
+	result := results.Results["it-deep"]
+	if result == nil || !result.IsValid || result.Depth != s3.ProbeDepthDeep {
+		t.Fatalf("expected a passing deep probe, got %+v", result)
+	}
+	if keys := minio.objects(t, bucket); len(keys) != 0 {
+		t.Fatalf("expected the probe object to be deleted, found %v", keys)
+	}
+	expectMetric(t, scrape(t), `s3_probe_success{bucket="it-deep",depth="deep"} 1`)
+}
+
+func TestPermissionDiscovery(t *testing.T) {
+	minio := startMinIO(t)
+	bucket := minio.createBucket(t)
+	manager := loadManager(t, fmt.Sprintf(
+		`[{"name":"it-discover","endpoint":%q,"bucket":%q,"access_key":%q,"secret_key":%q,"use_path_style":true,"expected_permissions":{"DeleteObject":"denied"}}]`,
+		minio.endpoint, bucket, minio.accessKey, minio.secretKey))
+
+	results := manager.AssertPermissions(context.Background())
+
+	drift, ok := results.PermissionDrift["it-discover"]
+	if !ok || !drift.Drifted() {
+		t.Fatalf("expected the root user to drift from a denied DeleteObject, got %+v", results.PermissionDrift)
+	}
+	if keys := minio.objects(t, bucket); len(keys) != 0 {
+		t.Fatalf("expected discovery to clean up, found %v", keys)
+	}
+	metrics := scrape(t)
+	expectMetric(t, metrics, `s3_permission{bucket="it-discover",operation="PutObject"} 1`)
+	expectMetric(t, metrics, `s3_permission_drift{bucket="it-discover",operation="DeleteObject"} 1`)
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// minioImage is the MinIO release started when MINIO_ENDPOINT is not set; it is
+	// pinned so a new release cannot change the results under an unchanged tree
+	minioImage = "minio/minio:RELEASE.2025-04-22T22-12-26Z"
+	minioUser  = "minioadmin"
+	minioPass  = "minioadmin"
+	// startupTimeout bounds how long MinIO may take to become ready
+	startupTimeout = 60 * time.Second
+)
+
+// minioServer is a running MinIO instance the tests validate against
+type minioServer struct {
+	endpoint  string
+	accessKey string
+	secretKey string
+}
+
+var (
+	minioOnce sync.Once
+	shared    *minioServer
+	sharedErr error
+	container testcontainers.Container
+)
+
+// TestMain removes the MinIO container once every test has run. Should the binary
+// panic or time out first, the testcontainers reaper removes it instead.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := testcontainers.TerminateContainer(container); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to remove the MinIO container: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// startMinIO returns the MinIO at MINIO_ENDPOINT (with MINIO_ACCESS_KEY and
+// MINIO_SECRET_KEY) or a throwaway container shared by all tests. The test is skipped
+// when neither is available.
+func startMinIO(t *testing.T) *minioServer {
+	t.Helper()
+	if os.Getenv("MINIO_ENDPOINT") == "" {
+		testcontainers.SkipIfProviderIsNotHealthy(t)
+	}
+	minioOnce.Do(func() {
+		shared, sharedErr = launchMinIO()
+	})
+	if sharedErr != nil {
+		t.Fatalf("failed to start MinIO: %v", sharedErr)
+	}
+	return shared
+}
+
+func launchMinIO() (*minioServer, error) {
+	if endpoint := os.Getenv("MINIO_ENDPOINT"); endpoint != "" {
+		server := &minioServer{endpoint: endpoint, accessKey: envOr("MINIO_ACCESS_KEY", minioUser), secretKey: envOr("MINIO_SECRET_KEY", minioPass)}
+		return server, waitReady(server.endpoint)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+	var err error
+	container, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        minioImage,
+			ExposedPorts: []string{"9000/tcp"},
+			Env:          map[string]string{"MINIO_ROOT_USER": minioUser, "MINIO_ROOT_PASSWORD": minioPass},
+			Cmd:          []string{"server", "/data"},
+			WaitingFor:   wait.ForHTTP("/minio/health/ready").WithPort("9000/tcp").WithStartupTimeout(startupTimeout),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := container.PortEndpoint(ctx, "9000/tcp", "http")
+	if err != nil {
+		return nil, err
+	}
+	return &minioServer{endpoint: endpoint, accessKey: minioUser, secretKey: minioPass}, nil
+}
+
+// createBucket creates a bucket with a unique name and returns it
+func (m *minioServer) createBucket(t *testing.T) string {
+	t.Helper()
+	bucket := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	_, err := m.client(t).CreateBucket(context.Background(), &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		t.Fatalf("failed to create bucket %s: %v", bucket, err)
+	}
+	return bucket
+}
+
+// objects lists every key in the bucket
+func (m *minioServer) objects(t *testing.T, bucket string) []string {
+	t.Helper()
+	out, err := m.client(t).ListObjectsV2(context.Background(), &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	if err != nil {
+		t.Fatalf("failed to list bucket %s: %v", bucket, err)
+	}
+	var keys []string
+	for _, object := range out.Contents {
+		keys = append(keys, aws.ToString(object.Key))
+	}
+	return keys
+}
+
+func (m *minioServer) client(t *testing.T) *s3.Client {
+	t.Helper()
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithRegion("us-east-1"),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(m.accessKey, m.secretKey, "")),
+	)
+	if err != nil {
+		t.Fatalf("failed to load AWS config: %v", err)
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(m.endpoint)
+		o.UsePathStyle = true
+	})
+}
+
+// waitReady polls MinIO's readiness endpoint until it answers
+func waitReady(endpoint string) error {
+	deadline := time.Now().Add(startupTimeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(endpoint + "/minio/health/ready")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("MinIO at %s did not become ready within %s", endpoint, startupTimeout)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"text/template"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+const (
+	// execOutputLimit caps how much command output is kept for error messages
+	execOutputLimit = 1024
+	// execWaitDelay is how long a killed command may hold its output pipes open
+	execWaitDelay = time.Second
+)
+
+// execPayload is the JSON document written to the command's stdin
+type execPayload struct {
+	Endpoint    string            `json:"endpoint"`
+	Bucket      string            `json:"bucket"`
+	Severity    string            `json:"severity"`
+	State       string            `json:"state"`
+	Check       string            `json:"check,omitempty"`
+	IsValid     bool              `json:"is_valid"`
+	ErrorType   string            `json:"error_type,omitempty"`
+	Message     string            `json:"message"`
+	CheckedAt   string            `json:"checked_at"`
+	DurationMs  int64             `json:"duration_ms"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Exec runs a local command for every event with the event as JSON on stdin. At most
+// MaxConcurrent commands run at once; further events wait for a free slot. A template
+// replaces the stdin payload.
+type Exec struct {
+	cfg   config.ExecConfig
+	tmpl  *template.Template
+	slots chan struct{}
+}
+
+// NewExec creates an exec channel; tmpl may be nil
+func NewExec(cfg config.ExecConfig, tmpl *template.Template) *Exec {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = config.Duration(config.DefaultExecTimeout)
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = config.DefaultExecMaxConcurrent
+	}
+	return &Exec{cfg: cfg, tmpl: tmpl, slots: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// timeout covers waiting for a free slot and running the command
+func (e *Exec) timeout() time.Duration {
+	return time.Duration(e.cfg.Timeout)
+}
+
+// Send runs the command and fails if it exits non-zero or times out
+func (e *Exec) Send(ctx context.Context, event Event) error {
+	select {
+	case e.slots <- struct{}{}:
+		defer func() { <-e.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("no free exec slot: %w", ctx.Err())
+	}
+
+	payload, err := e.payload(event)
+	if err != nil {
+		return err
+	}
+
+	output := &tailBuffer{limit: execOutputLimit}
+	cmd := exec.CommandContext(ctx, e.cfg.Command[0], e.cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = execWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w (%v)", ctx.Err(), err)
+		}
+		return fmt.Errorf("command %s failed: %w: %s", e.cfg.Command[0], err, bytes.TrimSpace(output.buf))
+	}
+	return nil
+}
+
+// payload renders the template or, without one, the default JSON document
+func (e *Exec) payload(event Event) ([]byte, error) {
+	if e.tmpl != nil {
+		payload, err := render(e.tmpl, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template: %w", err)
+		}
+		return payload, nil
+	}
+
+	payload, err := json.Marshal(execPayload{
+		Endpoint:    event.Endpoint,
+		Bucket:      event.Bucket,
+		Severity:    event.Severity,
+		State:       event.State,
+		Check:       event.Check,
+		IsValid:     event.IsValid,
+		ErrorType:   event.ErrorType,
+		Message:     event.Message,
+		CheckedAt:   event.CheckedAt.UTC().Format(time.RFC3339),
+		DurationMs:  event.Duration.Milliseconds(),
+		Labels:      event.Labels,
+		Annotations: event.Annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return payload, nil
+}
+
+// tailBuffer keeps the last limit bytes written to it and discards the rest, so a
+// chatty command cannot exhaust memory while its final output is still reported
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	if len(p) >= b.limit {
+		b.buf = append(b.buf[:0], p[len(p)-b.limit:]...)
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+func TestExecPassesEventOnStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event.json")
+	channel := NewExec(config.ExecConfig{Command: []string{"/bin/sh", "-c", `cat > "$0"`, out}}, nil)
+
+	event := Event{Endpoint: "prod", Bucket: "prod-bucket", Severity: config.SeverityCritical, State: StateFailed, ErrorType: "timeout", Message: "timed out", CheckedAt: time.Unix(1730000000, 0), Duration: 1500 * time.Millisecond}
+	if err := channel.Send(context.Background(), event); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("command did not write the payload: %v", err)
+	}
+	var payload execPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("invalid payload %q: %v", data, err)
+	}
+	if payload.Endpoint != "prod" || payload.State != StateFailed || payload.IsValid || payload.DurationMs != 1500 || payload.CheckedAt != "2024-10-27T03:33:20Z" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestExecReportsFailures(t *testing.T) {
+	channel := NewExec(config.ExecConfig{Command: []string{"/bin/sh", "-c", "echo boom >&2; exit 3"}}, nil)
+	err := channel.Send(context.Background(), Event{Endpoint: "prod"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the command output in the error, got %v", err)
+	}
+}
+
+func TestTailBufferKeepsLastOutput(t *testing.T) {
+	output := &tailBuffer{limit: 8}
+	for _, chunk := range []string{"abc", "defgh", "ij", strings.Repeat("x", 20) + "12345678", "9"} {
+		if n, err := output.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("expected the whole chunk to be accepted, got %d, %v", n, err)
+		}
+	}
+	if got := string(output.buf); got != "23456789" {
+		t.Fatalf("expected the last 8 bytes, got %q", got)
+	}
+	if cap(output.buf) > 4*output.limit {
+		t.Fatalf("expected the buffer to stay bounded, got capacity %d", cap(output.buf))
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	channel := NewExec(config.ExecConfig{Command: []string{"/bin/sh", "-c", "sleep 5"}, Timeout: config.Duration(100 * time.Millisecond)}, nil)
+	if channel.timeout() != 100*time.Millisecond {
+		t.Fatalf("expected the configured timeout, got %s", channel.timeout())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), channel.timeout())
+	defer cancel()
+
+	start := time.Now()
+	err := channel.Send(ctx, Event{Endpoint: "prod"})
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("expected the command to be killed, took %s", elapsed)
+	}
+}
+
+func TestExecLimitsConcurrency(t *testing.T) {
+	channel := NewExec(config.ExecConfig{Command: []string{"/bin/sh", "-c", "sleep 0.2"}, MaxConcurrent: 1}, nil)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := channel.Send(context.Background(), Event{Endpoint: "prod"}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Fatalf("expected commands to run one at a time, all finished in %s", elapsed)
+	}
+}
+
+func TestExecTemplateReplacesPayload(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event.txt")
+	tmpl, err := ParseTemplate("exec", "{{.Endpoint}} {{.Labels.owner}}")
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	channel := NewExec(config.ExecConfig{Command: []string{"/bin/sh", "-c", `cat > "$0"`, out}}, tmpl)
+
+	if err := channel.Send(context.Background(), Event{Endpoint: "prod", Labels: map[string]string{"owner": "storage"}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "prod storage" {
+		t.Fatalf("unexpected stdin %q", data)
+	}
+}
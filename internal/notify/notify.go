@@ -0,0 +1,404 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/internal/signing"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sendTimeout bounds a single channel delivery
+const sendTimeout = 10 * time.Second
+
+// Event states
+const (
+	StateFailed    = "failed"
+	StateRecovered = "recovered"
+	// StateLatencyAnomaly and StateLatencyNormal track latency anomalies of valid keys
+	StateLatencyAnomaly = "latency_anomaly"
+	StateLatencyNormal  = "latency_normal"
+	// StatePermissionDrift and StatePermissionRestored track expected_permissions mismatches
+	StatePermissionDrift    = "permission_drift"
+	StatePermissionRestored = "permission_restored"
+	// StateCheckFailed and StateCheckPassed track bucket checks failing critically, such
+	// as a bucket turning public. They are always sent with critical severity.
+	StateCheckFailed = "check_failed"
+	StateCheckPassed = "check_passed"
+)
+
+// Event describes an endpoint whose key validity, latency, permission or critical
+// check state changed
+type Event struct {
+	Endpoint  string
+	Bucket    string
+	Severity  string
+	State     string
+	Check     string // bucket check of check_failed and check_passed events
+	IsValid   bool
+	ErrorType string
+	Message   string
+	CheckedAt time.Time
+	Duration  time.Duration
+	Labels    map[string]string
+	// Annotations tell responders who owns the endpoint and where its runbook is
+	Annotations map[string]string
+}
+
+// Resolved reports whether the event ends a problem opened by an earlier event
+func (e Event) Resolved() bool {
+	return e.State == StateRecovered || e.State == StateLatencyNormal || e.State == StatePermissionRestored || e.State == StateCheckPassed
+}
+
+// Title summarizes the event in one line
+func (e Event) Title() string {
+	switch e.State {
+	case StateRecovered:
+		return fmt.Sprintf("S3 credentials recovered for %s", e.Endpoint)
+	case StateLatencyAnomaly:
+		return fmt.Sprintf("S3 validation latency anomaly for %s", e.Endpoint)
+	case StateLatencyNormal:
+		return fmt.Sprintf("S3 validation latency back to normal for %s", e.Endpoint)
+	case StatePermissionDrift:
+		return fmt.Sprintf("S3 permissions drifted from expectations for %s", e.Endpoint)
+	case StatePermissionRestored:
+		return fmt.Sprintf("S3 permissions match expectations again for %s", e.Endpoint)
+	case StateCheckFailed:
+		return fmt.Sprintf("S3 bucket check %s failed for %s", e.Check, e.Endpoint)
+	case StateCheckPassed:
+		return fmt.Sprintf("S3 bucket check %s passes again for %s", e.Check, e.Endpoint)
+	default:
+		return fmt.Sprintf("S3 credentials invalid for %s", e.Endpoint)
+	}
+}
+
+// Channel delivers events to an external system
+type Channel interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// timedChannel is implemented by channels that need a delivery timeout other than sendTimeout
+type timedChannel interface {
+	timeout() time.Duration
+}
+
+// httpChannel is implemented by channels delivering over HTTP, whose requests can be signed
+type httpChannel interface {
+	httpClient() *http.Client
+}
+
+// DispatcherOption customizes optional dispatcher settings
+type DispatcherOption func(*dispatcherSettings)
+
+type dispatcherSettings struct {
+	signer    *signing.Signer
+	endpoints EndpointResolver
+}
+
+// WithSigner signs the payloads of channels delivering over HTTP with an Ed25519 key
+func WithSigner(signer *signing.Signer) DispatcherOption {
+	return func(s *dispatcherSettings) {
+		s.signer = signer
+	}
+}
+
+// EndpointResolver returns the current configuration of an endpoint, including
+// endpoints added after startup such as discovered ones
+type EndpointResolver interface {
+	EndpointMetadata(endpointName string) (exporter.EndpointMetadata, bool)
+}
+
+// WithEndpointResolver looks up the bucket, severity, labels and annotations copied into
+// events in resolver, falling back to the endpoints passed to NewDispatcher
+func WithEndpointResolver(resolver EndpointResolver) DispatcherOption {
+	return func(s *dispatcherSettings) {
+		s.endpoints = resolver
+	}
+}
+
+// route is a channel together with the endpoint severities it receives
+type route struct {
+	name       string
+	channel    Channel
+	severities map[string]bool // empty accepts every severity
+}
+
+// checkKey identifies a bucket check of an endpoint
+type checkKey struct {
+	endpoint string
+	check    string
+}
+
+// endpointInfo is the endpoint configuration copied into events
+type endpointInfo struct {
+	bucket      string
+	severity    string
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// Dispatcher is a result sink that notifies channels when an endpoint's keys turn
+// invalid or recover. Deliveries run in the background so slow channels never delay
+// validation.
+type Dispatcher struct {
+	routes    []route
+	endpoints map[string]endpointInfo
+	resolver  EndpointResolver
+	log       *logrus.Logger
+
+	mu           sync.Mutex
+	valid        map[string]bool   // last known validity per endpoint
+	anomalous    map[string]bool   // endpoints whose latency is currently anomalous
+	drifted      map[string]bool   // endpoints whose permissions currently drift
+	failedChecks map[checkKey]bool // checks currently failing critically
+
+	wg sync.WaitGroup
+}
+
+// NewDispatcher builds the configured channels
+func NewDispatcher(cfg *config.NotificationsConfig, endpoints []config.S3EndpointConfig, log *logrus.Logger, opts ...DispatcherOption) (*Dispatcher, error) {
+	var settings dispatcherSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	d := &Dispatcher{
+		endpoints:    make(map[string]endpointInfo, len(endpoints)),
+		resolver:     settings.endpoints,
+		log:          log,
+		valid:        make(map[string]bool),
+		anomalous:    make(map[string]bool),
+		drifted:      make(map[string]bool),
+		failedChecks: make(map[checkKey]bool),
+	}
+	for _, endpoint := range endpoints {
+		d.endpoints[endpoint.Name] = endpointInfo{
+			bucket:      endpoint.Bucket,
+			severity:    endpoint.Severity,
+			labels:      endpoint.Labels,
+			annotations: endpoint.Annotations,
+		}
+	}
+
+	for _, channelCfg := range cfg.Channels {
+		channel, err := newChannel(channelCfg)
+		if err != nil {
+			return nil, fmt.Errorf("channel %q: %w", channelCfg.Name, err)
+		}
+		if client, ok := channel.(httpChannel); ok && settings.signer != nil {
+			client.httpClient().Transport = signing.Transport(settings.signer, client.httpClient().Transport)
+		}
+		r := route{name: channelCfg.Name, channel: channel, severities: make(map[string]bool)}
+		for _, severity := range channelCfg.Severities {
+			r.severities[severity] = true
+		}
+		d.routes = append(d.routes, r)
+	}
+	return d, nil
+}
+
+func newChannel(cfg config.ChannelConfig) (Channel, error) {
+	tmpl, err := ParseTemplate(cfg.Name, cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	switch cfg.Type {
+	case config.ChannelOpsgenie:
+		if cfg.Opsgenie == nil {
+			return nil, fmt.Errorf("missing opsgenie settings")
+		}
+		return NewOpsgenie(*cfg.Opsgenie, tmpl), nil
+	case config.ChannelTeams:
+		if cfg.Teams == nil {
+			return nil, fmt.Errorf("missing teams settings")
+		}
+		return NewTeams(*cfg.Teams, tmpl), nil
+	case config.ChannelExec:
+		if cfg.Exec == nil || len(cfg.Exec.Command) == 0 {
+			return nil, fmt.Errorf("missing exec command")
+		}
+		return NewExec(*cfg.Exec, tmpl), nil
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", cfg.Type)
+	}
+}
+
+// Consume turns validity, latency anomaly, permission drift and critical check changes
+// in the batch into events. An endpoint failing on its first validation is reported;
+// one that starts out valid is not. Endpoints rolled up into an unreachable provider
+// keep their state, and deep batches are ignored.
+func (d *Dispatcher) Consume(results *exporter.ValidationResults) {
+	if results.Deep() {
+		return
+	}
+	rolledUp := results.RolledUpEndpoints(results.UnreachableProviders())
+
+	for name, result := range results.Results {
+		if result == nil || rolledUp[name] {
+			continue
+		}
+		d.consumeChecks(name, result)
+
+		d.mu.Lock()
+		previous, seen := d.valid[name]
+		d.valid[name] = result.IsValid
+		d.mu.Unlock()
+
+		var state string
+		switch {
+		case !result.IsValid && (!seen || previous):
+			state = StateFailed
+		case result.IsValid && seen && !previous:
+			state = StateRecovered
+		default:
+			continue
+		}
+
+		event := d.newEvent(name, state, result)
+		event.ErrorType = result.ErrorType
+		event.Message = result.Message
+		d.dispatch(event)
+	}
+
+	for name, anomaly := range results.Anomalies {
+		d.mu.Lock()
+		previous := d.anomalous[name]
+		d.anomalous[name] = anomaly.Anomalous
+		d.mu.Unlock()
+
+		if previous == anomaly.Anomalous {
+			continue
+		}
+		state := StateLatencyNormal
+		if anomaly.Anomalous {
+			state = StateLatencyAnomaly
+		}
+		event := d.newEvent(name, state, results.Results[name])
+		event.Message = anomaly.Describe()
+		d.dispatch(event)
+	}
+
+	for name, drift := range results.PermissionDrift {
+		drifted := drift.Drifted()
+		d.mu.Lock()
+		previous := d.drifted[name]
+		d.drifted[name] = drifted
+		d.mu.Unlock()
+
+		if previous == drifted {
+			continue
+		}
+		state := StatePermissionRestored
+		if drifted {
+			state = StatePermissionDrift
+		}
+		event := d.newEvent(name, state, nil)
+		event.CheckedAt = drift.CheckedAt
+		event.Message = drift.Describe()
+		d.dispatch(event)
+	}
+}
+
+// consumeChecks reports bucket checks that start failing critically, such as a bucket
+// turning public, and their recovery. Other check verdicts are left to logs and metrics.
+func (d *Dispatcher) consumeChecks(name string, result *s3.ValidationResult) {
+	for _, check := range result.Checks {
+		key := checkKey{endpoint: name, check: check.Name}
+		d.mu.Lock()
+		failing := d.failedChecks[key]
+		if check.Critical {
+			d.failedChecks[key] = true
+		} else if check.Passed {
+			delete(d.failedChecks, key)
+		}
+		d.mu.Unlock()
+
+		var state string
+		switch {
+		case check.Critical && !failing:
+			state = StateCheckFailed
+		case check.Passed && failing:
+			state = StateCheckPassed
+		default:
+			continue
+		}
+
+		event := d.newEvent(name, state, result)
+		event.Severity = config.SeverityCritical
+		event.Check = check.Name
+		event.CheckedAt = check.CheckedAt
+		event.Message = check.Message
+		d.dispatch(event)
+	}
+}
+
+// newEvent fills in the endpoint's configuration and the result's timing; a severity
+// set by a result rule overrides the endpoint's
+func (d *Dispatcher) newEvent(name, state string, result *s3.ValidationResult) Event {
+	info := d.endpoints[name]
+	if d.resolver != nil {
+		if meta, ok := d.resolver.EndpointMetadata(name); ok {
+			info = endpointInfo{bucket: meta.Bucket, severity: meta.Severity, labels: meta.Labels, annotations: meta.Annotations}
+		}
+	}
+	if info.severity == "" {
+		info.severity = config.SeverityWarning
+	}
+	event := Event{
+		Endpoint:    name,
+		Bucket:      info.bucket,
+		Severity:    info.severity,
+		State:       state,
+		Labels:      info.labels,
+		Annotations: info.annotations,
+	}
+	if result != nil {
+		if result.Severity != "" {
+			event.Severity = result.Severity
+		}
+		event.IsValid = result.IsValid
+		event.CheckedAt = result.CheckedAt
+		event.Duration = result.Duration
+	}
+	return event
+}
+
+// dispatch sends the event to every channel accepting its severity
+func (d *Dispatcher) dispatch(event Event) {
+	for _, r := range d.routes {
+		if len(r.severities) > 0 && !r.severities[event.Severity] {
+			continue
+		}
+
+		d.wg.Add(1)
+		go func(r route) {
+			defer d.wg.Done()
+
+			timeout := sendTimeout
+			if tc, ok := r.channel.(timedChannel); ok {
+				timeout = tc.timeout()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			fields := logrus.Fields{"channel": r.name, "endpoint": event.Endpoint, "state": event.State}
+			if err := r.channel.Send(ctx, event); err != nil {
+				d.log.WithFields(fields).WithError(err).Error("Failed to send notification")
+				return
+			}
+			d.log.WithFields(fields).Debug("Sent notification")
+		}(r)
+	}
+}
+
+// Wait blocks until every pending delivery has finished
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,238 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recordingChannel struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *recordingChannel) Send(ctx context.Context, event Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	return nil
+}
+
+func (c *recordingChannel) states() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var states []string
+	for _, event := range c.events {
+		states = append(states, event.Endpoint+":"+event.State)
+	}
+	return states
+}
+
+func newTestDispatcher(routes ...route) *Dispatcher {
+	return &Dispatcher{
+		routes: routes,
+		endpoints: map[string]endpointInfo{
+			"prod":    {bucket: "prod-bucket", severity: config.SeverityCritical, labels: map[string]string{"team": "storage"}},
+			"staging": {bucket: "staging-bucket", severity: config.SeverityWarning},
+		},
+		log:          logrus.New(),
+		valid:        make(map[string]bool),
+		anomalous:    make(map[string]bool),
+		drifted:      make(map[string]bool),
+		failedChecks: make(map[checkKey]bool),
+	}
+}
+
+func batch(results map[string]bool) *exporter.ValidationResults {
+	batch := &exporter.ValidationResults{Results: make(map[string]*s3.ValidationResult)}
+	for name, valid := range results {
+		batch.Results[name] = &s3.ValidationResult{IsValid: valid, Message: "tested", ErrorType: "access_denied", CheckedAt: time.Now()}
+	}
+	return batch
+}
+
+func TestDispatcherNotifiesOnTransitions(t *testing.T) {
+	channel := &recordingChannel{}
+	d := newTestDispatcher(route{name: "all", channel: channel})
+
+	for _, results := range []map[string]bool{
+		{"prod": true},
+		{"prod": false},
+		{"prod": false},
+		{"prod": true},
+		{"staging": false},
+	} {
+		d.Consume(batch(results))
+		d.Wait()
+	}
+
+	states := channel.states()
+	want := []string{"prod:failed", "prod:recovered", "staging:failed"}
+	if len(states) != len(want) {
+		t.Fatalf("expected %v, got %v", want, states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, states)
+		}
+	}
+
+	event := channel.events[0]
+	if event.Bucket != "prod-bucket" || event.Severity != config.SeverityCritical || event.ErrorType != "access_denied" || event.Labels["team"] != "storage" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+}
+
+func TestDispatcherNotifiesCriticalChecks(t *testing.T) {
+	pager := &recordingChannel{}
+	d := newTestDispatcher(route{name: "pager", channel: pager, severities: map[string]bool{config.SeverityCritical: true}})
+
+	for _, check := range []s3.CheckResult{
+		{Name: s3.CheckPublicAccess, Passed: true},
+		{Name: s3.CheckPublicAccess, Critical: true, Message: "bucket policy is public"},
+		{Name: s3.CheckPublicAccess, Critical: true, Message: "bucket policy is public"},
+		{Name: s3.CheckPublicAccess, Passed: true},
+	} {
+		d.Consume(&exporter.ValidationResults{Results: map[string]*s3.ValidationResult{
+			"staging": {IsValid: true, CheckedAt: time.Now(), Checks: []s3.CheckResult{check}},
+		}})
+		d.Wait()
+	}
+
+	states := pager.states()
+	if len(states) != 2 || states[0] != "staging:"+StateCheckFailed || states[1] != "staging:"+StateCheckPassed {
+		t.Fatalf("expected the public bucket and its fix on the critical channel, got %v", states)
+	}
+	event := pager.events[0]
+	if event.Severity != config.SeverityCritical || event.Check != s3.CheckPublicAccess || event.Message != "bucket policy is public" || event.Bucket != "staging-bucket" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if !pager.events[1].Resolved() {
+		t.Fatalf("expected the passing check to resolve the alert")
+	}
+}
+
+type stubResolver map[string]exporter.EndpointMetadata
+
+func (r stubResolver) EndpointMetadata(endpointName string) (exporter.EndpointMetadata, bool) {
+	meta, ok := r[endpointName]
+	return meta, ok
+}
+
+func TestDispatcherResolvesDiscoveredEndpoints(t *testing.T) {
+	channel := &recordingChannel{}
+	d, err := NewDispatcher(&config.NotificationsConfig{}, nil, logrus.New(), WithEndpointResolver(stubResolver{
+		"discovered": {Bucket: "found-bucket", Severity: config.SeverityCritical, Annotations: map[string]string{"owner": "data"}},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.routes = []route{{name: "all", channel: channel}}
+
+	d.Consume(batch(map[string]bool{"discovered": false}))
+	d.Wait()
+
+	if len(channel.events) != 1 {
+		t.Fatalf("expected one event, got %v", channel.states())
+	}
+	event := channel.events[0]
+	if event.Bucket != "found-bucket" || event.Severity != config.SeverityCritical || event.Annotations["owner"] != "data" {
+		t.Fatalf("expected the discovered endpoint's configuration, got %+v", event)
+	}
+}
+
+func TestDispatcherRoutesBySeverity(t *testing.T) {
+	critical := &recordingChannel{}
+	everything := &recordingChannel{}
+	d := newTestDispatcher(
+		route{name: "pager", channel: critical, severities: map[string]bool{config.SeverityCritical: true}},
+		route{name: "chat", channel: everything},
+	)
+
+	d.Consume(batch(map[string]bool{"prod": false, "staging": false, "unknown": false}))
+	d.Wait()
+
+	if states := critical.states(); len(states) != 1 || states[0] != "prod:failed" {
+		t.Fatalf("expected only the critical endpoint on the pager channel, got %v", states)
+	}
+	if states := everything.states(); len(states) != 3 {
+		t.Fatalf("expected every endpoint on the unfiltered channel, got %v", states)
+	}
+}
+
+func TestNewDispatcher(t *testing.T) {
+	cfg := &config.NotificationsConfig{Channels: []config.ChannelConfig{
+		{Name: "og", Type: config.ChannelOpsgenie, Severities: []string{config.SeverityCritical}, Opsgenie: &config.OpsgenieConfig{APIKey: "key"}},
+		{Name: "teams", Type: config.ChannelTeams, Teams: &config.TeamsConfig{WebhookURL: "https://example.com/hook"}},
+	}}
+	d, err := NewDispatcher(cfg, []config.S3EndpointConfig{{Name: "prod", Bucket: "b", Severity: config.SeverityCritical}}, logrus.New())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(d.routes) != 2 || !d.routes[0].severities[config.SeverityCritical] {
+		t.Fatalf("unexpected routes: %+v", d.routes)
+	}
+
+	broken := *cfg
+	broken.Channels = []config.ChannelConfig{{Name: "broken", Type: "pager"}}
+	if _, err := NewDispatcher(&broken, nil, logrus.New()); err == nil {
+		t.Fatalf("expected error for an unknown channel type")
+	}
+
+	broken.Channels = []config.ChannelConfig{{Name: "teams", Type: config.ChannelTeams, Template: "{{.Endpoint", Teams: &config.TeamsConfig{WebhookURL: "https://example.com/hook"}}}
+	if _, err := NewDispatcher(&broken, nil, logrus.New()); err == nil {
+		t.Fatalf("expected error for an invalid template")
+	}
+}
+
+func TestDispatcherNotifiesLatencyAnomalies(t *testing.T) {
+	channel := &recordingChannel{}
+	d := newTestDispatcher(route{name: "all", channel: channel})
+
+	for _, anomalous := range []bool{false, true, true, false} {
+		results := batch(map[string]bool{"prod": true})
+		results.Anomalies = map[string]exporter.LatencyAnomaly{"prod": {Anomalous: anomalous, LatencyMs: 900, BaselineMs: 100, Factor: 5}}
+		d.Consume(results)
+		d.Wait()
+	}
+
+	states := channel.states()
+	if len(states) != 2 || states[0] != "prod:"+StateLatencyAnomaly || states[1] != "prod:"+StateLatencyNormal {
+		t.Fatalf("expected anomaly and normal events, got %v", states)
+	}
+	if event := channel.events[0]; !event.IsValid || event.Message == "" || event.Resolved() {
+		t.Fatalf("unexpected anomaly event: %+v", event)
+	}
+}
+
+func TestDispatcherNotifiesPermissionDrift(t *testing.T) {
+	channel := &recordingChannel{}
+	d := newTestDispatcher(route{name: "all", channel: channel})
+
+	for _, actual := range []string{s3.PermissionDenied, s3.PermissionAllowed, s3.PermissionAllowed, s3.PermissionDenied} {
+		d.Consume(&exporter.ValidationResults{PermissionDrift: map[string]exporter.PermissionDrift{
+			"prod": {CheckedAt: time.Now(), Assertions: []exporter.PermissionAssertion{
+				{Operation: s3.OperationDeleteObject, Expected: s3.PermissionDenied, Actual: actual},
+			}},
+		}})
+		d.Wait()
+	}
+
+	states := channel.states()
+	if len(states) != 2 || states[0] != "prod:"+StatePermissionDrift || states[1] != "prod:"+StatePermissionRestored {
+		t.Fatalf("expected drift and restored events, got %v", states)
+	}
+	if event := channel.events[0]; event.Message != "DeleteObject is allowed but expected denied" || event.Resolved() {
+		t.Fatalf("unexpected drift event: %+v", event)
+	}
+	if !channel.events[1].Resolved() {
+		t.Fatalf("expected the restored event to resolve the drift")
+	}
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+// opsgenieSource identifies the exporter in Opsgenie alerts
+const opsgenieSource = "key-aws-exporter"
+
+// severityPriority maps endpoint severities to Opsgenie priorities
+var severityPriority = map[string]string{
+	config.SeverityCritical: "P1",
+	config.SeverityWarning:  "P3",
+	config.SeverityInfo:     "P5",
+}
+
+// Opsgenie opens an alert when an endpoint fails and closes it on recovery. The
+// alert alias is derived from the endpoint so repeated failures deduplicate. A
+// template replaces the alert description.
+type Opsgenie struct {
+	cfg    config.OpsgenieConfig
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie channel; tmpl may be nil
+func NewOpsgenie(cfg config.OpsgenieConfig, tmpl *template.Template) *Opsgenie {
+	if cfg.APIURL == "" {
+		cfg.APIURL = config.DefaultOpsgenieAPIURL
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	return &Opsgenie{cfg: cfg, tmpl: tmpl, client: &http.Client{}}
+}
+
+func (o *Opsgenie) httpClient() *http.Client {
+	return o.client
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Priority    string              `json:"priority"`
+	Source      string              `json:"source"`
+	Tags        []string            `json:"tags"`
+	Details     map[string]string   `json:"details"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// Send creates or closes the endpoint's alert. Latency anomalies, permission drift and
+// each bucket check use their own aliases so they do not close a credential alert and
+// vice versa.
+func (o *Opsgenie) Send(ctx context.Context, event Event) error {
+	alias := opsgenieSource + "/" + event.Endpoint
+	switch event.State {
+	case StateLatencyAnomaly, StateLatencyNormal:
+		alias += "/latency"
+	case StatePermissionDrift, StatePermissionRestored:
+		alias += "/permissions"
+	case StateCheckFailed, StateCheckPassed:
+		alias += "/checks/" + event.Check
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + o.cfg.APIKey}
+
+	if event.Resolved() {
+		closeURL := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", o.cfg.APIURL, url.PathEscape(alias))
+		return postJSON(ctx, o.client, closeURL, headers, opsgenieClose{
+			Source: opsgenieSource,
+			Note:   event.Title(),
+		})
+	}
+
+	priority := o.cfg.Priority
+	if priority == "" {
+		priority = severityPriority[event.Severity]
+	}
+	alert := opsgenieAlert{
+		Message:     event.Title(),
+		Alias:       alias,
+		Description: event.Message,
+		Priority:    priority,
+		Source:      opsgenieSource,
+		Tags:        append([]string{opsgenieSource, event.Severity}, o.cfg.Tags...),
+		Details: map[string]string{
+			"endpoint":   event.Endpoint,
+			"bucket":     event.Bucket,
+			"severity":   event.Severity,
+			"error_type": event.ErrorType,
+			"checked_at": event.CheckedAt.UTC().Format(time.RFC3339),
+		},
+	}
+	if event.Check != "" {
+		alert.Details["check"] = event.Check
+	}
+	for key, value := range event.Annotations {
+		if _, taken := alert.Details[key]; !taken {
+			alert.Details[key] = value
+		}
+	}
+	if o.tmpl != nil {
+		description, err := render(o.tmpl, event)
+		if err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+		alert.Description = string(description)
+	}
+	if o.cfg.Team != "" {
+		alert.Responders = []opsgenieResponder{{Name: o.cfg.Team, Type: "team"}}
+	}
+	return postJSON(ctx, o.client, o.cfg.APIURL+"/v2/alerts", headers, alert)
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+func TestOpsgenieCreatesAndClosesAlerts(t *testing.T) {
+	type request struct {
+		path, query, auth string
+		body              map[string]any
+	}
+	requests := make(chan request, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests <- request{path: r.URL.EscapedPath(), query: r.URL.RawQuery, auth: r.Header.Get("Authorization"), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	og := NewOpsgenie(config.OpsgenieConfig{APIKey: "secret", APIURL: server.URL + "/", Team: "storage"}, nil)
+	event := Event{Endpoint: "prod", Bucket: "prod-bucket", Severity: config.SeverityCritical, State: StateFailed, ErrorType: "access_denied", Message: "denied", CheckedAt: time.Now(),
+		Annotations: map[string]string{"owner": "team-storage", "bucket": "not-the-bucket"}}
+
+	if err := og.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to create alert: %v", err)
+	}
+	created := <-requests
+	if created.path != "/v2/alerts" || created.auth != "GenieKey secret" {
+		t.Fatalf("unexpected create request: %+v", created)
+	}
+	if created.body["alias"] != "key-aws-exporter/prod" || created.body["priority"] != "P1" || created.body["description"] != "denied" {
+		t.Fatalf("unexpected alert: %v", created.body)
+	}
+	details, _ := created.body["details"].(map[string]any)
+	if details["owner"] != "team-storage" || details["bucket"] != "prod-bucket" {
+		t.Fatalf("expected annotations in the details without overriding built-in keys, got %v", details)
+	}
+	if responders, ok := created.body["responders"].([]any); !ok || len(responders) != 1 {
+		t.Fatalf("expected the team as responder, got %v", created.body["responders"])
+	}
+
+	event.State = StateRecovered
+	if err := og.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to close alert: %v", err)
+	}
+	closed := <-requests
+	if closed.path != "/v2/alerts/key-aws-exporter%2Fprod/close" || closed.query != "identifierType=alias" {
+		t.Fatalf("unexpected close request: %+v", closed)
+	}
+}
+
+func TestOpsgeniePermissionDriftAlias(t *testing.T) {
+	paths := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if alias, ok := body["alias"].(string); ok {
+			paths <- alias
+		} else {
+			paths <- r.URL.EscapedPath()
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	og := NewOpsgenie(config.OpsgenieConfig{APIKey: "secret", APIURL: server.URL}, nil)
+	event := Event{Endpoint: "prod", Severity: config.SeverityWarning, State: StatePermissionDrift, Message: "DeleteObject is allowed but expected denied"}
+	if err := og.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to create alert: %v", err)
+	}
+	if alias := <-paths; alias != "key-aws-exporter/prod/permissions" {
+		t.Fatalf("expected the permissions alias, got %q", alias)
+	}
+
+	event.State = StatePermissionRestored
+	if err := og.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to close alert: %v", err)
+	}
+	if path := <-paths; path != "/v2/alerts/key-aws-exporter%2Fprod%2Fpermissions/close" {
+		t.Fatalf("unexpected close request %q", path)
+	}
+}
+
+func TestOpsgenieReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Key format is not valid!"}`, http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	og := NewOpsgenie(config.OpsgenieConfig{APIKey: "bad", APIURL: server.URL, Priority: "P2"}, nil)
+	err := og.Send(context.Background(), Event{Endpoint: "prod", State: StateFailed})
+	if err == nil {
+		t.Fatalf("expected an error for a rejected alert")
+	}
+}
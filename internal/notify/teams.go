@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"text/template"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+// Teams posts an Adaptive Card to a Microsoft Teams incoming webhook or workflow URL.
+// A template replaces the whole JSON payload.
+type Teams struct {
+	cfg    config.TeamsConfig
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewTeams creates a Microsoft Teams channel; tmpl may be nil
+func NewTeams(cfg config.TeamsConfig, tmpl *template.Template) *Teams {
+	return &Teams{cfg: cfg, tmpl: tmpl, client: &http.Client{}}
+}
+
+func (t *Teams) httpClient() *http.Client {
+	return t.client
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Send posts a card describing the event
+func (t *Teams) Send(ctx context.Context, event Event) error {
+	if t.tmpl != nil {
+		body, err := render(t.tmpl, event)
+		if err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+		return post(ctx, t.client, t.cfg.WebhookURL, nil, body)
+	}
+
+	color := "Attention"
+	switch {
+	case event.Resolved():
+		color = "Good"
+	case event.State == StateLatencyAnomaly, event.State == StatePermissionDrift:
+		color = "Warning"
+	}
+
+	facts := []teamsFact{
+		{Title: "Endpoint", Value: event.Endpoint},
+		{Title: "Bucket", Value: event.Bucket},
+		{Title: "Severity", Value: event.Severity},
+		{Title: "Checked at", Value: event.CheckedAt.UTC().Format(time.RFC3339)},
+	}
+	if event.Check != "" {
+		facts = append(facts, teamsFact{Title: "Check", Value: event.Check})
+	}
+	if event.ErrorType != "" {
+		facts = append(facts, teamsFact{Title: "Error type", Value: event.ErrorType})
+	}
+	for _, key := range slices.Sorted(maps.Keys(event.Annotations)) {
+		facts = append(facts, teamsFact{Title: key, Value: event.Annotations[key]})
+	}
+
+	message := teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []teamsElement{
+					{Type: "TextBlock", Text: event.Title(), Weight: "Bolder", Size: "Medium", Color: color, Wrap: true},
+					{Type: "TextBlock", Text: event.Message, Wrap: true},
+					{Type: "FactSet", Facts: facts},
+				},
+			},
+		}},
+	}
+	return postJSON(ctx, t.client, t.cfg.WebhookURL, nil, message)
+}
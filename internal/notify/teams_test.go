@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/signing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTeamsPostsAdaptiveCard(t *testing.T) {
+	bodies := make(chan teamsMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message teamsMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		bodies <- message
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	teams := NewTeams(config.TeamsConfig{WebhookURL: server.URL}, nil)
+	event := Event{Endpoint: "prod", Bucket: "prod-bucket", Severity: config.SeverityWarning, State: StateRecovered, Message: "AWS credentials are valid", CheckedAt: time.Now(),
+		Annotations: map[string]string{"runbook_url": "https://runbooks.example.com/prod"}}
+	if err := teams.Send(context.Background(), event); err != nil {
+		t.Fatalf("failed to post card: %v", err)
+	}
+
+	message := <-bodies
+	if len(message.Attachments) != 1 || message.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+	title := message.Attachments[0].Content.Body[0]
+	if !strings.Contains(title.Text, "recovered for prod") || title.Color != "Good" {
+		t.Fatalf("unexpected title block: %+v", title)
+	}
+	facts := message.Attachments[0].Content.Body[2].Facts
+	if last := facts[len(facts)-1]; last.Title != "runbook_url" || last.Value != "https://runbooks.example.com/prod" {
+		t.Fatalf("expected the annotations as facts, got %+v", facts)
+	}
+}
+
+func TestTeamsTemplateReplacesPayload(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- string(data)
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate("teams", `{"text": "{{.Endpoint}} is {{.State}} ({{.ErrorType}})"}`)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	teams := NewTeams(config.TeamsConfig{WebhookURL: server.URL}, tmpl)
+	if err := teams.Send(context.Background(), Event{Endpoint: "prod", State: StateFailed, ErrorType: "timeout"}); err != nil {
+		t.Fatalf("failed to post: %v", err)
+	}
+
+	if body := <-bodies; body != `{"text": "prod is failed (timeout)"}` {
+		t.Fatalf("unexpected payload %s", body)
+	}
+}
+
+func TestDispatcherSignsWebhooks(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := signing.NewSigner(key)
+	verified := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified <- signing.Verify(signer.PublicKey(), body, r.Header.Get(signing.SignatureHeader)) && r.Header.Get(signing.KeyIDHeader) == signer.KeyID()
+	}))
+	defer server.Close()
+
+	cfg := &config.NotificationsConfig{Channels: []config.ChannelConfig{{Name: "teams", Type: config.ChannelTeams, Teams: &config.TeamsConfig{WebhookURL: server.URL}}}}
+	dispatcher, err := NewDispatcher(cfg, nil, logrus.New(), WithSigner(signer))
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	if err := dispatcher.routes[0].channel.Send(context.Background(), Event{Endpoint: "prod", State: StateFailed, CheckedAt: time.Now()}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !<-verified {
+		t.Fatalf("expected the webhook payload to carry a valid signature")
+	}
+}
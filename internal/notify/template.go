@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the value notification templates are executed with
+type TemplateData struct {
+	Endpoint  string
+	Bucket    string
+	Severity  string
+	State     string
+	Check     string
+	IsValid   bool
+	ErrorType string
+	Message   string
+	CheckedAt time.Time
+	Duration  time.Duration
+	Labels    map[string]string
+	// Annotations holds owner, runbook_url and other notes, e.g. {{ .Annotations.owner }}
+	Annotations map[string]string
+}
+
+// templateFuncs are available in every notification template
+var templateFuncs = template.FuncMap{
+	// json encodes a value, so strings can be embedded in JSON payloads safely
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseTemplate parses a notification template; an empty text returns nil
+func ParseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// newTemplateData exposes the event to templates
+func newTemplateData(event Event) TemplateData {
+	return TemplateData{
+		Endpoint:    event.Endpoint,
+		Bucket:      event.Bucket,
+		Severity:    event.Severity,
+		State:       event.State,
+		Check:       event.Check,
+		IsValid:     event.IsValid,
+		ErrorType:   event.ErrorType,
+		Message:     event.Message,
+		CheckedAt:   event.CheckedAt,
+		Duration:    event.Duration,
+		Labels:      event.Labels,
+		Annotations: event.Annotations,
+	}
+}
+
+// render executes the template with the event
+func render(tmpl *template.Template, event Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newTemplateData(event)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("empty", "")
+	if err != nil || tmpl != nil {
+		t.Fatalf("expected no template for empty text, got %v, %v", tmpl, err)
+	}
+
+	if _, err := ParseTemplate("broken", "{{.Endpoint"); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("test", `{"text": {{json .Message}}, "who": "{{.Labels.team}}", "sev": "{{upper .Severity}}", "took": "{{.Duration}}", "missing": "{{.Labels.nope}}", "ok": {{.IsValid}}, "owner": "{{.Annotations.owner}}"}`)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	event := Event{
+		Endpoint:    "prod",
+		Severity:    "critical",
+		State:       StateFailed,
+		Message:     `denied "quoted"`,
+		Duration:    1500 * time.Millisecond,
+		Labels:      map[string]string{"team": "storage"},
+		Annotations: map[string]string{"owner": "alice"},
+	}
+	out, err := render(tmpl, event)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	want := `{"text": "denied \"quoted\"", "who": "storage", "sev": "CRITICAL", "took": "1.5s", "missing": "", "ok": false, "owner": "alice"}`
+	if string(out) != want {
+		t.Fatalf("expected %s, got %s", want, out)
+	}
+}
@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// maxDocumentSize bounds the discovery document and key set responses
+const maxDocumentSize = 1 << 20
+
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// discover reads the jwks_uri of the issuer's discovery document
+func (v *Verifier) discover(ctx context.Context) (string, error) {
+	var doc discoveryDocument
+	if err := v.getJSON(ctx, strings.TrimSuffix(v.cfg.Issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+		return "", fmt.Errorf("discovery failed: %w", err)
+	}
+	if doc.Issuer != v.cfg.Issuer {
+		return "", fmt.Errorf("discovery document is for issuer %q, not %q", doc.Issuer, v.cfg.Issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document has no jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+// fetchKeys downloads the issuer's signing keys. Keys that fail to parse, are not RSA
+// or EC public keys, or are meant for other uses are skipped.
+func (v *Verifier) fetchKeys(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	url := v.cfg.JWKSURL
+	if url == "" {
+		var err error
+		if url, err = v.discover(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Keys are decoded one by one so a single unusable key does not discard the set
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := v.getJSON(ctx, url, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	for _, data := range set.Keys {
+		var jwk jose.JSONWebKey
+		if err := jwk.UnmarshalJSON(data); err != nil {
+			v.log.WithError(err).Warn("Skipping unusable OIDC signing key")
+			continue
+		}
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys.Keys = append(keys.Keys, jwk)
+		}
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("%s holds no usable signing keys", url)
+	}
+	return keys, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(out)
+}
@@ -0,0 +1,257 @@
+// Package oidc authenticates API callers with JWTs issued by an OIDC provider, checking
+// them against the provider's published signing keys.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// keysMaxAge is how long fetched signing keys are used before they are fetched again
+	keysMaxAge = time.Hour
+	// keysMinRefresh limits refetches triggered by tokens signed with an unknown key
+	keysMinRefresh = 30 * time.Second
+	// fetchTimeout bounds discovery and key set requests
+	fetchTimeout = 10 * time.Second
+)
+
+// Claims are the verified claims of a token
+type Claims struct {
+	Subject string
+	Roles   []string
+}
+
+// Verifier checks JWTs against an OIDC provider and maps their roles to permissions
+type Verifier struct {
+	cfg    config.OIDCConfig
+	client *http.Client
+	clock  clock.Clock
+	log    *logrus.Logger
+
+	mu       sync.Mutex
+	keys     *jose.JSONWebKeySet
+	fetched  time.Time
+	fetchErr error
+
+	// fetches shares one key set fetch between concurrent callers
+	fetches singleflight.Group
+}
+
+// Option customizes a Verifier
+type Option func(*Verifier)
+
+// WithClock sets the clock tokens' exp and nbf are checked against
+func WithClock(clk clock.Clock) Option {
+	return func(v *Verifier) {
+		v.clock = clk
+	}
+}
+
+// WithHTTPClient replaces the client fetching the discovery document and signing keys
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Verifier) {
+		v.client = client
+	}
+}
+
+// NewVerifier creates a verifier for cfg. Signing keys are fetched on the first token,
+// so the exporter starts while the provider is unreachable.
+func NewVerifier(cfg config.OIDCConfig, log *logrus.Logger, opts ...Option) *Verifier {
+	v := &Verifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: fetchTimeout},
+		clock:  clock.Real,
+		log:    log,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Authenticate verifies the request's bearer token and grants the permissions of its roles
+func (v *Verifier) Authenticate(r *http.Request) (*auth.Principal, error) {
+	token, ok := auth.BearerToken(r)
+	if !ok {
+		return nil, auth.ErrNoCredentials
+	}
+	claims, err := v.Verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	principal := &auth.Principal{Subject: claims.Subject, Roles: claims.Roles, Permissions: make(map[auth.Permission]bool)}
+	for _, role := range claims.Roles {
+		for _, permission := range v.cfg.RolePermissions[role] {
+			principal.Permissions[auth.Permission(permission)] = true
+		}
+	}
+	return principal, nil
+}
+
+// signatureAlgorithms are the accepted JWS algorithms; HMAC and "none" are refused
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+}
+
+// Verify checks the token's signature, issuer, audience and validity period
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+	kid := parsed.Headers[0].KeyID
+
+	keys, err := v.signingKeys(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	var claims jwt.Claims
+	var raw map[string]any
+	if !verifyWithAny(parsed, candidateKeys(keys, kid), &claims, &raw) {
+		return nil, fmt.Errorf("%w: signature does not match key %q", auth.ErrInvalidCredentials, kid)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return &Claims{Subject: claims.Subject, Roles: roles(raw, v.cfg.RolesClaim)}, nil
+}
+
+// checkClaims verifies iss, aud, exp and nbf, tolerating the configured clock skew
+func (v *Verifier) checkClaims(claims jwt.Claims) error {
+	if claims.Expiry == nil {
+		return fmt.Errorf("%w: no expiry", auth.ErrInvalidCredentials)
+	}
+	err := claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:      v.cfg.Issuer,
+		AnyAudience: jwt.Audience{v.cfg.Audience},
+		Time:        v.clock.Now(),
+	}, time.Duration(v.cfg.Leeway))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return fmt.Errorf("%w: issued by %q", auth.ErrInvalidCredentials, claims.Issuer)
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return fmt.Errorf("%w: not issued for audience %q", auth.ErrInvalidCredentials, v.cfg.Audience)
+	case errors.Is(err, jwt.ErrExpired):
+		return fmt.Errorf("%w: expired at %s", auth.ErrInvalidCredentials, claims.Expiry.Time().UTC().Format(time.RFC3339))
+	case errors.Is(err, jwt.ErrNotValidYet):
+		return fmt.Errorf("%w: not valid before %s", auth.ErrInvalidCredentials, claims.NotBefore.Time().UTC().Format(time.RFC3339))
+	default:
+		return fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+}
+
+// candidateKeys returns the keys named kid, or every key for tokens without kid
+func candidateKeys(keys *jose.JSONWebKeySet, kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return keys.Keys
+	}
+	return keys.Key(kid)
+}
+
+// verifyWithAny decodes the token's claims into dest with the first key its signature matches
+func verifyWithAny(token *jwt.JSONWebToken, keys []jose.JSONWebKey, dest ...any) bool {
+	for _, key := range keys {
+		if token.Claims(key.Key, dest...) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// signingKeys returns the cached key set, fetching it when it is stale or lacks kid.
+// Failed fetches are retried at most every keysMinRefresh. The fetch runs outside the
+// lock and is shared by concurrent callers; a stale set that still holds kid is used
+// while it is refreshed in the background.
+func (v *Verifier) signingKeys(ctx context.Context, kid string) (*jose.JSONWebKeySet, error) {
+	v.mu.Lock()
+	keys, fetched := v.keys, v.fetched
+	v.mu.Unlock()
+
+	age := v.clock.Now().Sub(fetched)
+	known := keys != nil && len(candidateKeys(keys, kid)) > 0
+	stale := age >= keysMaxAge || (keys == nil && age >= keysMinRefresh)
+	unknown := kid != "" && !known && age >= keysMinRefresh
+	switch {
+	case stale && known:
+		go v.refresh(context.WithoutCancel(ctx))
+	case stale || unknown:
+		v.refresh(ctx)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys == nil {
+		return nil, v.fetchErr
+	}
+	return v.keys, nil
+}
+
+// refresh fetches the key set, joining a fetch already in flight. The fetch outlives
+// the caller's cancellation so callers sharing it are not failed by one leaving.
+func (v *Verifier) refresh(ctx context.Context) {
+	done := v.fetches.DoChan("keys", func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		keys, err := v.fetchKeys(ctx)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if err != nil {
+			// Keep verifying with the previous keys while the provider is unreachable
+			v.log.WithError(err).Warn("Failed to fetch OIDC signing keys")
+		} else {
+			v.keys = keys
+		}
+		v.fetched, v.fetchErr = v.clock.Now(), err
+		return nil, nil
+	})
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// roles reads the claim at path, a dotted path into nested objects. The claim may be a
+// list of strings or a space-separated string, like scope.
+func roles(raw map[string]any, path string) []string {
+	var value any = raw
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		var list []string
+		for _, item := range value {
+			if role, ok := item.(string); ok {
+				list = append(list, role)
+			}
+		}
+		return list
+	}
+	return nil
+}
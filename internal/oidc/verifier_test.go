@@ -0,0 +1,234 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/auth"
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/sirupsen/logrus"
+)
+
+// provider serves a discovery document and a key set that tests can rotate
+type provider struct {
+	server  *httptest.Server
+	keys    atomic.Value // []map[string]string
+	fetches atomic.Int32
+}
+
+func newProvider(t *testing.T) *provider {
+	p := &provider{}
+	p.keys.Store([]map[string]string{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": p.server.URL, "jwks_uri": p.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": p.keys.Load()})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *provider) publish(keys ...map[string]string) {
+	p.keys.Store(keys)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	point, _ := key.PublicKey.Bytes()
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+		"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+	}
+}
+
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier(t *testing.T) {
+	p := newProvider(t)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p.publish(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	cfg := config.OIDCConfig{
+		Issuer:          p.server.URL,
+		Audience:        "key-aws-exporter",
+		RolesClaim:      "realm_access.roles",
+		RolePermissions: map[string][]string{"operators": {"read", "validate"}, "viewers": {"read"}},
+		Leeway:          config.Duration(time.Minute),
+	}
+	v := NewVerifier(cfg, logrus.New(), WithClock(clk))
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":          p.server.URL,
+			"aud":          []string{"other", "key-aws-exporter"},
+			"sub":          "alice",
+			"exp":          now.Add(5 * time.Minute).Unix(),
+			"realm_access": map[string]any{"roles": []string{"operators", "unmapped"}},
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	principal, err := v.Authenticate(request(sign(t, "RS256", "rsa-1", rsaKey, claims(nil))))
+	if err != nil {
+		t.Fatalf("expected the RS256 token to verify: %v", err)
+	}
+	if principal.Subject != "alice" || !principal.Allows(auth.PermissionValidate) || principal.Allows(auth.PermissionAdmin) {
+		t.Fatalf("expected alice with the operators' permissions, got %+v", principal)
+	}
+	if _, err := v.Authenticate(request(sign(t, "ES256", "ec-1", ecKey, claims(map[string]any{"aud": "key-aws-exporter"})))); err != nil {
+		t.Fatalf("expected the ES256 token to verify: %v", err)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for name, token := range map[string]string{
+		"expired":        sign(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})),
+		"no expiry":      sign(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"exp": nil})),
+		"not yet valid":  sign(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"nbf": now.Add(2 * time.Minute).Unix()})),
+		"wrong issuer":   sign(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"iss": "https://evil.example"})),
+		"wrong audience": sign(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"aud": "other"})),
+		"wrong key":      sign(t, "RS256", "rsa-1", otherKey, claims(nil)),
+		"key type":       sign(t, "ES256", "rsa-1", ecKey, claims(nil)),
+		"alg none":       base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.",
+		"garbage":        "not-a-jwt",
+	} {
+		if _, err := v.Authenticate(request(token)); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%s: expected an invalid token, got %v", name, err)
+		}
+	}
+	if _, err := v.Authenticate(httptest.NewRequest(http.MethodGet, "/metrics", nil)); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Fatalf("expected a request without a token to be reported, got %v", err)
+	}
+
+	// A rotated key is fetched once its kid shows up, at most every keysMinRefresh
+	fetches := p.fetches.Load()
+	rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p.publish(rsaJWK("rsa-2", rotated))
+	token := sign(t, "RS256", "rsa-2", rotated, claims(nil))
+	if _, err := v.Authenticate(request(token)); err == nil || p.fetches.Load() != fetches {
+		t.Fatalf("expected no refetch within keysMinRefresh, got %v after %d fetches", err, p.fetches.Load()-fetches)
+	}
+	clk.Advance(keysMinRefresh)
+	if _, err := v.Authenticate(request(token)); err != nil {
+		t.Fatalf("expected the rotated key to be fetched: %v", err)
+	}
+}
+
+func TestVerifierProviderUnreachable(t *testing.T) {
+	p := newProvider(t)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	cfg := config.OIDCConfig{Issuer: p.server.URL, Audience: "aud", RolesClaim: "roles"}
+	v := NewVerifier(cfg, logrus.New())
+	p.server.Close()
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer "+sign(t, "RS256", "k", key, map[string]any{"iss": p.server.URL, "aud": "aud", "exp": time.Now().Add(time.Hour).Unix()}))
+	_, err := v.Authenticate(r)
+	if err == nil || errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("expected an unreachable provider not to reject the token as invalid, got %v", err)
+	}
+}
+
+func TestVerifierKeepsVerifyingDuringKeyFetch(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{rsaJWK("k", key)}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	clk := clock.NewFake(time.Now())
+	cfg := config.OIDCConfig{Issuer: "https://issuer.example", Audience: "aud", JWKSURL: server.URL, RolesClaim: "roles"}
+	v := NewVerifier(cfg, logrus.New(), WithClock(clk))
+	claims := map[string]any{"iss": cfg.Issuer, "aud": "aud", "exp": clk.Now().Add(time.Hour).Unix()}
+	if _, err := v.Verify(context.Background(), sign(t, "RS256", "k", key, claims)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A token with an unknown kid starts a fetch that hangs
+	clk.Advance(keysMinRefresh)
+	go v.Verify(context.Background(), sign(t, "RS256", "unknown", key, claims))
+	for fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(context.Background(), sign(t, "RS256", "k", key, claims))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a token with a cached key to verify while the key set is fetched")
+	}
+}
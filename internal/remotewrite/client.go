@@ -0,0 +1,135 @@
+// Package remotewrite pushes the exporter's series to a Prometheus remote write
+// receiver, for environments without a scraper next to the exporter.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/version"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// maxErrorBody caps how much of a rejected push's response is included in the error
+const maxErrorBody = 512
+
+// Client gathers the registered metrics and sends them as one remote write request
+type Client struct {
+	cfg      config.RemoteWriteConfig
+	external map[string]string
+	gatherer prometheus.Gatherer
+	client   *http.Client
+	clock    clock.Clock
+	log      *logrus.Logger
+}
+
+// Option customizes a Client
+type Option func(*Client)
+
+// WithGatherer replaces the default Prometheus registry as the source of series
+func WithGatherer(g prometheus.Gatherer) Option {
+	return func(c *Client) {
+		c.gatherer = g
+	}
+}
+
+// WithClock sets the clock used for sample timestamps and the push interval
+func WithClock(clk clock.Clock) Option {
+	return func(c *Client) {
+		c.clock = clk
+	}
+}
+
+// NewClient creates a client pushing to cfg.URL
+func NewClient(cfg config.RemoteWriteConfig, log *logrus.Logger, opts ...Option) *Client {
+	external := maps.Clone(cfg.ExternalLabels)
+	if external == nil {
+		external = make(map[string]string)
+	}
+	if external["job"] == "" {
+		external["job"] = version.Name
+	}
+	c := &Client{
+		cfg:      cfg,
+		external: external,
+		gatherer: prometheus.DefaultGatherer,
+		client:   &http.Client{Timeout: time.Duration(cfg.Timeout)},
+		clock:    clock.Real,
+		log:      log,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Push sends the current value of every series
+func (c *Client) Push(ctx context.Context) error {
+	families, err := c.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	series := convert(families, c.external, c.clock.Now().UnixMilli())
+	body, err := encodeWriteRequest(series)
+	if err != nil {
+		return fmt.Errorf("failed to encode write request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", version.Name+"/"+version.Version)
+	for name, value := range c.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	switch {
+	case c.cfg.BasicAuth != nil:
+		req.SetBasicAuth(c.cfg.BasicAuth.Username, c.cfg.BasicAuth.Password)
+	case c.cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.cfg.BearerToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Run pushes every interval until ctx is done. A failed push is logged and counted in
+// remote_write_failures_total; the next one sends current values, so nothing is resent.
+func (c *Client) Run(ctx context.Context) {
+	ticker := c.clock.NewTicker(time.Duration(c.cfg.Interval))
+	defer ticker.Stop()
+	for {
+		if err := c.Push(ctx); err != nil && ctx.Err() == nil {
+			metrics.RecordRemoteWriteFailure()
+			c.log.WithError(err).Warn("Remote write push failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
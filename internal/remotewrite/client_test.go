@@ -0,0 +1,113 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/sirupsen/logrus"
+)
+
+// decodeWriteRequest renders every series as name{labels} value for assertions
+func decodeWriteRequest(t *testing.T, body []byte) map[string]float64 {
+	t.Helper()
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("invalid snappy block: %v", err)
+	}
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(data); err != nil {
+		t.Fatalf("invalid write request: %v", err)
+	}
+
+	series := make(map[string]float64)
+	for _, ts := range req.Timeseries {
+		var name string
+		var labels []string
+		for _, label := range ts.Labels {
+			if label.Name == "__name__" {
+				name = label.Value
+			} else {
+				labels = append(labels, label.Name+"="+label.Value)
+			}
+		}
+		if len(ts.Samples) != 1 {
+			t.Fatalf("expected one sample per series, got %v", ts.Samples)
+		}
+		series[name+"{"+strings.Join(labels, ",")+"}"] = ts.Samples[0].Value
+	}
+	return series
+}
+
+func TestClientPush(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "s3_keys_valid", Help: "h"}, []string{"bucket"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Help: "h", Buckets: []float64{0.5}})
+	registry.MustRegister(gauge, histogram)
+	gauge.WithLabelValues("a").Set(1)
+	histogram.Observe(0.2)
+	histogram.Observe(2)
+
+	var got map[string]float64
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := io.ReadAll(r.Body)
+		got = decodeWriteRequest(t, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := config.RemoteWriteConfig{
+		URL:            server.URL,
+		BearerToken:    "token",
+		Headers:        map[string]string{"X-Scope-OrgID": "tenant"},
+		ExternalLabels: map[string]string{"cluster": "prod"},
+	}
+	client := NewClient(cfg, logrus.New(), WithGatherer(registry), WithClock(clock.NewFake(time.Unix(1700000000, 0))))
+	if err := client.Push(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header.Get("Content-Encoding") != "snappy" || header.Get("Authorization") != "Bearer token" || header.Get("X-Scope-OrgID") != "tenant" {
+		t.Fatalf("unexpected headers: %v", header)
+	}
+	want := map[string]float64{
+		"s3_keys_valid{bucket=a,cluster=prod,job=key-aws-exporter}":          1,
+		"duration_seconds_bucket{cluster=prod,job=key-aws-exporter,le=0.5}":  1,
+		"duration_seconds_bucket{cluster=prod,job=key-aws-exporter,le=+Inf}": 2,
+		"duration_seconds_sum{cluster=prod,job=key-aws-exporter}":            2.2,
+		"duration_seconds_count{cluster=prod,job=key-aws-exporter}":          2,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d series, got %v", len(want), got)
+	}
+	for series, value := range want {
+		if got[series] != value {
+			t.Fatalf("expected %s = %v, got %v (all: %v)", series, value, got[series], got)
+		}
+	}
+}
+
+func TestClientPushReportsRejections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.RemoteWriteConfig{URL: server.URL}, logrus.New(), WithGatherer(prometheus.NewRegistry()))
+	err := client.Push(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "out of order sample") {
+		t.Fatalf("expected the rejection to be reported, got %v", err)
+	}
+}
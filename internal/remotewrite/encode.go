@@ -0,0 +1,101 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// convert flattens gathered metric families into series the way a scrape would see
+// them: histograms and summaries become their _bucket, quantile, _sum and _count
+// series. External labels are added to series that do not have them.
+func convert(families []*dto.MetricFamily, external map[string]string, timestampMs int64) []prompb.TimeSeries {
+	var series []prompb.TimeSeries
+	add := func(name string, metric *dto.Metric, value float64, extra ...prompb.Label) {
+		labels := make([]prompb.Label, 0, len(metric.GetLabel())+len(extra)+len(external)+1)
+		labels = append(labels, prompb.Label{Name: "__name__", Value: name})
+		present := make(map[string]bool, len(metric.GetLabel())+len(extra))
+		for _, pair := range metric.GetLabel() {
+			labels = append(labels, prompb.Label{Name: pair.GetName(), Value: pair.GetValue()})
+			present[pair.GetName()] = true
+		}
+		for _, label := range extra {
+			labels = append(labels, label)
+			present[label.Name] = true
+		}
+		for name, value := range external {
+			if !present[name] {
+				labels = append(labels, prompb.Label{Name: name, Value: value})
+			}
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+		at := timestampMs
+		if metric.TimestampMs != nil {
+			at = metric.GetTimestampMs()
+		}
+		series = append(series, prompb.TimeSeries{Labels: labels, Samples: []prompb.Sample{{Value: value, Timestamp: at}}})
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, metric, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, metric, metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, metric, metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					add(name, metric, quantile.GetValue(), prompb.Label{Name: "quantile", Value: formatFloat(quantile.GetQuantile())})
+				}
+				add(name+"_sum", metric, summary.GetSampleSum())
+				add(name+"_count", metric, float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				// Only the classic buckets are sent; native histogram spans are dropped
+				histogram := metric.GetHistogram()
+				infSeen := false
+				for _, bucket := range histogram.GetBucket() {
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						infSeen = true
+					}
+					add(name+"_bucket", metric, float64(bucket.GetCumulativeCount()), prompb.Label{Name: "le", Value: formatFloat(bucket.GetUpperBound())})
+				}
+				if !infSeen {
+					add(name+"_bucket", metric, float64(histogram.GetSampleCount()), prompb.Label{Name: "le", Value: "+Inf"})
+				}
+				add(name+"_sum", metric, histogram.GetSampleSum())
+				add(name+"_count", metric, float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// formatFloat renders bucket bounds and quantiles like the text exposition format
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest marshals series as a remote write WriteRequest compressed with the
+// snappy block format
+func encodeWriteRequest(series []prompb.TimeSeries) ([]byte, error) {
+	data, err := (&prompb.WriteRequest{Timeseries: series}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
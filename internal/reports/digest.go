@@ -0,0 +1,144 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+)
+
+// flapThreshold is how many valid/invalid transitions mark an endpoint as flapping
+const flapThreshold = 2
+
+// agingThreshold is the share of the rotation policy after which a key counts as aging
+const agingThreshold = 0.8
+
+// HistorySource provides the configured endpoints, their results and key ages
+type HistorySource interface {
+	GetEndpoints() []string
+	History(endpointName string) []exporter.HistoryEntry
+	// QueryHistory returns exporter.ErrNoHistoryStore without a persistent store
+	QueryHistory(ctx context.Context, query exporter.HistoryQuery) ([]exporter.HistoryRecord, error)
+	KeyAge(endpointName string) (exporter.KeyAge, bool)
+}
+
+// EndpointDigest summarizes one endpoint's results within the digest period
+type EndpointDigest struct {
+	Name        string
+	Checks      int
+	Failures    int
+	Transitions int
+	Failing     bool // latest result in the period was invalid
+	LastError   string
+	LastFailure time.Time
+}
+
+// KeyAgeDigest is an access key close to or past its rotation policy
+type KeyAgeDigest struct {
+	Name        string
+	Age         time.Duration
+	MaxAge      time.Duration
+	RotationDue bool
+}
+
+// Days returns the key age in whole days
+func (k KeyAgeDigest) Days() int {
+	return int(k.Age / (24 * time.Hour))
+}
+
+// MaxDays returns the rotation policy in whole days
+func (k KeyAgeDigest) MaxDays() int {
+	return int(k.MaxAge / (24 * time.Hour))
+}
+
+// Digest summarizes credential health over a period
+type Digest struct {
+	Since     time.Time
+	Until     time.Time
+	Endpoints int
+	Healthy   int
+	Unchecked []string
+	Failed    []EndpointDigest // failed at least once but did not flap
+	Flapped   []EndpointDigest // changed state at least flapThreshold times
+	AgingKeys []KeyAgeDigest   // older than agingThreshold of their rotation policy, oldest first
+}
+
+// BuildDigest summarizes the results checked in (since, until]. With a persistent
+// history store the whole period is read from it; otherwise only results still held in
+// the history buffer (HISTORY_SIZE per endpoint) are covered.
+func BuildDigest(ctx context.Context, source HistorySource, since, until time.Time) (Digest, error) {
+	digest := Digest{Since: since, Until: until}
+
+	names := source.GetEndpoints()
+	sort.Strings(names)
+	for _, name := range names {
+		digest.Endpoints++
+
+		entries, err := periodHistory(ctx, source, name, since, until)
+		if err != nil {
+			return Digest{}, err
+		}
+
+		summary := EndpointDigest{Name: name}
+		var previous *exporter.HistoryEntry
+		for _, entry := range entries {
+			if !entry.CheckedAt.After(since) || entry.CheckedAt.After(until) {
+				continue
+			}
+			summary.Checks++
+			if previous != nil && previous.IsValid != entry.IsValid {
+				summary.Transitions++
+			}
+			if !entry.IsValid {
+				summary.Failures++
+				summary.LastFailure = entry.CheckedAt
+				summary.LastError = entry.ErrorType
+				if entry.Message != "" {
+					summary.LastError += ": " + entry.Message
+				}
+			}
+			summary.Failing = !entry.IsValid
+			entry := entry
+			previous = &entry
+		}
+
+		switch {
+		case summary.Checks == 0:
+			digest.Unchecked = append(digest.Unchecked, name)
+		case summary.Transitions >= flapThreshold:
+			digest.Flapped = append(digest.Flapped, summary)
+		case summary.Failures > 0:
+			digest.Failed = append(digest.Failed, summary)
+		default:
+			digest.Healthy++
+		}
+
+		if age, ok := source.KeyAge(name); ok && age.MaxAge > 0 && float64(age.Age) >= agingThreshold*float64(age.MaxAge) {
+			digest.AgingKeys = append(digest.AgingKeys, KeyAgeDigest{Name: name, Age: age.Age, MaxAge: age.MaxAge, RotationDue: age.RotationDue})
+		}
+	}
+	sort.SliceStable(digest.AgingKeys, func(i, j int) bool { return digest.AgingKeys[i].Age > digest.AgingKeys[j].Age })
+	return digest, nil
+}
+
+// periodHistory returns the endpoint's results around (since, until], oldest first,
+// from the persistent store when one is configured and from the history buffer otherwise
+func periodHistory(ctx context.Context, source HistorySource, name string, since, until time.Time) ([]exporter.HistoryEntry, error) {
+	// The store's range excludes its end and has millisecond resolution, so it is
+	// extended past until; BuildDigest drops what falls outside the period
+	records, err := source.QueryHistory(ctx, exporter.HistoryQuery{Endpoint: name, From: since, To: until.Add(time.Millisecond)})
+	if errors.Is(err, exporter.ErrNoHistoryStore) {
+		return source.History(name), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", name, err)
+	}
+	entries := make([]exporter.HistoryEntry, len(records))
+	for i, record := range records {
+		entries[i] = record.HistoryEntry
+	}
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package reports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/exporter"
+)
+
+type stubSource struct {
+	endpoints []string
+	history   map[string][]exporter.HistoryEntry
+	stored    map[string][]exporter.HistoryEntry // nil without a persistent store
+	keyAges   map[string]exporter.KeyAge
+}
+
+func (s *stubSource) GetEndpoints() []string {
+	return s.endpoints
+}
+
+func (s *stubSource) History(name string) []exporter.HistoryEntry {
+	return s.history[name]
+}
+
+func (s *stubSource) QueryHistory(ctx context.Context, query exporter.HistoryQuery) ([]exporter.HistoryRecord, error) {
+	if s.stored == nil {
+		return nil, exporter.ErrNoHistoryStore
+	}
+	var records []exporter.HistoryRecord
+	for _, entry := range s.stored[query.Endpoint] {
+		if !entry.CheckedAt.Before(query.From) && entry.CheckedAt.Before(query.To) {
+			records = append(records, exporter.HistoryRecord{Endpoint: query.Endpoint, HistoryEntry: entry})
+		}
+	}
+	return records, nil
+}
+
+func (s *stubSource) KeyAge(name string) (exporter.KeyAge, bool) {
+	age, ok := s.keyAges[name]
+	return age, ok
+}
+
+func entry(minute int, valid bool) exporter.HistoryEntry {
+	e := exporter.HistoryEntry{CheckedAt: time.Date(2024, 11, 6, 10, minute, 0, 0, time.UTC), IsValid: valid}
+	if !valid {
+		e.ErrorType = "access_denied"
+		e.Message = "S3 validation failed"
+	}
+	return e
+}
+
+func newStubSource() *stubSource {
+	return &stubSource{
+		endpoints: []string{"stable", "broken", "flappy", "idle", "old"},
+		history: map[string][]exporter.HistoryEntry{
+			"stable": {entry(1, true), entry(2, true)},
+			"broken": {entry(1, true), entry(2, false), entry(3, false)},
+			"flappy": {entry(1, false), entry(2, true), entry(3, false), entry(4, true)},
+			"old":    {entry(0, false)},
+		},
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	since := time.Date(2024, 11, 6, 10, 0, 0, 0, time.UTC)
+	digest, err := BuildDigest(context.Background(), newStubSource(), since, since.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if digest.Endpoints != 5 || digest.Healthy != 1 {
+		t.Fatalf("unexpected totals: %+v", digest)
+	}
+	if len(digest.Unchecked) != 2 || digest.Unchecked[0] != "idle" || digest.Unchecked[1] != "old" {
+		t.Fatalf("expected entries before the period to be ignored, got unchecked %v", digest.Unchecked)
+	}
+
+	if len(digest.Failed) != 1 {
+		t.Fatalf("expected one failed endpoint, got %+v", digest.Failed)
+	}
+	broken := digest.Failed[0]
+	if broken.Name != "broken" || broken.Failures != 2 || broken.Checks != 3 || !broken.Failing {
+		t.Fatalf("unexpected failed summary: %+v", broken)
+	}
+	if broken.LastError != "access_denied: S3 validation failed" {
+		t.Fatalf("unexpected last error %q", broken.LastError)
+	}
+
+	if len(digest.Flapped) != 1 || digest.Flapped[0].Name != "flappy" || digest.Flapped[0].Transitions != 3 || digest.Flapped[0].Failing {
+		t.Fatalf("unexpected flapping summary: %+v", digest.Flapped)
+	}
+}
+
+func TestBuildDigestReadsHistoryStore(t *testing.T) {
+	source := newStubSource()
+	// The buffer only kept the last result; the store has the whole period
+	source.history["stable"] = []exporter.HistoryEntry{entry(59, true)}
+	source.stored = map[string][]exporter.HistoryEntry{
+		"stable": {entry(1, true), entry(2, false), entry(3, true), entry(59, true)},
+	}
+
+	since := time.Date(2024, 11, 6, 10, 0, 0, 0, time.UTC)
+	digest, err := BuildDigest(context.Background(), source, since, since.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(digest.Flapped) != 1 || digest.Flapped[0].Name != "stable" || digest.Flapped[0].Checks != 4 {
+		t.Fatalf("expected the stored results to be summarized, got %+v", digest.Flapped)
+	}
+	if len(digest.Unchecked) != 4 {
+		t.Fatalf("expected endpoints without stored results to be unchecked, got %v", digest.Unchecked)
+	}
+}
+
+func TestBuildDigestListsAgingKeys(t *testing.T) {
+	day := 24 * time.Hour
+	source := newStubSource()
+	source.keyAges = map[string]exporter.KeyAge{
+		"stable": {Age: 30 * day, MaxAge: 90 * day},
+		"broken": {Age: 80 * day, MaxAge: 90 * day},
+		"flappy": {Age: 120 * day, MaxAge: 90 * day, RotationDue: true},
+		"idle":   {Age: 400 * day}, // no rotation policy
+	}
+
+	since := time.Date(2024, 11, 6, 10, 0, 0, 0, time.UTC)
+	digest, err := BuildDigest(context.Background(), source, since, since.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(digest.AgingKeys) != 2 || digest.AgingKeys[0].Name != "flappy" || digest.AgingKeys[1].Name != "broken" {
+		t.Fatalf("expected the keys past 80%% of their policy, oldest first, got %+v", digest.AgingKeys)
+	}
+	if !digest.AgingKeys[0].RotationDue || digest.AgingKeys[0].Days() != 120 || digest.AgingKeys[0].MaxDays() != 90 {
+		t.Fatalf("unexpected aging key %+v", digest.AgingKeys[0])
+	}
+}
@@ -0,0 +1,222 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/notify"
+	"key-aws-exporter/internal/schedule"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDigestSubject = "S3 credential health digest"
+	smtpTimeout          = 30 * time.Second
+)
+
+var digestTemplate = template.Must(template.New("digest").Parse(`S3 credential health from {{.Since.Format "2006-01-02 15:04 MST"}} to {{.Until.Format "2006-01-02 15:04 MST"}}
+
+{{.Endpoints}} endpoints: {{.Healthy}} healthy, {{len .Failed}} failed, {{len .Flapped}} flapping, {{len .Unchecked}} without results
+{{- if .Failed}}
+
+Failed:
+{{- range .Failed}}
+  - {{.Name}}: {{.Failures}}/{{.Checks}} checks failed{{if .Failing}}, still failing{{else}}, recovered{{end}}; last error at {{.LastFailure.Format "2006-01-02 15:04 MST"}}: {{.LastError}}
+{{- end}}
+{{- end}}
+{{- if .Flapped}}
+
+Flapping:
+{{- range .Flapped}}
+  - {{.Name}}: {{.Transitions}} state changes, {{.Failures}}/{{.Checks}} checks failed{{if .Failing}}, currently failing{{end}}; last error: {{.LastError}}
+{{- end}}
+{{- end}}
+{{- if .Unchecked}}
+
+Without results: {{range $i, $name := .Unchecked}}{{if $i}}, {{end}}{{$name}}{{end}}
+{{- end}}
+{{- if .AgingKeys}}
+
+Aging keys:
+{{- range .AgingKeys}}
+  - {{.Name}}: {{.Days}} days old, rotation policy {{.MaxDays}} days{{if .RotationDue}}, rotation due{{end}}
+{{- end}}
+{{- end}}
+`))
+
+// EmailDigest mails a credential health digest on a cron schedule
+type EmailDigest struct {
+	cfg      *config.EmailDigestConfig
+	body     *template.Template
+	schedule *schedule.Schedule
+	source   HistorySource
+	log      *logrus.Logger
+	clock    clock.Clock
+
+	lastSent time.Time
+}
+
+// NewEmailDigest creates the digest reporter; the first digest covers results since creation
+func NewEmailDigest(cfg *config.EmailDigestConfig, source HistorySource, log *logrus.Logger) (*EmailDigest, error) {
+	expr := cfg.Schedule
+	if expr == "" {
+		expr = config.DefaultDigestSchedule
+	}
+	sched, err := schedule.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	body := digestTemplate
+	if cfg.Template != "" {
+		if body, err = notify.ParseTemplate("digest", cfg.Template); err != nil {
+			return nil, fmt.Errorf("invalid digest template: %w", err)
+		}
+	}
+
+	return &EmailDigest{
+		cfg:      cfg,
+		body:     body,
+		schedule: sched,
+		source:   source,
+		log:      log,
+		clock:    clock.Real,
+		lastSent: clock.Real.Now(),
+	}, nil
+}
+
+// Run sends a digest at every scheduled time until ctx is canceled
+func (d *EmailDigest) Run(ctx context.Context) {
+	for {
+		current := d.clock.Now()
+		next := d.schedule.Next(current)
+		if next.IsZero() {
+			d.log.Error("Email digest schedule never fires")
+			return
+		}
+
+		timer := d.clock.NewTimer(next.Sub(current))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C():
+			if err := d.Send(ctx, now); err != nil {
+				d.log.WithError(err).Error("Failed to send email digest")
+				continue
+			}
+			d.log.WithField("recipients", len(d.cfg.To)).Info("Sent email digest")
+		}
+	}
+}
+
+// Send mails the digest of results since the previous digest
+func (d *EmailDigest) Send(ctx context.Context, now time.Time) error {
+	digest, err := BuildDigest(ctx, d.source, d.lastSent, now)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := d.body.Execute(&body, digest); err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	subject := d.cfg.Subject
+	if subject == "" {
+		subject = defaultDigestSubject
+	}
+	if err := sendMail(ctx, d.cfg.SMTP, d.cfg.From, d.cfg.To, subject, body.String(), now); err != nil {
+		return err
+	}
+	d.lastSent = now
+	return nil
+}
+
+// sendMail delivers a plain text message through the configured relay
+func sendMail(ctx context.Context, cfg config.SMTPConfig, from string, to []string, subject, body string, now time.Time) error {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address %q: %w", cfg.Address, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", cfg.Address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if cfg.ImplicitTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if !cfg.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return fmt.Errorf("smtp starttls failed: %w", err)
+			}
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, host)); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp recipient %s rejected: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA rejected: %w", err)
+	}
+	if _, err := w.Write(formatMessage(from, to, subject, body, now)); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected the email: %w", err)
+	}
+	return client.Quit()
+}
+
+// formatMessage builds an RFC 5322 message with CRLF line endings
+func formatMessage(from string, to []string, subject, body string, now time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
@@ -0,0 +1,229 @@
+package reports
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/sirupsen/logrus"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer accepts one session of a minimal SMTP server without extensions
+func startSMTPServer(t *testing.T) (string, <-chan receivedMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan receivedMail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var mail receivedMail
+		_ = text.PrintfLine("220 localhost ready")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 localhost")
+			case "MAIL":
+				mail.from = strings.TrimSuffix(strings.TrimPrefix(line[len("MAIL FROM:"):], "<"), ">")
+				_ = text.PrintfLine("250 ok")
+			case "RCPT":
+				mail.to = append(mail.to, strings.TrimSuffix(strings.TrimPrefix(line[len("RCPT TO:"):], "<"), ">"))
+				_ = text.PrintfLine("250 ok")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				mail.data = string(data)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- mail
+				return
+			default:
+				_ = text.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestEmailDigestSend(t *testing.T) {
+	addr, received := startSMTPServer(t)
+
+	cfg := &config.EmailDigestConfig{
+		Schedule: "@weekly",
+		From:     "exporter@example.com",
+		To:       []string{"ops@example.com", "sec@example.com"},
+		SMTP:     config.SMTPConfig{Address: addr},
+	}
+	source := newStubSource()
+	source.keyAges = map[string]exporter.KeyAge{"stable": {Age: 95 * 24 * time.Hour, MaxAge: 90 * 24 * time.Hour, RotationDue: true}}
+	digest, err := NewEmailDigest(cfg, source, logrus.New())
+	if err != nil {
+		t.Fatalf("failed to create digest: %v", err)
+	}
+	digest.lastSent = time.Date(2024, 11, 6, 10, 0, 0, 0, time.UTC)
+
+	now := time.Date(2024, 11, 6, 11, 0, 0, 0, time.UTC)
+	if err := digest.Send(context.Background(), now); err != nil {
+		t.Fatalf("failed to send digest: %v", err)
+	}
+	if !digest.lastSent.Equal(now) {
+		t.Fatalf("expected the next digest to start at %s", now)
+	}
+
+	var mail receivedMail
+	select {
+	case mail = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no mail received")
+	}
+
+	if mail.from != "exporter@example.com" || len(mail.to) != 2 {
+		t.Fatalf("unexpected envelope: %+v", mail)
+	}
+	for _, want := range []string{
+		"Subject: " + defaultDigestSubject,
+		"5 endpoints: 1 healthy, 1 failed, 1 flapping, 2 without results",
+		"broken: 2/3 checks failed, still failing",
+		"flappy: 3 state changes",
+		"Without results: idle, old",
+		"stable: 95 days old, rotation policy 90 days, rotation due",
+	} {
+		if !strings.Contains(mail.data, want) {
+			t.Fatalf("expected %q in mail:\n%s", want, mail.data)
+		}
+	}
+}
+
+func TestEmailDigestRunFollowsSchedule(t *testing.T) {
+	addr, received := startSMTPServer(t)
+
+	cfg := &config.EmailDigestConfig{
+		Schedule: "0 9 * * *",
+		From:     "exporter@example.com",
+		To:       []string{"ops@example.com"},
+		SMTP:     config.SMTPConfig{Address: addr},
+	}
+	digest, err := NewEmailDigest(cfg, newStubSource(), logrus.New())
+	if err != nil {
+		t.Fatalf("failed to create digest: %v", err)
+	}
+	start := time.Date(2024, 11, 6, 8, 0, 0, 0, time.Local)
+	clk := clock.NewFake(start)
+	digest.clock = clk
+	digest.lastSent = start
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go digest.Run(ctx)
+
+	clk.BlockUntil(1)
+	clk.Advance(59 * time.Minute)
+	if clk.Waiters() != 1 {
+		t.Fatalf("expected the digest to still wait for 09:00")
+	}
+
+	clk.Advance(time.Minute)
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no digest sent at 09:00")
+	}
+}
+
+func TestEmailDigestTemplate(t *testing.T) {
+	addr, received := startSMTPServer(t)
+
+	cfg := &config.EmailDigestConfig{
+		From:     "exporter@example.com",
+		To:       []string{"ops@example.com"},
+		Template: "{{range .Failed}}FAILED {{.Name}}\n{{end}}{{range .Flapped}}FLAPPED {{.Name}}\n{{end}}",
+		SMTP:     config.SMTPConfig{Address: addr},
+	}
+	digest, err := NewEmailDigest(cfg, newStubSource(), logrus.New())
+	if err != nil {
+		t.Fatalf("failed to create digest: %v", err)
+	}
+	digest.lastSent = time.Date(2024, 11, 6, 10, 0, 0, 0, time.UTC)
+
+	if err := digest.Send(context.Background(), time.Date(2024, 11, 6, 11, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("failed to send digest: %v", err)
+	}
+	mail := <-received
+	if !strings.HasSuffix(mail.data, "\n\nFAILED broken\nFLAPPED flappy\n") {
+		t.Fatalf("expected the templated body, got:\n%s", mail.data)
+	}
+
+	cfg.Template = "{{.Failed"
+	if _, err := NewEmailDigest(cfg, newStubSource(), logrus.New()); err == nil {
+		t.Fatalf("expected error for an invalid template")
+	}
+}
+
+func TestEmailDigestSendFailureKeepsPeriod(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := &config.EmailDigestConfig{
+		From: "exporter@example.com",
+		To:   []string{"ops@example.com"},
+		SMTP: config.SMTPConfig{Address: addr},
+	}
+	digest, err := NewEmailDigest(cfg, newStubSource(), logrus.New())
+	if err != nil {
+		t.Fatalf("failed to create digest: %v", err)
+	}
+	start := digest.lastSent
+
+	if err := digest.Send(context.Background(), time.Now()); err == nil {
+		t.Fatalf("expected an error without an SMTP server")
+	}
+	if !digest.lastSent.Equal(start) {
+		t.Fatalf("expected a failed digest to keep its period for the next attempt")
+	}
+}
+
+func TestFormatMessageUsesCRLF(t *testing.T) {
+	msg := string(formatMessage("a@example.com", []string{"b@example.com"}, "hi", "line1\nline2\n", time.Unix(0, 0)))
+	if strings.Count(msg, "\n") != strings.Count(msg, "\r\n") {
+		t.Fatalf("expected CRLF line endings only:\n%q", msg)
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\nline1\r\nline2\r\n") {
+		t.Fatalf("expected the body after a blank line:\n%q", msg)
+	}
+}
@@ -0,0 +1,361 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rotationTimeout bounds a whole rotation including the propagation wait
+	rotationTimeout = 10 * time.Minute
+	// retryInterval spaces out attempts for an endpoint whose rotation failed
+	retryInterval = time.Hour
+	// propagationTimeout is how long a new key may take to work after IAM created it
+	propagationTimeout = 2 * time.Minute
+	// propagationInterval is the pause between validations of a new key
+	propagationInterval = 5 * time.Second
+)
+
+// EndpointManager is the subset of the validator manager the controller drives
+type EndpointManager interface {
+	AddEndpoint(endpointCfg config.S3EndpointConfig)
+	ValidateEndpoint(ctx context.Context, endpointName string) *s3.ValidationResult
+}
+
+// rotatedEndpoint is an endpoint opted into rotation together with its current key
+type rotatedEndpoint struct {
+	cfg         config.S3EndpointConfig
+	store       Store
+	running     bool
+	lastAttempt time.Time
+	// pending is the replaced key awaiting deactivation, if any
+	pending *pendingDeactivation
+}
+
+// pendingDeactivation is a replaced key that stays active until due, giving the
+// workloads reading the secret store time to pick up the new one
+type pendingDeactivation struct {
+	keyID string
+	due   time.Time
+}
+
+// Controller is a result sink that rotates IAM access keys once they are older than the
+// rotation policy: it creates a new key, validates it in the secondary slot, writes it to
+// the secret store and switches the endpoint to it. The old key is deactivated by a
+// later validation run once the rotation's deactivate_after has passed.
+type Controller struct {
+	manager  EndpointManager
+	log      *logrus.Logger
+	clock    clock.Clock
+	readOnly bool
+
+	propagationTimeout  time.Duration
+	propagationInterval time.Duration
+
+	mu        sync.Mutex
+	endpoints map[string]*rotatedEndpoint
+
+	wg sync.WaitGroup
+}
+
+// NewController sets up rotation for the endpoints that opted in. With readOnly every
+// rotation fails before touching IAM.
+func NewController(endpoints []config.S3EndpointConfig, manager EndpointManager, readOnly bool, log *logrus.Logger) (*Controller, error) {
+	c := &Controller{
+		manager:             manager,
+		log:                 log,
+		readOnly:            readOnly,
+		clock:               clock.Real,
+		propagationTimeout:  propagationTimeout,
+		propagationInterval: propagationInterval,
+		endpoints:           make(map[string]*rotatedEndpoint),
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Rotation == nil {
+			continue
+		}
+		store, err := NewStore(endpoint.Rotation.Store)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", endpoint.Name, err)
+		}
+		c.endpoints[endpoint.Name] = &rotatedEndpoint{cfg: endpoint, store: store}
+	}
+	return c, nil
+}
+
+// Endpoints returns how many endpoints are opted into rotation
+func (c *Controller) Endpoints() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.endpoints)
+}
+
+// Consume starts a background rotation for every opted-in endpoint whose key is due and
+// deactivates the replaced keys whose deactivate_after has passed
+func (c *Controller) Consume(results *exporter.ValidationResults) {
+	c.deactivateDue()
+	for name, age := range results.KeyAges {
+		if !age.RotationDue {
+			continue
+		}
+
+		c.mu.Lock()
+		ep, ok := c.endpoints[name]
+		start := ok && !ep.running && ep.pending == nil && (ep.lastAttempt.IsZero() || c.clock.Now().Sub(ep.lastAttempt) >= retryInterval)
+		if start {
+			ep.running = true
+			ep.lastAttempt = c.clock.Now()
+		}
+		c.mu.Unlock()
+		if !start {
+			continue
+		}
+
+		c.wg.Add(1)
+		go func(name string, age exporter.KeyAge) {
+			defer c.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
+			defer cancel()
+
+			entry := c.log.WithFields(logrus.Fields{"endpoint": name, "key_age": age.Age.Round(time.Second).String()})
+			entry.Info("Rotating S3 access key")
+			err := c.Rotate(ctx, name)
+			metrics.RecordKeyRotation(name, err == nil)
+			if err != nil {
+				entry.WithError(err).Error("S3 access key rotation failed")
+			} else {
+				entry.Info("S3 access key rotated")
+			}
+
+			c.mu.Lock()
+			ep.running = false
+			c.mu.Unlock()
+		}(name, age)
+	}
+}
+
+// Rotate replaces the endpoint's access key and schedules the old key's deactivation.
+// Until the new key is stored, any failure restores the old key in the exporter and
+// deletes the new key from IAM.
+func (c *Controller) Rotate(ctx context.Context, name string) error {
+	c.mu.Lock()
+	ep, ok := c.endpoints[name]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("endpoint %q is not configured for rotation", name)
+	}
+	if c.readOnly {
+		return fmt.Errorf("key rotation creates and deactivates IAM keys and is disabled by READ_ONLY")
+	}
+	cfg := ep.cfg
+
+	oldKey := AccessKey{ID: cfg.AccessKey, Secret: cfg.SecretKey}
+	oldIAM := newIAMClient(cfg, oldKey)
+
+	if err := c.freeKeySlot(ctx, oldIAM, oldKey.ID, cfg.Rotation.DeleteInactiveKeys); err != nil {
+		return err
+	}
+
+	newKey, err := oldIAM.CreateAccessKey(ctx)
+	if err != nil {
+		return err
+	}
+	rollback := func(cause error) error {
+		c.manager.AddEndpoint(cfg)
+		if err := oldIAM.DeleteAccessKey(ctx, newKey.ID); err != nil {
+			return fmt.Errorf("%w; deleting new key %s also failed: %v", cause, newKey.ID, err)
+		}
+		return cause
+	}
+
+	staged := cfg
+	staged.Secondary = &config.Credentials{AccessKey: newKey.ID, SecretKey: newKey.Secret}
+	c.manager.AddEndpoint(staged)
+	if err := c.awaitSecondary(ctx, name); err != nil {
+		return rollback(err)
+	}
+
+	if err := ep.store.Put(ctx, newKey); err != nil {
+		return rollback(fmt.Errorf("failed to update secret store: %w", err))
+	}
+
+	rotated := cfg
+	rotated.AccessKey = newKey.ID
+	rotated.SecretKey = newKey.Secret
+	c.manager.AddEndpoint(rotated)
+	c.mu.Lock()
+	ep.cfg = rotated
+	ep.pending = &pendingDeactivation{keyID: oldKey.ID, due: c.clock.Now().Add(time.Duration(cfg.Rotation.DeactivateAfter))}
+	c.mu.Unlock()
+	return nil
+}
+
+// Resume schedules the deactivation of replaced keys left active by an earlier run,
+// which only kept them in memory: any other active key of an endpoint's IAM user gets
+// a fresh deactivate_after. Without it a restart between a rotation and its
+// deactivation would leave the old key active and block every later rotation.
+func (c *Controller) Resume(ctx context.Context) error {
+	c.mu.Lock()
+	endpoints := make(map[string]config.S3EndpointConfig, len(c.endpoints))
+	for name, ep := range c.endpoints {
+		if ep.pending == nil {
+			endpoints[name] = ep.cfg
+		}
+	}
+	c.mu.Unlock()
+	if c.readOnly {
+		return nil
+	}
+
+	var errs []error
+	for name, cfg := range endpoints {
+		keys, err := newIAMClient(cfg, AccessKey{ID: cfg.AccessKey, Secret: cfg.SecretKey}).ListAccessKeys(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %q: %w", name, err))
+			continue
+		}
+		for _, key := range keys {
+			id := aws.ToString(key.AccessKeyId)
+			if id == cfg.AccessKey || key.Status != types.StatusTypeActive {
+				continue
+			}
+			c.mu.Lock()
+			c.endpoints[name].pending = &pendingDeactivation{keyID: id, due: c.clock.Now().Add(time.Duration(cfg.Rotation.DeactivateAfter))}
+			c.mu.Unlock()
+			c.log.WithFields(logrus.Fields{"endpoint": name, "access_key_id": id}).Info("Scheduled deactivation of an access key left active by an earlier rotation")
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deactivateDue starts a background deactivation for every replaced key that is due
+func (c *Controller) deactivateDue() {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, ep := range c.endpoints {
+		if ep.running || ep.pending == nil || now.Before(ep.pending.due) {
+			continue
+		}
+		ep.running = true
+
+		c.wg.Add(1)
+		go func(name string, ep *rotatedEndpoint) {
+			defer c.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
+			defer cancel()
+
+			entry := c.log.WithField("endpoint", name)
+			if err := c.Deactivate(ctx, name); err != nil {
+				entry.WithError(err).Error("Failed to deactivate the replaced S3 access key; retrying on the next validation")
+			} else {
+				entry.Info("Replaced S3 access key deactivated")
+			}
+
+			c.mu.Lock()
+			ep.running = false
+			c.mu.Unlock()
+		}(name, ep)
+	}
+}
+
+// Deactivate deactivates the key the endpoint's last rotation replaced, using the new
+// key, and validates the endpoint again so a workload still on the old key shows up
+func (c *Controller) Deactivate(ctx context.Context, name string) error {
+	c.mu.Lock()
+	ep, ok := c.endpoints[name]
+	var cfg config.S3EndpointConfig
+	var pending *pendingDeactivation
+	if ok {
+		cfg, pending = ep.cfg, ep.pending
+	}
+	c.mu.Unlock()
+	if pending == nil {
+		return fmt.Errorf("endpoint %q has no key awaiting deactivation", name)
+	}
+
+	newKey := AccessKey{ID: cfg.AccessKey, Secret: cfg.SecretKey}
+	if err := newIAMClient(cfg, newKey).DeactivateAccessKey(ctx, pending.keyID); err != nil {
+		return fmt.Errorf("switched to key %s but failed to deactivate %s: %w", newKey.ID, pending.keyID, err)
+	}
+
+	c.mu.Lock()
+	ep.pending = nil
+	c.mu.Unlock()
+	c.manager.ValidateEndpoint(ctx, name)
+	return nil
+}
+
+// freeKeySlot makes sure IAM accepts another key; users are limited to two
+func (c *Controller) freeKeySlot(ctx context.Context, iam *iamClient, currentID string, deleteInactive bool) error {
+	keys, err := iam.ListAccessKeys(ctx)
+	if err != nil {
+		return err
+	}
+	remaining := len(keys)
+	for _, key := range keys {
+		if remaining < 2 {
+			break
+		}
+		id := aws.ToString(key.AccessKeyId)
+		if !deleteInactive || id == currentID || key.Status != types.StatusTypeInactive {
+			continue
+		}
+		if err := iam.DeleteAccessKey(ctx, id); err != nil {
+			return err
+		}
+		remaining--
+	}
+	if remaining >= 2 {
+		return fmt.Errorf("IAM user already has two access keys; delete the unused one or enable delete_inactive_keys")
+	}
+	return nil
+}
+
+// awaitSecondary validates until the new key in the secondary slot works. IAM keys are
+// eventually consistent, so the first attempts may be rejected.
+func (c *Controller) awaitSecondary(ctx context.Context, name string) error {
+	deadline := c.clock.NewTimer(c.propagationTimeout)
+	defer deadline.Stop()
+
+	for {
+		result := c.manager.ValidateEndpoint(ctx, name)
+		if result.Secondary != nil && result.Secondary.IsValid {
+			return nil
+		}
+
+		message := "no secondary result"
+		if result.Secondary != nil {
+			message = result.Secondary.Message
+		}
+		pause := c.clock.NewTimer(c.propagationInterval)
+		select {
+		case <-ctx.Done():
+			pause.Stop()
+			return fmt.Errorf("new key did not validate: %w", ctx.Err())
+		case <-deadline.C():
+			pause.Stop()
+			return fmt.Errorf("new key did not validate within %s: %s", c.propagationTimeout, message)
+		case <-pause.C():
+		}
+	}
+}
+
+// Wait blocks until every running rotation has finished
+func (c *Controller) Wait() {
+	c.wg.Wait()
+}
@@ -0,0 +1,294 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// stubManager validates keys against the fake IAM user
+type stubManager struct {
+	iam *fakeIAM
+
+	mu          sync.Mutex
+	current     config.S3EndpointConfig
+	added       []config.S3EndpointConfig
+	validations int
+}
+
+func (m *stubManager) AddEndpoint(cfg config.S3EndpointConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = cfg
+	m.added = append(m.added, cfg)
+}
+
+func (m *stubManager) ValidateEndpoint(ctx context.Context, name string) *s3.ValidationResult {
+	m.mu.Lock()
+	cfg := m.current
+	m.validations++
+	m.mu.Unlock()
+
+	result := &s3.ValidationResult{IsValid: m.iam.active(cfg.AccessKey), CheckedAt: time.Now()}
+	if cfg.Secondary != nil {
+		result.Secondary = &s3.ValidationResult{IsValid: m.iam.active(cfg.Secondary.AccessKey), Message: "InvalidAccessKeyId"}
+	}
+	return result
+}
+
+type fakeStore struct {
+	stored []AccessKey
+	err    error
+}
+
+func (s *fakeStore) Put(ctx context.Context, key AccessKey) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.stored = append(s.stored, key)
+	return nil
+}
+
+func (s *fakeStore) Get(ctx context.Context) (AccessKey, error) {
+	if len(s.stored) == 0 {
+		return AccessKey{}, s.err
+	}
+	return s.stored[len(s.stored)-1], s.err
+}
+
+func newTestController(t *testing.T, iam *fakeIAM, store Store) (*Controller, *stubManager) {
+	t.Helper()
+	server := httptest.NewServer(iam)
+	t.Cleanup(server.Close)
+
+	endpoint := config.S3EndpointConfig{
+		Name:          "backups",
+		AccessKey:     "AKIAOLD",
+		SecretKey:     "old",
+		KeyAgeFromIAM: true,
+		IAMEndpoint:   server.URL,
+		Rotation:      &config.RotationConfig{},
+	}
+	manager := &stubManager{iam: iam, current: endpoint}
+	log, _ := test.NewNullLogger()
+	c := &Controller{
+		manager:             manager,
+		log:                 log,
+		clock:               clock.Real,
+		propagationTimeout:  50 * time.Millisecond,
+		propagationInterval: 5 * time.Millisecond,
+		endpoints:           map[string]*rotatedEndpoint{"backups": {cfg: endpoint, store: store}},
+	}
+	return c, manager
+}
+
+func TestControllerRotatesKey(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	store := &fakeStore{}
+	c, manager := newTestController(t, iam, store)
+
+	if err := c.Rotate(context.Background(), "backups"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(store.stored) != 1 || store.stored[0].ID != "AKIANEW1" {
+		t.Fatalf("expected the new key to be stored, got %+v", store.stored)
+	}
+	if manager.current.AccessKey != "AKIANEW1" || manager.current.Secondary != nil {
+		t.Fatalf("expected the endpoint to switch to the new key, got %+v", manager.current)
+	}
+	if staged := manager.added[0]; staged.AccessKey != "AKIAOLD" || staged.Secondary == nil || staged.Secondary.AccessKey != "AKIANEW1" {
+		t.Fatalf("expected the new key to be validated in the secondary slot first, got %+v", staged)
+	}
+	if !iam.active("AKIAOLD") {
+		t.Fatalf("expected the old key to stay active until the next validation run")
+	}
+	if c.endpoints["backups"].cfg.AccessKey != "AKIANEW1" {
+		t.Fatalf("expected the controller to track the new key")
+	}
+
+	c.Consume(&exporter.ValidationResults{})
+	c.Wait()
+	if iam.active("AKIAOLD") || c.endpoints["backups"].pending != nil {
+		t.Fatalf("expected the old key to be deactivated")
+	}
+}
+
+func TestControllerDeactivatesOldKeyAfterDelay(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	c, manager := newTestController(t, iam, &fakeStore{})
+	clk := clock.NewFake(time.Now())
+	c.clock = clk
+	c.endpoints["backups"].cfg.Rotation.DeactivateAfter = config.Duration(time.Hour)
+
+	if err := c.Rotate(context.Background(), "backups"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	due := &exporter.ValidationResults{KeyAges: map[string]exporter.KeyAge{"backups": {RotationDue: true}}}
+	c.Consume(due)
+	c.Wait()
+	if !iam.active("AKIAOLD") || len(iam.keys) != 2 {
+		t.Fatalf("expected the old key active and no further rotation before deactivate_after, got %v", iam.keys)
+	}
+
+	clk.Advance(time.Hour)
+	manager.mu.Lock()
+	validations := manager.validations
+	manager.mu.Unlock()
+	c.Consume(&exporter.ValidationResults{})
+	c.Wait()
+	if iam.active("AKIAOLD") {
+		t.Fatalf("expected the old key to be deactivated once deactivate_after passed")
+	}
+	if manager.validations != validations+1 {
+		t.Fatalf("expected the endpoint to be validated after the deactivation")
+	}
+}
+
+func TestControllerRollsBackWhenStoreFails(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	c, manager := newTestController(t, iam, &fakeStore{err: errors.New("forbidden")})
+
+	err := c.Rotate(context.Background(), "backups")
+	if err == nil || !strings.Contains(err.Error(), "secret store") {
+		t.Fatalf("expected a store error, got %v", err)
+	}
+	if manager.current.AccessKey != "AKIAOLD" || manager.current.Secondary != nil {
+		t.Fatalf("expected the old key to be restored, got %+v", manager.current)
+	}
+	if _, exists := iam.keys["AKIANEW1"]; exists || !iam.active("AKIAOLD") {
+		t.Fatalf("expected the new key deleted and the old one active, got %v", iam.keys)
+	}
+}
+
+func TestControllerRollsBackWhenNewKeyNeverWorks(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	store := &fakeStore{}
+	c, manager := newTestController(t, iam, store)
+	manager.iam = newFakeIAM(AccessKey{ID: "AKIAOLD"}) // validation never sees the new key
+	clk := clock.NewFake(time.Now())
+	c.clock = clk
+
+	done := make(chan error, 1)
+	go func() { done <- c.Rotate(context.Background(), "backups") }()
+	clk.BlockUntil(2) // propagation deadline and the pause before the next attempt
+	clk.Advance(c.propagationTimeout)
+
+	err := <-done
+	if err == nil || !strings.Contains(err.Error(), "did not validate") {
+		t.Fatalf("expected a propagation error, got %v", err)
+	}
+	if len(store.stored) != 0 || manager.current.AccessKey != "AKIAOLD" {
+		t.Fatalf("expected nothing stored and the old key kept")
+	}
+}
+
+func TestControllerNeedsFreeKeySlot(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"}, AccessKey{ID: "AKIASPARE"})
+	iam.keys["AKIASPARE"] = "Inactive"
+	c, _ := newTestController(t, iam, &fakeStore{})
+
+	if err := c.Rotate(context.Background(), "backups"); err == nil || !strings.Contains(err.Error(), "two access keys") {
+		t.Fatalf("expected the key limit error, got %v", err)
+	}
+
+	c.endpoints["backups"].cfg.Rotation.DeleteInactiveKeys = true
+	if err := c.Rotate(context.Background(), "backups"); err != nil {
+		t.Fatalf("expected the inactive key to be deleted first, got %v", err)
+	}
+	if _, exists := iam.keys["AKIASPARE"]; exists {
+		t.Fatalf("expected the inactive key to be deleted")
+	}
+}
+
+func TestControllerResumesLeftoverDeactivation(t *testing.T) {
+	// A restart after the rotation to AKIANEW lost the in-memory pending deactivation
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"}, AccessKey{ID: "AKIANEW", Secret: "new"})
+	c, _ := newTestController(t, iam, &fakeStore{})
+	clk := clock.NewFake(time.Now())
+	c.clock = clk
+	ep := c.endpoints["backups"]
+	ep.cfg.AccessKey, ep.cfg.SecretKey = "AKIANEW", "new"
+	ep.cfg.Rotation.DeactivateAfter = config.Duration(time.Hour)
+
+	if err := c.Resume(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ep.pending == nil || ep.pending.keyID != "AKIAOLD" {
+		t.Fatalf("expected the old key to await deactivation, got %+v", ep.pending)
+	}
+
+	c.Consume(&exporter.ValidationResults{})
+	c.Wait()
+	if !iam.active("AKIAOLD") {
+		t.Fatalf("expected the old key to stay active for deactivate_after")
+	}
+	clk.Advance(time.Hour)
+	c.Consume(&exporter.ValidationResults{})
+	c.Wait()
+	if iam.active("AKIAOLD") || !iam.active("AKIANEW") {
+		t.Fatalf("expected only the old key to be deactivated, got %v", iam.keys)
+	}
+}
+
+func TestControllerConsumeStartsDueRotationsOnce(t *testing.T) {
+	metrics.KeyRotations.Reset()
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	c, _ := newTestController(t, iam, &fakeStore{})
+
+	due := &exporter.ValidationResults{KeyAges: map[string]exporter.KeyAge{
+		"backups": {Age: 100 * 24 * time.Hour, MaxAge: 90 * 24 * time.Hour, RotationDue: true},
+		"other":   {RotationDue: true},
+	}}
+	c.Consume(&exporter.ValidationResults{KeyAges: map[string]exporter.KeyAge{"backups": {RotationDue: false}}})
+	c.Consume(due)
+	c.Wait()
+	c.Consume(due)
+	c.Wait()
+
+	if got := testutil.ToFloat64(metrics.KeyRotations.WithLabelValues("backups", "success")); got != 1 {
+		t.Fatalf("expected exactly one successful rotation, got %v", got)
+	}
+}
+
+func TestNewControllerSkipsEndpointsWithoutRotation(t *testing.T) {
+	c, err := NewController([]config.S3EndpointConfig{
+		{Name: "plain"},
+		{Name: "rotated", Rotation: &config.RotationConfig{Store: config.SecretStoreConfig{Type: config.StoreSecretsManager, SecretID: "s", Region: "us-east-1"}}},
+	}, &stubManager{}, false, logrus.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Endpoints() != 1 {
+		t.Fatalf("expected one rotated endpoint, got %d", c.Endpoints())
+	}
+}
+
+func TestControllerRefusesRotationWhenReadOnly(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	store := &fakeStore{}
+	c, manager := newTestController(t, iam, store)
+	c.readOnly = true
+
+	err := c.Rotate(context.Background(), "backups")
+	if err == nil || !strings.Contains(err.Error(), "READ_ONLY") {
+		t.Fatalf("expected a READ_ONLY error, got %v", err)
+	}
+	if len(iam.keys) != 1 || len(store.stored) != 0 || len(manager.added) != 0 {
+		t.Fatalf("expected nothing to change, got keys %v", iam.keys)
+	}
+}
@@ -0,0 +1,89 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// iamSigningRegion is the region IAM requests are signed for when the endpoint has none
+const iamSigningRegion = "us-east-1"
+
+// AccessKey is an IAM access key pair
+type AccessKey struct {
+	ID     string
+	Secret string
+}
+
+// iamClient manages the keys of the IAM user owning the signing key, so the key only
+// needs permissions on its own user
+type iamClient struct {
+	api *iam.Client
+}
+
+// newIAMClient builds an IAM client signing with key. Without an iam_endpoint the SDK
+// resolves the partition's global endpoint from the endpoint's region.
+func newIAMClient(endpoint config.S3EndpointConfig, key AccessKey) *iamClient {
+	region := endpoint.Region
+	if region == "" {
+		region = iamSigningRegion
+	}
+	return &iamClient{api: iam.NewFromConfig(aws.Config{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(key.ID, key.Secret, ""),
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+	}, func(o *iam.Options) {
+		if endpoint.IAMEndpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint.IAMEndpoint)
+		}
+	})}
+}
+
+// ListAccessKeys lists the keys of the calling user
+func (c *iamClient) ListAccessKeys(ctx context.Context) ([]types.AccessKeyMetadata, error) {
+	var keys []types.AccessKeyMetadata
+	paginator := iam.NewListAccessKeysPaginator(c.api, &iam.ListAccessKeysInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page.AccessKeyMetadata...)
+	}
+	return keys, nil
+}
+
+// CreateAccessKey issues a new key for the calling user
+func (c *iamClient) CreateAccessKey(ctx context.Context) (AccessKey, error) {
+	out, err := c.api.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{})
+	if err != nil {
+		return AccessKey{}, err
+	}
+	if out.AccessKey == nil || aws.ToString(out.AccessKey.AccessKeyId) == "" || aws.ToString(out.AccessKey.SecretAccessKey) == "" {
+		return AccessKey{}, fmt.Errorf("IAM CreateAccessKey returned no key")
+	}
+	return AccessKey{ID: aws.ToString(out.AccessKey.AccessKeyId), Secret: aws.ToString(out.AccessKey.SecretAccessKey)}, nil
+}
+
+// DeactivateAccessKey marks a key of the calling user as inactive
+func (c *iamClient) DeactivateAccessKey(ctx context.Context, id string) error {
+	_, err := c.api.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+		AccessKeyId: aws.String(id),
+		Status:      types.StatusTypeInactive,
+	})
+	return err
+}
+
+// DeleteAccessKey deletes a key of the calling user
+func (c *iamClient) DeleteAccessKey(ctx context.Context, id string) error {
+	_, err := c.api.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{AccessKeyId: aws.String(id)})
+	return err
+}
@@ -0,0 +1,120 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// fakeIAM is an in-memory IAM user serving the query API actions used by rotation
+type fakeIAM struct {
+	mu      sync.Mutex
+	keys    map[string]string // access key ID -> status
+	secrets map[string]string // access key ID -> secret
+	created int
+	calls   []string
+	fail    map[string]bool // actions answering with an error
+}
+
+func newFakeIAM(keys ...AccessKey) *fakeIAM {
+	f := &fakeIAM{keys: make(map[string]string), secrets: make(map[string]string), fail: make(map[string]bool)}
+	for _, key := range keys {
+		f.keys[key.ID] = "Active"
+		f.secrets[key.ID] = key.Secret
+	}
+	return f
+}
+
+func (f *fakeIAM) active(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[id] == "Active"
+}
+
+func (f *fakeIAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	action := r.PostForm.Get("Action")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, action)
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if f.fail[action] {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>not allowed to %s</Message></Error></ErrorResponse>`, action)
+		return
+	}
+
+	id := r.PostForm.Get("AccessKeyId")
+	switch action {
+	case "ListAccessKeys":
+		var members strings.Builder
+		for key, status := range f.keys {
+			fmt.Fprintf(&members, `<member><AccessKeyId>%s</AccessKeyId><Status>%s</Status></member>`, key, status)
+		}
+		fmt.Fprintf(w, `<ListAccessKeysResponse><ListAccessKeysResult><AccessKeyMetadata>%s</AccessKeyMetadata></ListAccessKeysResult></ListAccessKeysResponse>`, members.String())
+	case "CreateAccessKey":
+		f.created++
+		key := AccessKey{ID: fmt.Sprintf("AKIANEW%d", f.created), Secret: fmt.Sprintf("secret-%d", f.created)}
+		f.keys[key.ID] = "Active"
+		f.secrets[key.ID] = key.Secret
+		fmt.Fprintf(w, `<CreateAccessKeyResponse><CreateAccessKeyResult><AccessKey><AccessKeyId>%s</AccessKeyId><SecretAccessKey>%s</SecretAccessKey><Status>Active</Status></AccessKey></CreateAccessKeyResult></CreateAccessKeyResponse>`, key.ID, key.Secret)
+	case "UpdateAccessKey":
+		f.keys[id] = r.PostForm.Get("Status")
+		fmt.Fprint(w, `<UpdateAccessKeyResponse/>`)
+	case "DeleteAccessKey":
+		delete(f.keys, id)
+		fmt.Fprint(w, `<DeleteAccessKeyResponse/>`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestIAMClientActions(t *testing.T) {
+	iam := newFakeIAM(AccessKey{ID: "AKIAOLD", Secret: "old"})
+	server := httptest.NewServer(iam)
+	defer server.Close()
+
+	client := newIAMClient(config.S3EndpointConfig{IAMEndpoint: server.URL}, AccessKey{ID: "AKIAOLD", Secret: "old"})
+	ctx := context.Background()
+
+	key, err := client.CreateAccessKey(ctx)
+	if err != nil || key.ID != "AKIANEW1" || key.Secret != "secret-1" {
+		t.Fatalf("unexpected created key %+v (%v)", key, err)
+	}
+
+	if err := client.DeactivateAccessKey(ctx, "AKIAOLD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys, err := client.ListAccessKeys(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %+v (%v)", keys, err)
+	}
+	for _, k := range keys {
+		if aws.ToString(k.AccessKeyId) == "AKIAOLD" && k.Status != types.StatusTypeInactive {
+			t.Fatalf("expected the old key to be inactive, got %q", k.Status)
+		}
+	}
+
+	if err := client.DeleteAccessKey(ctx, "AKIAOLD"); err != nil || iam.active("AKIAOLD") {
+		t.Fatalf("expected the old key to be deleted (%v)", err)
+	}
+
+	iam.fail["CreateAccessKey"] = true
+	if _, err := client.CreateAccessKey(ctx); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("expected the IAM error code, got %v", err)
+	}
+}
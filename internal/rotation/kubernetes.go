@@ -0,0 +1,139 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"key-aws-exporter/internal/config"
+)
+
+// serviceAccountDir holds the in-cluster token, CA bundle and namespace
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Kubernetes reads and writes rotated keys in a Secret through the API server using the
+// pod's service account, which needs get and patch on the Secret
+type Kubernetes struct {
+	cfg       config.SecretStoreConfig
+	apiURL    string
+	namespace string
+	tokenFile string
+	client    *http.Client
+}
+
+// NewKubernetes creates a Kubernetes Secret store using the in-cluster configuration
+func NewKubernetes(cfg config.SecretStoreConfig) (*Kubernetes, error) {
+	k := &Kubernetes{
+		cfg:       cfg,
+		apiURL:    strings.TrimRight(cfg.APIURL, "/"),
+		namespace: cfg.Namespace,
+		tokenFile: serviceAccountDir + "/token",
+	}
+
+	if k.apiURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("not running in a Kubernetes cluster; set rotation.store.api_url")
+		}
+		k.apiURL = "https://" + net.JoinHostPort(host, port)
+	}
+
+	if k.namespace == "" {
+		namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("rotation.store.namespace is required outside a pod: %w", err)
+		}
+		k.namespace = strings.TrimSpace(string(namespace))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ca, err := os.ReadFile(serviceAccountDir + "/ca.crt"); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	k.client = &http.Client{Transport: transport, Timeout: 30 * time.Second}
+	return k, nil
+}
+
+// Put patches the key pair into the Secret, keeping its other keys
+func (k *Kubernetes) Put(ctx context.Context, key AccessKey) error {
+	patch, err := json.Marshal(map[string]any{
+		"stringData": map[string]string{
+			k.cfg.AccessKeyField: key.ID,
+			k.cfg.SecretKeyField: key.Secret,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, k.secretURL(), bytes.NewReader(patch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	k.authorize(req)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to patch secret %s/%s: %w", k.namespace, k.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("patching secret %s/%s returned %d: %s", k.namespace, k.cfg.Name, resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Get reads the key pair from the Secret's data
+func (k *Kubernetes) Get(ctx context.Context) (AccessKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.secretURL(), nil)
+	if err != nil {
+		return AccessKey{}, err
+	}
+	k.authorize(req)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return AccessKey{}, fmt.Errorf("failed to read secret %s/%s: %w", k.namespace, k.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return AccessKey{}, fmt.Errorf("reading secret %s/%s returned %d: %s", k.namespace, k.cfg.Name, resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	// Secret data values are base64, which encoding/json decodes into []byte
+	var secret struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return AccessKey{}, fmt.Errorf("failed to decode secret %s/%s: %w", k.namespace, k.cfg.Name, err)
+	}
+	return AccessKey{ID: string(secret.Data[k.cfg.AccessKeyField]), Secret: string(secret.Data[k.cfg.SecretKeyField])}, nil
+}
+
+func (k *Kubernetes) secretURL() string {
+	return fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", k.apiURL, url.PathEscape(k.namespace), url.PathEscape(k.cfg.Name))
+}
+
+// authorize adds the service account token when running in a pod
+func (k *Kubernetes) authorize(req *http.Request) {
+	if token, err := os.ReadFile(k.tokenFile); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+}
@@ -0,0 +1,98 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+)
+
+func TestKubernetesPatchesSecret(t *testing.T) {
+	var path, contentType, authorization string
+	var patch map[string]map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType, authorization = r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&patch)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	store, err := NewKubernetes(config.SecretStoreConfig{
+		Namespace:      "monitoring",
+		Name:           "s3-keys",
+		AccessKeyField: "AWS_ACCESS_KEY_ID",
+		SecretKeyField: "AWS_SECRET_ACCESS_KEY",
+		APIURL:         server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.tokenFile = filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(store.tokenFile, []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put(context.Background(), AccessKey{ID: "AKIANEW", Secret: "new"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/api/v1/namespaces/monitoring/secrets/s3-keys" || contentType != "application/merge-patch+json" {
+		t.Fatalf("unexpected request %s (%s)", path, contentType)
+	}
+	if authorization != "Bearer sa-token" {
+		t.Fatalf("expected the service account token, got %q", authorization)
+	}
+	if data := patch["stringData"]; data["AWS_ACCESS_KEY_ID"] != "AKIANEW" || data["AWS_SECRET_ACCESS_KEY"] != "new" {
+		t.Fatalf("unexpected patch %v", patch)
+	}
+}
+
+func TestKubernetesRequiresClusterOrAPIURL(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if _, err := NewKubernetes(config.SecretStoreConfig{Name: "s3-keys"}); err == nil {
+		t.Fatalf("expected an error outside a cluster without api_url")
+	}
+}
+
+func TestLoadCredentialsReadsKubernetesSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/namespaces/monitoring/secrets/s3-keys" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// "AKIANEW" and "new" in base64, as the API server returns Secret data
+		_, _ = w.Write([]byte(`{"data":{"AWS_ACCESS_KEY_ID":"QUtJQU5FVw==","AWS_SECRET_ACCESS_KEY":"bmV3"}}`))
+	}))
+	defer server.Close()
+
+	endpoints := []config.S3EndpointConfig{
+		{Name: "plain", AccessKey: "AKIAPLAIN", SecretKey: "plain"},
+		{Name: "rotated", Rotation: &config.RotationConfig{Store: config.SecretStoreConfig{
+			Type:           config.StoreKubernetes,
+			Namespace:      "monitoring",
+			Name:           "s3-keys",
+			AccessKeyField: "AWS_ACCESS_KEY_ID",
+			SecretKeyField: "AWS_SECRET_ACCESS_KEY",
+			APIURL:         server.URL,
+		}}},
+	}
+	if err := LoadCredentials(context.Background(), endpoints); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endpoints[0].AccessKey != "AKIAPLAIN" {
+		t.Fatalf("expected endpoints without rotation to keep their keys, got %+v", endpoints[0])
+	}
+	if endpoints[1].AccessKey != "AKIANEW" || endpoints[1].SecretKey != "new" {
+		t.Fatalf("expected the key pair from the Secret, got %+v", endpoints[1])
+	}
+
+	endpoints[1].Rotation.Store.Name = "missing"
+	if err := LoadCredentials(context.Background(), endpoints); err == nil {
+		t.Fatalf("expected an error for a missing Secret")
+	}
+}
@@ -0,0 +1,118 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// SecretsManager writes rotated keys into a JSON secret in AWS Secrets Manager. Other
+// fields of the secret are kept. Requests are signed with the exporter's own AWS
+// credentials from the default chain, not with the key being rotated.
+type SecretsManager struct {
+	cfg config.SecretStoreConfig
+
+	// credentials is resolved lazily from the default chain unless set
+	credentials aws.CredentialsProvider
+
+	mu  sync.Mutex
+	api *secretsmanager.Client
+}
+
+// NewSecretsManager creates a Secrets Manager store
+func NewSecretsManager(cfg config.SecretStoreConfig) *SecretsManager {
+	return &SecretsManager{cfg: cfg}
+}
+
+// Put merges the key pair into the secret's JSON document and stores a new version
+func (s *SecretsManager) Put(ctx context.Context, key AccessKey) error {
+	api, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	document, err := s.document(ctx, api)
+	if err != nil {
+		return err
+	}
+	document[s.cfg.AccessKeyField] = key.ID
+	document[s.cfg.SecretKeyField] = key.Secret
+
+	secret, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	_, err = api.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(s.cfg.SecretID),
+		SecretString: aws.String(string(secret)),
+	})
+	return err
+}
+
+// Get reads the key pair from the secret's JSON document
+func (s *SecretsManager) Get(ctx context.Context) (AccessKey, error) {
+	api, err := s.client(ctx)
+	if err != nil {
+		return AccessKey{}, err
+	}
+	document, err := s.document(ctx, api)
+	if err != nil {
+		return AccessKey{}, err
+	}
+	id, _ := document[s.cfg.AccessKeyField].(string)
+	secret, _ := document[s.cfg.SecretKeyField].(string)
+	return AccessKey{ID: id, Secret: secret}, nil
+}
+
+// document reads the current version of the secret as a JSON object
+func (s *SecretsManager) document(ctx context.Context, api *secretsmanager.Client) (map[string]any, error) {
+	current, err := api.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(s.cfg.SecretID)})
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]any)
+	if secret := aws.ToString(current.SecretString); secret != "" {
+		if err := json.Unmarshal([]byte(secret), &document); err != nil {
+			return nil, fmt.Errorf("secret %s is not a JSON object: %w", s.cfg.SecretID, err)
+		}
+	}
+	return document, nil
+}
+
+// client builds the Secrets Manager client on first use, loading the default
+// credential chain unless credentials were set. Without an api_url the SDK resolves
+// the regional endpoint.
+func (s *SecretsManager) client(ctx context.Context) (*secretsmanager.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.api != nil {
+		return s.api, nil
+	}
+
+	if s.credentials == nil {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(s.cfg.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS credentials for Secrets Manager: %w", err)
+		}
+		s.credentials = awsCfg.Credentials
+	}
+	s.api = secretsmanager.NewFromConfig(aws.Config{
+		Region:      s.cfg.Region,
+		Credentials: s.credentials,
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+	}, func(o *secretsmanager.Options) {
+		if s.cfg.APIURL != "" {
+			o.BaseEndpoint = aws.String(s.cfg.APIURL)
+		}
+	})
+	return s.api, nil
+}
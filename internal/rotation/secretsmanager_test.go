@@ -0,0 +1,84 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"key-aws-exporter/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestSecretsManagerMergesKeyPair(t *testing.T) {
+	var stored string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request") {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		var input map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if input["SecretId"] != "s3/backup" {
+			t.Errorf("unexpected secret id %q", input["SecretId"])
+		}
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"bucket":"backups","access_key":"AKIAOLD","secret_key":"old"}`})
+		case "secretsmanager.PutSecretValue":
+			stored = input["SecretString"]
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	store := NewSecretsManager(config.SecretStoreConfig{
+		SecretID:       "s3/backup",
+		Region:         "eu-west-1",
+		AccessKeyField: "access_key",
+		SecretKeyField: "secret_key",
+		APIURL:         server.URL,
+	})
+	store.credentials = credentials.NewStaticCredentialsProvider("AKIAEXPORTER", "secret", "")
+
+	if err := store.Put(context.Background(), AccessKey{ID: "AKIANEW", Secret: "new"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document map[string]string
+	if err := json.Unmarshal([]byte(stored), &document); err != nil {
+		t.Fatalf("stored secret is not JSON: %v", err)
+	}
+	if document["access_key"] != "AKIANEW" || document["secret_key"] != "new" || document["bucket"] != "backups" {
+		t.Fatalf("expected the key pair merged into the secret, got %v", document)
+	}
+
+	key, err := store.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.ID != "AKIAOLD" || key.Secret != "old" {
+		t.Fatalf("expected the key pair read from the secret, got %+v", key)
+	}
+}
+
+func TestSecretsManagerReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+	}))
+	defer server.Close()
+
+	store := NewSecretsManager(config.SecretStoreConfig{SecretID: "missing", Region: "us-east-1", APIURL: server.URL})
+	store.credentials = credentials.NewStaticCredentialsProvider("AKIAEXPORTER", "secret", "")
+
+	err := store.Put(context.Background(), AccessKey{ID: "AKIANEW", Secret: "new"})
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+
+	"key-aws-exporter/internal/config"
+)
+
+// Store persists a rotated key pair where the workloads using it read their credentials
+type Store interface {
+	Put(ctx context.Context, key AccessKey) error
+	// Get reads the key pair currently stored
+	Get(ctx context.Context) (AccessKey, error)
+}
+
+// NewStore builds the secret store described by cfg
+func NewStore(cfg config.SecretStoreConfig) (Store, error) {
+	switch cfg.Type {
+	case config.StoreSecretsManager:
+		return NewSecretsManager(cfg), nil
+	case config.StoreKubernetes:
+		return NewKubernetes(cfg)
+	default:
+		return nil, fmt.Errorf("unknown secret store type %q", cfg.Type)
+	}
+}
+
+// LoadCredentials fills in the key pair of every endpoint opted into rotation from its
+// secret store. Rotated endpoints take their keys only from there, so after a restart
+// the exporter uses the latest rotated key rather than one deactivated in IAM.
+func LoadCredentials(ctx context.Context, endpoints []config.S3EndpointConfig) error {
+	for i, endpoint := range endpoints {
+		if endpoint.Rotation == nil {
+			continue
+		}
+		store, err := NewStore(endpoint.Rotation.Store)
+		if err != nil {
+			return fmt.Errorf("endpoint %q: %w", endpoint.Name, err)
+		}
+		key, err := store.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("endpoint %q: failed to read credentials from the secret store: %w", endpoint.Name, err)
+		}
+		if key.ID == "" || key.Secret == "" {
+			return fmt.Errorf("endpoint %q: secret store has no %s and %s", endpoint.Name, endpoint.Rotation.Store.AccessKeyField, endpoint.Rotation.Store.SecretKeyField)
+		}
+		endpoints[i].AccessKey = key.ID
+		endpoints[i].SecretKey = key.Secret
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	base := time.Date(2024, 11, 6, 10, 30, 15, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 11, 6, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 11, 6, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2024, 11, 7, 8, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 11, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1", time.Date(2024, 11, 11, 8, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 9 1,15 * 1-5", time.Date(2024, 11, 7, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range cases {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.expr, err)
+		}
+		if got := schedule.Next(base); !got.Equal(tt.want) {
+			t.Fatalf("%q: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+}
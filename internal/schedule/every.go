@@ -0,0 +1,41 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+// Every calls run immediately and then on every tick of clk until ctx is done, in its
+// own goroutine. A non-positive interval disables the loop.
+func Every(ctx context.Context, clk clock.Clock, interval time.Duration, run func()) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		runOnce := func() {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			run()
+		}
+
+		runOnce()
+
+		ticker := clk.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				runOnce()
+			}
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+func TestEveryFollowsClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	runs := make(chan time.Time, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	Every(ctx, clk, time.Minute, func() { runs <- clk.Now() })
+
+	<-runs // the immediate run
+	clk.BlockUntil(1)
+	clk.Advance(59 * time.Second)
+	select {
+	case <-runs:
+		t.Fatalf("expected no run before the interval elapsed")
+	default:
+	}
+
+	clk.Advance(time.Second)
+	if at := <-runs; !at.Equal(time.Date(2024, 6, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Fatalf("expected the second run after one interval, got %s", at)
+	}
+}
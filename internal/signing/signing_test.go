@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSignerAndVerify(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	signer, err := LoadSigner(path)
+	if err != nil {
+		t.Fatalf("load signer: %v", err)
+	}
+	body := []byte(`{"is_valid":true}`)
+	header := http.Header{}
+	signer.SetHeaders(header, body)
+
+	if !Verify(signer.PublicKey(), body, header.Get(SignatureHeader)) {
+		t.Fatalf("expected the signature to verify")
+	}
+	if Verify(signer.PublicKey(), []byte(`{"is_valid":false}`), header.Get(SignatureHeader)) {
+		t.Fatalf("expected a tampered body to fail verification")
+	}
+	if header.Get(KeyIDHeader) != signer.KeyID() || len(signer.KeyID()) != 16 {
+		t.Fatalf("unexpected key ID %q", header.Get(KeyIDHeader))
+	}
+
+	block, _ := pem.Decode([]byte(signer.PublicKeyPEM()))
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil || !parsed.(ed25519.PublicKey).Equal(signer.PublicKey()) {
+		t.Fatalf("expected the PEM to hold the public key, got %v", err)
+	}
+}
+
+func TestLoadSignerRejectsOtherKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	os.WriteFile(path, []byte("not a key"), 0o600)
+	if _, err := LoadSigner(path); err == nil {
+		t.Fatalf("expected a non-PEM file to be rejected")
+	}
+	if _, err := LoadSigner(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatalf("expected a missing file to be rejected")
+	}
+}
+
+func TestTransportSignsRequests(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := NewSigner(key)
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = Verify(signer.PublicKey(), body, r.Header.Get(SignatureHeader)) && string(body) == `{"state":"invalid"}`
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport(signer, nil)}
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"state":"invalid"}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if !verified {
+		t.Fatalf("expected the webhook to receive a verifiable signature")
+	}
+}
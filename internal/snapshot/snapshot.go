@@ -0,0 +1,161 @@
+// Package snapshot periodically writes the exporter's metrics or latest results to an
+// S3 object, for collectors that cannot reach the exporter but can read the bucket.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/sirupsen/logrus"
+)
+
+// ObjectPutter writes an object, replacing the previous one
+type ObjectPutter interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// ResultSource exposes the latest result of every endpoint
+type ResultSource interface {
+	GetEndpoints() []string
+	History(endpointName string) []exporter.HistoryEntry
+}
+
+// Results is the JSON snapshot
+type Results struct {
+	Timestamp time.Time                 `json:"timestamp"`
+	Results   map[string]EndpointResult `json:"results"`
+}
+
+// EndpointResult is the latest result of an endpoint in the JSON snapshot
+type EndpointResult struct {
+	CheckedAt      time.Time `json:"checked_at"`
+	IsValid        bool      `json:"is_valid"`
+	ErrorType      string    `json:"error_type,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+}
+
+// Writer renders a snapshot and puts it into the bucket
+type Writer struct {
+	cfg      config.SnapshotConfig
+	putter   ObjectPutter
+	source   ResultSource
+	gatherer prometheus.Gatherer
+	clock    clock.Clock
+	log      *logrus.Logger
+}
+
+// Option customizes a Writer
+type Option func(*Writer)
+
+// WithGatherer replaces the default Prometheus registry as the source of metrics
+func WithGatherer(g prometheus.Gatherer) Option {
+	return func(w *Writer) {
+		w.gatherer = g
+	}
+}
+
+// WithClock sets the clock used for snapshot timestamps and the write interval
+func WithClock(clk clock.Clock) Option {
+	return func(w *Writer) {
+		w.clock = clk
+	}
+}
+
+// NewWriter creates a writer putting snapshots of cfg.Format through putter
+func NewWriter(cfg config.SnapshotConfig, putter ObjectPutter, source ResultSource, log *logrus.Logger, opts ...Option) *Writer {
+	w := &Writer{
+		cfg:      cfg,
+		putter:   putter,
+		source:   source,
+		gatherer: prometheus.DefaultGatherer,
+		clock:    clock.Real,
+		log:      log,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write renders the current snapshot and puts it at the configured key
+func (w *Writer) Write(ctx context.Context) error {
+	var body []byte
+	var contentType string
+	var err error
+	if w.cfg.Format == config.SnapshotFormatJSON {
+		body, err = w.renderResults()
+		contentType = "application/json"
+	} else {
+		body, err = w.renderMetrics()
+		contentType = string(expfmt.NewFormat(expfmt.TypeTextPlain))
+	}
+	if err != nil {
+		return err
+	}
+	return w.putter.Put(ctx, w.cfg.Key, contentType, body)
+}
+
+// Run writes a snapshot every interval until ctx is done. A failed write is logged and
+// counted in snapshot_export_failures_total; the next one replaces it.
+func (w *Writer) Run(ctx context.Context) {
+	ticker := w.clock.NewTicker(time.Duration(w.cfg.Interval))
+	defer ticker.Stop()
+	for {
+		if err := w.Write(ctx); err != nil && ctx.Err() == nil {
+			metrics.RecordSnapshotExportFailure()
+			w.log.WithError(err).Warn("Snapshot export failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// renderMetrics encodes the registered metrics like a /metrics scrape
+func (w *Writer) renderMetrics() ([]byte, error) {
+	families, err := w.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return nil, fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// renderResults encodes the latest result of every endpoint validated so far
+func (w *Writer) renderResults() ([]byte, error) {
+	snapshot := Results{Timestamp: w.clock.Now().UTC(), Results: make(map[string]EndpointResult)}
+	for _, name := range w.source.GetEndpoints() {
+		entries := w.source.History(name)
+		if len(entries) == 0 {
+			continue
+		}
+		latest := entries[len(entries)-1]
+		snapshot.Results[name] = EndpointResult{
+			CheckedAt:      latest.CheckedAt.UTC(),
+			IsValid:        latest.IsValid,
+			ErrorType:      latest.ErrorType,
+			Message:        latest.Message,
+			ResponseTimeMs: latest.ResponseTimeMs,
+		}
+	}
+	return json.Marshal(snapshot)
+}
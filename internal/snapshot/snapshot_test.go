@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+type putObject struct {
+	key, contentType string
+	body             []byte
+}
+
+type stubPutter struct {
+	puts []putObject
+}
+
+func (s *stubPutter) Put(ctx context.Context, key, contentType string, body []byte) error {
+	s.puts = append(s.puts, putObject{key: key, contentType: contentType, body: body})
+	return nil
+}
+
+type stubSource map[string][]exporter.HistoryEntry
+
+func (s stubSource) GetEndpoints() []string {
+	return []string{"primary", "unchecked"}
+}
+
+func (s stubSource) History(endpointName string) []exporter.HistoryEntry {
+	return s[endpointName]
+}
+
+func TestWriterPutsMetricsExposition(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "s3_keys_valid", Help: "Keys valid"})
+	gauge.Set(1)
+	registry.MustRegister(gauge)
+
+	putter := &stubPutter{}
+	cfg := config.SnapshotConfig{Format: config.SnapshotFormatPrometheus, Key: "exporter/metrics.prom"}
+	if err := NewWriter(cfg, putter, stubSource{}, logrus.New(), WithGatherer(registry)).Write(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(putter.puts) != 1 {
+		t.Fatalf("expected one object, got %d", len(putter.puts))
+	}
+	put := putter.puts[0]
+	if put.key != "exporter/metrics.prom" || !strings.HasPrefix(put.contentType, "text/plain") {
+		t.Fatalf("unexpected object %s (%s)", put.key, put.contentType)
+	}
+	if !strings.Contains(string(put.body), "# TYPE s3_keys_valid gauge\ns3_keys_valid 1\n") {
+		t.Fatalf("unexpected exposition:\n%s", put.body)
+	}
+}
+
+func TestWriterPutsLatestResults(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	source := stubSource{"primary": {
+		{CheckedAt: now.Add(-2 * time.Minute), IsValid: true, ResponseTimeMs: 20},
+		{CheckedAt: now.Add(-time.Minute), IsValid: false, ErrorType: "access_denied", ResponseTimeMs: 90},
+	}}
+	putter := &stubPutter{}
+	cfg := config.SnapshotConfig{Format: config.SnapshotFormatJSON, Key: "results.json"}
+	if err := NewWriter(cfg, putter, source, logrus.New(), WithClock(clock.NewFake(now))).Write(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var snapshot Results
+	if err := json.Unmarshal(putter.puts[0].body, &snapshot); err != nil {
+		t.Fatalf("invalid JSON snapshot: %v", err)
+	}
+	if !snapshot.Timestamp.Equal(now) || len(snapshot.Results) != 1 {
+		t.Fatalf("expected only the validated endpoint, got %+v", snapshot)
+	}
+	if latest := snapshot.Results["primary"]; latest.IsValid || latest.ErrorType != "access_denied" || latest.ResponseTimeMs != 90 {
+		t.Fatalf("expected the latest result, got %+v", latest)
+	}
+}
@@ -0,0 +1,8 @@
+package version
+
+// Name identifies the exporter in User-Agent strings and build info
+const Name = "key-aws-exporter"
+
+// Version is the exporter release, set at build time with
+// -ldflags "-X key-aws-exporter/internal/version.Version=v1.2.3"
+var Version = "dev"
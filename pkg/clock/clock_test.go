@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFakeTimerFiresOnAdvance(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Minute)
+
+	f.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatalf("timer fired early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Fatalf("expected the timer to fire at its deadline, got %s", fired)
+		}
+	default:
+		t.Fatalf("expected the timer to fire")
+	}
+	if f.Waiters() != 0 || timer.Stop() {
+		t.Fatalf("expected a fired timer to be gone")
+	}
+}
+
+func TestFakeTickerRepeats(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(10 * time.Second)
+
+	for i := 1; i <= 3; i++ {
+		f.Advance(10 * time.Second)
+		if fired := <-ticker.C(); !fired.Equal(start.Add(time.Duration(i) * 10 * time.Second)) {
+			t.Fatalf("tick %d at unexpected time %s", i, fired)
+		}
+	}
+
+	// Like time.Ticker, ticks are dropped while the channel is full
+	f.Advance(time.Minute)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatalf("expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	f.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatalf("expected a stopped ticker not to fire")
+	default:
+	}
+	if got := f.Since(start); got != 2*time.Minute+30*time.Second {
+		t.Fatalf("expected 2m30s to have passed, got %s", got)
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		timer := f.NewTimer(time.Hour)
+		done <- <-timer.C()
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Hour)
+	if fired := <-done; !fired.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected fire time %s", fired)
+	}
+}
+
+func TestOrReal(t *testing.T) {
+	if OrReal(nil) != Real {
+		t.Fatalf("expected nil to fall back to the real clock")
+	}
+	f := NewFake(start)
+	if OrReal(f) != f {
+		t.Fatalf("expected a set clock to be kept")
+	}
+}
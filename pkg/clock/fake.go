@@ -0,0 +1,136 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a manually advanced clock. Timers and tickers fire only when Advance moves
+// the time past their deadline, so tests control exactly when scheduled work runs.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	added   chan struct{}
+}
+
+// fakeWaiter is a pending timer, or a ticker when period is set
+type fakeWaiter struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration
+	c        chan time.Time
+}
+
+// NewFake creates a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, added: make(chan struct{}, 1)}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer creates a timer firing once Advance reaches now+d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return fakeTimer{f.add(d, 0)}
+}
+
+// NewTicker creates a ticker firing every d of advanced time
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	w := &fakeWaiter{clock: f, deadline: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.mu.Unlock()
+
+	select {
+	case f.added <- struct{}{}:
+	default:
+	}
+	return w
+}
+
+// Advance moves the time forward by d and fires every timer and ticker due on the way,
+// in deadline order. Like time.Ticker, a ticker whose channel is full drops the tick.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool {
+			return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].deadline.After(target) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.deadline
+		select {
+		case w.c <- w.deadline:
+		default:
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+	}
+	f.now = target
+	f.mu.Unlock()
+}
+
+// Waiters returns how many timers and tickers are pending
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n timers and tickers are pending, so a test can
+// advance the clock only after the code under test started waiting on it. Only one
+// goroutine may block at a time.
+func (f *Fake) BlockUntil(n int) {
+	for f.Waiters() < n {
+		<-f.added
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+
+// remove drops the waiter from the clock, reporting whether it was still pending
+func (w *fakeWaiter) remove() bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct{ *fakeWaiter }
+
+func (t fakeTimer) Stop() bool { return t.remove() }
+
+type fakeTicker struct{ *fakeWaiter }
+
+func (t fakeTicker) Stop() { t.remove() }
@@ -0,0 +1,325 @@
+// Package exporter embeds the key exporter in another program: New validates the
+// configured endpoints, Handler serves the exporter's API and /metrics, and Start runs
+// the scheduled validations, so services can check their keys without a sidecar.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"key-aws-exporter/internal/config"
+	"key-aws-exporter/internal/exporter"
+	"key-aws-exporter/internal/handlers"
+	"key-aws-exporter/internal/schedule"
+	"key-aws-exporter/pkg/clock"
+	"key-aws-exporter/pkg/metrics"
+	"key-aws-exporter/pkg/s3"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures an embedded exporter. A Config built by hand needs only Endpoints.
+// LoadConfig reads the environment variables of the exporter binary instead; settings
+// without a field here, such as bucket checks, authentication or result rules, are only
+// available that way and are kept when the fields are changed afterwards.
+type Config struct {
+	Endpoints []EndpointConfig
+	// ValidationTimeout bounds each endpoint's probe; 0 uses 10s
+	ValidationTimeout time.Duration
+	// AutoValidateInterval and DeepValidateInterval schedule the shallow and deep runs
+	// of Start; 0 disables a schedule
+	AutoValidateInterval time.Duration
+	DeepValidateInterval time.Duration
+	// PermissionCheckInterval schedules the expected permission assertions of Start,
+	// which only endpoints loaded with expected_permissions have; 0 disables them
+	PermissionCheckInterval time.Duration
+	// CanaryCleanupInterval schedules the deletion of leaked canary objects; 0 disables it
+	CanaryCleanupInterval time.Duration
+	// ReadOnly fails probes and checks that would write to a bucket
+	ReadOnly bool
+
+	loaded *config.Config
+}
+
+// EndpointConfig is one S3 endpoint whose keys are validated
+type EndpointConfig struct {
+	Name         string
+	Endpoint     string // S3-compatible URL; empty for AWS
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	UsePathStyle bool
+	// ProbeDepth is "shallow" (the default) or "deep"
+	ProbeDepth string
+	// Severity, Labels and Annotations are copied into results, logs and metrics
+	Severity    string
+	Labels      map[string]string
+	Annotations map[string]string
+
+	loaded *config.S3EndpointConfig
+}
+
+// Results are the results of one validation run
+type Results struct {
+	Timestamp time.Time
+	// Results holds the result of every endpoint, keyed by endpoint name
+	Results map[string]*s3.ValidationResult
+	// Deep marks a scheduled deep run, whose results do not change key validity
+	Deep bool
+}
+
+// ResultSink receives the results of every validation run, e.g. to alert on them
+type ResultSink interface {
+	Consume(results *Results)
+}
+
+// LoadConfig reads the configuration from the environment variables the exporter
+// binary uses
+func LoadConfig() (*Config, error) {
+	loaded, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		ValidationTimeout:       loaded.ValidationTimeout,
+		AutoValidateInterval:    loaded.AutoValidateInterval,
+		DeepValidateInterval:    loaded.DeepValidateInterval,
+		PermissionCheckInterval: loaded.PermissionCheckInterval,
+		CanaryCleanupInterval:   loaded.CanaryCleanupInterval,
+		ReadOnly:                loaded.ReadOnly,
+		loaded:                  loaded,
+	}
+	for i := range loaded.Endpoints {
+		cfg.Endpoints = append(cfg.Endpoints, newEndpointConfig(&loaded.Endpoints[i]))
+	}
+	return cfg, nil
+}
+
+func newEndpointConfig(loaded *config.S3EndpointConfig) EndpointConfig {
+	return EndpointConfig{
+		Name:         loaded.Name,
+		Endpoint:     loaded.Endpoint,
+		Region:       loaded.Region,
+		Bucket:       loaded.Bucket,
+		AccessKey:    loaded.AccessKey,
+		SecretKey:    loaded.SecretKey,
+		SessionToken: loaded.SessionToken,
+		UsePathStyle: loaded.UsePathStyle,
+		ProbeDepth:   loaded.ProbeDepth,
+		Severity:     loaded.Severity,
+		Labels:       loaded.Labels,
+		Annotations:  loaded.Annotations,
+		loaded:       loaded,
+	}
+}
+
+// internal merges the fields into the loaded configuration, or into defaults
+func (c *Config) internal() *config.Config {
+	var cfg config.Config
+	if c.loaded != nil {
+		cfg = *c.loaded
+	}
+	cfg.ValidationTimeout = c.ValidationTimeout
+	if cfg.ValidationTimeout <= 0 {
+		cfg.ValidationTimeout = config.DefaultValidationTimeout
+	}
+	if cfg.CanaryTTL <= cfg.ValidationTimeout {
+		cfg.CanaryTTL = max(config.DefaultCanaryTTL, 2*cfg.ValidationTimeout)
+	}
+	cfg.AutoValidateInterval = c.AutoValidateInterval
+	cfg.DeepValidateInterval = c.DeepValidateInterval
+	cfg.PermissionCheckInterval = c.PermissionCheckInterval
+	cfg.CanaryCleanupInterval = c.CanaryCleanupInterval
+	cfg.ReadOnly = c.ReadOnly
+
+	cfg.Endpoints = make([]config.S3EndpointConfig, 0, len(c.Endpoints))
+	for _, endpoint := range c.Endpoints {
+		cfg.Endpoints = append(cfg.Endpoints, endpoint.internal())
+	}
+	return &cfg
+}
+
+// internal merges the fields into the loaded endpoint configuration
+func (e EndpointConfig) internal() config.S3EndpointConfig {
+	var cfg config.S3EndpointConfig
+	if e.loaded != nil {
+		cfg = *e.loaded
+	}
+	cfg.Name = e.Name
+	cfg.Endpoint = e.Endpoint
+	cfg.Region = e.Region
+	cfg.Bucket = e.Bucket
+	cfg.AccessKey = e.AccessKey
+	cfg.SecretKey = e.SecretKey
+	cfg.SessionToken = e.SessionToken
+	cfg.UsePathStyle = e.UsePathStyle
+	cfg.ProbeDepth = e.ProbeDepth
+	cfg.Severity = e.Severity
+	cfg.Labels = e.Labels
+	cfg.Annotations = e.Annotations
+	return cfg
+}
+
+func newResults(results *exporter.ValidationResults) *Results {
+	return &Results{Timestamp: results.Timestamp, Results: results.Results, Deep: results.Deep()}
+}
+
+// sinkAdapter hands the manager's batches to a ResultSink
+type sinkAdapter struct {
+	sink ResultSink
+}
+
+func (a sinkAdapter) Consume(results *exporter.ValidationResults) {
+	a.sink.Consume(newResults(results))
+}
+
+// Option customizes an Exporter
+type Option func(*Exporter)
+
+// WithLogger logs through log instead of a new logrus logger
+func WithLogger(log *logrus.Logger) Option {
+	return func(e *Exporter) {
+		e.log = log
+	}
+}
+
+// WithSink also sends the results of every validation run to sink
+func WithSink(sink ResultSink) Option {
+	return func(e *Exporter) {
+		e.sinks = append(e.sinks, sink)
+	}
+}
+
+// WithClock runs the schedules of Start on clk instead of the wall clock
+func WithClock(clk clock.Clock) Option {
+	return func(e *Exporter) {
+		e.clock = clk
+	}
+}
+
+// WithRegisterer registers the exporter's own metrics on reg instead of recording into
+// metrics.Default on the Prometheus default registry, so several exporters, or an
+// exporter and the program's own collectors, can share a process
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(e *Exporter) {
+		e.registerer = reg
+	}
+}
+
+// Exporter validates the keys of the configured endpoints
+type Exporter struct {
+	cfg        *config.Config
+	log        *logrus.Logger
+	clock      clock.Clock
+	sinks      []ResultSink
+	registerer prometheus.Registerer
+	metrics    *metrics.Metrics
+	manager    *exporter.ValidatorManager
+}
+
+// New creates an exporter for cfg, which is not modified. Results are published as
+// metrics on the Prometheus default registry, like the exporter binary does, unless
+// WithRegisterer names another one.
+func New(cfg *Config, opts ...Option) (*Exporter, error) {
+	if cfg == nil {
+		return nil, errors.New("exporter config is required")
+	}
+
+	e := &Exporter{cfg: cfg.internal(), log: logrus.New(), clock: clock.Real, metrics: metrics.Default}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.registerer != nil {
+		m, err := metrics.NewMetrics(e.registerer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+		e.metrics = m
+	}
+
+	managerOpts := []exporter.ManagerOption{exporter.WithMetrics(e.metrics)}
+	if len(e.cfg.EndpointRules) > 0 {
+		managerOpts = append(managerOpts, exporter.WithEndpointRewrite(e.cfg.EndpointRules.Apply))
+	}
+	e.manager = exporter.NewValidatorManager(e.cfg, e.log, managerOpts...)
+	for _, sink := range e.sinks {
+		e.manager.AddSink(sinkAdapter{sink: sink})
+	}
+	return e, nil
+}
+
+// Handler serves the validation, results, history, report and cycle routes of the
+// binary's API behind the authentication, allowlist and CORS settings LoadConfig read.
+// The admin, Slack and submitted credentials routes are only served by the binary.
+// Mount it on a mux of your own, e.g. under a prefix with http.StripPrefix. /metrics
+// serves the exporter's registry when it is also a Gatherer, such as a
+// *prometheus.Registry, or the default registry without WithRegisterer; otherwise
+// serve the registry yourself.
+func (e *Exporter) Handler() http.Handler {
+	mux := handlers.NewRouter(e.manager, e.log, handlers.RouterOptions(e.cfg, e.metrics)...)
+	switch gatherer, ok := e.registerer.(prometheus.Gatherer); {
+	case e.registerer == nil:
+		mux.Handle("GET /metrics", promhttp.Handler())
+	case ok:
+		mux.Handle("GET /metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	}
+	return handlers.WithMiddleware(mux, e.cfg, e.metrics, e.log)
+}
+
+// Start runs the validations scheduled by AutoValidateInterval, DeepValidateInterval
+// and PermissionCheckInterval, and the canary cleanup scheduled by
+// CanaryCleanupInterval, in the background until ctx is done. Each validation run is
+// bounded by its interval.
+func (e *Exporter) Start(ctx context.Context) {
+	schedule.Every(ctx, e.clock, e.cfg.AutoValidateInterval, func() {
+		runCtx, cancel := context.WithTimeout(ctx, e.cfg.AutoValidateInterval)
+		defer cancel()
+		e.manager.ValidateCycle(runCtx)
+	})
+	schedule.Every(ctx, e.clock, e.cfg.DeepValidateInterval, func() {
+		runCtx, cancel := context.WithTimeout(ctx, e.cfg.DeepValidateInterval)
+		defer cancel()
+		e.manager.ValidateDeep(runCtx)
+	})
+	schedule.Every(ctx, e.clock, e.cfg.PermissionCheckInterval, func() {
+		e.manager.AssertPermissions(ctx)
+	})
+	schedule.Every(ctx, e.clock, e.cfg.CanaryCleanupInterval, func() {
+		e.manager.CleanCanaries(ctx)
+	})
+}
+
+// ValidateAll validates every endpoint now and returns the results, which also reach
+// the metrics and sinks
+func (e *Exporter) ValidateAll(ctx context.Context) *Results {
+	return newResults(e.manager.ValidateAll(ctx))
+}
+
+// ValidateEndpoint validates one endpoint now; the result reports unknown endpoints
+// as failed
+func (e *Exporter) ValidateEndpoint(ctx context.Context, name string) *s3.ValidationResult {
+	return e.manager.ValidateEndpoint(ctx, name)
+}
+
+// Endpoints returns the names of the configured endpoints
+func (e *Exporter) Endpoints() []string {
+	return e.manager.GetEndpoints()
+}
+
+// AddEndpoint starts validating another endpoint, replacing one with the same name
+func (e *Exporter) AddEndpoint(endpoint EndpointConfig) {
+	e.manager.AddEndpoint(endpoint.internal())
+}
+
+// RemoveEndpoint stops validating an endpoint and removes its metrics, reporting
+// whether it was configured
+func (e *Exporter) RemoveEndpoint(name string) bool {
+	return e.manager.RemoveEndpoint(name)
+}
@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resultsChan forwards every validation run
+type resultsChan chan *Results
+
+func (c resultsChan) Consume(results *Results) { c <- results }
+
+func newS3Server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<ListBucketResult><Name>embedded</Name></ListBucketResult>`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func embeddedConfig(url string) *Config {
+	return &Config{
+		Endpoints: []EndpointConfig{{
+			Name:         "embedded",
+			Endpoint:     url,
+			Region:       "us-east-1",
+			Bucket:       "embedded",
+			AccessKey:    "ak",
+			SecretKey:    "sk",
+			UsePathStyle: true,
+		}},
+	}
+}
+
+func TestExporterValidateAllAndHandler(t *testing.T) {
+	server := newS3Server(t)
+	sink := make(resultsChan, 1)
+	e, err := New(embeddedConfig(server.URL), WithSink(sink))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	results := e.ValidateAll(context.Background())
+	if !results.Results["embedded"].IsValid {
+		t.Fatalf("expected the endpoint to validate, got %+v", results.Results["embedded"])
+	}
+	if published := <-sink; len(published.Results) != 1 {
+		t.Fatalf("expected the run to reach the sink, got %+v", published)
+	}
+
+	handler := e.Handler()
+	for _, path := range []string{"/health", "/validate/embedded", "/metrics"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", path, rr.Code)
+		}
+		if path == "/metrics" && !strings.Contains(rr.Body.String(), `s3_keys_valid{bucket="embedded"} 1`) {
+			t.Fatalf("expected /metrics to export the result")
+		}
+	}
+}
+
+func TestExporterStartRunsSchedule(t *testing.T) {
+	server := newS3Server(t)
+	cfg := embeddedConfig(server.URL)
+	cfg.AutoValidateInterval = time.Minute
+	clk := clock.NewFake(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	sink := make(resultsChan, 2)
+	e, err := New(cfg, WithSink(sink), WithClock(clk))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e.Start(ctx)
+
+	<-sink // the immediate run
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	if results := <-sink; !results.Results["embedded"].IsValid {
+		t.Fatalf("expected the scheduled run to validate the endpoint, got %+v", results)
+	}
+}
+
+func TestNewRequiresConfig(t *testing.T) {
+	if _, err := New(nil); err == nil {
+		t.Fatalf("expected a nil config to be rejected")
+	}
+}
+
+func TestExporterWithRegistererKeepsMetricsApart(t *testing.T) {
+	server := newS3Server(t)
+	handlersByName := make(map[string]http.Handler)
+	for _, name := range []string{"first", "second"} {
+		cfg := embeddedConfig(server.URL)
+		cfg.Endpoints[0].Name = name
+		e, err := New(cfg, WithRegisterer(prometheus.NewRegistry()))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		e.ValidateAll(context.Background())
+		handlersByName[name] = e.Handler()
+	}
+
+	for name, handler := range handlersByName {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body := rr.Body.String()
+		if !strings.Contains(body, `s3_keys_valid{bucket="`+name+`"} 1`) {
+			t.Fatalf("expected %s's registry to export its endpoint", name)
+		}
+		for other := range handlersByName {
+			if other != name && strings.Contains(body, `bucket="`+other+`"`) {
+				t.Fatalf("expected %s's registry to leave out %s", name, other)
+			}
+		}
+	}
+}
+
+func TestLoadConfigKeepsSettingsWithoutFields(t *testing.T) {
+	t.Setenv("S3_ENDPOINTS_JSON", `[{"name":"loaded","bucket":"b","access_key":"AK","secret_key":"SK","checks":{"public_access":true}}]`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Endpoints) != 1 || cfg.Endpoints[0].Bucket != "b" {
+		t.Fatalf("expected the loaded endpoint, got %+v", cfg.Endpoints)
+	}
+	cfg.Endpoints[0].Bucket = "renamed"
+
+	internal := cfg.internal()
+	endpoint := internal.Endpoints[0]
+	if endpoint.Bucket != "renamed" || endpoint.Checks == nil || !endpoint.Checks.PublicAccess {
+		t.Fatalf("expected the change to keep the loaded checks, got %+v", endpoint)
+	}
+}
@@ -0,0 +1,338 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default is registered on prometheus.DefaultRegisterer and backs the package-level
+// collectors and functions below, which predate Metrics. Collectors the default
+// registry already has are shared; a conflicting collector of the same name panics at
+// import, like prometheus.MustRegister, rather than leaving metrics silently unexported.
+var Default = mustNewMetrics(prometheus.DefaultRegisterer)
+
+// The collectors of Default
+var (
+	ValidationAttempts                = Default.ValidationAttempts
+	ValidationSuccess                 = Default.ValidationSuccess
+	ValidationFailures                = Default.ValidationFailures
+	ValidationDuration                = Default.ValidationDuration
+	KeysValid                         = Default.KeysValid
+	LastValidationTimestamp           = Default.LastValidationTimestamp
+	LastSuccessfulValidationTimestamp = Default.LastSuccessfulValidationTimestamp
+	ResponseTime                      = Default.ResponseTime
+	ResponseTimeMs                    = Default.ResponseTimeMs
+	OutageDuration                    = Default.OutageDuration
+	Outages                           = Default.Outages
+	ProbeSuccess                      = Default.ProbeSuccess
+	ProbeDuration                     = Default.ProbeDuration
+	ActiveRegionInfo                  = Default.ActiveRegionInfo
+	IPFamilyInfo                      = Default.IPFamilyInfo
+	ViaPrivateEndpoint                = Default.ViaPrivateEndpoint
+	ProviderUnreachable               = Default.ProviderUnreachable
+	ProviderKeysValidCount            = Default.ProviderKeysValidCount
+	ProviderKeysInvalidCount          = Default.ProviderKeysInvalidCount
+	EndpointsValid                    = Default.EndpointsValid
+	EndpointsInvalid                  = Default.EndpointsInvalid
+	ClockSkewDetected                 = Default.ClockSkewDetected
+	LatencyAnomaly                    = Default.LatencyAnomaly
+	LatencyBaseline                   = Default.LatencyBaseline
+	ProbeRequests                     = Default.ProbeRequests
+	ProbeRetries                      = Default.ProbeRetries
+	ProbeEstimatedCost                = Default.ProbeEstimatedCost
+	CredentialSlotValid               = Default.CredentialSlotValid
+	KeyAge                            = Default.KeyAge
+	KeyRotationDue                    = Default.KeyRotationDue
+	KeyRotations                      = Default.KeyRotations
+	Permission                        = Default.Permission
+	PermissionDrift                   = Default.PermissionDrift
+	ValidationsUnfinished             = Default.ValidationsUnfinished
+	ResultsSuppressed                 = Default.ResultsSuppressed
+	CyclesSkipped                     = Default.CyclesSkipped
+	ProbeHedges                       = Default.ProbeHedges
+	CanaryOrphansCleaned              = Default.CanaryOrphansCleaned
+	EndpointPairState                 = Default.EndpointPairState
+	EndpointPairLatencyDelta          = Default.EndpointPairLatencyDelta
+	AccessLoggingWorking              = Default.AccessLoggingWorking
+	ObjectLockCompliant               = Default.ObjectLockCompliant
+	BucketTagsCompliant               = Default.BucketTagsCompliant
+	CORSConfiguredCorrectly           = Default.CORSConfiguredCorrectly
+	WebsiteServing                    = Default.WebsiteServing
+	BucketPublic                      = Default.BucketPublic
+	KMSKeyUsable                      = Default.KMSKeyUsable
+	RoundTripIntegrityOK              = Default.RoundTripIntegrityOK
+	EndpointConfigured                = Default.EndpointConfigured
+	EndpointInfo                      = Default.EndpointInfo
+	ConfigWarning                     = Default.ConfigWarning
+	RestoreInProgress                 = Default.RestoreInProgress
+	RestoreCompleted                  = Default.RestoreCompleted
+	ObjectsByStorageClass             = Default.ObjectsByStorageClass
+	DiscoveredEndpoints               = Default.DiscoveredEndpoints
+	DiscoveryFailures                 = Default.DiscoveryFailures
+	EndpointAccountInfo               = Default.EndpointAccountInfo
+	FailureInjected                   = Default.FailureInjected
+	HTTPRateLimited                   = Default.HTTPRateLimited
+	HTTPAuthRejected                  = Default.HTTPAuthRejected
+	RemoteWriteFailures               = Default.RemoteWriteFailures
+	CloudWatchFailures                = Default.CloudWatchFailures
+	SnapshotExportFailures            = Default.SnapshotExportFailures
+)
+
+func mustNewMetrics(reg prometheus.Registerer) *Metrics {
+	m, err := NewMetrics(reg)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// RecordValidationAttempt calls RecordValidationAttempt on Default
+func RecordValidationAttempt(bucket string, success bool) {
+	Default.RecordValidationAttempt(bucket, success)
+}
+
+// RecordValidationSuccess calls RecordValidationSuccess on Default
+func RecordValidationSuccess(bucket string) {
+	Default.RecordValidationSuccess(bucket)
+}
+
+// RecordValidationFailure calls RecordValidationFailure on Default
+func RecordValidationFailure(bucket, errorType string) {
+	Default.RecordValidationFailure(bucket, errorType)
+}
+
+// CountValidationFailure calls CountValidationFailure on Default
+func CountValidationFailure(bucket, errorType string) {
+	Default.CountValidationFailure(bucket, errorType)
+}
+
+// SetLastValidationTime calls SetLastValidationTime on Default
+func SetLastValidationTime(bucket string, timestamp float64) {
+	Default.SetLastValidationTime(bucket, timestamp)
+}
+
+// SetLastSuccessfulValidationTime calls SetLastSuccessfulValidationTime on Default
+func SetLastSuccessfulValidationTime(bucket string, timestamp float64) {
+	Default.SetLastSuccessfulValidationTime(bucket, timestamp)
+}
+
+// RecordOutage calls RecordOutage on Default
+func RecordOutage(bucket string, duration time.Duration) {
+	Default.RecordOutage(bucket, duration)
+}
+
+// RecordResponseTime calls RecordResponseTime on Default
+func RecordResponseTime(bucket, operation string, duration time.Duration) {
+	Default.RecordResponseTime(bucket, operation, duration)
+}
+
+// RecordValidationDuration calls RecordValidationDuration on Default
+func RecordValidationDuration(bucket string, duration time.Duration) {
+	Default.RecordValidationDuration(bucket, duration)
+}
+
+// RecordProbeResult calls RecordProbeResult on Default
+func RecordProbeResult(bucket, depth string, success bool, duration time.Duration) {
+	Default.RecordProbeResult(bucket, depth, success, duration)
+}
+
+// SetActiveRegion calls SetActiveRegion on Default
+func SetActiveRegion(bucket, region string) {
+	Default.SetActiveRegion(bucket, region)
+}
+
+// RecordCheckResult calls RecordCheckResult on Default
+func RecordCheckResult(bucket, check string, passed bool) {
+	Default.RecordCheckResult(bucket, check, passed)
+}
+
+// RecordCheckPending calls RecordCheckPending on Default
+func RecordCheckPending(bucket, check string, pending bool) {
+	Default.RecordCheckPending(bucket, check, pending)
+}
+
+// RecordCheckCounts calls RecordCheckCounts on Default
+func RecordCheckCounts(bucket, check string, counts map[string]int) {
+	Default.RecordCheckCounts(bucket, check, counts)
+}
+
+// SetViaPrivateEndpoint calls SetViaPrivateEndpoint on Default
+func SetViaPrivateEndpoint(bucket string, private bool) {
+	Default.SetViaPrivateEndpoint(bucket, private)
+}
+
+// SetIPFamily calls SetIPFamily on Default
+func SetIPFamily(bucket, family string) {
+	Default.SetIPFamily(bucket, family)
+}
+
+// SetClockSkewDetected calls SetClockSkewDetected on Default
+func SetClockSkewDetected(bucket string, detected bool) {
+	Default.SetClockSkewDetected(bucket, detected)
+}
+
+// SetLatencyAnomaly calls SetLatencyAnomaly on Default
+func SetLatencyAnomaly(bucket string, anomalous bool, baselineMs float64) {
+	Default.SetLatencyAnomaly(bucket, anomalous, baselineMs)
+}
+
+// RecordProbeRequest calls RecordProbeRequest on Default
+func RecordProbeRequest(bucket, operation string) {
+	Default.RecordProbeRequest(bucket, operation)
+}
+
+// RecordProbeRetries calls RecordProbeRetries on Default
+func RecordProbeRetries(bucket string, retries int) {
+	Default.RecordProbeRetries(bucket, retries)
+}
+
+// SetProbeEstimatedCost calls SetProbeEstimatedCost on Default
+func SetProbeEstimatedCost(bucket string, usd float64) {
+	Default.SetProbeEstimatedCost(bucket, usd)
+}
+
+// SetCredentialSlotValid calls SetCredentialSlotValid on Default
+func SetCredentialSlotValid(bucket, slot string, valid bool) {
+	Default.SetCredentialSlotValid(bucket, slot, valid)
+}
+
+// UnregisterCredentialSlot calls UnregisterCredentialSlot on Default
+func UnregisterCredentialSlot(bucket, slot string) {
+	Default.UnregisterCredentialSlot(bucket, slot)
+}
+
+// SetKeyAge calls SetKeyAge on Default
+func SetKeyAge(bucket string, age time.Duration, hasPolicy, rotationDue bool) {
+	Default.SetKeyAge(bucket, age, hasPolicy, rotationDue)
+}
+
+// SetPermission calls SetPermission on Default
+func SetPermission(bucket, operation string, known, allowed bool) {
+	Default.SetPermission(bucket, operation, known, allowed)
+}
+
+// SetPermissionDrift calls SetPermissionDrift on Default
+func SetPermissionDrift(bucket, operation string, known, drifted bool) {
+	Default.SetPermissionDrift(bucket, operation, known, drifted)
+}
+
+// RecordValidationUnfinished calls RecordValidationUnfinished on Default
+func RecordValidationUnfinished(bucket, reason string) {
+	Default.RecordValidationUnfinished(bucket, reason)
+}
+
+// RecordProbeHedge calls RecordProbeHedge on Default
+func RecordProbeHedge(bucket, winner string) {
+	Default.RecordProbeHedge(bucket, winner)
+}
+
+// SetEndpointPair calls SetEndpointPair on Default
+func SetEndpointPair(pair, oldEndpoint, newEndpoint, state string, latencyDelta time.Duration, bothOK bool) {
+	Default.SetEndpointPair(pair, oldEndpoint, newEndpoint, state, latencyDelta, bothOK)
+}
+
+// RecordCanaryOrphansCleaned calls RecordCanaryOrphansCleaned on Default
+func RecordCanaryOrphansCleaned(bucket string, count int) {
+	Default.RecordCanaryOrphansCleaned(bucket, count)
+}
+
+// RecordCycleSkipped calls RecordCycleSkipped on Default
+func RecordCycleSkipped(bucket, depth string) {
+	Default.RecordCycleSkipped(bucket, depth)
+}
+
+// RecordResultSuppressed calls RecordResultSuppressed on Default
+func RecordResultSuppressed(bucket, rule string) {
+	Default.RecordResultSuppressed(bucket, rule)
+}
+
+// RecordKeyRotation calls RecordKeyRotation on Default
+func RecordKeyRotation(bucket string, success bool) {
+	Default.RecordKeyRotation(bucket, success)
+}
+
+// RecordRateLimited calls RecordRateLimited on Default
+func RecordRateLimited(route string) {
+	Default.RecordRateLimited(route)
+}
+
+// RecordRemoteWriteFailure calls RecordRemoteWriteFailure on Default
+func RecordRemoteWriteFailure() {
+	Default.RecordRemoteWriteFailure()
+}
+
+// RecordAuthRejected calls RecordAuthRejected on Default
+func RecordAuthRejected(reason string) {
+	Default.RecordAuthRejected(reason)
+}
+
+// RecordCloudWatchFailure calls RecordCloudWatchFailure on Default
+func RecordCloudWatchFailure(account string) {
+	Default.RecordCloudWatchFailure(account)
+}
+
+// RecordSnapshotExportFailure calls RecordSnapshotExportFailure on Default
+func RecordSnapshotExportFailure() {
+	Default.RecordSnapshotExportFailure()
+}
+
+// SetProviderUnreachable calls SetProviderUnreachable on Default
+func SetProviderUnreachable(host string, unreachable bool) {
+	Default.SetProviderUnreachable(host, unreachable)
+}
+
+// SetProviderKeyCounts calls SetProviderKeyCounts on Default
+func SetProviderKeyCounts(host string, valid, invalid int) {
+	Default.SetProviderKeyCounts(host, valid, invalid)
+}
+
+// SetEndpointCounts calls SetEndpointCounts on Default
+func SetEndpointCounts(valid, invalid int) {
+	Default.SetEndpointCounts(valid, invalid)
+}
+
+// UnregisterProvider calls UnregisterProvider on Default
+func UnregisterProvider(host string) {
+	Default.UnregisterProvider(host)
+}
+
+// RegisterEndpoint calls RegisterEndpoint on Default
+func RegisterEndpoint(bucket string) {
+	Default.RegisterEndpoint(bucket)
+}
+
+// SetConfigWarnings calls SetConfigWarnings on Default
+func SetConfigWarnings(counts map[string]int) {
+	Default.SetConfigWarnings(counts)
+}
+
+// SetDiscoveredEndpoints calls SetDiscoveredEndpoints on Default
+func SetDiscoveredEndpoints(source string, count int) {
+	Default.SetDiscoveredEndpoints(source, count)
+}
+
+// RecordDiscoveryFailure calls RecordDiscoveryFailure on Default
+func RecordDiscoveryFailure(source string) {
+	Default.RecordDiscoveryFailure(source)
+}
+
+// SetEndpointAccount calls SetEndpointAccount on Default
+func SetEndpointAccount(bucket, accountID string) {
+	Default.SetEndpointAccount(bucket, accountID)
+}
+
+// SetFailureInjected calls SetFailureInjected on Default
+func SetFailureInjected(bucket, errorType string) {
+	Default.SetFailureInjected(bucket, errorType)
+}
+
+// SetEndpointInfo calls SetEndpointInfo on Default
+func SetEndpointInfo(bucket string, annotations map[string]string) {
+	Default.SetEndpointInfo(bucket, annotations)
+}
+
+// UnregisterEndpoint calls UnregisterEndpoint on Default
+func UnregisterEndpoint(bucket string) {
+	Default.UnregisterEndpoint(bucket)
+}
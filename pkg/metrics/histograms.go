@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultResponseTimeBuckets spans 5ms to about 10s, covering fast in-region calls as
+// well as slow cross-continent ones
+var DefaultResponseTimeBuckets = prometheus.ExponentialBuckets(0.005, 2, 12)
+
+// Native histogram settings: buckets grow by at most 10% per step, and a histogram that
+// exceeds the bucket limit lowers its resolution, resetting at most once an hour
+const (
+	nativeBucketFactor     = 1.1
+	nativeMaxBucketNumber  = 160
+	nativeMinResetDuration = time.Hour
+)
+
+// HistogramOptions configures the duration histograms
+type HistogramOptions struct {
+	// ResponseTimeBuckets are the bounds of s3_response_time_seconds, in seconds;
+	// empty uses DefaultResponseTimeBuckets
+	ResponseTimeBuckets []float64
+	// LegacyResponseTimeMs also exports s3_response_time_milliseconds for existing dashboards
+	LegacyResponseTimeMs bool
+	// Native adds native histograms to s3_response_time_seconds and
+	// s3_validation_duration_seconds. Classic buckets are kept for scrapers without
+	// native histogram support.
+	Native bool
+}
+
+// ConfigureHistograms re-registers the duration histograms of Default with opts. Call
+// it once at startup, before anything is recorded; recorded observations are discarded.
+func ConfigureHistograms(opts HistogramOptions) error {
+	if err := Default.ConfigureHistograms(opts); err != nil {
+		return err
+	}
+	ResponseTime = Default.ResponseTime
+	ValidationDuration = Default.ValidationDuration
+	return nil
+}
+
+// ConfigureHistograms re-registers the duration histograms with opts. Call it once
+// before anything is recorded; recorded observations are discarded.
+func (m *Metrics) ConfigureHistograms(opts HistogramOptions) error {
+	for i, bound := range opts.ResponseTimeBuckets {
+		if bound <= 0 || (i > 0 && bound <= opts.ResponseTimeBuckets[i-1]) {
+			return fmt.Errorf("response time buckets must be positive and increasing, got %v", opts.ResponseTimeBuckets)
+		}
+	}
+
+	responseTime := prometheus.NewHistogramVec(responseTimeOpts(opts), []string{"bucket", "operation"})
+	validationDuration := prometheus.NewHistogramVec(validationDurationOpts(opts), []string{"bucket"})
+
+	if m.reg != nil {
+		m.reg.Unregister(m.ResponseTime)
+		m.reg.Unregister(m.ValidationDuration)
+		m.reg.Unregister(m.ResponseTimeMs)
+		for _, collector := range []prometheus.Collector{responseTime, validationDuration} {
+			if err := m.reg.Register(collector); err != nil {
+				return fmt.Errorf("failed to register duration histogram: %w", err)
+			}
+		}
+		if opts.LegacyResponseTimeMs {
+			if err := m.reg.Register(m.ResponseTimeMs); err != nil {
+				return fmt.Errorf("failed to register s3_response_time_milliseconds: %w", err)
+			}
+		}
+	}
+
+	m.ResponseTime = responseTime
+	m.ValidationDuration = validationDuration
+	m.legacyResponseTimeMs = opts.LegacyResponseTimeMs
+	return nil
+}
+
+func responseTimeOpts(opts HistogramOptions) prometheus.HistogramOpts {
+	buckets := opts.ResponseTimeBuckets
+	if len(buckets) == 0 {
+		buckets = DefaultResponseTimeBuckets
+	}
+	return withNative(prometheus.HistogramOpts{
+		Name:    "s3_response_time_seconds",
+		Help:    "Response time of S3 operations in seconds",
+		Buckets: append([]float64(nil), buckets...),
+	}, opts.Native)
+}
+
+func validationDurationOpts(opts HistogramOptions) prometheus.HistogramOpts {
+	return withNative(prometheus.HistogramOpts{
+		Name:    "s3_validation_duration_seconds",
+		Help:    "Duration of S3 validation operations in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, opts.Native)
+}
+
+// withNative enables native histograms on o when native is set
+func withNative(o prometheus.HistogramOpts, native bool) prometheus.HistogramOpts {
+	if native {
+		o.NativeHistogramBucketFactor = nativeBucketFactor
+		o.NativeHistogramMaxBucketNumber = nativeMaxBucketNumber
+		o.NativeHistogramMinResetDuration = nativeMinResetDuration
+	}
+	return o
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConfigureHistograms(t *testing.T) {
+	t.Cleanup(func() {
+		if err := ConfigureHistograms(HistogramOptions{}); err != nil {
+			t.Fatalf("failed to restore default histograms: %v", err)
+		}
+	})
+
+	err := ConfigureHistograms(HistogramOptions{
+		ResponseTimeBuckets:  []float64{0.1, 1},
+		LegacyResponseTimeMs: true,
+		Native:               true,
+	})
+	if err != nil {
+		t.Fatalf("expected histograms to be configured, got %v", err)
+	}
+	ResponseTimeMs.Reset()
+
+	RecordResponseTime("bucket-a", "ListObjectsV2", 250*time.Millisecond)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	found := map[string]bool{}
+	for _, family := range families {
+		switch family.GetName() {
+		case "s3_response_time_seconds":
+			histogram := family.GetMetric()[0].GetHistogram()
+			found[family.GetName()] = true
+			if len(histogram.GetBucket()) != 2 || histogram.GetSampleSum() != 0.25 {
+				t.Fatalf("expected the configured buckets and a sample in seconds, got %v", histogram)
+			}
+			if histogram.GetSchema() == 0 && len(histogram.GetPositiveSpan()) == 0 {
+				t.Fatalf("expected a native histogram, got %v", histogram)
+			}
+		case "s3_response_time_milliseconds":
+			found[family.GetName()] = true
+			if sum := family.GetMetric()[0].GetHistogram().GetSampleSum(); sum != 250 {
+				t.Fatalf("expected the compat metric in milliseconds, got %v", sum)
+			}
+		}
+	}
+	if !found["s3_response_time_seconds"] || !found["s3_response_time_milliseconds"] {
+		t.Fatalf("expected both response time metrics to be exported, got %v", found)
+	}
+}
+
+func TestConfigureHistogramsDefaultDropsMilliseconds(t *testing.T) {
+	if err := ConfigureHistograms(HistogramOptions{}); err != nil {
+		t.Fatalf("expected default histograms, got %v", err)
+	}
+	ResponseTimeMs.Reset()
+	RecordResponseTime("bucket-a", "ListObjectsV2", 10*time.Millisecond)
+
+	if count := testutil.CollectAndCount(ResponseTimeMs); count != 0 {
+		t.Fatalf("expected the millisecond metric to stay empty without the compat flag, got %d series", count)
+	}
+	if err := ConfigureHistograms(HistogramOptions{ResponseTimeBuckets: []float64{1, 0.5}}); err == nil {
+		t.Fatalf("expected decreasing buckets to be rejected")
+	}
+}
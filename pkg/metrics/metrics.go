@@ -1,134 +1,1133 @@
 package metrics
 
 import (
+	"errors"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-var (
+// Metrics holds the exporter's collectors. Create it with NewMetrics to register them on
+// a registry of your own, e.g. when embedding the exporter in another program.
+type Metrics struct {
 	// ValidationAttempts tracks the total number of validation attempts
-	ValidationAttempts = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "s3_validation_attempts_total",
-			Help: "Total number of S3 key validation attempts",
-		},
-		[]string{"bucket", "status"},
-	)
+	ValidationAttempts *prometheus.CounterVec
 
 	// ValidationSuccess tracks the number of successful validations
-	ValidationSuccess = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "s3_validation_success_total",
-			Help: "Total number of successful S3 validations",
-		},
-		[]string{"bucket"},
-	)
+	ValidationSuccess *prometheus.CounterVec
 
 	// ValidationFailures tracks the number of failed validations
-	ValidationFailures = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "s3_validation_failures_total",
-			Help: "Total number of failed S3 validations",
-		},
-		[]string{"bucket", "error_type"},
-	)
+	ValidationFailures *prometheus.CounterVec
 
 	// ValidationDuration tracks the duration of validation operations
-	ValidationDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "s3_validation_duration_seconds",
-			Help:    "Duration of S3 validation operations in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"bucket"},
-	)
+	ValidationDuration *prometheus.HistogramVec
 
 	// KeysValid indicates whether the current keys are valid (1 = valid, 0 = invalid)
-	KeysValid = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "s3_keys_valid",
-			Help: "Whether the S3 keys are currently valid (1 = valid, 0 = invalid)",
-		},
-		[]string{"bucket"},
-	)
+	KeysValid *prometheus.GaugeVec
 
 	// LastValidationTimestamp tracks when the last validation occurred
-	LastValidationTimestamp = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "s3_last_validation_timestamp_seconds",
-			Help: "Unix timestamp of the last validation attempt",
-		},
-		[]string{"bucket"},
-	)
+	LastValidationTimestamp *prometheus.GaugeVec
+
+	// LastSuccessfulValidationTimestamp tracks when the keys were last known to work
+	LastSuccessfulValidationTimestamp *prometheus.GaugeVec
 
 	// ResponseTime tracks the response time of S3 operations
-	ResponseTime = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "s3_response_time_milliseconds",
-			Help:    "Response time of S3 operations in milliseconds",
-			Buckets: prometheus.ExponentialBuckets(10, 2, 8), // 10ms to 1280ms
-		},
-		[]string{"bucket", "operation"},
-	)
+	ResponseTime *prometheus.HistogramVec
+
+	// ResponseTimeMs is the millisecond version of ResponseTime, exported only with
+	// HistogramOptions.LegacyResponseTimeMs
+	ResponseTimeMs *prometheus.HistogramVec
+
+	// OutageDuration tracks how long endpoints failed before they recovered
+	OutageDuration *prometheus.HistogramVec
+
+	// Outages counts ended outages per endpoint
+	Outages *prometheus.CounterVec
+
+	// ProbeSuccess reports the outcome of the latest probe per depth (1 = passed, 0 = failed)
+	ProbeSuccess *prometheus.GaugeVec
+
+	// ProbeDuration tracks how long probes take per depth
+	ProbeDuration *prometheus.HistogramVec
+
+	// ActiveRegionInfo exposes the region that last validated successfully for each bucket
+	ActiveRegionInfo *prometheus.GaugeVec
+
+	// IPFamilyInfo exposes the address family of the connection used by the last validation
+	IPFamilyInfo *prometheus.GaugeVec
+
+	// ViaPrivateEndpoint tracks whether the last validation reached the endpoint over a
+	// private address
+	ViaPrivateEndpoint *prometheus.GaugeVec
+
+	// ProviderUnreachable flags providers whose endpoints all failed with connectivity errors
+	ProviderUnreachable *prometheus.GaugeVec
+
+	// ProviderKeysValidCount counts endpoints per provider whose keys last validated successfully
+	ProviderKeysValidCount *prometheus.GaugeVec
+
+	// ProviderKeysInvalidCount counts endpoints per provider whose keys last failed validation
+	ProviderKeysInvalidCount *prometheus.GaugeVec
+
+	// EndpointsValid counts endpoints whose keys last validated successfully
+	EndpointsValid prometheus.Gauge
+
+	// EndpointsInvalid counts endpoints whose keys last failed validation
+	EndpointsInvalid prometheus.Gauge
+
+	// ClockSkewDetected flags endpoints whose last validation was rejected for request time skew
+	ClockSkewDetected *prometheus.GaugeVec
+
+	// LatencyAnomaly flags endpoints whose latest validation was far slower than their baseline
+	LatencyAnomaly *prometheus.GaugeVec
+
+	// LatencyBaseline exposes the rolling median validation latency used for anomaly detection
+	LatencyBaseline *prometheus.GaugeVec
+
+	// ProbeRequests counts the S3 requests made by validation probes
+	ProbeRequests *prometheus.CounterVec
+
+	// ProbeRetries counts the attempts the SDK retried during validations
+	ProbeRetries *prometheus.CounterVec
+
+	// ProbeEstimatedCost is the projected monthly request cost of the bucket's probes
+	ProbeEstimatedCost *prometheus.GaugeVec
+
+	// CredentialSlotValid reports key validity per credential slot (primary or secondary)
+	CredentialSlotValid *prometheus.GaugeVec
+
+	// KeyAge exposes how long ago the endpoint's access key was created
+	KeyAge *prometheus.GaugeVec
+
+	// KeyRotationDue flags access keys older than their rotation policy
+	KeyRotationDue *prometheus.GaugeVec
+
+	// KeyRotations counts automatic access key rotations by outcome
+	KeyRotations *prometheus.CounterVec
+
+	// Permission exposes the permission matrix found by the latest discovery run
+	Permission *prometheus.GaugeVec
+
+	// PermissionDrift flags operations whose discovered permission contradicts expected_permissions
+	PermissionDrift *prometheus.GaugeVec
+
+	// ValidationsUnfinished counts validations cut off by a deadline or cancellation
+	ValidationsUnfinished *prometheus.CounterVec
+
+	// ProbeHedges counts second probes sent to endpoints slower than their p95, by the probe whose answer was used
+	ProbeHedges *prometheus.CounterVec
+
+	// EndpointPairState exposes which side of an endpoint pair validated last, such as
+	// the old and new gateway of a migration
+	EndpointPairState *prometheus.GaugeVec
+
+	// EndpointPairLatencyDelta tracks how much slower the new endpoint of a pair answered
+	EndpointPairLatencyDelta *prometheus.GaugeVec
+
+	// CanaryOrphansCleaned counts leaked canary objects deleted by the janitor
+	CanaryOrphansCleaned *prometheus.CounterVec
+
+	// CyclesSkipped counts scheduled runs that skipped an endpoint whose earlier probe was still running
+	CyclesSkipped *prometheus.CounterVec
+
+	// ResultsSuppressed counts failed results a result rule kept from the other metrics and notifications
+	ResultsSuppressed *prometheus.CounterVec
+
+	// AccessLoggingWorking reports whether the latest access log canary showed up in the server access logs
+	AccessLoggingWorking *prometheus.GaugeVec
+
+	// ObjectLockCompliant reports whether the bucket enforces the expected Object Lock retention
+	ObjectLockCompliant *prometheus.GaugeVec
+
+	// BucketTagsCompliant reports whether the bucket carries every required tag
+	BucketTagsCompliant *prometheus.GaugeVec
+
+	// CORSConfiguredCorrectly reports whether the bucket's CORS rules and preflight match expectations
+	CORSConfiguredCorrectly *prometheus.GaugeVec
+
+	// WebsiteServing reports whether the bucket's static website answers with HTTP 200
+	WebsiteServing *prometheus.GaugeVec
+
+	// BucketPublic flags buckets whose ACL or bucket policy grants public access
+	BucketPublic *prometheus.GaugeVec
+
+	// RoundTripIntegrityOK reports whether the latest deep probe object read back as it was written
+	RoundTripIntegrityOK *prometheus.GaugeVec
+
+	// KMSKeyUsable reports whether the SSE-KMS key can still encrypt and decrypt objects in the bucket
+	KMSKeyUsable *prometheus.GaugeVec
 
 	// EndpointConfigured marks configured endpoints so users can discover them via metrics
-	EndpointConfigured = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "s3_endpoint_configured",
-			Help: "Configured S3 endpoints (always 1 for configured endpoints)",
-		},
-		[]string{"bucket"},
-	)
-)
+	EndpointConfigured *prometheus.GaugeVec
+
+	// EndpointInfo carries the well-known endpoint annotations as labels for joins in alerts
+	EndpointInfo *prometheus.GaugeVec
+
+	// ConfigWarning counts risky settings found in the configuration, per reason
+	ConfigWarning *prometheus.GaugeVec
+
+	// RestoreInProgress reports whether the watched archived object is being restored
+	RestoreInProgress *prometheus.GaugeVec
+
+	// RestoreCompleted reports whether a restored copy of the watched archived object is available
+	RestoreCompleted *prometheus.GaugeVec
+
+	// ObjectsByStorageClass counts the objects sampled by the inventory check per storage class
+	ObjectsByStorageClass *prometheus.GaugeVec
+
+	// DiscoveredEndpoints is the number of endpoints created by discovery per source
+	DiscoveredEndpoints *prometheus.GaugeVec
+
+	// DiscoveryFailures counts discovery runs that could not list the buckets or accounts
+	DiscoveryFailures *prometheus.CounterVec
+
+	// EndpointAccountInfo maps a bucket to the AWS account owning it
+	EndpointAccountInfo *prometheus.GaugeVec
+
+	// FailureInjected marks endpoints whose results are synthetic failures
+	FailureInjected *prometheus.GaugeVec
+
+	// HTTPRateLimited counts API requests rejected by the rate limiter
+	HTTPRateLimited *prometheus.CounterVec
+
+	// HTTPAuthRejected counts API requests refused by the auth middleware
+	HTTPAuthRejected *prometheus.CounterVec
+
+	// RemoteWriteFailures counts pushes to the remote write receiver that failed
+	RemoteWriteFailures prometheus.Counter
+
+	// CloudWatchFailures counts PutMetricData calls that failed, by target account
+	CloudWatchFailures *prometheus.CounterVec
+
+	// SnapshotExportFailures counts snapshots that could not be written to S3
+	SnapshotExportFailures prometheus.Counter
+
+	// reg is the registerer the collectors were registered on; nil for none
+	reg prometheus.Registerer
+	// legacyResponseTimeMs is set by ConfigureHistograms
+	legacyResponseTimeMs bool
+
+	checks  map[string]checkGauge
+	pending map[string]*prometheus.GaugeVec
+	counts  map[string]*prometheus.GaugeVec
+}
+
+// NewMetrics creates the collectors and registers them on reg; a nil reg leaves them
+// unregistered. Collectors reg already has, e.g. from an earlier NewMetrics on the same
+// registry, are reused instead of panicking like promauto does. Collectors conflicting
+// with different ones of the same name are reported in the error but still returned
+// unregistered, so the Metrics is usable either way and only those go unexported.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	r := &registrar{reg: reg}
+	m := &Metrics{
+		reg: reg,
+		ValidationAttempts: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_validation_attempts_total",
+				Help: "Total number of S3 key validation attempts",
+			},
+			[]string{"bucket", "status"},
+		)),
+		ValidationSuccess: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_validation_success_total",
+				Help: "Total number of successful S3 validations",
+			},
+			[]string{"bucket"},
+		)),
+		ValidationFailures: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_validation_failures_total",
+				Help: "Total number of failed S3 validations",
+			},
+			[]string{"bucket", "error_type"},
+		)),
+		ValidationDuration: register(r, prometheus.NewHistogramVec(validationDurationOpts(HistogramOptions{}), []string{"bucket"})),
+		KeysValid: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_keys_valid",
+				Help: "Whether the S3 keys are currently valid (1 = valid, 0 = invalid)",
+			},
+			[]string{"bucket"},
+		)),
+		LastValidationTimestamp: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_last_validation_timestamp_seconds",
+				Help: "Unix timestamp of the last validation attempt",
+			},
+			[]string{"bucket"},
+		)),
+		LastSuccessfulValidationTimestamp: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_last_successful_validation_timestamp_seconds",
+				Help: "Unix timestamp of the last successful validation",
+			},
+			[]string{"bucket"},
+		)),
+		ResponseTime: register(r, prometheus.NewHistogramVec(responseTimeOpts(HistogramOptions{}), []string{"bucket", "operation"})),
+		ResponseTimeMs: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "s3_response_time_milliseconds",
+				Help:    "Response time of S3 operations in milliseconds (deprecated, use s3_response_time_seconds)",
+				Buckets: prometheus.ExponentialBuckets(10, 2, 8), // 10ms to 1280ms
+			},
+			[]string{"bucket", "operation"},
+		),
+		OutageDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "s3_outage_duration_seconds",
+				Help: "Length of ended outages in seconds, from the first failed to the next successful validation",
+				// 1 minute to about 2 days
+				Buckets: prometheus.ExponentialBuckets(60, 2, 12),
+			},
+			[]string{"bucket"},
+		)),
+		Outages: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_outages_total",
+				Help: "Total number of outages that ended with a successful validation",
+			},
+			[]string{"bucket"},
+		)),
+		ProbeSuccess: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_probe_success",
+				Help: "Whether the latest S3 probe at the given depth passed (1 = passed, 0 = failed)",
+			},
+			[]string{"bucket", "depth"},
+		)),
+		ProbeDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "s3_probe_duration_seconds",
+				Help:    "Duration of S3 probes in seconds, by probe depth",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"bucket", "depth"},
+		)),
+		ActiveRegionInfo: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_active_region_info",
+				Help: "Region that last validated successfully for the endpoint (always 1 for the active region)",
+			},
+			[]string{"bucket", "region"},
+		)),
+		IPFamilyInfo: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_ip_family_info",
+				Help: "Address family (ipv4 or ipv6) used by the last validation of the endpoint (always 1 for the current family)",
+			},
+			[]string{"bucket", "family"},
+		)),
+		ViaPrivateEndpoint: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_via_private_endpoint",
+				Help: "Whether the last validation connected to a private (VPC endpoint) address (1) or a public one (0)",
+			},
+			[]string{"bucket"},
+		)),
+		ProviderUnreachable: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_provider_unreachable",
+				Help: "Whether every endpoint of the provider failed with connectivity errors in the last run (1 = unreachable, 0 = reachable)",
+			},
+			[]string{"host"},
+		)),
+		ProviderKeysValidCount: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_provider_keys_valid_count",
+				Help: "Number of endpoints on the provider whose keys are currently valid",
+			},
+			[]string{"host"},
+		)),
+		ProviderKeysInvalidCount: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_provider_keys_invalid_count",
+				Help: "Number of endpoints on the provider whose keys are currently invalid",
+			},
+			[]string{"host"},
+		)),
+		EndpointsValid: register(r, prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoints_valid_total",
+				Help: "Number of endpoints whose keys are currently valid",
+			},
+		)),
+		EndpointsInvalid: register(r, prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoints_invalid_total",
+				Help: "Number of endpoints whose keys are currently invalid",
+			},
+		)),
+		ClockSkewDetected: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_clock_skew_detected",
+				Help: "Whether the last validation was rejected because the exporter clock is skewed (1 = skewed, 0 = ok)",
+			},
+			[]string{"bucket"},
+		)),
+		LatencyAnomaly: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_latency_anomaly",
+				Help: "Whether the latest validation latency exceeded the anomaly factor times the rolling median (1 = anomalous, 0 = normal)",
+			},
+			[]string{"bucket"},
+		)),
+		LatencyBaseline: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_latency_baseline_milliseconds",
+				Help: "Rolling median of recent successful validation latencies",
+			},
+			[]string{"bucket"},
+		)),
+		ProbeRequests: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_probe_requests_total",
+				Help: "Total number of S3 requests made by validation probes",
+			},
+			[]string{"bucket", "operation"},
+		)),
+		ProbeRetries: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_probe_retries_total",
+				Help: "Total number of S3 request attempts retried by the SDK during validations",
+			},
+			[]string{"bucket"},
+		)),
+		ProbeEstimatedCost: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_probe_estimated_cost_usd",
+				Help: "Estimated monthly cost in USD of the requests made by validation probes, at the configured pricing",
+			},
+			[]string{"bucket"},
+		)),
+		CredentialSlotValid: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_credential_slot_valid",
+				Help: "Whether the credentials in the given slot are currently valid (1 = valid, 0 = invalid)",
+			},
+			[]string{"bucket", "slot"},
+		)),
+		KeyAge: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_key_age_seconds",
+				Help: "Age of the endpoint's access key in seconds, from key_created_at or IAM",
+			},
+			[]string{"bucket"},
+		)),
+		KeyRotationDue: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_key_rotation_due",
+				Help: "Whether the access key is older than its rotation policy allows (1 = rotation due, 0 = within policy)",
+			},
+			[]string{"bucket"},
+		)),
+		KeyRotations: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_key_rotations_total",
+				Help: "Total number of automatic access key rotations by outcome (success or failure)",
+			},
+			[]string{"bucket", "outcome"},
+		)),
+		Permission: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_permission",
+				Help: "Whether the credentials may call the S3 operation, from the latest discovery run (1 = allowed, 0 = denied)",
+			},
+			[]string{"bucket", "operation"},
+		)),
+		PermissionDrift: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_permission_drift",
+				Help: "Whether the discovered permission for the operation contradicts expected_permissions (1 = drift, 0 = as expected)",
+			},
+			[]string{"bucket", "operation"},
+		)),
+		ValidationsUnfinished: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_validation_unfinished_total",
+				Help: "Total number of validations that did not finish before their run was cut off, by reason (timed_out or canceled)",
+			},
+			[]string{"bucket", "reason"},
+		)),
+		ProbeHedges: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_probe_hedges_total",
+				Help: "Total number of hedged probes sent because the first probe was slower than the endpoint's p95, by the probe whose answer was used (primary or hedge)",
+			},
+			[]string{"bucket", "winner"},
+		)),
+		EndpointPairState: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoint_pair_state",
+				Help: "Outcome of the latest validations of an endpoint pair: both_ok, only_old_ok, only_new_ok or both_failed (always 1 for the current state)",
+			},
+			[]string{"pair", "old", "new", "state"},
+		)),
+		EndpointPairLatencyDelta: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoint_pair_latency_delta_seconds",
+				Help: "Validation duration of the new endpoint of a pair minus that of the old one, from the latest validations where both succeeded",
+			},
+			[]string{"pair"},
+		)),
+		CanaryOrphansCleaned: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_canary_orphans_cleaned_total",
+				Help: "Total number of leaked canary objects older than CANARY_TTL deleted from the bucket",
+			},
+			[]string{"bucket"},
+		)),
+		CyclesSkipped: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_validation_cycles_skipped_total",
+				Help: "Total number of scheduled validation runs that skipped the endpoint because its probe from an earlier run was still running, by depth (shallow or deep)",
+			},
+			[]string{"bucket", "depth"},
+		)),
+		ResultsSuppressed: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_results_suppressed_total",
+				Help: "Total number of failed validation results suppressed by a result rule, by rule name",
+			},
+			[]string{"bucket", "rule"},
+		)),
+		AccessLoggingWorking: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_access_logging_working",
+				Help: "Whether the latest access log canary was found in the bucket's server access logs (1 = delivered, 0 = missing)",
+			},
+			[]string{"bucket"},
+		)),
+		ObjectLockCompliant: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_object_lock_compliant",
+				Help: "Whether the bucket has Object Lock enabled with the expected default retention (1 = compliant, 0 = not compliant)",
+			},
+			[]string{"bucket"},
+		)),
+		BucketTagsCompliant: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_bucket_tags_compliant",
+				Help: "Whether the bucket carries every required tag with a non-empty value (1 = compliant, 0 = tags missing)",
+			},
+			[]string{"bucket"},
+		)),
+		CORSConfiguredCorrectly: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_cors_configured_correctly",
+				Help: "Whether the bucket's CORS rules match the expected policy and a preflight from the configured origin is allowed (1 = correct, 0 = mismatch)",
+			},
+			[]string{"bucket"},
+		)),
+		WebsiteServing: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_website_serving",
+				Help: "Whether the bucket's static website endpoint serves the index document with HTTP 200 (1 = serving, 0 = down)",
+			},
+			[]string{"bucket"},
+		)),
+		BucketPublic: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_bucket_public",
+				Help: "Whether the bucket ACL or policy grants public access (1 = public, 0 = private)",
+			},
+			[]string{"bucket"},
+		)),
+		RoundTripIntegrityOK: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_roundtrip_integrity_ok",
+				Help: "Whether the latest deep probe object read back with the content, metadata and encryption it was written with (1 = identical, 0 = corrupted)",
+			},
+			[]string{"bucket"},
+		)),
+		KMSKeyUsable: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_kms_key_usable",
+				Help: "Whether an SSE-KMS encrypted probe object could be written and read back (1 = usable, 0 = unusable)",
+			},
+			[]string{"bucket"},
+		)),
+		EndpointConfigured: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoint_configured",
+				Help: "Configured S3 endpoints (always 1 for configured endpoints)",
+			},
+			[]string{"bucket"},
+		)),
+		EndpointInfo: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoint_info",
+				Help: "Endpoint annotations: owner, runbook URL and description (always 1)",
+			},
+			[]string{"bucket", "owner", "runbook_url", "description"},
+		)),
+		ConfigWarning: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_config_warning",
+				Help: "Number of configuration warnings per reason found at startup",
+			},
+			[]string{"reason"},
+		)),
+		RestoreInProgress: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_restore_in_progress",
+				Help: "Whether a restore of the watched archived object is in progress (1 = in progress)",
+			},
+			[]string{"bucket"},
+		)),
+		RestoreCompleted: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_restore_completed",
+				Help: "Whether a restored copy of the watched archived object is available (1 = available)",
+			},
+			[]string{"bucket"},
+		)),
+		ObjectsByStorageClass: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_objects_by_storage_class",
+				Help: "Number of objects per storage class in the latest inventory sample",
+			},
+			[]string{"bucket", "class"},
+		)),
+		DiscoveredEndpoints: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_discovered_endpoints",
+				Help: "Number of endpoints created by discovery (tags or organizations)",
+			},
+			[]string{"source"},
+		)),
+		DiscoveryFailures: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "s3_discovery_failures_total",
+				Help: "Total number of discovery runs that failed to list the buckets or accounts",
+			},
+			[]string{"source"},
+		)),
+		EndpointAccountInfo: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_endpoint_account_info",
+				Help: "AWS account of the endpoint; always 1, join on bucket to group by account_id",
+			},
+			[]string{"bucket", "account_id"},
+		)),
+		FailureInjected: register(r, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "s3_failure_injected",
+				Help: "Whether the endpoint reports an injected failure instead of probing (1 while injected)",
+			},
+			[]string{"bucket", "error_type"},
+		)),
+		HTTPRateLimited: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_rate_limited_total",
+				Help: "Total number of API requests rejected with 429 by the rate limiter",
+			},
+			[]string{"route"},
+		)),
+		HTTPAuthRejected: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_auth_rejected_total",
+				Help: "Total number of API requests refused by authentication (401), authorization or the client allowlist (403), or an unavailable identity provider (503)",
+			},
+			[]string{"reason"},
+		)),
+		RemoteWriteFailures: register(r, prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "remote_write_failures_total",
+				Help: "Total number of failed pushes to the Prometheus remote write receiver",
+			},
+		)),
+		CloudWatchFailures: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cloudwatch_publish_failures_total",
+				Help: "Total number of failed CloudWatch PutMetricData calls by account (empty for the configured keys' account)",
+			},
+			[]string{"account"},
+		)),
+		SnapshotExportFailures: register(r, prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "snapshot_export_failures_total",
+				Help: "Total number of failed snapshot writes to S3",
+			},
+		)),
+	}
+	m.checks = map[string]checkGauge{
+		"access_log":    {vec: m.AccessLoggingWorking},
+		"object_lock":   {vec: m.ObjectLockCompliant},
+		"public_access": {vec: m.BucketPublic, inverted: true},
+		"tags":          {vec: m.BucketTagsCompliant},
+		"cors":          {vec: m.CORSConfiguredCorrectly},
+		"website":       {vec: m.WebsiteServing},
+		"kms_key":       {vec: m.KMSKeyUsable},
+		"restore":       {vec: m.RestoreCompleted},
+		"roundtrip":     {vec: m.RoundTripIntegrityOK},
+	}
+	m.pending = map[string]*prometheus.GaugeVec{
+		"restore": m.RestoreInProgress,
+	}
+	m.counts = map[string]*prometheus.GaugeVec{
+		"inventory": m.ObjectsByStorageClass,
+	}
+	return m, errors.Join(r.errs...)
+}
+
+// registrar registers collectors, collecting the errors instead of panicking
+type registrar struct {
+	reg  prometheus.Registerer
+	errs []error
+}
+
+// register registers c on r, returning the collector registered earlier under the same
+// descriptors when there is one so both share their series
+func register[C prometheus.Collector](r *registrar, c C) C {
+	if r.reg == nil {
+		return c
+	}
+	err := r.reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	r.errs = append(r.errs, err)
+	return c
+}
+
+// checkGauge publishes a bucket check verdict; inverted gauges report 1 when the check fails
+type checkGauge struct {
+	vec      *prometheus.GaugeVec
+	inverted bool
+}
 
 // RecordValidationAttempt records a validation attempt in metrics
-func RecordValidationAttempt(bucket string, success bool) {
+func (m *Metrics) RecordValidationAttempt(bucket string, success bool) {
 	status := "success"
 	if !success {
 		status = "failure"
 	}
-	ValidationAttempts.WithLabelValues(bucket, status).Inc()
+	m.ValidationAttempts.WithLabelValues(bucket, status).Inc()
 }
 
 // RecordValidationSuccess records a successful validation
-func RecordValidationSuccess(bucket string) {
-	ValidationSuccess.WithLabelValues(bucket).Inc()
-	KeysValid.WithLabelValues(bucket).Set(1)
+func (m *Metrics) RecordValidationSuccess(bucket string) {
+	m.ValidationSuccess.WithLabelValues(bucket).Inc()
+	m.KeysValid.WithLabelValues(bucket).Set(1)
 }
 
 // RecordValidationFailure records a failed validation
-func RecordValidationFailure(bucket, errorType string) {
-	ValidationFailures.WithLabelValues(bucket, errorType).Inc()
-	KeysValid.WithLabelValues(bucket).Set(0)
+func (m *Metrics) RecordValidationFailure(bucket, errorType string) {
+	m.CountValidationFailure(bucket, errorType)
+	m.KeysValid.WithLabelValues(bucket).Set(0)
+}
+
+// CountValidationFailure increments the failure counter without touching the validity gauge
+func (m *Metrics) CountValidationFailure(bucket, errorType string) {
+	m.ValidationFailures.WithLabelValues(bucket, errorType).Inc()
 }
 
 // SetLastValidationTime sets the last validation timestamp
-func SetLastValidationTime(bucket string, timestamp float64) {
-	LastValidationTimestamp.WithLabelValues(bucket).Set(timestamp)
+func (m *Metrics) SetLastValidationTime(bucket string, timestamp float64) {
+	m.LastValidationTimestamp.WithLabelValues(bucket).Set(timestamp)
+}
+
+// SetLastSuccessfulValidationTime sets the timestamp of the last successful validation
+func (m *Metrics) SetLastSuccessfulValidationTime(bucket string, timestamp float64) {
+	m.LastSuccessfulValidationTimestamp.WithLabelValues(bucket).Set(timestamp)
+}
+
+// RecordOutage records an outage that ended after duration
+func (m *Metrics) RecordOutage(bucket string, duration time.Duration) {
+	m.Outages.WithLabelValues(bucket).Inc()
+	m.OutageDuration.WithLabelValues(bucket).Observe(duration.Seconds())
 }
 
 // RecordResponseTime records the response time of an operation
-func RecordResponseTime(bucket, operation string, milliseconds float64) {
-	ResponseTime.WithLabelValues(bucket, operation).Observe(milliseconds)
+func (m *Metrics) RecordResponseTime(bucket, operation string, duration time.Duration) {
+	m.ResponseTime.WithLabelValues(bucket, operation).Observe(duration.Seconds())
+	if m.legacyResponseTimeMs {
+		m.ResponseTimeMs.WithLabelValues(bucket, operation).Observe(float64(duration) / float64(time.Millisecond))
+	}
 }
 
 // RecordValidationDuration captures how long a validation took in seconds.
-func RecordValidationDuration(bucket string, duration time.Duration) {
+func (m *Metrics) RecordValidationDuration(bucket string, duration time.Duration) {
 	if duration <= 0 {
 		return
 	}
-	ValidationDuration.WithLabelValues(bucket).Observe(duration.Seconds())
+	m.ValidationDuration.WithLabelValues(bucket).Observe(duration.Seconds())
+}
+
+// RecordProbeResult records the outcome and duration of a probe at the given depth
+func (m *Metrics) RecordProbeResult(bucket, depth string, success bool, duration time.Duration) {
+	value := 0.0
+	if success {
+		value = 1
+	}
+	m.ProbeSuccess.WithLabelValues(bucket, depth).Set(value)
+	if duration > 0 {
+		m.ProbeDuration.WithLabelValues(bucket, depth).Observe(duration.Seconds())
+	}
+}
+
+// SetActiveRegion marks region as the only active region for the bucket
+func (m *Metrics) SetActiveRegion(bucket, region string) {
+	m.ActiveRegionInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ActiveRegionInfo.WithLabelValues(bucket, region).Set(1)
+}
+
+// RecordCheckResult publishes the verdict of a bucket check; unknown checks are ignored
+func (m *Metrics) RecordCheckResult(bucket, check string, passed bool) {
+	gauge, ok := m.checks[check]
+	if !ok {
+		return
+	}
+	value := 0.0
+	if passed != gauge.inverted {
+		value = 1
+	}
+	gauge.vec.WithLabelValues(bucket).Set(value)
+}
+
+// RecordCheckPending publishes whether a check waits on an operation still running;
+// checks that never wait are ignored
+func (m *Metrics) RecordCheckPending(bucket, check string, pending bool) {
+	vec, ok := m.pending[check]
+	if !ok {
+		return
+	}
+	value := 0.0
+	if pending {
+		value = 1
+	}
+	vec.WithLabelValues(bucket).Set(value)
+}
+
+// RecordCheckCounts replaces the counts published for a check; checks without counts
+// are ignored
+func (m *Metrics) RecordCheckCounts(bucket, check string, counts map[string]int) {
+	vec, ok := m.counts[check]
+	if !ok || counts == nil {
+		return
+	}
+	// Categories missing from the latest sample must not keep their old counts
+	vec.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	for category, count := range counts {
+		vec.WithLabelValues(bucket, category).Set(float64(count))
+	}
+}
+
+// SetViaPrivateEndpoint records whether the bucket was last reached over a private address
+func (m *Metrics) SetViaPrivateEndpoint(bucket string, private bool) {
+	value := 0.0
+	if private {
+		value = 1
+	}
+	m.ViaPrivateEndpoint.WithLabelValues(bucket).Set(value)
+}
+
+// SetIPFamily marks family as the only address family in use for the bucket
+func (m *Metrics) SetIPFamily(bucket, family string) {
+	m.IPFamilyInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.IPFamilyInfo.WithLabelValues(bucket, family).Set(1)
+}
+
+// SetClockSkewDetected records whether the last validation failed because of clock skew
+func (m *Metrics) SetClockSkewDetected(bucket string, detected bool) {
+	value := 0.0
+	if detected {
+		value = 1
+	}
+	m.ClockSkewDetected.WithLabelValues(bucket).Set(value)
+}
+
+// SetLatencyAnomaly records the anomaly verdict and the baseline it was measured against
+func (m *Metrics) SetLatencyAnomaly(bucket string, anomalous bool, baselineMs float64) {
+	value := 0.0
+	if anomalous {
+		value = 1
+	}
+	m.LatencyAnomaly.WithLabelValues(bucket).Set(value)
+	m.LatencyBaseline.WithLabelValues(bucket).Set(baselineMs)
+}
+
+// RecordProbeRequest counts one request made by a validation probe
+func (m *Metrics) RecordProbeRequest(bucket, operation string) {
+	m.ProbeRequests.WithLabelValues(bucket, operation).Inc()
+}
+
+// RecordProbeRetries counts the attempts the SDK retried during one validation
+func (m *Metrics) RecordProbeRetries(bucket string, retries int) {
+	m.ProbeRetries.WithLabelValues(bucket).Add(float64(retries))
+}
+
+// SetProbeEstimatedCost records the projected monthly request cost of the bucket's probes
+func (m *Metrics) SetProbeEstimatedCost(bucket string, usd float64) {
+	m.ProbeEstimatedCost.WithLabelValues(bucket).Set(usd)
+}
+
+// SetCredentialSlotValid records the validity of one credential slot of the bucket
+func (m *Metrics) SetCredentialSlotValid(bucket, slot string, valid bool) {
+	value := 0.0
+	if valid {
+		value = 1
+	}
+	m.CredentialSlotValid.WithLabelValues(bucket, slot).Set(value)
+}
+
+// UnregisterCredentialSlot removes the series of a slot that is no longer configured
+func (m *Metrics) UnregisterCredentialSlot(bucket, slot string) {
+	m.CredentialSlotValid.DeleteLabelValues(bucket, slot)
+}
+
+// SetKeyAge records the access key age; the rotation gauge is only published when a
+// rotation policy applies
+func (m *Metrics) SetKeyAge(bucket string, age time.Duration, hasPolicy, rotationDue bool) {
+	m.KeyAge.WithLabelValues(bucket).Set(age.Seconds())
+	if !hasPolicy {
+		m.KeyRotationDue.DeleteLabelValues(bucket)
+		return
+	}
+	value := 0.0
+	if rotationDue {
+		value = 1
+	}
+	m.KeyRotationDue.WithLabelValues(bucket).Set(value)
+}
+
+// SetPermission records a discovered permission; an unknown verdict removes the series
+func (m *Metrics) SetPermission(bucket, operation string, known, allowed bool) {
+	if !known {
+		m.Permission.DeleteLabelValues(bucket, operation)
+		return
+	}
+	value := 0.0
+	if allowed {
+		value = 1
+	}
+	m.Permission.WithLabelValues(bucket, operation).Set(value)
+}
+
+// SetPermissionDrift records whether an operation drifted from its expected permission;
+// an unknown verdict removes the series
+func (m *Metrics) SetPermissionDrift(bucket, operation string, known, drifted bool) {
+	if !known {
+		m.PermissionDrift.DeleteLabelValues(bucket, operation)
+		return
+	}
+	value := 0.0
+	if drifted {
+		value = 1
+	}
+	m.PermissionDrift.WithLabelValues(bucket, operation).Set(value)
+}
+
+// RecordValidationUnfinished counts a validation whose result was abandoned
+func (m *Metrics) RecordValidationUnfinished(bucket, reason string) {
+	m.ValidationsUnfinished.WithLabelValues(bucket, reason).Inc()
+}
+
+// RecordProbeHedge counts a hedged probe and which probe's answer was used
+func (m *Metrics) RecordProbeHedge(bucket, winner string) {
+	m.ProbeHedges.WithLabelValues(bucket, winner).Inc()
+}
+
+// SetEndpointPair marks state as the only current state of the pair and, when both
+// endpoints succeeded, records how much slower the new one answered
+func (m *Metrics) SetEndpointPair(pair, oldEndpoint, newEndpoint, state string, latencyDelta time.Duration, bothOK bool) {
+	m.EndpointPairState.DeletePartialMatch(prometheus.Labels{"pair": pair})
+	m.EndpointPairState.WithLabelValues(pair, oldEndpoint, newEndpoint, state).Set(1)
+	if bothOK {
+		m.EndpointPairLatencyDelta.WithLabelValues(pair).Set(latencyDelta.Seconds())
+	}
+}
+
+// RecordCanaryOrphansCleaned counts leaked canaries deleted from the bucket
+func (m *Metrics) RecordCanaryOrphansCleaned(bucket string, count int) {
+	m.CanaryOrphansCleaned.WithLabelValues(bucket).Add(float64(count))
+}
+
+// RecordCycleSkipped counts a scheduled run that skipped an endpoint still being probed
+func (m *Metrics) RecordCycleSkipped(bucket, depth string) {
+	m.CyclesSkipped.WithLabelValues(bucket, depth).Inc()
+}
+
+// RecordResultSuppressed counts a failed result suppressed by a result rule
+func (m *Metrics) RecordResultSuppressed(bucket, rule string) {
+	m.ResultsSuppressed.WithLabelValues(bucket, rule).Inc()
+}
+
+// RecordKeyRotation counts an automatic key rotation attempt
+func (m *Metrics) RecordKeyRotation(bucket string, success bool) {
+	outcome := "success"
+	if !success {
+		outcome = "failure"
+	}
+	m.KeyRotations.WithLabelValues(bucket, outcome).Inc()
+}
+
+// RecordRateLimited counts a request to route rejected by the rate limiter
+func (m *Metrics) RecordRateLimited(route string) {
+	m.HTTPRateLimited.WithLabelValues(route).Inc()
+}
+
+// RecordRemoteWriteFailure counts one failed remote write push
+func (m *Metrics) RecordRemoteWriteFailure() {
+	m.RemoteWriteFailures.Inc()
+}
+
+// RecordAuthRejected counts a request refused by the auth middleware for reason
+func (m *Metrics) RecordAuthRejected(reason string) {
+	m.HTTPAuthRejected.WithLabelValues(reason).Inc()
+}
+
+// RecordCloudWatchFailure counts one failed PutMetricData call for an account
+func (m *Metrics) RecordCloudWatchFailure(account string) {
+	m.CloudWatchFailures.WithLabelValues(account).Inc()
+}
+
+// RecordSnapshotExportFailure counts one failed snapshot write
+func (m *Metrics) RecordSnapshotExportFailure() {
+	m.SnapshotExportFailures.Inc()
+}
+
+// SetProviderUnreachable records whether a provider host was unreachable in the last run
+func (m *Metrics) SetProviderUnreachable(host string, unreachable bool) {
+	value := 0.0
+	if unreachable {
+		value = 1
+	}
+	m.ProviderUnreachable.WithLabelValues(host).Set(value)
+}
+
+// SetProviderKeyCounts publishes how many endpoints of a provider have valid and invalid keys
+func (m *Metrics) SetProviderKeyCounts(host string, valid, invalid int) {
+	m.ProviderKeysValidCount.WithLabelValues(host).Set(float64(valid))
+	m.ProviderKeysInvalidCount.WithLabelValues(host).Set(float64(invalid))
+}
+
+// SetEndpointCounts publishes how many endpoints have valid and invalid keys; endpoints
+// not validated yet count as neither
+func (m *Metrics) SetEndpointCounts(valid, invalid int) {
+	m.EndpointsValid.Set(float64(valid))
+	m.EndpointsInvalid.Set(float64(invalid))
+}
+
+// UnregisterProvider removes the series for a provider with no endpoints left
+func (m *Metrics) UnregisterProvider(host string) {
+	m.ProviderUnreachable.DeleteLabelValues(host)
+	m.ProviderKeysValidCount.DeleteLabelValues(host)
+	m.ProviderKeysInvalidCount.DeleteLabelValues(host)
 }
 
 // RegisterEndpoint seeds metrics for a bucket so they are visible before validation occurs
-func RegisterEndpoint(bucket string) {
-	EndpointConfigured.WithLabelValues(bucket).Set(1)
-	KeysValid.WithLabelValues(bucket).Set(0)
-	LastValidationTimestamp.WithLabelValues(bucket).Set(0)
-	ValidationAttempts.WithLabelValues(bucket, "success").Add(0)
-	ValidationAttempts.WithLabelValues(bucket, "failure").Add(0)
-	ValidationSuccess.WithLabelValues(bucket).Add(0)
-	ValidationFailures.WithLabelValues(bucket, "unknown").Add(0)
+func (m *Metrics) RegisterEndpoint(bucket string) {
+	m.EndpointConfigured.WithLabelValues(bucket).Set(1)
+	m.KeysValid.WithLabelValues(bucket).Set(0)
+	m.LastValidationTimestamp.WithLabelValues(bucket).Set(0)
+	m.ClockSkewDetected.WithLabelValues(bucket).Set(0)
+	m.ValidationAttempts.WithLabelValues(bucket, "success").Add(0)
+	m.ValidationAttempts.WithLabelValues(bucket, "failure").Add(0)
+	m.ValidationSuccess.WithLabelValues(bucket).Add(0)
+	m.ValidationFailures.WithLabelValues(bucket, "unknown").Add(0)
+}
+
+// SetConfigWarnings publishes the number of configuration warnings per reason,
+// dropping reasons that no longer apply
+func (m *Metrics) SetConfigWarnings(counts map[string]int) {
+	m.ConfigWarning.Reset()
+	for reason, count := range counts {
+		m.ConfigWarning.WithLabelValues(reason).Set(float64(count))
+	}
+}
+
+// SetDiscoveredEndpoints publishes the number of endpoints created by a discovery source
+func (m *Metrics) SetDiscoveredEndpoints(source string, count int) {
+	m.DiscoveredEndpoints.WithLabelValues(source).Set(float64(count))
+}
+
+// RecordDiscoveryFailure counts a discovery run that could not list the buckets or accounts
+func (m *Metrics) RecordDiscoveryFailure(source string) {
+	m.DiscoveryFailures.WithLabelValues(source).Inc()
+}
+
+// SetEndpointAccount publishes the AWS account of a bucket, replacing an earlier one
+func (m *Metrics) SetEndpointAccount(bucket, accountID string) {
+	m.EndpointAccountInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.FailureInjected.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	if accountID != "" {
+		m.EndpointAccountInfo.WithLabelValues(bucket, accountID).Set(1)
+	}
+}
+
+// SetFailureInjected marks a bucket as reporting injected failures of errorType, or
+// clears the mark when errorType is empty
+func (m *Metrics) SetFailureInjected(bucket, errorType string) {
+	m.FailureInjected.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	if errorType != "" {
+		m.FailureInjected.WithLabelValues(bucket, errorType).Set(1)
+	}
+}
+
+// SetEndpointInfo publishes the owner, runbook_url and description annotations of a
+// bucket, replacing the series of earlier annotations
+func (m *Metrics) SetEndpointInfo(bucket string, annotations map[string]string) {
+	m.EndpointInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.EndpointInfo.WithLabelValues(bucket, annotations["owner"], annotations["runbook_url"], annotations["description"]).Set(1)
+}
+
+// UnregisterEndpoint removes every series for a bucket so removed endpoints disappear from /metrics
+func (m *Metrics) UnregisterEndpoint(bucket string) {
+	m.EndpointConfigured.DeleteLabelValues(bucket)
+	m.KeysValid.DeleteLabelValues(bucket)
+	m.LastValidationTimestamp.DeleteLabelValues(bucket)
+	m.LastSuccessfulValidationTimestamp.DeleteLabelValues(bucket)
+	m.OutageDuration.DeleteLabelValues(bucket)
+	m.Outages.DeleteLabelValues(bucket)
+	m.ValidationSuccess.DeleteLabelValues(bucket)
+	m.ValidationDuration.DeleteLabelValues(bucket)
+	m.ClockSkewDetected.DeleteLabelValues(bucket)
+	m.LatencyAnomaly.DeleteLabelValues(bucket)
+	m.ProbeEstimatedCost.DeleteLabelValues(bucket)
+	m.LatencyBaseline.DeleteLabelValues(bucket)
+	m.KeyAge.DeleteLabelValues(bucket)
+	m.KeyRotationDue.DeleteLabelValues(bucket)
+	m.ValidationAttempts.DeleteLabelValues(bucket, "success")
+	m.ValidationAttempts.DeleteLabelValues(bucket, "failure")
+
+	// error_type and operation values are open-ended, so match on the bucket label alone
+	m.ValidationFailures.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ResponseTime.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ProbeRequests.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ProbeRetries.DeleteLabelValues(bucket)
+	m.ResponseTimeMs.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ProbeSuccess.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ProbeDuration.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ActiveRegionInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.IPFamilyInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ViaPrivateEndpoint.DeleteLabelValues(bucket)
+	m.CredentialSlotValid.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.KeyRotations.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.Permission.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.PermissionDrift.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ValidationsUnfinished.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ResultsSuppressed.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.CyclesSkipped.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.ProbeHedges.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.CanaryOrphansCleaned.DeleteLabelValues(bucket)
+	m.EndpointInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	m.EndpointAccountInfo.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+
+	for _, gauge := range m.checks {
+		gauge.vec.DeleteLabelValues(bucket)
+	}
+	for _, vec := range m.pending {
+		vec.DeleteLabelValues(bucket)
+	}
+	for _, vec := range m.counts {
+		vec.DeletePartialMatch(prometheus.Labels{"bucket": bucket})
+	}
 }
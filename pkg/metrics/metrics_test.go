@@ -2,7 +2,9 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
@@ -13,8 +15,53 @@ func resetAll() {
 	ValidationDuration.Reset()
 	KeysValid.Reset()
 	LastValidationTimestamp.Reset()
+	LastSuccessfulValidationTimestamp.Reset()
+	OutageDuration.Reset()
+	Outages.Reset()
 	ResponseTime.Reset()
+	ResponseTimeMs.Reset()
 	EndpointConfigured.Reset()
+	ProbeSuccess.Reset()
+	ProbeDuration.Reset()
+	ActiveRegionInfo.Reset()
+	ProviderUnreachable.Reset()
+	ProviderKeysValidCount.Reset()
+	ProviderKeysInvalidCount.Reset()
+	EndpointsValid.Set(0)
+	EndpointsInvalid.Set(0)
+	AccessLoggingWorking.Reset()
+	ObjectLockCompliant.Reset()
+	BucketTagsCompliant.Reset()
+	CORSConfiguredCorrectly.Reset()
+	WebsiteServing.Reset()
+	BucketPublic.Reset()
+	KMSKeyUsable.Reset()
+	RoundTripIntegrityOK.Reset()
+	RestoreInProgress.Reset()
+	RestoreCompleted.Reset()
+	ObjectsByStorageClass.Reset()
+	ProbeRequests.Reset()
+	ProbeRetries.Reset()
+	ProbeEstimatedCost.Reset()
+	ClockSkewDetected.Reset()
+	IPFamilyInfo.Reset()
+	ViaPrivateEndpoint.Reset()
+	LatencyAnomaly.Reset()
+	LatencyBaseline.Reset()
+	KeyAge.Reset()
+	CredentialSlotValid.Reset()
+	KeyRotations.Reset()
+	Permission.Reset()
+	PermissionDrift.Reset()
+	ValidationsUnfinished.Reset()
+	KeyRotationDue.Reset()
+	HTTPRateLimited.Reset()
+	EndpointInfo.Reset()
+	EndpointAccountInfo.Reset()
+	FailureInjected.Reset()
+	DiscoveredEndpoints.Reset()
+	DiscoveryFailures.Reset()
+	ConfigWarning.Reset()
 }
 
 func TestRecordValidationAttempt(t *testing.T) {
@@ -59,7 +106,7 @@ func TestSetLastValidationTimeAndResponse(t *testing.T) {
 	resetAll()
 
 	SetLastValidationTime("bucket-a", 12345)
-	RecordResponseTime("bucket-a", "ListObjectsV2", 42)
+	RecordResponseTime("bucket-a", "ListObjectsV2", 42*time.Millisecond)
 
 	last := testutil.ToFloat64(LastValidationTimestamp.WithLabelValues("bucket-a"))
 	if last != 12345 {
@@ -102,3 +149,583 @@ func TestRegisterEndpointSeedsMetrics(t *testing.T) {
 		t.Fatalf("expected failure detail counter 0")
 	}
 }
+
+func TestSetEndpointCounts(t *testing.T) {
+	resetAll()
+
+	SetEndpointCounts(3, 1)
+
+	if got := testutil.ToFloat64(EndpointsValid); got != 3 {
+		t.Fatalf("expected 3 valid endpoints, got %v", got)
+	}
+	if got := testutil.ToFloat64(EndpointsInvalid); got != 1 {
+		t.Fatalf("expected 1 invalid endpoint, got %v", got)
+	}
+}
+
+func TestUnregisterEndpointDeletesSeries(t *testing.T) {
+	resetAll()
+
+	RegisterEndpoint("bucket-a")
+	RegisterEndpoint("bucket-b")
+	RecordValidationFailure("bucket-a", "timeout")
+	RecordResponseTime("bucket-a", "ListObjectsV2", 12*time.Millisecond)
+
+	UnregisterEndpoint("bucket-a")
+
+	if count := testutil.CollectAndCount(EndpointConfigured); count != 1 {
+		t.Fatalf("expected only bucket-b to remain configured, got %d series", count)
+	}
+	if count := testutil.CollectAndCount(ValidationFailures); count != 1 {
+		t.Fatalf("expected bucket-a failure series to be removed, got %d series", count)
+	}
+	if count := testutil.CollectAndCount(ResponseTime); count != 0 {
+		t.Fatalf("expected response time series to be removed, got %d", count)
+	}
+	if count := testutil.CollectAndCount(ValidationAttempts); count != 2 {
+		t.Fatalf("expected only bucket-b attempt series, got %d", count)
+	}
+}
+
+func TestRecordProbeResultPerDepth(t *testing.T) {
+	resetAll()
+
+	RecordProbeResult("bucket-a", "shallow", true, 50*time.Millisecond)
+	RecordProbeResult("bucket-a", "deep", false, 2*time.Second)
+
+	if got := testutil.ToFloat64(ProbeSuccess.WithLabelValues("bucket-a", "shallow")); got != 1 {
+		t.Fatalf("expected shallow probe success 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(ProbeSuccess.WithLabelValues("bucket-a", "deep")); got != 0 {
+		t.Fatalf("expected deep probe success 0, got %v", got)
+	}
+	if count := testutil.CollectAndCount(ProbeDuration); count != 2 {
+		t.Fatalf("expected one duration series per depth, got %d", count)
+	}
+}
+
+func TestSetActiveRegionReplacesPrevious(t *testing.T) {
+	resetAll()
+
+	SetActiveRegion("bucket-a", "us-east-1")
+	SetActiveRegion("bucket-a", "us-west-2")
+	SetActiveRegion("bucket-b", "eu-west-1")
+
+	if count := testutil.CollectAndCount(ActiveRegionInfo); count != 2 {
+		t.Fatalf("expected one active region per bucket, got %d series", count)
+	}
+	if got := testutil.ToFloat64(ActiveRegionInfo.WithLabelValues("bucket-a", "us-west-2")); got != 1 {
+		t.Fatalf("expected us-west-2 to be active for bucket-a, got %v", got)
+	}
+}
+
+func TestCountValidationFailureLeavesGauge(t *testing.T) {
+	resetAll()
+
+	RecordValidationSuccess("bucket-a")
+	CountValidationFailure("bucket-a", "network")
+
+	if got := testutil.ToFloat64(KeysValid.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected keys valid gauge to stay 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(ValidationFailures.WithLabelValues("bucket-a", "network")); got != 1 {
+		t.Fatalf("expected failure counter 1, got %v", got)
+	}
+}
+
+func TestProviderKeyCountsAndUnregister(t *testing.T) {
+	resetAll()
+
+	SetProviderKeyCounts("minio.internal", 3, 1)
+	SetProviderUnreachable("minio.internal", false)
+
+	if got := testutil.ToFloat64(ProviderKeysValidCount.WithLabelValues("minio.internal")); got != 3 {
+		t.Fatalf("expected 3 valid keys, got %v", got)
+	}
+	if got := testutil.ToFloat64(ProviderKeysInvalidCount.WithLabelValues("minio.internal")); got != 1 {
+		t.Fatalf("expected 1 invalid key, got %v", got)
+	}
+
+	UnregisterProvider("minio.internal")
+
+	if count := testutil.CollectAndCount(ProviderKeysValidCount) + testutil.CollectAndCount(ProviderKeysInvalidCount) + testutil.CollectAndCount(ProviderUnreachable); count != 0 {
+		t.Fatalf("expected provider series to be removed, got %d", count)
+	}
+}
+
+func TestRecordCheckResult(t *testing.T) {
+	resetAll()
+
+	RecordCheckResult("bucket-a", "access_log", true)
+	RecordCheckResult("bucket-b", "access_log", false)
+	RecordCheckResult("bucket-a", "object_lock", false)
+	RecordCheckResult("bucket-a", "public_access", false)
+	RecordCheckResult("bucket-b", "public_access", true)
+	RecordCheckResult("bucket-a", "kms_key", true)
+	RecordCheckResult("bucket-b", "roundtrip", false)
+	RecordCheckResult("bucket-a", "unknown_check", true)
+
+	if got := testutil.ToFloat64(AccessLoggingWorking.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected access logging working for bucket-a, got %v", got)
+	}
+	if got := testutil.ToFloat64(AccessLoggingWorking.WithLabelValues("bucket-b")); got != 0 {
+		t.Fatalf("expected access logging missing for bucket-b, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(ObjectLockCompliant.WithLabelValues("bucket-a")); got != 0 {
+		t.Fatalf("expected object lock not compliant for bucket-a, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(BucketPublic.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected failed public access check to report a public bucket, got %v", got)
+	}
+	if got := testutil.ToFloat64(BucketPublic.WithLabelValues("bucket-b")); got != 0 {
+		t.Fatalf("expected passed public access check to report a private bucket, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(KMSKeyUsable.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected kms key usable for bucket-a, got %v", got)
+	}
+	if got := testutil.ToFloat64(RoundTripIntegrityOK.WithLabelValues("bucket-b")); got != 0 {
+		t.Fatalf("expected a corrupted roundtrip for bucket-b, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+
+	if count := testutil.CollectAndCount(AccessLoggingWorking); count != 1 {
+		t.Fatalf("expected only bucket-b check series to remain, got %d", count)
+	}
+	if count := testutil.CollectAndCount(ObjectLockCompliant); count != 0 {
+		t.Fatalf("expected bucket-a object lock series to be removed, got %d", count)
+	}
+}
+
+func TestSetClockSkewDetected(t *testing.T) {
+	resetAll()
+
+	RegisterEndpoint("bucket-a")
+	if got := testutil.ToFloat64(ClockSkewDetected.WithLabelValues("bucket-a")); got != 0 {
+		t.Fatalf("expected clock skew gauge to be seeded with 0, got %v", got)
+	}
+
+	SetClockSkewDetected("bucket-a", true)
+	if got := testutil.ToFloat64(ClockSkewDetected.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected clock skew to be flagged, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(ClockSkewDetected); count != 0 {
+		t.Fatalf("expected clock skew series to be removed, got %d", count)
+	}
+}
+
+func TestSetLatencyAnomaly(t *testing.T) {
+	resetAll()
+
+	SetLatencyAnomaly("bucket-a", true, 120)
+	if got := testutil.ToFloat64(LatencyAnomaly.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected anomaly to be flagged, got %v", got)
+	}
+	if got := testutil.ToFloat64(LatencyBaseline.WithLabelValues("bucket-a")); got != 120 {
+		t.Fatalf("expected baseline 120, got %v", got)
+	}
+
+	SetLatencyAnomaly("bucket-a", false, 100)
+	if got := testutil.ToFloat64(LatencyAnomaly.WithLabelValues("bucket-a")); got != 0 {
+		t.Fatalf("expected anomaly to clear, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(LatencyAnomaly) + testutil.CollectAndCount(LatencyBaseline); count != 0 {
+		t.Fatalf("expected latency anomaly series to be removed, got %d", count)
+	}
+}
+
+func TestSetCredentialSlotValid(t *testing.T) {
+	resetAll()
+
+	SetCredentialSlotValid("bucket-a", "primary", true)
+	SetCredentialSlotValid("bucket-a", "secondary", false)
+	if got := testutil.ToFloat64(CredentialSlotValid.WithLabelValues("bucket-a", "secondary")); got != 0 {
+		t.Fatalf("expected secondary slot to be invalid, got %v", got)
+	}
+
+	UnregisterCredentialSlot("bucket-a", "secondary")
+	if count := testutil.CollectAndCount(CredentialSlotValid); count != 1 {
+		t.Fatalf("expected only the primary slot series, got %d", count)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(CredentialSlotValid); count != 0 {
+		t.Fatalf("expected slot series to be removed, got %d", count)
+	}
+}
+
+func TestRecordKeyRotation(t *testing.T) {
+	resetAll()
+
+	RecordKeyRotation("bucket-a", true)
+	RecordKeyRotation("bucket-a", false)
+	RecordKeyRotation("bucket-a", false)
+	if got := testutil.ToFloat64(KeyRotations.WithLabelValues("bucket-a", "failure")); got != 2 {
+		t.Fatalf("expected 2 failed rotations, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(KeyRotations); count != 0 {
+		t.Fatalf("expected rotation series to be removed, got %d", count)
+	}
+}
+
+func TestSetPermission(t *testing.T) {
+	resetAll()
+
+	SetPermission("bucket-a", "PutObject", true, false)
+	SetPermission("bucket-a", "GetObject", true, true)
+	if got := testutil.ToFloat64(Permission.WithLabelValues("bucket-a", "PutObject")); got != 0 {
+		t.Fatalf("expected PutObject to be denied, got %v", got)
+	}
+
+	SetPermission("bucket-a", "GetObject", false, false)
+	if count := testutil.CollectAndCount(Permission); count != 1 {
+		t.Fatalf("expected the unknown verdict to remove its series, got %d", count)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(Permission); count != 0 {
+		t.Fatalf("expected permission series to be removed, got %d", count)
+	}
+}
+
+func TestSetPermissionDrift(t *testing.T) {
+	resetAll()
+
+	SetPermissionDrift("bucket-a", "DeleteObject", true, true)
+	SetPermissionDrift("bucket-a", "ListObjectsV2", true, false)
+	if got := testutil.ToFloat64(PermissionDrift.WithLabelValues("bucket-a", "DeleteObject")); got != 1 {
+		t.Fatalf("expected DeleteObject to drift, got %v", got)
+	}
+
+	SetPermissionDrift("bucket-a", "DeleteObject", false, false)
+	if count := testutil.CollectAndCount(PermissionDrift); count != 1 {
+		t.Fatalf("expected the unknown verdict to remove its series, got %d", count)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(PermissionDrift); count != 0 {
+		t.Fatalf("expected drift series to be removed, got %d", count)
+	}
+}
+
+func TestSetKeyAge(t *testing.T) {
+	resetAll()
+
+	SetKeyAge("bucket-a", 48*time.Hour, true, true)
+	if got := testutil.ToFloat64(KeyAge.WithLabelValues("bucket-a")); got != 172800 {
+		t.Fatalf("expected age 172800s, got %v", got)
+	}
+	if got := testutil.ToFloat64(KeyRotationDue.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected rotation to be due, got %v", got)
+	}
+
+	SetKeyAge("bucket-a", time.Hour, false, false)
+	if count := testutil.CollectAndCount(KeyRotationDue); count != 0 {
+		t.Fatalf("expected no rotation series without a policy, got %d", count)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(KeyAge); count != 0 {
+		t.Fatalf("expected key age series to be removed, got %d", count)
+	}
+}
+
+func TestSetIPFamily(t *testing.T) {
+	resetAll()
+
+	SetIPFamily("bucket-a", "ipv4")
+	SetIPFamily("bucket-a", "ipv6")
+
+	if count := testutil.CollectAndCount(IPFamilyInfo); count != 1 {
+		t.Fatalf("expected a single family series per bucket, got %d", count)
+	}
+	if got := testutil.ToFloat64(IPFamilyInfo.WithLabelValues("bucket-a", "ipv6")); got != 1 {
+		t.Fatalf("expected ipv6 to be the current family, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(IPFamilyInfo); count != 0 {
+		t.Fatalf("expected family series to be removed, got %d", count)
+	}
+}
+
+func TestSetViaPrivateEndpoint(t *testing.T) {
+	resetAll()
+
+	SetViaPrivateEndpoint("bucket-a", true)
+	if got := testutil.ToFloat64(ViaPrivateEndpoint.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected a private route, got %v", got)
+	}
+	SetViaPrivateEndpoint("bucket-a", false)
+	if got := testutil.ToFloat64(ViaPrivateEndpoint.WithLabelValues("bucket-a")); got != 0 {
+		t.Fatalf("expected a public route, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(ViaPrivateEndpoint); count != 0 {
+		t.Fatalf("expected the route series to be removed, got %d", count)
+	}
+}
+
+func TestRecordValidationUnfinished(t *testing.T) {
+	resetAll()
+
+	RecordValidationUnfinished("bucket-a", "timed_out")
+	RecordValidationUnfinished("bucket-a", "timed_out")
+	RecordValidationUnfinished("bucket-a", "canceled")
+	if got := testutil.ToFloat64(ValidationsUnfinished.WithLabelValues("bucket-a", "timed_out")); got != 2 {
+		t.Fatalf("expected 2 timed out validations, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(ValidationsUnfinished); count != 0 {
+		t.Fatalf("expected unfinished series to be removed, got %d", count)
+	}
+}
+
+func TestRecordRateLimited(t *testing.T) {
+	resetAll()
+
+	RecordRateLimited("validate")
+	RecordRateLimited("validate")
+	RecordRateLimited("validate_endpoint")
+
+	if got := testutil.ToFloat64(HTTPRateLimited.WithLabelValues("validate")); got != 2 {
+		t.Fatalf("expected 2 rejected validate requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(HTTPRateLimited.WithLabelValues("validate_endpoint")); got != 1 {
+		t.Fatalf("expected 1 rejected validate_endpoint request, got %v", got)
+	}
+}
+
+func TestSetEndpointInfo(t *testing.T) {
+	resetAll()
+
+	SetEndpointInfo("bucket-a", map[string]string{"owner": "team-a", "runbook_url": "https://runbooks/a", "team": "ignored"})
+	if got := testutil.ToFloat64(EndpointInfo.WithLabelValues("bucket-a", "team-a", "https://runbooks/a", "")); got != 1 {
+		t.Fatalf("expected the annotations as labels, got %v", got)
+	}
+
+	SetEndpointInfo("bucket-a", map[string]string{"owner": "team-b"})
+	if count := testutil.CollectAndCount(EndpointInfo); count != 1 {
+		t.Fatalf("expected changed annotations to replace the series, got %d", count)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(EndpointInfo); count != 0 {
+		t.Fatalf("expected the info series to be removed, got %d", count)
+	}
+}
+
+func TestSetConfigWarnings(t *testing.T) {
+	resetAll()
+
+	SetConfigWarnings(map[string]int{"shared_credentials": 2, "high_concurrency": 1})
+	if got := testutil.ToFloat64(ConfigWarning.WithLabelValues("shared_credentials")); got != 2 {
+		t.Fatalf("expected 2 shared credential warnings, got %v", got)
+	}
+
+	SetConfigWarnings(map[string]int{"high_concurrency": 1})
+	if count := testutil.CollectAndCount(ConfigWarning); count != 1 {
+		t.Fatalf("expected reasons that no longer apply to be dropped, got %d series", count)
+	}
+}
+
+func TestRecordRestoreStatus(t *testing.T) {
+	resetAll()
+
+	RecordCheckResult("bucket-a", "restore", false)
+	RecordCheckPending("bucket-a", "restore", true)
+	RecordCheckPending("bucket-a", "kms_key", true)
+
+	if got := testutil.ToFloat64(RestoreInProgress.WithLabelValues("bucket-a")); got != 1 {
+		t.Fatalf("expected restore in progress, got %v", got)
+	}
+	if got := testutil.ToFloat64(RestoreCompleted.WithLabelValues("bucket-a")); got != 0 {
+		t.Fatalf("expected restore not completed, got %v", got)
+	}
+
+	RecordCheckResult("bucket-a", "restore", true)
+	RecordCheckPending("bucket-a", "restore", false)
+	if testutil.ToFloat64(RestoreInProgress.WithLabelValues("bucket-a")) != 0 || testutil.ToFloat64(RestoreCompleted.WithLabelValues("bucket-a")) != 1 {
+		t.Fatalf("expected a completed restore")
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if testutil.CollectAndCount(RestoreInProgress) != 0 || testutil.CollectAndCount(RestoreCompleted) != 0 {
+		t.Fatalf("expected restore gauges to be removed with the endpoint")
+	}
+}
+
+func TestRecordCheckCounts(t *testing.T) {
+	resetAll()
+
+	RecordCheckCounts("bucket-a", "inventory", map[string]int{"STANDARD": 90, "GLACIER": 10})
+	RecordCheckCounts("bucket-a", "kms_key", map[string]int{"STANDARD": 1})
+	if got := testutil.ToFloat64(ObjectsByStorageClass.WithLabelValues("bucket-a", "GLACIER")); got != 10 {
+		t.Fatalf("expected 10 GLACIER objects, got %v", got)
+	}
+
+	RecordCheckCounts("bucket-a", "inventory", map[string]int{"STANDARD": 100})
+	if got := testutil.CollectAndCount(ObjectsByStorageClass); got != 1 {
+		t.Fatalf("expected classes missing from the latest sample to be dropped, got %d series", got)
+	}
+	RecordCheckCounts("bucket-a", "inventory", nil)
+	if got := testutil.CollectAndCount(ObjectsByStorageClass); got != 1 {
+		t.Fatalf("expected a failed sample to keep the last counts, got %d series", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if got := testutil.CollectAndCount(ObjectsByStorageClass); got != 0 {
+		t.Fatalf("expected storage class counts to be removed with the endpoint, got %d", got)
+	}
+}
+
+func TestProbeCostMetrics(t *testing.T) {
+	resetAll()
+
+	RecordProbeRequest("bucket-a", "ListObjectsV2")
+	RecordProbeRequest("bucket-a", "ListObjectsV2")
+	SetProbeEstimatedCost("bucket-a", 0.25)
+	if got := testutil.ToFloat64(ProbeRequests.WithLabelValues("bucket-a", "ListObjectsV2")); got != 2 {
+		t.Fatalf("expected 2 requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(ProbeEstimatedCost.WithLabelValues("bucket-a")); got != 0.25 {
+		t.Fatalf("expected estimated cost 0.25, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if testutil.CollectAndCount(ProbeRequests) != 0 || testutil.CollectAndCount(ProbeEstimatedCost) != 0 {
+		t.Fatalf("expected probe cost series to be removed with the endpoint")
+	}
+}
+
+func TestSetEndpointAccount(t *testing.T) {
+	resetAll()
+
+	SetEndpointAccount("bucket-a", "111111111111")
+	SetEndpointAccount("bucket-a", "222222222222")
+	if got := testutil.CollectAndCount(EndpointAccountInfo); got != 1 {
+		t.Fatalf("expected the account to be replaced, got %d series", got)
+	}
+	if got := testutil.ToFloat64(EndpointAccountInfo.WithLabelValues("bucket-a", "222222222222")); got != 1 {
+		t.Fatalf("expected the latest account, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if got := testutil.CollectAndCount(EndpointAccountInfo); got != 0 {
+		t.Fatalf("expected the account to be removed with the endpoint, got %d", got)
+	}
+}
+
+func TestSetFailureInjected(t *testing.T) {
+	resetAll()
+
+	SetFailureInjected("bucket-a", "access_denied")
+	SetFailureInjected("bucket-a", "throttled")
+	if got := testutil.CollectAndCount(FailureInjected); got != 1 {
+		t.Fatalf("expected the injected error type to be replaced, got %d series", got)
+	}
+	SetFailureInjected("bucket-a", "")
+	if got := testutil.CollectAndCount(FailureInjected); got != 0 {
+		t.Fatalf("expected clearing to remove the series, got %d", got)
+	}
+}
+
+func TestRecordProbeRetries(t *testing.T) {
+	resetAll()
+
+	RecordProbeRetries("bucket-a", 2)
+	RecordProbeRetries("bucket-a", 0)
+	if got := testutil.ToFloat64(ProbeRetries.WithLabelValues("bucket-a")); got != 2 {
+		t.Fatalf("expected 2 retries, got %v", got)
+	}
+
+	UnregisterEndpoint("bucket-a")
+	if count := testutil.CollectAndCount(ProbeRetries); count != 0 {
+		t.Fatalf("expected the retry series to be removed, got %d", count)
+	}
+}
+
+func TestNewMetricsOnOwnRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first, err := NewMetrics(registry)
+	if err != nil {
+		t.Fatalf("expected a fresh registry to accept the collectors, got %v", err)
+	}
+	// A second instance on the same registry must not panic and shares the series
+	second, err := NewMetrics(registry)
+	if err != nil {
+		t.Fatalf("expected registering twice to reuse the collectors, got %v", err)
+	}
+
+	first.RecordValidationSuccess("embedded")
+	second.RecordValidationSuccess("embedded")
+	if got := testutil.ToFloat64(second.ValidationSuccess.WithLabelValues("embedded")); got != 2 {
+		t.Fatalf("expected both instances to count into the same series, got %v", got)
+	}
+	if got := testutil.ToFloat64(ValidationSuccess.WithLabelValues("embedded")); got != 0 {
+		t.Fatalf("expected Default to stay untouched, got %v", got)
+	}
+	if count, err := testutil.GatherAndCount(registry, "s3_validation_success_total"); err != nil || count != 1 {
+		t.Fatalf("expected the registry to export one series, got %d (%v)", count, err)
+	}
+}
+
+func TestNewMetricsConflictDegrades(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "s3_validation_success_total",
+		Help: "Something else entirely",
+	}))
+
+	m, err := NewMetrics(registry)
+	if err == nil {
+		t.Fatalf("expected the conflicting collector to be reported")
+	}
+	// Conflicting collectors still record, they are just not exported
+	m.RecordValidationSuccess("embedded")
+	if got := testutil.ToFloat64(m.KeysValid.WithLabelValues("embedded")); got != 1 {
+		t.Fatalf("expected the other collectors to work, got %v", got)
+	}
+	if count, err := testutil.GatherAndCount(registry, "s3_keys_valid"); err != nil || count != 1 {
+		t.Fatalf("expected the other collectors to be exported, got %d (%v)", count, err)
+	}
+}
+
+func TestNewMetricsUnregistered(t *testing.T) {
+	m, err := NewMetrics(nil)
+	if err != nil {
+		t.Fatalf("expected no registerer to be fine, got %v", err)
+	}
+	if err := m.ConfigureHistograms(HistogramOptions{LegacyResponseTimeMs: true}); err != nil {
+		t.Fatalf("expected histograms to be configured without a registerer, got %v", err)
+	}
+	m.RecordResponseTime("embedded", "HeadBucket", 0)
+	if count := testutil.CollectAndCount(m.ResponseTimeMs); count != 1 {
+		t.Fatalf("expected the millisecond metric to be recorded, got %d series", count)
+	}
+}
+
+func TestMustNewMetricsPanicsOnConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "s3_validation_success_total",
+		Help: "Something else entirely",
+	}))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a conflicting registry to panic")
+		}
+	}()
+	mustNewMetrics(registry)
+}
@@ -0,0 +1,10 @@
+package s3
+
+// WithUseARNRegion lets a bucket given as an access point ARN in another region than the
+// validator's route requests to the ARN's region instead of failing. Multi-Region Access
+// Point ARNs carry no region: they are signed with SigV4A for every region.
+func WithUseARNRegion() Option {
+	return func(s *validatorSettings) {
+		s.useARNRegion = true
+	}
+}
@@ -0,0 +1,98 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// recordingHTTPClient answers every request with an empty listing and keeps the last one
+type recordingHTTPClient struct {
+	last *http.Request
+}
+
+func (c *recordingHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	c.last = r
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/xml"}},
+		Body:       io.NopCloser(strings.NewReader(`<ListBucketResult><KeyCount>0</KeyCount></ListBucketResult>`)),
+		Request:    r,
+	}, nil
+}
+
+// withRecordingClient routes the validator's requests to a recording client
+func withRecordingClient(t *testing.T, v *S3Validator) *recordingHTTPClient {
+	t.Helper()
+	recorder := &recordingHTTPClient{}
+	v.clientPool = NewClientPool()
+	_, _, err := v.clientPool.config(context.Background(), v.clientKey(), func(ctx context.Context) (aws.Config, error) {
+		cfg, err := v.loadConfig(ctx)
+		cfg.HTTPClient = recorder
+		return cfg, err
+	})
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	return recorder
+}
+
+func TestValidateKeysMultiRegionAccessPointSignsWithSigV4A(t *testing.T) {
+	validator := NewS3Validator("", "us-east-1", "arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap", "AKIDEXAMPLE", "secret", "", false, false)
+	recorder := withRecordingClient(t, validator)
+
+	if result := validator.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected validation through the access point to succeed: %s", result.Message)
+	}
+
+	req := recorder.last
+	if req.URL.Host != "mfzwi23gnjvgw.mrap.accesspoint.s3-global.amazonaws.com" {
+		t.Fatalf("expected the global access point host, got %s", req.URL.Host)
+	}
+	if auth := req.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-ECDSA-P256-SHA256") {
+		t.Fatalf("expected a SigV4A signature, got %q", auth)
+	}
+	if regions := req.Header.Get("X-Amz-Region-Set"); regions != "*" {
+		t.Fatalf("expected the signature to cover every region, got %q", regions)
+	}
+}
+
+func TestValidateKeysAccessPointARN(t *testing.T) {
+	arn := "arn:aws:s3:us-west-2:123456789012:accesspoint/reports"
+
+	validator := NewS3Validator("", "us-west-2", arn, "AKIDEXAMPLE", "secret", "", false, false)
+	recorder := withRecordingClient(t, validator)
+	if result := validator.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected validation through the access point to succeed: %s", result.Message)
+	}
+	if host := recorder.last.URL.Host; host != "reports-123456789012.s3-accesspoint.us-west-2.amazonaws.com" {
+		t.Fatalf("expected the access point host, got %s", host)
+	}
+	if auth := recorder.last.Header.Get("Authorization"); !strings.Contains(auth, "/us-west-2/s3/aws4_request") {
+		t.Fatalf("expected a SigV4 signature for the access point's region, got %q", auth)
+	}
+}
+
+func TestValidateKeysAccessPointARNInOtherRegion(t *testing.T) {
+	arn := "arn:aws:s3:eu-west-1:123456789012:accesspoint/reports"
+
+	mismatched := NewS3Validator("", "us-east-1", arn, "AKIDEXAMPLE", "secret", "", false, false)
+	withRecordingClient(t, mismatched)
+	if result := mismatched.ValidateKeys(context.Background(), 5*time.Second); result.IsValid {
+		t.Fatalf("expected an access point in another region to be refused without use_arn_region")
+	}
+
+	routed := NewS3Validator("", "us-east-1", arn, "AKIDEXAMPLE", "secret", "", false, false, WithUseARNRegion())
+	recorder := withRecordingClient(t, routed)
+	if result := routed.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected use_arn_region to route to the access point's region: %s", result.Message)
+	}
+	if host := recorder.last.URL.Host; host != "reports-123456789012.s3-accesspoint.eu-west-1.amazonaws.com" {
+		t.Fatalf("expected the access point host in eu-west-1, got %s", host)
+	}
+}
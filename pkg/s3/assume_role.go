@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// assumeRoleSessionName identifies the exporter's sessions in CloudTrail
+const assumeRoleSessionName = "key-aws-exporter"
+
+// assumeRoleDuration is how long assumed role credentials are requested for
+const assumeRoleDuration = time.Hour
+
+// WithAssumeRole validates with temporary credentials for roleARN, assumed with the
+// validator's keys. externalID is sent when not empty; stsEndpoint defaults to the
+// regional STS endpoint.
+func WithAssumeRole(roleARN, externalID, stsEndpoint string) Option {
+	return func(s *validatorSettings) {
+		s.roleARN = roleARN
+		s.externalID = externalID
+		s.stsEndpoint = strings.TrimRight(stsEndpoint, "/")
+	}
+}
+
+// newAssumeRoleProvider returns the credentials provider for the validator's role
+func (v *S3Validator) newAssumeRoleProvider() aws.CredentialsProvider {
+	var client aws.HTTPClient = http.DefaultClient
+	if custom := v.httpClient(); custom != nil {
+		client = custom
+	}
+	base := aws.Credentials{
+		AccessKeyID:     v.accessKey,
+		SecretAccessKey: v.secretKey,
+		SessionToken:    v.sessionToken,
+	}
+	return NewAssumeRoleCredentials(base, v.roleARN, v.externalID, v.stsEndpoint, v.region, client)
+}
+
+// NewAssumeRoleCredentials returns cached credentials for roleARN, assumed with base
+// through STS and refreshed before they expire. STS failures surface as the SDK's
+// smithy.APIError, so a refused AssumeRole classifies like any other AccessDenied.
+// stsEndpoint defaults to the regional STS endpoint; client defaults to
+// http.DefaultClient.
+func NewAssumeRoleCredentials(base aws.Credentials, roleARN, externalID, stsEndpoint, region string, client aws.HTTPClient) aws.CredentialsProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	stsClient := sts.NewFromConfig(aws.Config{
+		Region:      region,
+		Credentials: credentials.StaticCredentialsProvider{Value: base},
+		HTTPClient:  client,
+	}, func(o *sts.Options) {
+		if endpoint := strings.TrimRight(stsEndpoint, "/"); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = assumeRoleSessionName
+		o.Duration = assumeRoleDuration
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	}))
+}
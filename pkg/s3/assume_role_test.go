@@ -0,0 +1,85 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	smithy "github.com/aws/smithy-go"
+)
+
+const assumeRoleXML = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>role-secret</SecretAccessKey>
+      <SessionToken>role-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	var form map[string][]string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		form = r.PostForm
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(assumeRoleXML))
+	}))
+	defer server.Close()
+
+	v := NewS3Validator("", "eu-west-1", "bucket", "AKIABASE", "base-secret", "", false, false,
+		WithAssumeRole("arn:aws:iam::123456789012:role/monitor", "ext-1", server.URL))
+	cfg, err := v.loadConfig(context.Background())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("retrieve credentials: %v", err)
+	}
+
+	if creds.AccessKeyID != "ASIAROLE" || creds.SessionToken != "role-token" || !creds.CanExpire {
+		t.Fatalf("expected the assumed role credentials, got %+v", creds)
+	}
+	if form["RoleArn"][0] != "arn:aws:iam::123456789012:role/monitor" || form["ExternalId"][0] != "ext-1" || form["Action"][0] != "AssumeRole" {
+		t.Fatalf("unexpected AssumeRole request %v", form)
+	}
+	if !strings.Contains(auth, "Credential=AKIABASE/") || !strings.Contains(auth, "/eu-west-1/sts/") {
+		t.Fatalf("expected the request to be signed with the base key for STS, got %q", auth)
+	}
+}
+
+func TestAssumeRoleDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized to assume</Message></Error></ErrorResponse>`))
+	}))
+	defer server.Close()
+
+	provider := NewAssumeRoleCredentials(aws.Credentials{AccessKeyID: "AK", SecretAccessKey: "SK"}, "arn:aws:iam::1:role/r", "", server.URL, "us-east-1", nil)
+	_, err := provider.Retrieve(context.Background())
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		t.Fatalf("expected the STS API error, got %v", err)
+	}
+	if errorType := classifyValidationError(err); errorType != errorTypeForbidden {
+		t.Fatalf("expected %s, got %s", errorTypeForbidden, errorType)
+	}
+}
+
+func TestAssumeRoleChangesClientKey(t *testing.T) {
+	plain := NewS3Validator("", "us-east-1", "bucket", "AK", "SK", "", false, false)
+	role := NewS3Validator("", "us-east-1", "bucket", "AK", "SK", "", false, false, WithAssumeRole("arn:aws:iam::1:role/r", "", ""))
+	if plain.clientKey() == role.clientKey() {
+		t.Fatalf("expected validators assuming a role not to share the plain key's configuration")
+	}
+}
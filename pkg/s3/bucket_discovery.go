@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// BucketDiscovery selects the buckets of an account to monitor by their tags
+type BucketDiscovery struct {
+	Endpoint     string // empty uses AWS
+	Region       string // region of the listing, and of buckets the listing reports none for
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	UsePathStyle bool
+	Tags         map[string]string // a bucket must carry every tag with the same value
+}
+
+// DiscoveredBucket is a bucket carrying every discovery tag
+type DiscoveredBucket struct {
+	Name   string
+	Region string
+	Tags   map[string]string
+}
+
+// BucketDiscoveryResult lists the matching buckets. Buckets whose tags could not be read are
+// neither matched nor ruled out, so callers keep monitoring them.
+type BucketDiscoveryResult struct {
+	Buckets    []DiscoveredBucket
+	Unreadable map[string]error // key: bucket name
+}
+
+type bucketDiscoveryAPI interface {
+	ListBuckets(context.Context, *s3.ListBucketsInput, ...func(*s3.Options)) (*s3.ListBucketsOutput, error)
+	GetBucketTagging(context.Context, *s3.GetBucketTaggingInput, ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error)
+}
+
+// listBucketsPageSize also makes AWS report each bucket's region
+const listBucketsPageSize = 1000
+
+// DiscoverBuckets lists the account's buckets and returns those carrying every tag.
+// The credentials need s3:ListAllMyBuckets and s3:GetBucketTagging.
+func DiscoverBuckets(ctx context.Context, d BucketDiscovery) (*BucketDiscoveryResult, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(d.Region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(d.AccessKey, d.SecretKey, d.SessionToken)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = d.UsePathStyle
+		if d.Endpoint != "" {
+			o.BaseEndpoint = aws.String(d.Endpoint)
+		}
+	})
+	return discoverBuckets(ctx, client, d)
+}
+
+func discoverBuckets(ctx context.Context, client bucketDiscoveryAPI, d BucketDiscovery) (*BucketDiscoveryResult, error) {
+	result := &BucketDiscoveryResult{Unreadable: make(map[string]error)}
+
+	var token *string
+	for {
+		out, err := client.ListBuckets(ctx, &s3.ListBucketsInput{
+			MaxBuckets:        aws.Int32(listBucketsPageSize),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+
+		for _, bucket := range out.Buckets {
+			name := aws.ToString(bucket.Name)
+			region := aws.ToString(bucket.BucketRegion)
+			if region == "" {
+				region = d.Region
+			}
+			tags, err := bucketTags(ctx, client, name, region)
+			if err != nil {
+				result.Unreadable[name] = err
+				continue
+			}
+			if matchesTags(tags, d.Tags) {
+				result.Buckets = append(result.Buckets, DiscoveredBucket{Name: name, Region: region, Tags: tags})
+			}
+		}
+
+		if aws.ToString(out.ContinuationToken) == "" {
+			break
+		}
+		token = out.ContinuationToken
+	}
+
+	sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Name < result.Buckets[j].Name })
+	return result, nil
+}
+
+// bucketTags reads the bucket's tags in its own region; untagged buckets have none
+func bucketTags(ctx context.Context, client bucketDiscoveryAPI, bucket, region string) (map[string]string, error) {
+	out, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(bucket)}, func(o *s3.Options) {
+		o.Region = region
+	})
+	if err != nil {
+		if ignoreErrorCodes(err, "NoSuchTagSet", "NoSuchTagSetError") == nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tags := make(map[string]string, len(out.TagSet))
+	for _, tag := range out.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// matchesTags reports whether tags carry every wanted tag with the same value
+func matchesTags(tags, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if got, ok := tags[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
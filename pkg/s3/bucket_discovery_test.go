@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type fakeDiscoveryClient struct {
+	pages   [][]types.Bucket
+	tags    map[string]map[string]string
+	errs    map[string]error
+	regions map[string]string // region each tagging call was made in
+}
+
+func (f *fakeDiscoveryClient) ListBuckets(_ context.Context, in *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	page := 0
+	if in.ContinuationToken != nil {
+		page = 1
+	}
+	out := &s3.ListBucketsOutput{Buckets: f.pages[page]}
+	if page+1 < len(f.pages) {
+		out.ContinuationToken = aws.String("next")
+	}
+	return out, nil
+}
+
+func (f *fakeDiscoveryClient) GetBucketTagging(_ context.Context, in *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	var o s3.Options
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	f.regions[bucket] = o.Region
+	if err := f.errs[bucket]; err != nil {
+		return nil, err
+	}
+	out := &s3.GetBucketTaggingOutput{}
+	for key, value := range f.tags[bucket] {
+		out.TagSet = append(out.TagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return out, nil
+}
+
+func TestDiscoverBucketsByTag(t *testing.T) {
+	client := &fakeDiscoveryClient{
+		pages: [][]types.Bucket{
+			{{Name: aws.String("logs"), BucketRegion: aws.String("eu-west-1")}, {Name: aws.String("scratch")}},
+			{{Name: aws.String("backups")}, {Name: aws.String("untagged")}, {Name: aws.String("locked")}},
+		},
+		tags: map[string]map[string]string{
+			"logs":    {"monitor": "true", "team": "storage"},
+			"scratch": {"monitor": "false"},
+			"backups": {"monitor": "true"},
+		},
+		errs: map[string]error{
+			"untagged": &mockAPIError{code: "NoSuchTagSet"},
+			"locked":   &mockAPIError{code: "AccessDenied"},
+		},
+		regions: make(map[string]string),
+	}
+
+	result, err := discoverBuckets(context.Background(), client, BucketDiscovery{Region: "us-east-1", Tags: map[string]string{"monitor": "true"}})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(result.Buckets) != 2 || result.Buckets[0].Name != "backups" || result.Buckets[1].Name != "logs" {
+		t.Fatalf("expected the tagged buckets across pages, got %+v", result.Buckets)
+	}
+	if result.Buckets[1].Region != "eu-west-1" || result.Buckets[0].Region != "us-east-1" {
+		t.Fatalf("expected listed regions with the discovery region as fallback, got %+v", result.Buckets)
+	}
+	if client.regions["logs"] != "eu-west-1" {
+		t.Fatalf("expected tags to be read in the bucket's region, got %q", client.regions["logs"])
+	}
+	if _, ok := result.Unreadable["locked"]; !ok || len(result.Unreadable) != 1 {
+		t.Fatalf("expected only the denied bucket to be unreadable, got %v", result.Unreadable)
+	}
+}
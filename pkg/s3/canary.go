@@ -0,0 +1,171 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultCanaryTTL is how long a canary object may exist before the janitor treats it
+// as leaked. Probes delete their canaries right away; only crashed or cut-off probes
+// leave them behind.
+const DefaultCanaryTTL = time.Hour
+
+// canaryRoot is the prefix every canary object is written under
+const canaryRoot = ".key-aws-exporter/"
+
+// canaryPrefixes are the key prefixes of the canaries written by probes and checks
+var canaryPrefixes = []string{deepProbeKeyPrefix, kmsProbeKeyPrefix, accessLogCanaryPrefix + accessLogCanaryName, discoverProbeKeyPrefix}
+
+// WithCanaryNaming makes canary keys unique per exporter replica and records when they
+// expire: <prefix><expiry unix seconds>-<replica>-<nanoseconds>. Replicas writing to the
+// same bucket then never collide, and any replica's janitor can tell a leaked canary
+// from one still in use, provided the replicas' clocks are synchronized to well within
+// ttl. An empty replica uses the host name, a zero ttl DefaultCanaryTTL.
+func WithCanaryNaming(replica string, ttl time.Duration) Option {
+	return func(s *validatorSettings) {
+		s.replicaID = replica
+		s.canaryTTL = ttl
+	}
+}
+
+// canaryNamer builds canary keys for a validator and the checks it runs
+type canaryNamer struct {
+	replica string
+	ttl     time.Duration
+	clock   clock.Clock
+}
+
+// canaryCheck is implemented by checks that write canary objects
+type canaryCheck interface {
+	useCanaryNames(names canaryNamer)
+}
+
+func newCanaryNamer(s validatorSettings) canaryNamer {
+	replica := s.replicaID
+	if replica == "" {
+		replica = DefaultReplicaID()
+	}
+	ttl := s.canaryTTL
+	if ttl <= 0 {
+		ttl = DefaultCanaryTTL
+	}
+	return canaryNamer{replica: sanitizeReplicaID(replica), ttl: ttl, clock: clock.OrReal(s.clock)}
+}
+
+// name returns the unique part of a canary key written now, after its prefix
+func (n canaryNamer) name() string {
+	if n.clock == nil {
+		// Checks built without a validator name their canaries with the defaults
+		n = newCanaryNamer(validatorSettings{})
+	}
+	now := n.clock.Now()
+	return fmt.Sprintf("%d-%s-%d", now.Add(n.ttl).Unix(), n.replica, now.UnixNano())
+}
+
+// DefaultReplicaID identifies this process in canary keys when no replica ID is
+// configured: the host name, which is the pod name on Kubernetes
+func DefaultReplicaID() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return sanitizeReplicaID(host)
+	}
+	return "exporter"
+}
+
+// sanitizeReplicaID keeps replica IDs to characters that are safe in object keys
+func sanitizeReplicaID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// canaryExpiry reports when the canary with key expires. Keys written before canary
+// naming carry no expiry and fall back to their modification time plus ttl. ok is
+// false for objects that are not canaries.
+func canaryExpiry(key string, lastModified time.Time, ttl time.Duration) (expiry time.Time, ok bool) {
+	for _, prefix := range canaryPrefixes {
+		rest, found := strings.CutPrefix(key, prefix)
+		if !found || rest == "" {
+			continue
+		}
+		if expires, _, named := strings.Cut(rest, "-"); named {
+			seconds, err := strconv.ParseInt(expires, 10, 64)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return time.Unix(seconds, 0), true
+		}
+		if _, err := strconv.ParseInt(rest, 10, 64); err != nil || lastModified.IsZero() {
+			return time.Time{}, false
+		}
+		return lastModified.Add(ttl), true
+	}
+	return time.Time{}, false
+}
+
+// CanaryCleanup is the outcome of one janitor run over a bucket
+type CanaryCleanup struct {
+	Deleted []string // keys of the expired canaries removed
+	Err     error    // listing and delete failures; the deletes that succeeded still count
+}
+
+// CleanCanaries deletes canary objects whose expiry has passed, whichever replica wrote
+// them. Only keys in the canary naming scheme under .key-aws-exporter/ are touched.
+func (v *S3Validator) CleanCanaries(ctx context.Context, timeout time.Duration) *CanaryCleanup {
+	cleanup := &CanaryCleanup{}
+	if v.readOnly {
+		return cleanup
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := v.getClient(ctx)
+	if err != nil {
+		cleanup.Err = fmt.Errorf("failed to create AWS client: %w", err)
+		return cleanup
+	}
+
+	now := v.clock.Now()
+	var errs []error
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(v.bucket), Prefix: aws.String(canaryRoot)}
+	for {
+		out, err := client.ListObjectsV2(ctx, input)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		for _, object := range out.Contents {
+			key := aws.ToString(object.Key)
+			expiry, ok := canaryExpiry(key, aws.ToTime(object.LastModified), v.canaries.ttl)
+			if !ok || now.Before(expiry) {
+				continue
+			}
+			if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(v.bucket), Key: aws.String(key)}); err != nil {
+				errs = append(errs, fmt.Errorf("delete %s: %w", key, err))
+				continue
+			}
+			cleanup.Deleted = append(cleanup.Deleted, key)
+		}
+		if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+			break
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+	if len(errs) > 0 {
+		cleanup.Err = errors.Join(errs...)
+	}
+	return cleanup
+}
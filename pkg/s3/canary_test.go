@@ -0,0 +1,97 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+func TestCanaryNamesCarryExpiryAndReplica(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	client := &mockS3Client{}
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, WithClock(clk), WithCanaryNaming("pod/a", 10*time.Minute))
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+
+	if result := validator.ValidateDeep(context.Background(), time.Second); !result.IsValid {
+		t.Fatalf("expected the deep probe to pass, got %+v", result)
+	}
+	want := fmt.Sprintf("%s%d-pod_a-", deepProbeKeyPrefix, clk.Now().Add(10*time.Minute).Unix())
+	if len(client.deleted) != 1 || !strings.HasPrefix(client.deleted[0], want) {
+		t.Fatalf("expected a probe object named %s..., got %v", want, client.deleted)
+	}
+	if expiry, ok := canaryExpiry(client.deleted[0], time.Time{}, time.Hour); !ok || !expiry.Equal(clk.Now().Add(10*time.Minute)) {
+		t.Fatalf("expected the expiry to be read back from the key, got %s", expiry)
+	}
+}
+
+func TestCanaryExpiry(t *testing.T) {
+	modified := time.Unix(1700000000, 0)
+	tests := []struct {
+		key  string
+		want time.Time
+		ok   bool
+	}{
+		{deepProbeKeyPrefix + "1700000600-pod-a-1700000000000000000", time.Unix(1700000600, 0), true},
+		{kmsProbeKeyPrefix + "1700000600-pod-a-1", time.Unix(1700000600, 0), true},
+		{accessLogCanaryPrefix + accessLogCanaryName + "1700000600-pod-a-1", time.Unix(1700000600, 0), true},
+		{discoverProbeKeyPrefix + "1700000600-pod-a-1", time.Unix(1700000600, 0), true},
+		{deepProbeKeyPrefix + "1700000000000000000", modified.Add(time.Hour), true}, // written before canary naming
+		{deepProbeKeyPrefix + "notes-1", time.Time{}, false},
+		{canaryRoot + "README", time.Time{}, false},
+		{"data/" + deepProbeKeyPrefix + "1700000600-pod-a-1", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := canaryExpiry(tt.key, modified, time.Hour)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("canaryExpiry(%q) = %s, %v; want %s, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCleanCanariesDeletesOnlyExpired(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	expired := fmt.Sprintf("%d-other-1", clk.Now().Add(-time.Second).Unix())
+	live := fmt.Sprintf("%d-other-2", clk.Now().Add(time.Minute).Unix())
+	client := &mockS3Client{objects: map[string]string{
+		deepProbeKeyPrefix + expired:                          "",
+		kmsProbeKeyPrefix + expired:                           "",
+		deepProbeKeyPrefix + live:                             "",
+		canaryRoot + "README":                                 "",
+		"data/" + deepProbeKeyPrefix + expired:                "",
+		accessLogCanaryPrefix + accessLogCanaryName + expired: "",
+	}}
+	newValidator := func(opts ...Option) *S3Validator {
+		validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, append(opts, WithClock(clk))...)
+		validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+			return client, nil
+		}
+		return validator
+	}
+
+	cleanup := newValidator().CleanCanaries(context.Background(), time.Second)
+	if cleanup.Err != nil {
+		t.Fatalf("unexpected error: %v", cleanup.Err)
+	}
+	sort.Strings(cleanup.Deleted)
+	want := []string{
+		accessLogCanaryPrefix + accessLogCanaryName + expired,
+		kmsProbeKeyPrefix + expired,
+		deepProbeKeyPrefix + expired,
+	}
+	sort.Strings(want)
+	if fmt.Sprint(cleanup.Deleted) != fmt.Sprint(want) {
+		t.Fatalf("expected only expired canaries deleted, got %v", cleanup.Deleted)
+	}
+
+	client.deleted = nil
+	if cleanup := newValidator(WithReadOnly()).CleanCanaries(context.Background(), time.Second); len(client.deleted) != 0 || cleanup.Err != nil {
+		t.Fatalf("expected read-only validators to leave the bucket alone, deleted %v", client.deleted)
+	}
+}
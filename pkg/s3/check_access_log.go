@@ -0,0 +1,198 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CheckAccessLog is the name of the server access logging self-verification check
+const CheckAccessLog = "access_log"
+
+const (
+	// DefaultAccessLogDelay is the minimum wait before looking for the canary's log record
+	DefaultAccessLogDelay = time.Hour
+	// DefaultAccessLogMaxWait is how long a canary may stay undelivered before the check fails
+	DefaultAccessLogMaxWait = 6 * time.Hour
+
+	accessLogCanaryPrefix = ".key-aws-exporter/"
+	accessLogCanaryName   = "access-log-canary-"
+
+	// accessLogScanLimit bounds how many log objects a single run reads
+	accessLogScanLimit = 1000
+	// accessLogMaxObjectSize caps how much of each log object is searched
+	accessLogMaxObjectSize = 10 << 20
+
+	// accessLogTimeLayout matches the timestamp in simple-format log object keys
+	accessLogTimeLayout = "2006-01-02-15-04-05"
+)
+
+// AccessLogCheckConfig configures the access logging self-verification check
+type AccessLogCheckConfig struct {
+	TargetBucket string        // bucket receiving the server access logs
+	TargetPrefix string        // prefix of the log objects (simple key format)
+	Delay        time.Duration // minimum wait before searching for the canary record
+	MaxWait      time.Duration // report failure if the record has not appeared by then
+}
+
+// WithAccessLogCheck writes a canary object and later verifies that a matching record
+// shows up in the server access logs, proving the audit pipeline end to end
+func WithAccessLogCheck(cfg AccessLogCheckConfig) Option {
+	if cfg.Delay <= 0 {
+		cfg.Delay = DefaultAccessLogDelay
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = DefaultAccessLogMaxWait
+	}
+	return withCheck(&accessLogCheck{cfg: cfg})
+}
+
+// accessLogCheck keeps one canary in flight. The scheduledCheck mutex guards its state.
+type accessLogCheck struct {
+	cfg AccessLogCheckConfig
+
+	canaryName string    // unique part of the canary key searched for in log records
+	writtenAt  time.Time // when the canary was written
+	cursor     string    // last log object already searched for this canary
+	names      canaryNamer
+	clock      clock.Clock // nil uses the real clock
+}
+
+func (c *accessLogCheck) useCanaryNames(names canaryNamer) {
+	c.names = names
+}
+
+func (c *accessLogCheck) useClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+func (c *accessLogCheck) name() string {
+	return CheckAccessLog
+}
+
+// mutates reports that the check writes a probe object
+func (c *accessLogCheck) mutates() bool {
+	return true
+}
+
+func (c *accessLogCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	if c.canaryName == "" {
+		if err := c.writeCanary(ctx, client, bucket); err != nil {
+			return false, fmt.Sprintf("failed to write access log canary: %v", err), true
+		}
+		return false, "", false
+	}
+
+	age := clock.OrReal(c.clock).Since(c.writtenAt)
+	if age < c.cfg.Delay {
+		return false, "", false
+	}
+
+	found, err := c.searchLogs(ctx, client)
+	if err != nil {
+		return false, fmt.Sprintf("failed to read access logs from %s: %v", c.cfg.TargetBucket, err), true
+	}
+
+	switch {
+	case found:
+		message := fmt.Sprintf("access log record for %s delivered within %s", c.canaryName, age.Round(time.Second))
+		c.restart(ctx, client, bucket)
+		return true, message, true
+	case age >= c.cfg.MaxWait:
+		message := fmt.Sprintf("no access log record for %s in %s/%s after %s", c.canaryName, c.cfg.TargetBucket, c.cfg.TargetPrefix, age.Round(time.Second))
+		c.restart(ctx, client, bucket)
+		return false, message, true
+	default:
+		return false, "", false
+	}
+}
+
+// restart starts the next canary right away; a failed write is retried on the next run
+func (c *accessLogCheck) restart(ctx context.Context, client s3ProbeClient, bucket string) {
+	c.canaryName = ""
+	_ = c.writeCanary(ctx, client, bucket)
+}
+
+func (c *accessLogCheck) writeCanary(ctx context.Context, client s3ProbeClient, bucket string) error {
+	now := clock.OrReal(c.clock).Now()
+	name := accessLogCanaryName + c.names.name()
+	key := accessLogCanaryPrefix + name
+
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   strings.NewReader("key-aws-exporter access log canary"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The PUT is already logged; the object itself is not needed
+	_, _ = client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	c.canaryName = name
+	c.writtenAt = now
+	// Log objects are named after their delivery time, so skip everything older than the canary
+	c.cursor = c.cfg.TargetPrefix + now.Add(-time.Minute).UTC().Format(accessLogTimeLayout)
+	return nil
+}
+
+// searchLogs reads log objects newer than the cursor looking for the canary name
+func (c *accessLogCheck) searchLogs(ctx context.Context, client s3ProbeClient) (bool, error) {
+	scanned := 0
+	for scanned < accessLogScanLimit {
+		out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:     aws.String(c.cfg.TargetBucket),
+			Prefix:     aws.String(c.cfg.TargetPrefix),
+			StartAfter: aws.String(c.cursor),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		for _, obj := range out.Contents {
+			key := aws.ToString(obj.Key)
+			found, err := c.objectContainsCanary(ctx, client, key)
+			if err != nil {
+				return false, err
+			}
+			c.cursor = key
+			scanned++
+			if found {
+				return true, nil
+			}
+		}
+
+		if !aws.ToBool(out.IsTruncated) || len(out.Contents) == 0 {
+			break
+		}
+	}
+	return false, nil
+}
+
+func (c *accessLogCheck) objectContainsCanary(ctx context.Context, client s3ProbeClient, key string) (bool, error) {
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.cfg.TargetBucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return false, err
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(out.Body, accessLogMaxObjectSize))
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(body), c.canaryName), nil
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+func newTestAccessLogCheck() *accessLogCheck {
+	return &accessLogCheck{cfg: AccessLogCheckConfig{
+		TargetBucket: "logs",
+		TargetPrefix: "access/",
+		Delay:        time.Hour,
+		MaxWait:      3 * time.Hour,
+	}}
+}
+
+func TestAccessLogCheckWritesCanary(t *testing.T) {
+	client := &mockS3Client{}
+	check := newTestAccessLogCheck()
+
+	_, _, done := check.run(context.Background(), client, "bucket")
+
+	if done {
+		t.Fatalf("expected no verdict right after writing the canary")
+	}
+	if check.canaryName == "" || !strings.HasPrefix(check.canaryName, accessLogCanaryName) {
+		t.Fatalf("expected canary to be recorded, got %q", check.canaryName)
+	}
+	if len(client.deleted) != 1 || !strings.HasSuffix(client.deleted[0], check.canaryName) {
+		t.Fatalf("expected canary object to be deleted, got %v", client.deleted)
+	}
+}
+
+func TestAccessLogCheckWaitsForDelay(t *testing.T) {
+	client := &mockS3Client{}
+	check := newTestAccessLogCheck()
+	check.run(context.Background(), client, "bucket")
+	client.called = false
+
+	_, _, done := check.run(context.Background(), client, "bucket")
+
+	if done {
+		t.Fatalf("expected no verdict before the delay elapsed")
+	}
+	if client.called {
+		t.Fatalf("expected logs not to be listed before the delay elapsed")
+	}
+}
+
+func TestAccessLogCheckFindsRecord(t *testing.T) {
+	client := &mockS3Client{}
+	check := newTestAccessLogCheck()
+	check.run(context.Background(), client, "bucket")
+	canary := check.canaryName
+	check.writtenAt = check.writtenAt.Add(-2 * time.Hour)
+
+	logKey := "access/" + time.Now().UTC().Format(accessLogTimeLayout) + "-ABCDEF"
+	client.objects["access/2000-01-01-00-00-00-OLD"] = "REST.PUT.OBJECT .key-aws-exporter/" + canary
+	client.objects[logKey] = "bucket [16/Oct/2026:10:00:00 +0000] REST.PUT.OBJECT .key-aws-exporter/" + canary + " 200"
+
+	passed, message, done := check.run(context.Background(), client, "bucket")
+
+	if !done || !passed {
+		t.Fatalf("expected passing verdict, got passed=%v done=%v message=%q", passed, done, message)
+	}
+	if check.canaryName == canary {
+		t.Fatalf("expected a new canary after the verdict")
+	}
+}
+
+func TestAccessLogCheckFailsAfterMaxWait(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	client := &mockS3Client{}
+	check := newTestAccessLogCheck()
+	check.useClock(clk)
+	check.run(context.Background(), client, "bucket")
+	clk.Advance(2 * time.Hour)
+
+	if _, _, done := check.run(context.Background(), client, "bucket"); done {
+		t.Fatalf("expected pending verdict while within max wait")
+	}
+
+	clk.Advance(2 * time.Hour)
+	passed, message, done := check.run(context.Background(), client, "bucket")
+
+	if !done || passed {
+		t.Fatalf("expected failing verdict, got passed=%v done=%v", passed, done)
+	}
+	if !strings.Contains(message, "no access log record") {
+		t.Fatalf("unexpected message %q", message)
+	}
+}
+
+func TestAccessLogCheckWriteFailure(t *testing.T) {
+	client := &mockS3Client{putErr: errors.New("denied")}
+	check := newTestAccessLogCheck()
+
+	passed, message, done := check.run(context.Background(), client, "bucket")
+
+	if !done || passed {
+		t.Fatalf("expected failing verdict, got passed=%v done=%v", passed, done)
+	}
+	if !strings.Contains(message, "denied") {
+		t.Fatalf("expected error in message, got %q", message)
+	}
+}
+
+func TestWithAccessLogCheckDefaults(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false,
+		WithAccessLogCheck(AccessLogCheckConfig{TargetBucket: "logs"}))
+
+	if len(validator.checks) != 1 {
+		t.Fatalf("expected one check, got %d", len(validator.checks))
+	}
+	check := validator.checks[0].check.(*accessLogCheck)
+	if check.cfg.Delay != DefaultAccessLogDelay || check.cfg.MaxWait != DefaultAccessLogMaxWait {
+		t.Fatalf("expected defaults, got %+v", check.cfg)
+	}
+}
@@ -0,0 +1,215 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+// CheckCORS is the name of the CORS configuration check
+const CheckCORS = "cors"
+
+// CORSRule is one expected rule of the bucket's CORS configuration
+type CORSRule struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposeHeaders  []string
+	MaxAgeSeconds  int32
+}
+
+// CORSCheckConfig describes the CORS policy a bucket serving web assets must have
+type CORSCheckConfig struct {
+	// Rules are compared with the bucket's CORS rules in order; empty skips the comparison
+	Rules []CORSRule
+	// Origin sends a preflight request from this origin; empty skips the preflight
+	Origin string
+	// Method is the Access-Control-Request-Method of the preflight; empty uses GET
+	Method string
+	// URL receives the preflight; empty uses the bucket's S3 URL
+	URL string
+}
+
+// WithCORSCheck verifies the bucket's CORS rules and that a browser preflight from the
+// configured origin is allowed
+func WithCORSCheck(cfg CORSCheckConfig) Option {
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	return withCheck(&corsCheck{cfg: cfg})
+}
+
+type bucketCORSAPI interface {
+	GetBucketCors(ctx context.Context, params *s3.GetBucketCorsInput, optFns ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error)
+}
+
+type corsCheck struct {
+	cfg    CORSCheckConfig
+	client aws.HTTPClient
+}
+
+func (c *corsCheck) name() string {
+	return CheckCORS
+}
+
+// useHTTP sends the preflight through the validator's transport, to its bucket URL
+// unless one is configured
+func (c *corsCheck) useHTTP(v *S3Validator) {
+	c.client = v.httpClient()
+	if c.cfg.URL == "" {
+		c.cfg.URL = v.bucketURL()
+	}
+}
+
+func (c *corsCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	var problems []string
+	if len(c.cfg.Rules) > 0 {
+		api, ok := client.(bucketCORSAPI)
+		if !ok {
+			return false, "client does not support GetBucketCors", true
+		}
+		out, err := api.GetBucketCors(ctx, &s3.GetBucketCorsInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchCORSConfiguration" {
+				return false, "bucket has no CORS configuration", true
+			}
+			return false, fmt.Sprintf("failed to read CORS configuration: %v", err), true
+		}
+		problems = append(problems, compareCORSRules(c.cfg.Rules, out.CORSRules)...)
+	}
+	if c.cfg.Origin != "" {
+		if problem := c.preflight(ctx); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return false, "CORS mismatch: " + strings.Join(problems, "; "), true
+	}
+	if c.cfg.Origin == "" {
+		return true, fmt.Sprintf("CORS configuration matches the expected %d rules", len(c.cfg.Rules)), true
+	}
+	return true, fmt.Sprintf("CORS allows %s from %s", c.cfg.Method, c.cfg.Origin), true
+}
+
+// compareCORSRules lists the differences between the expected and the actual rules.
+// Rules are compared in order since S3 applies the first one matching a request;
+// the values within a rule are compared as sets.
+func compareCORSRules(expected []CORSRule, actual []types.CORSRule) []string {
+	if len(expected) != len(actual) {
+		return []string{fmt.Sprintf("bucket has %d CORS rules, expected %d", len(actual), len(expected))}
+	}
+	var problems []string
+	for i, want := range expected {
+		got := actual[i]
+		fields := []struct {
+			name      string
+			want, got []string
+		}{
+			{"allowed origins", want.AllowedOrigins, got.AllowedOrigins},
+			{"allowed methods", upper(want.AllowedMethods), upper(got.AllowedMethods)},
+			{"allowed headers", lower(want.AllowedHeaders), lower(got.AllowedHeaders)},
+			{"expose headers", lower(want.ExposeHeaders), lower(got.ExposeHeaders)},
+		}
+		for _, field := range fields {
+			if !sameSet(field.want, field.got) {
+				problems = append(problems, fmt.Sprintf("rule %d %s are [%s], expected [%s]", i+1, field.name, strings.Join(field.got, " "), strings.Join(field.want, " ")))
+			}
+		}
+		if maxAge := aws.ToInt32(got.MaxAgeSeconds); maxAge != want.MaxAgeSeconds {
+			problems = append(problems, fmt.Sprintf("rule %d max age is %ds, expected %ds", i+1, maxAge, want.MaxAgeSeconds))
+		}
+	}
+	return problems
+}
+
+// preflight sends the OPTIONS request a browser sends before a cross-origin request
+// and reports why it would be refused, or "" when it is allowed
+func (c *corsCheck) preflight(ctx context.Context) string {
+	if c.cfg.URL == "" {
+		return "preflight skipped: no URL to send it to"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, c.cfg.URL, nil)
+	if err != nil {
+		return fmt.Sprintf("invalid preflight URL: %v", err)
+	}
+	req.Header.Set("Origin", c.cfg.Origin)
+	req.Header.Set("Access-Control-Request-Method", c.cfg.Method)
+
+	var client aws.HTTPClient = http.DefaultClient
+	if c.client != nil {
+		client = c.client
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Sprintf("preflight failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Sprintf("preflight from %s returned HTTP %d", c.cfg.Origin, resp.StatusCode)
+	}
+	if allowed := resp.Header.Get("Access-Control-Allow-Origin"); allowed != "*" && allowed != c.cfg.Origin {
+		return fmt.Sprintf("preflight allows origin %q, expected %q", allowed, c.cfg.Origin)
+	}
+	if methods := resp.Header.Get("Access-Control-Allow-Methods"); methods != "" && !slices.Contains(upper(strings.Split(methods, ",")), c.cfg.Method) {
+		return fmt.Sprintf("preflight allows methods %q, expected %s", methods, c.cfg.Method)
+	}
+	return ""
+}
+
+// bucketURL returns the HTTPS URL of the bucket on its S3 endpoint
+func (v *S3Validator) bucketURL() string {
+	if v.endpoint == "" {
+		region := v.region
+		if region == "" {
+			region = "us-east-1"
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", v.bucket, region)
+	}
+	u, err := url.Parse(v.endpoint)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if v.usePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + v.bucket + "/"
+	} else {
+		u.Host = v.bucket + "." + u.Host
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+func sameSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func upper(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = strings.ToUpper(strings.TrimSpace(value))
+	}
+	return out
+}
+
+func lower(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = strings.ToLower(strings.TrimSpace(value))
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockCORSClient struct {
+	mockS3Client
+	rules []types.CORSRule
+	err   error
+}
+
+func (m *mockCORSClient) GetBucketCors(_ context.Context, _ *s3.GetBucketCorsInput, _ ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.GetBucketCorsOutput{CORSRules: m.rules}, nil
+}
+
+// corsServer answers preflights like S3 with a rule allowing origin to GET and HEAD
+func corsServer(t *testing.T, origin string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions || r.Header.Get("Origin") != origin || r.Header.Get("Access-Control-Request-Method") == "PUT" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCORSCheckRules(t *testing.T) {
+	expected := []CORSRule{{
+		AllowedOrigins: []string{"https://www.example.com"},
+		AllowedMethods: []string{"GET", "HEAD"},
+		AllowedHeaders: []string{"Authorization"},
+		MaxAgeSeconds:  3000,
+	}}
+	actual := func(methods ...string) []types.CORSRule {
+		return []types.CORSRule{{
+			AllowedOrigins: []string{"https://www.example.com"},
+			AllowedMethods: methods,
+			AllowedHeaders: []string{"authorization"},
+			MaxAgeSeconds:  aws.Int32(3000),
+		}}
+	}
+	tests := []struct {
+		name    string
+		client  *mockCORSClient
+		passed  bool
+		message string
+	}{
+		{name: "matching", client: &mockCORSClient{rules: actual("head", "GET")}, passed: true, message: "matches the expected 1 rules"},
+		{name: "extra method", client: &mockCORSClient{rules: actual("GET", "HEAD", "PUT")}, message: "rule 1 allowed methods are [GET HEAD PUT], expected [GET HEAD]"},
+		{name: "extra rule", client: &mockCORSClient{rules: append(actual("GET", "HEAD"), actual("PUT")...)}, message: "bucket has 2 CORS rules, expected 1"},
+		{name: "no configuration", client: &mockCORSClient{err: &mockAPIError{code: "NoSuchCORSConfiguration"}}, message: "no CORS configuration"},
+	}
+
+	check := &corsCheck{cfg: CORSCheckConfig{Rules: expected}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message, done := check.run(context.Background(), tt.client, "bucket")
+			if !done || passed != tt.passed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.passed, passed, message)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
+
+func TestCORSCheckPreflight(t *testing.T) {
+	origin := "https://www.example.com"
+	server := corsServer(t, origin)
+
+	tests := []struct {
+		name    string
+		cfg     CORSCheckConfig
+		passed  bool
+		message string
+	}{
+		{name: "allowed", cfg: CORSCheckConfig{Origin: origin, URL: server.URL}, passed: true, message: "allows GET from " + origin},
+		{name: "other origin", cfg: CORSCheckConfig{Origin: "https://evil.example.com", URL: server.URL}, message: "returned HTTP 403"},
+		{name: "refused method", cfg: CORSCheckConfig{Origin: origin, Method: "put", URL: server.URL}, message: "returned HTTP 403"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewS3Validator("", "us-east-1", "assets", "ak", "sk", "", false, false, WithCORSCheck(tt.cfg))
+			passed, message, _ := validator.checks[0].check.run(context.Background(), &mockS3Client{}, "assets")
+			if passed != tt.passed || !strings.Contains(message, tt.message) {
+				t.Fatalf("expected passed=%v with %q, got %v (%s)", tt.passed, tt.message, passed, message)
+			}
+		})
+	}
+}
+
+func TestBucketURL(t *testing.T) {
+	tests := []struct {
+		endpoint  string
+		pathStyle bool
+		want      string
+	}{
+		{"", false, "https://assets.s3.eu-west-1.amazonaws.com/"},
+		{"https://minio.local:9000", true, "https://minio.local:9000/assets/"},
+		{"https://storage.example.com", false, "https://assets.storage.example.com/"},
+	}
+	for _, tt := range tests {
+		validator := NewS3Validator(tt.endpoint, "eu-west-1", "assets", "ak", "sk", "", tt.pathStyle, false)
+		if got := validator.bucketURL(); got != tt.want {
+			t.Errorf("bucketURL(%q, pathStyle=%v) = %q, want %q", tt.endpoint, tt.pathStyle, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CheckInventory is the name of the storage class sampling check
+const CheckInventory = "inventory"
+
+// DefaultInventorySampleSize is how many objects are sampled when no size is configured
+const DefaultInventorySampleSize = 1000
+
+// InventoryCheckConfig selects the objects sampled by the inventory check
+type InventoryCheckConfig struct {
+	Prefix     string // key prefix to sample; empty samples the whole bucket
+	SampleSize int    // objects listed per run; 0 uses DefaultInventorySampleSize
+}
+
+// WithInventoryCheck samples objects from the bucket listing and counts them by storage
+// class, making lifecycle drift (everything landing in STANDARD) visible
+func WithInventoryCheck(cfg InventoryCheckConfig) Option {
+	return withCheck(&inventoryCheck{cfg: cfg})
+}
+
+// countingCheck is implemented by checks whose verdict comes with counts by category.
+// It is read right after run, under the scheduledCheck lock.
+type countingCheck interface {
+	counts() map[string]int
+}
+
+type inventoryCheck struct {
+	cfg     InventoryCheckConfig
+	classes map[string]int
+}
+
+func (c *inventoryCheck) name() string {
+	return CheckInventory
+}
+
+func (c *inventoryCheck) counts() map[string]int {
+	return c.classes
+}
+
+func (c *inventoryCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	c.classes = nil
+	size := c.cfg.SampleSize
+	if size <= 0 {
+		size = DefaultInventorySampleSize
+	}
+
+	classes := make(map[string]int)
+	sampled := 0
+	var token *string
+	for sampled < size {
+		out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(bucket),
+			Prefix:            aws.String(c.cfg.Prefix),
+			MaxKeys:           aws.Int32(int32(min(size-sampled, 1000))),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return false, fmt.Sprintf("failed to list objects: %v", err), true
+		}
+		for _, object := range out.Contents {
+			if sampled == size {
+				break
+			}
+			class := string(object.StorageClass)
+			if class == "" {
+				class = "STANDARD"
+			}
+			classes[class]++
+			sampled++
+		}
+		if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+			break
+		}
+		token = out.NextContinuationToken
+	}
+
+	c.classes = classes
+	if sampled == 0 {
+		return true, "no objects to sample", true
+	}
+	return true, fmt.Sprintf("sampled %d objects: %s", sampled, formatClassCounts(classes)), true
+}
+
+// formatClassCounts lists the counts by class, largest first
+func formatClassCounts(classes map[string]int) string {
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, class := range names {
+		parts[i] = fmt.Sprintf("%s %d", class, classes[class])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,86 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// pagedListClient serves objects in pages of MaxKeys, like S3
+type pagedListClient struct {
+	mockS3Client
+	objects []types.Object
+	calls   int
+}
+
+func (m *pagedListClient) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.calls++
+	start := 0
+	if in.ContinuationToken != nil {
+		fmt.Sscan(*in.ContinuationToken, &start)
+	}
+	end := min(start+int(aws.ToInt32(in.MaxKeys)), len(m.objects))
+	out := &s3.ListObjectsV2Output{Contents: m.objects[start:end], IsTruncated: aws.Bool(end < len(m.objects))}
+	if end < len(m.objects) {
+		out.NextContinuationToken = aws.String(fmt.Sprint(end))
+	}
+	return out, nil
+}
+
+func objectsInClasses(classes map[types.ObjectStorageClass]int) []types.Object {
+	var objects []types.Object
+	for class, n := range classes {
+		for range n {
+			objects = append(objects, types.Object{Key: aws.String("k"), StorageClass: class})
+		}
+	}
+	return objects
+}
+
+func TestInventoryCheckCountsStorageClasses(t *testing.T) {
+	client := &pagedListClient{objects: objectsInClasses(map[types.ObjectStorageClass]int{"": 3, types.ObjectStorageClassGlacier: 2})}
+	sc := &scheduledCheck{check: &inventoryCheck{cfg: InventoryCheckConfig{SampleSize: 10}}}
+
+	result, ok := sc.runIfDue(context.Background(), client, "bucket", clock.Real, DefaultCheckInterval, false)
+	if !ok || !result.Passed {
+		t.Fatalf("expected a passing verdict, got %+v", result)
+	}
+	if result.Counts["STANDARD"] != 3 || result.Counts["GLACIER"] != 2 {
+		t.Fatalf("expected unset classes to count as STANDARD, got %v", result.Counts)
+	}
+	if result.Message != "sampled 5 objects: STANDARD 3, GLACIER 2" {
+		t.Fatalf("unexpected message %q", result.Message)
+	}
+}
+
+func TestInventoryCheckStopsAtSampleSize(t *testing.T) {
+	client := &pagedListClient{objects: objectsInClasses(map[types.ObjectStorageClass]int{types.ObjectStorageClassStandardIa: 2500})}
+	check := &inventoryCheck{}
+
+	if passed, _, _ := check.run(context.Background(), client, "bucket"); !passed {
+		t.Fatalf("expected a passing verdict")
+	}
+	if check.counts()["STANDARD_IA"] != DefaultInventorySampleSize || client.calls != 1 {
+		t.Fatalf("expected one page of the default sample size, got %v in %d calls", check.counts(), client.calls)
+	}
+
+	check = &inventoryCheck{cfg: InventoryCheckConfig{SampleSize: 2200}}
+	check.run(context.Background(), client, "bucket")
+	if check.counts()["STANDARD_IA"] != 2200 {
+		t.Fatalf("expected the sample to span pages, got %v", check.counts())
+	}
+}
+
+func TestInventoryCheckListFailure(t *testing.T) {
+	check := &inventoryCheck{classes: map[string]int{"STANDARD": 1}}
+	passed, _, done := check.run(context.Background(), &mockS3Client{err: &mockAPIError{code: "AccessDenied"}}, "bucket")
+	if passed || !done || check.counts() != nil {
+		t.Fatalf("expected a failed verdict without counts, got passed=%v counts=%v", passed, check.counts())
+	}
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// CheckKMSKey is the name of the SSE-KMS key usability check
+const CheckKMSKey = "kms_key"
+
+// kmsProbeKeyPrefix names the objects written by the KMS check
+const kmsProbeKeyPrefix = ".key-aws-exporter/kms-probe-"
+
+// WithKMSKeyCheck writes a small SSE-KMS encrypted object, reads it back and deletes it
+// to catch revoked KMS grants or disabled keys. An empty keyID uses the bucket's default key.
+func WithKMSKeyCheck(keyID string) Option {
+	return withCheck(&kmsKeyCheck{keyID: keyID})
+}
+
+type kmsKeyCheck struct {
+	keyID string
+	names canaryNamer
+}
+
+func (c *kmsKeyCheck) useCanaryNames(names canaryNamer) {
+	c.names = names
+}
+
+func (c *kmsKeyCheck) name() string {
+	return CheckKMSKey
+}
+
+// mutates reports that the check writes a probe object
+func (c *kmsKeyCheck) mutates() bool {
+	return true
+}
+
+func (c *kmsKeyCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	key := kmsProbeKeyPrefix + c.names.name()
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 strings.NewReader("key-aws-exporter kms probe"),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	}
+	if c.keyID != "" {
+		input.SSEKMSKeyId = aws.String(c.keyID)
+	}
+	if _, err := client.PutObject(ctx, input); err != nil {
+		return false, fmt.Sprintf("failed to write SSE-KMS object with %s: %v", c.describeKey(), err), true
+	}
+
+	// Reading back needs kms:Decrypt, which can be revoked independently of GenerateDataKey
+	readErr := func() error {
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return err
+		}
+		defer out.Body.Close()
+		_, err = io.Copy(io.Discard, out.Body)
+		return err
+	}()
+
+	_, deleteErr := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	if readErr != nil {
+		return false, fmt.Sprintf("failed to read SSE-KMS object encrypted with %s: %v", c.describeKey(), readErr), true
+	}
+	if deleteErr != nil {
+		return true, fmt.Sprintf("%s is usable (probe object cleanup failed: %v)", c.describeKey(), deleteErr), true
+	}
+	return true, fmt.Sprintf("%s is usable for encrypt and decrypt", c.describeKey()), true
+}
+
+func (c *kmsKeyCheck) describeKey() string {
+	if c.keyID == "" {
+		return "bucket default KMS key"
+	}
+	return "KMS key " + c.keyID
+}
@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type recordingPutClient struct {
+	mockS3Client
+	lastPut *s3.PutObjectInput
+}
+
+func (m *recordingPutClient) PutObject(ctx context.Context, in *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.lastPut = in
+	return m.mockS3Client.PutObject(ctx, in, opts...)
+}
+
+func TestKMSKeyCheckUsable(t *testing.T) {
+	client := &recordingPutClient{}
+	check := &kmsKeyCheck{keyID: "alias/backups"}
+
+	passed, message, done := check.run(context.Background(), client, "bucket")
+
+	if !passed || !done {
+		t.Fatalf("expected passing verdict, got passed=%v done=%v (%s)", passed, done, message)
+	}
+	if client.lastPut.ServerSideEncryption != types.ServerSideEncryptionAwsKms || *client.lastPut.SSEKMSKeyId != "alias/backups" {
+		t.Fatalf("expected SSE-KMS put with the configured key, got %+v", client.lastPut)
+	}
+	if len(client.deleted) != 1 || !strings.HasPrefix(client.deleted[0], kmsProbeKeyPrefix) {
+		t.Fatalf("expected probe object to be deleted, got %v", client.deleted)
+	}
+}
+
+func TestKMSKeyCheckDefaultKey(t *testing.T) {
+	client := &recordingPutClient{}
+
+	passed, message, _ := (&kmsKeyCheck{}).run(context.Background(), client, "bucket")
+
+	if !passed || client.lastPut.SSEKMSKeyId != nil {
+		t.Fatalf("expected bucket default key to be used, got %+v (%s)", client.lastPut, message)
+	}
+}
+
+func TestKMSKeyCheckRevoked(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *mockS3Client
+		message string
+	}{
+		{
+			name:    "encrypt denied",
+			client:  &mockS3Client{putErr: &mockAPIError{code: "KMS.DisabledException"}},
+			message: "failed to write",
+		},
+		{
+			name:    "decrypt denied",
+			client:  &mockS3Client{getErr: errors.New("kms:Decrypt denied")},
+			message: "failed to read",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message, done := (&kmsKeyCheck{keyID: "key"}).run(context.Background(), tt.client, "bucket")
+			if passed || !done {
+				t.Fatalf("expected failing verdict, got passed=%v done=%v", passed, done)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
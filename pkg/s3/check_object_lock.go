@@ -0,0 +1,91 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+// CheckObjectLock is the name of the Object Lock retention check
+const CheckObjectLock = "object_lock"
+
+// ObjectLockCheckConfig describes the default retention a WORM bucket must enforce
+type ObjectLockCheckConfig struct {
+	Mode  string // GOVERNANCE or COMPLIANCE
+	Days  int32  // expected default retention in days; 0 skips the comparison
+	Years int32  // expected default retention in years; 0 skips the comparison
+}
+
+// WithObjectLockCheck verifies that Object Lock is enabled with the expected default retention
+func WithObjectLockCheck(cfg ObjectLockCheckConfig) Option {
+	cfg.Mode = strings.ToUpper(cfg.Mode)
+	return withCheck(&objectLockCheck{cfg: cfg})
+}
+
+type objectLockAPI interface {
+	GetObjectLockConfiguration(ctx context.Context, params *s3.GetObjectLockConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error)
+}
+
+type objectLockCheck struct {
+	cfg ObjectLockCheckConfig
+}
+
+func (c *objectLockCheck) name() string {
+	return CheckObjectLock
+}
+
+func (c *objectLockCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	api, ok := client.(objectLockAPI)
+	if !ok {
+		return false, "client does not support GetObjectLockConfiguration", true
+	}
+
+	out, err := api.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ObjectLockConfigurationNotFoundError" {
+			return false, "object lock is not enabled on the bucket", true
+		}
+		return false, fmt.Sprintf("failed to read object lock configuration: %v", err), true
+	}
+
+	lock := out.ObjectLockConfiguration
+	if lock == nil || lock.ObjectLockEnabled != types.ObjectLockEnabledEnabled {
+		return false, "object lock is not enabled on the bucket", true
+	}
+	if lock.Rule == nil || lock.Rule.DefaultRetention == nil {
+		return false, "object lock has no default retention rule", true
+	}
+
+	retention := lock.Rule.DefaultRetention
+	var problems []string
+	if c.cfg.Mode != "" && string(retention.Mode) != c.cfg.Mode {
+		problems = append(problems, fmt.Sprintf("mode is %s, expected %s", retention.Mode, c.cfg.Mode))
+	}
+	if c.cfg.Days > 0 && aws.ToInt32(retention.Days) != c.cfg.Days {
+		problems = append(problems, fmt.Sprintf("retention is %d days, expected %d", aws.ToInt32(retention.Days), c.cfg.Days))
+	}
+	if c.cfg.Years > 0 && aws.ToInt32(retention.Years) != c.cfg.Years {
+		problems = append(problems, fmt.Sprintf("retention is %d years, expected %d", aws.ToInt32(retention.Years), c.cfg.Years))
+	}
+	if len(problems) > 0 {
+		return false, "object lock retention mismatch: " + strings.Join(problems, "; "), true
+	}
+
+	return true, fmt.Sprintf("object lock enforces %s retention of %s", retention.Mode, describeRetention(retention)), true
+}
+
+func describeRetention(retention *types.DefaultRetention) string {
+	if years := aws.ToInt32(retention.Years); years > 0 {
+		return fmt.Sprintf("%d years", years)
+	}
+	return fmt.Sprintf("%d days", aws.ToInt32(retention.Days))
+}
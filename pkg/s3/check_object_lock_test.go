@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockObjectLockClient struct {
+	mockS3Client
+	lock *types.ObjectLockConfiguration
+	err  error
+}
+
+func (m *mockObjectLockClient) GetObjectLockConfiguration(_ context.Context, _ *s3.GetObjectLockConfigurationInput, _ ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.GetObjectLockConfigurationOutput{ObjectLockConfiguration: m.lock}, nil
+}
+
+func lockConfig(mode types.ObjectLockRetentionMode, days int32) *types.ObjectLockConfiguration {
+	return &types.ObjectLockConfiguration{
+		ObjectLockEnabled: types.ObjectLockEnabledEnabled,
+		Rule: &types.ObjectLockRule{
+			DefaultRetention: &types.DefaultRetention{Mode: mode, Days: aws.Int32(days)},
+		},
+	}
+}
+
+func TestObjectLockCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *mockObjectLockClient
+		passed  bool
+		message string
+	}{
+		{
+			name:    "compliant",
+			client:  &mockObjectLockClient{lock: lockConfig(types.ObjectLockRetentionModeCompliance, 365)},
+			passed:  true,
+			message: "COMPLIANCE retention of 365 days",
+		},
+		{
+			name:    "wrong mode",
+			client:  &mockObjectLockClient{lock: lockConfig(types.ObjectLockRetentionModeGovernance, 365)},
+			message: "mode is GOVERNANCE, expected COMPLIANCE",
+		},
+		{
+			name:    "wrong days",
+			client:  &mockObjectLockClient{lock: lockConfig(types.ObjectLockRetentionModeCompliance, 30)},
+			message: "retention is 30 days, expected 365",
+		},
+		{
+			name:    "no default retention",
+			client:  &mockObjectLockClient{lock: &types.ObjectLockConfiguration{ObjectLockEnabled: types.ObjectLockEnabledEnabled}},
+			message: "no default retention",
+		},
+		{
+			name:    "not enabled",
+			client:  &mockObjectLockClient{err: &mockAPIError{code: "ObjectLockConfigurationNotFoundError"}},
+			message: "not enabled",
+		},
+	}
+
+	check := &objectLockCheck{cfg: ObjectLockCheckConfig{Mode: "COMPLIANCE", Days: 365}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message, done := check.run(context.Background(), tt.client, "bucket")
+			if !done {
+				t.Fatalf("expected a verdict")
+			}
+			if passed != tt.passed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.passed, passed, message)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
+
+func TestObjectLockCheckUnsupportedClient(t *testing.T) {
+	check := &objectLockCheck{}
+
+	passed, _, done := check.run(context.Background(), &mockS3Client{}, "bucket")
+
+	if passed || !done {
+		t.Fatalf("expected failing verdict for a client without object lock support")
+	}
+}
@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+// CheckPublicAccess is the name of the public exposure check
+const CheckPublicAccess = "public_access"
+
+// Grantee groups that make an ACL grant public
+const (
+	allUsersGroupURI           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersGroupURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// WithPublicAccessCheck flags buckets whose ACL or bucket policy grants public access.
+// Failures are critical: a leaked public bucket matters more than an expired key.
+func WithPublicAccessCheck() Option {
+	return withCheck(&publicAccessCheck{})
+}
+
+type bucketACLAPI interface {
+	GetBucketAcl(ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options)) (*s3.GetBucketAclOutput, error)
+}
+
+type bucketPolicyStatusAPI interface {
+	GetBucketPolicyStatus(ctx context.Context, params *s3.GetBucketPolicyStatusInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyStatusOutput, error)
+}
+
+type publicAccessCheck struct{}
+
+func (c *publicAccessCheck) name() string {
+	return CheckPublicAccess
+}
+
+func (c *publicAccessCheck) critical() bool {
+	return true
+}
+
+// run reports a verdict when at least one of the ACL and policy status could be read;
+// if neither is readable the exposure is unknown and no verdict is reported
+func (c *publicAccessCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	var findings []string
+	checked := 0
+
+	if api, ok := client.(bucketACLAPI); ok {
+		out, err := api.GetBucketAcl(ctx, &s3.GetBucketAclInput{Bucket: aws.String(bucket)})
+		if err == nil {
+			checked++
+			findings = append(findings, publicACLGrants(out.Grants)...)
+		}
+	}
+
+	if api, ok := client.(bucketPolicyStatusAPI); ok {
+		out, err := api.GetBucketPolicyStatus(ctx, &s3.GetBucketPolicyStatusInput{Bucket: aws.String(bucket)})
+		var apiErr smithy.APIError
+		switch {
+		case err == nil:
+			checked++
+			if out.PolicyStatus != nil && aws.ToBool(out.PolicyStatus.IsPublic) {
+				findings = append(findings, "bucket policy is public")
+			}
+		case errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy":
+			checked++
+		}
+	}
+
+	if checked == 0 {
+		return false, "", false
+	}
+	if len(findings) > 0 {
+		return false, "bucket is publicly exposed: " + strings.Join(findings, "; "), true
+	}
+	return true, "bucket is not publicly accessible", true
+}
+
+// publicACLGrants describes grants that give everyone (or every AWS account) access
+func publicACLGrants(grants []types.Grant) []string {
+	permissions := make(map[string][]string)
+	for _, grant := range grants {
+		if grant.Grantee == nil || grant.Grantee.Type != types.TypeGroup {
+			continue
+		}
+		var group string
+		switch aws.ToString(grant.Grantee.URI) {
+		case allUsersGroupURI:
+			group = "AllUsers"
+		case authenticatedUsersGroupURI:
+			group = "AuthenticatedUsers"
+		default:
+			continue
+		}
+		permissions[group] = append(permissions[group], string(grant.Permission))
+	}
+
+	var findings []string
+	for group, perms := range permissions {
+		sort.Strings(perms)
+		findings = append(findings, fmt.Sprintf("ACL grants %s to %s (%s)", strings.Join(perms, ", "), group, aclExposure(perms)))
+	}
+	sort.Strings(findings)
+	return findings
+}
+
+// aclExposure summarizes whether the permissions make the bucket readable, writable or both
+func aclExposure(perms []string) string {
+	var readable, writable bool
+	for _, perm := range perms {
+		switch types.Permission(perm) {
+		case types.PermissionRead, types.PermissionReadAcp:
+			readable = true
+		case types.PermissionWrite, types.PermissionWriteAcp:
+			writable = true
+		case types.PermissionFullControl:
+			readable, writable = true, true
+		}
+	}
+	switch {
+	case readable && writable:
+		return "readable and writable"
+	case writable:
+		return "writable"
+	default:
+		return "readable"
+	}
+}
@@ -0,0 +1,127 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockPublicAccessClient struct {
+	mockS3Client
+	grants    []types.Grant
+	aclErr    error
+	public    bool
+	policyErr error
+}
+
+func (m *mockPublicAccessClient) GetBucketAcl(_ context.Context, _ *s3.GetBucketAclInput, _ ...func(*s3.Options)) (*s3.GetBucketAclOutput, error) {
+	if m.aclErr != nil {
+		return nil, m.aclErr
+	}
+	return &s3.GetBucketAclOutput{Grants: m.grants}, nil
+}
+
+func (m *mockPublicAccessClient) GetBucketPolicyStatus(_ context.Context, _ *s3.GetBucketPolicyStatusInput, _ ...func(*s3.Options)) (*s3.GetBucketPolicyStatusOutput, error) {
+	if m.policyErr != nil {
+		return nil, m.policyErr
+	}
+	return &s3.GetBucketPolicyStatusOutput{PolicyStatus: &types.PolicyStatus{IsPublic: aws.Bool(m.public)}}, nil
+}
+
+func groupGrant(uri string, perm types.Permission) types.Grant {
+	return types.Grant{
+		Grantee:    &types.Grantee{Type: types.TypeGroup, URI: aws.String(uri)},
+		Permission: perm,
+	}
+}
+
+func TestPublicAccessCheck(t *testing.T) {
+	ownerGrant := types.Grant{
+		Grantee:    &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String("owner")},
+		Permission: types.PermissionFullControl,
+	}
+
+	tests := []struct {
+		name    string
+		client  *mockPublicAccessClient
+		passed  bool
+		message string
+	}{
+		{
+			name:    "private",
+			client:  &mockPublicAccessClient{grants: []types.Grant{ownerGrant}},
+			passed:  true,
+			message: "not publicly accessible",
+		},
+		{
+			name:    "public read acl",
+			client:  &mockPublicAccessClient{grants: []types.Grant{ownerGrant, groupGrant(allUsersGroupURI, types.PermissionRead)}},
+			message: "ACL grants READ to AllUsers (readable)",
+		},
+		{
+			name:    "authenticated users write",
+			client:  &mockPublicAccessClient{grants: []types.Grant{groupGrant(authenticatedUsersGroupURI, types.PermissionWrite)}},
+			message: "AuthenticatedUsers (writable)",
+		},
+		{
+			name:    "public policy",
+			client:  &mockPublicAccessClient{public: true},
+			message: "bucket policy is public",
+		},
+		{
+			name:    "no bucket policy",
+			client:  &mockPublicAccessClient{policyErr: &mockAPIError{code: "NoSuchBucketPolicy"}},
+			passed:  true,
+			message: "not publicly accessible",
+		},
+		{
+			name:    "acl readable only",
+			client:  &mockPublicAccessClient{policyErr: errors.New("not implemented"), grants: []types.Grant{groupGrant(allUsersGroupURI, types.PermissionFullControl)}},
+			message: "readable and writable",
+		},
+	}
+
+	check := &publicAccessCheck{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message, done := check.run(context.Background(), tt.client, "bucket")
+			if !done {
+				t.Fatalf("expected a verdict")
+			}
+			if passed != tt.passed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.passed, passed, message)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
+
+func TestPublicAccessCheckNoVerdictWithoutPermissions(t *testing.T) {
+	client := &mockPublicAccessClient{aclErr: errors.New("denied"), policyErr: errors.New("denied")}
+
+	if _, _, done := (&publicAccessCheck{}).run(context.Background(), client, "bucket"); done {
+		t.Fatalf("expected no verdict when neither ACL nor policy status is readable")
+	}
+}
+
+func TestPublicAccessFailureIsCritical(t *testing.T) {
+	client := &mockPublicAccessClient{public: true}
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, WithPublicAccessCheck())
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if len(result.Checks) != 1 || result.Checks[0].Passed || !result.Checks[0].Critical {
+		t.Fatalf("expected a critical failed check, got %+v", result.Checks)
+	}
+}
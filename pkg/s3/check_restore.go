@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CheckRestore is the name of the archived object restore status check
+const CheckRestore = "restore"
+
+// RestoreCheckConfig names the archived object whose restore is watched
+type RestoreCheckConfig struct {
+	Key string // object in the GLACIER or DEEP_ARCHIVE storage class, or archived by Intelligent-Tiering
+}
+
+// WithRestoreCheck reports the restore status of an archived object, e.g. during DR
+// rehearsals. The check passes once a restored copy is available and is pending while
+// the restore is in progress.
+func WithRestoreCheck(cfg RestoreCheckConfig) Option {
+	return withCheck(&restoreCheck{cfg: cfg})
+}
+
+// pendingCheck is implemented by checks whose last verdict may wait on an operation
+// that is still running. It is read right after run, under the scheduledCheck lock.
+type pendingCheck interface {
+	pending() bool
+}
+
+type headObjectAPI interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+// restoreHeaderPattern parses x-amz-restore: ongoing-request="false", expiry-date="..."
+var restoreHeaderPattern = regexp.MustCompile(`ongoing-request="(true|false)"(?:,\s*expiry-date="([^"]+)")?`)
+
+type restoreCheck struct {
+	cfg        RestoreCheckConfig
+	inProgress bool
+}
+
+func (c *restoreCheck) name() string {
+	return CheckRestore
+}
+
+func (c *restoreCheck) pending() bool {
+	return c.inProgress
+}
+
+func (c *restoreCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	c.inProgress = false
+	api, ok := client.(headObjectAPI)
+	if !ok {
+		return false, "client does not support HeadObject", true
+	}
+
+	out, err := api.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(c.cfg.Key),
+	})
+	if err != nil {
+		return false, fmt.Sprintf("failed to read %s: %v", c.cfg.Key, err), true
+	}
+
+	restore := aws.ToString(out.Restore)
+	match := restoreHeaderPattern.FindStringSubmatch(restore)
+	switch {
+	case restore == "":
+		class := string(out.StorageClass)
+		if archive := string(out.ArchiveStatus); archive != "" {
+			class = archive
+		}
+		if class == "" {
+			class = "STANDARD"
+		}
+		return false, fmt.Sprintf("no restore of %s was requested (storage class %s)", c.cfg.Key, class), true
+	case match == nil:
+		return false, fmt.Sprintf("unrecognized restore status of %s: %s", c.cfg.Key, restore), true
+	case match[1] == "true":
+		c.inProgress = true
+		return false, fmt.Sprintf("restore of %s is in progress", c.cfg.Key), true
+	case match[2] != "":
+		return true, fmt.Sprintf("restored copy of %s is available until %s", c.cfg.Key, match[2]), true
+	default:
+		return true, fmt.Sprintf("restored copy of %s is available", c.cfg.Key), true
+	}
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockHeadObjectClient struct {
+	mockS3Client
+	head *s3.HeadObjectOutput
+	err  error
+}
+
+func (m *mockHeadObjectClient) HeadObject(_ context.Context, _ *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.head, nil
+}
+
+func TestRestoreCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    *s3.HeadObjectOutput
+		passed  bool
+		pending bool
+		message string
+	}{
+		{
+			name:    "not requested",
+			head:    &s3.HeadObjectOutput{StorageClass: types.StorageClassDeepArchive},
+			message: "no restore of dr/backup.tar was requested (storage class DEEP_ARCHIVE)",
+		},
+		{
+			name:    "in progress",
+			head:    &s3.HeadObjectOutput{StorageClass: types.StorageClassGlacier, Restore: aws.String(`ongoing-request="true"`)},
+			pending: true,
+			message: "in progress",
+		},
+		{
+			name:    "completed",
+			head:    &s3.HeadObjectOutput{StorageClass: types.StorageClassGlacier, Restore: aws.String(`ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`)},
+			passed:  true,
+			message: "available until Fri, 21 Dec 2012 00:00:00 GMT",
+		},
+		{
+			name:    "intelligent tiering archive",
+			head:    &s3.HeadObjectOutput{StorageClass: types.StorageClassIntelligentTiering, ArchiveStatus: types.ArchiveStatusDeepArchiveAccess},
+			message: "storage class DEEP_ARCHIVE_ACCESS",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sc := &scheduledCheck{check: &restoreCheck{cfg: RestoreCheckConfig{Key: "dr/backup.tar"}}}
+			result, ok := sc.runIfDue(context.Background(), &mockHeadObjectClient{head: tc.head}, "bucket", clock.Real, time.Hour, false)
+			if !ok {
+				t.Fatalf("expected a verdict")
+			}
+			if result.Passed != tc.passed || result.Pending != tc.pending {
+				t.Fatalf("expected passed=%v pending=%v, got %+v", tc.passed, tc.pending, result)
+			}
+			if !strings.Contains(result.Message, tc.message) {
+				t.Fatalf("expected message to contain %q, got %q", tc.message, result.Message)
+			}
+		})
+	}
+}
+
+func TestRestoreCheckHeadFailure(t *testing.T) {
+	check := &restoreCheck{cfg: RestoreCheckConfig{Key: "missing"}}
+	passed, message, done := check.run(context.Background(), &mockHeadObjectClient{err: &mockAPIError{code: "NotFound"}}, "bucket")
+	if passed || !done || check.pending() || !strings.Contains(message, "failed to read missing") {
+		t.Fatalf("expected a failed verdict for an unreadable object, got %v %q", passed, message)
+	}
+}
@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// CheckTags is the name of the required bucket tags check
+const CheckTags = "tags"
+
+// WithTagsCheck verifies that the bucket carries every required tag with a non-empty value
+func WithTagsCheck(required []string) Option {
+	return withCheck(&tagsCheck{required: required})
+}
+
+type bucketTaggingAPI interface {
+	GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error)
+}
+
+type tagsCheck struct {
+	required []string
+}
+
+func (c *tagsCheck) name() string {
+	return CheckTags
+}
+
+func (c *tagsCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	api, ok := client.(bucketTaggingAPI)
+	if !ok {
+		return false, "client does not support GetBucketTagging", true
+	}
+
+	tags := make(map[string]string)
+	out, err := api.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchTagSet" {
+			return false, fmt.Sprintf("failed to read bucket tags: %v", err), true
+		}
+		// A bucket without tags misses every required one
+	} else {
+		for _, tag := range out.TagSet {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	var missing []string
+	for _, key := range c.required {
+		if strings.TrimSpace(tags[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return false, "bucket is missing required tags: " + strings.Join(missing, ", "), true
+	}
+	return true, fmt.Sprintf("bucket carries all %d required tags", len(c.required)), true
+}
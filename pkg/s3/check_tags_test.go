@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockTaggingClient struct {
+	mockS3Client
+	tags map[string]string
+	err  error
+}
+
+func (m *mockTaggingClient) GetBucketTagging(_ context.Context, _ *s3.GetBucketTaggingInput, _ ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := &s3.GetBucketTaggingOutput{}
+	for key, value := range m.tags {
+		out.TagSet = append(out.TagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return out, nil
+}
+
+func TestTagsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *mockTaggingClient
+		passed  bool
+		message string
+	}{
+		{
+			name:    "compliant",
+			client:  &mockTaggingClient{tags: map[string]string{"cost-center": "1234", "owner": "storage", "env": "prod"}},
+			passed:  true,
+			message: "all 2 required tags",
+		},
+		{
+			name:    "missing tag",
+			client:  &mockTaggingClient{tags: map[string]string{"cost-center": "1234"}},
+			message: "missing required tags: owner",
+		},
+		{
+			name:    "empty value",
+			client:  &mockTaggingClient{tags: map[string]string{"cost-center": " ", "owner": "storage"}},
+			message: "missing required tags: cost-center",
+		},
+		{
+			name:    "no tag set",
+			client:  &mockTaggingClient{err: &mockAPIError{code: "NoSuchTagSet"}},
+			message: "missing required tags: cost-center, owner",
+		},
+		{
+			name:    "denied",
+			client:  &mockTaggingClient{err: errors.New("AccessDenied")},
+			message: "failed to read bucket tags",
+		},
+	}
+
+	check := &tagsCheck{required: []string{"owner", "cost-center"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, message, done := check.run(context.Background(), tt.client, "bucket")
+			if !done {
+				t.Fatalf("expected a verdict")
+			}
+			if passed != tt.passed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.passed, passed, message)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
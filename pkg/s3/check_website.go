@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// CheckWebsite is the name of the static website hosting check
+const CheckWebsite = "website"
+
+// websiteDashRegions use the s3-website-<region> form of the website endpoint; every
+// other region uses s3-website.<region>
+var websiteDashRegions = []string{
+	"us-east-1", "us-west-1", "us-west-2", "eu-west-1", "ap-southeast-1",
+	"ap-southeast-2", "ap-northeast-1", "sa-east-1", "us-gov-west-1",
+}
+
+// WebsiteCheckConfig describes the static website a bucket must serve
+type WebsiteCheckConfig struct {
+	// IndexDocument is compared with the bucket's website configuration; empty skips it
+	IndexDocument string
+	// URL is the site root; empty uses the bucket's AWS website endpoint
+	URL string
+}
+
+// WithWebsiteCheck verifies that the bucket's website endpoint serves its index
+// document with HTTP 200
+func WithWebsiteCheck(cfg WebsiteCheckConfig) Option {
+	return withCheck(&websiteCheck{cfg: cfg})
+}
+
+type bucketWebsiteAPI interface {
+	GetBucketWebsite(ctx context.Context, params *s3.GetBucketWebsiteInput, optFns ...func(*s3.Options)) (*s3.GetBucketWebsiteOutput, error)
+}
+
+type websiteCheck struct {
+	cfg    WebsiteCheckConfig
+	client aws.HTTPClient
+}
+
+func (c *websiteCheck) name() string {
+	return CheckWebsite
+}
+
+// useHTTP requests the site through the validator's transport, from its AWS website
+// endpoint unless a URL is configured
+func (c *websiteCheck) useHTTP(v *S3Validator) {
+	c.client = v.httpClient()
+	if c.cfg.URL == "" && v.endpoint == "" {
+		c.cfg.URL = websiteURL(v.bucket, v.region)
+	}
+}
+
+func (c *websiteCheck) run(ctx context.Context, client s3ProbeClient, bucket string) (bool, string, bool) {
+	if c.cfg.URL == "" {
+		return false, "no website URL: set checks.website.url for endpoints outside AWS", true
+	}
+
+	if c.cfg.IndexDocument != "" {
+		api, ok := client.(bucketWebsiteAPI)
+		if !ok {
+			return false, "client does not support GetBucketWebsite", true
+		}
+		out, err := api.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchWebsiteConfiguration" {
+				return false, "website hosting is not enabled on the bucket", true
+			}
+			return false, fmt.Sprintf("failed to read website configuration: %v", err), true
+		}
+		if out.IndexDocument == nil || aws.ToString(out.IndexDocument.Suffix) != c.cfg.IndexDocument {
+			var suffix string
+			if out.IndexDocument != nil {
+				suffix = aws.ToString(out.IndexDocument.Suffix)
+			}
+			return false, fmt.Sprintf("website index document is %q, expected %q", suffix, c.cfg.IndexDocument), true
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		return false, fmt.Sprintf("invalid website URL: %v", err), true
+	}
+	var httpClient aws.HTTPClient = http.DefaultClient
+	if c.client != nil {
+		httpClient = c.client
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Sprintf("website request failed: %v", err), true
+	}
+	defer resp.Body.Close()
+	// Read the whole page so a connection cut off mid-body fails the check
+	size, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		return false, fmt.Sprintf("website response failed: %v", err), true
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Sprintf("website %s returned HTTP %d", c.cfg.URL, resp.StatusCode), true
+	}
+	return true, fmt.Sprintf("website %s served %d bytes", c.cfg.URL, size), true
+}
+
+// websiteURL returns the root of the bucket's S3 website endpoint
+func websiteURL(bucket, region string) string {
+	if region == "" {
+		region = "us-east-1"
+	}
+	if slices.Contains(websiteDashRegions, region) {
+		return fmt.Sprintf("http://%s.s3-website-%s.amazonaws.com/", bucket, region)
+	}
+	return fmt.Sprintf("http://%s.s3-website.%s.amazonaws.com/", bucket, region)
+}
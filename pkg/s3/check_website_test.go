@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type mockWebsiteClient struct {
+	mockS3Client
+	index string
+	err   error
+}
+
+func (m *mockWebsiteClient) GetBucketWebsite(_ context.Context, _ *s3.GetBucketWebsiteInput, _ ...func(*s3.Options)) (*s3.GetBucketWebsiteOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.GetBucketWebsiteOutput{IndexDocument: &types.IndexDocument{Suffix: aws.String(m.index)}}, nil
+}
+
+func TestWebsiteCheck(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html>marketing</html>"))
+	}))
+	defer site.Close()
+
+	tests := []struct {
+		name    string
+		cfg     WebsiteCheckConfig
+		client  *mockWebsiteClient
+		passed  bool
+		message string
+	}{
+		{name: "serving", cfg: WebsiteCheckConfig{URL: site.URL + "/"}, client: &mockWebsiteClient{}, passed: true, message: "served 22 bytes"},
+		{name: "index matches", cfg: WebsiteCheckConfig{URL: site.URL + "/", IndexDocument: "index.html"}, client: &mockWebsiteClient{index: "index.html"}, passed: true, message: "served"},
+		{name: "not found", cfg: WebsiteCheckConfig{URL: site.URL + "/missing"}, client: &mockWebsiteClient{}, message: "returned HTTP 404"},
+		{name: "other index", cfg: WebsiteCheckConfig{URL: site.URL + "/", IndexDocument: "index.html"}, client: &mockWebsiteClient{index: "home.html"}, message: `index document is "home.html"`},
+		{name: "hosting disabled", cfg: WebsiteCheckConfig{URL: site.URL + "/", IndexDocument: "index.html"}, client: &mockWebsiteClient{err: &mockAPIError{code: "NoSuchWebsiteConfiguration"}}, message: "not enabled"},
+		{name: "no url", cfg: WebsiteCheckConfig{}, client: &mockWebsiteClient{}, message: "no website URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &websiteCheck{cfg: tt.cfg}
+			passed, message, done := check.run(context.Background(), tt.client, "site")
+			if !done || passed != tt.passed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.passed, passed, message)
+			}
+			if !strings.Contains(message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
+
+func TestWebsiteURL(t *testing.T) {
+	tests := map[string]string{
+		"":             "http://site.s3-website-us-east-1.amazonaws.com/",
+		"eu-west-1":    "http://site.s3-website-eu-west-1.amazonaws.com/",
+		"eu-central-1": "http://site.s3-website.eu-central-1.amazonaws.com/",
+	}
+	for region, want := range tests {
+		if got := websiteURL("site", region); got != want {
+			t.Errorf("websiteURL(%q) = %q, want %q", region, got, want)
+		}
+	}
+
+	custom := NewS3Validator("https://minio.local:9000", "us-east-1", "site", "ak", "sk", "", true, false, WithWebsiteCheck(WebsiteCheckConfig{}))
+	if url := custom.checks[0].check.(*websiteCheck).cfg.URL; url != "" {
+		t.Fatalf("expected no website URL guessed for a custom endpoint, got %q", url)
+	}
+}
@@ -0,0 +1,164 @@
+package s3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+const (
+	// DefaultCheckInterval is how often bucket checks run when no interval is configured
+	DefaultCheckInterval = time.Hour
+	// DefaultCheckTimeout bounds a single check run when no timeout is configured
+	DefaultCheckTimeout = 2 * time.Minute
+)
+
+// CheckResult is the outcome of an optional bucket check. Checks verify bucket
+// configuration or pipelines beyond the credentials and never affect IsValid.
+type CheckResult struct {
+	Name      string
+	Passed    bool
+	Critical  bool // failed verdict that needs immediate attention, such as a public bucket
+	Pending   bool // failed verdict waiting on an operation still running, such as an object restore
+	Message   string
+	Counts    map[string]int // objects by category, such as the storage classes sampled by the inventory check
+	CheckedAt time.Time
+	Duration  time.Duration
+}
+
+// bucketCheck is implemented by every optional check. run reports done=false when
+// the check has no verdict yet (for example while waiting for log delivery).
+type bucketCheck interface {
+	name() string
+	run(ctx context.Context, client s3ProbeClient, bucket string) (passed bool, message string, done bool)
+}
+
+// criticalCheck is implemented by checks whose failures are critical
+type criticalCheck interface {
+	critical() bool
+}
+
+// httpCheck is implemented by checks that make plain HTTP requests next to the S3 API,
+// which go through the validator's transport settings
+type httpCheck interface {
+	useHTTP(v *S3Validator)
+}
+
+// clockedCheck is implemented by checks that measure time, which read the validator's clock
+type clockedCheck interface {
+	useClock(clk clock.Clock)
+}
+
+// scheduledCheck throttles a check to its interval. The mutex also serializes
+// stateful checks shared between region clones.
+type scheduledCheck struct {
+	check bucketCheck
+
+	mu      sync.Mutex
+	lastRun time.Time
+}
+
+// WithCheckInterval sets how often bucket checks run; validations in between skip them
+func WithCheckInterval(interval time.Duration) Option {
+	return func(s *validatorSettings) {
+		s.checkInterval = interval
+	}
+}
+
+// WithCheckTimeout bounds each check run. Checks such as the access log search read far
+// more than a probe, so they get their own deadline instead of the validation timeout.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(s *validatorSettings) {
+		s.checkTimeout = timeout
+	}
+}
+
+func withCheck(check bucketCheck) Option {
+	return func(s *validatorSettings) {
+		s.checks = append(s.checks, &scheduledCheck{check: check})
+	}
+}
+
+// runChecks runs every check that is due, each within the check timeout, and returns
+// the fresh verdicts
+func (v *S3Validator) runChecks(ctx context.Context, client s3ProbeClient) []CheckResult {
+	interval := v.checkInterval
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	timeout := v.checkTimeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	var results []CheckResult
+	for _, sc := range v.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, ok := sc.runIfDue(checkCtx, client, v.bucket, v.clock, interval, v.readOnly)
+		cancel()
+		if ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// runIfDue runs the check once its interval has passed. With readOnly, checks that
+// write fail without running.
+func (sc *scheduledCheck) runIfDue(ctx context.Context, client s3ProbeClient, bucket string, clk clock.Clock, interval time.Duration, readOnly bool) (CheckResult, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	now := clk.Now()
+	if !sc.lastRun.IsZero() && now.Sub(sc.lastRun) < interval {
+		return CheckResult{}, false
+	}
+	sc.lastRun = now
+
+	if mc, ok := sc.check.(mutatingCheck); ok && readOnly && mc.mutates() {
+		return CheckResult{
+			Name:      sc.check.name(),
+			Message:   readOnlyMessage("the " + sc.check.name() + " check"),
+			CheckedAt: now,
+		}, true
+	}
+
+	passed, message, done := sc.check.run(ctx, client, bucket)
+	if !done {
+		return CheckResult{}, false
+	}
+
+	critical := false
+	if cc, ok := sc.check.(criticalCheck); ok {
+		critical = !passed && cc.critical()
+	}
+	pending := false
+	if pc, ok := sc.check.(pendingCheck); ok {
+		pending = !passed && pc.pending()
+	}
+
+	var counts map[string]int
+	if cc, ok := sc.check.(countingCheck); ok {
+		counts = cc.counts()
+	}
+
+	return CheckResult{
+		Name:      sc.check.name(),
+		Passed:    passed,
+		Critical:  critical,
+		Pending:   pending,
+		Message:   message,
+		Counts:    counts,
+		CheckedAt: now,
+		Duration:  clk.Since(now),
+	}, true
+}
+
+// reset makes the check due on the next validation
+func (sc *scheduledCheck) reset() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.lastRun = time.Time{}
+}
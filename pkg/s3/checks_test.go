@@ -0,0 +1,125 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+type stubCheck struct {
+	runs     int
+	passed   bool
+	message  string
+	done     bool
+	deadline time.Time // of the last run's context
+}
+
+func (c *stubCheck) name() string { return "stub" }
+
+func (c *stubCheck) run(ctx context.Context, _ s3ProbeClient, _ string) (bool, string, bool) {
+	c.runs++
+	c.deadline, _ = ctx.Deadline()
+	return c.passed, c.message, c.done
+}
+
+func newCheckedValidator(client s3ProbeClient, check bucketCheck, opts ...Option) *S3Validator {
+	opts = append(opts, withCheck(check))
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, opts...)
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+	return validator
+}
+
+func TestChecksRunAfterSuccessfulValidation(t *testing.T) {
+	check := &stubCheck{passed: true, message: "ok", done: true}
+	validator := newCheckedValidator(&mockS3Client{}, check)
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected validation success, got %s", result.Message)
+	}
+	if len(result.Checks) != 1 {
+		t.Fatalf("expected one check result, got %+v", result.Checks)
+	}
+	got := result.Checks[0]
+	if got.Name != "stub" || !got.Passed || got.Message != "ok" {
+		t.Fatalf("unexpected check result %+v", got)
+	}
+}
+
+func TestChecksRunWithTheirOwnTimeout(t *testing.T) {
+	check := &stubCheck{passed: true, done: true}
+	validator := newCheckedValidator(&mockS3Client{}, check, WithCheckTimeout(time.Minute))
+
+	start := time.Now()
+	validator.ValidateKeys(context.Background(), time.Second)
+
+	if left := check.deadline.Sub(start); left < 30*time.Second {
+		t.Fatalf("expected the check timeout instead of the validation timeout, got %s", left)
+	}
+}
+
+func TestChecksSkippedWhenValidationFails(t *testing.T) {
+	check := &stubCheck{done: true}
+	validator := newCheckedValidator(&mockS3Client{err: errors.New("boom")}, check)
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected validation failure")
+	}
+	if check.runs != 0 || len(result.Checks) != 0 {
+		t.Fatalf("expected checks to be skipped, ran %d times with %+v", check.runs, result.Checks)
+	}
+}
+
+func TestChecksThrottledByInterval(t *testing.T) {
+	check := &stubCheck{passed: true, done: true}
+	validator := newCheckedValidator(&mockS3Client{}, check, WithCheckInterval(time.Hour))
+
+	validator.ValidateKeys(context.Background(), time.Second)
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if check.runs != 1 {
+		t.Fatalf("expected check to run once within the interval, ran %d times", check.runs)
+	}
+	if len(result.Checks) != 0 {
+		t.Fatalf("expected no fresh verdict on the throttled run, got %+v", result.Checks)
+	}
+}
+
+func TestChecksRerunAfterInterval(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	check := &stubCheck{passed: true, done: true}
+	validator := newCheckedValidator(&mockS3Client{}, check, WithCheckInterval(time.Hour), WithClock(clk))
+
+	validator.ValidateKeys(context.Background(), time.Second)
+	clk.Advance(time.Hour)
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if check.runs != 2 {
+		t.Fatalf("expected check to run again after the interval, ran %d times", check.runs)
+	}
+	if len(result.Checks) != 1 || !result.Checks[0].CheckedAt.Equal(clk.Now()) {
+		t.Fatalf("expected a fresh verdict stamped with the clock, got %+v", result.Checks)
+	}
+}
+
+func TestChecksPendingVerdictOmitted(t *testing.T) {
+	check := &stubCheck{done: false}
+	validator := newCheckedValidator(&mockS3Client{}, check)
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if check.runs != 1 {
+		t.Fatalf("expected check to run, ran %d times", check.runs)
+	}
+	if len(result.Checks) != 0 {
+		t.Fatalf("expected pending check to be omitted, got %+v", result.Checks)
+	}
+}
@@ -0,0 +1,12 @@
+package s3
+
+import "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+// WithChecksumAlgorithm makes write probes upload with an additional checksum such as
+// CRC32C or SHA256, and verify it when reading the object back, for buckets or gateways
+// that require a specific algorithm
+func WithChecksumAlgorithm(algorithm string) Option {
+	return func(s *validatorSettings) {
+		s.checksumAlgorithm = types.ChecksumAlgorithm(algorithm)
+	}
+}
@@ -0,0 +1,50 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeepProbeUploadsAndVerifiesChecksum(t *testing.T) {
+	var mu sync.Mutex
+	var putChecksum, getMode string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/xml")
+		switch r.Method {
+		case http.MethodPut:
+			_, _ = io.Copy(io.Discard, r.Body)
+			putChecksum = r.Header.Get("X-Amz-Sdk-Checksum-Algorithm")
+		case http.MethodGet:
+			if r.URL.Query().Has("list-type") {
+				_, _ = w.Write([]byte(`<ListBucketResult><Name>bucket</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+				return
+			}
+			getMode = r.Header.Get("X-Amz-Checksum-Mode")
+			_, _ = w.Write([]byte("key-aws-exporter probe"))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithChecksumAlgorithm("CRC32C"))
+	if result := validator.ValidateDeep(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected the deep probe to succeed: %s", result.Message)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if putChecksum != "CRC32C" {
+		t.Fatalf("expected the upload to carry a CRC32C checksum, got %q", putChecksum)
+	}
+	if getMode != "ENABLED" {
+		t.Fatalf("expected the read back to ask for checksum validation, got %q", getMode)
+	}
+}
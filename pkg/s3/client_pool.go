@@ -0,0 +1,172 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// ClientPool shares SDK configurations between validators with identical credentials,
+// endpoint, region and transport settings. Sharing the configuration shares its HTTP
+// client, so such validators reuse one connection pool, one set of TLS sessions and one
+// credential cache instead of opening their own. Each validator still builds its own
+// lightweight S3 client on top, keeping its User-Agent and request headers.
+//
+// Configurations unused for the idle timeout are dropped, and those older than the max
+// lifetime are rebuilt on their next use, so a long-running exporter neither keeps
+// clients of removed endpoints nor holds on to connections and credentials forever.
+type ClientPool struct {
+	idleTimeout time.Duration // 0 keeps unused configurations
+	maxLifetime time.Duration // 0 never rebuilds configurations
+	clock       clock.Clock
+
+	mu      sync.Mutex
+	configs map[string]*pooledConfig
+}
+
+// pooledConfig is a shared configuration and when it was built and last used
+type pooledConfig struct {
+	cfg      aws.Config
+	created  time.Time
+	lastUsed time.Time
+}
+
+// PoolOption customizes a client pool
+type PoolOption func(*ClientPool)
+
+// WithIdleTimeout drops configurations no validation has used for d
+func WithIdleTimeout(d time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.idleTimeout = d
+	}
+}
+
+// WithMaxLifetime rebuilds configurations, with new connections and credentials, once
+// they are older than d
+func WithMaxLifetime(d time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.maxLifetime = d
+	}
+}
+
+// WithPoolClock sets the clock idle and lifetime limits are measured with
+func WithPoolClock(c clock.Clock) PoolOption {
+	return func(p *ClientPool) {
+		p.clock = c
+	}
+}
+
+// NewClientPool creates an empty pool
+func NewClientPool(opts ...PoolOption) *ClientPool {
+	p := &ClientPool{configs: make(map[string]*pooledConfig)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.clock = clock.OrReal(p.clock)
+	return p
+}
+
+// WithClientPool makes the validator share SDK configurations through pool
+func WithClientPool(pool *ClientPool) Option {
+	return func(s *validatorSettings) {
+		s.clientPool = pool
+	}
+}
+
+// Len returns the number of distinct configurations in the pool
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked(p.clock.Now())
+	return len(p.configs)
+}
+
+// config returns the configuration stored under key and when it was built, loading it
+// on first use or once it expired. Loading happens under the lock, so concurrent first
+// validations share one configuration.
+func (p *ClientPool) config(ctx context.Context, key string, load func(context.Context) (aws.Config, error)) (aws.Config, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	p.expireLocked(now)
+	if entry, ok := p.configs[key]; ok {
+		entry.lastUsed = now
+		return entry.cfg, entry.created, nil
+	}
+	cfg, err := load(ctx)
+	if err != nil {
+		return aws.Config{}, time.Time{}, err
+	}
+	p.configs[key] = &pooledConfig{cfg: cfg, created: now, lastUsed: now}
+	return cfg, now, nil
+}
+
+// current reports whether the configuration built at created is still the one pooled
+// under key, marking it used. A validator whose client was built from an evicted or
+// rebuilt configuration must build a new one.
+func (p *ClientPool) current(key string, created time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	p.expireLocked(now)
+	entry, ok := p.configs[key]
+	if !ok || !entry.created.Equal(created) {
+		return false
+	}
+	entry.lastUsed = now
+	return true
+}
+
+// forget drops the configuration stored under key, so the next validation using it
+// opens new connections
+func (p *ClientPool) forget(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.configs, key)
+}
+
+// expireLocked drops configurations past the idle timeout or the max lifetime. Their
+// idle connections are closed by the transport's own idle timeout.
+func (p *ClientPool) expireLocked(now time.Time) {
+	for key, entry := range p.configs {
+		idle := p.idleTimeout > 0 && !now.Before(entry.lastUsed.Add(p.idleTimeout))
+		old := p.maxLifetime > 0 && !now.Before(entry.created.Add(p.maxLifetime))
+		if idle || old {
+			delete(p.configs, key)
+		}
+	}
+}
+
+// clientKey identifies the settings that shape the SDK configuration. Secrets are hashed
+// so the key can be logged or inspected safely.
+func (s *validatorSettings) clientKey() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\x00%s\x00%s\x00%s\x00%s\x00", s.endpoint, s.region, s.accessKey, s.secretKey, s.sessionToken)
+	fmt.Fprintf(&b, "%t\x00%s\x00%s\x00", s.insecureSkipVerify, s.ipFamily, strings.Join(s.dnsServers, ","))
+	for _, host := range slices.Sorted(maps.Keys(s.resolve)) {
+		fmt.Fprintf(&b, "%s=%s,", host, s.resolve[host])
+	}
+	if proxy := s.socks5Proxy; proxy != nil {
+		fmt.Fprintf(&b, "\x00%s\x00%s\x00%s", proxy.Address, proxy.Username, proxy.Password)
+	}
+	if s.roleARN != "" {
+		fmt.Fprintf(&b, "\x00%s\x00%s\x00%s", s.roleARN, s.externalID, s.stsEndpoint)
+	}
+	if s.clientCertFile != "" {
+		fmt.Fprintf(&b, "\x00%s\x00%s", s.clientCertFile, s.clientKeyFile)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+)
+
+func TestClientPoolSharesConnectionsBetweenIdenticalEndpoints(t *testing.T) {
+	var conns atomic.Int32
+	server := newListBucketServer()
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	defer server.Close()
+
+	pool := NewClientPool()
+	first := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool), WithUserAgent("first"))
+	second := NewS3Validator(server.URL, "us-east-1", "other", "ak", "sk", "", true, false, WithClientPool(pool), WithUserAgent("second"))
+
+	for _, v := range []*S3Validator{first, second, first} {
+		if result := v.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+			t.Fatalf("expected validation to succeed: %s", result.Message)
+		}
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("expected one shared configuration, got %d", pool.Len())
+	}
+	if got := conns.Load(); got != 1 {
+		t.Fatalf("expected both endpoints to reuse one connection, got %d", got)
+	}
+}
+
+func TestClientPoolSeparatesDifferentCredentials(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	pool := NewClientPool()
+	validators := []*S3Validator{
+		NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool)),
+		NewS3Validator(server.URL, "us-east-1", "bucket", "ak2", "sk", "", true, false, WithClientPool(pool)),
+		NewS3Validator(server.URL, "eu-west-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool)),
+		NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, true, WithClientPool(pool)),
+	}
+	for _, v := range validators {
+		v.ValidateKeys(context.Background(), 5*time.Second)
+	}
+	if pool.Len() != len(validators) {
+		t.Fatalf("expected one configuration per distinct credential set, got %d", pool.Len())
+	}
+}
+
+func TestRefreshForgetsPooledConfiguration(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	pool := NewClientPool()
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool))
+	validator.ValidateKeys(context.Background(), 5*time.Second)
+
+	validator.Refresh()
+	if pool.Len() != 0 {
+		t.Fatalf("expected refresh to drop the pooled configuration, got %d", pool.Len())
+	}
+}
+
+func TestClientKeyIgnoresResolveOrder(t *testing.T) {
+	a := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithResolve(map[string]string{"a": "1.1.1.1", "b": "2.2.2.2"}))
+	b := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithResolve(map[string]string{"b": "2.2.2.2", "a": "1.1.1.1"}))
+	if a.clientKey() != b.clientKey() {
+		t.Fatalf("expected identical settings to share a key")
+	}
+	c := NewS3Validator("", "us-east-1", "bucket", "ak", "other", "", false, false, WithResolve(map[string]string{"a": "1.1.1.1", "b": "2.2.2.2"}))
+	if a.clientKey() == c.clientKey() {
+		t.Fatalf("expected a different secret to change the key")
+	}
+}
+
+func TestClientPoolEvictsIdleConfigurations(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	pool := NewClientPool(WithIdleTimeout(10*time.Minute), WithPoolClock(clk))
+	used := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool))
+	unused := NewS3Validator(server.URL, "us-east-1", "bucket", "ak2", "sk", "", true, false, WithClientPool(pool))
+	used.ValidateKeys(context.Background(), 5*time.Second)
+	unused.ValidateKeys(context.Background(), 5*time.Second)
+
+	clk.Advance(6 * time.Minute)
+	used.ValidateKeys(context.Background(), 5*time.Second)
+	clk.Advance(6 * time.Minute)
+	if pool.Len() != 1 {
+		t.Fatalf("expected only the idle configuration to be evicted, got %d", pool.Len())
+	}
+
+	clk.Advance(10 * time.Minute)
+	if pool.Len() != 0 {
+		t.Fatalf("expected every configuration to be evicted once idle, got %d", pool.Len())
+	}
+	if result := unused.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid || pool.Len() != 1 {
+		t.Fatalf("expected an evicted client to be rebuilt on its next validation: %s", result.Message)
+	}
+}
+
+func TestClientPoolRebuildsClientsAfterMaxLifetime(t *testing.T) {
+	var conns atomic.Int32
+	server := newListBucketServer()
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	defer server.Close()
+
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	pool := NewClientPool(WithMaxLifetime(time.Hour), WithPoolClock(clk))
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithClientPool(pool))
+
+	validator.ValidateKeys(context.Background(), 5*time.Second)
+	first := validator.client
+	clk.Advance(59 * time.Minute)
+	validator.ValidateKeys(context.Background(), 5*time.Second)
+	if validator.client != first || conns.Load() != 1 {
+		t.Fatalf("expected the client and connection to be reused within the lifetime, got %d connections", conns.Load())
+	}
+
+	clk.Advance(time.Minute)
+	if result := validator.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected validation to succeed: %s", result.Message)
+	}
+	if validator.client == first || conns.Load() != 2 {
+		t.Fatalf("expected a new client and connection after the max lifetime, got %d connections", conns.Load())
+	}
+}
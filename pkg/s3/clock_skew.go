@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// errorTypeClockSkew marks requests rejected because the exporter's clock is off
+const errorTypeClockSkew = "clock_skew"
+
+// IsClockSkewError reports whether the error type means S3 rejected the request signature time
+func IsClockSkewError(errorType string) bool {
+	return errorType == errorTypeClockSkew
+}
+
+// serverClockSkew derives how far the server clock is ahead of the local clock from the
+// Date header of the error response
+func serverClockSkew(err error, now time.Time) (time.Duration, bool) {
+	var respErr *smithyhttp.ResponseError
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return 0, false
+	}
+
+	serverTime, parseErr := http.ParseTime(respErr.Response.Header.Get("Date"))
+	if parseErr != nil {
+		return 0, false
+	}
+	// The Date header has second resolution
+	return serverTime.Sub(now).Round(time.Second), true
+}
+
+// describeClockSkew turns the skew into a hint pointing at the exporter host's clock
+func describeClockSkew(skew time.Duration) string {
+	direction := "behind"
+	if skew < 0 {
+		direction = "ahead of"
+		skew = -skew
+	}
+	return fmt.Sprintf("exporter clock is %s %s the S3 server clock; check NTP on this host", skew, direction)
+}
@@ -0,0 +1,49 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKeysReportsClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(20*time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>RequestTimeTooSkewed</Code><Message>The difference between the request time and the current time is too large.</Message></Error>`))
+	}))
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false)
+
+	result := validator.ValidateKeys(context.Background(), 10*time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected validation to fail")
+	}
+	if !IsClockSkewError(result.ErrorType) {
+		t.Fatalf("expected clock skew error type, got %s (%s)", result.ErrorType, result.Message)
+	}
+	if result.ClockSkew < 19*time.Minute || result.ClockSkew > 21*time.Minute {
+		t.Fatalf("expected ~20m skew from the Date header, got %s", result.ClockSkew)
+	}
+	if !strings.Contains(result.Message, "behind the S3 server clock") {
+		t.Fatalf("expected skew hint in message, got %q", result.Message)
+	}
+}
+
+func TestClassifyValidationErrorClockSkew(t *testing.T) {
+	if got := classifyValidationError(&mockAPIError{code: "RequestTimeTooSkewed"}); got != errorTypeClockSkew {
+		t.Fatalf("expected clock skew error type, got %s", got)
+	}
+}
+
+func TestDescribeClockSkew(t *testing.T) {
+	if got := describeClockSkew(-90 * time.Second); !strings.Contains(got, "1m30s ahead of") {
+		t.Fatalf("unexpected description %q", got)
+	}
+}
@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithy "github.com/aws/smithy-go"
+)
+
+// Operation labels for the calls made only by permission discovery
+const (
+	OperationMultipartUpload = "MultipartUpload"
+	OperationGetBucketPolicy = "GetBucketPolicy"
+)
+
+// Permission verdicts reported by Discover
+const (
+	PermissionAllowed = "allowed"
+	PermissionDenied  = "denied"
+	// PermissionUnknown means the call failed for another reason, e.g. a timeout
+	PermissionUnknown = "unknown"
+	// PermissionSkipped means the call was not attempted, e.g. writes under READ_ONLY
+	PermissionSkipped = "skipped"
+)
+
+// discoverProbeKeyPrefix names the objects and uploads created by discovery; the rest
+// of the key follows the canary naming, so the janitor removes leftovers
+const discoverProbeKeyPrefix = ".key-aws-exporter/discover-"
+
+// PermissionResult is the verdict for one S3 operation
+type PermissionResult struct {
+	Operation string
+	Status    string
+	Message   string
+	Duration  time.Duration
+}
+
+// DiscoveryResult is the permission matrix of an endpoint's credentials
+type DiscoveryResult struct {
+	CheckedAt   time.Time
+	Duration    time.Duration
+	Permissions []PermissionResult
+}
+
+type multipartAPI interface {
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+type bucketPolicyAPI interface {
+	GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error)
+}
+
+// Discover tries a battery of S3 operations and reports which ones the credentials may
+// call. Everything it writes is removed again; under READ_ONLY writes are skipped.
+func (v *S3Validator) Discover(ctx context.Context, timeout time.Duration) *DiscoveryResult {
+	result := &DiscoveryResult{CheckedAt: v.clock.Now()}
+	start := v.clock.Now()
+	defer func() { result.Duration = v.clock.Since(start) }()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := v.getClient(ctx)
+	if err != nil {
+		message := fmt.Sprintf("Failed to create AWS client: %v", err)
+		for _, op := range []string{OperationListObjects, OperationGetObject, OperationPutObject, OperationDeleteObject, OperationMultipartUpload, OperationGetBucketPolicy} {
+			result.Permissions = append(result.Permissions, PermissionResult{Operation: op, Status: PermissionUnknown, Message: message})
+		}
+		return result
+	}
+
+	var listed string
+	result.try(v.clock, OperationListObjects, func() error {
+		out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:  aws.String(v.bucket),
+			MaxKeys: aws.Int32(1),
+		})
+		if err == nil && len(out.Contents) > 0 {
+			listed = aws.ToString(out.Contents[0].Key)
+		}
+		return err
+	})
+
+	key := discoverProbeKeyPrefix + v.canaries.name()
+	written := false
+	if v.readOnly {
+		result.skip(OperationPutObject)
+	} else {
+		written = result.try(v.clock, OperationPutObject, func() error {
+			_, err := client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket: aws.String(v.bucket),
+				Key:    aws.String(key),
+				Body:   strings.NewReader("key-aws-exporter permission discovery"),
+			})
+			return err
+		})
+	}
+
+	// Read our own object when possible, otherwise an existing one; a missing key
+	// still proves the read was authorized
+	readKey := key
+	if !written && listed != "" {
+		readKey = listed
+	}
+	result.try(v.clock, OperationGetObject, func() error {
+		out, err := client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(v.bucket),
+			Key:    aws.String(readKey),
+			Range:  aws.String("bytes=0-0"),
+		})
+		if err != nil {
+			return ignoreErrorCodes(err, "NoSuchKey")
+		}
+		defer out.Body.Close()
+		_, err = io.Copy(io.Discard, out.Body)
+		return err
+	})
+
+	// Deleting a key that was never written is a no-op for the bucket
+	if v.readOnly {
+		result.skip(OperationDeleteObject)
+	} else {
+		result.try(v.clock, OperationDeleteObject, func() error {
+			_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(v.bucket),
+				Key:    aws.String(key),
+			})
+			return err
+		})
+	}
+
+	if api, ok := client.(multipartAPI); !ok {
+		result.unsupported(OperationMultipartUpload)
+	} else if v.readOnly {
+		result.skip(OperationMultipartUpload)
+	} else {
+		result.try(v.clock, OperationMultipartUpload, func() error {
+			out, err := api.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+				Bucket: aws.String(v.bucket),
+				Key:    aws.String(key + "-multipart"),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = api.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(v.bucket),
+				Key:      aws.String(key + "-multipart"),
+				UploadId: out.UploadId,
+			})
+			if err != nil {
+				return fmt.Errorf("upload %s was created but could not be aborted: %w", aws.ToString(out.UploadId), err)
+			}
+			return nil
+		})
+	}
+
+	if api, ok := client.(bucketPolicyAPI); !ok {
+		result.unsupported(OperationGetBucketPolicy)
+	} else {
+		result.try(v.clock, OperationGetBucketPolicy, func() error {
+			_, err := api.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(v.bucket)})
+			return ignoreErrorCodes(err, "NoSuchBucketPolicy")
+		})
+	}
+
+	return result
+}
+
+// try runs the call for operation and records its verdict, reporting whether it succeeded
+func (r *DiscoveryResult) try(clk clock.Clock, operation string, call func() error) bool {
+	start := clk.Now()
+	err := call()
+	permission := PermissionResult{Operation: operation, Duration: clk.Since(start)}
+	switch {
+	case err == nil:
+		permission.Status = PermissionAllowed
+	case classifyValidationError(err) == errorTypeForbidden:
+		permission.Status = PermissionDenied
+		permission.Message = err.Error()
+	default:
+		permission.Status = PermissionUnknown
+		permission.Message = err.Error()
+	}
+	r.Permissions = append(r.Permissions, permission)
+	return err == nil
+}
+
+// skip records an operation that READ_ONLY keeps from running
+func (r *DiscoveryResult) skip(operation string) {
+	r.Permissions = append(r.Permissions, PermissionResult{
+		Operation: operation,
+		Status:    PermissionSkipped,
+		Message:   "writes to the bucket and is disabled by READ_ONLY",
+	})
+}
+
+// unsupported records an operation the client cannot issue
+func (r *DiscoveryResult) unsupported(operation string) {
+	r.Permissions = append(r.Permissions, PermissionResult{
+		Operation: operation,
+		Status:    PermissionUnknown,
+		Message:   "not supported by the S3 client",
+	})
+}
+
+// ignoreErrorCodes drops API errors whose code shows the request itself was authorized
+func ignoreErrorCodes(err error, codes ...string) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range codes {
+			if apiErr.ErrorCode() == code {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+// Discover runs permission discovery in the primary region; the credentials are the same
+// in every region
+func (fv *RegionFailoverValidator) Discover(ctx context.Context, timeout time.Duration) *DiscoveryResult {
+	return fv.primary.Discover(ctx, timeout)
+}
@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/pkg/clock"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// discoverClient adds the multipart and bucket policy calls used by discovery
+type discoverClient struct {
+	mockS3Client
+	multipartErr error
+	policyErr    error
+	aborted      []string
+}
+
+func (c *discoverClient) CreateMultipartUpload(_ context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	if c.multipartErr != nil {
+		return nil, c.multipartErr
+	}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (c *discoverClient) AbortMultipartUpload(_ context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	c.aborted = append(c.aborted, aws.ToString(in.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (c *discoverClient) GetBucketPolicy(_ context.Context, _ *s3.GetBucketPolicyInput, _ ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	if c.policyErr != nil {
+		return nil, c.policyErr
+	}
+	return &s3.GetBucketPolicyOutput{Policy: aws.String("{}")}, nil
+}
+
+func discover(t *testing.T, client s3ProbeClient, opts ...Option) map[string]PermissionResult {
+	t.Helper()
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, opts...)
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+
+	result := validator.Discover(context.Background(), time.Second)
+	permissions := make(map[string]PermissionResult, len(result.Permissions))
+	for _, permission := range result.Permissions {
+		permissions[permission.Operation] = permission
+	}
+	if len(permissions) != 6 {
+		t.Fatalf("expected six operations, got %+v", result.Permissions)
+	}
+	return permissions
+}
+
+func TestDiscoverAllAllowed(t *testing.T) {
+	client := &discoverClient{policyErr: &mockAPIError{code: "NoSuchBucketPolicy"}}
+
+	permissions := discover(t, client)
+
+	for operation, permission := range permissions {
+		if permission.Status != PermissionAllowed {
+			t.Fatalf("expected %s to be allowed, got %+v", operation, permission)
+		}
+	}
+	if len(client.objects) != 0 || len(client.aborted) != 1 {
+		t.Fatalf("expected the probe object deleted and the upload aborted, got %v and %v", client.objects, client.aborted)
+	}
+}
+
+func TestDiscoverReadOnlyCredentials(t *testing.T) {
+	denied := &mockAPIError{code: "AccessDenied"}
+	client := &discoverClient{
+		mockS3Client: mockS3Client{putErr: denied, deleteErr: denied, objects: map[string]string{"existing": "data"}},
+		multipartErr: denied,
+		policyErr:    denied,
+	}
+
+	permissions := discover(t, client)
+
+	for _, operation := range []string{OperationPutObject, OperationDeleteObject, OperationMultipartUpload, OperationGetBucketPolicy} {
+		if permissions[operation].Status != PermissionDenied {
+			t.Fatalf("expected %s to be denied, got %+v", operation, permissions[operation])
+		}
+	}
+	if permissions[OperationGetObject].Status != PermissionAllowed {
+		t.Fatalf("expected reading the listed object to be allowed, got %+v", permissions[OperationGetObject])
+	}
+}
+
+func TestDiscoverSkipsWritesWhenReadOnly(t *testing.T) {
+	client := &discoverClient{}
+
+	permissions := discover(t, client, WithReadOnly())
+
+	for _, operation := range []string{OperationPutObject, OperationDeleteObject, OperationMultipartUpload} {
+		if permissions[operation].Status != PermissionSkipped {
+			t.Fatalf("expected %s to be skipped, got %+v", operation, permissions[operation])
+		}
+	}
+	if len(client.deleted) != 0 || len(client.aborted) != 0 {
+		t.Fatalf("expected no writes, got deletes %v and uploads %v", client.deleted, client.aborted)
+	}
+	if permissions[OperationGetObject].Status != PermissionAllowed {
+		t.Fatalf("expected a missing key to prove read access, got %+v", permissions[OperationGetObject])
+	}
+}
+
+func TestDiscoverWithoutOptionalCalls(t *testing.T) {
+	permissions := discover(t, &mockS3Client{})
+
+	if permissions[OperationMultipartUpload].Status != PermissionUnknown {
+		t.Fatalf("expected multipart to be unknown for a client without it, got %+v", permissions[OperationMultipartUpload])
+	}
+}
+
+func TestDiscoverNamesProbeAsCanary(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	client := &discoverClient{}
+
+	discover(t, client, WithClock(clk), WithCanaryNaming("pod-a", 10*time.Minute))
+
+	want := fmt.Sprintf("%s%d-pod-a-", discoverProbeKeyPrefix, clk.Now().Add(10*time.Minute).Unix())
+	if len(client.deleted) != 1 || !strings.HasPrefix(client.deleted[0], want) {
+		t.Fatalf("expected a probe object named %s..., got %v", want, client.deleted)
+	}
+	if expiry, ok := canaryExpiry(client.deleted[0], time.Time{}, time.Hour); !ok || !expiry.Equal(clk.Now().Add(10*time.Minute)) {
+		t.Fatalf("expected the janitor to read the expiry from the key, got %s", expiry)
+	}
+}
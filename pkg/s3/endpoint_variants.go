@@ -0,0 +1,41 @@
+package s3
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// WithAccelerate sends validation requests to the bucket's Transfer Acceleration
+// endpoint (bucket.s3-accelerate.amazonaws.com), the one accelerated uploads use
+func WithAccelerate() Option {
+	return func(s *validatorSettings) {
+		s.accelerate = true
+	}
+}
+
+// WithDualStack sends validation requests to the dual-stack endpoint
+// (s3.dualstack.region.amazonaws.com), which answers over IPv4 and IPv6
+func WithDualStack() Option {
+	return func(s *validatorSettings) {
+		s.dualStack = true
+	}
+}
+
+// WithFIPS sends validation requests to the FIPS endpoint
+// (s3-fips.region.amazonaws.com), which terminates TLS with FIPS 140 validated modules
+func WithFIPS() Option {
+	return func(s *validatorSettings) {
+		s.fips = true
+	}
+}
+
+// applyEndpointVariants selects the accelerated, dual-stack and FIPS endpoint variants
+func (v *S3Validator) applyEndpointVariants(o *s3.Options) {
+	o.UseAccelerate = v.accelerate
+	if v.dualStack {
+		o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
+	}
+	if v.fips {
+		o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateEnabled
+	}
+}
@@ -0,0 +1,69 @@
+package s3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func clientOptions(t *testing.T, v *S3Validator) s3.Options {
+	t.Helper()
+	client, err := v.defaultClientBuilder(context.Background())
+	if err != nil {
+		t.Fatalf("build client: %v", err)
+	}
+	return client.(*s3.Client).Options()
+}
+
+func TestEndpointVariantsReachClientOptions(t *testing.T) {
+	plain := clientOptions(t, NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false))
+	if plain.UseAccelerate || plain.EndpointOptions.UseDualStackEndpoint == aws.DualStackEndpointStateEnabled {
+		t.Fatalf("expected the standard endpoint by default")
+	}
+
+	opts := clientOptions(t, NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithAccelerate(), WithDualStack()))
+	if !opts.UseAccelerate {
+		t.Fatalf("expected transfer acceleration to be enabled")
+	}
+	if opts.EndpointOptions.UseDualStackEndpoint != aws.DualStackEndpointStateEnabled {
+		t.Fatalf("expected the dual-stack endpoint to be enabled")
+	}
+}
+
+func TestEndpointVariantsResolveAcceleratedDualStackHost(t *testing.T) {
+	opts := clientOptions(t, NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithAccelerate(), WithDualStack()))
+
+	endpoint, err := opts.EndpointResolverV2.ResolveEndpoint(context.Background(), s3.EndpointParameters{
+		Bucket:       aws.String("bucket"),
+		Region:       aws.String(opts.Region),
+		Accelerate:   aws.Bool(opts.UseAccelerate),
+		UseDualStack: aws.Bool(opts.EndpointOptions.UseDualStackEndpoint == aws.DualStackEndpointStateEnabled),
+	})
+	if err != nil {
+		t.Fatalf("resolve endpoint: %v", err)
+	}
+	if got := endpoint.URI.Host; got != "bucket.s3-accelerate.dualstack.amazonaws.com" {
+		t.Fatalf("expected the accelerated dual-stack host, got %s", got)
+	}
+}
+
+func TestEndpointVariantsResolveFIPSHost(t *testing.T) {
+	opts := clientOptions(t, NewS3Validator("", "us-gov-west-1", "bucket", "ak", "sk", "", false, false, WithFIPS()))
+	if opts.EndpointOptions.UseFIPSEndpoint != aws.FIPSEndpointStateEnabled {
+		t.Fatalf("expected the FIPS endpoint to be enabled")
+	}
+
+	endpoint, err := opts.EndpointResolverV2.ResolveEndpoint(context.Background(), s3.EndpointParameters{
+		Bucket:  aws.String("bucket"),
+		Region:  aws.String(opts.Region),
+		UseFIPS: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatalf("resolve endpoint: %v", err)
+	}
+	if got := endpoint.URI.Host; got != "bucket.s3-fips.us-gov-west-1.amazonaws.com" {
+		t.Fatalf("expected the FIPS host, got %s", got)
+	}
+}
@@ -0,0 +1,72 @@
+package s3
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smithy "github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ErrorDetail is the machine-readable cause of a failed validation, taken from the
+// SDK error chain
+type ErrorDetail struct {
+	Type       string // classified error type, same as ValidationResult.ErrorType
+	Code       string // AWS error code such as AccessDenied; empty when the service did not answer
+	HTTPStatus int    // status of the service response; 0 when there was none
+	RequestID  string // AWS request ID, for support cases
+	// Operation is the S3 call that failed. It differs from the probed one when the SDK
+	// made a call of its own first, e.g. CreateSession for directory bucket session auth.
+	Operation string
+	Retryable bool // whether retrying later may succeed without changing the credentials
+}
+
+// retryables is the SDK's own judgement of which errors are worth retrying
+var retryables = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+// newErrorDetail unwraps err into an ErrorDetail classified as errorType
+func newErrorDetail(err error, errorType string) *ErrorDetail {
+	detail := &ErrorDetail{
+		Type:      errorType,
+		Retryable: IsRetryableErrorType(errorType) || retryables.IsErrorRetryable(err) == aws.TrueTernary,
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		detail.Code = apiErr.ErrorCode()
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		detail.HTTPStatus = respErr.HTTPStatusCode()
+	}
+	var awsRespErr *awshttp.ResponseError
+	if errors.As(err, &awsRespErr) {
+		detail.RequestID = awsRespErr.ServiceRequestID()
+	}
+	detail.Operation = failedOperation(err)
+	if session, ok := sessionError(err); ok {
+		detail.Operation = "CreateSession"
+		detail.Code = session.code
+		detail.HTTPStatus = session.httpStatus
+	}
+	return detail
+}
+
+// failedOperation returns the innermost operation in the error chain, the one whose
+// failure made the outer ones fail
+func failedOperation(err error) string {
+	var operation string
+	var opErr *smithy.OperationError
+	for errors.As(err, &opErr) {
+		operation = opErr.Operation()
+		err = opErr.Unwrap()
+	}
+	return operation
+}
+
+// IsRetryableErrorType reports whether failures of an error type are usually transient
+func IsRetryableErrorType(errorType string) bool {
+	return IsConnectivityError(errorType) || errorType == errorTypeThrottled
+}
@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smithy "github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// sdkError builds the error chain the S3 client returns for a service error
+func sdkError(status int, code string) error {
+	return &smithy.OperationError{
+		ServiceID:     "S3",
+		OperationName: "ListObjectsV2",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      &smithy.GenericAPIError{Code: code, Message: "denied"},
+			},
+			RequestID: "REQ123",
+		},
+	}
+}
+
+func TestNewErrorDetailFromServiceError(t *testing.T) {
+	err := sdkError(http.StatusForbidden, "AccessDenied")
+
+	detail := newErrorDetail(err, classifyValidationError(err))
+
+	if detail.Type != errorTypeForbidden || detail.Code != "AccessDenied" || detail.HTTPStatus != http.StatusForbidden || detail.RequestID != "REQ123" {
+		t.Fatalf("expected the service error to be unwrapped, got %+v", detail)
+	}
+	if detail.Retryable {
+		t.Fatalf("expected access denied not to be retryable")
+	}
+}
+
+func TestNewErrorDetailRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"throttled", sdkError(http.StatusServiceUnavailable, "SlowDown")},
+		{"server error", sdkError(http.StatusInternalServerError, "InternalError")},
+		{"network", &mockNetError{msg: "connection refused"}},
+		{"timeout", context.DeadlineExceeded},
+	}
+	for _, tc := range cases {
+		if detail := newErrorDetail(tc.err, classifyValidationError(tc.err)); !detail.Retryable {
+			t.Fatalf("%s: expected a retryable error, got %+v", tc.name, detail)
+		}
+	}
+
+	if detail := newErrorDetail(context.Canceled, errorTypeCanceled); detail.Retryable || detail.HTTPStatus != 0 {
+		t.Fatalf("expected a canceled probe without a response not to be retryable, got %+v", detail)
+	}
+}
+
+func TestValidateKeysAttachesErrorDetail(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return &mockS3Client{err: sdkError(http.StatusNotFound, "NoSuchBucket")}, nil
+	}
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if result.Error == nil || result.Error.Code != "NoSuchBucket" || result.Error.Type != errorTypeNotFound {
+		t.Fatalf("expected the error detail on the result, got %+v", result.Error)
+	}
+}
+
+func TestFailedOperationIsInnermost(t *testing.T) {
+	inner := sdkError(http.StatusForbidden, "AccessDenied").(*smithy.OperationError)
+	inner.OperationName = "CreateSession"
+	err := &smithy.OperationError{ServiceID: "S3", OperationName: "ListObjectsV2", Err: inner}
+
+	if got := failedOperation(err); got != "CreateSession" {
+		t.Fatalf("expected the innermost operation, got %q", got)
+	}
+	if got := failedOperation(context.Canceled); got != "" {
+		t.Fatalf("expected no operation outside the SDK, got %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package s3
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// directoryBucketSuffix ends the names of S3 Express One Zone directory buckets, e.g.
+// logs--usw2-az1--x-s3. The SDK routes them to their zonal endpoint and signs requests
+// with session credentials from CreateSession, cached per client until they expire.
+const directoryBucketSuffix = "--x-s3"
+
+// IsDirectoryBucket reports whether bucket is an S3 Express One Zone directory bucket
+func IsDirectoryBucket(bucket string) bool {
+	return strings.HasSuffix(bucket, directoryBucketSuffix)
+}
+
+// sessionErrorPattern matches a failed CreateSession in an error message. The SDK
+// flattens session errors into text, so their chain cannot be unwrapped.
+var sessionErrorPattern = regexp.MustCompile(`operation error S3: CreateSession, (?:https response error StatusCode: (\d+),.*api error (\w+):)?`)
+
+// sessionFailure is what could be recovered from a flattened CreateSession error
+type sessionFailure struct {
+	code       string // empty when the service did not answer
+	httpStatus int
+}
+
+// sessionError reports whether err is a failed directory bucket session, and how it failed
+func sessionError(err error) (sessionFailure, bool) {
+	match := sessionErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return sessionFailure{}, false
+	}
+	status, _ := strconv.Atoi(match[1])
+	return sessionFailure{code: match[2], httpStatus: status}, true
+}
+
+// validateDirectoryPrefix rejects prefixes directory buckets cannot list: only prefixes
+// ending in the "/" delimiter are supported
+func (v *S3Validator) validateDirectoryPrefix(prefix string) error {
+	if !IsDirectoryBucket(v.bucket) || prefix == "" || strings.HasSuffix(prefix, "/") {
+		return nil
+	}
+	return fmt.Errorf("directory buckets only list prefixes ending in \"/\", got %q", prefix)
+}
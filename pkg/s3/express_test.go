@@ -0,0 +1,74 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newDirectoryBucketServer answers CreateSession with session credentials and only
+// lists objects for requests signed with them
+func newDirectoryBucketServer(t *testing.T, sessionStatus int) (*httptest.Server, *atomic.Int32) {
+	var sessions atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		if r.URL.Query().Has("session") {
+			sessions.Add(1)
+			if sessionStatus != http.StatusOK {
+				w.WriteHeader(sessionStatus)
+				_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>denied</Message></Error>`))
+				return
+			}
+			_, _ = w.Write([]byte(`<CreateSessionResult><Credentials><AccessKeyId>ASIASESSION</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session-token</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration></Credentials></CreateSessionResult>`))
+			return
+		}
+		if r.Header.Get("X-Amz-S3session-Token") != "session-token" || !strings.Contains(r.Header.Get("Authorization"), "ASIASESSION") {
+			t.Errorf("expected the list to be signed with the session credentials, got %v", r.Header)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>logs--usw2-az1--x-s3</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+	}))
+	return server, &sessions
+}
+
+func TestValidateKeysDirectoryBucketSessionAuth(t *testing.T) {
+	server, sessions := newDirectoryBucketServer(t, http.StatusOK)
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-west-2", "logs--usw2-az1--x-s3", "ak", "sk", "", false, false)
+	for range 2 {
+		if result := validator.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+			t.Fatalf("expected directory bucket validation to succeed: %s", result.Message)
+		}
+	}
+	if got := sessions.Load(); got != 1 {
+		t.Fatalf("expected the session to be created once and reused, got %d", got)
+	}
+}
+
+func TestValidateKeysDirectoryBucketSessionDenied(t *testing.T) {
+	server, _ := newDirectoryBucketServer(t, http.StatusForbidden)
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-west-2", "logs--usw2-az1--x-s3", "ak", "sk", "", false, false)
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+	if result.IsValid || result.ErrorType != "access_denied" {
+		t.Fatalf("expected a refused session to deny access, got %+v", result)
+	}
+	if result.Error == nil || result.Error.Operation != "CreateSession" {
+		t.Fatalf("expected CreateSession to be reported as the failed operation, got %+v", result.Error)
+	}
+}
+
+func TestValidateWithDirectoryBucketPrefix(t *testing.T) {
+	validator := NewS3Validator("", "us-west-2", "logs--usw2-az1--x-s3", "ak", "sk", "", false, false)
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Prefix: "2024"})
+	if result.IsValid || result.ErrorType != errorTypeConfig {
+		t.Fatalf("expected a prefix without a trailing slash to be rejected, got %+v", result)
+	}
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RegionFailoverValidator validates against a primary region and, when that fails
+// with a network-level error, probes the fallback regions in parallel. The timeout is
+// shared: the primary gets half of it, the fallbacks whatever the primary left over.
+type RegionFailoverValidator struct {
+	primary   *S3Validator
+	fallbacks []*S3Validator
+}
+
+// NewRegionFailoverValidator builds one validator per fallback region sharing the primary's settings
+func NewRegionFailoverValidator(primary *S3Validator, fallbackRegions []string) *RegionFailoverValidator {
+	fv := &RegionFailoverValidator{primary: primary}
+	for _, region := range fallbackRegions {
+		fv.fallbacks = append(fv.fallbacks, primary.withRegion(region))
+	}
+	return fv
+}
+
+// ValidateKeys runs the shallow check with region failover
+func (fv *RegionFailoverValidator) ValidateKeys(ctx context.Context, timeout time.Duration) *ValidationResult {
+	return fv.run(timeout, func(v *S3Validator, timeout time.Duration) *ValidationResult {
+		return v.ValidateKeys(ctx, timeout)
+	})
+}
+
+// ValidateDeep runs the deep probe with region failover
+func (fv *RegionFailoverValidator) ValidateDeep(ctx context.Context, timeout time.Duration) *ValidationResult {
+	return fv.run(timeout, func(v *S3Validator, timeout time.Duration) *ValidationResult {
+		return v.ValidateDeep(ctx, timeout)
+	})
+}
+
+// ValidateWith runs a single validation with probe overrides and region failover
+func (fv *RegionFailoverValidator) ValidateWith(ctx context.Context, timeout time.Duration, opts ProbeOptions) *ValidationResult {
+	return fv.run(timeout, func(v *S3Validator, timeout time.Duration) *ValidationResult {
+		return v.ValidateWith(ctx, timeout, opts)
+	})
+}
+
+// Refresh drops the cached clients and check schedules of every region
+func (fv *RegionFailoverValidator) Refresh() {
+	fv.primary.Refresh()
+	for _, fallback := range fv.fallbacks {
+		fallback.Refresh()
+	}
+}
+
+// CleanCanaries deletes expired canaries through the primary region; all regions
+// share the bucket
+func (fv *RegionFailoverValidator) CleanCanaries(ctx context.Context, timeout time.Duration) *CanaryCleanup {
+	return fv.primary.CleanCanaries(ctx, timeout)
+}
+
+// run probes the primary and, if it is unreachable, the fallbacks within timeout. The
+// reported result carries the requests of every attempt, so probe request counts and
+// costs include the ones the failover spent.
+func (fv *RegionFailoverValidator) run(timeout time.Duration, probe func(*S3Validator, time.Duration) *ValidationResult) *ValidationResult {
+	start := fv.primary.clock.Now()
+	if len(fv.fallbacks) == 0 {
+		return probe(fv.primary, timeout)
+	}
+
+	primary := probe(fv.primary, timeout/2)
+	if primary.IsValid || !IsConnectivityError(primary.ErrorType) {
+		return primary
+	}
+	remaining := timeout - fv.primary.clock.Since(start)
+	if remaining <= 0 {
+		primary.Message = fmt.Sprintf("%s; no time left to try the fallback regions", primary.Message)
+		return primary
+	}
+
+	results := make([]*ValidationResult, len(fv.fallbacks))
+	var wg sync.WaitGroup
+	for i, fallback := range fv.fallbacks {
+		wg.Add(1)
+		go func(i int, v *S3Validator) {
+			defer wg.Done()
+			results[i] = probe(v, remaining)
+		}(i, fallback)
+	}
+	wg.Wait()
+
+	// Prefer fallbacks in configured order so the active region is stable
+	reported := primary
+	for _, result := range results {
+		if result.IsValid {
+			reported = result
+			break
+		}
+	}
+	var operations []OperationTiming
+	retries := 0
+	for _, result := range append([]*ValidationResult{primary}, results...) {
+		operations = append(operations, result.Operations...)
+		retries += result.Retries
+	}
+	reported.Operations = operations
+	reported.Retries = retries
+	reported.CheckedAt = primary.CheckedAt
+	reported.Duration = fv.primary.clock.Since(start)
+	reported.ResponseTimeMs = reported.Duration.Milliseconds()
+
+	if reported == primary {
+		primary.Message = fmt.Sprintf("%s; all %d fallback regions also failed", primary.Message, len(fv.fallbacks))
+	} else {
+		reported.Message = fmt.Sprintf("%s (failed over from %s)", reported.Message, primary.Region)
+	}
+	return reported
+}
+
+// IsConnectivityError reports whether an error type points at the endpoint being
+// unreachable rather than at the credentials themselves
+func IsConnectivityError(errorType string) bool {
+	return errorType == errorTypeNetwork || errorType == errorTypeTimeout
+}
@@ -0,0 +1,120 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func newTestFailoverValidator(primaryErr error, fallbackErrs map[string]error) *RegionFailoverValidator {
+	primary := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false)
+	primary.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return &mockS3Client{err: primaryErr}, nil
+	}
+
+	regions := make([]string, 0, len(fallbackErrs))
+	for _, region := range []string{"us-west-2", "eu-west-1"} {
+		if _, ok := fallbackErrs[region]; ok {
+			regions = append(regions, region)
+		}
+	}
+
+	fv := NewRegionFailoverValidator(primary, regions)
+	for _, fallback := range fv.fallbacks {
+		err := fallbackErrs[fallback.region]
+		fallback.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+			return &mockS3Client{err: err}, nil
+		}
+	}
+	return fv
+}
+
+func TestRegionFailoverPrimaryHealthy(t *testing.T) {
+	fv := newTestFailoverValidator(nil, map[string]error{"us-west-2": nil})
+
+	result := fv.ValidateKeys(context.Background(), time.Second)
+
+	if !result.IsValid || result.Region != "us-east-1" {
+		t.Fatalf("expected primary region to be used, got valid=%v region=%s", result.IsValid, result.Region)
+	}
+}
+
+func TestRegionFailoverOnNetworkError(t *testing.T) {
+	fv := newTestFailoverValidator(
+		&mockNetError{msg: "connection refused"},
+		map[string]error{"us-west-2": &mockNetError{msg: "connection refused"}, "eu-west-1": nil},
+	)
+
+	result := fv.ValidateKeys(context.Background(), time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected failover to succeed, got %s", result.Message)
+	}
+	if result.Region != "eu-west-1" {
+		t.Fatalf("expected eu-west-1 to be active, got %s", result.Region)
+	}
+	if !strings.Contains(result.Message, "failed over from us-east-1") {
+		t.Fatalf("expected failover note in message, got %s", result.Message)
+	}
+}
+
+func TestRegionFailoverSkippedForCredentialErrors(t *testing.T) {
+	fv := newTestFailoverValidator(&mockAPIError{code: "InvalidAccessKeyId"}, map[string]error{"us-west-2": nil})
+
+	result := fv.ValidateKeys(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected credential errors not to trigger failover")
+	}
+	if result.Region != "us-east-1" {
+		t.Fatalf("expected primary region in result, got %s", result.Region)
+	}
+}
+
+func TestRegionFailoverAllRegionsDown(t *testing.T) {
+	fv := newTestFailoverValidator(
+		&mockNetError{msg: "connection refused"},
+		map[string]error{"us-west-2": &mockNetError{msg: "connection refused"}},
+	)
+
+	result := fv.ValidateKeys(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected failure when all regions are unreachable")
+	}
+	if result.ErrorType != errorTypeNetwork {
+		t.Fatalf("expected primary network error type, got %s", result.ErrorType)
+	}
+	if !strings.Contains(result.Message, "fallback regions also failed") {
+		t.Fatalf("expected fallback failure note, got %s", result.Message)
+	}
+}
+
+// hangingS3Client never answers, so only the probe's deadline ends its calls
+type hangingS3Client struct {
+	mockS3Client
+}
+
+func (c *hangingS3Client) ListObjectsV2(ctx context.Context, _ *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRegionFailoverSplitsTimeout(t *testing.T) {
+	fv := newTestFailoverValidator(nil, map[string]error{"us-west-2": nil})
+	fv.primary.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return &hangingS3Client{}, nil
+	}
+
+	result := fv.ValidateKeys(context.Background(), 200*time.Millisecond)
+
+	if !result.IsValid || result.Region != "us-west-2" {
+		t.Fatalf("expected the fallback to get the rest of the timeout, got valid=%v region=%s (%s)", result.IsValid, result.Region, result.Message)
+	}
+	if len(result.Operations) != 2 {
+		t.Fatalf("expected the requests of both attempts, got %+v", result.Operations)
+	}
+}
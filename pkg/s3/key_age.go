@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// iamDefaultRegion is the region IAM endpoints are resolved for when the validator has none
+const iamDefaultRegion = "us-east-1"
+
+// WithIAMEndpoint overrides the IAM endpoint used by KeyCreatedAt. Without it the SDK
+// resolves the partition's global endpoint from the region, e.g. for GovCloud or China.
+func WithIAMEndpoint(endpoint string) Option {
+	return func(s *validatorSettings) {
+		s.iamEndpoint = endpoint
+	}
+}
+
+// KeyCreatedAt asks IAM when the validator's access key was created. It calls
+// ListAccessKeys for the key's own user, so the key needs iam:ListAccessKeys on itself;
+// temporary and root credentials are not supported by IAM.
+func (v *S3Validator) KeyCreatedAt(ctx context.Context) (time.Time, error) {
+	paginator := iam.NewListAccessKeysPaginator(v.iamClient(), &iam.ListAccessKeysInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, key := range page.AccessKeyMetadata {
+			if aws.ToString(key.AccessKeyId) != v.accessKey {
+				continue
+			}
+			if key.CreateDate == nil {
+				return time.Time{}, fmt.Errorf("IAM listed no creation date for the access key")
+			}
+			return *key.CreateDate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("access key not listed by IAM")
+}
+
+// iamClient builds an IAM client signing with the validator's own key, not an assumed
+// role, over the same HTTP client as the S3 probes
+func (v *S3Validator) iamClient() *iam.Client {
+	region := v.region
+	if region == "" {
+		region = iamDefaultRegion
+	}
+	cfg := aws.Config{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(v.accessKey, v.secretKey, v.sessionToken),
+	}
+	if client := v.httpClient(); client != nil {
+		cfg.HTTPClient = client
+	}
+	return iam.NewFromConfig(cfg, func(o *iam.Options) {
+		if v.iamEndpoint != "" {
+			o.BaseEndpoint = aws.String(v.iamEndpoint)
+		}
+	})
+}
+
+// KeyCreatedAt looks up the key creation date; every region shares the same key
+func (fv *RegionFailoverValidator) KeyCreatedAt(ctx context.Context) (time.Time, error) {
+	return fv.primary.KeyCreatedAt(ctx)
+}
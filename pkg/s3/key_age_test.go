@@ -0,0 +1,68 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const listAccessKeysXML = `<ListAccessKeysResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <ListAccessKeysResult>
+    <AccessKeyMetadata>
+      <member><UserName>svc</UserName><AccessKeyId>AKIAOTHER</AccessKeyId><Status>Active</Status><CreateDate>2020-01-01T00:00:00Z</CreateDate></member>
+      <member><UserName>svc</UserName><AccessKeyId>AKIAMINE</AccessKeyId><Status>Active</Status><CreateDate>2024-03-05T10:20:30Z</CreateDate></member>
+    </AccessKeyMetadata>
+    <IsTruncated>false</IsTruncated>
+  </ListAccessKeysResult>
+</ListAccessKeysResponse>`
+
+func TestKeyCreatedAtReadsIAM(t *testing.T) {
+	var authorization, form string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = r.ParseForm()
+		form = r.PostForm.Encode()
+		_, _ = w.Write([]byte(listAccessKeysXML))
+	}))
+	defer server.Close()
+
+	validator := NewS3Validator("", "eu-west-1", "bucket", "AKIAMINE", "secret", "", false, false, WithIAMEndpoint(server.URL))
+
+	created, err := validator.KeyCreatedAt(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC); !created.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, created)
+	}
+	if !strings.Contains(authorization, "AKIAMINE/") || !strings.Contains(authorization, "/iam/aws4_request") {
+		t.Fatalf("expected SigV4 authorization for IAM, got %q", authorization)
+	}
+	if !strings.Contains(form, "Action=ListAccessKeys") {
+		t.Fatalf("unexpected request body %q", form)
+	}
+}
+
+func TestKeyCreatedAtErrors(t *testing.T) {
+	status := http.StatusForbidden
+	body := `<ErrorResponse><Error><Code>AccessDenied</Code></Error></ErrorResponse>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	validator := NewS3Validator("", "us-east-1", "bucket", "AKIAUNLISTED", "secret", "", false, false, WithIAMEndpoint(server.URL))
+
+	if _, err := validator.KeyCreatedAt(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+
+	status, body = http.StatusOK, listAccessKeysXML
+	if _, err := validator.KeyCreatedAt(context.Background()); err == nil || !strings.Contains(err.Error(), "not listed") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// errorTypeTransform is a failure of the Object Lambda function transforming the object,
+// as opposed to the credentials
+const errorTypeTransform = "transform_failed"
+
+// IsObjectLambdaARN reports whether bucket is an S3 Object Lambda access point ARN,
+// arn:partition:s3-object-lambda:region:account-id:accesspoint/name
+func IsObjectLambdaARN(bucket string) bool {
+	parts := strings.SplitN(bucket, ":", 4)
+	return len(parts) == 4 && parts[0] == "arn" && parts[2] == "s3-object-lambda"
+}
+
+// classifyLambdaErrorCode maps the error codes Object Lambda returns when the transform
+// function fails, or "" for other codes. A function the access point may not invoke is
+// a permission problem; anything else the function did is not the credentials' fault.
+func classifyLambdaErrorCode(code string) string {
+	switch {
+	case code == "LambdaPermissionError":
+		return errorTypeForbidden
+	case strings.HasPrefix(code, "Lambda"):
+		return errorTypeTransform
+	}
+	return ""
+}
+
+// objectLambdaWriteResult fails a writing probe against an Object Lambda access point,
+// which only transforms reads, without contacting the endpoint
+func (v *S3Validator) objectLambdaWriteResult(depth ProbeDepth) *ValidationResult {
+	return &ValidationResult{
+		IsValid:   false,
+		Message:   fmt.Sprintf("the %s probe writes, but Object Lambda access points only serve reads", depth),
+		CheckedAt: v.clock.Now(),
+		ErrorType: errorTypeConfig,
+		Depth:     depth,
+		Region:    v.region,
+	}
+}
@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKeysObjectLambdaReadsThroughTransform(t *testing.T) {
+	validator := NewS3Validator("", "eu-west-1", "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redacted", "AKIDEXAMPLE", "secret", "", false, false)
+	recorder := withRecordingClient(t, validator)
+
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+	if !result.IsValid {
+		t.Fatalf("expected validation through the Object Lambda access point to succeed: %s", result.Message)
+	}
+	if len(result.Operations) != 2 || result.Operations[1].Operation != OperationGetObject {
+		t.Fatalf("expected a list then a read, got %+v", result.Operations)
+	}
+
+	get := recorder.last
+	if get.Method != http.MethodGet || get.URL.Query().Has("list-type") {
+		t.Fatalf("expected the last request to read an object, got %s %s", get.Method, get.URL)
+	}
+	if host := get.URL.Host; host != "redacted-123456789012.s3-object-lambda.eu-west-1.amazonaws.com" {
+		t.Fatalf("expected the Object Lambda host, got %s", host)
+	}
+	if auth := get.Header.Get("Authorization"); !strings.Contains(auth, "/eu-west-1/s3-object-lambda/aws4_request") {
+		t.Fatalf("expected the request to be signed for s3-object-lambda, got %q", auth)
+	}
+	if get.Header.Get("Range") != "" {
+		t.Fatalf("expected whole-object reads, transform functions need not support ranges")
+	}
+}
+
+func TestObjectLambdaRefusesWrites(t *testing.T) {
+	validator := NewS3Validator("", "eu-west-1", "arn:aws:s3-object-lambda:eu-west-1:123456789012:accesspoint/redacted", "ak", "sk", "", false, false)
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		t.Fatalf("expected writes to be refused without a client")
+		return nil, nil
+	}
+
+	if result := validator.ValidateDeep(context.Background(), time.Second); result.IsValid || result.ErrorType != errorTypeConfig {
+		t.Fatalf("expected deep probes to be refused, got %+v", result)
+	}
+	if result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationPutObject}); result.IsValid || result.ErrorType != errorTypeConfig {
+		t.Fatalf("expected write operations to be refused, got %+v", result)
+	}
+}
+
+func TestClassifyObjectLambdaErrors(t *testing.T) {
+	for code, want := range map[string]string{
+		"LambdaRuntimeError":    errorTypeTransform,
+		"LambdaTimeout":         errorTypeTransform,
+		"LambdaPermissionError": errorTypeForbidden,
+	} {
+		if got := classifyValidationError(&mockAPIError{code: code}); got != want {
+			t.Fatalf("expected %s to be classified as %s, got %s", code, want, got)
+		}
+	}
+}
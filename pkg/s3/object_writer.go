@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ObjectTarget is a bucket the exporter writes its own objects to, such as snapshots
+type ObjectTarget struct {
+	Endpoint     string // empty uses AWS
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	UsePathStyle bool
+	Bucket       string
+}
+
+type putObjectAPI interface {
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// ObjectWriter puts objects into one bucket, reusing its client
+type ObjectWriter struct {
+	client putObjectAPI
+	bucket string
+}
+
+// NewObjectWriter creates a writer for t.Bucket. The credentials need s3:PutObject.
+func NewObjectWriter(ctx context.Context, t ObjectTarget) (*ObjectWriter, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(t.Region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(t.AccessKey, t.SecretKey, t.SessionToken)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = t.UsePathStyle
+		if t.Endpoint != "" {
+			o.BaseEndpoint = aws.String(t.Endpoint)
+		}
+	})
+	return &ObjectWriter{client: client, bucket: t.Bucket}, nil
+}
+
+// Put writes body to key, replacing the previous object
+func (w *ObjectWriter) Put(ctx context.Context, key, contentType string, body []byte) error {
+	_, err := w.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put s3://%s/%s: %w", w.bucket, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type stubPutObjectAPI struct {
+	input *s3.PutObjectInput
+	body  string
+	err   error
+}
+
+func (s *stubPutObjectAPI) PutObject(ctx context.Context, input *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	s.input = input
+	data, _ := io.ReadAll(input.Body)
+	s.body = string(data)
+	return &s3.PutObjectOutput{}, s.err
+}
+
+func TestObjectWriterPut(t *testing.T) {
+	api := &stubPutObjectAPI{}
+	w := &ObjectWriter{client: api, bucket: "snapshots"}
+	if err := w.Put(context.Background(), "exporter/metrics.prom", "text/plain", []byte("s3_keys_valid 1\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aws.ToString(api.input.Bucket) != "snapshots" || aws.ToString(api.input.Key) != "exporter/metrics.prom" || aws.ToInt64(api.input.ContentLength) != 16 || api.body != "s3_keys_valid 1\n" {
+		t.Fatalf("unexpected PutObject input %+v", api.input)
+	}
+
+	api.err = errors.New("AccessDenied")
+	if err := w.Put(context.Background(), "k", "text/plain", nil); err == nil || !strings.Contains(err.Error(), "s3://snapshots/k") {
+		t.Fatalf("expected the failing object in the error, got %v", err)
+	}
+}
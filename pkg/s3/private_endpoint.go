@@ -0,0 +1,28 @@
+package s3
+
+import "net/netip"
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which some private
+// networks route to their VPC endpoints
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// WithPrivateCIDRs sets the ranges VPC endpoints answer from, e.g. the subnets of an
+// interface endpoint. By default any RFC 1918, RFC 6598 or unique local address counts.
+func WithPrivateCIDRs(cidrs []netip.Prefix) Option {
+	return func(s *validatorSettings) {
+		s.privateCIDRs = cidrs
+	}
+}
+
+// isPrivate reports whether a connection to addr went through a private endpoint
+func (v *S3Validator) isPrivate(addr netip.Addr) bool {
+	if v.privateCIDRs == nil {
+		return addr.IsPrivate() || sharedAddressSpace.Contains(addr)
+	}
+	for _, cidr := range v.privateCIDRs {
+		if cidr.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
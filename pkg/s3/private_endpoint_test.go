@@ -0,0 +1,45 @@
+package s3
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestValidateKeysReportsPrivateEndpoint(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	public := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false).ValidateKeys(context.Background(), 5*time.Second)
+	if public.RemoteIP != "127.0.0.1" || public.ViaPrivateEndpoint == nil || *public.ViaPrivateEndpoint {
+		t.Fatalf("expected loopback to count as public by default, got %q (%v)", public.RemoteIP, public.ViaPrivateEndpoint)
+	}
+
+	cidrs := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	private := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithPrivateCIDRs(cidrs)).ValidateKeys(context.Background(), 5*time.Second)
+	if private.ViaPrivateEndpoint == nil || !*private.ViaPrivateEndpoint {
+		t.Fatalf("expected an address in private_cidrs to count as private")
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	v := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false)
+	for addr, want := range map[string]bool{
+		"10.0.12.7":     true,
+		"172.31.0.5":    true,
+		"100.64.1.1":    true,
+		"fd00:ec2::23":  true,
+		"52.216.8.1":    false,
+		"2600:1fa0::10": false,
+	} {
+		if got := v.isPrivate(netip.MustParseAddr(addr)); got != want {
+			t.Fatalf("expected isPrivate(%s) = %v, got %v", addr, want, got)
+		}
+	}
+
+	scoped := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithPrivateCIDRs([]netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}))
+	if scoped.isPrivate(netip.MustParseAddr("10.0.12.7")) || !scoped.isPrivate(netip.MustParseAddr("10.20.3.4")) {
+		t.Fatalf("expected private_cidrs to replace the default ranges")
+	}
+}
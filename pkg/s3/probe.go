@@ -0,0 +1,144 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ProbeDepth selects how much of the bucket a validation exercises
+type ProbeDepth string
+
+const (
+	// ProbeDepthShallow only proves the credentials authenticate (ListObjectsV2)
+	ProbeDepthShallow ProbeDepth = "shallow"
+	// ProbeDepthDeep lists, writes, reads back and deletes a probe object
+	ProbeDepthDeep ProbeDepth = "deep"
+)
+
+// Operation labels for the calls made by the deep probe
+const (
+	OperationPutObject    = "PutObject"
+	OperationGetObject    = "GetObject"
+	OperationDeleteObject = "DeleteObject"
+)
+
+// deepProbeKeyPrefix keeps probe objects out of the way of real bucket contents
+const deepProbeKeyPrefix = ".key-aws-exporter/probe-"
+
+type probeFunc func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error
+
+// ValidateDeep runs the list check and then writes, reads back and deletes a small
+// probe object so missing read or write permissions are caught as well
+func (v *S3Validator) ValidateDeep(ctx context.Context, timeout time.Duration) *ValidationResult {
+	if v.readOnly {
+		return v.readOnlyResult(ProbeDepthDeep)
+	}
+	if IsObjectLambdaARN(v.bucket) {
+		return v.objectLambdaWriteResult(ProbeDepthDeep)
+	}
+	return v.validate(ctx, timeout, ProbeDepthDeep, v.deepProbe(""))
+}
+
+// deepProbe lists, then writes, reads back and deletes a probe object under prefix
+func (v *S3Validator) deepProbe(prefix string) probeFunc {
+	return func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error {
+		if err := v.listProbe(prefix)(ctx, client, result); err != nil {
+			return err
+		}
+		return v.writeProbe(prefix, true)(ctx, client, result)
+	}
+}
+
+// writeProbe writes a probe object under prefix, optionally reads it back, and deletes it
+func (v *S3Validator) writeProbe(prefix string, readBack bool) probeFunc {
+	return func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error {
+		nonce := v.canaries.name()
+		key := prefix + deepProbeKeyPrefix + nonce
+		body := []byte("key-aws-exporter probe")
+		input := &s3.PutObjectInput{
+			Bucket:            aws.String(v.bucket),
+			Key:               aws.String(key),
+			ChecksumAlgorithm: v.checksumAlgorithm,
+		}
+		verify := readBack && v.roundTrip != nil
+		if verify {
+			body = roundTripBody(nonce)
+			v.roundTrip.prepare(input, nonce)
+		}
+		input.Body = bytes.NewReader(body)
+
+		err := result.timeOperation(OperationPutObject, func() error {
+			_, err := client.PutObject(ctx, input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		var readErr error
+		if readBack {
+			var out *s3.GetObjectOutput
+			var read []byte
+			out, read, readErr = v.readObject(ctx, client, result, key)
+			if readErr == nil && verify {
+				result.Checks = append(result.Checks, v.roundTripCheck(out, read, body, nonce))
+			}
+		}
+
+		// Always try to clean up once the object was written, even if the read failed
+		deleteErr := result.timeOperation(OperationDeleteObject, func() error {
+			_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(v.bucket),
+				Key:    aws.String(key),
+			})
+			return err
+		})
+
+		return errors.Join(readErr, deleteErr)
+	}
+}
+
+// readObject reads key, returning the response and its content
+func (v *S3Validator) readObject(ctx context.Context, client s3ProbeClient, result *ValidationResult, key string) (*s3.GetObjectOutput, []byte, error) {
+	var out *s3.GetObjectOutput
+	var body []byte
+	err := result.timeOperation(OperationGetObject, func() error {
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(v.bucket),
+			Key:    aws.String(key),
+		}
+		if v.checksumAlgorithm != "" {
+			input.ChecksumMode = types.ChecksumModeEnabled
+		}
+		var err error
+		out, err = client.GetObject(ctx, input)
+		if err != nil {
+			return err
+		}
+		defer out.Body.Close()
+		// The SDK verifies the checksum while the body is read
+		body, err = io.ReadAll(out.Body)
+		return err
+	})
+	return out, body, err
+}
+
+// roundTripCheck reports whether the probe object read back as it was written
+func (v *S3Validator) roundTripCheck(out *s3.GetObjectOutput, read, written []byte, nonce string) CheckResult {
+	check := CheckResult{Name: CheckRoundTrip, CheckedAt: v.clock.Now()}
+	if mismatches := v.roundTrip.verify(out, read, written, nonce); len(mismatches) > 0 {
+		check.Message = "probe object read back differently: " + strings.Join(mismatches, "; ")
+		return check
+	}
+	check.Passed = true
+	check.Message = "probe object read back identical to what was written"
+	return check
+}
@@ -0,0 +1,123 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ProbeOptions overrides how a single validation probes the bucket
+type ProbeOptions struct {
+	Depth ProbeDepth // empty is shallow
+	// Operation is the call made by a shallow probe: ListObjectsV2 (the default),
+	// GetObject, which reads the first object under Prefix, or PutObject, which writes
+	// and deletes a probe object under Prefix
+	Operation string
+	Prefix    string // key prefix to list, read from or write under
+}
+
+// Validate reports options that cannot be probed
+func (o ProbeOptions) Validate() error {
+	switch o.Depth {
+	case "", ProbeDepthShallow:
+	case ProbeDepthDeep:
+		if o.Operation != "" {
+			return fmt.Errorf("operation only applies to shallow probes; deep probes list, write, read and delete")
+		}
+	default:
+		return fmt.Errorf("depth must be %q or %q, got %q", ProbeDepthShallow, ProbeDepthDeep, o.Depth)
+	}
+
+	switch o.Operation {
+	case "", OperationListObjects, OperationGetObject, OperationPutObject:
+		return nil
+	default:
+		return fmt.Errorf("operation must be %s, %s or %s, got %q", OperationListObjects, OperationGetObject, OperationPutObject, o.Operation)
+	}
+}
+
+// writes reports whether the probe writes to the bucket
+func (o ProbeOptions) writes() bool {
+	return o.Depth == ProbeDepthDeep || o.Operation == OperationPutObject
+}
+
+// ValidateWith runs a single validation with probe overrides
+func (v *S3Validator) ValidateWith(ctx context.Context, timeout time.Duration, opts ProbeOptions) *ValidationResult {
+	depth := opts.Depth
+	if depth == "" {
+		depth = ProbeDepthShallow
+	}
+	err := opts.Validate()
+	if err == nil {
+		err = v.validateDirectoryPrefix(opts.Prefix)
+	}
+	if err != nil {
+		return &ValidationResult{
+			IsValid:   false,
+			Message:   err.Error(),
+			CheckedAt: v.clock.Now(),
+			ErrorType: errorTypeConfig,
+			Depth:     depth,
+			Region:    v.region,
+		}
+	}
+	if v.readOnly && opts.writes() {
+		return v.readOnlyResult(depth)
+	}
+	if IsObjectLambdaARN(v.bucket) && opts.writes() {
+		return v.objectLambdaWriteResult(depth)
+	}
+
+	probe := v.listProbe(opts.Prefix)
+	switch {
+	case depth == ProbeDepthDeep:
+		probe = v.deepProbe(opts.Prefix)
+	case opts.Operation == OperationGetObject:
+		probe = v.readProbe(opts.Prefix)
+	case opts.Operation == OperationPutObject:
+		probe = v.writeProbe(opts.Prefix, false)
+	}
+	return v.validate(ctx, timeout, depth, probe)
+}
+
+// readProbe reads the first byte of the first object under prefix. Without one it
+// reads a key that does not exist: NoSuchKey still proves the read was authorized.
+func (v *S3Validator) readProbe(prefix string) probeFunc {
+	return func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error {
+		key := fmt.Sprintf("%s%s%d", prefix, deepProbeKeyPrefix, time.Now().UnixNano())
+		// Listing may be denied while reading is allowed, so its error only costs the key
+		_ = result.timeOperation(OperationListObjects, func() error {
+			out, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket:  aws.String(v.bucket),
+				Prefix:  aws.String(prefix),
+				MaxKeys: aws.Int32(1),
+			})
+			if err == nil && len(out.Contents) > 0 {
+				key = aws.ToString(out.Contents[0].Key)
+			}
+			return err
+		})
+
+		return result.timeOperation(OperationGetObject, func() error {
+			input := &s3.GetObjectInput{
+				Bucket: aws.String(v.bucket),
+				Key:    aws.String(key),
+			}
+			// Transform functions need not support ranges, so Object Lambda reads whole objects
+			if !IsObjectLambdaARN(v.bucket) {
+				input.Range = aws.String("bytes=0-0")
+			}
+			out, err := client.GetObject(ctx, input)
+			if err != nil {
+				return ignoreErrorCodes(err, "NoSuchKey")
+			}
+			defer out.Body.Close()
+			_, err = io.Copy(io.Discard, out.Body)
+			return err
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOptionsValidator(client *mockS3Client, opts ...Option) *S3Validator {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, opts...)
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+	return validator
+}
+
+func TestValidateWithReadsFirstObjectUnderPrefix(t *testing.T) {
+	client := &mockS3Client{objects: map[string]string{"logs/a": "x", "other/b": "y"}}
+	validator := newOptionsValidator(client)
+
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationGetObject, Prefix: "logs/"})
+	if !result.IsValid {
+		t.Fatalf("expected the read probe to pass, got %s", result.Message)
+	}
+	if len(result.Operations) != 2 || result.Operations[1].Operation != OperationGetObject {
+		t.Fatalf("expected a list and a read, got %+v", result.Operations)
+	}
+
+	empty := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationGetObject, Prefix: "missing/"})
+	if !empty.IsValid {
+		t.Fatalf("expected NoSuchKey to count as an authorized read, got %s", empty.Message)
+	}
+}
+
+func TestValidateWithWritesUnderPrefix(t *testing.T) {
+	client := &mockS3Client{}
+	validator := newOptionsValidator(client)
+
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationPutObject, Prefix: "probes/"})
+	if !result.IsValid {
+		t.Fatalf("expected the write probe to pass, got %s", result.Message)
+	}
+	if len(client.deleted) != 1 || !strings.HasPrefix(client.deleted[0], "probes/") {
+		t.Fatalf("expected the probe object to be written under the prefix and deleted, got %v", client.deleted)
+	}
+	for _, op := range result.Operations {
+		if op.Operation == OperationGetObject {
+			t.Fatalf("expected a write probe not to read back, got %+v", result.Operations)
+		}
+	}
+}
+
+func TestValidateWithDeepUsesPrefix(t *testing.T) {
+	client := &mockS3Client{}
+	validator := newOptionsValidator(client)
+
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Depth: ProbeDepthDeep, Prefix: "deep/"})
+	if !result.IsValid || result.Depth != ProbeDepthDeep {
+		t.Fatalf("expected a passing deep probe, got %+v", result)
+	}
+	if len(client.deleted) != 1 || !strings.HasPrefix(client.deleted[0], "deep/") {
+		t.Fatalf("expected the deep probe object under the prefix, got %v", client.deleted)
+	}
+}
+
+func TestValidateWithRejectsInvalidOptions(t *testing.T) {
+	client := &mockS3Client{}
+	validator := newOptionsValidator(client)
+
+	for _, opts := range []ProbeOptions{
+		{Depth: "thorough"},
+		{Operation: "HeadBucket"},
+		{Depth: ProbeDepthDeep, Operation: OperationGetObject},
+	} {
+		result := validator.ValidateWith(context.Background(), time.Second, opts)
+		if result.IsValid || result.ErrorType != errorTypeConfig {
+			t.Fatalf("expected %+v to be rejected as a config error, got %+v", opts, result)
+		}
+	}
+	if client.called {
+		t.Fatalf("expected invalid options not to reach the bucket")
+	}
+}
+
+func TestValidateWithHonoursReadOnly(t *testing.T) {
+	client := &mockS3Client{}
+	validator := newOptionsValidator(client, WithReadOnly())
+
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationPutObject})
+	if result.IsValid || len(client.objects) != 0 || len(client.deleted) != 0 {
+		t.Fatalf("expected a write probe to be refused in read-only mode, got %+v", result)
+	}
+}
@@ -0,0 +1,92 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateDeepSuccess(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
+	mockClient := &mockS3Client{}
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return mockClient, nil
+	}
+
+	result := validator.ValidateDeep(context.Background(), time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected deep validation success, got failure: %s", result.Message)
+	}
+	if result.Depth != ProbeDepthDeep {
+		t.Fatalf("expected deep depth, got %s", result.Depth)
+	}
+
+	wantOps := []string{OperationListObjects, OperationPutObject, OperationGetObject, OperationDeleteObject}
+	if len(result.Operations) != len(wantOps) {
+		t.Fatalf("expected %d operations, got %+v", len(wantOps), result.Operations)
+	}
+	for i, op := range wantOps {
+		if result.Operations[i].Operation != op {
+			t.Fatalf("expected operation %d to be %s, got %s", i, op, result.Operations[i].Operation)
+		}
+	}
+	if len(mockClient.objects) != 0 {
+		t.Fatalf("expected probe object to be deleted, still have %v", mockClient.objects)
+	}
+}
+
+func TestValidateDeepWriteDenied(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
+	mockClient := &mockS3Client{putErr: &mockAPIError{code: "AccessDenied"}}
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return mockClient, nil
+	}
+
+	result := validator.ValidateDeep(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected deep validation to fail when writes are denied")
+	}
+	if result.ErrorType != errorTypeForbidden {
+		t.Fatalf("expected access_denied error type, got %s", result.ErrorType)
+	}
+	if len(mockClient.deleted) != 0 {
+		t.Fatalf("expected no cleanup when the write failed, got %v", mockClient.deleted)
+	}
+}
+
+func TestValidateDeepCleansUpAfterReadFailure(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
+	mockClient := &mockS3Client{getErr: errors.New("read failed")}
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return mockClient, nil
+	}
+
+	result := validator.ValidateDeep(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected deep validation to fail when reads fail")
+	}
+	if len(mockClient.deleted) != 1 {
+		t.Fatalf("expected probe object cleanup after read failure, got %v", mockClient.deleted)
+	}
+}
+
+func TestValidateKeysIsShallow(t *testing.T) {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
+	mockClient := &mockS3Client{}
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return mockClient, nil
+	}
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if result.Depth != ProbeDepthShallow {
+		t.Fatalf("expected shallow depth, got %s", result.Depth)
+	}
+	if len(mockClient.objects) != 0 || len(mockClient.deleted) != 0 {
+		t.Fatalf("expected shallow validation to avoid object writes")
+	}
+}
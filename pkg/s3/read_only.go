@@ -0,0 +1,33 @@
+package s3
+
+import "fmt"
+
+// WithReadOnly refuses every probe and check that writes to the bucket. They fail with
+// a config_error instead, so the exporter is guaranteed never to mutate buckets.
+func WithReadOnly() Option {
+	return func(s *validatorSettings) {
+		s.readOnly = true
+	}
+}
+
+// mutatingCheck is implemented by checks that write objects to the bucket
+type mutatingCheck interface {
+	mutates() bool
+}
+
+// readOnlyMessage explains why a write was refused
+func readOnlyMessage(what string) string {
+	return fmt.Sprintf("%s writes to the bucket and is disabled by READ_ONLY", what)
+}
+
+// readOnlyResult fails a writing probe without contacting the endpoint
+func (v *S3Validator) readOnlyResult(depth ProbeDepth) *ValidationResult {
+	return &ValidationResult{
+		IsValid:   false,
+		Message:   readOnlyMessage(fmt.Sprintf("the %s probe", depth)),
+		CheckedAt: v.clock.Now(),
+		ErrorType: errorTypeConfig,
+		Depth:     depth,
+		Region:    v.region,
+	}
+}
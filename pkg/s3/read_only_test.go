@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writingCheck is a stub check that declares it writes to the bucket
+type writingCheck struct {
+	stubCheck
+}
+
+func (c *writingCheck) mutates() bool { return true }
+
+func TestReadOnlyRefusesDeepProbe(t *testing.T) {
+	mockClient := &mockS3Client{}
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, WithReadOnly())
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return mockClient, nil
+	}
+
+	result := validator.ValidateDeep(context.Background(), time.Second)
+
+	if result.IsValid || result.ErrorType != errorTypeConfig {
+		t.Fatalf("expected a config error, got %+v", result)
+	}
+	if !strings.Contains(result.Message, "READ_ONLY") || result.Depth != ProbeDepthDeep {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(mockClient.objects) != 0 || len(mockClient.deleted) != 0 {
+		t.Fatalf("expected the bucket to be untouched")
+	}
+}
+
+func TestReadOnlyKeepsShallowProbe(t *testing.T) {
+	validator := newCheckedValidator(&mockS3Client{}, &stubCheck{passed: true, done: true}, WithReadOnly())
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if !result.IsValid || len(result.Checks) != 1 || !result.Checks[0].Passed {
+		t.Fatalf("expected read-only checks to keep running, got %+v", result)
+	}
+}
+
+func TestReadOnlyFailsWritingChecks(t *testing.T) {
+	check := &writingCheck{stubCheck{passed: true, done: true}}
+	validator := newCheckedValidator(&mockS3Client{}, check, WithReadOnly())
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if check.runs != 0 {
+		t.Fatalf("expected the writing check not to run, ran %d times", check.runs)
+	}
+	if len(result.Checks) != 1 || result.Checks[0].Passed || !strings.Contains(result.Checks[0].Message, "READ_ONLY") {
+		t.Fatalf("expected a failed check verdict, got %+v", result.Checks)
+	}
+}
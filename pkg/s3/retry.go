@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// WithRetry overrides the SDK's retry behavior: mode is standard or adaptive (which also
+// rate limits requests after throttling), maxAttempts counts the first attempt and
+// maxBackoff caps the delay between attempts. Zero values keep the SDK defaults.
+func WithRetry(mode string, maxAttempts int, maxBackoff time.Duration) Option {
+	return func(s *validatorSettings) {
+		s.retryMode = aws.RetryMode(mode)
+		s.maxAttempts = maxAttempts
+		s.maxBackoff = maxBackoff
+	}
+}
+
+// retryer builds the retryer of a client, or returns nil to keep the SDK's
+func (v *S3Validator) retryer() aws.Retryer {
+	if v.retryMode == "" && v.maxAttempts == 0 && v.maxBackoff == 0 {
+		return nil
+	}
+	configure := func(o *retry.StandardOptions) {
+		if v.maxAttempts > 0 {
+			o.MaxAttempts = v.maxAttempts
+		}
+		if v.maxBackoff > 0 {
+			o.MaxBackoff = v.maxBackoff
+		}
+	}
+	if v.retryMode == aws.RetryModeAdaptive {
+		return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+			o.StandardOptions = append(o.StandardOptions, configure)
+		})
+	}
+	return retry.NewStandard(configure)
+}
+
+// retryCounter counts the calls and attempts made during a validation; attempts beyond
+// the first of each call are retries
+type retryCounter struct {
+	calls    atomic.Int64
+	attempts atomic.Int64
+}
+
+type retryCounterKey struct{}
+
+// withRetryCounter attaches a counter to ctx for the middleware to update
+func withRetryCounter(ctx context.Context, counter *retryCounter) context.Context {
+	return context.WithValue(ctx, retryCounterKey{}, counter)
+}
+
+// retries returns how many attempts were retries
+func (c *retryCounter) retries() int {
+	return int(max(c.attempts.Load()-c.calls.Load(), 0))
+}
+
+// retryCountingOptions count every call once and every attempt the retryer makes
+func retryCountingOptions(stack *middleware.Stack) error {
+	counter := func(ctx context.Context) *retryCounter {
+		c, _ := ctx.Value(retryCounterKey{}).(*retryCounter)
+		return c
+	}
+	err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("KeyAwsExporterCountCalls",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			if c := counter(ctx); c != nil {
+				c.calls.Add(1)
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.Before)
+	if err != nil {
+		return err
+	}
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("KeyAwsExporterCountAttempts",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if c := counter(ctx); c != nil {
+				c.attempts.Add(1)
+			}
+			return next.HandleFinalize(ctx, in)
+		}), "Retry", middleware.After)
+}
@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newThrottlingServer answers the first failures requests with SlowDown
+func newThrottlingServer(failures int64) (*httptest.Server, *atomic.Int64) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`))
+			return
+		}
+		w.Write([]byte(`<ListBucketResult><Name>bucket</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+	}))
+	return server, &requests
+}
+
+func TestValidateKeysCountsRetries(t *testing.T) {
+	server, requests := newThrottlingServer(2)
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithRetry("standard", 3, 10*time.Millisecond))
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+	if !result.IsValid || result.Retries != 2 {
+		t.Fatalf("expected success after two retries, got valid=%v retries=%d", result.IsValid, result.Retries)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("expected three requests, got %d", got)
+	}
+}
+
+func TestWithRetryLimitsAttempts(t *testing.T) {
+	server, requests := newThrottlingServer(2)
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithRetry("adaptive", 1, 0))
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+	if result.IsValid || result.ErrorType != "throttled" || result.Retries != 0 {
+		t.Fatalf("expected a single throttled attempt, got valid=%v type=%s retries=%d", result.IsValid, result.ErrorType, result.Retries)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected one request, got %d", got)
+	}
+}
@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// CheckRoundTrip is the name of the deep probe's read-back integrity verdict
+const CheckRoundTrip = "roundtrip"
+
+// roundTripNonceKey is the user metadata every verified probe object carries, so a
+// gateway that drops or rewrites metadata is caught even without configured metadata
+const roundTripNonceKey = "key-aws-exporter-nonce"
+
+// RoundTrip selects what deep probes verify when they read their probe object back.
+// The body is always compared byte for byte.
+type RoundTrip struct {
+	// SSE is requested on upload and expected back: AES256 or aws:kms; empty skips it
+	SSE string
+	// KMSKeyID is the SSE-KMS key requested on upload; key IDs and ARNs are also
+	// expected back, aliases only on upload since S3 reports the key ARN
+	KMSKeyID string
+	// Metadata is written as user metadata and expected back unchanged
+	Metadata map[string]string
+}
+
+// WithRoundTripVerification makes deep probes verify that their probe object reads back
+// identical to what was written, reporting the verdict as the roundtrip check. It
+// catches gateways that accept writes but corrupt content, metadata or encryption.
+func WithRoundTripVerification(rt RoundTrip) Option {
+	return func(s *validatorSettings) {
+		s.roundTrip = &rt
+	}
+}
+
+// roundTripBody returns a probe body unique to nonce that covers every byte value, so
+// charset conversion or truncation by a gateway changes it
+func roundTripBody(nonce string) []byte {
+	body := []byte("key-aws-exporter probe " + nonce + "\n")
+	for b := range 256 {
+		body = append(body, byte(b))
+	}
+	return body
+}
+
+// prepare sets the encryption and metadata of a probe object upload
+func (rt *RoundTrip) prepare(input *s3.PutObjectInput, nonce string) {
+	input.Metadata = map[string]string{roundTripNonceKey: nonce}
+	for key, value := range rt.Metadata {
+		input.Metadata[key] = value
+	}
+	if rt.SSE != "" {
+		input.ServerSideEncryption = types.ServerSideEncryption(rt.SSE)
+	}
+	if rt.KMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(rt.KMSKeyID)
+	}
+}
+
+// verify compares a probe object read back with what was written, returning the
+// differences found
+func (rt *RoundTrip) verify(out *s3.GetObjectOutput, body, want []byte, nonce string) []string {
+	var mismatches []string
+	if !bytes.Equal(body, want) {
+		mismatches = append(mismatches, fmt.Sprintf("body differs (%d bytes written, %d read)", len(want), len(body)))
+	}
+
+	// S3 returns user metadata keys in lower case
+	got := make(map[string]string, len(out.Metadata))
+	for key, value := range out.Metadata {
+		got[strings.ToLower(key)] = value
+	}
+	expected := map[string]string{roundTripNonceKey: nonce}
+	for key, value := range rt.Metadata {
+		expected[strings.ToLower(key)] = value
+	}
+	for key, value := range expected {
+		if actual, ok := got[key]; !ok {
+			mismatches = append(mismatches, fmt.Sprintf("metadata %s missing", key))
+		} else if actual != value {
+			mismatches = append(mismatches, fmt.Sprintf("metadata %s is %s, wrote %s", key, strconv.Quote(actual), strconv.Quote(value)))
+		}
+	}
+
+	if rt.SSE != "" && string(out.ServerSideEncryption) != rt.SSE {
+		mismatches = append(mismatches, fmt.Sprintf("server-side encryption is %q, requested %q", out.ServerSideEncryption, rt.SSE))
+	}
+	if rt.KMSKeyID != "" && !strings.HasPrefix(rt.KMSKeyID, "alias/") && !strings.Contains(rt.KMSKeyID, ":alias/") {
+		if keyID := aws.ToString(out.SSEKMSKeyId); keyID != rt.KMSKeyID && !strings.HasSuffix(keyID, "/"+rt.KMSKeyID) {
+			mismatches = append(mismatches, fmt.Sprintf("KMS key is %q, requested %q", keyID, rt.KMSKeyID))
+		}
+	}
+	return mismatches
+}
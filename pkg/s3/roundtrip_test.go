@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// gatewayClient stores metadata and encryption with the objects, and lets a test rewrite
+// what a read returns like a gateway that mangles objects
+type gatewayClient struct {
+	mockS3Client
+	puts    map[string]*s3.PutObjectInput
+	corrupt func(out *s3.GetObjectOutput, body []byte) []byte
+}
+
+func (g *gatewayClient) PutObject(ctx context.Context, in *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if g.puts == nil {
+		g.puts = make(map[string]*s3.PutObjectInput)
+	}
+	g.puts[*in.Key] = in
+	return g.mockS3Client.PutObject(ctx, in, opts...)
+}
+
+func (g *gatewayClient) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := g.objects[*in.Key]
+	if !ok {
+		return nil, &mockAPIError{code: "NoSuchKey"}
+	}
+	put := g.puts[*in.Key]
+	out := &s3.GetObjectOutput{
+		Metadata:             make(map[string]string),
+		ServerSideEncryption: put.ServerSideEncryption,
+	}
+	for key, value := range put.Metadata {
+		out.Metadata[strings.ToLower(key)] = value
+	}
+	if put.SSEKMSKeyId != nil {
+		out.SSEKMSKeyId = aws.String("arn:aws:kms:us-east-1:123456789012:key/" + *put.SSEKMSKeyId)
+	}
+	data := []byte(body)
+	if g.corrupt != nil {
+		data = g.corrupt(out, data)
+	}
+	out.Body = io.NopCloser(bytes.NewReader(data))
+	return out, nil
+}
+
+func roundTripValidator(client s3ProbeClient, rt RoundTrip) *S3Validator {
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false, WithRoundTripVerification(rt))
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+	return validator
+}
+
+func TestRoundTripVerification(t *testing.T) {
+	rt := RoundTrip{SSE: "aws:kms", KMSKeyID: "1234abcd", Metadata: map[string]string{"Team": "storage"}}
+
+	client := &gatewayClient{}
+	result := roundTripValidator(client, rt).ValidateDeep(context.Background(), time.Second)
+	if !result.IsValid || len(result.Checks) != 1 {
+		t.Fatalf("expected a valid result with a roundtrip verdict, got %+v", result)
+	}
+	if check := result.Checks[0]; check.Name != CheckRoundTrip || !check.Passed {
+		t.Fatalf("expected the roundtrip check to pass, got %+v", check)
+	}
+	for _, put := range client.puts {
+		if put.ServerSideEncryption != "aws:kms" || aws.ToString(put.SSEKMSKeyId) != "1234abcd" || put.Metadata["Team"] != "storage" {
+			t.Fatalf("expected the upload to request encryption and metadata, got %+v", put)
+		}
+	}
+
+	corruptions := map[string]func(out *s3.GetObjectOutput, body []byte) []byte{
+		"body differs": func(out *s3.GetObjectOutput, body []byte) []byte {
+			return bytes.ToValidUTF8(body, []byte("?"))
+		},
+		"metadata team": func(out *s3.GetObjectOutput, body []byte) []byte {
+			delete(out.Metadata, "team")
+			return body
+		},
+		"metadata key-aws-exporter-nonce": func(out *s3.GetObjectOutput, body []byte) []byte {
+			out.Metadata[roundTripNonceKey] = "stale"
+			return body
+		},
+		"server-side encryption": func(out *s3.GetObjectOutput, body []byte) []byte {
+			out.ServerSideEncryption = "AES256"
+			return body
+		},
+		"KMS key": func(out *s3.GetObjectOutput, body []byte) []byte {
+			out.SSEKMSKeyId = aws.String("arn:aws:kms:us-east-1:123456789012:key/other")
+			return body
+		},
+	}
+	for want, corrupt := range corruptions {
+		t.Run(want, func(t *testing.T) {
+			client := &gatewayClient{corrupt: corrupt}
+			result := roundTripValidator(client, rt).ValidateDeep(context.Background(), time.Second)
+			if !result.IsValid {
+				t.Fatalf("expected a mangled read not to invalidate the keys, got %+v", result)
+			}
+			if check := result.Checks[0]; check.Passed || !strings.Contains(check.Message, want) {
+				t.Fatalf("expected a failed roundtrip check mentioning %q, got %+v", want, check)
+			}
+		})
+	}
+}
+
+func TestRoundTripSkippedWithoutReadBack(t *testing.T) {
+	client := &gatewayClient{}
+	validator := roundTripValidator(client, RoundTrip{})
+
+	result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{Operation: OperationPutObject})
+	if !result.IsValid || len(result.Checks) != 0 {
+		t.Fatalf("expected a write-only probe without a roundtrip verdict, got %+v", result)
+	}
+
+	validator = roundTripValidator(&gatewayClient{}, RoundTrip{KMSKeyID: "alias/backups", SSE: "aws:kms"})
+	if check := validator.ValidateDeep(context.Background(), time.Second).Checks[0]; !check.Passed {
+		t.Fatalf("expected an alias not to be compared with the key ARN read back, got %+v", check)
+	}
+}
@@ -0,0 +1,103 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// errorTypeCriterion is a shallow probe whose call succeeded but did not meet the
+// endpoint's success criterion, such as too few objects or a slow answer
+const errorTypeCriterion = "criterion_failed"
+
+// Criterion decides from a shallow probe's outcome whether it succeeded, e.g. a compiled
+// CEL expression
+type Criterion interface {
+	EvalBool(vars map[string]any) (bool, error)
+	String() string
+}
+
+// SuccessCriterion replaces "the call did not fail" as the definition of a successful
+// shallow probe. Expect reads is_valid (the call succeeded), error_type, error_code,
+// http_status, response_time_ms and object_count.
+type SuccessCriterion struct {
+	Expect  Criterion
+	Prefix  string // listed instead of the bucket root
+	MaxKeys int32  // objects listed, which bounds object_count; 0 lists one
+}
+
+// WithSuccessCriterion judges shallow probes by criterion instead of by whether the
+// call failed. A failure the criterion expects, such as AccessDenied for a key that must
+// not read the bucket, counts as valid; a call that succeeded but misses the criterion
+// fails with error type criterion_failed.
+func WithSuccessCriterion(criterion SuccessCriterion) Option {
+	return func(s *validatorSettings) {
+		s.success = &criterion
+	}
+}
+
+// countProbe lists up to the criterion's MaxKeys objects under its prefix and reports
+// how many it got
+func (v *S3Validator) countProbe(count *int) probeFunc {
+	return func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error {
+		input := &s3.ListObjectsV2Input{
+			Bucket:  aws.String(v.bucket),
+			MaxKeys: aws.Int32(max(v.success.MaxKeys, 1)),
+		}
+		if v.success.Prefix != "" {
+			input.Prefix = aws.String(v.success.Prefix)
+		}
+
+		return result.timeOperation(OperationListObjects, func() error {
+			out, err := client.ListObjectsV2(ctx, input)
+			if err == nil {
+				*count = len(out.Contents)
+			}
+			return err
+		})
+	}
+}
+
+// judge applies the success criterion to a shallow probe's result
+func (v *S3Validator) judge(result *ValidationResult, objectCount int) *ValidationResult {
+	if v.success == nil {
+		return result
+	}
+
+	vars := map[string]any{
+		"is_valid":         result.IsValid,
+		"error_type":       result.ErrorType,
+		"error_code":       "",
+		"http_status":      0,
+		"response_time_ms": result.ResponseTimeMs,
+		"object_count":     objectCount,
+	}
+	if result.Error != nil {
+		vars["error_code"] = result.Error.Code
+		vars["http_status"] = result.Error.HTTPStatus
+	}
+
+	expect := v.success.Expect
+	met, err := expect.EvalBool(vars)
+	switch {
+	case err != nil:
+		result.IsValid = false
+		result.Message = fmt.Sprintf("Failed to evaluate success criterion %q: %v", expect, err)
+		result.ErrorType = errorTypeConfig
+	case met && !result.IsValid:
+		result.IsValid = true
+		result.Message = fmt.Sprintf("Success criterion %q met: %s", expect, result.Message)
+		result.ErrorType = ""
+		result.Error = nil
+	case !met && result.IsValid:
+		result.IsValid = false
+		result.Message = fmt.Sprintf("Success criterion %q not met: the call succeeded in %dms with %d objects listed", expect, result.ResponseTimeMs, objectCount)
+		result.ErrorType = errorTypeCriterion
+	case !met:
+		// An unexpected failure keeps its own error type
+		result.Message = fmt.Sprintf("Success criterion %q not met: %s", expect, result.Message)
+	}
+	return result
+}
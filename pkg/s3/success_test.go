@@ -0,0 +1,110 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"key-aws-exporter/internal/expr"
+)
+
+var successEnv = expr.MustNewEnv(
+	expr.Var{Name: "is_valid", Type: expr.Bool},
+	expr.Var{Name: "error_type", Type: expr.String},
+	expr.Var{Name: "error_code", Type: expr.String},
+	expr.Var{Name: "http_status", Type: expr.Int},
+	expr.Var{Name: "response_time_ms", Type: expr.Int},
+	expr.Var{Name: "object_count", Type: expr.Int},
+)
+
+func successValidator(t *testing.T, client s3ProbeClient, expect, prefix string, maxKeys int32) *S3Validator {
+	t.Helper()
+	prog, err := successEnv.Compile(expect)
+	if err != nil {
+		t.Fatalf("compile %q: %v", expect, err)
+	}
+	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false,
+		WithSuccessCriterion(SuccessCriterion{Expect: prog, Prefix: prefix, MaxKeys: maxKeys}))
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		return client, nil
+	}
+	return validator
+}
+
+func TestSuccessCriterion(t *testing.T) {
+	backups := map[string]string{"backups/1": "", "backups/2": "", "backups/3": "", "logs/1": ""}
+	tests := []struct {
+		name      string
+		client    *mockS3Client
+		expect    string
+		valid     bool
+		errorType string
+		message   string
+	}{
+		{
+			name:    "enough objects",
+			client:  &mockS3Client{objects: backups},
+			expect:  "is_valid && object_count >= 3",
+			valid:   true,
+			message: "AWS credentials are valid",
+		},
+		{
+			name:      "too few objects",
+			client:    &mockS3Client{objects: backups},
+			expect:    "is_valid && object_count >= 10",
+			errorType: errorTypeCriterion,
+			message:   "with 3 objects listed",
+		},
+		{
+			name:    "expected access denied",
+			client:  &mockS3Client{err: &mockAPIError{code: "AccessDenied"}},
+			expect:  `error_code == "AccessDenied"`,
+			valid:   true,
+			message: `criterion "error_code == \"AccessDenied\"" met`,
+		},
+		{
+			name:      "unexpected access",
+			client:    &mockS3Client{objects: backups},
+			expect:    `error_code == "AccessDenied"`,
+			errorType: errorTypeCriterion,
+			message:   "not met",
+		},
+		{
+			name:      "other failure",
+			client:    &mockS3Client{err: &mockAPIError{code: "NoSuchBucket"}},
+			expect:    `error_code == "AccessDenied"`,
+			errorType: errorTypeNotFound,
+			message:   "not met: S3 validation failed",
+		},
+		{
+			name:      "evaluation error",
+			client:    &mockS3Client{objects: backups},
+			expect:    `1 / (object_count - 3) == 0`, // three objects: division by zero
+			errorType: errorTypeConfig,
+			message:   "Failed to evaluate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := successValidator(t, tt.client, tt.expect, "backups/", 1000).ValidateKeys(context.Background(), time.Second)
+			if result.IsValid != tt.valid || result.ErrorType != tt.errorType {
+				t.Fatalf("expected valid=%v error type %q, got %+v", tt.valid, tt.errorType, result)
+			}
+			if !strings.Contains(result.Message, tt.message) {
+				t.Fatalf("expected message to contain %q, got %q", tt.message, result.Message)
+			}
+			if tt.valid && result.Error != nil {
+				t.Fatalf("expected an expected failure to drop its error detail, got %+v", result.Error)
+			}
+		})
+	}
+}
+
+func TestSuccessCriterionLeavesOtherProbesAlone(t *testing.T) {
+	validator := successValidator(t, &mockS3Client{err: errors.New("boom")}, `error_type == "unknown"`, "", 0)
+	if result := validator.ValidateWith(context.Background(), time.Second, ProbeOptions{}); result.IsValid {
+		t.Fatalf("expected on-demand probes to ignore the success criterion, got %+v", result)
+	}
+}
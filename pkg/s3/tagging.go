@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"context"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// WithUserAgent prefixes the SDK User-Agent of every validation request so the
+// monitoring traffic is easy to spot in storage access logs
+func WithUserAgent(userAgent string) Option {
+	return func(s *validatorSettings) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithRequestHeaders adds static headers to every validation request
+func WithRequestHeaders(headers map[string]string) Option {
+	return func(s *validatorSettings) {
+		s.requestHeaders = headers
+	}
+}
+
+func (v *S3Validator) requestTaggingOptions() []func(*middleware.Stack) error {
+	var opts []func(*middleware.Stack) error
+	if v.userAgent != "" {
+		opts = append(opts, func(stack *middleware.Stack) error {
+			return stack.Build.Add(userAgentPrefix{value: v.userAgent}, middleware.After)
+		})
+	}
+	for name, value := range v.requestHeaders {
+		opts = append(opts, smithyhttp.SetHeaderValue(name, value))
+	}
+	return opts
+}
+
+// userAgentPrefix runs after the SDK's own User-Agent middleware and prepends the
+// configured value, keeping the SDK details for troubleshooting
+type userAgentPrefix struct {
+	value string
+}
+
+func (userAgentPrefix) ID() string {
+	return "KeyAwsExporterUserAgent"
+}
+
+func (m userAgentPrefix) HandleBuild(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (
+	middleware.BuildOutput, middleware.Metadata, error,
+) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok {
+		userAgent := m.value
+		if existing := req.Header.Get("User-Agent"); existing != "" {
+			userAgent += " " + existing
+		}
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return next.HandleBuild(ctx, in)
+}
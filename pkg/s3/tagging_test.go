@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestTaggingHeaders(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>bucket</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+	}))
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false,
+		WithUserAgent("key-aws-exporter/test endpoint/primary"),
+		WithRequestHeaders(map[string]string{"X-Monitoring-Source": "key-aws-exporter"}),
+	)
+
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+	if !result.IsValid {
+		t.Fatalf("expected validation against test server to succeed: %s", result.Message)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	userAgent := headers.Get("User-Agent")
+	if !strings.HasPrefix(userAgent, "key-aws-exporter/test endpoint/primary ") {
+		t.Fatalf("expected configured User-Agent prefix, got %q", userAgent)
+	}
+	if !strings.Contains(userAgent, "aws-sdk-go-v2") {
+		t.Fatalf("expected SDK User-Agent to be preserved, got %q", userAgent)
+	}
+	if got := headers.Get("X-Monitoring-Source"); got != "key-aws-exporter" {
+		t.Fatalf("expected custom header, got %q", got)
+	}
+}
+
+func TestWithRegionKeepsTagging(t *testing.T) {
+	validator := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false,
+		WithUserAgent("ua"),
+		WithRequestHeaders(map[string]string{"X-Test": "1"}),
+	)
+
+	clone := validator.withRegion("eu-west-1")
+
+	if clone.region != "eu-west-1" || clone.userAgent != "ua" || clone.requestHeaders["X-Test"] != "1" {
+		t.Fatalf("expected region clone to keep tagging settings, got %+v", clone.validatorSettings)
+	}
+}
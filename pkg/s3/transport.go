@@ -0,0 +1,269 @@
+package s3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// IPFamily selects the address family used to reach the endpoint
+type IPFamily string
+
+const (
+	// IPFamilyAuto lets the dialer pick (dual-stack, Happy Eyeballs)
+	IPFamilyAuto IPFamily = "auto"
+	// IPFamilyIPv4 only dials IPv4 addresses
+	IPFamilyIPv4 IPFamily = "ipv4"
+	// IPFamilyIPv6 only dials IPv6 addresses
+	IPFamilyIPv6 IPFamily = "ipv6"
+)
+
+// network maps the family to the dial network name
+func (f IPFamily) network() string {
+	switch f {
+	case IPFamilyIPv4:
+		return "tcp4"
+	case IPFamilyIPv6:
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// WithIPFamily restricts connections to IPv4 or IPv6; IPFamilyAuto keeps the default dialer
+func WithIPFamily(family IPFamily) Option {
+	return func(s *validatorSettings) {
+		s.ipFamily = family
+	}
+}
+
+// WithDNSServers resolves endpoint hostnames through the given DNS servers instead of the
+// system resolver. Servers without a port use 53.
+func WithDNSServers(servers []string) Option {
+	return func(s *validatorSettings) {
+		s.dnsServers = nil
+		for _, server := range servers {
+			if _, _, err := net.SplitHostPort(server); err != nil {
+				server = net.JoinHostPort(server, "53")
+			}
+			s.dnsServers = append(s.dnsServers, server)
+		}
+	}
+}
+
+// WithResolve pins hostnames to IP addresses, like curl --resolve. TLS and the Host
+// header keep using the hostname; only the dialed address changes.
+func WithResolve(hosts map[string]string) Option {
+	return func(s *validatorSettings) {
+		s.resolve = make(map[string]string, len(hosts))
+		for host, ip := range hosts {
+			s.resolve[strings.ToLower(host)] = ip
+		}
+	}
+}
+
+// SOCKS5Proxy routes validation traffic through a SOCKS5 proxy such as a bastion tunnel
+type SOCKS5Proxy struct {
+	Address  string // host:port of the proxy
+	Username string // optional username/password authentication
+	Password string
+}
+
+// WithSOCKS5Proxy sends every request through the proxy. Target hostnames are resolved
+// by the proxy, so dns_servers and resolve only apply to the proxy address itself.
+func WithSOCKS5Proxy(proxy SOCKS5Proxy) Option {
+	return func(s *validatorSettings) {
+		if proxy.Address == "" {
+			s.socks5Proxy = nil
+			return
+		}
+		s.socks5Proxy = &proxy
+	}
+}
+
+// WithClientCertificate presents a client certificate to endpoints requiring mutual TLS.
+// The PEM files are read on every handshake, so renewed certificates are picked up
+// without a restart.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(s *validatorSettings) {
+		s.clientCertFile, s.clientKeyFile = certFile, keyFile
+	}
+}
+
+// proxyURL builds the socks5:// URL understood by net/http
+func (p *SOCKS5Proxy) proxyURL() *url.URL {
+	u := &url.URL{Scheme: "socks5", Host: p.Address}
+	if p.Username != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+	return u
+}
+
+// WithHTTPClient sends requests through client instead of a client of the validator's
+// own, so short-lived validators share one connection pool rather than each leaving
+// idle connections behind. Endpoints with custom transport settings still build theirs.
+func WithHTTPClient(client aws.HTTPClient) Option {
+	return func(s *validatorSettings) {
+		s.sharedClient = client
+	}
+}
+
+// customDial reports whether the dialer needs anything beyond the SDK defaults
+func (v *S3Validator) customDial() bool {
+	return v.ipFamily.network() != "tcp" || len(v.dnsServers) > 0 || len(v.resolve) > 0
+}
+
+// httpClient returns a custom HTTP client when the endpoint needs non-default transport
+// settings, otherwise the shared client or nil to keep the SDK default client
+func (v *S3Validator) httpClient() aws.HTTPClient {
+	if !v.insecureSkipVerify && v.clientCertFile == "" && !v.customDial() && v.socks5Proxy == nil {
+		return v.sharedClient
+	}
+
+	client := awshttp.NewBuildableClient()
+	if v.insecureSkipVerify || v.clientCertFile != "" {
+		tlsConfig := v.tlsConfig()
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			tr.TLSClientConfig = tlsConfig
+		})
+	}
+	if v.socks5Proxy != nil {
+		proxy := http.ProxyURL(v.socks5Proxy.proxyURL())
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			tr.Proxy = proxy
+		})
+	}
+	if v.customDial() {
+		dial := v.dialContext(client.GetDialer())
+		client = client.WithTransportOptions(func(tr *http.Transport) {
+			tr.DialContext = dial
+		})
+	}
+	return client
+}
+
+// tlsConfig returns the TLS settings for endpoints that skip verification or require a
+// client certificate
+func (v *S3Validator) tlsConfig() *tls.Config {
+	cfg := &tls.Config{InsecureSkipVerify: v.insecureSkipVerify} //nolint:gosec // intentional for MinIO/self-signed setups
+	if v.clientCertFile != "" {
+		certFile, keyFile := v.clientCertFile, v.clientKeyFile
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+	return cfg
+}
+
+// dialContext applies the address family, static host mapping and DNS servers to dialer
+func (v *S3Validator) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	network := v.ipFamily.network()
+	if len(v.dnsServers) > 0 {
+		dialer.Resolver = newResolver(v.dnsServers)
+	}
+	resolve := v.resolve
+
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := resolve[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// newResolver queries the given servers in turn
+func newResolver(servers []string) *net.Resolver {
+	var next atomic.Uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[int(next.Add(1)-1)%len(servers)]
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// connTracker records the address family and remote address of the connections used
+// during a validation
+type connTracker struct {
+	mu     sync.Mutex
+	family IPFamily
+	remote netip.Addr
+}
+
+// trace attaches an httptrace hook to ctx that remembers the last connection's family
+// and remote address
+func (t *connTracker) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn == nil {
+				return
+			}
+			if family := addrFamily(info.Conn.RemoteAddr()); family != "" {
+				t.mu.Lock()
+				t.family = family
+				t.remote = remoteAddr(info.Conn.RemoteAddr())
+				t.mu.Unlock()
+			}
+		},
+	})
+}
+
+func (t *connTracker) lastFamily() IPFamily {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.family
+}
+
+func (t *connTracker) lastRemote() netip.Addr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remote
+}
+
+// remoteAddr returns the IP of a connection's remote address
+func remoteAddr(addr net.Addr) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addrPort.Addr().Unmap()
+}
+
+// addrFamily reports whether a remote address is IPv4 or IPv6
+func addrFamily(addr net.Addr) IPFamily {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return IPFamilyIPv4
+	default:
+		return IPFamilyIPv6
+	}
+}
@@ -0,0 +1,346 @@
+package s3
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+func newListBucketServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>bucket</Name><KeyCount>0</KeyCount></ListBucketResult>`))
+	}))
+}
+
+func TestValidateKeysReportsIPFamily(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	// The test server only listens on 127.0.0.1, so localhost must resolve to IPv4
+	endpoint := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	validator := NewS3Validator(endpoint, "us-east-1", "bucket", "ak", "sk", "", true, false, WithIPFamily(IPFamilyIPv4))
+
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected validation over IPv4 to succeed: %s", result.Message)
+	}
+	if result.IPFamily != IPFamilyIPv4 {
+		t.Fatalf("expected IPv4 connection, got %q", result.IPFamily)
+	}
+}
+
+func TestValidateKeysIPv6OnlyCannotReachIPv4Server(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false, WithIPFamily(IPFamilyIPv6))
+
+	result := validator.ValidateKeys(context.Background(), 2*time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected IPv6-only dialing of an IPv4 address to fail")
+	}
+}
+
+func TestHTTPClientOnlyWhenNeeded(t *testing.T) {
+	plain := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false)
+	if plain.httpClient() != nil {
+		t.Fatalf("expected SDK default client without transport settings")
+	}
+
+	auto := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithIPFamily(IPFamilyAuto))
+	if auto.httpClient() != nil {
+		t.Fatalf("expected SDK default client for the auto family")
+	}
+
+	ipv6 := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithIPFamily(IPFamilyIPv6))
+	if ipv6.httpClient() == nil {
+		t.Fatalf("expected a custom client for an IPv6-only endpoint")
+	}
+
+	shared := awshttp.NewBuildableClient()
+	if NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithHTTPClient(shared)).httpClient() != shared {
+		t.Fatalf("expected the shared client without transport settings")
+	}
+	if custom := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false, WithHTTPClient(shared), WithIPFamily(IPFamilyIPv6)).httpClient(); custom == nil || custom == shared {
+		t.Fatalf("expected a custom client for an IPv6-only endpoint despite the shared client")
+	}
+}
+
+func TestAddrFamily(t *testing.T) {
+	tests := map[string]IPFamily{
+		"10.0.0.1:443":       IPFamilyIPv4,
+		"[2001:db8::1]:9000": IPFamilyIPv6,
+		"not-an-address":     "",
+	}
+	for addr, want := range tests {
+		if got := addrFamily(stringAddr(addr)); got != want {
+			t.Fatalf("addrFamily(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+type stringAddr string
+
+func (a stringAddr) Network() string { return "tcp" }
+func (a stringAddr) String() string  { return string(a) }
+
+func TestValidateKeysWithStaticResolve(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+
+	// s3.migration.invalid is not in any DNS; the static mapping must be used
+	endpoint := strings.Replace(server.URL, "127.0.0.1", "s3.migration.invalid", 1)
+	validator := NewS3Validator(endpoint, "us-east-1", "bucket", "ak", "sk", "", true, false,
+		WithResolve(map[string]string{"S3.Migration.Invalid": "127.0.0.1"}))
+
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected validation through the static mapping to succeed: %s", result.Message)
+	}
+}
+
+func TestValidateKeysQueriesConfiguredDNSServer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake DNS server: %v", err)
+	}
+	defer conn.Close()
+
+	queried := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 512)
+		if _, _, err := conn.ReadFrom(buf); err == nil {
+			queried <- struct{}{}
+		}
+	}()
+
+	validator := NewS3Validator("http://s3.gateway.invalid:9000", "us-east-1", "bucket", "ak", "sk", "", true, false,
+		WithDNSServers([]string{conn.LocalAddr().String()}))
+
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected validation to fail without a DNS answer")
+	}
+	select {
+	case <-queried:
+	default:
+		t.Fatalf("expected the configured DNS server to be queried")
+	}
+}
+
+func TestWithDNSServersDefaultsPort(t *testing.T) {
+	validator := NewS3Validator("", "us-east-1", "bucket", "ak", "sk", "", false, false,
+		WithDNSServers([]string{"10.0.0.2", "10.0.0.3:5353", "2001:db8::53"}))
+
+	want := []string{"10.0.0.2:53", "10.0.0.3:5353", "[2001:db8::53]:53"}
+	for i, server := range validator.dnsServers {
+		if server != want[i] {
+			t.Fatalf("dnsServers[%d] = %q, want %q", i, server, want[i])
+		}
+	}
+}
+
+// startSOCKS5Server runs a minimal SOCKS5 proxy (RFC 1928/1929) that requires the given
+// credentials and records the requested target addresses
+func startSOCKS5Server(t *testing.T, username, password string) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SOCKS5 server: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	targets := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, username, password, targets)
+		}
+	}()
+	return listener.Addr().String(), targets
+}
+
+func serveSOCKS5(conn net.Conn, username, password string, targets chan<- string) {
+	defer conn.Close()
+	read := func(n int) []byte {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil
+		}
+		return buf
+	}
+
+	// Greeting: version, method count, methods; reply with username/password auth
+	header := read(2)
+	if header == nil || read(int(header[1])) == nil {
+		return
+	}
+	_, _ = conn.Write([]byte{0x05, 0x02})
+
+	// Username/password sub-negotiation
+	authHeader := read(2)
+	if authHeader == nil {
+		return
+	}
+	user := string(read(int(authHeader[1])))
+	passLen := read(1)
+	if passLen == nil {
+		return
+	}
+	pass := string(read(int(passLen[0])))
+	if user != username || pass != password {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	_, _ = conn.Write([]byte{0x01, 0x00})
+
+	// CONNECT request with a domain or IPv4 target
+	request := read(4)
+	if request == nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 0x01:
+		host = net.IP(read(4)).String()
+	case 0x03:
+		length := read(1)
+		host = string(read(int(length[0])))
+	default:
+		return
+	}
+	portBytes := read(2)
+	target := net.JoinHostPort(host, strconv.Itoa(int(portBytes[0])<<8|int(portBytes[1])))
+	targets <- target
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
+
+func TestValidateKeysThroughSOCKS5Proxy(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+	proxyAddr, targets := startSOCKS5Server(t, "monitor", "secret")
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false,
+		WithSOCKS5Proxy(SOCKS5Proxy{Address: proxyAddr, Username: "monitor", Password: "secret"}))
+
+	result := validator.ValidateKeys(context.Background(), 5*time.Second)
+
+	if !result.IsValid {
+		t.Fatalf("expected validation through the proxy to succeed: %s", result.Message)
+	}
+	select {
+	case target := <-targets:
+		if target != strings.TrimPrefix(server.URL, "http://") {
+			t.Fatalf("expected proxy to connect to the test server, got %s", target)
+		}
+	default:
+		t.Fatalf("expected the request to go through the proxy")
+	}
+}
+
+func TestValidateKeysSOCKS5AuthFailure(t *testing.T) {
+	server := newListBucketServer()
+	defer server.Close()
+	proxyAddr, _ := startSOCKS5Server(t, "monitor", "secret")
+
+	validator := NewS3Validator(server.URL, "us-east-1", "bucket", "ak", "sk", "", true, false,
+		WithSOCKS5Proxy(SOCKS5Proxy{Address: proxyAddr, Username: "monitor", Password: "wrong"}))
+
+	result := validator.ValidateKeys(context.Background(), 2*time.Second)
+
+	if result.IsValid {
+		t.Fatalf("expected validation to fail when the proxy rejects the credentials")
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key as PEM
+// files and returns their paths and the certificate
+func writeClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "key-aws-exporter"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile, cert
+}
+
+func TestValidateKeysPresentsClientCertificate(t *testing.T) {
+	certFile, keyFile, cert := writeClientCertificate(t)
+	listing := newListBucketServer()
+	listing.Close()
+	server := httptest.NewUnstartedServer(listing.Config.Handler)
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	withCert := NewS3Validator(server.URL, "us-east-1", "bucket", "AK", "SK", "", true, true, WithClientCertificate(certFile, keyFile))
+	if result := withCert.ValidateKeys(context.Background(), 5*time.Second); !result.IsValid {
+		t.Fatalf("expected the gateway to accept the client certificate, got %+v", result)
+	}
+
+	without := NewS3Validator(server.URL, "us-east-1", "bucket", "AK", "SK", "", true, true)
+	if result := without.ValidateKeys(context.Background(), 500*time.Millisecond); result.IsValid {
+		t.Fatalf("expected the gateway to reject connections without a client certificate")
+	}
+}
@@ -2,19 +2,22 @@ package s3
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
 	"time"
 
+	"key-aws-exporter/pkg/clock"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	smithy "github.com/aws/smithy-go"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
@@ -27,8 +30,12 @@ const (
 	errorTypeNetwork   = "network"
 	errorTypeForbidden = "access_denied"
 	errorTypeNotFound  = "bucket_not_found"
+	errorTypeThrottled = "throttled"
 )
 
+// OperationListObjects is the operation label used for the ListObjectsV2 credential check
+const OperationListObjects = "ListObjectsV2"
+
 type ValidationResult struct {
 	IsValid        bool
 	Message        string
@@ -36,9 +43,35 @@ type ValidationResult struct {
 	ResponseTimeMs int64
 	ErrorType      string
 	Duration       time.Duration
+	Operations     []OperationTiming
+	Depth          ProbeDepth
+	Region         string
+	Checks         []CheckResult
+	ClockSkew      time.Duration     // server clock minus local clock, set for clock_skew failures
+	IPFamily       IPFamily          // address family of the last connection used, when known
+	RemoteIP       string            // address of the last connection used, when known
+	Retries        int               // attempts the SDK retried across the probe's calls
+	Error          *ErrorDetail      // cause of the failure, when it came from an error chain
+	Secondary      *ValidationResult // outcome for the endpoint's secondary credentials, when configured
+	// ViaPrivateEndpoint reports whether RemoteIP is in a private range, i.e. traffic went
+	// through an interface VPC endpoint rather than the public internet; nil when unknown
+	ViaPrivateEndpoint *bool
+	// Severity is set by a result rule to override the endpoint's severity
+	Severity string
+	// SuppressedBy names the result rule that kept this failure from metrics and
+	// notifications
+	SuppressedBy string
 }
 
-type S3Validator struct {
+// OperationTiming captures the latency of a single S3 call made during validation
+type OperationTiming struct {
+	Operation string
+	Duration  time.Duration
+}
+
+// validatorSettings holds the connection settings of a validator. It is kept separate
+// from the client state so region clones can copy it by value.
+type validatorSettings struct {
 	endpoint           string
 	region             string
 	bucket             string
@@ -46,21 +79,72 @@ type S3Validator struct {
 	secretKey          string
 	sessionToken       string
 	usePathStyle       bool
+	accelerate         bool
+	dualStack          bool
+	fips               bool
+	useARNRegion       bool
+	checksumAlgorithm  types.ChecksumAlgorithm // empty leaves write probes to the SDK default
+	roundTrip          *RoundTrip              // nil skips verifying what deep probes read back
+	success            *SuccessCriterion       // nil counts every shallow probe whose call succeeded
+	replicaID          string                  // names this process in canary keys; empty uses the host name
+	canaryTTL          time.Duration           // age after which canaries count as leaked
 	insecureSkipVerify bool
+	userAgent          string
+	requestHeaders     map[string]string
+	checks             []*scheduledCheck
+	checkInterval      time.Duration
+	checkTimeout       time.Duration
+	ipFamily           IPFamily
+	dnsServers         []string
+	resolve            map[string]string
+	socks5Proxy        *SOCKS5Proxy
+	sharedClient       aws.HTTPClient // used instead of the SDK default client; nil builds one per validator
+	clientCertFile     string         // PEM client certificate and key for mutual TLS
+	clientKeyFile      string
+	privateCIDRs       []netip.Prefix // ranges of private endpoints; nil uses the RFC 1918 and ULA ranges
+	retryMode          aws.RetryMode  // empty keeps the SDK's retry settings
+	maxAttempts        int
+	maxBackoff         time.Duration
+	iamEndpoint        string
+	roleARN            string // assumed with the access key when set
+	externalID         string
+	stsEndpoint        string
+	readOnly           bool
+	clock              clock.Clock
+	clientPool         *ClientPool // nil gives every validator its own configuration
+}
 
-	client   s3ListObjectsClient
-	clientMu sync.Mutex
+type S3Validator struct {
+	validatorSettings
+
+	client      s3ProbeClient
+	clientBuilt time.Time // creation time of the pooled configuration client was built from
+	clientMu    sync.Mutex
+	canaries    canaryNamer
 
-	newClient func(ctx context.Context) (s3ListObjectsClient, error)
+	newClient func(ctx context.Context) (s3ProbeClient, error)
 }
 
-type s3ListObjectsClient interface {
+type s3ProbeClient interface {
 	ListObjectsV2(context.Context, *s3.ListObjectsV2Input, ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Option customizes optional validator settings
+type Option func(*validatorSettings)
+
+// WithClock sets the clock used for result timestamps and check intervals
+func WithClock(c clock.Clock) Option {
+	return func(s *validatorSettings) {
+		s.clock = c
+	}
 }
 
 // NewS3Validator creates a new S3 validator instance
-func NewS3Validator(endpoint, region, bucket, accessKey, secretKey, sessionToken string, usePathStyle, insecureSkipVerify bool) *S3Validator {
-	v := &S3Validator{
+func NewS3Validator(endpoint, region, bucket, accessKey, secretKey, sessionToken string, usePathStyle, insecureSkipVerify bool, opts ...Option) *S3Validator {
+	settings := validatorSettings{
 		endpoint:           endpoint,
 		region:             region,
 		bucket:             bucket,
@@ -70,109 +154,220 @@ func NewS3Validator(endpoint, region, bucket, accessKey, secretKey, sessionToken
 		usePathStyle:       usePathStyle,
 		insecureSkipVerify: insecureSkipVerify,
 	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	settings.clock = clock.OrReal(settings.clock)
+	v := newValidator(settings)
+	for _, sc := range settings.checks {
+		if cc, ok := sc.check.(canaryCheck); ok {
+			cc.useCanaryNames(v.canaries)
+		}
+		if hc, ok := sc.check.(httpCheck); ok {
+			hc.useHTTP(v)
+		}
+		if cc, ok := sc.check.(clockedCheck); ok {
+			cc.useClock(v.clock)
+		}
+	}
+	return v
+}
+
+func newValidator(settings validatorSettings) *S3Validator {
+	v := &S3Validator{validatorSettings: settings, canaries: newCanaryNamer(settings)}
 	v.newClient = v.defaultClientBuilder
 	return v
 }
 
+// withRegion returns a validator with the same settings targeting another region
+func (v *S3Validator) withRegion(region string) *S3Validator {
+	settings := v.validatorSettings
+	settings.region = region
+	return newValidator(settings)
+}
+
 // ValidateKeys checks if the provided AWS credentials are valid by attempting
 // to list objects in the S3 bucket
 func (v *S3Validator) ValidateKeys(ctx context.Context, timeout time.Duration) *ValidationResult {
+	if IsObjectLambdaARN(v.bucket) {
+		// Listing bypasses the transform; reading an object runs it
+		return v.judge(v.validate(ctx, timeout, ProbeDepthShallow, v.readProbe("")), 0)
+	}
+	if v.success != nil {
+		var count int
+		return v.judge(v.validate(ctx, timeout, ProbeDepthShallow, v.countProbe(&count)), count)
+	}
+	return v.validate(ctx, timeout, ProbeDepthShallow, v.listProbe(""))
+}
+
+// validate wraps a credential probe with client setup, timing and error classification,
+// then runs any bucket checks that are due
+func (v *S3Validator) validate(ctx context.Context, timeout time.Duration, depth ProbeDepth, probe probeFunc) *ValidationResult {
 	result := &ValidationResult{
-		CheckedAt: time.Now(),
+		CheckedAt: v.clock.Now(),
+		Depth:     depth,
+		Region:    v.region,
 	}
 
-	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start)
+	start := v.clock.Now()
+	var conns connTracker
+	var retries retryCounter
+	finish := func() {
+		elapsed := v.clock.Since(start)
 		result.Duration = elapsed
 		result.ResponseTimeMs = elapsed.Milliseconds()
-	}()
+		result.IPFamily = conns.lastFamily()
+		result.Retries = retries.retries()
+		if remote := conns.lastRemote(); remote.IsValid() {
+			result.RemoteIP = remote.String()
+			private := v.isPrivate(remote)
+			result.ViaPrivateEndpoint = &private
+		}
+	}
 
-	// Create context with timeout
+	// Checks get their own timeout, so they run under the caller's context
+	checkCtx := ctx
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
+	ctx = withRetryCounter(conns.trace(ctx), &retries)
 
 	client, err := v.getClient(ctx)
 	if err != nil {
 		result.IsValid = false
 		result.Message = fmt.Sprintf("Failed to create AWS client: %v", err)
 		result.ErrorType = errorTypeConfig
+		result.Error = newErrorDetail(err, errorTypeConfig)
+		finish()
 		return result
 	}
 
-	// Try to list objects (minimal operation to validate credentials)
-	input := &s3.ListObjectsV2Input{
-		Bucket:  aws.String(v.bucket),
-		MaxKeys: aws.Int32(1), // Only fetch 1 object to minimize latency
-	}
-
-	_, err = client.ListObjectsV2(ctx, input)
-	if err != nil {
+	if err := probe(ctx, client, result); err != nil {
 		result.IsValid = false
 		result.Message = fmt.Sprintf("S3 validation failed: %v", err)
 		result.ErrorType = classifyValidationError(err)
+		result.Error = newErrorDetail(err, result.ErrorType)
+		if result.ErrorType == errorTypeClockSkew {
+			if skew, ok := serverClockSkew(err, v.clock.Now()); ok {
+				result.ClockSkew = skew
+				result.Message = fmt.Sprintf("%s (%s)", result.Message, describeClockSkew(skew))
+			}
+		}
+		finish()
 		return result
 	}
 
 	result.IsValid = true
 	result.Message = "AWS credentials are valid"
 	result.ErrorType = ""
+	finish()
+
+	// Bucket checks only make sense once the credentials work; their time is tracked per check
+	result.Checks = append(result.Checks, v.runChecks(checkCtx, client)...)
 	return result
 }
 
+// listProbe lists at most one object under prefix, the minimal operation that proves
+// the credentials work
+func (v *S3Validator) listProbe(prefix string) probeFunc {
+	return func(ctx context.Context, client s3ProbeClient, result *ValidationResult) error {
+		input := &s3.ListObjectsV2Input{
+			Bucket:  aws.String(v.bucket),
+			MaxKeys: aws.Int32(1), // Only fetch 1 object to minimize latency
+		}
+		if prefix != "" {
+			input.Prefix = aws.String(prefix)
+		}
+
+		return result.timeOperation(OperationListObjects, func() error {
+			_, err := client.ListObjectsV2(ctx, input)
+			return err
+		})
+	}
+}
+
+// timeOperation runs fn and appends its latency to the result under the given operation name
+func (r *ValidationResult) timeOperation(operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	r.Operations = append(r.Operations, OperationTiming{
+		Operation: operation,
+		Duration:  time.Since(start),
+	})
+	return err
+}
+
 // HealthCheck performs a lightweight health check to S3
 func (v *S3Validator) HealthCheck(ctx context.Context, timeout time.Duration) bool {
 	result := v.ValidateKeys(ctx, timeout)
 	return result.IsValid
 }
 
-func (v *S3Validator) defaultClientBuilder(ctx context.Context) (s3ListObjectsClient, error) {
-	loadOptions := []func(*config.LoadOptions) error{
-		config.WithRegion(v.region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			v.accessKey,
-			v.secretKey,
-			v.sessionToken,
-		)),
-	}
-
-	var insecureTransport *http.Client
-	if v.insecureSkipVerify {
-		insecureTransport = &http.Client{
-			Transport: &http.Transport{
-				Proxy:           http.ProxyFromEnvironment,
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // intentional for MinIO/self-signed setups
-			},
-		}
-		loadOptions = append(loadOptions, config.WithHTTPClient(insecureTransport))
+func (v *S3Validator) defaultClientBuilder(ctx context.Context) (s3ProbeClient, error) {
+	var cfg aws.Config
+	var err error
+	if v.clientPool != nil {
+		// Called from getClient with clientMu held
+		cfg, v.clientBuilt, err = v.clientPool.config(ctx, v.clientKey(), v.loadConfig)
+	} else {
+		cfg, err = v.loadConfig(ctx)
 	}
-
-	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Apply custom endpoint if provided
-	if v.endpoint != "" {
-		cfg.BaseEndpoint = aws.String(v.endpoint)
-	}
-
 	return s3.NewFromConfig(cfg, func(o *s3.Options) {
 		o.UsePathStyle = v.usePathStyle
 		if v.endpoint != "" {
 			o.BaseEndpoint = aws.String(v.endpoint)
 		}
-		if v.insecureSkipVerify && insecureTransport != nil {
-			o.HTTPClient = insecureTransport
+		v.applyEndpointVariants(o)
+		o.UseARNRegion = v.useARNRegion
+		o.APIOptions = append(o.APIOptions, v.requestTaggingOptions()...)
+		o.APIOptions = append(o.APIOptions, retryCountingOptions)
+		if retryer := v.retryer(); retryer != nil {
+			o.Retryer = retryer
 		}
 	}), nil
 }
 
-func (v *S3Validator) getClient(ctx context.Context) (s3ListObjectsClient, error) {
+// loadConfig builds the SDK configuration: credentials, region, endpoint and the HTTP
+// client carrying the transport settings
+func (v *S3Validator) loadConfig(ctx context.Context) (aws.Config, error) {
+	var provider aws.CredentialsProvider = credentials.NewStaticCredentialsProvider(
+		v.accessKey,
+		v.secretKey,
+		v.sessionToken,
+	)
+	if v.roleARN != "" {
+		provider = v.newAssumeRoleProvider()
+	}
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(v.region),
+		config.WithCredentialsProvider(provider),
+	}
+
+	httpClient := v.httpClient()
+	if httpClient != nil {
+		loadOptions = append(loadOptions, config.WithHTTPClient(httpClient))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	// Apply custom endpoint if provided
+	if v.endpoint != "" {
+		cfg.BaseEndpoint = aws.String(v.endpoint)
+	}
+	return cfg, nil
+}
+
+func (v *S3Validator) getClient(ctx context.Context) (s3ProbeClient, error) {
 	v.clientMu.Lock()
 	defer v.clientMu.Unlock()
 
-	if v.client != nil {
+	if v.client != nil && (v.clientPool == nil || v.clientPool.current(v.clientKey(), v.clientBuilt)) {
 		return v.client, nil
 	}
 
@@ -185,6 +380,21 @@ func (v *S3Validator) getClient(ctx context.Context) (s3ListObjectsClient, error
 	return client, nil
 }
 
+// Refresh drops the cached client and the schedule of bucket checks, so the next
+// validation resolves credentials again, opens new connections and reruns every check
+func (v *S3Validator) Refresh() {
+	v.clientMu.Lock()
+	v.client = nil
+	v.clientMu.Unlock()
+	if v.clientPool != nil {
+		v.clientPool.forget(v.clientKey())
+	}
+
+	for _, sc := range v.checks {
+		sc.reset()
+	}
+}
+
 func classifyValidationError(err error) string {
 	if err == nil {
 		return ""
@@ -208,18 +418,13 @@ func classifyValidationError(err error) string {
 
 	var apiErr smithy.APIError
 	if errors.As(err, &apiErr) {
-		code := strings.ToLower(apiErr.ErrorCode())
-		switch code {
-		case "accessdenied", "invalidaccesskeyid", "signaturedoesnotmatch":
-			return errorTypeForbidden
-		case "nosuchbucket", "nosuchbucketpolicy":
-			return errorTypeNotFound
-		case "expiredtoken":
-			return "token_expired"
-		case "slowdown", "throttling", "throttlingexception":
-			return "throttled"
-		case "requesttimeout":
-			return errorTypeTimeout
+		if errorType := classifyErrorCode(apiErr.ErrorCode()); errorType != "" {
+			return errorType
+		}
+	}
+	if session, ok := sessionError(err); ok {
+		if errorType := classifyErrorCode(session.code); errorType != "" {
+			return errorType
 		}
 	}
 
@@ -237,3 +442,25 @@ func classifyValidationError(err error) string {
 
 	return errorTypeUnknown
 }
+
+// classifyErrorCode maps AWS error codes to error types, or "" for codes without one
+func classifyErrorCode(code string) string {
+	if errorType := classifyLambdaErrorCode(code); errorType != "" {
+		return errorType
+	}
+	switch strings.ToLower(code) {
+	case "accessdenied", "invalidaccesskeyid", "signaturedoesnotmatch":
+		return errorTypeForbidden
+	case "nosuchbucket", "nosuchbucketpolicy":
+		return errorTypeNotFound
+	case "expiredtoken":
+		return "token_expired"
+	case "slowdown", "throttling", "throttlingexception":
+		return errorTypeThrottled
+	case "requesttimeout":
+		return errorTypeTimeout
+	case "requesttimetooskewed":
+		return errorTypeClockSkew
+	}
+	return ""
+}
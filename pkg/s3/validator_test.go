@@ -3,25 +3,83 @@ package s3
 import (
 	"context"
 	"errors"
+	"io"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	smithy "github.com/aws/smithy-go"
 )
 
 type mockS3Client struct {
 	err    error
 	called bool
+
+	putErr    error
+	getErr    error
+	deleteErr error
+	objects   map[string]string
+	deleted   []string
 }
 
-func (m *mockS3Client) ListObjectsV2(_ context.Context, _ *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+func (m *mockS3Client) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
 	m.called = true
 	if m.err != nil {
 		return nil, m.err
 	}
-	return &s3.ListObjectsV2Output{}, nil
+
+	var keys []string
+	for key := range m.objects {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) && key > aws.ToString(in.StartAfter) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	for _, key := range keys {
+		out.Contents = append(out.Contents, types.Object{Key: aws.String(key)})
+	}
+	return out, nil
+}
+
+func (m *mockS3Client) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.putErr != nil {
+		return nil, m.putErr
+	}
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	if m.objects == nil {
+		m.objects = make(map[string]string)
+	}
+	m.objects[*in.Key] = string(body)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Client) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	body, ok := m.objects[*in.Key]
+	if !ok {
+		return nil, &mockAPIError{code: "NoSuchKey"}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func (m *mockS3Client) DeleteObject(_ context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.deleted = append(m.deleted, *in.Key)
+	if m.deleteErr != nil {
+		return nil, m.deleteErr
+	}
+	delete(m.objects, *in.Key)
+	return &s3.DeleteObjectOutput{}, nil
 }
 
 type mockNetError struct {
@@ -36,7 +94,7 @@ func (m *mockNetError) Temporary() bool { return false }
 func TestValidateKeysSuccess(t *testing.T) {
 	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
 	mockClient := &mockS3Client{}
-	validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 		return mockClient, nil
 	}
 
@@ -57,12 +115,15 @@ func TestValidateKeysSuccess(t *testing.T) {
 	if result.ErrorType != "" {
 		t.Fatalf("expected empty error type on success, got %s", result.ErrorType)
 	}
+	if len(result.Operations) != 1 || result.Operations[0].Operation != OperationListObjects {
+		t.Fatalf("expected a single %s operation timing, got %+v", OperationListObjects, result.Operations)
+	}
 }
 
 func TestValidateKeysListError(t *testing.T) {
 	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
 	mockClient := &mockS3Client{err: errors.New("boom")}
-	validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 		return mockClient, nil
 	}
 
@@ -81,7 +142,7 @@ func TestValidateKeysListError(t *testing.T) {
 
 func TestValidateKeysConfigError(t *testing.T) {
 	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
-	validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 		return nil, errors.New("config failed")
 	}
 
@@ -96,6 +157,9 @@ func TestValidateKeysConfigError(t *testing.T) {
 	if result.ErrorType != errorTypeConfig {
 		t.Fatalf("expected config error type, got %s", result.ErrorType)
 	}
+	if len(result.Operations) != 0 {
+		t.Fatalf("expected no operation timings when client creation fails, got %+v", result.Operations)
+	}
 }
 
 func TestHealthCheck(t *testing.T) {
@@ -123,7 +187,7 @@ func TestHealthCheck(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
 			mockClient := &mockS3Client{err: tt.mockErr}
-			validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+			validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 				return mockClient, nil
 			}
 
@@ -141,7 +205,7 @@ func TestClientCaching(t *testing.T) {
 	mockClient := &mockS3Client{}
 	callCount := 0
 
-	validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 		callCount++
 		return mockClient, nil
 	}
@@ -163,6 +227,27 @@ func TestClientCaching(t *testing.T) {
 	}
 }
 
+func TestRefreshRebuildsClientAndRerunsChecks(t *testing.T) {
+	check := &stubCheck{passed: true, done: true}
+	validator := newCheckedValidator(&mockS3Client{}, check, WithCheckInterval(time.Hour))
+	builds := 0
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
+		builds++
+		return &mockS3Client{}, nil
+	}
+
+	validator.ValidateKeys(context.Background(), time.Second)
+	validator.Refresh()
+	result := validator.ValidateKeys(context.Background(), time.Second)
+
+	if builds != 2 {
+		t.Fatalf("expected the client to be rebuilt after a refresh, got %d builds", builds)
+	}
+	if check.runs != 2 || len(result.Checks) != 1 {
+		t.Fatalf("expected the check to rerun within its interval after a refresh, ran %d times", check.runs)
+	}
+}
+
 func TestNewS3Validator(t *testing.T) {
 	validator := NewS3Validator("https://s3.amazonaws.com", "us-east-1", "test-bucket", "access-key", "secret-key", "session-token", true, true)
 
@@ -196,7 +281,7 @@ func TestContextTimeout(t *testing.T) {
 	validator := NewS3Validator("endpoint", "region", "bucket", "ak", "sk", "", false, false)
 
 	// Simulate slow client that doesn't return until after timeout
-	validator.newClient = func(ctx context.Context) (s3ListObjectsClient, error) {
+	validator.newClient = func(ctx context.Context) (s3ProbeClient, error) {
 		time.Sleep(100 * time.Millisecond)
 		return &mockS3Client{}, nil
 	}